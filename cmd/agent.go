@@ -1,23 +1,35 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/Masterminds/semver"
 	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/cli/internal/auth"
+	"github.com/agentuity/cli/internal/bundler"
+	"github.com/agentuity/cli/internal/deployer"
 	"github.com/agentuity/cli/internal/dev"
+	"github.com/agentuity/cli/internal/envutil"
 	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/loadtest"
+	"github.com/agentuity/cli/internal/openapi"
 	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/sbom"
 	"github.com/agentuity/cli/internal/templates"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/env"
@@ -26,6 +38,7 @@ import (
 	"github.com/agentuity/go-common/slice"
 	"github.com/agentuity/go-common/tui"
 	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/iancoleman/strcase"
 	"github.com/spf13/cobra"
 )
 
@@ -40,12 +53,28 @@ var agentCmd = &cobra.Command{
 }
 
 var agentDeleteCmd = &cobra.Command{
-	Use:     "delete [id]",
-	Short:   "Delete one or more Agents",
+	Use:   "delete [id]",
+	Short: "Delete one or more Agents",
+	Long: `Delete one or more Agents from Agentuity Cloud and the project.
+
+The source files of the deleted Agents can be removed too. They are backed up to
+.agentuity/backup first and can be restored with agentuity agent restore. The
+number and total size of the backups kept are set in the backup section of
+agentuity.yaml (keep and max_size, 10 backups and 100Mi by default).
+
+Arguments:
+  [id]       The ID of the Agent to delete, prompts for the Agents if not provided
+
+Flags:
+  --force    Don't prompt for confirmation and remove the source files
+
+Examples:
+  agentuity agent delete
+  agentuity agent delete agent_123 --force`,
 	Args:    cobra.MaximumNArgs(1),
 	Aliases: []string{"rm", "del"},
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 		theproject := project.EnsureProject(ctx, cmd)
@@ -56,6 +85,8 @@ var agentDeleteCmd = &cobra.Command{
 			logger.Fatal("No TTY detected, please specify an Agent id from the command line")
 		}
 
+		ensurePermission(ctx, logger, apiUrl, theproject.Token, theproject.Project.ProjectId, auth.PermissionAgentDelete, "delete agents")
+
 		keys, state := reconcileAgentList(logger, cmd, apiUrl, theproject.Token, theproject)
 		var selected []string
 
@@ -86,84 +117,214 @@ var agentDeleteCmd = &cobra.Command{
 			}
 		}
 
-		var deleted []string
-		var maybedelete []string
+		force, _ := cmd.Flags().GetBool("force")
 
-		action := func() {
-			var err error
-			deleted, err = agent.DeleteAgents(context.Background(), logger, apiUrl, theproject.Token, theproject.Project.ProjectId, selected)
-			if err != nil {
-				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to delete agents")).ShowErrorAndExit()
-			}
-			for _, key := range keys {
-				agent := state[key]
-				if slices.Contains(deleted, agent.Agent.ID) && util.Exists(agent.Filename) {
-					maybedelete = append(maybedelete, agent.Filename)
+		if !force && !tui.HasTTY {
+			logger.Fatal("No TTY detected, please --force to delete the selected Agents and pass in the Agent id from the command line")
+		}
+
+		deleted := deleteAgents(logger, theproject, keys, state, selected, force)
+		if deleted == nil {
+			return
+		}
+
+		if len(deleted) < len(selected) {
+			var failed []string
+			for _, id := range selected {
+				if !slices.Contains(deleted, id) {
+					failed = append(failed, id)
 				}
 			}
-			var agents []cproject.AgentConfig
-			for _, agent := range theproject.Project.Agents {
-				if !slice.Contains(deleted, agent.ID) {
-					agents = append(agents, agent)
-				}
+			errsystem.New(errsystem.ErrOperationPartiallyCompleted, fmt.Errorf("failed to delete %s", strings.Join(failed, ", ")),
+				errsystem.WithUserMessage("%s deleted but %s could not be deleted: %s", util.Pluralize(len(deleted), "Agent was", "Agents were"), util.Pluralize(len(failed), "Agent", "Agents"), strings.Join(failed, ", "))).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("%s deleted successfully", util.Pluralize(len(deleted), "Agent", "Agents"))
+	},
+}
+
+// deleteAgents deletes the agents with the ids from the cloud and the project file, asking for
+// confirmation unless forced, and offers to remove their source files. It returns the ids which
+// were deleted or nil when cancelled.
+func deleteAgents(logger logger.Logger, theproject project.ProjectContext, keys []string, state map[string]agentListState, selected []string, force bool) []string {
+	var deleted []string
+	var maybedelete []string
+	names := make(map[string]string)
+
+	action := func() {
+		var err error
+		deleted, err = agent.DeleteAgents(context.Background(), logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, selected)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to delete agents")).ShowErrorAndExit()
+		}
+		for _, key := range keys {
+			agent := state[key]
+			if slices.Contains(deleted, agent.Agent.ID) && util.Exists(agent.Filename) {
+				maybedelete = append(maybedelete, agent.Filename)
+				names[agent.Filename] = agent.Agent.Name
 			}
-			theproject.Project.Agents = agents
-			if err := theproject.Project.Save(theproject.Dir); err != nil {
-				errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("saving project after agent delete")).ShowErrorAndExit()
+		}
+		var agents []cproject.AgentConfig
+		for _, agent := range theproject.Project.Agents {
+			if !slice.Contains(deleted, agent.ID) {
+				agents = append(agents, agent)
 			}
 		}
+		theproject.Project.Agents = agents
+		if err := project.SaveProject(theproject.Dir, theproject.Project); err != nil {
+			errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("saving project after agent delete")).ShowErrorAndExit()
+		}
+	}
 
-		force, _ := cmd.Flags().GetBool("force")
+	if !force && !tui.Ask(logger, "Are you sure you want to delete the selected Agents from Agentuity Cloud?", true) {
+		tui.ShowWarning("cancelled")
+		return nil
+	}
 
-		if !force && !tui.HasTTY {
-			logger.Fatal("No TTY detected, please --force to delete the selected Agents and pass in the Agent id from the command line")
+	tui.ShowSpinner("Deleting Agents ...", action)
+
+	var filedeletes []string
+
+	if len(maybedelete) > 0 {
+		if !force {
+			filetext := util.Pluralize(len(maybedelete), "source file", "source files")
+			var opts []tui.Option
+			for _, f := range maybedelete {
+				rel, _ := filepath.Rel(theproject.Dir, f)
+				opts = append(opts, tui.Option{
+					ID:       f,
+					Text:     rel,
+					Selected: true,
+				})
+			}
+			filedeletes = tui.MultiSelect(logger, fmt.Sprintf("Would you like to delete the %s?", filetext), "Press spacebar to toggle file selection. Press enter to continue.", opts)
+		} else {
+			filedeletes = maybedelete
+		}
+	}
+
+	if len(filedeletes) > 0 {
+		files := make(map[string]string)
+		for _, f := range filedeletes {
+			files[f] = names[f]
+		}
+		backup, err := agent.RemoveSourceFiles(theproject.Dir, files)
+		if err != nil {
+			errsystem.New(errsystem.ErrDeleteAgents, err, errsystem.WithContextMessage("Failed to remove the agent source files")).ShowErrorAndExit()
 		}
+		tui.ShowSuccess("A backup was made in %s, restore it with %s", backup.Path(theproject.Dir), tui.Command("agent", "restore"))
+		pruneAgentBackups(logger, theproject.Dir)
+	}
+
+	return deleted
+}
 
-		if !force && !tui.Ask(logger, "Are you sure you want to delete the selected Agents from Agentuity Cloud?", true) {
-			tui.ShowWarning("cancelled")
+// pruneAgentBackups removes the oldest backups of the source files of the deleted agents beyond the
+// retention set in the backup section of the project file
+func pruneAgentBackups(logger logger.Logger, dir string) {
+	config, err := project.LoadBackupConfig(dir)
+	if err != nil {
+		logger.Warn("failed to load the backup configuration: %s", err)
+		return
+	}
+	keep := agent.DefaultBackupKeep
+	if config.Keep > 0 {
+		keep = config.Keep
+	}
+	var maxSize int64 = agent.DefaultBackupMaxSize
+	if config.MaxSize != "" {
+		if maxSize, err = deployer.ParseSize(config.MaxSize); err != nil {
+			tui.ShowWarning("The old backups weren't removed since the backup max_size is invalid: %s", err)
 			return
 		}
+	}
+	removed, err := agent.PruneBackups(dir, keep, maxSize)
+	if err != nil {
+		logger.Warn("failed to remove the old backups: %s", err)
+	}
+	if len(removed) > 0 {
+		fmt.Println(tui.Muted(fmt.Sprintf("Removed %s", util.Pluralize(len(removed), "old backup", "old backups"))))
+	}
+}
 
-		tui.ShowSpinner("Deleting Agents ...", action)
+var agentRestoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Restore the source files of a deleted Agent",
+	Long: `Restore the source files of a deleted Agent from the backups made by agentuity agent delete.
 
-		var filedeletes []string
+Without a name the backups are listed, the most recent first. With a name the
+source files of the Agent are restored from its most recent backup, or from the
+backup with --backup. The Agent is created again in the cloud on the next deploy.
 
-		if len(maybedelete) > 0 {
-			if !force {
-				filetext := util.Pluralize(len(maybedelete), "source file", "source files")
-				var opts []tui.Option
-				for _, f := range maybedelete {
-					rel, _ := filepath.Rel(theproject.Dir, f)
-					opts = append(opts, tui.Option{
-						ID:       f,
-						Text:     rel,
-						Selected: true,
-					})
+Arguments:
+  [name]      The name of the Agent to restore
+
+Flags:
+  --backup    The ID of the backup to restore from, defaults to the most recent one with the Agent
+  --force     Replace the files of the Agent if its directory already exists
+  --format    The format of the list of backups (text or json)
+
+Examples:
+  agentuity agent restore
+  agentuity agent restore my-agent
+  agentuity agent restore my-agent --backup 20250101-120000`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		theproject := project.EnsureProject(ctx, cmd)
+		backupID, _ := cmd.Flags().GetString("backup")
+		force, _ := cmd.Flags().GetBool("force")
+		format, _ := cmd.Flags().GetString("format")
+
+		if len(args) == 0 {
+			backups, err := agent.ListBackups(theproject.Dir)
+			if err != nil {
+				errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to list the backups")).ShowErrorAndExit()
+			}
+			if format == "json" {
+				type backupOutput struct {
+					agent.SourceBackup
+					Size int64 `json:"size"`
 				}
-				filedeletes = tui.MultiSelect(logger, fmt.Sprintf("Would you like to delete the %s?", filetext), "Press spacebar to toggle file selection. Press enter to continue.", opts)
-			} else {
-				filedeletes = maybedelete
+				out := make([]backupOutput, 0, len(backups))
+				for _, b := range backups {
+					out = append(out, backupOutput{b, b.Size})
+				}
+				json.NewEncoder(os.Stdout).Encode(out)
+				return
 			}
-		}
-
-		if len(filedeletes) > 0 {
-			ad := filepath.Join(theproject.Dir, ".agentuity", "backup")
-			if !util.Exists(ad) {
-				os.MkdirAll(ad, 0755)
+			if len(backups) == 0 {
+				tui.ShowWarning("No backups found")
+				return
 			}
-			for _, f := range filedeletes {
-				fd := filepath.Dir(f)
-				util.CopyDir(fd, filepath.Join(ad, filepath.Base(fd))) // make a backup
-				os.Remove(f)
-				files, _ := util.ListDir(fd)
-				if len(files) == 0 {
-					os.Remove(fd)
+			rows := make([][]string, 0, len(backups))
+			for _, b := range backups {
+				var names []string
+				for _, a := range b.Agents {
+					names = append(names, a.Name)
 				}
+				rows = append(rows, []string{b.ID, b.CreatedAt.Local().Format(time.DateTime), strings.Join(names, ", "), deployer.FormatSize(b.Size)})
 			}
-			tui.ShowSuccess("A backup was made temporarily in %s", ad)
+			tui.Table([]string{"Backup", "Created At", "Agents", "Size"}, rows)
+			return
 		}
 
-		tui.ShowSuccess("%s deleted successfully", util.Pluralize(len(deleted), "Agent", "Agents"))
+		name := args[0]
+		backup, theagent, err := agent.FindBackup(theproject.Dir, name, backupID)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		if !force && util.Exists(filepath.Join(theproject.Dir, filepath.FromSlash(theagent.Dir))) {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("the directory %s already exists", theagent.Dir),
+				errsystem.WithUserMessage("The directory %s of the Agent already exists. Use --force to replace its files.", theagent.Dir)).ShowErrorAndExit()
+		}
+		dir, err := agent.RestoreBackup(theproject.Dir, backup, theagent, force)
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to restore the Agent")).ShowErrorAndExit()
+		}
+		rel, _ := filepath.Rel(theproject.Dir, dir)
+		tui.ShowSuccess("Restored the Agent %s to %s from the backup %s", name, rel, backup.ID)
+		fmt.Printf("Run %s to create it again in the cloud.\n", tui.Command("deploy"))
 	},
 }
 
@@ -227,10 +388,47 @@ var agentCreateCmd = &cobra.Command{
 	Short:   "Create a new Agent",
 	Aliases: []string{"new"},
 	Args:    cobra.MaximumNArgs(3),
+	Long: `Create a new Agent in the project.
+
+When --from-openapi is provided, the Agent handler is generated from the
+OpenAPI specification or JSON Schema file. The handler validates incoming
+payloads against the request schema, typed request and response models are
+generated (TypeScript types or Pydantic models) and the webhook contract is
+documented in the Agent README. Without a TTY the name of the Agent defaults
+to the operationId, the schema title or the file name.
+
+When --from is provided, the Agent is a copy of an existing Agent of the
+project. Its source directory is copied (without node_modules, __pycache__,
+.venv and dist), the name and id of the existing Agent are replaced with the
+ones of the new Agent in the file names and contents, and the description of
+the existing Agent is used unless one is provided.
+
+Arguments:
+  [name]           The name of the Agent
+  [description]    The description of the Agent
+  [auth_type]      The authentication type of the Agent (project or webhook)
+
+When --plan is provided, the files which would be written, the manifests which
+would be modified and the cloud entities which would be created are shown and
+nothing is changed.
+
+Flags:
+  --from-openapi   Generate the Agent from an OpenAPI specification or JSON Schema file
+  --operation      The operationId to generate the Agent for (defaults to the first operation with a JSON request body)
+  --from           The name or id of an existing Agent of the project to copy
+  --plan           Show the changes without making them
+  --format         The format of the plan. Can be either 'text' or 'json'
+
+Examples:
+  agentuity agent create
+  agentuity agent create my-agent "My agent" project
+  agentuity agent create refund-bot --from order-bot
+  agentuity agent create --from-openapi spec.yaml --operation createOrder
+  agentuity agent create my-agent "My agent" project --plan --format json`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		theproject := project.EnsureProject(ctx, cmd)
 		apikey := theproject.Token
 		urls := util.GetURLs(logger)
@@ -238,19 +436,33 @@ var agentCreateCmd = &cobra.Command{
 
 		var remoteAgents []agent.Agent
 
+		var plan *agent.Plan
+		if planOnly, _ := cmd.Flags().GetBool("plan"); planOnly {
+			plan = agent.NewPlan()
+		}
+
 		if theproject.NewProject {
-			var projectId string
-			if theproject.Project != nil {
-				projectId = theproject.Project.ProjectId
+			if plan != nil {
+				plan.AddCloud(agent.PlanCreate, "project "+theproject.Project.Name, "import the project, which isn't in the cloud yet")
+			} else {
+				var projectId string
+				if theproject.Project != nil {
+					projectId = theproject.Project.ProjectId
+				}
+				ShowNewProjectImport(ctx, logger, cmd, apiUrl, apikey, projectId, theproject.Project, theproject.Dir, false)
 			}
-			ShowNewProjectImport(ctx, logger, cmd, apiUrl, apikey, projectId, theproject.Project, theproject.Dir, false)
-		} else {
+		} else if plan == nil {
 			initScreenWithLogo()
 		}
 
-		checkForUpgrade(ctx, logger, false)
+		if plan == nil {
+			checkForUpgrade(ctx, logger, false)
+		}
 
-		loadTemplates(ctx, cmd)
+		fromAgent, _ := cmd.Flags().GetString("from")
+		if fromAgent == "" {
+			loadTemplates(ctx, cmd)
+		}
 
 		var err error
 		remoteAgents, err = getAgentList(logger, apiUrl, apikey, theproject)
@@ -274,12 +486,58 @@ var agentCreateCmd = &cobra.Command{
 			authType = args[2]
 		}
 
+		var contract *openapi.Contract
+		if spec, _ := cmd.Flags().GetString("from-openapi"); spec != "" {
+			operationId, _ := cmd.Flags().GetString("operation")
+			contract, err = openapi.LoadContract(spec, operationId)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to load the schema from %s: %s", spec, err)).ShowErrorAndExit()
+			}
+			if contract.Request == nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no request schema found"), errsystem.WithUserMessage("No JSON request body schema was found in %s", spec)).ShowErrorAndExit()
+			}
+			if name == "" && !tui.HasTTY {
+				if name = contract.AgentName(); name == "" {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no agent name"),
+						errsystem.WithUserMessage("No Agent name could be derived from %s, please specify the Agent name from the command line", spec)).ShowErrorAndExit()
+				}
+			}
+			if description == "" && contract.Title != "" {
+				description = contract.Title
+			}
+		}
+
+		// the agent to copy with --from
+		var from *cproject.AgentConfig
+		var fromDir string
+		if fromAgent != "" {
+			if contract != nil {
+				errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--from cannot be used with --from-openapi"),
+					errsystem.WithUserMessage("The --from and --from-openapi flags cannot be used together")).ShowErrorAndExit()
+			}
+			a := selectProjectAgent(logger, theproject, []string{fromAgent}, "")
+			from = &a
+			fromDir = agentSourceDir(theproject, a.Name)
+			if !util.Exists(fromDir) {
+				errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("agent directory not found"),
+					errsystem.WithUserMessage("The directory of the Agent %s was not found in %s", a.Name, theproject.Project.Bundler.AgentConfig.Dir)).ShowErrorAndExit()
+			}
+			if description == "" {
+				description = a.Description
+			}
+		}
+
 		force, _ := cmd.Flags().GetBool("force")
 
 		// if we have a force flag and a name passed in, delete the existing agent if found
 		if force && name != "" {
 			for _, a := range remoteAgents {
 				if strings.EqualFold(a.Name, name) {
+					if plan != nil {
+						plan.AddCloud(agent.PlanDelete, "agent "+a.Name, "replaced because of --force")
+						plan.AddFile(agent.PlanModify, "agentuity.yaml", "remove agent "+a.Name)
+						break
+					}
 					if _, err := agent.DeleteAgents(ctx, logger, apiUrl, apikey, theproject.Project.ProjectId, []string{a.ID}); err != nil {
 						errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to delete existing Agent")).ShowErrorAndExit()
 					}
@@ -296,39 +554,57 @@ var agentCreateCmd = &cobra.Command{
 
 		name, description, authType = getAgentInfoFlow(logger, remoteAgents, name, description, authType)
 
+		var copied []agent.DuplicatedFile
+		if from != nil {
+			// checked before the Agent is created in the cloud so that nothing needs to be undone
+			if dest := agentSourceDir(theproject, name); util.Exists(dest) {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s already exists", dest),
+					errsystem.WithUserMessage("The directory %s already exists. Remove it or choose another name for the Agent.", dest)).ShowErrorAndExit()
+			}
+		}
+
+		if plan != nil {
+			planAgentCreate(cmd, logger, plan, theproject, contract, from, fromDir, name, description, authType, urls.Transport)
+			format, _ := cmd.Flags().GetString("format")
+			printPlan(plan, format)
+			return
+		}
+
 		action := func() {
 			agentID, err := agent.CreateAgent(ctx, logger, apiUrl, apikey, theproject.Project.ProjectId, name, description, authType)
 			if err != nil {
 				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to create Agent")).ShowErrorAndExit()
 			}
 
-			tmpdir, _, err := getConfigTemplateDir(cmd)
-			if err != nil {
-				errsystem.New(errsystem.ErrLoadTemplates, err, errsystem.WithContextMessage("Failed to load templates from directory")).ShowErrorAndExit()
-			}
-
-			rules, err := templates.LoadTemplateRuleForIdentifier(tmpdir, theproject.Project.Bundler.Identifier)
-			if err != nil {
-				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
+			if from != nil {
+				copied, err = agent.DuplicateAgent(fromDir, agentSourceDir(theproject, name), agent.Identity{ID: from.ID, Name: from.Name}, agent.Identity{ID: agentID, Name: name})
+				if err != nil {
+					if _, derr := agent.DeleteAgents(context.Background(), logger, apiUrl, apikey, theproject.Project.ProjectId, []string{agentID}); derr != nil {
+						logger.Warn("failed to delete the Agent %s after the copy failed: %s", agentID, derr)
+					}
+					errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to copy the Agent")).ShowErrorAndExit()
+				}
+				theproject.Project.Agents = append(theproject.Project.Agents, cproject.AgentConfig{
+					ID:          agentID,
+					Name:        name,
+					Description: description,
+				})
+				if err := project.SaveProject(theproject.Dir, theproject.Project); err != nil {
+					errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("Failed to save project to disk")).ShowErrorAndExit()
+				}
+				return
 			}
 
-			template, err := templates.LoadTemplateForRuntime(context.Background(), tmpdir, theproject.Project.Bundler.Identifier)
-			if err != nil {
-				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
+			rules, tmplContext := agentTemplateContext(cmd, logger, theproject, name, description)
+			if err := rules.NewAgent(tmplContext); err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithAttributes(map[string]any{"name": name})).ShowErrorAndExit()
 			}
 
-			if err := rules.NewAgent(templates.TemplateContext{
-				Logger:           logger,
-				AgentName:        name,
-				Name:             name,
-				Description:      description,
-				AgentDescription: description,
-				ProjectDir:       theproject.Dir,
-				TemplateDir:      tmpdir,
-				Template:         template,
-				AgentuityCommand: getAgentuityCommand(),
-			}); err != nil {
-				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithAttributes(map[string]any{"name": name})).ShowErrorAndExit()
+			if contract != nil {
+				agentDir, files := contractAgentFiles(theproject, contract, rules, name, description, fmt.Sprintf("%s/webhook/%s", urls.Transport, strings.Replace(agentID, "agent_", "", 1)))
+				if err := openapi.WriteAgentFiles(agentDir, files); err != nil {
+					errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to write the generated Agent files")).ShowErrorAndExit()
+				}
 			}
 
 			theproject.Project.Agents = append(theproject.Project.Agents, cproject.AgentConfig{
@@ -337,7 +613,7 @@ var agentCreateCmd = &cobra.Command{
 				Description: description,
 			})
 
-			if err := theproject.Project.Save(theproject.Dir); err != nil {
+			if err := project.SaveProject(theproject.Dir, theproject.Project); err != nil {
 				errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("Failed to save project to disk")).ShowErrorAndExit()
 			}
 		}
@@ -346,523 +622,3481 @@ var agentCreateCmd = &cobra.Command{
 		format, _ := cmd.Flags().GetString("format")
 		if format == "json" {
 			json.NewEncoder(os.Stdout).Encode(theproject.Project.Agents[len(theproject.Project.Agents)-1])
+		} else if from != nil {
+			tui.ShowSuccess("Agent %s created from %s", name, from.Name)
+			rows := [][]string{}
+			for _, f := range copied {
+				var rewritten string
+				if f.Rewritten {
+					rewritten = tui.Muted("renamed " + from.Name + " to " + name)
+				}
+				rows = append(rows, []string{tui.Text(f.Path), rewritten})
+			}
+			tui.Table([]string{"File", ""}, rows)
+			tui.ShowWarning("Review the copied files for other references to %s before deploying", from.Name)
 		} else {
 			tui.ShowSuccess("Agent created successfully")
+			if contract != nil && theproject.Project.IsPython() {
+				if buf, err := os.ReadFile(filepath.Join(theproject.Dir, "pyproject.toml")); err == nil && !strings.Contains(string(buf), "pydantic") {
+					tui.ShowWarning("The generated models require pydantic. Run uv add pydantic to add it to the project.")
+				}
+			}
 		}
 
 	},
 }
 
-type agentListState struct {
-	Agent       *agent.Agent `json:"agent"`
-	Filename    string       `json:"filename"`
-	FoundLocal  bool         `json:"foundLocal"`
-	FoundRemote bool         `json:"foundRemote"`
-	Rename      bool         `json:"rename"`
-	RenameFrom  string       `json:"renameFrom"`
-}
-
-func getAgentList(logger logger.Logger, apiUrl string, apikey string, project project.ProjectContext) ([]agent.Agent, error) {
-	var remoteAgents []agent.Agent
-	var err error
-	action := func() {
-		remoteAgents, err = agent.ListAgents(context.Background(), logger, apiUrl, apikey, project.Project.ProjectId)
+// agentTemplateContext loads the template rules of the runtime of the project and returns them
+// with the context of the template for a new Agent
+func agentTemplateContext(cmd *cobra.Command, logger logger.Logger, theproject project.ProjectContext, name string, description string) (*templates.TemplateRules, templates.TemplateContext) {
+	tmpdir, _, err := getConfigTemplateDir(cmd)
+	if err != nil {
+		errsystem.New(errsystem.ErrLoadTemplates, err, errsystem.WithContextMessage("Failed to load templates from directory")).ShowErrorAndExit()
 	}
-	tui.ShowSpinner("Fetching Agents ...", action)
-	return remoteAgents, err
-}
 
-func normalAgentName(name string, isPython bool) string {
-	return util.SafeProjectFilename(strings.ToLower(name), isPython)
-}
-
-func reconcileAgentList(logger logger.Logger, cmd *cobra.Command, apiUrl string, apikey string, theproject project.ProjectContext) ([]string, map[string]agentListState) {
-	remoteAgents, err := getAgentList(logger, apiUrl, apikey, theproject)
+	rules, err := templates.LoadTemplateRuleForIdentifier(tmpdir, theproject.Project.Bundler.Identifier)
 	if err != nil {
-		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent list")).ShowErrorAndExit()
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
 	}
 
-	tmpdir, _, err := getConfigTemplateDir(cmd)
+	template, err := templates.LoadTemplateForRuntime(context.Background(), tmpdir, theproject.Project.Bundler.Identifier)
 	if err != nil {
-		errsystem.New(errsystem.ErrLoadTemplates, err, errsystem.WithContextMessage("Failed to load templates from directory")).ShowErrorAndExit()
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
 	}
 
-	rules, err := templates.LoadTemplateRuleForIdentifier(tmpdir, theproject.Project.Bundler.Identifier)
+	return rules, templates.TemplateContext{
+		Logger:           logger,
+		AgentName:        name,
+		Name:             name,
+		Description:      description,
+		AgentDescription: description,
+		ProjectDir:       theproject.Dir,
+		TemplateDir:      tmpdir,
+		Template:         template,
+		AgentuityCommand: getAgentuityCommand(),
+	}
+}
+
+// contractAgentFiles returns the directory of the Agent and the files generated from the schema by
+// their path relative to it
+func contractAgentFiles(theproject project.ProjectContext, contract *openapi.Contract, rules *templates.TemplateRules, name string, description string, webhookURL string) (string, map[string]string) {
+	agentDir := filepath.Join(theproject.Dir, theproject.Project.Bundler.AgentConfig.Dir, util.SafeProjectFilename(name, theproject.Project.IsPython()))
+	files, err := contract.AgentFiles(rules.Filename, name, description, webhookURL)
 	if err != nil {
-		errsystem.New(errsystem.ErrInvalidConfiguration, err,
-			errsystem.WithContextMessage("Failed loading template rule"),
-			errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the Agent from the schema")).ShowErrorAndExit()
+	}
+	if theproject.Project.IsPython() && !util.Exists(filepath.Join(agentDir, "__init__.py")) {
+		files["__init__.py"] = ""
 	}
+	return agentDir, files
+}
 
-	// make a map of the agents in the agentuity config file
-	fileAgents := make(map[string]cproject.AgentConfig)
-	fileAgentsByID := make(map[string]cproject.AgentConfig)
-	for _, agent := range theproject.Project.Agents {
-		key := normalAgentName(agent.Name, theproject.Project.IsPython())
-		if existing, ok := fileAgents[key]; ok {
-			logger.Warn(
-				"agent name collision: %q and %q normalize to %q; keeping first entry",
-				existing.Name, agent.Name, key,
-			)
-			continue
-		}
-		fileAgents[key] = agent
-		fileAgentsByID[agent.ID] = agent
+// planFileAction returns the action of a plan which writes the file
+func planFileAction(filename string) string {
+	if util.Exists(filename) {
+		return agent.PlanModify
 	}
+	return agent.PlanCreate
+}
 
-	agentFilename := rules.Filename
-	agentSrcDir := filepath.Join(theproject.Dir, theproject.Project.Bundler.AgentConfig.Dir)
+// projectRelativePath returns the path of the file relative to the project directory with forward slashes
+func projectRelativePath(theproject project.ProjectContext, filename string) string {
+	rel, err := filepath.Rel(theproject.Dir, filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	return filepath.ToSlash(rel)
+}
 
-	// perform the reconcilation
-	state := make(map[string]agentListState)
-	for _, agent := range remoteAgents {
-		normalizedName := normalAgentName(agent.Name, theproject.Project.IsPython())
-		filename1 := filepath.Join(agentSrcDir, normalizedName, agentFilename)
-		filename2 := filepath.Join(agentSrcDir, agent.Name, agentFilename)
-		if util.Exists(filename1) {
-			state[normalizedName] = agentListState{
-				Agent:       &agent,
-				Filename:    filename1,
-				FoundLocal:  true,
-				FoundRemote: true,
+// planAgentCreate adds the changes which agent create would make to the plan
+func planAgentCreate(cmd *cobra.Command, logger logger.Logger, plan *agent.Plan, theproject project.ProjectContext, contract *openapi.Contract, from *cproject.AgentConfig, fromDir string, name string, description string, authType string, transportURL string) {
+	plan.AddCloud(agent.PlanCreate, "agent "+name, authType+" authentication")
+	if from != nil {
+		dest := agentSourceDir(theproject, name)
+		copied, err := agent.PlanDuplicateAgent(fromDir, dest, agent.Identity{ID: from.ID, Name: from.Name}, agent.Identity{Name: name})
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to plan the copy of the Agent")).ShowErrorAndExit()
+		}
+		for _, f := range copied {
+			var detail string
+			if f.Rewritten {
+				detail = "copied from " + from.Name + " with " + from.Name + " renamed to " + name
+			} else {
+				detail = "copied from " + from.Name
 			}
-		} else if util.Exists(filename2) {
-			state[normalizedName] = agentListState{
-				Agent:       &agent,
-				Filename:    filename2,
-				FoundLocal:  true,
-				FoundRemote: true,
+			plan.AddFile(agent.PlanCreate, projectRelativePath(theproject, filepath.Join(dest, filepath.FromSlash(f.Path))), detail)
+		}
+	} else {
+		rules, tmplContext := agentTemplateContext(cmd, logger, theproject, name, description)
+		changes, err := rules.PlanNewAgent(tmplContext)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to plan the Agent template")).ShowErrorAndExit()
+		}
+		for _, c := range changes {
+			if c.Action == templates.ChangeRun {
+				plan.AddCommand(c.Path, "")
+				continue
+			}
+			plan.AddFile(c.Action, c.Path, "from the template")
+		}
+		if contract != nil {
+			// the id of the Agent isn't known until it is created
+			agentDir, files := contractAgentFiles(theproject, contract, rules, name, description, transportURL+"/webhook/<agent id>")
+			names := make([]string, 0, len(files))
+			for fn := range files {
+				names = append(names, fn)
+			}
+			sort.Strings(names)
+			for _, fn := range names {
+				filename := filepath.Join(agentDir, filepath.FromSlash(fn))
+				plan.AddFile(planFileAction(filename), projectRelativePath(theproject, filename), "generated from the schema")
 			}
 		}
 	}
-	localAgents, err := util.ListDir(agentSrcDir)
-	if err != nil {
-		errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to list agent source directory")).ShowErrorAndExit()
+	plan.AddFile(agent.PlanModify, "agentuity.yaml", "add agent "+name)
+}
+
+// printPlan shows the changes of the plan as a list by kind or as JSON
+func printPlan(plan *agent.Plan, format string) {
+	if format == "json" {
+		json.NewEncoder(os.Stdout).Encode(plan)
+		return
 	}
-	for _, filename := range localAgents {
-		agentName := filepath.Base(filepath.Dir(filename))
-		normalizedName := normalAgentName(agentName, theproject.Project.IsPython())
-		key := normalizedName
-		// var found bool
-		// for _, agent := range remoteAgents {
-		// 	if localAgent, ok := fileAgentsByID[agent.ID]; ok {
-		// 		if localAgent.Name == agentName {
-		// 			oldkey := normalAgentName(agent.Name)
-		// 			agent.Name = localAgent.Name
-		// 			state[key] = agentListState{
-		// 				Agent:       &agent,
-		// 				Filename:    filename,
-		// 				FoundLocal:  true,
-		// 				FoundRemote: true,
-		// 				Rename:      true,
-		// 				RenameFrom:  oldkey,
-		// 			}
-		// 			delete(state, oldkey)
-		// 			found = true
-		// 			break
-		// 		}
-		// 	}
-		// }
-		// if found {
-		// 	continue
-		// }
-		if filepath.Base(filename) == agentFilename {
-			if found, ok := state[key]; ok {
-				state[key] = agentListState{
-					Agent:       found.Agent,
-					Filename:    filename,
-					FoundLocal:  true,
-					FoundRemote: true,
-				}
-				continue
+	symbols := map[string]string{
+		agent.PlanCreate: greenDiff.Render("+"),
+		agent.PlanModify: tui.Warning("~"),
+		agent.PlanDelete: redDiff.Render("-"),
+		agent.PlanRun:    tui.Muted("$"),
+	}
+	section := func(title string, changes []agent.PlanChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Println(tui.Bold(title))
+		for _, c := range changes {
+			line := fmt.Sprintf("  %s %s", symbols[c.Action], c.Target)
+			if c.Detail != "" {
+				line += " " + tui.Muted("("+c.Detail+")")
 			}
-			if a, ok := fileAgents[key]; ok {
-				state[key] = agentListState{
-					Agent:       &agent.Agent{Name: a.Name, ID: a.ID, Description: a.Description},
-					Filename:    filename,
-					FoundLocal:  true,
-					FoundRemote: true,
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+	section("Files", plan.Files)
+	section("Manifests", plan.Manifests)
+	section("Cloud", plan.Cloud)
+	section("Commands", plan.Commands)
+	tui.ShowWarning("This is a plan: nothing was written and nothing was created in the cloud")
+}
+
+var agentImportCmd = &cobra.Command{
+	Use:   "import <source>",
+	Short: "Import Agents from a GitHub repository",
+	Args:  cobra.ExactArgs(1),
+	Long: `Import one or more Agents from a GitHub repository into the project.
+
+The source is a GitHub URL such as https://github.com/owner/repo/tree/main/src/agents
+or owner/repo[/path][@ref]. When no path is provided, the agents directory of
+the project in the repository is used.
+
+The files are fetched concurrently. If the import fails part way, run the same
+command again to resume it: files which were already fetched are not fetched
+again. Set GITHUB_TOKEN to import from a private repository or to raise the
+GitHub rate limit.
+
+With --git, the files are fetched with a shallow, sparse git clone of only the
+selected Agent directories instead of file by file over HTTP. This is faster
+for large trees, respects the .gitattributes of the repository and supports
+any git host, such as https://gitlab.com/owner/repo.git. It uses your git
+credentials.
+
+The imported code is third-party code: use --sandbox with dev or bundle to
+install its dependencies without running their scripts or exposing secrets.
+When the project file has a licenses policy, the license of the repository is
+checked against it before anything is fetched.
+
+The external packages which the imported Agents use and the project doesn't
+declare are added with the package manager of the project (bun, npm, pnpm,
+yarn or uv), at the version range of the source project when it declares
+them, so the lockfile is updated. A report shows the version which was pinned
+for each dependency.
+
+The environment variables which the imported Agents use and which aren't set
+in the .env file or the cloud project are then asked for, with a description
+taken from the comment or the code next to where they are used. An empty value
+skips the variable. Without a terminal, the values are read from the file of
+--env-values. The values are saved to the .env file and, with --push-env, set
+in the cloud project, as secrets when they look like one.
+
+The entrypoints which the project file of the source declares for the imported
+Agents (agents[].entrypoint) are kept when they are in the directory of the Agent.
+
+When --plan is provided, the files which would be fetched, the manifests which
+would be modified and the cloud entities which would be created are shown and
+nothing is written. The dependencies and environment variables are only known
+once the files are fetched so they are described rather than listed.
+
+Arguments:
+  <source>         The repository (and optionally the path and ref) to import from
+
+Flags:
+  --agent          The name of an Agent to import (can be repeated)
+  --all            Import all the Agents found in the source
+  --auth-type      The authentication type of the imported Agents (project, bearer or none)
+  --concurrency    The maximum number of concurrent requests to GitHub
+  --git            Fetch the files with a shallow, sparse git clone
+  --ref            The branch, tag or commit to import from
+  --path           The directory of the repository which contains the Agents
+  --no-deps        Don't add the dependencies of the imported Agents to the project
+  --env-values     A .env file with the values of the environment variables of the Agents
+  --push-env       Set the environment variables in the cloud project as well
+  --plan           Show the changes without making them
+  --format         The format of the plan. Can be either 'text' or 'json'
+
+Examples:
+  agentuity agent import agentuity/examples/src/agents --agent my-agent
+  agentuity agent import https://github.com/owner/repo/tree/main/src/agents --all
+  agentuity agent import https://gitlab.com/owner/repo.git --git --path src/agents --all
+  agentuity agent import owner/repo --all --env-values .env.import --push-env
+  agentuity agent import owner/repo --all --plan`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		apikey := theproject.Token
+		urls := util.GetURLs(logger)
+		apiUrl := urls.API
+
+		var plan *agent.Plan
+		if planOnly, _ := cmd.Flags().GetBool("plan"); planOnly {
+			plan = agent.NewPlan()
+		}
+
+		if theproject.NewProject {
+			if plan != nil {
+				plan.AddCloud(agent.PlanCreate, "project "+theproject.Project.Name, "import the project, which isn't in the cloud yet")
+			} else {
+				var projectId string
+				if theproject.Project != nil {
+					projectId = theproject.Project.ProjectId
 				}
+				ShowNewProjectImport(ctx, logger, cmd, apiUrl, apikey, projectId, theproject.Project, theproject.Dir, false)
+			}
+		}
+
+		useGit, _ := cmd.Flags().GetBool("git")
+		var source *agent.Source
+		var err error
+		if useGit {
+			source, err = agent.ParseGitSource(args[0])
+		} else {
+			source, err = agent.ParseSource(args[0])
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid import source: %s", err)).ShowErrorAndExit()
+		}
+		if ref, _ := cmd.Flags().GetString("ref"); ref != "" {
+			if err := agent.ValidateRef(ref); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid --ref: %s", err)).ShowErrorAndExit()
+			}
+			source.Ref = ref
+		}
+		// the values are read before anything is imported so that a bad file fails fast
+		var envValues map[string]string
+		if filename, _ := cmd.Flags().GetString("env-values"); filename != "" {
+			lines, err := env.ParseEnvFile(filename)
+			if err != nil {
+				errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to parse the file of --env-values")).ShowErrorAndExit()
+			}
+			envValues = make(map[string]string)
+			for _, line := range lines {
+				envValues[line.Key] = line.Val
+			}
+		}
+		if dir, _ := cmd.Flags().GetString("path"); dir != "" {
+			source.Path = strings.Trim(filepath.ToSlash(dir), "/")
+		}
+
+		progress := func(done int, total int) {
+			if tui.HasTTY {
+				fmt.Fprintf(os.Stderr, "\r\033[KFetching files %d/%d", done, total)
 			} else {
-				state[key] = agentListState{
-					Agent:       &agent.Agent{Name: agentName},
-					Filename:    filename,
-					FoundLocal:  true,
-					FoundRemote: false,
+				logger.Debug("fetched %d/%d files", done, total)
+			}
+		}
+		var fetcher agent.ImportFetcher
+		if useGit {
+			gitFetcher, err := agent.NewGitFetcher(source)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("The --git flag requires git to be installed")).ShowErrorAndExit()
+			}
+			gitFetcher.Progress = progress
+			fetcher = gitFetcher
+		} else {
+			httpFetcher := agent.NewFetcher(source, os.Getenv("GITHUB_TOKEN"))
+			httpFetcher.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+			httpFetcher.Progress = progress
+			fetcher = httpFetcher
+		}
+		defer fetcher.Close()
+
+		var names []string
+		tui.ShowSpinner(fmt.Sprintf("Fetching Agents from %s ...", source), func() {
+			if err = fetcher.ResolveAgentsDir(ctx); err == nil {
+				names, err = fetcher.ListAgents(ctx)
+			}
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the Agents of the import source")).ShowErrorAndExit()
+		}
+		if len(names) == 0 {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no agents found"), errsystem.WithUserMessage("No Agents were found in %s", source)).ShowErrorAndExit()
+		}
+
+		selected, _ := cmd.Flags().GetStringSlice("agent")
+		all, _ := cmd.Flags().GetBool("all")
+		switch {
+		case all:
+			selected = names
+		case len(selected) > 0:
+			for _, name := range selected {
+				if !slices.Contains(names, name) {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", name), errsystem.WithUserMessage("The Agent %s was not found in %s. The Agents are: %s", name, source, strings.Join(names, ", "))).ShowErrorAndExit()
 				}
 			}
+		case tui.HasTTY:
+			var opts []tui.Option
+			for _, name := range names {
+				opts = append(opts, tui.Option{ID: name, Text: name})
+			}
+			selected = tui.MultiSelect(logger, "Which Agents would you like to import?", "Press spacebar to toggle selection. Press enter to continue.", opts)
+			if len(selected) == 0 {
+				tui.ShowWarning("no Agents selected")
+				return
+			}
+		default:
+			logger.Fatal("No TTY detected, please use --agent or --all to select the Agents to import")
 		}
-	}
 
-	keys := make([]string, 0, len(state))
-	for k := range state {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+		if policy := loadLicensePolicy(theproject.Dir); policy != nil {
+			var license string
+			tui.ShowSpinner("Checking the license ...", func() {
+				license, err = fetcher.License(ctx)
+			})
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to check the license of the import source")).ShowErrorAndExit()
+			}
+			logger.Debug("the license of %s is %q", source, license)
+			if err := showLicenseViolations(policy, policy.Check([]sbom.Component{{Name: source.String(), License: license}})); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
 
-	return keys, state
-}
+		state, err := agent.LoadImportState(theproject.Dir, source)
+		if err != nil {
+			errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to load the state of the previous import")).ShowErrorAndExit()
+		}
+		agentSrcDir := filepath.Join(theproject.Dir, theproject.Project.Bundler.AgentConfig.Dir)
+		resumed := func(name string) bool {
+			prefix := path.Join(source.Path, name) + "/"
+			for file := range state.Files {
+				if strings.HasPrefix(file, prefix) {
+					return true
+				}
+			}
+			return false
+		}
+		imported := make(map[string]string)
+		for _, name := range selected {
+			for _, a := range theproject.Project.Agents {
+				if normalAgentName(a.Name, theproject.Project.IsPython()) == normalAgentName(name, theproject.Project.IsPython()) {
+					if !resumed(name) {
+						errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s already exists", name), errsystem.WithUserMessage("An Agent named %s already exists in the project", a.Name)).ShowErrorAndExit()
+					}
+					// created by the import which is being resumed
+					imported[name] = a.ID
+				}
+			}
+			if _, ok := imported[name]; !ok && util.Exists(filepath.Join(agentSrcDir, name)) && !resumed(name) {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("directory %s already exists", name), errsystem.WithUserMessage("The directory %s already exists in the project", filepath.Join(theproject.Project.Bundler.AgentConfig.Dir, name))).ShowErrorAndExit()
+			}
+		}
 
-var wrappedPipe = "\n│"
+		var files []agent.RemoteFile
+		tui.ShowSpinner("Listing files ...", func() {
+			files, err = fetcher.ListFiles(ctx, selected)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the files of the Agents")).ShowErrorAndExit()
+		}
 
-func buildAgentTree(keys []string, state map[string]agentListState, project project.ProjectContext) (*tree.Tree, int, int, error) {
-	agentSrcDir := filepath.Join(project.Dir, project.Project.Bundler.AgentConfig.Dir)
-	var root *tree.Tree
-	var files *tree.Tree
-	cwd, err := os.Getwd()
+		dest := func(file agent.RemoteFile) string {
+			return filepath.Join(agentSrcDir, filepath.FromSlash(strings.TrimPrefix(file.Path, source.Path+"/")))
+		}
+		if plan != nil {
+			planAgentImport(cmd, logger, plan, theproject, source, selected, imported, files, state, dest)
+			format, _ := cmd.Flags().GetString("format")
+			printPlan(plan, format)
+			return
+		}
+		fetched, err := fetcher.FetchFiles(ctx, files, state, dest)
+		if tui.HasTTY && len(files) > 0 {
+			fmt.Fprintln(os.Stderr)
+		}
+		if serr := state.Save(theproject.Dir); serr != nil {
+			logger.Warn("failed to save the state of the import: %s", serr)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithUserMessage("%s. Run the same command again to resume the import.", err)).ShowErrorAndExit()
+		}
+		logger.Debug("fetched %d files, %d already fetched", fetched, len(files)-fetched)
+
+		authType, _ := cmd.Flags().GetString("auth-type")
+		if authType == "" && !tui.HasTTY {
+			authType = "project"
+		}
+		authType = getAgentAuthType(logger, authType)
+
+		tui.ShowSpinner("Creating Agents ...", func() {
+			for _, name := range selected {
+				if _, ok := imported[name]; ok {
+					continue
+				}
+				var agentID string
+				agentID, err = agent.CreateAgent(ctx, logger, apiUrl, apikey, theproject.Project.ProjectId, name, "", authType)
+				if err != nil {
+					return
+				}
+				imported[name] = agentID
+				theproject.Project.Agents = append(theproject.Project.Agents, cproject.AgentConfig{ID: agentID, Name: name})
+				// save after each agent so a resumed import doesn't create it again
+				if err = project.SaveProject(theproject.Dir, theproject.Project); err != nil {
+					return
+				}
+			}
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to create the imported Agents")).ShowErrorAndExit()
+		}
+		importAgentEntrypoints(ctx, logger, theproject, fetcher, source, selected, imported, agentSrcDir)
+		if err := agent.RemoveImportState(theproject.Dir); err != nil {
+			logger.Warn("failed to remove the state of the import: %s", err)
+		}
+		if noDeps, _ := cmd.Flags().GetBool("no-deps"); !noDeps {
+			var localFiles []string
+			for _, file := range files {
+				localFiles = append(localFiles, dest(file))
+			}
+			pinImportDependencies(ctx, logger, theproject, fetcher, source, localFiles)
+		}
+		var agentDirs []string
+		for _, name := range selected {
+			agentDirs = append(agentDirs, filepath.Join(agentSrcDir, name))
+		}
+		pushEnv, _ := cmd.Flags().GetBool("push-env")
+		importEnvValues(ctx, logger, theproject, agentDirs, envValues, pushEnv)
+		tui.ShowSuccess("Imported %s from %s", util.Pluralize(len(selected), "Agent", "Agents"), source)
+	},
+}
+
+// importAgentEntrypoints sets the entrypoints of the imported Agents to the ones which the project
+// file of the source declares for them
+func importAgentEntrypoints(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, fetcher agent.ImportFetcher, source *agent.Source, selected []string, imported map[string]string, agentSrcDir string) {
+	buf, err := fetcher.ReadFile(ctx, "agentuity.yaml")
 	if err != nil {
-		return nil, 0, 0, fmt.Errorf("failed to get current working directory: %w", err)
+		logger.Warn("failed to read the project file of %s: %s", source, err)
+		return
 	}
-	if filepath.Join(cwd, project.Project.Bundler.AgentConfig.Dir) == agentSrcDir {
-		files = tree.Root(tui.Title(project.Project.Bundler.AgentConfig.Dir) + wrappedPipe)
-		root = files
-	} else {
-		srcdir := tree.New().Root(tui.Title(project.Project.Bundler.AgentConfig.Dir) + wrappedPipe)
-		root = tree.New().Root(tui.Muted(project.Dir) + wrappedPipe).Child(srcdir)
-		files = srcdir
+	if buf == nil {
+		return
+	}
+	found, outside, err := agent.SourceEntrypoints(buf, source, selected)
+	if err != nil {
+		logger.Warn("%s", err)
+		return
+	}
+	for name, entrypoint := range outside {
+		tui.ShowWarning("The entrypoint %s of the Agent %s isn't in its directory so it wasn't imported. Copy it to the Agent and set its entrypoint in agentuity.yaml.", entrypoint, name)
+	}
+	if len(found) == 0 {
+		return
+	}
+	entrypoints := make(map[string]string)
+	for name, rel := range found {
+		entrypoints[imported[name]] = projectRelativePath(theproject, filepath.Join(agentSrcDir, name, filepath.FromSlash(rel)))
 	}
+	if err := project.SaveProjectWithAgentEntrypoints(theproject.Dir, theproject.Project, entrypoints); err != nil {
+		errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("Failed to save the entrypoints of the imported Agents")).ShowErrorAndExit()
+	}
+}
 
-	var localIssues, remoteIssues int
+// planAgentImport adds the changes which agent import would make to the plan. The dependencies and
+// the environment variables of the imported Agents are only known once their files are fetched so
+// they are described rather than listed.
+func planAgentImport(cmd *cobra.Command, logger logger.Logger, plan *agent.Plan, theproject project.ProjectContext, source *agent.Source, selected []string, imported map[string]string, files []agent.RemoteFile, state *agent.ImportState, dest func(file agent.RemoteFile) string) {
+	for _, file := range files {
+		filename := dest(file)
+		if state.Files[file.Path] == file.SHA && util.Exists(filename) {
+			// fetched by the import which is being resumed
+			continue
+		}
+		plan.AddFile(planFileAction(filename), projectRelativePath(theproject, filename), "fetched from "+source.String())
+	}
+	authType, _ := cmd.Flags().GetString("auth-type")
+	if authType == "" && !tui.HasTTY {
+		authType = "project"
+	}
+	var created []string
+	for _, name := range selected {
+		if _, ok := imported[name]; ok {
+			continue
+		}
+		if len(created) == 0 {
+			authType = getAgentAuthType(logger, authType)
+		}
+		plan.AddCloud(agent.PlanCreate, "agent "+name, authType+" authentication")
+		created = append(created, name)
+	}
+	if len(created) > 0 {
+		plan.AddFile(agent.PlanModify, "agentuity.yaml", "add "+util.Pluralize(len(created), "agent", "agents")+": "+strings.Join(created, ", "))
+	}
+	if noDeps, _ := cmd.Flags().GetBool("no-deps"); !noDeps {
+		manifest := "package.json"
+		if theproject.Project.IsPython() {
+			manifest = "pyproject.toml"
+		}
+		plan.AddFile(agent.PlanModify, manifest, "add the packages which the imported Agents use and the project doesn't declare, with the lockfile")
+	}
+	plan.AddFile(agent.PlanModify, ".env", "set the variables which the imported Agents use and which aren't set")
+	if pushEnv, _ := cmd.Flags().GetBool("push-env"); pushEnv {
+		plan.AddCloud(agent.PlanModify, "project environment", "set the variables which the imported Agents use and which aren't set")
+	}
+}
 
-	for _, k := range keys {
-		st := state[k]
-		label := tui.PadRight(tui.Bold(st.Agent.Name), 20, " ")
-		var sublabels []any
-		if st.FoundLocal && st.FoundRemote {
-			sublabels = append(sublabels, tui.Muted("ID: ")+tui.Secondary(st.Agent.ID))
-			desc := st.Agent.Description
-			if desc == "" {
-				desc = emptyProjectDescription
+// pinImportDependencies adds the external packages which the imported files use to the project
+// with its package manager, which updates the lockfile, and shows the versions which were pinned.
+// A dependency which can't be added is a warning since the agents were already imported.
+func pinImportDependencies(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, fetcher agent.ImportFetcher, source *agent.Source, files []string) {
+	language := theproject.Project.Bundler.Language
+	runtime := theproject.Project.Bundler.Runtime
+	var sourceManifest []byte
+	var err error
+	for _, manifest := range agent.SourceManifestPaths(source, language) {
+		if sourceManifest, err = fetcher.ReadFile(ctx, manifest); err != nil {
+			break
+		}
+		if sourceManifest != nil {
+			logger.Debug("using the dependencies of %s", manifest)
+			break
+		}
+	}
+	if err != nil {
+		tui.ShowWarning("Failed to read the dependencies of %s: %s", source, err)
+		return
+	}
+	projectManifest, err := os.ReadFile(filepath.Join(theproject.Dir, agent.DependencyManifest(language)))
+	if err != nil && !os.IsNotExist(err) {
+		tui.ShowWarning("Failed to read the dependencies of the project: %s", err)
+		return
+	}
+	deps, err := agent.ResolveDependencies(language, files, projectManifest, sourceManifest)
+	if err != nil {
+		tui.ShowWarning("Failed to resolve the dependencies of the imported Agents: %s", err)
+		return
+	}
+	if len(deps) == 0 {
+		logger.Debug("the imported Agents don't have new dependencies")
+		return
+	}
+	lockfile := bundler.Lockfile(theproject.Dir, language, runtime)
+	rows := [][]string{}
+	var failed []agent.Dependency
+	tui.ShowSpinner(fmt.Sprintf("Pinning %s ...", util.Pluralize(len(deps), "dependency", "dependencies")), func() {
+		for _, dep := range deps {
+			version, err := bundler.AddDependency(ctx, theproject.Dir, language, runtime, dep.Name, dep.Spec)
+			if err != nil {
+				logger.Debug("failed to add %s: %s", dep, err)
+				failed = append(failed, dep)
+				continue
 			}
-			sublabels = append(sublabels, tui.Muted("Description: ")+tui.Secondary(desc))
-			if st.Rename {
-				label += " " + tui.Warning("⚠ Renaming from "+st.RenameFrom)
+			requested := dep.Spec
+			if requested == "" {
+				requested = "latest"
 			}
-		} else if st.FoundLocal {
-			sublabels = append(sublabels, tui.Warning("⚠ Agent found local but not remotely"))
-			localIssues++
-		} else if st.FoundRemote {
-			sublabels = append(sublabels, tui.Muted("ID: ")+tui.Secondary(st.Agent.ID))
-			sublabels = append(sublabels, tui.Warning("⚠ Agent found remotely but not locally"))
-			remoteIssues++
+			rows = append(rows, []string{tui.Bold(dep.Name), tui.Muted(requested), tui.Title(version), tui.Muted(lockfile)})
 		}
-		if len(sublabels) > 0 {
-			sublabels[len(sublabels)-1] = sublabels[len(sublabels)-1].(string) + "\n"
-		}
-		agentTree := tree.New().Root(label).Child(sublabels...)
-		files.Child(agentTree)
+	})
+	if len(rows) > 0 {
+		tui.Table([]string{"Dependency", "Requested", "Pinned", "Lockfile"}, rows)
+	}
+	for _, dep := range failed {
+		tui.ShowWarning("Failed to add %s, add it to the project manually", dep)
 	}
-
-	return root, localIssues, remoteIssues, nil
 }
 
-func showAgentWarnings(remoteIssues int, localIssues int, deploying bool) bool {
-	issues := remoteIssues + localIssues
-	if issues > 0 {
-		var msg string
-		var title string
-		if issues > 1 {
-			title = "Issues"
-		} else {
-			title = "Issue"
+// importEnvValues asks for the values of the environment variables which the imported agents use
+// and which aren't set locally or in the cloud, or takes them from values, and saves them to the
+// .env file and to the cloud project with push. Like the dependencies, a failure is a warning
+// since the agents were already imported.
+func importEnvValues(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, dirs []string, values map[string]string, push bool) {
+	var refs []envutil.EnvReference
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		found, err := envutil.ScanForEnvironmentVariables(dir, "")
+		if err != nil {
+			tui.ShowWarning("Failed to scan the imported Agents for environment variables: %s", err)
+			return
 		}
-		localFmt := util.Pluralize(localIssues, "local agent", "local agents")
-		remoteFmt := util.Pluralize(remoteIssues, "remote agent", "remote agents")
-		var prefix string
-		if !deploying {
-			prefix = "When you deploy your project, the"
+		for _, ref := range found {
+			if !seen[ref.Key] {
+				seen[ref.Key] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	// a blank value in the .env file is a placeholder, not a value
+	configured := make(map[string]bool)
+	localenv := make(map[string]string)
+	if envfile := filepath.Join(theproject.Dir, ".env"); util.Exists(envfile) {
+		if lines, err := env.ParseEnvFile(envfile); err == nil {
+			for _, line := range lines {
+				if line.Val != "" {
+					configured[line.Key] = true
+					localenv[line.Key] = line.Val
+				}
+			}
 		} else {
-			prefix = "The"
+			logger.Debug("failed to parse %s: %s", envfile, err)
 		}
-		switch {
-		case localIssues > 0 && remoteIssues > 0:
-			msg = fmt.Sprintf("%s %s will be deployed and the %s will be undeployed.", prefix, localFmt, remoteFmt)
-		case localIssues > 0:
-			msg = fmt.Sprintf("%s %s will be deployed to the cloud and the ID will be saved.", prefix, localFmt)
-		case remoteIssues > 0:
-			msg = fmt.Sprintf("%s %s will be undeployed from the cloud and the ID will be removed from your project locally.", prefix, remoteFmt)
+	}
+	if projectData, err := project.GetProject(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, true, false); err == nil {
+		for key := range projectData.Env {
+			configured[key] = true
 		}
-		body := fmt.Sprintf("Detected %s in your project. %s\n\n", util.Pluralize(issues, "discrepancy", "discrepancies"), msg) + tui.Muted("$ ") + tui.Command("deploy")
-		tui.ShowBanner(tui.Warning(fmt.Sprintf("⚠ Agent %s Detected", title)), body, false)
-		if deploying {
-			tui.WaitForAnyKey()
+		for key := range projectData.Secrets {
+			configured[key] = true
+		}
+	} else {
+		logger.Debug("failed to fetch the environment of the project: %s", err)
+	}
+
+	policy, err := envutil.LoadEnvPolicy(theproject.Dir)
+	if err != nil {
+		tui.ShowWarning("Failed to load the env policy: %s", err)
+	}
+	osenv := envutil.LoadOSEnv()
+	envs := make(map[string]string)
+	secrets := make(map[string]string)
+	var unset []envutil.EnvReference
+	for _, ref := range refs {
+		if configured[ref.Key] {
+			continue
+		}
+		value := values[ref.Key]
+		if value == "" && tui.HasTTY {
+			fmt.Printf("%s %s\n", tui.Bold(ref.Key), tui.Muted(ref.Description))
+			value = envutil.PromptForEnv(logger, ref.Key, ref.IsSecret(), localenv, osenv, osenv[ref.Key], "Press enter to skip")
+		}
+		switch {
+		case value == "":
+			unset = append(unset, ref)
+		case policy[ref.Key] == envutil.PolicySecret || (policy[ref.Key] == "" && ref.IsSecret()):
+			secrets[ref.Key] = value
+		default:
+			envs[ref.Key] = value
+		}
+	}
+
+	if len(envs)+len(secrets) > 0 {
+		combined := maps.Clone(envs)
+		maps.Copy(combined, secrets)
+		if push {
+			if len(secrets) > 0 {
+				ensurePermission(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, auth.PermissionSecretWrite, "set secrets")
+			}
+			// the local-only variables are only saved to the .env file
+			pushEnvs := maps.Clone(envs)
+			pushSecrets := maps.Clone(secrets)
+			maps.DeleteFunc(pushEnvs, func(key string, _ string) bool { return policy.IsLocalOnly(key) })
+			maps.DeleteFunc(pushSecrets, func(key string, _ string) bool { return policy.IsLocalOnly(key) })
+			var err error
+			tui.ShowSpinner("Setting environment variables ...", func() {
+				envutil.SnapshotProjectEnv(ctx, logger, theproject.Dir, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, "agent import")
+				_, err = project.SetProjectEnv(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, pushEnvs, pushSecrets)
+			})
+			if err != nil {
+				tui.ShowWarning("Failed to set the environment variables in the cloud project: %s", err)
+			}
+		}
+		if err := project.SaveEnvValue(ctx, logger, theproject.Dir, combined); err != nil {
+			tui.ShowWarning("Failed to save the environment variables to the .env file: %s", err)
+		} else {
+			tui.ShowSuccess("Saved %s of the imported Agents", util.Pluralize(len(combined), "environment variable", "environment variables"))
+		}
+	}
+	if len(unset) > 0 {
+		tui.ShowWarning("%s used by the imported Agents not set:", util.Pluralize(len(unset), "variable is", "variables are"))
+		fmt.Println()
+		for _, ref := range unset {
+			fmt.Printf("  %s %s\n", tui.Bold(tui.PadRight(ref.Key, 30, " ")), tui.Muted(ref.Description))
+		}
+		fmt.Println()
+		fmt.Printf("You can set them with %s\n", tui.Command("env", "set", "<key>", "<value>"))
+	}
+}
+
+type agentListState struct {
+	Agent       *agent.Agent `json:"agent"`
+	Filename    string       `json:"filename"`
+	FoundLocal  bool         `json:"foundLocal"`
+	FoundRemote bool         `json:"foundRemote"`
+	Rename      bool         `json:"rename"`
+	RenameFrom  string       `json:"renameFrom"`
+}
+
+func getAgentList(logger logger.Logger, apiUrl string, apikey string, project project.ProjectContext) ([]agent.Agent, error) {
+	var remoteAgents []agent.Agent
+	var err error
+	action := func() {
+		remoteAgents, err = agent.ListAgents(context.Background(), logger, apiUrl, apikey, project.Project.ProjectId)
+	}
+	tui.ShowSpinner("Fetching Agents ...", action)
+	return remoteAgents, err
+}
+
+// agentSourceDir returns the source directory of the agent with the name. An existing directory
+// with the name as is is used when the directory with the safe name doesn't exist.
+func agentSourceDir(theproject project.ProjectContext, name string) string {
+	agentSrcDir := filepath.Join(theproject.Dir, theproject.Project.Bundler.AgentConfig.Dir)
+	agentDir := filepath.Join(agentSrcDir, util.SafeProjectFilename(name, theproject.Project.IsPython()))
+	if !util.Exists(agentDir) && util.Exists(filepath.Join(agentSrcDir, name)) {
+		return filepath.Join(agentSrcDir, name)
+	}
+	return agentDir
+}
+
+func normalAgentName(name string, isPython bool) string {
+	return util.SafeProjectFilename(strings.ToLower(name), isPython)
+}
+
+func reconcileAgentList(logger logger.Logger, cmd *cobra.Command, apiUrl string, apikey string, theproject project.ProjectContext) ([]string, map[string]agentListState) {
+	remoteAgents, err := getAgentList(logger, apiUrl, apikey, theproject)
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent list")).ShowErrorAndExit()
+	}
+
+	tmpdir, _, err := getConfigTemplateDir(cmd)
+	if err != nil {
+		errsystem.New(errsystem.ErrLoadTemplates, err, errsystem.WithContextMessage("Failed to load templates from directory")).ShowErrorAndExit()
+	}
+
+	rules, err := templates.LoadTemplateRuleForIdentifier(tmpdir, theproject.Project.Bundler.Identifier)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithContextMessage("Failed loading template rule"),
+			errsystem.WithAttributes(map[string]any{"identifier": theproject.Project.Bundler.Identifier})).ShowErrorAndExit()
+	}
+
+	// make a map of the agents in the agentuity config file
+	fileAgents := make(map[string]cproject.AgentConfig)
+	fileAgentsByID := make(map[string]cproject.AgentConfig)
+	for _, agent := range theproject.Project.Agents {
+		key := normalAgentName(agent.Name, theproject.Project.IsPython())
+		if existing, ok := fileAgents[key]; ok {
+			logger.Warn(
+				"agent name collision: %q and %q normalize to %q; keeping first entry",
+				existing.Name, agent.Name, key,
+			)
+			continue
+		}
+		fileAgents[key] = agent
+		fileAgentsByID[agent.ID] = agent
+	}
+
+	agentFilename := rules.Filename
+	agentSrcDir := filepath.Join(theproject.Dir, theproject.Project.Bundler.AgentConfig.Dir)
+
+	// the agents which declare an entrypoint in the project file are found by it instead of the
+	// filename of the template
+	entrypoints, err := project.LoadAgentEntrypoints(theproject.Dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the entrypoints of the agents")).ShowErrorAndExit()
+	}
+	declared := make(map[string]string)
+	for key, agent := range fileAgents {
+		if entrypoint, ok := entrypoints[agent.ID]; ok {
+			declared[key] = filepath.Join(theproject.Dir, filepath.FromSlash(entrypoint))
+		}
+	}
+
+	// perform the reconcilation
+	state := make(map[string]agentListState)
+	for _, agent := range remoteAgents {
+		normalizedName := normalAgentName(agent.Name, theproject.Project.IsPython())
+		filename1 := filepath.Join(agentSrcDir, normalizedName, agentFilename)
+		filename2 := filepath.Join(agentSrcDir, agent.Name, agentFilename)
+		if filename, ok := declared[normalizedName]; ok {
+			if util.Exists(filename) {
+				state[normalizedName] = agentListState{
+					Agent:       &agent,
+					Filename:    filename,
+					FoundLocal:  true,
+					FoundRemote: true,
+				}
+			}
+		} else if util.Exists(filename1) {
+			state[normalizedName] = agentListState{
+				Agent:       &agent,
+				Filename:    filename1,
+				FoundLocal:  true,
+				FoundRemote: true,
+			}
+		} else if util.Exists(filename2) {
+			state[normalizedName] = agentListState{
+				Agent:       &agent,
+				Filename:    filename2,
+				FoundLocal:  true,
+				FoundRemote: true,
+			}
+		}
+	}
+	localAgents, err := util.ListDir(agentSrcDir)
+	if err != nil {
+		errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to list agent source directory")).ShowErrorAndExit()
+	}
+	for _, filename := range localAgents {
+		agentName := filepath.Base(filepath.Dir(filename))
+		normalizedName := normalAgentName(agentName, theproject.Project.IsPython())
+		key := normalizedName
+		// var found bool
+		// for _, agent := range remoteAgents {
+		// 	if localAgent, ok := fileAgentsByID[agent.ID]; ok {
+		// 		if localAgent.Name == agentName {
+		// 			oldkey := normalAgentName(agent.Name)
+		// 			agent.Name = localAgent.Name
+		// 			state[key] = agentListState{
+		// 				Agent:       &agent,
+		// 				Filename:    filename,
+		// 				FoundLocal:  true,
+		// 				FoundRemote: true,
+		// 				Rename:      true,
+		// 				RenameFrom:  oldkey,
+		// 			}
+		// 			delete(state, oldkey)
+		// 			found = true
+		// 			break
+		// 		}
+		// 	}
+		// }
+		// if found {
+		// 	continue
+		// }
+		if _, ok := declared[key]; ok {
+			continue
+		}
+		if filepath.Base(filename) == agentFilename {
+			if found, ok := state[key]; ok {
+				state[key] = agentListState{
+					Agent:       found.Agent,
+					Filename:    filename,
+					FoundLocal:  true,
+					FoundRemote: true,
+				}
+				continue
+			}
+			if a, ok := fileAgents[key]; ok {
+				state[key] = agentListState{
+					Agent:       &agent.Agent{Name: a.Name, ID: a.ID, Description: a.Description},
+					Filename:    filename,
+					FoundLocal:  true,
+					FoundRemote: true,
+				}
+			} else {
+				state[key] = agentListState{
+					Agent:       &agent.Agent{Name: agentName},
+					Filename:    filename,
+					FoundLocal:  true,
+					FoundRemote: false,
+				}
+			}
+		}
+	}
+
+	for key, filename := range declared {
+		if _, ok := state[key]; ok || !util.Exists(filename) {
+			continue
+		}
+		a := fileAgents[key]
+		state[key] = agentListState{
+			Agent:       &agent.Agent{Name: a.Name, ID: a.ID, Description: a.Description},
+			Filename:    filename,
+			FoundLocal:  true,
+			FoundRemote: true,
+		}
+	}
+
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys, state
+}
+
+var wrappedPipe = "\n│"
+
+func buildAgentTree(keys []string, state map[string]agentListState, project project.ProjectContext) (*tree.Tree, int, int, error) {
+	agentSrcDir := filepath.Join(project.Dir, project.Project.Bundler.AgentConfig.Dir)
+	var root *tree.Tree
+	var files *tree.Tree
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	if filepath.Join(cwd, project.Project.Bundler.AgentConfig.Dir) == agentSrcDir {
+		files = tree.Root(tui.Title(project.Project.Bundler.AgentConfig.Dir) + wrappedPipe)
+		root = files
+	} else {
+		srcdir := tree.New().Root(tui.Title(project.Project.Bundler.AgentConfig.Dir) + wrappedPipe)
+		root = tree.New().Root(tui.Muted(project.Dir) + wrappedPipe).Child(srcdir)
+		files = srcdir
+	}
+
+	var localIssues, remoteIssues int
+
+	for _, k := range keys {
+		st := state[k]
+		label := tui.PadRight(tui.Bold(st.Agent.Name), 20, " ")
+		var sublabels []any
+		if st.FoundLocal && st.FoundRemote {
+			sublabels = append(sublabels, tui.Muted("ID: ")+tui.Secondary(st.Agent.ID))
+			desc := st.Agent.Description
+			if desc == "" {
+				desc = emptyProjectDescription
+			}
+			sublabels = append(sublabels, tui.Muted("Description: ")+tui.Secondary(desc))
+			if st.Rename {
+				label += " " + tui.Warning("⚠ Renaming from "+st.RenameFrom)
+			}
+		} else if st.FoundLocal {
+			sublabels = append(sublabels, tui.Warning("⚠ Agent found local but not remotely"))
+			localIssues++
+		} else if st.FoundRemote {
+			sublabels = append(sublabels, tui.Muted("ID: ")+tui.Secondary(st.Agent.ID))
+			sublabels = append(sublabels, tui.Warning("⚠ Agent found remotely but not locally"))
+			remoteIssues++
+		}
+		if len(sublabels) > 0 {
+			sublabels[len(sublabels)-1] = sublabels[len(sublabels)-1].(string) + "\n"
+		}
+		agentTree := tree.New().Root(label).Child(sublabels...)
+		files.Child(agentTree)
+	}
+
+	return root, localIssues, remoteIssues, nil
+}
+
+func showAgentWarnings(remoteIssues int, localIssues int, deploying bool) bool {
+	issues := remoteIssues + localIssues
+	if issues > 0 {
+		var msg string
+		var title string
+		if issues > 1 {
+			title = "Issues"
+		} else {
+			title = "Issue"
+		}
+		localFmt := util.Pluralize(localIssues, "local agent", "local agents")
+		remoteFmt := util.Pluralize(remoteIssues, "remote agent", "remote agents")
+		var prefix string
+		if !deploying {
+			prefix = "When you deploy your project, the"
+		} else {
+			prefix = "The"
+		}
+		switch {
+		case localIssues > 0 && remoteIssues > 0:
+			msg = fmt.Sprintf("%s %s will be deployed and the %s will be undeployed.", prefix, localFmt, remoteFmt)
+		case localIssues > 0:
+			msg = fmt.Sprintf("%s %s will be deployed to the cloud and the ID will be saved.", prefix, localFmt)
+		case remoteIssues > 0:
+			msg = fmt.Sprintf("%s %s will be undeployed from the cloud and the ID will be removed from your project locally.", prefix, remoteFmt)
+		}
+		body := fmt.Sprintf("Detected %s in your project. %s\n\n", util.Pluralize(issues, "discrepancy", "discrepancies"), msg) + tui.Muted("$ ") + tui.Command("deploy")
+		tui.ShowBanner(tui.Warning(fmt.Sprintf("⚠ Agent %s Detected", title)), body, false)
+		if deploying {
+			tui.WaitForAnyKey()
+		}
+		return true
+	}
+	return false
+}
+
+var agentListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List all Agents in the project",
+	Aliases: []string{"ls"},
+	Long: `List all Agents in the project, comparing the Agents in the project with
+the Agents in Agentuity Cloud.
+
+With --interactive, one or more Agents can be selected from the list to delete
+them, get their API keys, send them a test payload or edit their descriptions.
+The list is fetched once and reused between the actions until you're done.
+
+Flags:
+  --interactive    Select Agents from the list to run actions on them
+  --format         The format to use for the output. Can be either 'text' or 'json'
+  --workspace      List the Agents of every project of the workspace
+  --workspace-project   List the Agents of the projects of the workspace with the names
+
+Examples:
+  agentuity agent list
+  agentuity agent list --interactive
+  agentuity agent list --format json
+  agentuity agent list --workspace`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if runWorkspace(cmd, args) {
+			return
+		}
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		project := project.EnsureProject(ctx, cmd)
+		urls := util.GetURLs(logger)
+		apiUrl := urls.API
+
+		// perform the reconcilation
+		keys, state := reconcileAgentList(logger, cmd, apiUrl, project.Token, project)
+
+		format, _ := cmd.Flags().GetString("format")
+		if len(keys) == 0 {
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(state)
+				return
+			}
+			tui.ShowWarning("no Agents found")
+			tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
+			return
+		}
+
+		if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+			if !tui.HasTTY || format == "json" {
+				logger.Fatal("The --interactive flag requires a TTY and the text format")
+			}
+			runAgentListInteractive(ctx, logger, project, keys, state)
+			return
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(state)
+		} else {
+			root, localIssues, remoteIssues, err := buildAgentTree(keys, state, project)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to build agent tree")).ShowErrorAndExit()
+			}
+			fmt.Println(root)
+			if showAgentWarnings(remoteIssues, localIssues, false) {
+				os.Exit(1)
+			}
+		}
+
+	},
+}
+
+// runAgentListInteractive shows the agents and runs an action on the agents selected from the list
+// until the user is done, reusing the reconciled state between the actions
+func runAgentListInteractive(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, keys []string, state map[string]agentListState) {
+	for {
+		root, _, _, err := buildAgentTree(keys, state, theproject)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to build agent tree")).ShowErrorAndExit()
+		}
+		fmt.Println(root)
+
+		var options []tui.Option
+		for _, key := range keys {
+			if st := state[key]; st.FoundRemote {
+				options = append(options, tui.Option{
+					ID:   key,
+					Text: tui.PadRight(st.Agent.Name, 20, " ") + tui.Muted(st.Agent.ID),
+				})
+			}
+		}
+		if len(options) == 0 {
+			tui.ShowWarning("no Agents found in Agentuity Cloud")
+			return
+		}
+		selected := tui.MultiSelect(logger, "Select one or more Agents", "Toggle selection by pressing the spacebar\nPress enter to confirm, or without a selection to quit\n", options)
+		if len(selected) == 0 {
+			return
+		}
+
+		action := tui.Select(logger, fmt.Sprintf("What would you like to do with the %s?", util.Pluralize(len(selected), "Agent", "Agents")), "", []tui.Option{
+			{ID: "apikey", Text: "Get the API keys"},
+			{ID: "test", Text: "Send a test payload"},
+			{ID: "description", Text: "Edit the descriptions"},
+			{ID: "delete", Text: "Delete from Agentuity Cloud"},
+			{ID: "done", Text: "Done"},
+		})
+		switch action {
+		case "apikey":
+			showAgentApiKeys(ctx, logger, theproject, selected, state)
+		case "test":
+			testAgents(ctx, logger, theproject, selected, state)
+		case "description":
+			editAgentDescriptions(ctx, logger, theproject, selected, state)
+		case "delete":
+			ensurePermission(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, auth.PermissionAgentDelete, "delete agents")
+			var ids []string
+			for _, key := range selected {
+				ids = append(ids, state[key].Agent.ID)
+			}
+			deleted := deleteAgents(logger, theproject, keys, state, ids, false)
+			if len(deleted) > 0 {
+				tui.ShowSuccess("%s deleted successfully", util.Pluralize(len(deleted), "Agent", "Agents"))
+			}
+			keys = slices.DeleteFunc(keys, func(key string) bool {
+				if slices.Contains(deleted, state[key].Agent.ID) {
+					delete(state, key)
+					return true
+				}
+				return false
+			})
+		default:
+			return
+		}
+	}
+}
+
+// deployedAgents returns the selected agents which were deployed, warning about the others
+func deployedAgents(selected []string, state map[string]agentListState) []*agent.Agent {
+	var agents []*agent.Agent
+	for _, key := range selected {
+		theagent := state[key].Agent
+		if len(theagent.Types) == 0 {
+			tui.ShowWarning("Agent %s (%s) has not been deployed", theagent.Name, theagent.ID)
+			continue
+		}
+		agents = append(agents, theagent)
+	}
+	return agents
+}
+
+// showAgentApiKeys shows the API keys of the selected agents
+func showAgentApiKeys(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, selected []string, state map[string]agentListState) {
+	agents := deployedAgents(selected, state)
+	if len(agents) == 0 {
+		return
+	}
+	rows := [][]string{}
+	var err error
+	tui.ShowSpinner("Fetching API keys ...", func() {
+		for _, theagent := range agents {
+			var apikey string
+			if apikey, err = agent.GetApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.ID, theagent.Types[0]); err != nil {
+				return
+			}
+			if apikey == "" {
+				apikey = tui.Warning("none")
+			}
+			rows = append(rows, []string{tui.Bold(theagent.Name), tui.Muted(theagent.ID), apikey})
+		}
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+	}
+	tui.Table([]string{"Agent", "ID", "API Key"}, rows)
+}
+
+// testAgents sends the same test payload to each of the selected agents
+func testAgents(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, selected []string, state map[string]agentListState) {
+	agents := deployedAgents(selected, state)
+	if len(agents) == 0 {
+		return
+	}
+	payload := tui.InputWithPlaceholder(logger, "Enter the payload to send to the agents", "", "{\"hello\": \"world\"}")
+	validators := loadAgentValidators(logger, theproject.Dir)
+	for _, theagent := range agents {
+		if errs := validateAgentPayload(validators, theagent.ID, []byte(payload)); len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Println(tui.Warning("✕ ") + e)
+			}
+			tui.ShowWarning("The payload does not match the schema for Agent %s, skipping it", theagent.Name)
+			continue
+		}
+		route := theagent.Types[0]
+		apikey, err := agent.GetApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.ID, route)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+		}
+		var body []byte
+		tui.ShowSpinner(fmt.Sprintf("Testing %s ...", theagent.Name), func() {
+			_, _, body, err = sendAgentPayload(ctx, fmt.Sprintf("%s/%s/%s", theproject.TransportURL, route, theagent.ID), apikey, payload, "")
+		})
+		if err != nil {
+			tui.ShowWarning("Failed to test Agent %s: %s", theagent.Name, err)
+			continue
+		}
+		showAgentTestResponse(theagent.Name, body)
+	}
+}
+
+// editAgentDescriptions prompts for the description of each of the selected agents and updates the
+// changed ones in the cloud and the project file
+func editAgentDescriptions(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, selected []string, state map[string]agentListState) {
+	previous := make(map[string]string)
+	for _, key := range selected {
+		st := state[key]
+		description := tui.InputWithPlaceholder(logger, fmt.Sprintf("Description of %s", st.Agent.Name), "Press enter to keep the current description", st.Agent.Description)
+		if description == st.Agent.Description {
+			continue
+		}
+		var err error
+		tui.ShowSpinner(fmt.Sprintf("Updating %s ...", st.Agent.Name), func() {
+			err = agent.UpdateAgent(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, st.Agent.ID, agent.AgentUpdate{Description: &description})
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to update the Agent")).ShowErrorAndExit()
+		}
+		previous[st.Agent.ID] = st.Agent.Description
+		st.Agent.Description = description
+		for i, a := range theproject.Project.Agents {
+			if a.ID == st.Agent.ID {
+				theproject.Project.Agents[i].Description = description
+			}
+		}
+	}
+	if len(previous) == 0 {
+		tui.ShowWarning("no descriptions were changed")
+		return
+	}
+	if err := project.SaveProject(theproject.Dir, theproject.Project); err != nil {
+		// keep the cloud and the project file in sync by reverting the changes we made
+		for id, description := range previous {
+			if rerr := agent.UpdateAgent(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, id, agent.AgentUpdate{Description: &description}); rerr != nil {
+				logger.Error("failed to revert the Agent update: %s", rerr)
+			}
+		}
+		errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the project file")).ShowErrorAndExit()
+	}
+	tui.ShowSuccess("Updated the %s", util.Pluralize(len(previous), "description", "descriptions"))
+}
+
+// selectAgentForApiKey resolves the agent from the name or id argument, prompting for it if required
+func selectAgentForApiKey(logger logger.Logger, cmd *cobra.Command, theproject project.ProjectContext, args []string, help string) *agentListState {
+	// perform the reconcilation
+	keys, state := reconcileAgentList(logger, cmd, theproject.APIURL, theproject.Token, theproject)
+
+	if len(keys) == 0 {
+		tui.ShowWarning("no Agents found")
+		tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
+		return nil
+	}
+
+	var theagent *agentListState
+	if len(args) > 0 {
+		agentName := args[0]
+		for _, v := range state {
+			if v.Agent.ID == agentName || v.Agent.Name == agentName {
+				theagent = &v
+				break
+			}
+		}
+	}
+	if theagent == nil {
+		if len(state) == 1 {
+			for _, v := range state {
+				theagent = &v
+				break
+			}
+		} else {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please specify an Agent name or id")
+			}
+			var options []tui.Option
+			for _, v := range keys {
+				options = append(options, tui.Option{
+					ID:   state[v].Agent.ID,
+					Text: tui.PadRight(state[v].Agent.Name, 20, " ") + tui.Muted(state[v].Agent.ID),
+				})
+			}
+			selected := tui.Select(logger, "Select an Agent", help, options)
+			for _, v := range state {
+				if v.Agent.ID == selected {
+					theagent = &v
+					break
+				}
+			}
+		}
+	}
+	if theagent == nil {
+		tui.ShowWarning("Agent not found")
+		return nil
+	}
+	if len(theagent.Agent.Types) == 0 {
+		tui.ShowWarning("Agent %s (%s) has not been deployed", theagent.Agent.Name, theagent.Agent.ID)
+		return nil
+	}
+	return theagent
+}
+
+func runAgentApiKeyGet(cmd *cobra.Command, args []string) {
+	logger := util.NewLogger(cmd)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	theproject := project.EnsureProject(ctx, cmd)
+
+	theagent := selectAgentForApiKey(logger, cmd, theproject, args, "Select the Agent you want to get the API key for")
+	if theagent == nil {
+		return
+	}
+	apikey, err := agent.GetApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.Agent.ID, theagent.Agent.Types[0])
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+	}
+	if !tui.HasTTY {
+		if apikey != "" {
+			fmt.Print(apikey)
+			return
+		}
+	}
+	if apikey != "" {
+		fmt.Println()
+		tui.ShowLock("Agent %s API key: %s", theagent.Agent.Name, apikey)
+		tip := fmt.Sprintf(`$(agentuity agent apikey %s)`, theagent.Agent.ID)
+		tui.ShowBanner("Developer Pro Tip", tui.Paragraph("Fetch your Agent's API key into a shell command dynamically:", tip), false)
+		return
+	} else {
+		tui.ShowWarning("No API key found for Agent %s (%s)", theagent.Agent.Name, theagent.Agent.ID)
+	}
+	os.Exit(1) // no key
+}
+
+var agentGetApiKeyCmd = &cobra.Command{
+	Use:   "apikey [agent_name]",
+	Short: "Get, rotate or expire the API key for an agent",
+	Long: `Get, rotate or expire the API key for an agent by name or ID.
+
+Arguments:
+  [agent_name]  The name or ID of the agent to get the API key for
+
+If no agent name is provided, you will be prompted to select an agent.
+Running the command without a subcommand is the same as running get.
+
+Examples:
+  agentuity agent apikey "My Agent"
+  agentuity agent apikey get agent_ID
+  agentuity agent apikey rotate "My Agent" --ttl 720h
+  agentuity agent apikey expire "My Agent"`,
+	Args:    cobra.MaximumNArgs(1),
+	Aliases: []string{"key"},
+	Run:     runAgentApiKeyGet,
+}
+
+var agentApiKeyGetCmd = &cobra.Command{
+	Use:   "get [agent_name]",
+	Short: "Get the API key for an agent",
+	Long: `Get the API key for an agent by name or ID.
+
+Arguments:
+  [agent_name]  The name or ID of the agent to get the API key for
+
+Examples:
+  agentuity agent apikey get "My Agent"
+  agentuity agent apikey get agent_ID`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runAgentApiKeyGet,
+}
+
+var agentApiKeyRotateCmd = &cobra.Command{
+	Use:   "rotate [agent_name]",
+	Short: "Rotate the API key for an agent",
+	Long: `Rotate the API key for an agent by name or ID.
+
+The current key is revoked and a new key is generated. The new key is only
+printed once so make sure you store it somewhere safe.
+
+Arguments:
+  [agent_name]  The name or ID of the agent to rotate the API key for
+
+Flags:
+  --ttl      The duration the new key is valid for (default is no expiration)
+  --force    Don't prompt for confirmation
+
+Examples:
+  agentuity agent apikey rotate "My Agent"
+  agentuity agent apikey rotate agent_ID --ttl 720h --force`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		theproject := project.EnsureProject(ctx, cmd)
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if ttl < 0 {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("ttl must be a positive duration"), errsystem.WithContextMessage("Invalid ttl provided")).ShowErrorAndExit()
+		}
+
+		theagent := selectAgentForApiKey(logger, cmd, theproject, args, "Select the Agent you want to rotate the API key for")
+		if theagent == nil {
+			return
+		}
+
+		if !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to rotate the API key")
+			}
+			if !tui.Ask(logger, fmt.Sprintf("Are you sure you want to rotate the API key for %s? The current key will stop working immediately.", theagent.Agent.Name), false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		var result *agent.AgentAPIKeyRotation
+		tui.ShowSpinner("Rotating API key ...", func() {
+			var err error
+			result, err = agent.RotateApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.Agent.ID, theagent.Agent.Types[0], ttl)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to rotate agent API key")).ShowErrorAndExit()
+			}
+		})
+
+		if !tui.HasTTY {
+			fmt.Print(result.Token)
+			return
+		}
+		fmt.Println()
+		tui.ShowLock("Agent %s API key: %s", theagent.Agent.Name, result.Token)
+		if result.ExpiresAt != "" {
+			tui.ShowSuccess("The new API key expires at %s", result.ExpiresAt)
+		}
+		tui.ShowWarning("This key will not be shown again. Make sure to update any clients using the previous key.")
+	},
+}
+
+var agentApiKeyExpireCmd = &cobra.Command{
+	Use:   "expire [agent_name]",
+	Short: "Expire the API key for an agent",
+	Long: `Expire the API key for an agent by name or ID.
+
+By default the key is expired immediately. Use --ttl to schedule the
+expiration, for example to give clients time to move to a rotated key.
+
+Arguments:
+  [agent_name]  The name or ID of the agent to expire the API key for
+
+Flags:
+  --ttl      The duration until the key expires (default is immediately)
+  --force    Don't prompt for confirmation
+
+Examples:
+  agentuity agent apikey expire "My Agent"
+  agentuity agent apikey expire agent_ID --ttl 24h`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		theproject := project.EnsureProject(ctx, cmd)
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if ttl < 0 {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("ttl must be a positive duration"), errsystem.WithContextMessage("Invalid ttl provided")).ShowErrorAndExit()
+		}
+
+		theagent := selectAgentForApiKey(logger, cmd, theproject, args, "Select the Agent you want to expire the API key for")
+		if theagent == nil {
+			return
+		}
+
+		if !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to expire the API key")
+			}
+			question := fmt.Sprintf("Are you sure you want to expire the API key for %s immediately?", theagent.Agent.Name)
+			if ttl > 0 {
+				question = fmt.Sprintf("Are you sure you want to expire the API key for %s in %s?", theagent.Agent.Name, ttl)
+			}
+			if !tui.Ask(logger, question, false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		var expiresAt string
+		tui.ShowSpinner("Expiring API key ...", func() {
+			var err error
+			expiresAt, err = agent.ExpireApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.Agent.ID, theagent.Agent.Types[0], ttl)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to expire agent API key")).ShowErrorAndExit()
+			}
+		})
+
+		if ttl > 0 {
+			tui.ShowSuccess("API key for Agent %s will expire at %s", theagent.Agent.Name, expiresAt)
+		} else {
+			tui.ShowSuccess("API key for Agent %s has been expired", theagent.Agent.Name)
+		}
+		tui.ShowBanner("Generate a new key", tui.Text("Use the ")+tui.Command("agent apikey rotate", theagent.Agent.ID)+tui.Text(" command to generate a new API key"), false)
+	},
+}
+
+// findAgent returns the agent matching the id or name (case insensitive) or nil if not found
+func findAgent(agents []agent.Agent, name string) *agent.Agent {
+	for i, a := range agents {
+		if a.ID == name || strings.EqualFold(a.Name, name) {
+			return &agents[i]
+		}
+	}
+	return nil
+}
+
+// agentEndpoint returns the endpoint and API key to send requests to the agent using its first running type
+func agentEndpoint(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, theagent *agent.Agent, local bool, port int, tag string) (string, string, error) {
+	if len(theagent.Types) == 0 {
+		return "", "", fmt.Errorf("agent %s has no running types (webhook or api)", theagent.Name)
+	}
+	route := theagent.Types[0]
+	apikey, err := agent.GetApiKey(ctx, logger, theproject.APIURL, theproject.Token, theagent.ID, route)
+	if err != nil {
+		return "", "", err
+	}
+	endpoint := fmt.Sprintf("%s/%s/%s", theproject.TransportURL, route, theagent.ID)
+	if local {
+		endpoint = fmt.Sprintf("%s/%s", dev.LocalURL(theproject, port), theagent.ID)
+	}
+	if tag != "" {
+		endpoint = fmt.Sprintf("%s/%s", endpoint, tag)
+	}
+	return endpoint, apikey, nil
+}
+
+// loadAgentValidators loads the payload schemas declared for the agents in the project file keyed by agent id
+func loadAgentValidators(logger logger.Logger, dir string) map[string]*openapi.Validator {
+	schemas, err := project.LoadAgentSchemas(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the agent schemas")).ShowErrorAndExit()
+	}
+	validators := make(map[string]*openapi.Validator)
+	for agentID, filename := range schemas {
+		validator, err := openapi.LoadValidator(filename)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to load the payload schema for agent %s", agentID)).ShowErrorAndExit()
+		}
+		logger.Debug("loaded payload schema for agent %s from %s", agentID, filename)
+		validators[agentID] = validator
+	}
+	return validators
+}
+
+// validateAgentPayload validates the payload against the schema of the agent and returns the validation errors
+func validateAgentPayload(validators map[string]*openapi.Validator, agentID string, payload []byte) []string {
+	validator, ok := validators[agentID]
+	if !ok {
+		validator, ok = validators["agent_"+agentID]
+	}
+	if !ok {
+		return nil
+	}
+	return validator.Validate(payload)
+}
+
+var agentTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Test an agent",
+	Long: `Send a test payload to an Agent.
+
+If the Agent declares a payload schema in agentuity.yaml (agents[].schema), the
+payload is validated against the schema before it is sent.
+
+Flags:
+  --agent-id       The ID of the agent to test
+  --payload        The payload to send to the agent
+  --file           The file to send as the payload, or - for stdin
+  --form           A form field as key=value, or key=@path to attach a file (repeatable)
+  --base64         Send the payload base64 encoded
+  --output         Write the response to the file, or - for stdout
+  --output-binary  Write a binary response to stdout even when it's a terminal
+  --local          Send the payload to the local development server
+  --port           The port of the local development server (defaults to the running one)
+  --content-type   The content type to use for the request
+  --tag            The tag to use for the deployment
+  --snapshot       Compare the response with the stored snapshot
+  --update         Update the stored snapshot with the response
+  --snapshot-name  The name of the snapshot (defaults to a hash of the payload)
+  --agents         Send the payload to several agents by name or ID
+  --all            Send the payload to all the agents of the project
+  --coverage       Run the agents locally and write the coverage reports of the agents
+  --coverage-dir   The directory of the coverage reports (default .agentuity/coverage)
+
+With --file, the content type is detected from the extension of the file or
+else its content, so images and audio are sent with their own content type.
+With --form, the fields are sent as multipart/form-data, or URL encoded with
+--content-type application/x-www-form-urlencoded. With --base64, the payload is
+sent as base64 text with a Content-Transfer-Encoding: base64 header for the
+agents and proxies which only accept text. Only JSON payloads are validated
+against the payload schema.
+
+Responses such as images, audio or PDFs aren't shown in the terminal, save them
+with --output image.png. When stdout isn't a terminal, the response is written
+as is so that it can be piped, such as to jq. The command exits with 6 when the
+agent responds with a 4xx status and 7 with a 5xx status, unless --snapshot is
+used, in which case the status is compared with the snapshot.
+
+With --snapshot, the response is stored under .agentuity/snapshots the first
+time and later responses which drift from it fail the command with a diff of
+the changes. Use --update to accept the new response.
+
+With --agents or --all, the payload is sent to the agents concurrently and their
+responses are compared in a table with the latency of each agent, which is
+useful to compare two implementations of the same capability. The command fails
+when any of the agents fails.
+
+With --coverage, the project is built and run locally with the coverage of the
+agents collected, like dev --coverage, instead of sending the payload to the
+deployment or the development server. The lcov.info and coverage.xml reports of
+the agent source directory are written once the agents have responded.
+
+Examples:
+  agentuity agent test
+  agentuity agent test --agents summarizer,summarizer-v2 --payload '{"text": "..."}'
+  agentuity agent test --all --local --payload '{"hello": "world"}'
+  agentuity agent test --local --payload '{"hello": "world"}'
+  agentuity agent test --local --file image.png
+  agentuity agent test --local --form prompt='describe it' --form image=@image.png
+  agentuity agent test --file recording.wav --base64
+  agentuity agent test --local --payload '{"prompt": "a cat"}' --output cat.png
+  agentuity agent test --agent-id agent_123 --payload '{}' | jq .
+  agentuity agent test --all --coverage --payload '{"hello": "world"}'
+  agentuity agent test --agent-id agent_123 --payload '{"hello": "world"}' --snapshot
+  agentuity agent test --agent-id agent_123 --payload '{"hello": "world"}' --update`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		theproject := project.EnsureProject(ctx, cmd)
+
+		agentID, _ := cmd.Flags().GetString("agent-id")
+		local, _ := cmd.Flags().GetBool("local")
+		tag, _ := cmd.Flags().GetString("tag")
+		update, _ := cmd.Flags().GetBool("update")
+		snapshot, _ := cmd.Flags().GetBool("snapshot")
+		snapshotName, _ := cmd.Flags().GetString("snapshot-name")
+		snapshot = snapshot || update || snapshotName != ""
+		agentNames, _ := cmd.Flags().GetStringSlice("agents")
+		all, _ := cmd.Flags().GetBool("all")
+		port, _ := cmd.Flags().GetInt("port")
+		coverage, _ := cmd.Flags().GetBool("coverage")
+		fanOut := len(agentNames) > 0 || all
+		output, _ := cmd.Flags().GetString("output")
+		outputBinary, _ := cmd.Flags().GetBool("output-binary")
+		if fanOut && (snapshot || agentID != "" || output != "" || outputBinary) {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("--agents and --all can't be used with --agent-id, the snapshot or the output flags"),
+				errsystem.WithUserMessage("The --agents and --all flags can't be used with --agent-id, --snapshot, --update, --snapshot-name, --output or --output-binary")).ShowErrorAndExit()
+		}
+		var selectedAgent *agent.Agent
+		if fanOut {
+			keys, state := reconcileAgentList(logger, cmd, theproject.APIURL, theproject.Token, theproject)
+			var agents []agent.Agent
+			for _, k := range keys {
+				if state[k].Agent != nil {
+					agents = append(agents, *state[k].Agent)
+				}
+			}
+			var stopCoverage func()
+			if coverage {
+				local = true
+				port, stopCoverage = startCoverageProject(ctx, logger, theproject, cmd)
+			}
+			fanOutAgentTest(ctx, logger, theproject, agents, agentNames, cmd, local, port, tag, stopCoverage)
+			return
+		}
+		if agentID != "" {
+			// the agent is used as is, only its running types are looked up, since it may not be in
+			// the local project such as when testing an agent of another checkout
+			selectedAgent = lookupAgentTypes(logger, theproject, agentID)
+		} else {
+			keys, state := reconcileAgentList(logger, cmd, theproject.APIURL, theproject.Token, theproject)
+			if len(keys) == 0 {
+				tui.ShowWarning("no Agents found")
+				tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
+				return
+			}
+			var options []tui.Option
+			for _, v := range keys {
+				options = append(options, tui.Option{
+					ID:   v,
+					Text: tui.PadRight(state[v].Agent.Name, 20, " ") + tui.Muted(state[v].Agent.ID),
+				})
+			}
+			selected := tui.Select(logger, "Select an agent", "Select the agent you want to test", options)
+			selectedAgent = state[selected].Agent
+			agentID = selectedAgent.ID
+		}
+
+		if len(selectedAgent.Types) == 0 {
+			// this should never ever happen
+			tui.ShowError("Agent %s has no running types (webhook or api)", selectedAgent.Name)
+			os.Exit(1)
+		}
+		var route string
+		if len(selectedAgent.Types) > 1 {
+			options := []tui.Option{}
+			for _, route := range selectedAgent.Types {
+				options = append(options, tui.Option{
+					ID:   route,
+					Text: route,
+				})
+			}
+			route = tui.Select(logger, "Select an running type", "Select the running type you want to use", options)
+		} else {
+			route = selectedAgent.Types[0]
+		}
+
+		payload, validate := agentTestPayload(logger, cmd, "Enter the payload to send to the agent")
+		if !validate {
+			logger.Debug("not validating the %s payload against the schema", payload.ContentType)
+		} else if errs := validateAgentPayload(loadAgentValidators(logger, theproject.Dir), agentID, payload.Body); len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Println(tui.Warning("✕ ") + e)
+			}
+			fmt.Println()
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid payload"),
+				errsystem.WithUserMessage("The payload does not match the schema for Agent %s", agentID)).ShowErrorAndExit()
+		}
+
+		apikey, err := agent.GetApiKey(context.Background(), logger, theproject.APIURL, theproject.Token, agentID, route)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+		}
+		var stopCoverage func()
+		if coverage {
+			local = true
+			port, stopCoverage = startCoverageProject(ctx, logger, theproject, cmd)
+		}
+		endpoint := fmt.Sprintf("%s/%s/%s", theproject.TransportURL, route, agentID)
+		if local {
+			endpoint = fmt.Sprintf("%s/%s", dev.LocalURL(theproject, port), agentID)
+		}
+
+		if tag != "" {
+			endpoint = fmt.Sprintf("%s/%s", endpoint, tag)
+		}
+
+		status, responseType, body, err := sendAgentRequest(ctx, endpoint, apikey, payload)
+		if stopCoverage != nil {
+			stopCoverage()
+		}
+		if err != nil {
+			logger.Fatal("%s", err)
+		}
+		writeAgentResponse("Agent Test "+selectedAgent.Name, responseType, body, output, outputBinary)
+
+		if snapshot {
+			text, encoding := payload.Text()
+			if snapshotName == "" {
+				snapshotName = agent.SnapshotName(payload.ContentType, text)
+			}
+			filename := agent.SnapshotFilename(theproject.Dir, agentID, snapshotName)
+			actual := agent.NewSnapshot(agentID, payload.ContentType, text, status, body)
+			actual.PayloadEncoding = encoding
+			compareAgentSnapshot(filename, actual, update)
+			return
+		}
+		if code := agent.ResponseExitCode(status); code != 0 {
+			if tui.HasTTY {
+				tui.ShowError("Agent %s responded with status %d", selectedAgent.Name, status)
+			}
+			os.Exit(code)
+		}
+	},
+}
+
+var agentEnqueueCmd = &cobra.Command{
+	Use:   "enqueue <agent>",
+	Short: "Queue an asynchronous invocation of an agent",
+	Args:  cobra.ExactArgs(1),
+	Long: `Queue an asynchronous invocation of an agent which is configured with a queue
+trigger and print the ID of its job.
+
+The agent runs in the background in the Agentuity Cloud, which is useful for
+long-running agents which would time out as a request. Use the jobs command to
+check the status of the job, wait for its result or cancel it.
+
+When stdout isn't a terminal, only the ID of the job is written so that it can be
+captured in scripts and CI.
+
+Arguments:
+  <agent>    The name or ID of the agent
+
+Flags:
+  --payload        The payload to send to the agent
+  --file           The file to send as the payload, or - for stdin
+  --form           A form field as key=value, or key=@path to attach a file (repeatable)
+  --content-type   The content type of the payload, detected if not provided
+  --tag            The tag of the deployment to run
+  --wait           Wait for the job to complete and write its result, like jobs wait
+  --timeout        How long to wait for the job with --wait (default 30m)
+  --interval       How often to check the status of the job with --wait
+  --output         Write the result to the file, or - for stdout, with --wait
+  --output-binary  Write a binary result to stdout even when it's a terminal
+  --format         The output format (text or json)
+
+Examples:
+  agentuity agent enqueue summarizer --payload '{"url": "https://example.com/report.pdf"}'
+  agentuity agent enqueue transcriber --file meeting.wav --wait --output transcript.txt
+  JOB=$(agentuity agent enqueue summarizer --payload '{}') && agentuity jobs wait $JOB`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		tag, _ := cmd.Flags().GetString("tag")
+		wait, _ := cmd.Flags().GetBool("wait")
+		format, _ := cmd.Flags().GetString("format")
+
+		var agents []agent.Agent
+		tui.ShowSpinner("Fetching Agents ...", func() {
+			var err error
+			agents, err = agent.ListAgents(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent list")).ShowErrorAndExit()
+			}
+		})
+		theagent := findAgent(agents, args[0])
+		if theagent == nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", args[0]),
+				errsystem.WithUserMessage("Agent %s was not found in the deployed Agents of the project", args[0])).ShowErrorAndExit()
+		}
+		if !theagent.IsQueued() {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s has no queue trigger", theagent.Name),
+				errsystem.WithUserMessage("Agent %s isn't configured with a queue trigger, add one in the Agentuity Console or use agent test to send it a request", theagent.Name)).ShowErrorAndExit()
+		}
+
+		payload, validate := agentTestPayload(logger, cmd, "Enter the payload to send to the agent")
+		if validate {
+			if errs := validateAgentPayload(loadAgentValidators(logger, theproject.Dir), theagent.ID, payload.Body); len(errs) > 0 {
+				for _, e := range errs {
+					fmt.Println(tui.Warning("✕ ") + e)
+				}
+				fmt.Println()
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid payload"),
+					errsystem.WithUserMessage("The payload does not match the schema for Agent %s", theagent.Name)).ShowErrorAndExit()
+			}
+		}
+
+		var job *agent.Job
+		tui.ShowSpinner(fmt.Sprintf("Enqueuing %s ...", theagent.Name), func() {
+			var err error
+			job, err = agent.EnqueueAgent(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, theagent.ID, payload, tag)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to enqueue the agent")).ShowErrorAndExit()
+			}
+		})
+		logger.Debug("enqueued job %s for agent %s (%s)", job.ID, theagent.Name, theagent.ID)
+
+		if wait {
+			waitForAgentJob(ctx, logger, cmd, theproject.APIURL, theproject.Token, job.ID)
+			return
+		}
+		switch {
+		case format == "json":
+			outputJSON(job)
+		case !tui.HasTTY:
+			fmt.Println(job.ID)
+		default:
+			tui.ShowSuccess("Enqueued job %s for Agent %s", tui.Bold(job.ID), theagent.Name)
+			tui.ShowBanner("Track the job", tui.Text("Use the ")+tui.Command("jobs", "wait", job.ID)+tui.Text(" command to wait for its result"), false)
+		}
+	},
+}
+
+var agentCallCmd = &cobra.Command{
+	Use:   "call <org/project/agent>",
+	Short: "Call an agent of any project you have access to",
+	Args:  cobra.ExactArgs(1),
+	Long: `Call an agent of any project you have access to, without being in its project directory.
+
+The agent is resolved from the projects you have access to and its API key is fetched
+for the call, so you don't need to look up the URL and the key of the agent.
+
+Arguments:
+  <org/project/agent>   The organization, project and agent by name or ID. The organization
+                        can be left out when only one of your projects has the name.
+
+Flags:
+  --payload        The payload to send to the agent
+  --content-type   The content type to use for the request
+  --tag            The tag to use for the deployment
+
+The response is printed as is when the output isn't a terminal so that it can be piped
+to other commands. The command fails when the agent responds with an error status.
+
+Examples:
+  agentuity agent call acme/support/triage --payload '{"message": "hello"}'
+  agentuity agent call support/triage --payload 'hello' --content-type text/plain
+  agentuity agent call org_123/proj_456/agent_789 --payload '{}' --tag preview | jq .`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		ref, err := agent.ParseRef(args[0])
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		payload, _ := cmd.Flags().GetString("payload")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		tag, _ := cmd.Flags().GetString("tag")
+		urls := util.GetURLs(logger)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		// the agent belongs to another project so only the urls and the token of the context are used
+		theproject := project.ProjectContext{Logger: logger, APIURL: urls.API, APPURL: urls.App, TransportURL: urls.Transport, Token: apikey}
+
+		var theagent *agent.Agent
+		var endpoint, agentKey string
+		resolve := func() {
+			projects, err := project.ListProjects(ctx, logger, theproject.APIURL, theproject.Token)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the projects")).ShowErrorAndExit()
+			}
+			p, err := ref.ResolveProject(projects)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			agents, err := agent.ListAgents(ctx, logger, theproject.APIURL, theproject.Token, p.ID)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the agents")).ShowErrorAndExit()
+			}
+			theagent, err = ref.ResolveAgent(agents)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			endpoint, agentKey, err = agentEndpoint(ctx, logger, theproject, theagent, false, 0, tag)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the agent endpoint")).ShowErrorAndExit()
+			}
+		}
+		if tui.HasTTY {
+			tui.ShowSpinner(fmt.Sprintf("Resolving %s ...", ref), resolve)
+		} else {
+			resolve()
+		}
+
+		if payload == "" {
+			if !tui.HasTTY {
+				errsystem.New(errsystem.ErrMissingRequiredArgument, fmt.Errorf("missing payload"), errsystem.WithUserMessage("The --payload flag is required")).ShowErrorAndExit()
+			}
+			payload = tui.Input(logger, "Enter the payload to send to the agent", "{\"hello\": \"world\"}")
+		}
+
+		logger.Debug("calling agent %s (%s) at %s", theagent.Name, theagent.ID, endpoint)
+		_, status, body, err := sendAgentPayload(ctx, endpoint, agentKey, payload, contentType)
+		if err != nil {
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to call the agent")).ShowErrorAndExit()
+		}
+		if !tui.HasTTY {
+			os.Stdout.Write(body)
+		} else if status < 400 {
+			showAgentTestResponse(theagent.Name, body)
+		}
+		if status >= 400 {
+			if tui.HasTTY {
+				tui.ShowError("Agent %s responded with status %d: %s", theagent.Name, status, agent.ResponsePreview(body, 200))
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+// fanOutAgentTest sends the payload of the flags of the command to the named agents, or all the agents
+// when there are no names, concurrently and shows a comparison of their responses. The done function
+// is called once the agents have responded, such as to stop the agents run locally.
+func fanOutAgentTest(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, agents []agent.Agent, names []string, cmd *cobra.Command, local bool, port int, tag string, done func()) {
+	selected := agents
+	if len(names) > 0 {
+		selected = nil
+		for _, name := range names {
+			theagent := findAgent(agents, strings.TrimSpace(name))
+			if theagent == nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", name),
+					errsystem.WithUserMessage("Agent %s was not found in the project", name)).ShowErrorAndExit()
+			}
+			selected = append(selected, *theagent)
+		}
+	}
+	if len(selected) == 0 {
+		tui.ShowWarning("no Agents found")
+		return
+	}
+	payload, validate := agentTestPayload(logger, cmd, "Enter the payload to send to the agents")
+
+	var invalid bool
+	if validate {
+		validators := loadAgentValidators(logger, theproject.Dir)
+		for _, a := range selected {
+			for _, e := range validateAgentPayload(validators, a.ID, payload.Body) {
+				fmt.Println(tui.Warning("✕ ") + a.Name + ": " + e)
+				invalid = true
+			}
+		}
+	}
+	if invalid {
+		fmt.Println()
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid payload"),
+			errsystem.WithUserMessage("The payload does not match the schema of every Agent")).ShowErrorAndExit()
+	}
+
+	targets := make([]agent.FanOutTarget, 0, len(selected))
+	for i := range selected {
+		endpoint, apikey, err := agentEndpoint(ctx, logger, theproject, &selected[i], local, port, tag)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the agent endpoint")).ShowErrorAndExit()
+		}
+		targets = append(targets, agent.FanOutTarget{Agent: selected[i], Endpoint: endpoint, APIKey: apikey})
+	}
+
+	var results []agent.FanOutResult
+	tui.ShowSpinner(fmt.Sprintf("Sending the payload to %d Agents ...", len(targets)), func() {
+		results = agent.FanOut(ctx, targets, func(ctx context.Context, target agent.FanOutTarget) (int, []byte, error) {
+			status, _, body, err := sendAgentRequest(ctx, target.Endpoint, target.APIKey, payload)
+			return status, body, err
+		})
+	})
+	if done != nil {
+		done()
+	}
+	if isCancelled(ctx) {
+		errsystem.ShowCancelledAndExit()
+	}
+
+	var failed int
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status := fmt.Sprintf("%d", r.Status)
+		response := agent.ResponsePreview(r.Body, 60)
+		if r.Error != "" {
+			status = "error"
+			response = r.Error
+		}
+		if r.Failed() {
+			failed++
+			status = tui.Warning(status)
+		}
+		same := ""
+		if r.Same {
+			same = "✓"
+		}
+		rows = append(rows, []string{r.Name, status, fmt.Sprintf("%dms", r.Latency.Milliseconds()), same, response})
+	}
+	tui.Table([]string{"Agent", "Status", "Latency", "Same", "Response"}, rows)
+	if failed > 0 {
+		errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("%d of %d agents failed", failed, len(results)),
+			errsystem.WithUserMessage("%d of %d Agents failed", failed, len(results))).ShowErrorAndExit()
+	}
+}
+
+// startCoverageProject builds the project and runs it on its own with the coverage of the agents
+// collected. It returns the port of the project server and the function which stops it and writes
+// the coverage reports.
+func startCoverageProject(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, cmd *cobra.Command) (int, func()) {
+	coverage := newDevCoverage(cmd, theproject)
+	var buildErr error
+	tui.ShowSpinner("Building project ...", func() {
+		buildErr = bundler.Bundle(bundler.BundleContext{
+			Context:    ctx,
+			Logger:     logger,
+			ProjectDir: theproject.Dir,
+			Production: false,
+			DevMode:    true,
+			Writer:     os.Stderr,
+		})
+	})
+	if buildErr != nil {
+		if buildErr == bundler.ErrBuildFailed {
+			os.Exit(1)
+		}
+		exitOnSDKCompatibilityError(buildErr)
+		errsystem.New(errsystem.ErrInvalidConfiguration, buildErr, errsystem.WithContextMessage(fmt.Sprintf("Failed to bundle project: %s", buildErr))).ShowErrorAndExit()
+	}
+	port, err := dev.FindAvailablePort(theproject, 0)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to find an available port")).ShowErrorAndExit()
+	}
+	processCtx, cancelProcess := context.WithCancel(context.Background())
+	projectServerCmd, err := dev.CreateStandaloneProjectCmd(processCtx, theproject, theproject.Dir, "", port, os.Stderr, os.Stderr)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
+	}
+	if err := coverage.Instrument(projectServerCmd); err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to collect the coverage: %s", err)).ShowErrorAndExit()
+	}
+	if err := projectServerCmd.Start(); err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to start project: %s", err))).ShowErrorAndExit()
+	}
+	logger.Debug("started project server (pid: %d) on port %d with coverage", projectServerCmd.Process.Pid, port)
+	exited := make(chan struct{})
+	go func() {
+		projectServerCmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		dev.KillProjectServer(logger, projectServerCmd, projectServerCmd.Process.Pid)
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			logger.Debug("project server didn't stop in time")
+		}
+		cancelProcess()
+	}
+	var waitErr error
+	tui.ShowSpinner("Starting Agents ...", func() {
+		waitErr = dev.WaitForProject(ctx, fmt.Sprintf("http://%s:%d", dev.DefaultHost, port), 30*time.Second, exited)
+	})
+	if waitErr != nil {
+		stop()
+		errsystem.New(errsystem.ErrInvalidConfiguration, waitErr, errsystem.WithUserMessage("Failed to start the agents: %s", waitErr)).ShowErrorAndExit()
+	}
+	return port, func() {
+		stop()
+		reportDevCoverage(context.Background(), coverage)
+	}
+}
+
+// agentTestPayload returns the payload of the --payload, --file or --form flags of the command,
+// prompting for it when none is set, and whether it should be validated against the payload schema,
+// which is only the case for JSON and the text entered as is
+func agentTestPayload(logger logger.Logger, cmd *cobra.Command, prompt string) (*agent.Payload, bool) {
+	var opts agent.PayloadOptions
+	opts.Data, _ = cmd.Flags().GetString("payload")
+	opts.File, _ = cmd.Flags().GetString("file")
+	opts.Form, _ = cmd.Flags().GetStringArray("form")
+	opts.ContentType, _ = cmd.Flags().GetString("content-type")
+	opts.Base64, _ = cmd.Flags().GetBool("base64")
+	if opts.Data == "" && opts.File == "" && len(opts.Form) == 0 {
+		opts.Data = tui.Input(logger, prompt, "{\"hello\": \"world\"}")
+	}
+	payload, err := agent.NewPayload(opts)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to create the payload: %s", err)).ShowErrorAndExit()
+	}
+	logger.Debug("sending payload %s", payload.Describe())
+	return payload, !payload.Base64 && (opts.Data != "" || payload.IsJSON())
+}
+
+// sendAgentPayload sends the payload to the agent endpoint, detecting the content type when it's
+// empty, and returns the content type which was sent with the status and the body of the response
+func sendAgentPayload(ctx context.Context, endpoint string, apikey string, payload string, contentType string) (string, int, []byte, error) {
+	p, err := agent.NewPayload(agent.PayloadOptions{Data: payload, ContentType: contentType})
+	if err != nil {
+		return "", 0, nil, err
+	}
+	status, _, body, err := sendAgentRequest(ctx, endpoint, apikey, p)
+	return p.ContentType, status, body, err
+}
+
+// sendAgentRequest sends the payload to the agent endpoint and returns the status, the content type
+// and the body of the response
+func sendAgentRequest(ctx context.Context, endpoint string, apikey string, payload *agent.Payload) (int, string, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload.Body))
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", payload.ContentType)
+	if payload.Base64 {
+		req.Header.Set("Content-Transfer-Encoding", "base64")
+	}
+	if apikey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apikey))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, resp.Header.Get("Content-Type"), body, nil
+}
+
+// writeAgentResponse writes the response of the agent to the output file, or to stdout as is
+// when stdout isn't a terminal. Otherwise the response is shown with the title unless it's binary,
+// which is only written to the terminal with outputBinary.
+func writeAgentResponse(title string, contentType string, body []byte, output string, outputBinary bool) {
+	binary := agent.IsBinaryResponse(contentType, body)
+	switch {
+	case output == "-" || (output == "" && (!tui.HasTTY || (binary && outputBinary))):
+		os.Stdout.Write(body)
+	case output != "":
+		if err := os.WriteFile(output, body, 0644); err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithUserMessage("Failed to write the response to %s", output)).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("%s: saved the response (%s) to %s", title, agent.DescribeResponse(contentType, body), output)
+	case binary:
+		tui.ShowWarning("%s: the response (%s) isn't shown since it's binary", title, agent.DescribeResponse(contentType, body))
+		tui.ShowBanner("Save the response", tui.Text("Use ")+tui.Bold("--output <file>")+tui.Text(" to write the response to a file"), false)
+	default:
+		showAgentResponse(title, body)
+	}
+}
+
+// showAgentTestResponse shows the response of the agent, indenting it when it's JSON
+func showAgentTestResponse(name string, body []byte) {
+	showAgentResponse("Agent Test "+name, body)
+}
+
+// showAgentResponse shows the response of an agent with the title, indenting it when it's JSON
+func showAgentResponse(title string, body []byte) {
+	var jsonBody map[string]interface{}
+	if json.Unmarshal(body, &jsonBody) == nil {
+		stringified, _ := json.MarshalIndent(jsonBody, "", "  ")
+		body = stringified
+	}
+	tui.ShowSuccess("%s: %s", title, tui.Paragraph(tui.Bold(string(body))))
+}
+
+// lookupAgentTypes returns the agent with the id and its running types from the agents of the
+// project, or with the webhook type when the agent can't be found
+func lookupAgentTypes(logger logger.Logger, theproject project.ProjectContext, agentID string) *agent.Agent {
+	agents, err := getAgentList(logger, theproject.APIURL, theproject.Token, theproject)
+	if err != nil {
+		logger.Debug("failed to get the agents of the project: %s", err)
+	}
+	for _, a := range agents {
+		if a.ID == agentID && len(a.Types) > 0 {
+			return &a
+		}
+	}
+	logger.Debug("agent %s was not found in the project, using the webhook type", agentID)
+	return &agent.Agent{ID: agentID, Name: agentID, Types: []string{"webhook"}}
+}
+
+// compareAgentSnapshot compares the response with the stored snapshot, recording it if there's no snapshot
+// yet or update is set, and exits with an error showing the differences when the response has drifted
+func compareAgentSnapshot(filename string, actual *agent.Snapshot, update bool) {
+	expected, err := agent.LoadSnapshot(filename)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load snapshot")).ShowErrorAndExit()
+	}
+	relname := filename
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, filename); err == nil {
+			relname = rel
+		}
+	}
+	var diff string
+	if expected != nil {
+		diff = expected.Diff(actual)
+		if diff == "" {
+			tui.ShowSuccess("Response matches snapshot %s", tui.Muted(relname))
+			return
+		}
+	}
+	if expected == nil || update {
+		if err := actual.Save(filename); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save snapshot")).ShowErrorAndExit()
+		}
+		if expected == nil {
+			tui.ShowSuccess("Saved snapshot %s", tui.Muted(relname))
+		} else {
+			tui.ShowSuccess("Updated snapshot %s", tui.Muted(relname))
+		}
+		return
+	}
+	fmt.Println()
+	printUnifiedDiff(diff)
+	fmt.Println()
+	tui.ShowError("Response does not match snapshot %s", relname)
+	tui.ShowBanner("Accept the changes", tui.Text("Use the ")+tui.Command("agent test", "--snapshot", "--update")+tui.Text(" command to update the snapshot"), false)
+	os.Exit(1)
+}
+
+// printUnifiedDiff prints the unified diff with the added lines in green and the removed lines in red
+func printUnifiedDiff(diff string) {
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			fmt.Println(tui.Bold(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(greenDiff.Render(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(redDiff.Render(line))
+		default:
+			fmt.Println(tui.Muted(line))
+		}
+	}
+}
+
+func printLoadTestReport(report *loadtest.Report) {
+	ms := func(v float64) string {
+		return fmt.Sprintf("%.0fms", v)
+	}
+	row := func(label string, value string) {
+		fmt.Printf("%s %s\n", tui.Bold(tui.PadRight(label, 14, " ")), value)
+	}
+	var throttled int
+	if report.Throttling != nil {
+		throttled = report.Throttling.Throttled
+	}
+	fmt.Println()
+	row("Requests", fmt.Sprintf("%d %s", report.Requests, tui.Muted(fmt.Sprintf("(%d succeeded, %d failed, %d throttled, %d dropped)", report.Succeeded, report.Failed, throttled, report.Dropped))))
+	row("Throughput", fmt.Sprintf("%.1f req/s %s", report.Throughput, tui.Muted(fmt.Sprintf("(target %g req/s, %s profile)", report.TargetRPS, report.Profile))))
+	errorRate := fmt.Sprintf("%.2f%%", report.ErrorRate)
+	if report.Failed > 0 {
+		errorRate = tui.Warning(errorRate)
+	}
+	row("Error rate", errorRate)
+	row("Latency", fmt.Sprintf("p50 %s · p90 %s · p95 %s · p99 %s %s", ms(report.Latency.P50), ms(report.Latency.P90), ms(report.Latency.P95), ms(report.Latency.P99),
+		tui.Muted(fmt.Sprintf("(min %s, mean %s, max %s)", ms(report.Latency.Min), ms(report.Latency.Mean), ms(report.Latency.Max)))))
+	codes := make([]string, 0, len(report.StatusCodes))
+	for code := range report.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var statuses []string
+	for _, code := range codes {
+		statuses = append(statuses, fmt.Sprintf("%s: %d", code, report.StatusCodes[code]))
+	}
+	row("Status codes", strings.Join(statuses, " · "))
+	if report.Throttling != nil {
+		row("Throttling", tui.Warning(fmt.Sprintf("first 429 after %.1fs at %g req/s (%.1f%% of requests throttled)", report.Throttling.FirstAt, report.Throttling.AtRPS, report.Throttling.Percentage)))
+	} else {
+		row("Throttling", "none")
+	}
+	if report.Dropped > 0 {
+		fmt.Println()
+		tui.ShowWarning("%d requests were not sent because the concurrency limit was reached, increase --concurrency or lower --rps", report.Dropped)
+	}
+	fmt.Println()
+}
+
+var agentLoadtestCmd = &cobra.Command{
+	Use:   "loadtest [agent]",
+	Short: "Load test an agent",
+	Args:  cobra.MaximumNArgs(1),
+	Long: `Send concurrent traffic to an agent and report the latency percentiles, error
+rate and throttling behavior.
+
+Requests are sent at the target rate regardless of how long the agent takes to
+respond. The rate can be ramped up to the target with the linear or step profile.
+Throttled requests (HTTP 429) are reported separately from errors along with
+the rate at which throttling started.
+
+Arguments:
+  [agent]          The name or ID of the agent to test
+
+Flags:
+  --rps            The target number of requests per second
+  --duration       How long to run the test
+  --payload        The payload to send to the agent
+  --payload-file   The file containing the payload to send to the agent
+  --profile        The ramp profile (constant, linear or step)
+  --ramp-up        The time to ramp up to the target rate for the linear and step profiles
+  --steps          The number of steps for the step profile
+  --concurrency    The maximum number of requests in flight
+  --export         Export the results to a .json or .csv file
+  --local          Send the requests to the local development server
+
+Examples:
+  agentuity agent loadtest my-agent --rps 50 --duration 2m --payload-file p.json
+  agentuity agent loadtest my-agent --rps 100 --duration 5m --profile linear --ramp-up 2m
+  agentuity agent loadtest my-agent --rps 20 --duration 1m --profile step --ramp-up 40s --steps 4 --export results.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		rps, _ := cmd.Flags().GetFloat64("rps")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		payload, _ := cmd.Flags().GetString("payload")
+		payloadFile, _ := cmd.Flags().GetString("payload-file")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		profile, _ := cmd.Flags().GetString("profile")
+		rampUp, _ := cmd.Flags().GetDuration("ramp-up")
+		steps, _ := cmd.Flags().GetInt("steps")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		export, _ := cmd.Flags().GetString("export")
+		local, _ := cmd.Flags().GetBool("local")
+		port, _ := cmd.Flags().GetInt("port")
+		tag, _ := cmd.Flags().GetString("tag")
+		force, _ := cmd.Flags().GetBool("force")
+		format, _ := cmd.Flags().GetString("format")
+
+		if payloadFile != "" {
+			if payload != "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("both payload flags provided"),
+					errsystem.WithUserMessage("The --payload and --payload-file flags cannot be used together")).ShowErrorAndExit()
+			}
+			buf, err := os.ReadFile(payloadFile)
+			if err != nil {
+				errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithUserMessage("Failed to read the payload file %s", payloadFile)).ShowErrorAndExit()
+			}
+			payload = string(buf)
+		}
+		if contentType == "" {
+			if json.Valid([]byte(payload)) {
+				contentType = "application/json"
+			} else {
+				contentType = "text/plain"
+			}
+		}
+		if export != "" && filepath.Ext(export) != ".json" && filepath.Ext(export) != ".csv" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid export file: %s", export),
+				errsystem.WithUserMessage("The export file must have a .json or .csv extension")).ShowErrorAndExit()
+		}
+
+		config := loadtest.Config{
+			ContentType:    contentType,
+			Payload:        []byte(payload),
+			RPS:            rps,
+			Duration:       duration,
+			Profile:        loadtest.Profile(profile),
+			RampUp:         rampUp,
+			Steps:          steps,
+			MaxConcurrency: concurrency,
+			Timeout:        timeout,
+		}
+		if err := config.Validate(); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid load test: %s", err)).ShowErrorAndExit()
+		}
+
+		var agents []agent.Agent
+		tui.ShowSpinner("Fetching Agents ...", func() {
+			var err error
+			agents, err = agent.ListAgents(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent list")).ShowErrorAndExit()
+			}
+		})
+		var theagent *agent.Agent
+		if len(args) > 0 {
+			theagent = findAgent(agents, args[0])
+			if theagent == nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", args[0]),
+					errsystem.WithUserMessage("Agent %s was not found in the project", args[0])).ShowErrorAndExit()
+			}
+		} else {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please specify an agent from the command line")
+			}
+			if len(agents) == 0 {
+				tui.ShowWarning("no deployed Agents found")
+				return
+			}
+			var options []tui.Option
+			for _, a := range agents {
+				options = append(options, tui.Option{ID: a.ID, Text: tui.PadRight(a.Name, 20, " ") + tui.Muted(a.ID)})
+			}
+			theagent = findAgent(agents, tui.Select(logger, "Select an agent", "Select the agent you want to load test", options))
+		}
+
+		endpoint, apikey, err := agentEndpoint(ctx, logger, theproject, theagent, local, port, tag)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the agent endpoint")).ShowErrorAndExit()
+		}
+		config.Endpoint = endpoint
+		config.APIKey = apikey
+
+		if !local && !force && tui.HasTTY {
+			if !tui.Ask(logger, fmt.Sprintf("This will send about %d requests to the deployed Agent %s over %s. Continue?", config.ExpectedRequests(), theagent.Name, duration), true) {
+				return
+			}
+		}
+
+		var report *loadtest.Report
+		action := func() {
+			report, err = loadtest.Run(ctx, config, func(elapsed time.Duration, samples int) {
+				logger.Debug("load test %s elapsed, %d responses", elapsed.Truncate(time.Second), samples)
+			})
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner(fmt.Sprintf("Load testing %s at %g req/s for %s ...", theagent.Name, rps, duration), action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithContextMessage("Failed to run the load test")).ShowErrorAndExit()
+		}
+
+		if export != "" {
+			of, err := os.Create(export)
+			if err != nil {
+				errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithUserMessage("Failed to create the export file %s", export)).ShowErrorAndExit()
+			}
+			if filepath.Ext(export) == ".csv" {
+				err = report.WriteCSV(of)
+			} else {
+				err = report.WriteJSON(of)
+			}
+			of.Close()
+			if err != nil {
+				errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithUserMessage("Failed to write the export file %s", export)).ShowErrorAndExit()
+			}
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(report)
+			return
+		}
+		printLoadTestReport(report)
+		if export != "" {
+			tui.ShowSuccess("Exported results to %s", tui.Muted(export))
+		}
+	},
+}
+
+var agentSetCmd = &cobra.Command{
+	Use:   "set [agent]",
+	Short: "Update the description or tags of an Agent",
+	Long: `Update the description or tags of an Agent.
+
+The Agent is updated in Agentuity Cloud and in agentuity.yaml together. If the
+project file can't be written the change in the cloud is reverted.
+
+Arguments:
+  [agent]    The name or id of the Agent to update
+
+Flags:
+  --description    The new description of the Agent
+  --tags           The tags of the Agent, replacing the current tags (pass "" to remove them)
+  --format         The format to use for the output. Can be either 'text' or 'json'
+
+Examples:
+  agentuity agent set my-agent --description "Answers questions about orders"
+  agentuity agent set my-agent --tags beta,support
+  agentuity agent set agent_123 --tags "" --format json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		if !cmd.Flags().Changed("description") && !cmd.Flags().Changed("tags") {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("nothing to update"),
+				errsystem.WithUserMessage("Nothing to update. Pass at least one of --description or --tags.")).ShowErrorAndExit()
+		}
+
+		selected := selectProjectAgent(logger, theproject, args, "Select the Agent you want to update")
+		var theagent *cproject.AgentConfig
+		for i, a := range theproject.Project.Agents {
+			if a.ID == selected.ID {
+				theagent = &theproject.Project.Agents[i]
+				break
+			}
+		}
+
+		allTags, err := project.LoadAgentTags(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to read the agent tags")).ShowErrorAndExit()
+		}
+		currentTags := allTags[theagent.ID]
+		if currentTags == nil {
+			currentTags = []string{}
+		}
+		previousDescription := theagent.Description
+		previous := agent.AgentUpdate{Description: &previousDescription, Tags: &currentTags}
+
+		var update agent.AgentUpdate
+		tags := currentTags
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			if description != theagent.Description {
+				update.Description = &description
+			}
+		}
+		if cmd.Flags().Changed("tags") {
+			values, _ := cmd.Flags().GetStringSlice("tags")
+			tags, err = project.NormalizeTags(values)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			if !slices.Equal(tags, currentTags) {
+				update.Tags = &tags
+			}
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if update.Description != nil || update.Tags != nil {
+			action := func() {
+				err = agent.UpdateAgent(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, theagent.ID, update)
+			}
+			if format == "json" {
+				action()
+			} else {
+				tui.ShowSpinner("Updating Agent ...", action)
+			}
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to update the Agent")).ShowErrorAndExit()
+			}
+			if update.Description != nil {
+				theagent.Description = *update.Description
+			}
+			if update.Tags != nil {
+				err = project.SaveProjectWithAgentTags(theproject.Dir, theproject.Project, theagent.ID, tags)
+			} else {
+				err = project.SaveProject(theproject.Dir, theproject.Project)
+			}
+			if err != nil {
+				// keep the cloud and the project file in sync by reverting the change we made
+				if rerr := agent.UpdateAgent(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, theagent.ID, previous); rerr != nil {
+					logger.Error("failed to revert the Agent update: %s", rerr)
+				}
+				errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the project file")).ShowErrorAndExit()
+			}
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(map[string]any{
+				"id":          theagent.ID,
+				"name":        theagent.Name,
+				"description": theagent.Description,
+				"tags":        tags,
+				"updated":     update.Description != nil || update.Tags != nil,
+			})
+			return
+		}
+		if update.Description == nil && update.Tags == nil {
+			tui.ShowWarning("Agent %s is already up to date", theagent.Name)
+			return
+		}
+		tui.ShowSuccess("Updated Agent %s", tui.Bold(theagent.Name))
+	},
+}
+
+var agentPublishCmd = &cobra.Command{
+	Use:   "publish [agent]",
+	Short: "Publish an Agent to the community registry",
+	Long: `Publish an Agent to the Agentuity community registry so that other projects can import it.
+
+The Agent directory is packaged with a manifest which describes the Agent. The
+manifest is derived from the project: the name, description and tags of the
+Agent, the language and runtime of the project and the environment variables
+referenced in the source of the Agent. An agent.yaml file in the Agent directory
+overrides any of these and can describe the environment variables:
+
+  name: order-lookup
+  description: Answers questions about orders
+  visibility: public
+  pricing: free
+  env:
+    - name: SHOPIFY_TOKEN
+      description: The Admin API token of the store
+
+The version must be a semantic version which is greater than the latest
+published version. Use --bump to publish the next major, minor or patch version.
+
+Use agentuity agent unpublish to remove a version and agentuity agent deprecate
+to warn the users of a version which shouldn't be used anymore.
+
+Arguments:
+  [agent]    The name or id of the Agent to publish
+
+Flags:
+  --version       The version to publish (overrides the manifest version)
+  --bump          Publish the next major, minor or patch version
+  --description   The description of the Agent (overrides the manifest description)
+  --visibility    Who can find and import the Agent (public, unlisted or private)
+  --pricing       Whether the Agent is free or paid
+  --org-id        The organization which publishes the Agent
+  --dry-run       Validate and package the Agent without publishing it
+  --force         Don't prompt for confirmation
+  --format        The output format (text or json)
+
+Examples:
+  agentuity agent publish my-agent --version 1.0.0
+  agentuity agent publish my-agent --bump minor
+  agentuity agent publish my-agent --bump patch --visibility unlisted --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		format, _ := cmd.Flags().GetString("format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+
+		theagent := selectProjectAgent(logger, theproject, args, "Select the Agent you want to publish")
+		agentDir := agentSourceDir(theproject, theagent.Name)
+		if !util.Exists(agentDir) {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("agent directory not found"),
+				errsystem.WithUserMessage("The directory of the Agent %s was not found in %s", theagent.Name, theproject.Project.Bundler.AgentConfig.Dir)).ShowErrorAndExit()
+		}
+
+		manifest, err := agent.LoadManifest(agentDir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the Agent manifest")).ShowErrorAndExit()
+		}
+		if manifest.Name == "" {
+			manifest.Name = strcase.ToKebab(theagent.Name)
+		}
+		if manifest.Description == "" {
+			manifest.Description = theagent.Description
+		}
+		if manifest.Language == "" {
+			manifest.Language = theproject.Project.Bundler.Language
+		}
+		if manifest.Runtime == "" {
+			manifest.Runtime = theproject.Project.Bundler.Runtime
+		}
+		if manifest.Visibility == "" {
+			manifest.Visibility = agent.VisibilityPublic
+		}
+		if manifest.Pricing == "" {
+			manifest.Pricing = agent.PricingFree
+		}
+		if tags, err := project.LoadAgentTags(theproject.Dir); err == nil {
+			for _, tag := range tags[theagent.ID] {
+				if !slices.Contains(manifest.Tags, tag) {
+					manifest.Tags = append(manifest.Tags, tag)
+				}
+			}
+		}
+		if manifest.License == "" {
+			for _, name := range sbom.LicenseFilenames {
+				if buf, err := os.ReadFile(filepath.Join(theproject.Dir, name)); err == nil {
+					manifest.License = sbom.DetectLicense(string(buf))
+					break
+				}
+			}
+		}
+		for flag, val := range map[string]*string{"description": &manifest.Description, "visibility": &manifest.Visibility, "pricing": &manifest.Pricing, "version": &manifest.Version} {
+			if cmd.Flags().Changed(flag) {
+				*val, _ = cmd.Flags().GetString(flag)
+			}
+		}
+		if err := manifest.ScanEnvRequirements(agentDir); err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to scan the Agent for environment variables")).ShowErrorAndExit()
+		}
+
+		orgId := promptForOrganization(ctx, logger, cmd, theproject.APIURL, theproject.Token)
+		var published []agent.PublishedVersion
+		tui.ShowSpinner("Checking the published versions ...", func() {
+			published, err = agent.ListPublishedVersions(ctx, logger, theproject.APIURL, theproject.Token, orgId, manifest.Name)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the published versions of the Agent")).ShowErrorAndExit()
+		}
+		if bump, _ := cmd.Flags().GetString("bump"); bump != "" {
+			if cmd.Flags().Changed("version") {
+				errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--bump and --version"), errsystem.WithUserMessage("The --bump and --version flags cannot be used together")).ShowErrorAndExit()
+			}
+			manifest.Version, err = agent.BumpVersion(bump, published)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidCommandFlag, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
+
+		if err := manifest.Validate(); err != nil {
+			if verr, ok := err.(*agent.ValidationError); ok {
+				if format == "json" {
+					json.NewEncoder(os.Stdout).Encode(map[string]any{"valid": false, "problems": verr.Problems})
+					os.Exit(1)
+				}
+				for _, problem := range verr.Problems {
+					fmt.Println(tui.Warning("✕ ") + problem)
+				}
+				fmt.Println()
+			}
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("The Agent %s can't be published. Fix the problems with flags or in %s", theagent.Name, filepath.Join(theproject.Project.Bundler.AgentConfig.Dir, filepath.Base(agentDir), agent.ManifestFilename))).ShowErrorAndExit()
+		}
+		if err := agent.CheckVersion(manifest.Version, published); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s. Use --version or --bump to publish a new version.", err)).ShowErrorAndExit()
+		}
+
+		tmpfile, err := os.CreateTemp("", "agentuity-agent-*.zip")
+		if err != nil {
+			errsystem.New(errsystem.ErrCreateTemporaryFile, err,
+				errsystem.WithContextMessage("Error creating temp file")).ShowErrorAndExit()
+		}
+		tmpfile.Close()
+		defer os.Remove(tmpfile.Name())
+		if err := agent.PackageAgent(theproject.Dir, agentDir, manifest, tmpfile.Name()); err != nil {
+			errsystem.New(errsystem.ErrCreateZipFile, err,
+				errsystem.WithContextMessage("Error packaging the Agent")).ShowErrorAndExit()
+		}
+
+		if dryRun {
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(map[string]any{"valid": true, "manifest": manifest})
+				return
+			}
+			showAgentManifest(manifest)
+			tui.ShowSuccess("Agent %s %s is valid and ready to publish", manifest.Name, manifest.Version)
+			return
+		}
+
+		if !force && tui.HasTTY {
+			if format != "json" {
+				showAgentManifest(manifest)
+			}
+			if !tui.Ask(logger, fmt.Sprintf("Publish %s %s to the community registry as %s?", manifest.Name, manifest.Version, manifest.Visibility), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		var version *agent.PublishedVersion
+		tui.ShowSpinner("Publishing Agent ...", func() {
+			version, err = agent.PublishAgent(ctx, logger, theproject.APIURL, theproject.Token, agent.PublishRequest{OrgId: orgId, Manifest: *manifest}, tmpfile.Name())
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err,
+				errsystem.WithContextMessage("Failed to publish the Agent")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(version)
+			return
+		}
+		tui.ShowSuccess("Published %s %s", version.Name, version.Version)
+		if version.URL != "" {
+			fmt.Println()
+			fmt.Println(tui.Link("%s", version.URL))
+		}
+	},
+}
+
+func showAgentManifest(manifest *agent.Manifest) {
+	row := func(label string, val string) {
+		if val != "" {
+			fmt.Printf("%s %s\n", tui.Muted(tui.PadRight(label+":", 14, " ")), val)
+		}
+	}
+	row("Name", manifest.Name)
+	row("Version", manifest.Version)
+	row("Description", manifest.Description)
+	row("Runtime", manifest.Language+" ("+manifest.Runtime+")")
+	row("Tags", strings.Join(manifest.Tags, ", "))
+	row("Visibility", manifest.Visibility)
+	row("Pricing", manifest.Pricing)
+	row("License", manifest.License)
+	for i, env := range manifest.Env {
+		label := ""
+		if i == 0 {
+			label = "Env:"
+		}
+		val := env.Name
+		if env.Optional {
+			val += tui.Muted(" (optional)")
+		}
+		if env.Description != "" {
+			val += tui.Muted(" " + env.Description)
+		}
+		fmt.Printf("%s %s\n", tui.Muted(tui.PadRight(label, 14, " ")), val)
+	}
+	fmt.Println()
+}
+
+// parseRegistryAgent parses name[@version] and validates the version when there is one
+func parseRegistryAgent(val string) (string, string) {
+	name, version, _ := strings.Cut(val, "@")
+	if version != "" {
+		if _, err := semver.NewVersion(version); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("The version %s is not a valid semantic version", version)).ShowErrorAndExit()
 		}
-		return true
 	}
-	return false
+	return name, version
 }
 
-var agentListCmd = &cobra.Command{
-	Use:     "list",
-	Short:   "List all Agents in the project",
-	Aliases: []string{"ls"},
+var agentUnpublishCmd = &cobra.Command{
+	Use:   "unpublish <name@version>",
+	Short: "Remove a published version of an Agent from the community registry",
+	Long: `Remove a published version of an Agent from the community registry.
+
+The version can't be imported anymore but projects which already imported it
+keep their copy. A version which was unpublished can't be published again, so
+prefer agentuity agent deprecate for a version which shouldn't be used anymore.
+
+Arguments:
+  <name@version>    The registry name of the Agent and the version to remove
+
+Flags:
+  --org-id    The organization which published the Agent
+  --force     Don't prompt for confirmation
+
+Examples:
+  agentuity agent unpublish order-lookup@1.0.1`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		project := project.EnsureProject(ctx, cmd)
-		urls := util.GetURLs(logger)
-		apiUrl := urls.API
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		name, version := parseRegistryAgent(args[0])
+		if version == "" {
+			errsystem.New(errsystem.ErrMissingRequiredArgument, fmt.Errorf("missing version"), errsystem.WithUserMessage("The version to unpublish is required, for example %s@1.0.0", name)).ShowErrorAndExit()
+		}
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please use --force to unpublish without confirmation")
+			}
+			if !tui.Ask(logger, fmt.Sprintf("Unpublish %s %s? The version can't be published again.", name, version), false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		var err error
+		tui.ShowSpinner("Unpublishing Agent ...", func() {
+			err = agent.UnpublishAgent(ctx, logger, apiUrl, apikey, orgId, name, version)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to unpublish the Agent")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Unpublished %s %s", name, version)
+	},
+}
 
-		// perform the reconcilation
-		keys, state := reconcileAgentList(logger, cmd, apiUrl, project.Token, project)
+var agentDeprecateCmd = &cobra.Command{
+	Use:   "deprecate <name[@version]>",
+	Short: "Deprecate a published Agent in the community registry",
+	Long: `Deprecate a published Agent in the community registry.
 
-		if len(keys) == 0 {
-			tui.ShowWarning("no Agents found")
-			tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
+A deprecated version can still be imported but the message is shown to
+everyone who imports it. Without a version, every version is deprecated.
+
+Arguments:
+  <name[@version]>    The registry name of the Agent and optionally the version
+
+Flags:
+  --message   The message shown to the users of the Agent (required)
+  --undo      Remove the deprecation
+  --org-id    The organization which published the Agent
+
+Examples:
+  agentuity agent deprecate order-lookup@1.0.1 --message "Has a bug in the refund flow, use 1.0.2"
+  agentuity agent deprecate order-lookup --message "Use order-assistant instead"
+  agentuity agent deprecate order-lookup@1.0.1 --undo`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		name, version := parseRegistryAgent(args[0])
+		message, _ := cmd.Flags().GetString("message")
+		undo, _ := cmd.Flags().GetBool("undo")
+		if undo && message != "" {
+			errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--undo and --message"), errsystem.WithUserMessage("The --undo and --message flags cannot be used together")).ShowErrorAndExit()
+		}
+		if !undo && strings.TrimSpace(message) == "" {
+			errsystem.New(errsystem.ErrMissingRequiredArgument, fmt.Errorf("missing message"), errsystem.WithUserMessage("A --message for the users of the Agent is required")).ShowErrorAndExit()
+		}
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		var versions []agent.PublishedVersion
+		var err error
+		tui.ShowSpinner("Updating Agent ...", func() {
+			versions, err = agent.DeprecateAgent(ctx, logger, apiUrl, apikey, orgId, name, version, strings.TrimSpace(message))
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to deprecate the Agent")).ShowErrorAndExit()
+		}
+		if undo {
+			tui.ShowSuccess("Removed the deprecation of %s of %s", util.Pluralize(len(versions), "version", "versions"), name)
 			return
 		}
+		tui.ShowSuccess("Deprecated %s of %s", util.Pluralize(len(versions), "version", "versions"), name)
+	},
+}
+
+// agentRelativeDir returns the source directory of the agent relative to the project directory with
+// forward slashes like the files of the bundle of a deployment
+func agentRelativeDir(theproject project.ProjectContext, name string) string {
+	dir, err := filepath.Rel(theproject.Dir, agentSourceDir(theproject, name))
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to find the agent directory")).ShowErrorAndExit()
+	}
+	return filepath.ToSlash(dir)
+}
+
+var agentHistoryCmd = &cobra.Command{
+	Use:   "history [agent]",
+	Short: "List the deployments in which an agent changed",
+	Long: `List the deployments in which the source of an agent changed.
+
+The digests of the files of each deployment in the directory of the Agent are
+compared with the previous deployment, so deployments which only changed other
+Agents aren't listed. Deployments without the digests of their files are skipped.
+
+Arguments:
+  [agent]      The name or ID of the agent, prompts for one if not provided
+
+Flags:
+  --limit      The number of most recent deployments to look at
+  --format     The output format (text or json)
 
+Examples:
+  agentuity agent history my-agent
+  agentuity agent history my-agent --limit 50 --format json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		limit, _ := cmd.Flags().GetInt("limit")
 		format, _ := cmd.Flags().GetString("format")
-		if format == "json" {
-			json.NewEncoder(os.Stdout).Encode(state)
-		} else {
-			root, localIssues, remoteIssues, err := buildAgentTree(keys, state, project)
+
+		theagent := selectProjectAgent(logger, theproject, args, "Select the agent to show the history of")
+		dir := agentRelativeDir(theproject, theagent.Name)
+
+		var details []project.DeploymentDetail
+		tui.ShowSpinner("Fetching deployments ...", func() {
+			deployments, err := project.ListDeployments(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
 			if err != nil {
-				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to build agent tree")).ShowErrorAndExit()
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
 			}
-			fmt.Println(root)
-			if showAgentWarnings(remoteIssues, localIssues, false) {
-				os.Exit(1)
+			sort.SliceStable(deployments, func(i, j int) bool {
+				return deployments[i].CreatedAt > deployments[j].CreatedAt
+			})
+			if limit > 0 && len(deployments) > limit {
+				deployments = deployments[:limit]
 			}
-		}
+			for _, d := range deployments {
+				detail, err := project.GetDeployment(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, d.ID)
+				if err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+				}
+				details = append(details, *detail)
+			}
+		})
 
+		history := project.AgentHistory(details, dir)
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(history)
+			return
+		}
+		if len(history) == 0 {
+			tui.ShowWarning("No deployments of the Agent %s were found", theagent.Name)
+			return
+		}
+		rows := make([][]string, 0, len(history))
+		for _, h := range history {
+			id := h.DeploymentID
+			if h.Active {
+				id += tui.Muted(" (active)")
+			}
+			files := fmt.Sprintf("+%d -%d ~%d", len(h.Files.Added), len(h.Files.Removed), len(h.Files.Changed))
+			rows = append(rows, []string{id, h.Change, files, strings.Join(h.Tags, ", "), h.Message, h.CreatedAt})
+		}
+		tui.Table([]string{"Deployment", "Change", "Files", "Tags", "Message", "Created At"}, rows)
 	},
 }
 
-var agentGetApiKeyCmd = &cobra.Command{
-	Use:   "apikey [agent_name]",
-	Short: "Get the API key for an agent",
-	Long: `Get the API key for an agent by name or ID.
+var agentDiffCmd = &cobra.Command{
+	Use:   "diff [agent]",
+	Short: "Compare the local source of an agent with a deployment",
+	Long: `Show a unified diff between the local source of an agent and its source in a
+deployment. Only the files in the directory of the Agent are compared.
 
 Arguments:
-  [agent_name]  The name or ID of the agent to get the API key for
+  [agent]          The name or ID of the agent, prompts for one if not provided
 
-If no agent name is provided, you will be prompted to select an agent.
+Flags:
+  --deployment     The deployment id or tag to compare with, defaults to the active deployment
+  --name-only      Only list the names of the changed files
 
 Examples:
-  agentuity agent apikey "My Agent"
-  agentuity agent apikey agent_ID
-  agentuity agent apikey`,
-	Args:    cobra.MaximumNArgs(1),
-	Aliases: []string{"key"},
+  agentuity agent diff my-agent
+  agentuity agent diff my-agent --deployment deploy_123
+  agentuity agent diff my-agent --deployment staging --name-only`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		project := project.EnsureProject(ctx, cmd)
-		urls := util.GetURLs(logger)
-		apiUrl := urls.API
-
-		// perform the reconcilation
-		keys, state := reconcileAgentList(logger, cmd, apiUrl, project.Token, project)
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		deploymentId, _ := cmd.Flags().GetString("deployment")
+		nameOnly, _ := cmd.Flags().GetBool("name-only")
 
-		if len(keys) == 0 {
-			tui.ShowWarning("no Agents found")
-			tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
-			return
-		}
+		theagent := selectProjectAgent(logger, theproject, args, "Select the agent to compare")
+		dir := agentRelativeDir(theproject, theagent.Name)
 
-		var agentID string
-		var theagent *agentListState
-		if len(args) > 0 {
-			agentName := args[0]
-			for _, v := range state {
-				if v.Agent.ID == agentName || v.Agent.Name == agentName {
-					theagent = &v
-					agentID = v.Agent.ID
-					break
-				}
+		var deployment *project.DeploymentDetail
+		tui.ShowSpinner("Fetching deployment ...", func() {
+			deployments, err := project.ListDeployments(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
 			}
-		}
-		if theagent == nil {
-			if len(state) == 1 {
-				for _, v := range state {
-					theagent = &v
-					agentID = v.Agent.ID
-					break
-				}
+			if deploymentId != "" {
+				deploymentId = resolveDeployment(deployments, deploymentId)
 			} else {
-				if !tui.HasTTY {
-					logger.Fatal("No TTY detected, please specify an Agent name or id")
-				}
-				var options []tui.Option
-				for _, v := range keys {
-					options = append(options, tui.Option{
-						ID:   state[v].Agent.ID,
-						Text: tui.PadRight(state[v].Agent.Name, 20, " ") + tui.Muted(state[v].Agent.ID),
-					})
-				}
-				selected := tui.Select(logger, "Select an Agent", "Select the Agent you want to get the API key for", options)
-				for _, v := range state {
-					if v.Agent.ID == selected {
-						theagent = &v
-						break
+				for _, d := range deployments {
+					if d.Active {
+						deploymentId = d.ID
 					}
 				}
+				if deploymentId == "" {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no active deployment"),
+						errsystem.WithUserMessage("The project has no active deployment, please specify the deployment with --deployment")).ShowErrorAndExit()
+				}
 			}
+			deployment, err = project.GetDeployment(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, deploymentId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+		})
+		if deployment.Files == nil {
+			errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("deployment %s has no file digests", deployment.ID),
+				errsystem.WithUserMessage("The deployment %s doesn't have the digests of its files so it can't be compared", deployment.ID)).ShowErrorAndExit()
 		}
-		if theagent == nil {
-			tui.ShowWarning("Agent not found")
-			return
-		}
-		apikey, err := agent.GetApiKey(context.Background(), logger, apiUrl, project.Token, theagent.Agent.ID, theagent.Agent.Types[0])
+
+		deployed := project.AgentFiles(deployment.Files, dir)
+		localFiles, err := agent.SourceFiles(theproject.Dir, filepath.Join(theproject.Dir, filepath.FromSlash(dir)))
 		if err != nil {
-			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+			errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to list the agent files")).ShowErrorAndExit()
 		}
-		if !tui.HasTTY {
-			if apikey != "" {
-				fmt.Print(apikey)
-				return
+		changed := make(map[string]bool)
+		for _, fn := range localFiles {
+			digest, err := deployer.HashFile(filepath.Join(theproject.Dir, filepath.FromSlash(fn)))
+			if err != nil {
+				errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to read the agent files")).ShowErrorAndExit()
+			}
+			if d, ok := deployed[fn]; !ok || !agent.SameDigest(d, digest) {
+				changed[fn] = true
 			}
 		}
-		if apikey != "" {
-			fmt.Println()
-			tui.ShowLock("Agent %s API key: %s", theagent.Agent.Name, apikey)
-			tip := fmt.Sprintf(`$(agentuity agent apikey %s)`, agentID)
-			tui.ShowBanner("Developer Pro Tip", tui.Paragraph("Fetch your Agent's API key into a shell command dynamically:", tip), false)
+		for fn := range deployed {
+			if !slices.Contains(localFiles, fn) {
+				changed[fn] = true
+			}
+		}
+		if len(changed) == 0 {
+			tui.ShowSuccess("The Agent %s is the same as in the deployment %s", theagent.Name, deployment.ID)
 			return
-		} else {
-			tui.ShowWarning("No API key found for Agent %s (%s)", theagent.Agent.Name, theagent.Agent.ID)
 		}
-		os.Exit(1) // no key
+		names := slices.Sorted(maps.Keys(changed))
+		if nameOnly {
+			for _, fn := range names {
+				fmt.Println(fn)
+			}
+			return
+		}
+
+		for _, fn := range names {
+			var before, after []byte
+			if _, ok := deployed[fn]; ok {
+				tui.ShowSpinner(fmt.Sprintf("Fetching %s ...", fn), func() {
+					before, err = project.GetDeploymentFile(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, deployment.ID, fn)
+				})
+				if err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployed file")).ShowErrorAndExit()
+				}
+			}
+			if slices.Contains(localFiles, fn) {
+				after, err = os.ReadFile(filepath.Join(theproject.Dir, filepath.FromSlash(fn)))
+				if err != nil {
+					errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to read the agent file")).ShowErrorAndExit()
+				}
+			}
+			printUnifiedDiff(agent.SourceDiff(fn, before, after))
+		}
 	},
 }
 
-var agentTestCmd = &cobra.Command{
-	Use:   "test",
-	Short: "Test an agent",
-	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+var agentGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete the cloud agents which are no longer used",
+	Long: `Find the Agents in the cloud whose source is neither in the local project nor in
+any of the recent deployments and offer to delete them.
 
+The Agents of the project file and the agent directories are reconciled with the
+Agents in the cloud, and the Agents of the most recent deployments and of the
+active deployments are kept. The orphaned Agents are listed and, unless --dry-run
+is provided, the selected ones are deleted from the cloud and the project file.
+
+Flags:
+  --deployments   The number of most recent deployments whose Agents are kept
+  --dry-run       List the orphaned Agents without deleting them
+  --force         Delete all the orphaned Agents without asking for confirmation
+  --format        The format of the list of --dry-run (text or json)
+
+Examples:
+  agentuity agent gc --dry-run
+  agentuity agent gc --deployments 50
+  agentuity agent gc --force`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
+		logger := util.NewLogger(cmd)
 		theproject := project.EnsureProject(ctx, cmd)
+		limit, _ := cmd.Flags().GetInt("deployments")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		format, _ := cmd.Flags().GetString("format")
 
-		agentID, _ := cmd.Flags().GetString("agent-id")
-		payload, _ := cmd.Flags().GetString("payload")
-		local, _ := cmd.Flags().GetBool("local")
-		contentType, _ := cmd.Flags().GetString("content-type")
-		tag, _ := cmd.Flags().GetString("tag")
-		var selectedAgent *agent.Agent
-		if agentID == "" {
-			keys, state := reconcileAgentList(logger, cmd, theproject.APIURL, theproject.Token, theproject)
-			if len(keys) == 0 {
-				tui.ShowWarning("no Agents found")
-				tui.ShowBanner("Create a new Agent", tui.Text("Use the ")+tui.Command("agent new")+tui.Text(" command to create a new Agent"), false)
-				return
-			}
-			var options []tui.Option
-			for _, v := range keys {
-				options = append(options, tui.Option{
-					ID:   v,
-					Text: tui.PadRight(state[v].Agent.Name, 20, " ") + tui.Muted(state[v].Agent.ID),
-				})
+		if !dryRun {
+			if !force && !tui.HasTTY {
+				logger.Fatal("No TTY detected, please use --force to delete the orphaned Agents or --dry-run to list them")
 			}
-			selected := tui.Select(logger, "Select an agent", "Select the agent you want to test", options)
-			selectedAgent = state[selected].Agent
-			agentID = selectedAgent.ID
+			ensurePermission(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, auth.PermissionAgentDelete, "delete agents")
 		}
 
-		if len(selectedAgent.Types) == 0 {
-			// this should never ever happen
-			tui.ShowError("Agent %s has no running types (webhook or api)", selectedAgent.Name)
-			os.Exit(1)
-		}
-		var route string
-		if len(selectedAgent.Types) > 1 {
-			options := []tui.Option{}
-			for _, route := range selectedAgent.Types {
-				options = append(options, tui.Option{
-					ID:   route,
-					Text: route,
-				})
+		keys, state := reconcileAgentList(logger, cmd, theproject.APIURL, theproject.Token, theproject)
+		local := make(map[string]bool)
+		for _, st := range state {
+			if st.FoundLocal && st.Agent.ID != "" {
+				local[st.Agent.ID] = true
 			}
-			route = tui.Select(logger, "Select an running type", "Select the running type you want to use", options)
-		} else {
-			route = selectedAgent.Types[0]
 		}
 
-		if payload == "" {
-			payload = tui.Input(logger, "Enter the payload to send to the agent", "{\"hello\": \"world\"}")
+		var orphans []agent.Agent
+		var err error
+		tui.ShowSpinner("Looking for orphaned Agents ...", func() {
+			var remoteAgents []agent.Agent
+			remoteAgents, err = agent.ListAgents(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				err = fmt.Errorf("failed to list the agents: %w", err)
+				return
+			}
+			var deployments []project.DeploymentListData
+			deployments, err = project.ListDeployments(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				return
+			}
+			sort.SliceStable(deployments, func(i, j int) bool {
+				return deployments[i].CreatedAt > deployments[j].CreatedAt
+			})
+			var details []project.DeploymentDetail
+			for i, d := range deployments {
+				// the agents of an active deployment are in use however old it is
+				if i >= limit && !d.Active {
+					continue
+				}
+				var detail *project.DeploymentDetail
+				detail, err = project.GetDeployment(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, d.ID)
+				if err != nil {
+					return
+				}
+				details = append(details, *detail)
+			}
+			orphans, err = agent.FindOrphanedAgents(remoteAgents, local, details)
+		})
+		if err != nil {
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to find the orphaned Agents")).ShowErrorAndExit()
 		}
 
-		apikey, err := agent.GetApiKey(context.Background(), logger, theproject.APIURL, theproject.Token, agentID, route)
-		if err != nil {
-			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get agent API key")).ShowErrorAndExit()
+		if dryRun && format == "json" {
+			if orphans == nil {
+				orphans = []agent.Agent{}
+			}
+			json.NewEncoder(os.Stdout).Encode(orphans)
+			return
 		}
-		endpoint := fmt.Sprintf("%s/%s/%s", theproject.TransportURL, route, agentID)
-		if local {
-			port, _ := dev.FindAvailablePort(theproject, 0)
-			endpoint = fmt.Sprintf("http://127.0.0.1:%d/%s", port, agentID)
+		if len(orphans) == 0 {
+			tui.ShowSuccess("No orphaned Agents found")
+			return
 		}
-
-		if tag != "" {
-			endpoint = fmt.Sprintf("%s/%s", endpoint, tag)
+		rows := make([][]string, 0, len(orphans))
+		for _, a := range orphans {
+			rows = append(rows, []string{tui.Bold(a.Name), tui.Muted(a.ID), a.Description})
 		}
-
-		// use http package to send a POST request to the agent
-		req, err := http.NewRequest("POST", endpoint, strings.NewReader(payload))
-		if err != nil {
-			logger.Fatal("Failed to create request: %s", err)
+		tui.ShowWarning("%s not in the project nor in the last %s:", util.Pluralize(len(orphans), "Agent is", "Agents are"), util.Pluralize(limit, "deployment", "deployments"))
+		tui.Table([]string{"Name", "ID", "Description"}, rows)
+		if dryRun {
+			fmt.Println(tui.Muted("Run without --dry-run to delete them"))
+			return
 		}
-		if contentType == "" {
-			// check if payload is json
-			if json.Valid([]byte(payload)) {
-				req.Header.Set("Content-Type", "application/json")
-			} else {
-				req.Header.Set("Content-Type", "text/plain")
+
+		var selected []string
+		if force {
+			for _, a := range orphans {
+				selected = append(selected, a.ID)
 			}
 		} else {
-			req.Header.Set("Content-Type", contentType)
-		}
-		if apikey != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apikey))
+			var options []tui.Option
+			for _, a := range orphans {
+				options = append(options, tui.Option{ID: a.ID, Text: tui.PadRight(a.Name, 20, " ") + tui.Muted(a.ID), Selected: true})
+			}
+			selected = tui.MultiSelect(logger, "Select the Agents to delete from Agentuity Cloud", "Toggle selection by pressing the spacebar\nPress enter to confirm\n", options)
+			if len(selected) == 0 {
+				tui.ShowWarning("no Agents selected")
+				return
+			}
 		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			logger.Fatal("Failed to send request: %s", err)
-		}
-		defer resp.Body.Close()
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			logger.Fatal("Failed to read response: %s", err)
+		deleted := deleteAgents(logger, theproject, keys, state, selected, force)
+		if deleted == nil {
+			return
 		}
-		var jsonBody map[string]interface{}
-		if json.Unmarshal(body, &jsonBody) == nil {
-			stringified, _ := json.MarshalIndent(jsonBody, "", "  ")
-			tui.ShowSuccess("Agent Test: %s", tui.Paragraph(tui.Bold(string(stringified))))
-		} else {
-			tui.ShowSuccess("Agent Test: %s", tui.Paragraph(tui.Bold(string(body))))
+		if len(deleted) < len(selected) {
+			errsystem.New(errsystem.ErrOperationPartiallyCompleted, fmt.Errorf("failed to delete %d agents", len(selected)-len(deleted)),
+				errsystem.WithUserMessage("%s deleted but %d could not be deleted", util.Pluralize(len(deleted), "Agent was", "Agents were"), len(selected)-len(deleted))).ShowErrorAndExit()
 		}
+		tui.ShowSuccess("%s deleted successfully", util.Pluralize(len(deleted), "orphaned Agent", "orphaned Agents"))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(agentCmd)
 	agentCmd.AddCommand(agentCreateCmd)
+	agentCmd.AddCommand(agentImportCmd)
 	agentCmd.AddCommand(agentListCmd)
 	agentCmd.AddCommand(agentDeleteCmd)
 	agentCmd.AddCommand(agentGetApiKeyCmd)
+	agentGetApiKeyCmd.AddCommand(agentApiKeyGetCmd)
+	agentGetApiKeyCmd.AddCommand(agentApiKeyRotateCmd)
+	agentGetApiKeyCmd.AddCommand(agentApiKeyExpireCmd)
+
+	agentApiKeyRotateCmd.Flags().Duration("ttl", 0, "The duration the new API key is valid for (e.g. 720h). Defaults to no expiration")
+	agentApiKeyExpireCmd.Flags().Duration("ttl", 0, "The duration until the API key expires (e.g. 24h). Defaults to immediately")
+	for _, cmd := range []*cobra.Command{agentApiKeyRotateCmd, agentApiKeyExpireCmd} {
+		cmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	}
 
 	agentTestCmd.Flags().String("agent-id", "", "The ID of the agent to test")
 	agentTestCmd.Flags().String("payload", "", "The payload to send to the agent")
+	agentTestCmd.Flags().String("file", "", "The file to send as the payload, or - for stdin")
+	agentTestCmd.Flags().StringArray("form", nil, "A form field to send as key=value, or key=@path to attach a file")
+	agentTestCmd.Flags().Bool("base64", false, "Send the payload base64 encoded")
+	agentTestCmd.Flags().StringP("output", "o", "", "Write the response to the file, or - for stdout")
+	agentTestCmd.Flags().Bool("output-binary", false, "Write a binary response to stdout even when it's a terminal")
 	agentTestCmd.Flags().Bool("local", false, "Enable local testing")
+	agentTestCmd.Flags().Int("port", 0, "The port of the local development server (defaults to the port of the running development server)")
 	agentTestCmd.Flags().String("content-type", "", "The content type to use for the request, will try to detect if not provided")
 	agentTestCmd.Flags().String("tag", "", "The tag to use for the deployment")
+	agentTestCmd.Flags().Bool("snapshot", false, "Compare the response with the stored snapshot, recording it if there isn't one")
+	agentTestCmd.Flags().Bool("update", false, "Update the stored snapshot with the response (implies --snapshot)")
+	agentTestCmd.Flags().String("snapshot-name", "", "The name of the snapshot (defaults to a hash of the payload)")
+	agentTestCmd.Flags().StringSlice("agents", nil, "Send the payload to several agents by name or ID")
+	agentTestCmd.Flags().Bool("all", false, "Send the payload to all the agents of the project")
+	agentTestCmd.Flags().Bool("coverage", false, "Run the agents locally and write the lcov and Cobertura reports of their coverage")
+	agentTestCmd.Flags().String("coverage-dir", dev.DefaultCoverageDir, "The directory to write the coverage reports to")
 	agentCmd.AddCommand(agentTestCmd)
+	agentCmd.AddCommand(agentCallCmd)
+	agentCmd.AddCommand(agentEnqueueCmd)
+	agentCmd.AddCommand(agentLoadtestCmd)
+	agentCmd.AddCommand(agentSetCmd)
+
+	agentEnqueueCmd.Flags().String("payload", "", "The payload to send to the agent")
+	agentEnqueueCmd.Flags().String("file", "", "The file to send as the payload, or - for stdin")
+	agentEnqueueCmd.Flags().StringArray("form", nil, "A form field to send as key=value, or key=@path to attach a file")
+	agentEnqueueCmd.Flags().String("content-type", "", "The content type of the payload, will try to detect if not provided")
+	agentEnqueueCmd.Flags().String("tag", "", "The tag of the deployment to run")
+	agentEnqueueCmd.Flags().Bool("wait", false, "Wait for the job to complete and write its result")
+	addJobWaitFlags(agentEnqueueCmd)
+	agentEnqueueCmd.Flags().String("format", "text", "The output format (text or json)")
+
+	agentCallCmd.Flags().String("payload", "", "The payload to send to the agent")
+	agentCallCmd.Flags().String("content-type", "", "The content type to use for the request, will try to detect if not provided")
+	agentCallCmd.Flags().String("tag", "", "The tag to use for the deployment")
+
+	agentSetCmd.Flags().String("description", "", "The new description of the Agent")
+	agentSetCmd.Flags().StringSlice("tags", nil, "The tags of the Agent, replacing the current tags")
+	agentSetCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
 
-	for _, cmd := range []*cobra.Command{agentListCmd, agentCreateCmd, agentDeleteCmd, agentGetApiKeyCmd, agentTestCmd} {
+	for _, cmd := range []*cobra.Command{agentListCmd, agentCreateCmd, agentImportCmd, agentDeleteCmd, agentGetApiKeyCmd, agentApiKeyGetCmd, agentApiKeyRotateCmd, agentApiKeyExpireCmd, agentTestCmd, agentLoadtestCmd, agentSetCmd} {
 		cmd.Flags().StringP("dir", "d", "", "The project directory")
 		cmd.Flags().String("templates-dir", "", "The directory to load the templates. Defaults to loading them from the github.com/agentuity/templates repository")
 	}
-	for _, cmd := range []*cobra.Command{agentListCmd, agentCreateCmd} {
+	for _, cmd := range []*cobra.Command{agentListCmd, agentCreateCmd, agentLoadtestCmd} {
 		cmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
 	}
 	agentListCmd.Flags().String("org-id", "", "The organization to create the project in on import")
+	agentListCmd.Flags().BoolP("interactive", "i", false, "Select Agents from the list to run actions on them")
 	for _, cmd := range []*cobra.Command{agentCreateCmd, agentDeleteCmd} {
 		cmd.Flags().Bool("force", false, "Force the creation of the agent even if it already exists")
 	}
+	agentLoadtestCmd.Flags().Float64("rps", 10, "The target number of requests per second")
+	agentLoadtestCmd.Flags().Duration("duration", 30*time.Second, "How long to run the load test")
+	agentLoadtestCmd.Flags().String("payload", "", "The payload to send to the agent")
+	agentLoadtestCmd.Flags().String("payload-file", "", "The file containing the payload to send to the agent")
+	agentLoadtestCmd.Flags().String("content-type", "", "The content type to use for the requests, will try to detect if not provided")
+	agentLoadtestCmd.Flags().String("profile", "constant", "The ramp profile which can be constant, linear or step")
+	agentLoadtestCmd.Flags().Duration("ramp-up", 0, "The time to ramp up to the target rate for the linear and step profiles")
+	agentLoadtestCmd.Flags().Int("steps", 5, "The number of steps for the step profile")
+	agentLoadtestCmd.Flags().Int("concurrency", 200, "The maximum number of requests in flight")
+	agentLoadtestCmd.Flags().Duration("timeout", 60*time.Second, "The timeout for each request")
+	agentLoadtestCmd.Flags().String("export", "", "Export the results to a .json or .csv file")
+	agentLoadtestCmd.Flags().Bool("local", false, "Send the requests to the local development server")
+	agentLoadtestCmd.Flags().Int("port", 0, "The port of the local development server (defaults to the port of the running development server)")
+	agentLoadtestCmd.Flags().String("tag", "", "The deployment tag to send the requests to")
+	agentLoadtestCmd.Flags().Bool("force", false, "Don't ask for confirmation before load testing a deployed agent")
 
+	agentCreateCmd.Flags().String("from-openapi", "", "Generate the agent from an OpenAPI specification or JSON Schema file")
+	agentCreateCmd.Flags().String("operation", "", "The operationId in the OpenAPI specification to generate the agent for")
+	agentCreateCmd.Flags().String("from", "", "The name or id of an existing agent of the project to copy")
+	for _, cmd := range []*cobra.Command{agentCreateCmd, agentImportCmd} {
+		cmd.Flags().Bool("plan", false, "Show the changes without making them")
+	}
+
+	agentImportCmd.Flags().StringSlice("agent", nil, "The name of an agent to import (can be repeated)")
+	agentImportCmd.Flags().Bool("all", false, "Import all the agents found in the source")
+	agentImportCmd.Flags().String("auth-type", "", "The authentication type of the imported agents (project, bearer or none)")
+	agentImportCmd.Flags().Int("concurrency", agent.DefaultFetchConcurrency, "The maximum number of concurrent requests to GitHub")
+	agentImportCmd.Flags().Bool("git", false, "Fetch the files with a shallow, sparse git clone which supports any git host")
+	agentImportCmd.Flags().String("ref", "", "The branch, tag or commit to import from")
+	agentImportCmd.Flags().String("path", "", "The directory of the repository which contains the agents")
+	agentImportCmd.Flags().Bool("no-deps", false, "Don't add the dependencies of the imported agents to the project")
+	agentImportCmd.Flags().String("env-values", "", "A .env file with the values of the environment variables used by the imported agents")
+	agentImportCmd.Flags().Bool("push-env", false, "Set the environment variables of the imported agents in the cloud project as well as the .env file")
+	agentImportCmd.Flags().String("format", "text", "The format of the plan. Can be either 'text' or 'json'")
+
+	agentCmd.AddCommand(agentPublishCmd)
+	agentCmd.AddCommand(agentUnpublishCmd)
+	agentCmd.AddCommand(agentDeprecateCmd)
+	agentCmd.AddCommand(agentHistoryCmd)
+	agentCmd.AddCommand(agentDiffCmd)
+
+	for _, cmd := range []*cobra.Command{agentHistoryCmd, agentDiffCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+	}
+	agentHistoryCmd.Flags().Int("limit", 20, "The number of most recent deployments to look at")
+	agentCmd.AddCommand(agentGCCmd)
+	agentCmd.AddCommand(agentRestoreCmd)
+	agentRestoreCmd.Flags().StringP("dir", "d", "", "The project directory")
+	agentRestoreCmd.Flags().String("backup", "", "The ID of the backup to restore from")
+	agentRestoreCmd.Flags().Bool("force", false, "Replace the files of the Agent if its directory already exists")
+	agentRestoreCmd.Flags().String("format", "text", "The format of the list of backups. Can be either 'text' or 'json'")
+	agentGCCmd.Flags().StringP("dir", "d", "", "The project directory")
+	agentGCCmd.Flags().String("templates-dir", "", "The directory to load the templates. Defaults to loading them from the github.com/agentuity/templates repository")
+	agentGCCmd.Flags().Int("deployments", 10, "The number of most recent deployments whose agents are kept")
+	agentGCCmd.Flags().Bool("dry-run", false, "List the orphaned agents without deleting them")
+	agentGCCmd.Flags().Bool("force", false, "Delete all the orphaned agents without asking for confirmation")
+	agentGCCmd.Flags().String("format", "text", "The format of the list of --dry-run. Can be either 'text' or 'json'")
+	agentHistoryCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	agentDiffCmd.Flags().String("deployment", "", "The deployment id or tag to compare with, defaults to the active deployment")
+	agentDiffCmd.Flags().Bool("name-only", false, "Only list the names of the changed files")
+
+	agentPublishCmd.Flags().StringP("dir", "d", "", "The project directory")
+	agentPublishCmd.Flags().String("version", "", "The version to publish (overrides the version in the manifest)")
+	agentPublishCmd.Flags().String("bump", "", "Publish the next major, minor or patch version after the latest published version")
+	agentPublishCmd.Flags().String("description", "", "The description of the Agent (overrides the description in the manifest)")
+	agentPublishCmd.Flags().String("visibility", "", "Who can find and import the Agent: public, unlisted or private (defaults to public)")
+	agentPublishCmd.Flags().String("pricing", "", "Whether the Agent is free or paid (defaults to free)")
+	agentPublishCmd.Flags().Bool("dry-run", false, "Validate and package the Agent without publishing it")
+	agentPublishCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	agentPublishCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	agentUnpublishCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	agentDeprecateCmd.Flags().String("message", "", "The message shown to the users of the Agent")
+	agentDeprecateCmd.Flags().Bool("undo", false, "Remove the deprecation")
+	for _, cmd := range []*cobra.Command{agentPublishCmd, agentUnpublishCmd, agentDeprecateCmd} {
+		cmd.Flags().String("org-id", "", "The organization which publishes the Agent")
+	}
 }
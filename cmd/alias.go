@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// aliasesKey is the key of the aliases in the config file
+const aliasesKey = "aliases"
+
+// getAliases returns the aliases of the config file by name
+func getAliases() map[string]string {
+	return viper.GetStringMapString(aliasesKey)
+}
+
+func saveAliases(aliases map[string]string) {
+	viper.Set(aliasesKey, aliases)
+	if err := viper.WriteConfig(); err != nil {
+		errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the aliases")).ShowErrorAndExit()
+	}
+}
+
+// findCommand returns the command of the CLI which the arguments invoke or nil when the first
+// argument isn't a command
+func findCommand(args []string) *cobra.Command {
+	if len(args) == 0 || args[0] == "help" || args[0] == "completion" {
+		return rootCmd
+	}
+	found, _, err := rootCmd.Find(args)
+	if err != nil || found == rootCmd {
+		return nil
+	}
+	return found
+}
+
+// expandAlias returns the arguments of the command when the first argument is an alias. The
+// commands of the CLI always take precedence over the aliases.
+func expandAlias(args []string) ([]string, bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || findCommand(args[:1]) != nil {
+		return nil, false, nil
+	}
+	// the config is loaded when the command is executed, after the alias has to be expanded
+	initConfig()
+	expansion, ok := getAliases()[strings.ToLower(args[0])]
+	if !ok {
+		return nil, false, nil
+	}
+	expanded, err := util.ExpandAlias(expansion, args[1:])
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to expand alias %s: %w", args[0], err)
+	}
+	return expanded, true, nil
+}
+
+var aliasCmd = &cobra.Command{
+	Use:     "alias",
+	Aliases: []string{"aliases"},
+	Args:    cobra.NoArgs,
+	Short:   "Manage command aliases",
+	Long: `Manage command aliases.
+
+An alias is a shortcut for a command and its flags which is saved in the config
+file. When the alias is invoked, the placeholders $1, $2, ... of the expansion
+are replaced with the arguments of the alias and the other arguments are added
+to the end of the command.
+
+Use the subcommands to set, list and delete aliases.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set [name] [expansion]",
+	Args:  cobra.ExactArgs(2),
+	Short: "Create or change an alias",
+	Long: `Create or change an alias.
+
+The expansion is the command without the agentuity prefix and is split into
+arguments the way a shell would, so quote it. The name can't be the name of a
+command and the expansion must start with a command.
+
+Arguments:
+  [name]         The name of the alias
+  [expansion]    The command the alias expands to
+
+Examples:
+  agentuity alias set dp "deploy --tag preview"
+  agentuity alias set release 'deploy --tag $1 --message "release $1"'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, expansion := strings.ToLower(args[0]), args[1]
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\n") {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid alias name %q", args[0]),
+				errsystem.WithUserMessage("The alias name can't be empty, contain spaces or start with a dash")).ShowErrorAndExit()
+		}
+		if findCommand([]string{name}) != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s is a command", name),
+				errsystem.WithUserMessage("The alias %s can't be the name of a command", name)).ShowErrorAndExit()
+		}
+		words, err := util.ParseAlias(expansion)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("The alias expansion is invalid: %s", err)).ShowErrorAndExit()
+		}
+		if findCommand(words) == nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s is not a command", words[0]),
+				errsystem.WithUserMessage("The alias expansion must start with a command of the CLI and %s is not a command", words[0])).ShowErrorAndExit()
+		}
+		aliases := getAliases()
+		previous, exists := aliases[name]
+		aliases[name] = expansion
+		saveAliases(aliases)
+		if exists && previous != expansion {
+			tui.ShowSuccess("Changed alias %s from %s to %s", tui.Bold(name), tui.Command(previous), tui.Command(expansion))
+			return
+		}
+		tui.ShowSuccess("Alias %s expands to %s", tui.Bold(name), tui.Command(expansion))
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	Short:   "List the aliases",
+	Long: `List the aliases.
+
+Flags:
+  --format    The format to use for the output. Can be either 'text' or 'json'
+
+Examples:
+  agentuity alias list
+  agentuity alias list --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		aliases := getAliases()
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(aliases)
+			return
+		}
+		if len(aliases) == 0 {
+			tui.ShowWarning("No aliases found")
+			tui.ShowBanner("Create an alias", tui.Text("Use ")+tui.Command("alias set dp \"deploy --tag preview\"")+tui.Text(" to create an alias"), false)
+			return
+		}
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rows := make([][]string, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, []string{tui.Bold(name), aliases[name]})
+		}
+		tui.Table([]string{"Alias", "Expansion"}, rows)
+	},
+}
+
+var aliasDeleteCmd = &cobra.Command{
+	Use:     "delete [name]",
+	Aliases: []string{"del", "rm"},
+	Args:    cobra.ExactArgs(1),
+	Short:   "Delete an alias",
+	Long: `Delete an alias.
+
+Arguments:
+  [name]    The name of the alias
+
+Examples:
+  agentuity alias delete dp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.ToLower(args[0])
+		aliases := getAliases()
+		if _, ok := aliases[name]; !ok {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("alias %s not found", name),
+				errsystem.WithUserMessage("There is no alias named %s", name)).ShowErrorAndExit()
+		}
+		delete(aliases, name)
+		saveAliases(aliases)
+		tui.ShowSuccess("Deleted alias %s", tui.Bold(name))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasDeleteCmd)
+	aliasListCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+}
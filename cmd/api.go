@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/apiserver"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api [method] [path]",
+	Args:  cobra.MaximumNArgs(2),
+	Short: "Send requests to the Agentuity API and HTTP API related commands",
+	Long: `Send a request to the Agentuity API or use the HTTP API related commands.
+
+With a method and a path, the request is sent to the Agentuity API with your
+login and the response is printed as JSON. This gives access to the endpoints
+which don't have a command yet and helps when debugging with support. The method
+defaults to GET when only the path is given. When the request fails, the
+response is printed and the command exits with an error.
+
+The HTTP API served by the serve command lets editors, dashboards and scripts
+written in other languages use the CLI without running it and parsing its output.
+
+Arguments:
+  [method]    The HTTP method (GET, POST, PUT, PATCH or DELETE)
+  [path]      The path of the endpoint with its query string
+
+Flags:
+  --data      The JSON body of the request, @file to read it from a file or
+              @- to read it from stdin
+
+Examples:
+  agentuity api /cli/cluster
+  agentuity api GET /cli/project/proj_123/deployments
+  agentuity api POST /cli/agent/proj_123 --data @agent.json
+  echo '{"name":"renamed"}' | agentuity api PUT /cli/agent/proj_123/agent_123 --data @-`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cmd.Help()
+			return
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		method, path := http.MethodGet, args[0]
+		if len(args) == 2 {
+			method, path = strings.ToUpper(args[0]), args[1]
+		}
+		switch method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid method %s", method), errsystem.WithUserMessage("The method must be GET, POST, PUT, PATCH or DELETE")).ShowErrorAndExit()
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		var payload any
+		if data, _ := cmd.Flags().GetString("data"); data != "" {
+			body, err := readAPIData(data)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid --data: %s", err)).ShowErrorAndExit()
+			}
+			payload = body
+		}
+
+		var token string
+		if cmd.Flags().Changed("api-key") {
+			token = util.EnsureLoggedInWithOnlyAPIKey(ctx, logger, cmd)
+		} else {
+			token, _ = util.EnsureLoggedIn(ctx, logger, cmd)
+		}
+		client := util.NewAPIClient(ctx, logger, util.GetURLs(logger).API, token)
+		var response json.RawMessage
+		err := client.Do(method, path, payload, &response)
+		var apiErr *util.APIError
+		if err != nil && errors.As(err, &apiErr) && apiErr.Status > 0 {
+			// the response isn't JSON or the request failed with a response to print
+			printAPIResponse([]byte(apiErr.Body))
+			if apiErr.Status < 300 {
+				return
+			}
+		} else if err == nil {
+			printAPIResponse(response)
+			return
+		}
+		if isCancelled(ctx) {
+			errsystem.ShowCancelledAndExit()
+		}
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage(fmt.Sprintf("The request %s %s failed", method, path))).ShowErrorAndExit()
+	},
+}
+
+// readAPIData returns the JSON body of the --data flag which is the JSON itself, @file or @- for stdin
+func readAPIData(data string) (json.RawMessage, error) {
+	buf := []byte(data)
+	if name, ok := strings.CutPrefix(data, "@"); ok {
+		var err error
+		if name == "-" {
+			buf, err = io.ReadAll(os.Stdin)
+		} else {
+			buf, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !json.Valid(buf) {
+		return nil, fmt.Errorf("the body isn't valid JSON")
+	}
+	return json.RawMessage(buf), nil
+}
+
+// printAPIResponse prints the response indented when it is JSON and as is otherwise
+func printAPIResponse(body []byte) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		os.Stdout.Write(body)
+		if len(body) > 0 && body[len(body)-1] != '\n' {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(out.String())
+}
+
+var apiServeCmd = &cobra.Command{
+	Use:   "serve",
+	Args:  cobra.NoArgs,
+	Short: "Serve the HTTP API of the CLI on localhost",
+	Long: `Serve the HTTP API of the CLI on localhost.
+
+The API runs the commands of the CLI with your login and returns their results
+as JSON. Every request except the health check needs the token printed when the
+server starts in the Authorization header:
+
+  Authorization: Bearer <token>
+
+Endpoints:
+  GET    /v1/health              The version of the CLI
+  GET    /v1/project             The project in the directory
+  GET    /v1/agents              The Agents of the project, local and in the cloud
+  GET    /v1/deployments         The deployments of the project
+  GET    /v1/status              The project, its active deployment and its deploys
+  POST   /v1/deploys             Start a deploy, the body can have dir, tags,
+                                 message, envProfile, labels and wait
+  GET    /v1/deploys             The deploys started through the API
+  GET    /v1/deploys/{id}        The status, result and output of a deploy
+  DELETE /v1/deploys/{id}        Cancel a deploy
+
+The endpoints use the project in --dir unless the request has a dir query
+parameter (or a dir in the body of a deploy). The errors are returned as
+{"error": {...}} with the code and message of the error of the CLI.
+
+The server only listens on localhost and only accepts requests to localhost.
+
+Flags:
+  --port     The port to listen on (default 8787)
+  --dir      The default project directory
+  --token    The token of the API (default AGENTUITY_API_TOKEN or a random token)
+
+Examples:
+  agentuity api serve
+  agentuity api serve --port 9000 --dir ~/code/my-project
+  curl -H "Authorization: Bearer $TOKEN" http://localhost:8787/v1/agents`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		port, _ := cmd.Flags().GetInt("port")
+		dir, _ := cmd.Flags().GetString("dir")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv("AGENTUITY_API_TOKEN")
+		}
+		if token == "" {
+			buf := make([]byte, 24)
+			if _, err := rand.Read(buf); err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the API token")).ShowErrorAndExit()
+			}
+			token = hex.EncodeToString(buf)
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to get executable path")).ShowErrorAndExit()
+		}
+
+		server := apiserver.New(apiserver.Options{
+			Logger:  logger,
+			Token:   token,
+			Dir:     dir,
+			Version: Version,
+			Runner:  apiserver.ExecRunner(exe),
+		})
+		defer server.Close()
+
+		listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+				errsystem.WithUserMessage("Failed to listen on port %d: %s. Use --port to choose another port.", port, err)).ShowErrorAndExit()
+		}
+		httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		url := fmt.Sprintf("http://localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+		tui.ShowBanner("Agentuity API", tui.Text("Listening on ")+tui.Link("%s", url)+"\n"+tui.Text("Token: ")+tui.Bold(token), false)
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to serve the API")).ShowErrorAndExit()
+		}
+		logger.Info("👋 API server stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.AddCommand(apiServeCmd)
+	apiCmd.Flags().String("data", "", "The JSON body of the request, @file to read it from a file or @- to read it from stdin")
+	apiServeCmd.Flags().Int("port", 8787, "The port to listen on")
+	apiServeCmd.Flags().StringP("dir", "d", ".", "The default project directory of the requests")
+	apiServeCmd.Flags().String("token", "", "The token required by the API (defaults to AGENTUITY_API_TOKEN or a random token)")
+}
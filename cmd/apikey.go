@@ -14,7 +14,6 @@ import (
 	"github.com/agentuity/cli/internal/apikey"
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	cstr "github.com/agentuity/go-common/string"
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
@@ -82,7 +81,7 @@ Examples:
   agentuity apikey ls --project-id <projectId>
   agentuity apikey ls --mask`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -161,7 +160,7 @@ Examples:
   agentuity apikey create <name> --expires-at <expiresAt> --org-id <orgId>
   agentuity apikey create <name> --expires-at <expiresAt> --project-id <projectId>`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -224,7 +223,7 @@ var apikeyDeleteCmd = &cobra.Command{
 	Short:   "Delete an API key",
 	Long:    `Delete an API key.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -272,7 +271,7 @@ Examples:
   agentuity apikey get <id>
   agentuity apikey get <id> --mask`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -2,17 +2,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/agentuity/cli/internal/auth"
 	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -23,7 +26,8 @@ var authCmd = &cobra.Command{
 	Short: "Authentication and authorization related commands",
 	Long: `Authentication and authorization related commands for managing your Agentuity account.
 
-Use the subcommands to login, logout, and check your authentication status.`,
+Use the subcommands to login, logout, check your authentication status and
+manage the tokens cached for your projects.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -41,7 +45,7 @@ Examples:
   agentuity login
   agentuity auth login`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		appUrl := urls.App
@@ -57,7 +61,7 @@ Examples:
 			}
 			if err != nil {
 				if isCancelled(ctx) {
-					os.Exit(1)
+					errsystem.ShowCancelledAndExit()
 				}
 				errsystem.New(errsystem.ErrAuthenticateUser, err,
 					errsystem.WithContextMessage("Failed to generate login OTP")).ShowErrorAndExit()
@@ -92,7 +96,7 @@ Examples:
 			authResult, err := auth.PollForLoginCompletion(ctx, logger, apiUrl, otp)
 			if err != nil {
 				if isCancelled(ctx) {
-					os.Exit(1)
+					errsystem.ShowCancelledAndExit()
 				}
 				if errors.Is(err, auth.ErrLoginTimeout) {
 					tui.ShowWarning("Login timed out. Please try again.")
@@ -104,6 +108,7 @@ Examples:
 			viper.Set("auth.api_key", authResult.APIKey)
 			viper.Set("auth.user_id", authResult.UserId)
 			viper.Set("auth.expires", authResult.Expires.UnixMilli())
+			viper.Set("auth.refresh_token", authResult.RefreshToken)
 			viper.Set("preferences.orgId", "")
 			if err := viper.WriteConfig(); err != nil {
 				errsystem.New(errsystem.ErrWriteConfigurationFile, err,
@@ -140,12 +145,17 @@ var authWhoamiCmd = &cobra.Command{
 	Long: `Print the current logged in user details.
 
 This command displays information about the currently authenticated user,
-including name, organization, and IDs.
+including name, organizations, your role in each organization and the
+effective permissions granted by that role.
+
+Flags:
+  --format    The output format (text or json)
 
 Examples:
-  agentuity auth whoami`,
+  agentuity whoami
+  agentuity auth whoami --format json`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -161,10 +171,38 @@ Examples:
 			util.ShowLogin(ctx, logger, cmd)
 			os.Exit(1)
 		}
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			type whoamiOrg struct {
+				auth.Organization
+				Permissions []string `json:"permissions"`
+			}
+			var orgs []whoamiOrg
+			for _, org := range user.Organizations {
+				perms, _ := org.EffectivePermissions()
+				orgs = append(orgs, whoamiOrg{Organization: org, Permissions: perms})
+			}
+			json.NewEncoder(os.Stdout).Encode(map[string]any{
+				"userId":        userId,
+				"firstName":     user.FirstName,
+				"lastName":      user.LastName,
+				"organizations": orgs,
+			})
+			return
+		}
 		var orgs []string
 		orgs = append(orgs, tui.Bold(tui.Muted("You are a member of the following organizations:")))
 		for _, org := range user.Organizations {
 			orgs = append(orgs, tui.PadRight("Organization:", 15, " ")+" "+tui.Bold(tui.PadRight(org.Name, 31, " "))+" "+tui.Muted(org.Id))
+			if org.Role != "" {
+				orgs = append(orgs, tui.PadRight("Role:", 15, " ")+" "+org.Role)
+			}
+			if perms, ok := org.EffectivePermissions(); ok {
+				if len(perms) == 0 {
+					orgs = append(orgs, tui.PadRight("Permissions:", 15, " ")+" "+tui.Muted("read only"))
+				} else {
+					orgs = append(orgs, tui.PadRight("Permissions:", 15, " ")+" "+tui.Muted(strings.Join(perms, ", ")))
+				}
+			}
 		}
 		body := tui.Paragraph(
 			tui.PadRight("Name:", 15, " ")+" "+tui.Bold(tui.PadRight(user.FirstName+" "+user.LastName, 30, " "))+" "+tui.Muted(userId),
@@ -177,6 +215,44 @@ Examples:
 	},
 }
 
+// ensurePermission checks ahead of time that the logged in user has the permission in the
+// organization which owns the project so that we can show a clear message instead of an API
+// failure. If the user or project can't be resolved, the check is skipped and the API decides.
+func ensurePermission(ctx context.Context, logger logger.Logger, apiUrl string, apiKey string, projectId string, permission string, what string) {
+	projects, err := project.ListProjects(ctx, logger, apiUrl, apiKey)
+	if err != nil {
+		logger.Debug("skipping permission check, failed to list projects: %s", err)
+		return
+	}
+	var orgId string
+	for _, p := range projects {
+		if p.ID == projectId {
+			orgId = p.OrgId
+			break
+		}
+	}
+	if orgId == "" {
+		logger.Debug("skipping permission check, project %s not found", projectId)
+		return
+	}
+	user, err := auth.GetUser(ctx, logger, apiUrl, apiKey)
+	if err != nil || user == nil {
+		logger.Debug("skipping permission check, failed to get user: %v", err)
+		return
+	}
+	org := user.FindOrganization(orgId)
+	if org == nil || org.Can(permission) {
+		return
+	}
+	role := org.Role
+	if role == "" {
+		role = "unknown"
+	}
+	errsystem.New(errsystem.ErrInsufficientPermission, fmt.Errorf("missing permission %s", permission),
+		errsystem.WithUserMessage("Insufficient permission to %s. Your role in %s is %s which does not grant %s. Ask an organization admin for access.", what, org.Name, role, permission),
+		errsystem.WithAttributes(map[string]any{"permission": permission, "orgId": orgId, "role": role})).ShowErrorAndExit()
+}
+
 var authSignupCmd = &cobra.Command{
 	Use:   "signup",
 	Short: "Create a new Agentuity Cloud Platform account",
@@ -185,7 +261,7 @@ var authSignupCmd = &cobra.Command{
 Examples:
   agentuity auth signup`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
 		appUrl := urls.App
@@ -214,6 +290,7 @@ Examples:
 			viper.Set("auth.api_key", apiKey)
 			viper.Set("auth.user_id", userId)
 			viper.Set("auth.expires", expires)
+			viper.Set("auth.refresh_token", "")
 			viper.Set("preferences.orgId", "")
 			if err := viper.WriteConfig(); err != nil {
 				errsystem.New(errsystem.ErrWriteConfigurationFile, err,
@@ -230,12 +307,166 @@ Examples:
 	},
 }
 
+var authProjectTokensCmd = &cobra.Command{
+	Use:   "project-tokens",
+	Short: "Manage the tokens cached for your projects",
+	Long: `Manage the tokens cached for your projects.
+
+The CLI caches the token used with each project, encrypted with a key stored
+in the keychain of your machine. When you login with another account, the
+projects keep using the account they were last used with until their token
+expires so you don't need to login again when switching between projects.
+
+When your machine has no keychain the tokens aren't cached, unless you allow
+the key to be stored in a file next to them by adding to your config file
+(~/.config/agentuity/config.yaml by default):
+
+  auth:
+    project_tokens_key_file: true
+
+Examples:
+  agentuity auth project-tokens list
+  agentuity auth project-tokens revoke`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func openProjectTokens() *util.ProjectTokens {
+	tokens, err := util.OpenProjectTokens()
+	if err != nil {
+		errsystem.New(errsystem.ErrReadConfigurationFile, err,
+			errsystem.WithContextMessage("Failed to open the project tokens")).ShowErrorAndExit()
+	}
+	return tokens
+}
+
+var authProjectTokensListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	Short:   "List the tokens cached for your projects",
+	Long: `List the tokens cached for your projects with the user and the expiration of each.
+
+The tokens themselves are never shown.
+
+Flags:
+  --format    The output format (text or json)
+
+Examples:
+  agentuity auth project-tokens list
+  agentuity auth project-tokens list --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens := openProjectTokens()
+		list := tokens.List()
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			type projectToken struct {
+				ProjectId string    `json:"projectId"`
+				Dir       string    `json:"dir,omitempty"`
+				UserId    string    `json:"userId"`
+				Expires   time.Time `json:"expires"`
+				Expired   bool      `json:"expired"`
+			}
+			result := make([]projectToken, 0, len(list))
+			for _, token := range list {
+				result = append(result, projectToken{token.ProjectId, token.Dir, token.UserId, token.Expires, !token.ValidFor(0)})
+			}
+			json.NewEncoder(os.Stdout).Encode(result)
+			return
+		}
+		if len(list) == 0 {
+			fmt.Println(tui.Muted("No project tokens are cached"))
+			return
+		}
+		var rows [][]string
+		for _, token := range list {
+			expires := token.Expires.Local().Format(time.DateTime)
+			if !token.ValidFor(0) {
+				expires = tui.Warning("expired")
+			}
+			rows = append(rows, []string{token.ProjectId, tui.Muted(token.Dir), token.UserId, expires})
+		}
+		tui.Table([]string{"Project", "Directory", "User", "Expires"}, rows)
+		if tokens.KeyStore == util.ProjectTokensKeyFileStore {
+			tui.ShowWarning("The key of the tokens is stored in a file next to them since there is no keychain")
+		} else {
+			fmt.Println(tui.Muted("Encrypted with the key in the " + tokens.KeyStore))
+		}
+	},
+}
+
+var authProjectTokensRevokeCmd = &cobra.Command{
+	Use:     "revoke [project-id...]",
+	Aliases: []string{"rm", "delete"},
+	Short:   "Remove the tokens cached for projects",
+	Long: `Remove the tokens cached for projects so that they use your current login again.
+
+Arguments:
+  [project-id...]   The ids of the projects, the project in the directory when not given
+
+Flags:
+  --all    Remove the tokens of all the projects
+  --dir    The directory of the project when no project id is given
+
+Examples:
+  agentuity auth project-tokens revoke
+  agentuity auth project-tokens revoke proj_123
+  agentuity auth project-tokens revoke --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		tokens := openProjectTokens()
+		if all, _ := cmd.Flags().GetBool("all"); all {
+			if err := util.ClearProjectTokens(); err != nil {
+				errsystem.New(errsystem.ErrWriteConfigurationFile, err,
+					errsystem.WithContextMessage("Failed to remove the project tokens")).ShowErrorAndExit()
+			}
+			tui.ShowSuccess("Removed the tokens of %d projects", len(tokens.List()))
+			return
+		}
+		projectIds := args
+		if len(projectIds) == 0 {
+			dir := project.ResolveProjectDir(logger, cmd, true)
+			theproject := project.NewProject()
+			if err := theproject.Load(dir); err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err,
+					errsystem.WithContextMessage("Error loading project from disk")).ShowErrorAndExit()
+			}
+			projectIds = []string{theproject.ProjectId}
+		}
+		var removed int
+		for _, projectId := range projectIds {
+			if tokens.Remove(projectId) {
+				removed++
+			} else {
+				tui.ShowWarning("No token is cached for project %s", projectId)
+			}
+		}
+		if removed == 0 {
+			return
+		}
+		if err := tokens.Save(); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err,
+				errsystem.WithContextMessage("Failed to save the project tokens")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Removed the tokens of %d projects", removed)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(authCmd)
 	authCmd.AddCommand(authLoginCmd)
 	authCmd.AddCommand(authLogoutCmd)
 	authCmd.AddCommand(authWhoamiCmd)
 	authCmd.AddCommand(authSignupCmd)
+	authCmd.AddCommand(authProjectTokensCmd)
+	authProjectTokensCmd.AddCommand(authProjectTokensListCmd)
+	authProjectTokensCmd.AddCommand(authProjectTokensRevokeCmd)
 	rootCmd.AddCommand(authLoginCmd)
 	rootCmd.AddCommand(authLogoutCmd)
+	rootCmd.AddCommand(authWhoamiCmd)
+
+	authWhoamiCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	authProjectTokensListCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	authProjectTokensRevokeCmd.Flags().Bool("all", false, "Remove the tokens of all the projects")
+	authProjectTokensRevokeCmd.Flags().StringP("dir", "d", "", "The project directory")
 }
@@ -1,17 +1,29 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/agentuity/cli/internal/agent"
 	"github.com/agentuity/cli/internal/bundler"
+	"github.com/agentuity/cli/internal/dev"
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
 )
 
@@ -22,14 +34,46 @@ var bundleCmd = &cobra.Command{
 
 This command bundles your project code and dependencies for deployment. You generally should not need to call this command directly as it is automatically called when you run the project.
 
+An agent is bundled from the file of the template in its directory (such as
+index.ts or agent.py) unless it declares another file of the project as its
+entrypoint in agentuity.yaml (agents[].entrypoint), such as src/support/handler.ts.
+
+With --watch, the project is bundled again whenever it changes until the command
+is interrupted. With --events json, each build writes a build-start event and then
+a build-success or build-error event to stdout, one JSON object per line:
+
+  {"type":"build-start","time":"...","build":1,"changed":["src/agents/a/index.ts"]}
+  {"type":"build-error","time":"...","build":1,"duration":812,"error":"build failed","output":"..."}
+
+The output of the build goes to stderr. Other tools can request a build or stop
+the watch by writing {"id":1,"method":"build"} or {"method":"stop"} to stdin.
+
 Flags:
-  --production    Bundle for production deployment
-  --install       Install dependencies before bundling
-  --deploy        Deploy after bundling
+  --production       Bundle for production deployment
+  --install          Install dependencies before bundling
+  --no-install       Never install dependencies, even when they aren't installed
+  --frozen-lockfile  Fail instead of updating the lockfile when it is out of date
+  --sandbox          Install dependencies without running their scripts or exposing secrets
+  --skip-compat-check  Don't check that the installed SDK is supported by the CLI
+  --deploy           Deploy after bundling
+  --watch            Bundle again whenever the project changes
+  --events           The format of the build events of --watch, text or json
+  --deps             Report the unused and missing dependencies after bundling
+  --fix              Remove the unused and add the missing dependencies (implies --deps)
+
+With --deps, the dependencies declared in package.json or pyproject.toml are
+compared with the packages imported by the project: the dependencies which no file
+imports and the imported packages which aren't declared are reported. The report
+is based on the import statements so a package which is only loaded dynamically
+can be reported as unused. With --fix, the package manager of the project removes
+the unused dependencies and adds the missing ones, updating the lockfile.
 
 Examples:
   agentuity bundle --production
-  agentuity bundle --install --deploy`,
+  agentuity bundle --install --deploy
+  agentuity bundle --watch --events json
+  agentuity bundle --deps
+  agentuity bundle --fix`,
 	Args:    cobra.NoArgs,
 	Aliases: []string{"build"},
 	Hidden:  true,
@@ -49,7 +93,7 @@ Examples:
 		tags, _ := cmd.Flags().GetStringArray("tag")
 		description, _ := cmd.Flags().GetString("description")
 
-		if err := bundler.Bundle(bundler.BundleContext{
+		bundleCtx := bundler.BundleContext{
 			Context:        ctx,
 			Logger:         projectContext.Logger,
 			Project:        projectContext.Project,
@@ -59,9 +103,28 @@ Examples:
 			Install:        install,
 			CI:             ci,
 			Writer:         os.Stderr,
-		}); err != nil {
+		}
+		applyInstallFlags(cmd, &bundleCtx)
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			if deploy {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("--watch and --deploy are mutually exclusive"), errsystem.WithUserMessage("Use %s to redeploy whenever the project changes", tui.Command("deploy", "--watch"))).ShowErrorAndExit()
+			}
+			events, _ := cmd.Flags().GetString("events")
+			if events != "text" && events != "json" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid events format %q", events), errsystem.WithUserMessage("The --events flag must be text or json")).ShowErrorAndExit()
+			}
+			bundleWatch(ctx, projectContext, bundleCtx, events == "json")
+			return
+		}
+		if err := bundler.Bundle(bundleCtx); err != nil {
+			exitOnSDKCompatibilityError(err)
 			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to bundle project")).ShowErrorAndExit()
 		}
+		if fix, _ := cmd.Flags().GetBool("fix"); fix {
+			reportDependencies(ctx, projectContext, true)
+		} else if deps, _ := cmd.Flags().GetBool("deps"); deps {
+			reportDependencies(ctx, projectContext, false)
+		}
 		if !deploy {
 			projectContext.Logger.Debug("bundled in %s", time.Since(started))
 			return
@@ -106,6 +169,7 @@ Examples:
 				"ci-git-provider",
 				"ci-logs-url",
 				"tag",
+				"skip-compat-check",
 			}
 
 			f := cmd.Flags()
@@ -155,13 +219,217 @@ Examples:
 	},
 }
 
+// reportDependencies shows the dependencies of the project which aren't imported and the imported
+// packages which aren't declared, removing and adding them with fix
+func reportDependencies(ctx context.Context, projectContext project.ProjectContext, fix bool) {
+	language := projectContext.Project.Bundler.Language
+	runtime := projectContext.Project.Bundler.Runtime
+	manifest := agent.DependencyManifest(language)
+	buf, err := os.ReadFile(filepath.Join(projectContext.Dir, manifest))
+	if err != nil && !os.IsNotExist(err) {
+		errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to read the dependencies")).ShowErrorAndExit()
+	}
+	files, err := agent.ProjectSourceFiles(projectContext.Dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to list the project files")).ShowErrorAndExit()
+	}
+	report, err := agent.AuditDependencies(projectContext.Dir, language, files, buf)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to check the dependencies")).ShowErrorAndExit()
+	}
+	if report.Empty() {
+		tui.ShowSuccess("The dependencies in %s match the imports of the project", manifest)
+		return
+	}
+	if !fix {
+		for _, name := range report.Unused {
+			tui.ShowWarning("%s is declared in %s but never imported", name, manifest)
+		}
+		for _, name := range report.Missing {
+			tui.ShowWarning("%s is imported but not declared in %s", name, manifest)
+		}
+		tui.ShowBanner("Fix the dependencies", tui.Text("Use the ")+tui.Command("bundle", "--fix")+tui.Text(" command to remove the unused and add the missing dependencies"), false)
+		return
+	}
+	var failed []string
+	tui.ShowSpinner("Fixing the dependencies ...", func() {
+		for _, name := range report.Unused {
+			if err := bundler.RemoveDependency(ctx, projectContext.Dir, language, runtime, name); err != nil {
+				projectContext.Logger.Debug("failed to remove %s: %s", name, err)
+				failed = append(failed, fmt.Sprintf("Failed to remove %s: %s", name, err))
+			}
+		}
+		for _, name := range report.Missing {
+			if _, err := bundler.AddDependency(ctx, projectContext.Dir, language, runtime, name, ""); err != nil {
+				projectContext.Logger.Debug("failed to add %s: %s", name, err)
+				failed = append(failed, fmt.Sprintf("Failed to add %s: %s", name, err))
+			}
+		}
+	})
+	for _, msg := range failed {
+		tui.ShowWarning("%s", msg)
+	}
+	if len(failed) == 0 {
+		tui.ShowSuccess("Removed %s and added %s", util.Pluralize(len(report.Unused), "dependency", "dependencies"), util.Pluralize(len(report.Missing), "dependency", "dependencies"))
+	}
+}
+
+// addInstallFlags adds the flags which control how the dependencies are installed and checked when bundling
+func addInstallFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-install", false, "Do not install dependencies, even when they aren't installed")
+	cmd.Flags().Bool("frozen-lockfile", false, "Fail instead of updating the lockfile when it is out of date")
+	cmd.Flags().Bool("sandbox", false, "Install dependencies without running their scripts or exposing secrets in the environment, for untrusted code")
+	cmd.Flags().Bool("skip-compat-check", false, "Don't check that the installed SDK is supported by this version of the CLI")
+}
+
+// applyInstallFlags sets the install options of the bundle context from the flags added by addInstallFlags
+func applyInstallFlags(cmd *cobra.Command, ctx *bundler.BundleContext) {
+	ctx.NoInstall, _ = cmd.Flags().GetBool("no-install")
+	ctx.FrozenLockfile, _ = cmd.Flags().GetBool("frozen-lockfile")
+	ctx.Sandbox, _ = cmd.Flags().GetBool("sandbox")
+	ctx.SkipCompatCheck, _ = cmd.Flags().GetBool("skip-compat-check")
+	if ctx.NoInstall && ctx.Install {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("--install and --no-install are mutually exclusive"), errsystem.WithUserMessage("The --install and --no-install flags can't be used together")).ShowErrorAndExit()
+	}
+}
+
+// bundleWatch bundles the project whenever it changes, or when a build is requested on stdin, until
+// the context is done, writing the events of the builds as JSON to stdout with jsonEvents
+func bundleWatch(ctx context.Context, projectContext project.ProjectContext, bundleCtx bundler.BundleContext, jsonEvents bool) {
+	logger := projectContext.Logger
+	dir := projectContext.Dir
+	bundleCtx.Watch = true
+	var events *bundler.EventWriter
+	if jsonEvents {
+		events = bundler.NewEventWriter(os.Stdout)
+	}
+	emit := func(event bundler.BuildEvent) {
+		if events != nil {
+			if err := events.Emit(event); err != nil {
+				logger.Debug("failed to write the build event: %s", err)
+			}
+		}
+	}
+
+	var count int
+	build := func(changed []string, request any) {
+		count++
+		started := time.Now()
+		emit(bundler.BuildEvent{Type: bundler.BuildStartEvent, Build: count, Changed: changed, Request: request})
+		var output bytes.Buffer
+		buildCtx := bundleCtx
+		buildCtx.Writer = io.MultiWriter(os.Stderr, &output)
+		// only the first build installs the dependencies when --install is used
+		bundleCtx.Install = false
+		err := bundler.Bundle(buildCtx)
+		duration := time.Since(started)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			event := bundler.BuildEvent{Type: bundler.BuildErrorEvent, Build: count, Duration: duration.Milliseconds(), Error: err.Error(), Output: strings.TrimSpace(output.String())}
+			var compat *bundler.SDKCompatibilityError
+			if errors.As(err, &compat) {
+				event.Error = compat.Advice()
+			}
+			emit(event)
+			if !jsonEvents {
+				tui.ShowWarning("Build failed: %s. Waiting for changes ...", event.Error)
+			}
+			return
+		}
+		emit(bundler.BuildEvent{Type: bundler.BuildSuccessEvent, Build: count, Duration: duration.Milliseconds()})
+		if !jsonEvents {
+			tui.ShowSuccess("Built in %s. Waiting for changes ...", duration.Round(time.Millisecond))
+		}
+	}
+
+	changes := make(chan string, 100)
+	rules := createProjectIgnoreRules(dir, projectContext.Project, true)
+	watcher, err := dev.NewWatcher(logger, dir, rules, func(path string) {
+		select {
+		case changes <- path:
+		default:
+		}
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to start watcher: %s", err))).ShowErrorAndExit()
+	}
+	defer watcher.Close(logger)
+
+	// the requests are only read from stdin when the events are written as JSON for another tool
+	requests := make(chan *bundler.WatchRequest)
+	if jsonEvents {
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				if strings.TrimSpace(scanner.Text()) == "" {
+					continue
+				}
+				req, err := bundler.ParseWatchRequest(scanner.Text())
+				if err != nil {
+					logger.Warn("%s", err)
+					continue
+				}
+				select {
+				case requests <- req:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	} else {
+		tui.ShowSuccess("Watching %s for changes", dir)
+	}
+
+	build(nil, nil)
+	changed := make(map[string]bool)
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path := <-changes:
+			if rel, err := filepath.Rel(dir, path); err == nil {
+				path = filepath.ToSlash(rel)
+			}
+			changed[path] = true
+			settle = time.After(250 * time.Millisecond)
+		case <-settle:
+			settle = nil
+			paths := slices.Sorted(maps.Keys(changed))
+			clear(changed)
+			build(paths, nil)
+		case req := <-requests:
+			if req.Method == bundler.WatchMethodStop {
+				logger.Debug("stopping the watch as requested")
+				return
+			}
+			build(nil, req.ID)
+		}
+	}
+}
+
+// exitOnSDKCompatibilityError shows how to fix an installed SDK which isn't supported by the CLI and exits
+func exitOnSDKCompatibilityError(err error) {
+	var compat *bundler.SDKCompatibilityError
+	if errors.As(err, &compat) {
+		errsystem.New(errsystem.ErrSdkUpdateRequired, err, errsystem.WithUserMessage("%s", compat.Advice())).ShowErrorAndExit()
+	}
+}
+
 func init() {
 	bundler.Version = Version
 	rootCmd.AddCommand(bundleCmd)
 	bundleCmd.Flags().StringP("dir", "d", ".", "The directory to the project")
 	bundleCmd.Flags().BoolP("production", "p", false, "Whether to bundle for production")
 	bundleCmd.Flags().BoolP("install", "i", false, "Whether to install dependencies before bundling")
+	addInstallFlags(bundleCmd)
 	bundleCmd.Flags().Bool("deploy", false, "Whether to deploy after bundling")
+	bundleCmd.Flags().Bool("watch", false, "Bundle again whenever the project changes")
+	bundleCmd.Flags().String("events", "text", "The format of the build events with --watch which can be either 'text' or 'json'")
+	bundleCmd.Flags().Bool("deps", false, "Report the unused and missing dependencies after bundling")
+	bundleCmd.Flags().Bool("fix", false, "Remove the unused and add the missing dependencies (implies --deps)")
 	bundleCmd.Flags().String("deploymentId", "", "Used to track a specific deployment")
 	bundleCmd.Flags().StringArray("tag", nil, "Tag(s) to associate with this deployment (can be specified multiple times)")
 	bundleCmd.Flags().String("description", "", "Used to set the description of the deployment")
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agentuity/cli/internal/deployer"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Args:  cobra.NoArgs,
+	Short: "Remove the local build output, state and temporary files of the CLI",
+	Long: `Remove the local build output, state and temporary files of the CLI.
+
+This removes the build output in .agentuity, the backups made when deleting agents,
+the log files, the recorded dev mode sessions, the last eval run, crash reports and
+stale temporary files from deploys, templates and upgrades.
+
+Snapshots, the eval baseline and the prompts generated into the SDK are only removed
+with --all since they can't be recreated by building the project.
+
+Flags:
+  --dry-run   List what would be removed without removing anything
+  --all       Also remove the snapshots, eval baseline and generated prompts
+  --force     Don't prompt for confirmation
+
+Examples:
+  agentuity clean --dry-run
+  agentuity clean
+  agentuity clean --all --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		all, _ := cmd.Flags().GetBool("all")
+		force, _ := cmd.Flags().GetBool("force")
+		format, _ := cmd.Flags().GetString("format")
+
+		artifacts, err := project.FindArtifacts(dir, project.ArtifactOptions{StaleAfter: time.Hour, All: all})
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to find the local files of the project")).ShowErrorAndExit()
+		}
+		var total int64
+		for _, artifact := range artifacts {
+			total += artifact.Size
+		}
+
+		if format == "json" {
+			if !dryRun {
+				if err := project.RemoveArtifacts(artifacts); err != nil {
+					errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to remove the local files of the project")).ShowErrorAndExit()
+				}
+			}
+			json.NewEncoder(os.Stdout).Encode(map[string]any{
+				"artifacts": artifacts,
+				"size":      total,
+				"removed":   !dryRun,
+			})
+			return
+		}
+
+		if len(artifacts) == 0 {
+			tui.ShowSuccess("Nothing to clean")
+			return
+		}
+
+		headers := []string{"Kind", "Path", "Size"}
+		rows := [][]string{}
+		for _, artifact := range artifacts {
+			path := artifact.Path
+			if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsLocal(rel) {
+				path = rel
+			}
+			rows = append(rows, []string{tui.Bold(artifact.Kind), path, tui.Muted(deployer.FormatSize(artifact.Size))})
+		}
+		tui.Table(headers, rows)
+
+		if dryRun {
+			fmt.Printf("%d items (%s) would be removed\n", len(artifacts), deployer.FormatSize(total))
+			return
+		}
+		if !force && tui.HasTTY {
+			if !tui.Ask(logger, fmt.Sprintf("Remove %d items (%s)?", len(artifacts), deployer.FormatSize(total)), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		if err := project.RemoveArtifacts(artifacts); err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to remove the local files of the project")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Removed %d items (%s)", len(artifacts), deployer.FormatSize(total))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+	cleanCmd.Flags().StringP("dir", "d", "", "The project directory")
+	cleanCmd.Flags().Bool("dry-run", false, "List what would be removed without removing anything")
+	cleanCmd.Flags().Bool("all", false, "Also remove the snapshots, eval baseline and generated prompts")
+	cleanCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	cleanCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/openapi"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+// clientFilenames are the default filenames of the generated clients by language
+var clientFilenames = map[string]string{
+	"ts":     "agentuity-client.ts",
+	"python": "agentuity_client.py",
+}
+
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Client libraries for invoking the Agents",
+	Long: `Client libraries for invoking the Agents.
+
+Use the subcommands to generate a client library of the Agents of a project.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var clientGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a typed client library for invoking the Agents",
+	Long: `Generate a typed client library for invoking the Agents of the project.
+
+The client has a method for each Agent which sends the payload to the webhook
+of the Agent and returns its response. The API key is sent as a bearer token
+to the Agents which require authentication in Agentuity Cloud, either the key
+the client is created with or the key of the Agent by name. The payload schemas
+declared in the agents section of agentuity.yaml are generated as the payload
+types of the methods: TypeScript interfaces or Pydantic models.
+
+The TypeScript client uses fetch and the Python client only the standard
+library, plus Pydantic when an Agent has a payload schema. The language
+defaults to the language of the project.
+
+Flags:
+  --dir       The project directory
+  --lang      The language of the client (ts or python)
+  --output    The file to write the client to
+
+Examples:
+  agentuity client generate
+  agentuity client generate --lang ts --output src/lib/agents.ts
+  agentuity client generate --lang python`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		lang, _ := cmd.Flags().GetString("lang")
+		output, _ := cmd.Flags().GetString("output")
+		if lang == "" {
+			lang = "ts"
+			if theproject.Project.Bundler != nil && theproject.Project.Bundler.Language == "python" {
+				lang = "python"
+			}
+		}
+		if !slices.Contains(openapi.ClientLanguages, lang) {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid language %s", lang),
+				errsystem.WithUserMessage("The language must be one of %s", strings.Join(openapi.ClientLanguages, ", "))).ShowErrorAndExit()
+		}
+		if output == "" {
+			output = filepath.Join(theproject.Dir, clientFilenames[lang])
+		}
+
+		schemas, err := project.LoadAgentSchemas(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the agent schemas")).ShowErrorAndExit()
+		}
+		contracts := make(map[string]*openapi.Contract)
+		for agentID, filename := range schemas {
+			contract, err := openapi.LoadContract(filename, "")
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to load the payload schema for agent %s", agentID)).ShowErrorAndExit()
+			}
+			logger.Debug("loaded payload schema for agent %s from %s", agentID, filename)
+			contracts[agentID] = contract
+		}
+
+		spec := projectAgentSpec(ctx, logger, theproject)
+		if len(spec.Agents) == 0 {
+			tui.ShowWarning("The project has no Agents to generate a client for")
+			os.Exit(1)
+		}
+		code, err := openapi.GenerateClient(lang, spec, contracts)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the client")).ShowErrorAndExit()
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			errsystem.New(errsystem.ErrCreateDirectory, err, errsystem.WithContextMessage("Failed to create the output directory")).ShowErrorAndExit()
+		}
+		if err := os.WriteFile(output, []byte(code), 0644); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to write the client")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Wrote the client of %d Agents to %s", len(spec.Agents), output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clientCmd)
+	clientCmd.AddCommand(clientGenerateCmd)
+	clientGenerateCmd.Flags().StringP("dir", "d", "", "The project directory")
+	clientGenerateCmd.Flags().String("lang", "", "The language of the client, either 'ts' or 'python' (defaults to the language of the project)")
+	clientGenerateCmd.Flags().StringP("output", "o", "", "The file to write the client to")
+}
@@ -2,31 +2,33 @@ package cmd
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"slices"
-	"strconv"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/agentuity/cli/internal/auth"
+	"github.com/agentuity/cli/internal/bundler"
 	"github.com/agentuity/cli/internal/bundler/prompts"
 	"github.com/agentuity/cli/internal/deployer"
+	"github.com/agentuity/cli/internal/dev"
 	"github.com/agentuity/cli/internal/envutil"
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/ignore"
+	"github.com/agentuity/cli/internal/keys"
 	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/sbom"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/crypto"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/project"
 	"github.com/agentuity/go-common/tui"
@@ -59,6 +61,7 @@ type startResponse struct {
 		Created      []Agent `json:"created,omitempty"`
 		OrgSecret    *string `json:"orgSecret,omitempty"`
 		PublicKey    *string `json:"publicKey,omitempty"`
+		ApprovalUrl  *string `json:"approvalUrl,omitempty"`
 	}
 	Message *string `json:"message,omitempty"`
 }
@@ -90,14 +93,17 @@ type DeployPrompt struct {
 }
 
 type startRequest struct {
-	Agents         []startAgent       `json:"agents"`
-	Resources      *Resources         `json:"resources,omitempty"`
-	Metadata       *deployer.Metadata `json:"metadata,omitempty"`
-	Tags           []string           `json:"tags,omitempty"`
-	TagDescription string             `json:"description,omitempty"`
-	TagMessage     string             `json:"message,omitempty"`
-	UsePrivateKey  bool               `json:"usePrivateKey,omitempty"`
-	Prompts        []DeployPrompt     `json:"prompts,omitempty"`
+	Agents          []startAgent               `json:"agents"`
+	Resources       *Resources                 `json:"resources,omitempty"`
+	Metadata        *deployer.Metadata         `json:"metadata,omitempty"`
+	Tags            []string                   `json:"tags,omitempty"`
+	TagDescription  string                     `json:"description,omitempty"`
+	TagMessage      string                     `json:"message,omitempty"`
+	UsePrivateKey   bool                       `json:"usePrivateKey,omitempty"`
+	RequireApproval bool                       `json:"requireApproval,omitempty"`
+	Prompts         []DeployPrompt             `json:"prompts,omitempty"`
+	Schemas         map[string]json.RawMessage `json:"schemas,omitempty"`
+	LockId          string                     `json:"lockId,omitempty"`
 }
 
 func ShowNewProjectImport(ctx context.Context, logger logger.Logger, cmd *cobra.Command, apiUrl string, apikey string, projectId string, project *project.Project, dir string, isImport bool) {
@@ -127,27 +133,33 @@ func ShowNewProjectImport(ctx context.Context, logger logger.Logger, cmd *cobra.
 	}
 	tui.ClearScreen()
 	tui.ShowSpinner("Importing project ...", func() {
-		result, err := iproject.ProjectImport(ctx, logger, apiUrl, apikey, orgId, project, createWebhookAuth)
-		if err != nil {
-			if isCancelled(ctx) {
-				os.Exit(1)
-			}
-			errsystem.New(errsystem.ErrImportingProject, err,
-				errsystem.WithContextMessage("Error importing project")).ShowErrorAndExit()
-		}
-		if err := project.Save(dir); err != nil {
-			errsystem.New(errsystem.ErrSaveProject, err,
-				errsystem.WithContextMessage("Error saving project after import")).ShowErrorAndExit()
-		}
-		saveEnv(dir, result.APIKey, result.ProjectKey)
+		importProject(ctx, logger, apiUrl, apikey, orgId, project, dir, createWebhookAuth)
 	})
 	tui.ShowSuccess("Project imported successfully")
 }
 
+// importProject adds the project to the organization and saves its new identifiers and keys in dir
+func importProject(ctx context.Context, logger logger.Logger, apiUrl string, apikey string, orgId string, project *project.Project, dir string, createWebhookAuth bool) {
+	result, err := iproject.ProjectImport(ctx, logger, apiUrl, apikey, orgId, project, createWebhookAuth)
+	if err != nil {
+		if isCancelled(ctx) {
+			errsystem.ShowCancelledAndExit()
+		}
+		errsystem.New(errsystem.ErrImportingProject, err,
+			errsystem.WithContextMessage("Error importing project")).ShowErrorAndExit()
+	}
+	if err := iproject.SaveProject(dir, project); err != nil {
+		errsystem.New(errsystem.ErrSaveProject, err,
+			errsystem.WithContextMessage("Error saving project after import")).ShowErrorAndExit()
+	}
+	saveEnv(dir, result.APIKey, result.ProjectKey)
+}
+
 var envTemplateFileNames = []string{".env.example", ".env.template"}
 
 var border = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(1).BorderForeground(lipgloss.AdaptiveColor{Light: "#999999", Dark: "#999999"})
 var redDiff = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#990000", Dark: "#EE0000"})
+var greenDiff = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#006600", Dark: "#00CC00"})
 
 func createProjectIgnoreRules(dir string, theproject *project.Project, skipProjectIgnore bool) *ignore.Rules {
 	// load up any gitignore files
@@ -163,10 +175,22 @@ func createProjectIgnoreRules(dir string, theproject *project.Project, skipProje
 	}
 	rules.AddDefaults()
 
+	// the local state of the CLI is kept next to the build output but is never deployed
+	for _, name := range iproject.StateDirs {
+		rules.Add(fmt.Sprintf("**/%s/%s/**", iproject.AgentuityDir, name))
+	}
+	for _, name := range iproject.StateFiles {
+		rules.Add(fmt.Sprintf("**/%s/%s", iproject.AgentuityDir, name))
+	}
+
 	if skipProjectIgnore {
 		return rules
 	}
 
+	// the assets and the output of the asset pipeline are always deployed even when they are ignored,
+	// which is common for large model files and for the sources of the pipeline
+	rules.Add(fmt.Sprintf("!**/%s/%s/**", iproject.AgentuityDir, bundler.AssetsDir))
+
 	// add any provider specific ignore rules
 	for _, rule := range theproject.Bundler.Ignore {
 		if err := rules.Add(rule); err != nil {
@@ -178,6 +202,181 @@ func createProjectIgnoreRules(dir string, theproject *project.Project, skipProje
 	return rules
 }
 
+func loadDeploymentSizeBudget(cmd *cobra.Command, dir string) *deployer.SizeBudget {
+	budget, err := deployer.LoadSizeBudget(project.GetProjectFilename(dir))
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithContextMessage("Error loading deployment size budget")).ShowErrorAndExit()
+	}
+	if budget == nil {
+		budget = &deployer.SizeBudget{}
+	}
+	if cmd.Flags().Changed("size-budget") {
+		budget.Warn, _ = cmd.Flags().GetString("size-budget")
+	}
+	if cmd.Flags().Changed("size-limit") {
+		budget.Limit, _ = cmd.Flags().GetString("size-limit")
+	}
+	return budget
+}
+
+// loadDeploymentScale loads and validates the deployment.scale section of the project file which
+// sets the autoscaling of the deployment, or returns nil when it isn't set
+func loadDeploymentScale(dir string) *iproject.ScaleConfig {
+	scale, err := iproject.LoadScaleConfig(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithContextMessage("Error loading deployment scale")).ShowErrorAndExit()
+	}
+	if err := scale.Validate(); err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithUserMessage("Invalid deployment scale in %s: %s", filepath.Base(project.GetProjectFilename(dir)), err)).ShowErrorAndExit()
+	}
+	if scale.Empty() {
+		return nil
+	}
+	return scale
+}
+
+// loadExternalizeConfig loads the deployment.externalize section of the project file which selects
+// the large files uploaded separately from the deployment zip file
+func loadExternalizeConfig(cmd *cobra.Command, dir string) *deployer.ExternalizeConfig {
+	if noExternalize, _ := cmd.Flags().GetBool("no-externalize"); noExternalize {
+		return nil
+	}
+	config, err := deployer.LoadExternalizeConfig(project.GetProjectFilename(dir))
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithContextMessage("Error loading deployment externalize configuration")).ShowErrorAndExit()
+	}
+	if config != nil && config.Threshold != "" {
+		if _, err := deployer.ParseSize(config.Threshold); err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("Invalid deployment externalize threshold: %s", config.Threshold)).ShowErrorAndExit()
+		}
+	}
+	return config
+}
+
+// transferProgressLogInterval is how often the progress of a transfer is logged without a terminal
+const transferProgressLogInterval = 5 * time.Second
+
+// newTransferProgress returns the function which shows the progress of an upload or a download with
+// the bytes transferred, the rate and the ETA as a progress bar on a terminal, or otherwise (such as
+// in CI) as a log line every few seconds
+func newTransferProgress(logger logger.Logger, title string) func(util.Progress) {
+	var logged time.Time
+	return func(p util.Progress) {
+		size := fmt.Sprintf("%s / %s", deployer.FormatSize(p.Done), deployer.FormatSize(p.Total))
+		rate := deployer.FormatSize(int64(p.Rate())) + "/s"
+		var eta string
+		if d := p.ETA(); d > 0 {
+			eta = "ETA " + d.Round(time.Second).String()
+		}
+		if tui.HasTTY {
+			if p.Finished {
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			}
+			fmt.Fprintf(os.Stderr, "\r\033[K%s %s %3.0f%% %s %s %s", title, p.Bar(30), p.Percent(), size, tui.Muted(rate), tui.Muted(eta))
+			return
+		}
+		if p.Finished {
+			logger.Info("%s done: %s in %s (%s)", title, deployer.FormatSize(p.Done), p.Elapsed.Round(time.Millisecond), rate)
+			return
+		}
+		if time.Since(logged) < transferProgressLogInterval {
+			return
+		}
+		logged = time.Now()
+		logger.Info("%s %.0f%% (%s, %s) %s", title, p.Percent(), size, rate, eta)
+	}
+}
+
+// loadDeploymentSchemas loads the payload schemas of the agents which are uploaded with the deployment
+// so that invalid payloads can be rejected before the agent is invoked
+func loadDeploymentSchemas(logger logger.Logger, dir string) map[string]json.RawMessage {
+	validators := loadAgentValidators(logger, dir)
+	if len(validators) == 0 {
+		return nil
+	}
+	schemas := make(map[string]json.RawMessage)
+	for agentID, validator := range validators {
+		buf, err := validator.JSON()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("Failed to encode the payload schema for agent %s", agentID)).ShowErrorAndExit()
+		}
+		schemas[agentID] = buf
+	}
+	return schemas
+}
+
+func checkDeploymentSize(logger logger.Logger, cmd *cobra.Command, zipfile string, budget *deployer.SizeBudget, analyze bool) {
+	var warnSize, limitSize int64
+	if budget.Warn != "" {
+		val, err := deployer.ParseSize(budget.Warn)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("Invalid deployment size budget: %s", budget.Warn)).ShowErrorAndExit()
+		}
+		warnSize = val
+	}
+	if budget.Limit != "" {
+		val, err := deployer.ParseSize(budget.Limit)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("Invalid deployment size limit: %s", budget.Limit)).ShowErrorAndExit()
+		}
+		limitSize = val
+	}
+	if !analyze && warnSize == 0 && limitSize == 0 {
+		return
+	}
+	analysis, err := deployer.AnalyzeBundle(zipfile)
+	if err != nil {
+		errsystem.New(errsystem.ErrCreateZipFile, err,
+			errsystem.WithContextMessage("Error analyzing deployment zip file")).ShowErrorAndExit()
+	}
+	logger.Debug("deployment zip file is %d bytes with %d files", analysis.ArchiveSize, analysis.Files)
+
+	if analyze {
+		top, _ := cmd.Flags().GetInt("analyze-top")
+		entries := analysis.Entries
+		if top > 0 && len(entries) > top {
+			entries = entries[:top]
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if format == "json" {
+			analysis.Entries = entries
+			json.NewEncoder(os.Stderr).Encode(analysis)
+		} else {
+			fmt.Println()
+			fmt.Printf("%s %s (%s uncompressed, %d files)\n", tui.Bold("Deployment size:"), deployer.FormatSize(analysis.ArchiveSize), deployer.FormatSize(analysis.Size), analysis.Files)
+			fmt.Println()
+			for _, entry := range entries {
+				fmt.Printf("  %s %s %s %s\n",
+					tui.PadRight(deployer.FormatSize(entry.CompressedSize), 12, " "),
+					tui.PadRight(fmt.Sprintf("%d%%", entry.PercentOfArchive), 5, " "),
+					entry.Name,
+					tui.Muted(fmt.Sprintf("(%s uncompressed, %d files)", deployer.FormatSize(entry.Size), entry.Files)))
+			}
+			if len(analysis.Entries) > len(entries) {
+				fmt.Println(tui.Muted(fmt.Sprintf("  ... and %d more", len(analysis.Entries)-len(entries))))
+			}
+			fmt.Println()
+		}
+	}
+
+	if limitSize > 0 && analysis.ArchiveSize > limitSize {
+		errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("deployment size %d exceeds the limit of %d bytes", analysis.ArchiveSize, limitSize),
+			errsystem.WithUserMessage("The deployment is %s which exceeds the size limit of %s. Run with --analyze to see the largest directories and packages.", deployer.FormatSize(analysis.ArchiveSize), budget.Limit)).ShowErrorAndExit()
+	}
+	if warnSize > 0 && analysis.ArchiveSize > warnSize {
+		tui.ShowWarning("The deployment is %s which exceeds the size budget of %s", deployer.FormatSize(analysis.ArchiveSize), budget.Warn)
+	}
+}
+
 var cloudDeployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy project to the cloud",
@@ -187,20 +386,71 @@ This command packages your project, uploads it to the Agentuity Cloud,
 and starts the deployment process. It will reconcile any differences
 between local and remote agents.
 
+The deployment includes a software bill of materials (SBOM) of the
+dependencies. When the project file has a licenses policy, the licenses of
+the dependencies are checked before anything is uploaded.
+
+Your session must be valid for at least 15 minutes when the deploy starts so
+that it doesn't expire during the upload. It is refreshed automatically when
+possible, otherwise you are asked to login again and the deploy continues.
+
+Only one deploy of a tag of the project runs at a time. When another deploy of
+one of the tags is in progress, you are shown who started it and asked whether
+to wait for it or take over its lock. Without a terminal, such as in CI, the
+deploy fails unless --wait or --force-takeover is used.
+
+Once uploaded, the deploy waits for the deployment to be built and started in
+the cloud. When it fails, the reason, an excerpt of its logs and the suggested
+fixes (such as a missing dependency or a wrong start command) are shown.
+
+The deployment is uploaded directly to the storage of the Agentuity Cloud. When
+that fails with a network error, such as on a network which blocks the storage,
+it is uploaded through the Agentuity API instead. Use --upload-via-api to always
+upload through the API.
+
 Flags:
   --dir       The directory containing the project to deploy
   --dry-run   Save deployment zip file to specified directory instead of uploading
+  --env-profile   Load environment variables from .env.<profile> instead of .env
+  --watch     Redeploy to a preview tag whenever the project changes
+  --analyze   Show a breakdown of the deployment size by directory and package
+  --size-budget   Warn when the deployment exceeds the size (overrides deployment.budget.warn)
+  --size-limit    Fail when the deployment exceeds the size (overrides deployment.budget.limit)
+  --no-externalize   Include the files in deployment.externalize in the deployment zip file
+  --no-sbom   Don't attach the SBOM of the dependencies to the deployment
+  --wait      Wait for another deploy of the same tags to finish instead of failing
+  --wait-timeout     How long to wait for another deploy with --wait
+  --force-takeover   Take over the lock of another deploy of the same tags
+  --label     A key=value label stored with the deployment (can be repeated)
+  --status-timeout   How long to wait for the deployment to start (0 to not wait)
+  --skip-compat-check   Don't check that the installed SDK is supported by the CLI
+  --upload-via-api   Upload the deployment through the Agentuity API instead of directly to the storage
+  --workspace        Deploy every project of the workspace (agentuity.workspace.yaml)
+  --workspace-project   Deploy the projects of the workspace with the names (can be repeated)
 
 Examples:
   agentuity cloud deploy
   agentuity deploy
   agentuity cloud deploy --dir /path/to/project
-  agentuity deploy --dry-run ./output`,
+  agentuity deploy --dry-run ./output
+  agentuity deploy --env-profile staging
+  agentuity deploy --watch --tag preview-my-branch
+  agentuity deploy --analyze --dry-run ./output
+  agentuity deploy --size-limit 100Mi
+  agentuity deploy --wait --wait-timeout 10m
+  agentuity deploy --label team=payments --label ticket=JIRA-123
+  agentuity deploy --upload-via-api
+  agentuity deploy --workspace
+  agentuity deploy --workspace-project support --workspace-project billing`,
+	Annotations: map[string]string{util.SessionValidityAnnotation: "15m"},
 	Run: func(cmd *cobra.Command, args []string) {
+		if runWorkspace(cmd, args) {
+			return
+		}
 		parentCtx := context.Background()
 		ctx, cancel := signal.NotifyContext(parentCtx, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		context := iproject.EnsureProject(ctx, cmd)
 		theproject := context.Project
 		dir := context.Dir
@@ -216,15 +466,23 @@ Examples:
 		ciGitProvider, _ := cmd.Flags().GetString("ci-git-provider")
 		ciLogsUrl, _ := cmd.Flags().GetString("ci-logs-url")
 		tags, _ := cmd.Flags().GetStringArray("tag")
+		labelValues, _ := cmd.Flags().GetStringArray("label")
 		description, _ := cmd.Flags().GetString("description")
 		message, _ := cmd.Flags().GetString("message")
 		dryRun, _ := cmd.Flags().GetString("dry-run")
 		noBuild, _ := cmd.Flags().GetBool("no-build")
+		skipCompatCheck, _ := cmd.Flags().GetBool("skip-compat-check")
 
 		// remove duplicates and empty strings
 		tags = util.RemoveDuplicates(tags)
 		tags = util.RemoveEmpty(tags)
 
+		labels, err := iproject.ParseDeploymentLabels(labelValues)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+				errsystem.WithUserMessage("%s. Labels are set with --label key=value.", err)).ShowErrorAndExit()
+		}
+
 		var preview bool
 
 		// If no tags are provided, default to ["latest"]
@@ -240,6 +498,44 @@ Examples:
 
 		logger.Debug("preview: %v", preview)
 
+		// the policy is checked before anything is built or uploaded so a refused deploy fails fast
+		if !context.NewProject && dryRun == "" {
+			checkDeployPolicy(ctx, logger, context, tags, ci)
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			if !preview {
+				errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--watch requires a non-latest tag"),
+					errsystem.WithUserMessage("Watch mode can only deploy to a preview tag. Use --tag to provide a tag other than latest, for example --tag preview-my-branch")).ShowErrorAndExit()
+			}
+			if dryRun != "" {
+				errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--watch cannot be used with --dry-run"),
+					errsystem.WithUserMessage("The --watch and --dry-run flags cannot be used together")).ShowErrorAndExit()
+			}
+			watchAndDeploy(ctx, logger, cmd, dir, theproject, tags)
+			return
+		}
+
+		// the lock is held for the whole deploy so that two deploys of the same tags don't race, and
+		// the deploy is cancelled when the lock is lost
+		var lock *iproject.DeployLock
+		if !context.NewProject && dryRun == "" {
+			var ok bool
+			if lock, ok = acquireDeployLock(ctx, logger, cmd, context, tags, deployLockHolder(logger, cmd, dir, ci)); !ok {
+				return
+			}
+			if lock != nil {
+				var release func()
+				ctx, release = holdDeployLock(ctx, logger, context, lock)
+				defer release()
+			}
+		}
+
+		budget := loadDeploymentSizeBudget(cmd, dir)
+		externalize := loadExternalizeConfig(cmd, dir)
+		scale := loadDeploymentScale(dir)
+		schemas := loadDeploymentSchemas(logger, dir)
+
 		deploymentConfig := iproject.NewDeploymentConfig()
 		client := util.NewAPIClient(ctx, logger, apiUrl, token)
 		var envFile *deployer.EnvFile
@@ -275,7 +571,7 @@ Examples:
 							return
 						}
 						if isCancelled(ctx) {
-							os.Exit(1)
+							errsystem.ShowCancelledAndExit()
 						}
 						errsystem.New(errsystem.ErrApiRequest, err,
 							errsystem.WithContextMessage("Error listing project environment")).ShowErrorAndExit()
@@ -298,7 +594,13 @@ Examples:
 				force = true
 			}
 			// check to see if we have any env vars that are not in the project
-			envFile, projectData = envutil.ProcessEnvFiles(ctx, logger, dir, theproject, projectData, apiUrl, token, force, false)
+			envProfile, _ := cmd.Flags().GetString("env-profile")
+			if envProfile != "" && tui.HasTTY {
+				if err := envutil.WarnEnvProfileConflicts(dir, envProfile); err != nil {
+					logger.Debug("failed to compare environment profiles: %s", err)
+				}
+			}
+			envFile, projectData = envutil.ProcessEnvFiles(ctx, logger, dir, theproject, projectData, apiUrl, token, force, false, envProfile)
 
 			if tui.HasTTY {
 				_, localIssues, remoteIssues, err := buildAgentTree(keys, state, context)
@@ -330,8 +632,11 @@ Examples:
 				Config:        deploymentConfig,
 				OSEnvironment: loadOSEnv(),
 				PromptHelpers: createPromptHelper(),
+
+				SkipCompatCheck: skipCompatCheck,
 			}, noBuild)
 			if err != nil {
+				exitOnSDKCompatibilityError(err)
 				errsystem.New(errsystem.ErrDeployProject, err).ShowErrorAndExit()
 			}
 
@@ -435,12 +740,19 @@ Examples:
 				Type: originType,
 				Data: data,
 			},
+			Labels: labels,
+			Scale:  scale,
 		}
 
 		startRequest.Tags = tags
 		startRequest.TagDescription = description
 		startRequest.TagMessage = message
 		startRequest.UsePrivateKey = true
+		startRequest.RequireApproval, _ = cmd.Flags().GetBool("require-approval")
+		startRequest.Schemas = schemas
+		if lock != nil {
+			startRequest.LockId = lock.ID
+		}
 
 		// Collect prompts data if prompts feature flag is enabled
 		promptsEvalsFF := CheckFeatureFlag(cmd, FeaturePromptsEvals, "enable-prompts-evals")
@@ -455,6 +767,7 @@ Examples:
 		}
 
 		// Start deployment
+		ensureDeployLockHeld(ctx)
 		if err := client.Do("PUT", fmt.Sprintf("/cli/deploy/start/%s%s", theproject.ProjectId, deploymentId), startRequest, &startResponse); err != nil {
 			errsystem.New(errsystem.ErrDeployProject, err,
 				errsystem.WithContextMessage("Error starting deployment")).ShowErrorAndExit()
@@ -515,15 +828,81 @@ Examples:
 		}
 
 		if saveProject {
-			if err := theproject.Save(dir); err != nil {
+			if err := iproject.SaveProject(dir, theproject); err != nil {
 				errsystem.New(errsystem.ErrSaveProject, err,
 					errsystem.WithContextMessage("Error saving project with new Agents")).ShowErrorAndExit()
 			}
 			logger.Debug("saved project with updated Agents")
 		}
 
+		noSBOM, _ := cmd.Flags().GetBool("no-sbom")
+		if policy := loadLicensePolicy(dir); policy != nil || !noSBOM {
+			doc, err := generateSBOM(dir, theproject.Name)
+			switch {
+			case err != nil && policy != nil && policy.Fail():
+				errsystem.New(errsystem.ErrDeployProject, err,
+					errsystem.WithContextMessage("Error collecting the dependencies to check their licenses")).ShowErrorAndExit()
+			case err != nil:
+				// the SBOM is informational so a failure doesn't stop the deployment
+				logger.Warn("failed to collect the dependencies of the project: %s", err)
+			default:
+				if policy != nil {
+					if err := showLicenseViolations(policy, policy.Check(doc.Components)); err != nil {
+						errsystem.New(errsystem.ErrDeployProject, err,
+							errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+					}
+				}
+				if !noSBOM {
+					logger.Debug("attaching the SBOM with %d packages", len(doc.Components))
+					zipMutator = sbom.Mutator(doc, zipMutator)
+				}
+			}
+		}
+
 		rules := createProjectIgnoreRules(dir, theproject, false)
 
+		externals, err := deployer.FindExternalFiles(dir, externalize, func(fn string, fi os.FileInfo) bool {
+			return !rules.Ignore(fn, fi)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithContextMessage("Error finding the files to externalize")).ShowErrorAndExit()
+		}
+		externalized := make(map[string]bool)
+		if len(externals) > 0 {
+			for _, file := range externals {
+				externalized[file.Path] = true
+			}
+			manifest := &deployer.ExternalsManifest{Files: externals}
+			externalsAction := func() {
+				started := time.Now()
+				if dryRun != "" {
+					// nothing is uploaded in a dry run but the manifest still records the digests
+					for i, file := range manifest.Files {
+						digest, err := deployer.HashFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+						if err != nil {
+							errsystem.New(errsystem.ErrOpenFile, err,
+								errsystem.WithContextMessage("Error hashing external file")).ShowErrorAndExit()
+						}
+						manifest.Files[i].Digest = digest
+					}
+					return
+				}
+				manifest, err = deployer.UploadExternalFiles(ctx, logger, client, theproject.ProjectId, dir, externals, newTransferProgress(logger, fmt.Sprintf("Uploading %d external files", len(externals))))
+				if err != nil {
+					errsystem.New(errsystem.ErrUploadProject, err,
+						errsystem.WithContextMessage("Error uploading external files")).ShowErrorAndExit()
+				}
+				logger.Debug("uploaded %d external files in %v", len(externals), time.Since(started))
+			}
+			if dryRun != "" {
+				tui.ShowSpinner(fmt.Sprintf("Hashing %d external files ...", len(externals)), externalsAction)
+			} else {
+				externalsAction()
+			}
+			zipMutator = deployer.ExternalsMutator(manifest, zipMutator)
+		}
+
 		// create a temp file we're going to use for zip and upload
 		tmpfile, err := os.CreateTemp("", "agentuity-deploy-*.zip")
 		if err != nil {
@@ -539,6 +918,10 @@ Examples:
 			var seenGit, seenNodeModules, seenVenv bool
 			logger.Debug("creating a zip file of %s into %s", dir, tmpfile.Name())
 			if err := util.ZipDir(dir, tmpfile.Name(), util.WithMutator(zipMutator), util.WithMatcher(func(fn string, fi os.FileInfo) bool {
+				if externalized[filepath.ToSlash(fn)] {
+					logger.Trace("☁️ %s", fn)
+					return false
+				}
 				notok := rules.Ignore(fn, fi)
 				if notok {
 					if strings.HasPrefix(fn, ".git") {
@@ -573,6 +956,9 @@ Examples:
 
 		tui.ShowSpinner("Packaging ...", zipaction)
 
+		analyze, _ := cmd.Flags().GetBool("analyze")
+		checkDeploymentSize(logger, cmd, tmpfile.Name(), budget, analyze)
+
 		if dryRun != "" {
 
 			// Validate and create the dryRun directory if it doesn't exist
@@ -627,21 +1013,12 @@ Examples:
 
 		// check to see if the organization is configured to use a public key for encryption
 		if publicKey != "" {
-			block, _ := pem.Decode([]byte(publicKey))
-			if block == nil {
-				errsystem.New(errsystem.ErrEncryptingDeploymentZipFile, fmt.Errorf("failed to decode PEM formatted public key"),
-					errsystem.WithContextMessage("Error decoding the PEM formatted public key for encrypting the deployment zip file")).ShowErrorAndExit()
-			}
-			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			pubKey, fingerprint, err := keys.ParsePublicKey([]byte(publicKey))
 			if err != nil {
 				errsystem.New(errsystem.ErrEncryptingDeploymentZipFile, err,
 					errsystem.WithContextMessage("Error parsing the PEM formatted public key for encrypting the deployment zip file")).ShowErrorAndExit()
 			}
-			pubKey, ok := pub.(*ecdsa.PublicKey)
-			if !ok {
-				errsystem.New(errsystem.ErrEncryptingDeploymentZipFile, fmt.Errorf("unexpected public key type: %T", pub),
-					errsystem.WithContextMessage("Error parsing the PEM x509 public key for encrypting the deployment zip file")).ShowErrorAndExit()
-			}
+			logger.Debug("encrypting deployment with public key %s", fingerprint)
 			if _, err := crypto.EncryptFIPSKEMDEMStream(pubKey, dof, ef); err != nil {
 				errsystem.New(errsystem.ErrEncryptingDeploymentZipFile, err,
 					errsystem.WithContextMessage("Error encrypting deployment zip file (public key)")).ShowErrorAndExit()
@@ -670,45 +1047,36 @@ Examples:
 		started := time.Now()
 		var webhookToken string
 
+		uploadViaAPI, _ := cmd.Flags().GetBool("upload-via-api")
+		var upload *deployer.UploadResult
+		ensureDeployLockHeld(ctx)
 		uploadAction := func() {
-			url := util.TransformUrl(startResponse.Data.Url)
-			// send the zip file to the upload endpoint provided
-			logger.Trace("uploading to %s", url)
-			// NOTE: we don't use the apiclient here because we're not going to our api
-			req, err := http.NewRequest("PUT", url, ef)
-			if err != nil {
-				errsystem.New(errsystem.ErrUploadProject, err,
-					errsystem.WithContextMessage("Error creating PUT request")).ShowErrorAndExit()
-			}
-			req.ContentLength = fi.Size()
-			// NOTE: this is a one-time signed url so we don't need to add authorization header
-			req.Header.Set("Content-Type", "application/zip")
-			req.Header.Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
-
-			resp, err := http.DefaultClient.Do(req)
+			upload, err = deployer.UploadDeployment(ctx, logger, deployer.UploadRequest{
+				Filename:     ef.Name(),
+				SignedURL:    startResponse.Data.Url,
+				APIURL:       apiUrl,
+				Token:        token,
+				DeploymentID: startResponse.Data.DeploymentId,
+				ViaAPI:       uploadViaAPI,
+				Progress:     newTransferProgress(logger, "Uploading"),
+			})
 			if err != nil {
 				if err := updateDeploymentStatus(logger, apiUrl, token, startResponse.Data.DeploymentId, "failed"); err != nil {
 					errsystem.New(errsystem.ErrApiRequest, err,
 						errsystem.WithContextMessage("Error updating deployment status to failed")).ShowErrorAndExit()
 				}
+				ensureDeployLockHeld(ctx)
 				errsystem.New(errsystem.ErrUploadProject, err,
-					errsystem.WithContextMessage("Error deploying project")).ShowErrorAndExit()
-			}
-			if resp.StatusCode > 299 {
-				buf, _ := io.ReadAll(resp.Body)
-				if err := updateDeploymentStatus(logger, apiUrl, token, startResponse.Data.DeploymentId, "failed"); err != nil {
-					errsystem.New(errsystem.ErrApiRequest, err,
-						errsystem.WithContextMessage("Error updating deployment status to failed")).ShowErrorAndExit()
-				}
-				errsystem.New(errsystem.ErrUploadProject, nil,
-					errsystem.WithContextMessage(fmt.Sprintf("Unexpected response (status %d): %s", resp.StatusCode, string(buf))),
-					errsystem.WithUserMessage("Unexpected response from API for deployment")).ShowErrorAndExit()
+					errsystem.WithContextMessage("Error deploying project"),
+					errsystem.WithUserMessage("Failed to upload the deployment: %s", err)).ShowErrorAndExit()
 			}
-			resp.Body.Close()
-			logger.Debug("deployment uploaded %d bytes in %v", fi.Size(), time.Since(started))
+			logger.Debug("deployment uploaded %d bytes in %v (via api: %v)", fi.Size(), time.Since(started), upload.ViaAPI)
 		}
 
-		tui.ShowSpinner("Uploading ...", uploadAction)
+		uploadAction()
+		if upload.DirectError != nil {
+			tui.ShowWarning("The upload to the storage failed (%s) so the deployment was uploaded through the Agentuity API. Use --upload-via-api to skip the direct upload on this network.", upload.DirectError)
+		}
 
 		deployAction := func() {
 			// tell the api that we've completed the upload for the deployment
@@ -730,6 +1098,32 @@ Examples:
 		tui.ShowSpinner("Deploying ...", deployAction)
 
 		format, _ := cmd.Flags().GetString("format")
+
+		var approval *iproject.DeploymentApproval
+		if startRequest.RequireApproval {
+			approvalUrl := fmt.Sprintf("%s/projects/%s/deployments/%s", appUrl, theproject.ProjectId, startResponse.Data.DeploymentId)
+			if startResponse.Data.ApprovalUrl != nil {
+				approvalUrl = *startResponse.Data.ApprovalUrl
+			}
+			timeout, _ := cmd.Flags().GetDuration("approval-timeout")
+			approval = waitForDeploymentApproval(ctx, logger, apiUrl, token, theproject.ProjectId, startResponse.Data.DeploymentId, approvalUrl, timeout, format)
+		}
+
+		// the deployment is built and started in the cloud after the upload
+		var triage *deployer.TriageReport
+		if statusTimeout, _ := cmd.Flags().GetDuration("status-timeout"); statusTimeout > 0 && (approval == nil || approval.State == iproject.DeploymentApprovalApproved) {
+			triage = waitForDeploymentResult(ctx, logger, apiUrl, token, theproject.ProjectId, startResponse.Data.DeploymentId, statusTimeout, deploymentConfig)
+		}
+		if triage != nil {
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(map[string]any{"deployment_id": startResponse.Data.DeploymentId, "failure": triage})
+			} else {
+				showTriageReport(triage)
+			}
+			errsystem.New(errsystem.ErrDeployProject, errors.New(triage.Reason),
+				errsystem.WithUserMessage("The deployment %s failed in the cloud: %s", startResponse.Data.DeploymentId, triage.Reason)).ShowErrorAndExit()
+		}
+
 		if format == "json" {
 			buf, _ := json.Marshal(theproject)
 			kv := map[string]any{}
@@ -737,6 +1131,13 @@ Examples:
 			kv["deployment_id"] = startResponse.Data.DeploymentId
 			kv["deployment_url"] = fmt.Sprintf("%s/projects/%s/deployments", appUrl, theproject.ProjectId)
 			kv["project_url"] = fmt.Sprintf("%s/projects/%s", appUrl, theproject.ProjectId)
+			if len(labels) > 0 {
+				kv["labels"] = labels
+			}
+			if approval != nil {
+				kv["approval_state"] = approval.State
+				kv["approval_url"] = approval.ApprovalURL
+			}
 			json.NewEncoder(os.Stdout).Encode(kv)
 		} else {
 			if tui.HasTTY {
@@ -753,90 +1154,539 @@ Examples:
 						body2 += tui.Body(fmt.Sprintf("· Send %s webhook POST request to\n  ", theproject.Agents[0].Name) + tui.Link("%s/webhook/%s", transportUrl, strings.Replace(theproject.Agents[0].ID, "agent_", "", 1)))
 					}
 
-					tui.ShowBanner("Your project was deployed successfully!", body+body2, true)
+					title := "Your project was deployed successfully!"
+					if approval != nil && approval.State == iproject.DeploymentApprovalPending {
+						title = "Your project was deployed and is pending approval"
+					}
+					tui.ShowBanner(title, body+body2, true)
 				}
 			}
 		}
 	},
 }
 
-func updateDeploymentStatus(logger logger.Logger, apiUrl, token, deploymentId, status string) error {
-	client := util.NewAPIClient(context.Background(), logger, apiUrl, token)
-	payload := map[string]string{"state": status}
-	return client.Do("PUT", fmt.Sprintf("/cli/deploy/upload/%s", deploymentId), payload, nil)
-}
-
-func updateDeploymentStatusCompleted(logger logger.Logger, apiUrl, token, deploymentId string, preview bool) error {
-	client := util.NewAPIClient(context.Background(), logger, apiUrl, token)
-	payload := map[string]any{"state": "completed", "preview": preview}
-	return client.Do("PUT", fmt.Sprintf("/cli/deploy/upload/%s", deploymentId), payload, nil)
-}
-
-var cloudRollbackCmd = &cobra.Command{
-	Use:   "rollback",
-	Short: "Rollback (undeploy) or delete a deployment from the cloud",
-	Long: `Rollback (undeploy) or delete a specific deployment for a project by selecting a project and deployment.
-
-Examples:
-  agentuity rollback
-  agentuity cloud rollback
-  agentuity rollback --tag name
-  agentuity rollback --delete
-`,
-	Args: cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
-		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-		defer cancel()
-		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
-		urls := util.GetURLs(logger)
-		apiUrl := urls.API
+// watchAndDeploy watches the project for changes and redeploys after the changes settle. Each
+// deployment runs in a child process (without --watch) so a failed deployment doesn't stop watching.
+func watchAndDeploy(ctx context.Context, logger logger.Logger, cmd *cobra.Command, dir string, theproject *project.Project, tags []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to get executable path")).ShowErrorAndExit()
+	}
+	args := append([]string{"deploy"}, forwardedFlags(cmd, "watch", "watch-debounce")...)
+	if !cmd.Flags().Changed("dir") {
+		args = append(args, "--dir", dir)
+	}
+	debounce, _ := cmd.Flags().GetDuration("watch-debounce")
+
+	deploy := func() {
+		c := exec.CommandContext(ctx, exe, args...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = os.Environ()
+		started := time.Now()
+		if err := c.Run(); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			tui.ShowWarning("Deployment failed: %s. Waiting for changes ...", err)
+			return
+		}
+		tui.ShowSuccess("Deployed to %s in %s. Waiting for changes ...", strings.Join(tags, ", "), time.Since(started).Round(time.Millisecond))
+	}
 
-		deleteFlag, _ := cmd.Flags().GetBool("delete")
-		dir, _ := cmd.Flags().GetString("dir")
+	changes := make(chan string, 1)
+	rules := createProjectIgnoreRules(dir, theproject, false)
+	watcher, err := dev.NewWatcher(logger, dir, rules, func(path string) {
+		select {
+		case changes <- path:
+		default:
+		}
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to start watcher: %s", err))).ShowErrorAndExit()
+	}
+	defer watcher.Close(logger)
 
-		var selectedProject string
-		if dir != "" {
-			proj := iproject.EnsureProject(ctx, cmd)
-			if proj.Project == nil {
-				errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("project not found")).ShowErrorAndExit()
-			}
-			selectedProject = proj.Project.ProjectId
-		} else {
-			projectId, _ := cmd.Flags().GetString("project")
-			if projectId != "" {
-				// look up the project by id
-				projects, err := iproject.ListProjects(ctx, logger, apiUrl, apikey)
-				if err != nil {
-					errsystem.New(errsystem.ErrApiRequest, err).ShowErrorAndExit()
-				}
-				for _, p := range projects {
-					if p.ID == projectId {
-						selectedProject = p.ID
-						break
+	tui.ShowSuccess("Watching %s for changes and deploying to %s", dir, strings.Join(tags, ", "))
+	deploy()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path := <-changes:
+			logger.Debug("%s has changed, waiting %s for changes to settle", path, debounce)
+			timer := time.NewTimer(debounce)
+		settle:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case path = <-changes:
+					logger.Trace("%s has changed", path)
+					if !timer.Stop() {
+						<-timer.C
 					}
-				}
-				if selectedProject == "" {
-					// this will never happen because we've already checked the project id
-					errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("project not found")).ShowErrorAndExit()
+					timer.Reset(debounce)
+				case <-timer.C:
+					break settle
 				}
 			}
+			deploy()
 		}
+	}
+}
 
-		question := "Select a project to rollback a deployment"
-		if deleteFlag {
-			question = "Select a project to delete a deployment"
+// waitForDeploymentApproval prints the approval URL and polls until the deployment is approved,
+// rejected or the timeout is reached. A timeout of zero returns immediately without waiting.
+func waitForDeploymentApproval(ctx context.Context, logger logger.Logger, apiUrl, token, projectId, deploymentId, approvalUrl string, timeout time.Duration, format string) *iproject.DeploymentApproval {
+	if format == "json" {
+		fmt.Fprintf(os.Stderr, "Deployment %s requires approval: %s\n", deploymentId, approvalUrl)
+	} else {
+		body := tui.Body("· Another member of your organization must approve this deployment at\n  "+tui.Link("%s", approvalUrl)) +
+			"\n\n" + tui.Body("· Or run ") + tui.Command("cloud approve "+deploymentId)
+		tui.ShowBanner("Deployment pending approval", body, false)
+	}
+	if timeout <= 0 {
+		return &iproject.DeploymentApproval{State: iproject.DeploymentApprovalPending, ApprovalURL: approvalUrl}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var approval *iproject.DeploymentApproval
+	tui.ShowSpinner("Waiting for approval ...", func() {
+		approval, _ = iproject.WaitForDeploymentApproval(waitCtx, logger, apiUrl, token, projectId, deploymentId, 5*time.Second)
+	})
+	if approval == nil {
+		if isCancelled(ctx) {
+			errsystem.ShowCancelledAndExit()
 		}
+		errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("timed out waiting for approval after %s", timeout),
+			errsystem.WithUserMessage("The deployment was not approved in time. It will remain pending until approved with: agentuity cloud approve %s", deploymentId)).ShowErrorAndExit()
+	}
+	if approval.State == iproject.DeploymentApprovalRejected {
+		errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("deployment %s was rejected", deploymentId),
+			errsystem.WithUserMessage("The deployment was rejected by %s", approval.ApprovedBy)).ShowErrorAndExit()
+	}
+	if approval.ApprovalURL == "" {
+		approval.ApprovalURL = approvalUrl
+	}
+	return approval
+}
 
-		if selectedProject == "" {
-			selectedProject = cloudSelectProject(ctx, logger, apiUrl, apikey, question)
-		}
+// deploymentFailureLogs returns the logs of the deployment for its triage when the failure
+// doesn't include them
+func deploymentFailureLogs(ctx context.Context, logger logger.Logger, apiUrl, token, deploymentId string) []string {
+	query := url.Values{}
+	query.Set("deployment", deploymentId)
+	query.Set("startDate", time.Now().Add(-1*time.Hour).Format(time.RFC3339))
+	client := util.NewAPIClient(ctx, logger, apiUrl, token)
+	var response LogsResponse
+	if err := client.Do("GET", fmt.Sprintf("/cli/logs?%s", query.Encode()), nil, &response); err != nil {
+		logger.Debug("failed to get the logs of deployment %s: %s", deploymentId, err)
+		return nil
+	}
+	sort.Slice(response.Data, func(i, j int) bool { return response.Data[i].Timestamp.Before(response.Data[j].Timestamp) })
+	var lines []string
+	for _, log := range response.Data {
+		lines = append(lines, log.Body)
+	}
+	return lines
+}
 
-		if selectedProject == "" {
-			return
+// waitForDeploymentResult waits until the deployment is built and started in the cloud and returns
+// the triage of its failure or nil when it is active. It stops waiting without a result when the
+// API doesn't report the state of the deployment or the timeout is reached.
+func waitForDeploymentResult(ctx context.Context, logger logger.Logger, apiUrl, token, projectId, deploymentId string, timeout time.Duration, config *iproject.DeploymentConfig) *deployer.TriageReport {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var detail *iproject.DeploymentDetail
+	action := func() {
+		ticker := time.NewTicker(3 * time.Second)
+		defer ticker.Stop()
+		for {
+			res, err := iproject.GetDeployment(ctx, logger, apiUrl, token, projectId, deploymentId)
+			if err != nil {
+				logger.Debug("error checking the deployment state: %s", err)
+			} else if res.State != iproject.DeploymentStateBuilding && res.State != iproject.DeploymentStateStarting {
+				detail = res
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
 		}
+	}
+	tui.ShowSpinner("Waiting for the deployment to start ...", action)
+	if detail == nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			tui.ShowWarning("The deployment %s is still in progress after %s", deploymentId, timeout)
+		}
+		return nil
+	}
+	if detail.State != iproject.DeploymentStateFailed {
+		logger.Debug("deployment %s is %s", deploymentId, detail.State)
+		return nil
+	}
+	failure := iproject.DeploymentFailure{}
+	if detail.Failure != nil {
+		failure = *detail.Failure
+	}
+	if len(failure.Logs) == 0 {
+		failure.Logs = deploymentFailureLogs(context.Background(), logger, apiUrl, token, deploymentId)
+	}
+	report := deployer.Triage(failure, config.Language, config.Command)
+	return &report
+}
 
-		// Try to get tag flag
+// showTriageReport prints why the deployment failed with the suggested fixes
+func showTriageReport(report *deployer.TriageReport) {
+	title := "The deployment failed"
+	switch report.Phase {
+	case iproject.FailureBuild:
+		title = "The deployment failed to build"
+	case iproject.FailureBoot:
+		title = "The deployment failed to start"
+	}
+	body := tui.Body(report.Reason)
+	if len(report.Excerpt) > 0 {
+		body += "\n\n" + tui.Bold("Logs") + "\n" + tui.Muted(strings.Join(report.Excerpt, "\n"))
+	}
+	if len(report.Suggestions) > 0 {
+		body += "\n\n" + tui.Bold("Suggested fixes")
+		for _, s := range report.Suggestions {
+			body += "\n" + tui.Body("· "+s)
+		}
+	}
+	tui.ShowBanner(title, body, false)
+}
+
+// deployLockHolder describes this deploy to the other deploys which are blocked by its lock
+func deployLockHolder(logger logger.Logger, cmd *cobra.Command, dir string, ci bool) iproject.DeployLockHolder {
+	machine := deployer.GetMachineInfo()
+	holder := iproject.DeployLockHolder{Username: machine.Username, Hostname: machine.Hostname, Origin: "cli"}
+	if ci {
+		holder.Origin = "ci"
+		holder.Branch, _ = cmd.Flags().GetString("ci-branch")
+		holder.Commit, _ = cmd.Flags().GetString("ci-commit")
+		holder.LogsURL, _ = cmd.Flags().GetString("ci-logs-url")
+	}
+	if holder.Branch == "" && holder.Commit == "" {
+		info, err := deployer.GetGitInfoRecursive(logger, dir)
+		if err != nil {
+			logger.Debug("failed to get git info: %v", err)
+		} else if info.IsRepo {
+			if info.Branch != nil {
+				holder.Branch = *info.Branch
+			}
+			if info.Commit != nil {
+				holder.Commit = *info.Commit
+			}
+		}
+	}
+	return holder
+}
+
+// acquireDeployLock acquires the lock to deploy the tags. When another deploy of the tags is in
+// progress it waits for it with --wait, takes over its lock with --force-takeover or otherwise asks
+// what to do. It returns false when the deploy is cancelled, and a nil lock when the API doesn't
+// support deploy locks, in which case the deploy continues without one.
+func acquireDeployLock(ctx context.Context, logger logger.Logger, cmd *cobra.Command, theproject iproject.ProjectContext, tags []string, holder iproject.DeployLockHolder) (*iproject.DeployLock, bool) {
+	wait, _ := cmd.Flags().GetBool("wait")
+	takeover, _ := cmd.Flags().GetBool("force-takeover")
+	if wait && takeover {
+		errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--wait cannot be used with --force-takeover"),
+			errsystem.WithUserMessage("The --wait and --force-takeover flags cannot be used together")).ShowErrorAndExit()
+	}
+	projectId := theproject.Project.ProjectId
+	acquire := func(takeover bool) *iproject.DeployLockResult {
+		var result *iproject.DeployLockResult
+		var err error
+		tui.ShowSpinner("Acquiring the deploy lock ...", func() {
+			result, err = iproject.AcquireDeployLock(ctx, logger, theproject.APIURL, theproject.Token, projectId, tags, holder, takeover)
+		})
+		if errors.Is(err, iproject.ErrDeployLockUnsupported) {
+			return nil
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Error acquiring the deploy lock")).ShowErrorAndExit()
+		}
+		return result
+	}
+
+	result := acquire(false)
+	if result == nil {
+		logger.Debug("deploying without a deploy lock since the API doesn't support them")
+		return nil, true
+	}
+	if result.Acquired {
+		return &result.Lock, true
+	}
+	other := result.Lock
+	inProgress := fmt.Sprintf("A deploy of %s started by %s %s ago is in progress", strings.Join(other.Tags, ", "), other.Holder, time.Since(other.AcquiredAt).Round(time.Second))
+
+	if !wait && !takeover {
+		if !tui.HasTTY {
+			errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("another deploy of %s is in progress (lock %s)", strings.Join(other.Tags, ", "), other.ID),
+				errsystem.WithUserMessage("%s. Use --wait to deploy once it has finished or --force-takeover to take over its lock.", inProgress)).ShowErrorAndExit()
+		}
+		switch tui.Select(logger, inProgress, "What do you want to do?", []tui.Option{
+			{ID: "wait", Text: "Wait for it to finish and then deploy"},
+			{ID: "takeover", Text: "Take over its lock and deploy now"},
+			{ID: "cancel", Text: "Cancel"},
+		}) {
+		case "wait":
+			wait = true
+		case "takeover":
+			takeover = true
+		default:
+			tui.ShowWarning("cancelled")
+			return nil, false
+		}
+	}
+
+	if takeover {
+		result = acquire(true)
+		if result == nil || !result.Acquired {
+			errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("failed to take over the deploy lock %s", other.ID),
+				errsystem.WithUserMessage("The deploy lock held by %s could not be taken over", other.Holder)).ShowErrorAndExit()
+		}
+		tui.ShowWarning("Took over the deploy lock from %s", other.Holder)
+		return &result.Lock, true
+	}
+
+	tui.ShowWarning("%s", inProgress)
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var lock *iproject.DeployLock
+	action := func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-waitCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			res, err := iproject.AcquireDeployLock(waitCtx, logger, theproject.APIURL, theproject.Token, projectId, tags, holder, false)
+			if err != nil {
+				logger.Debug("error acquiring the deploy lock: %s", err)
+			} else if res.Acquired {
+				lock = &res.Lock
+				return
+			}
+		}
+	}
+	tui.ShowSpinner("Waiting for the other deploy to finish ...", action)
+	if lock == nil {
+		if isCancelled(ctx) {
+			errsystem.ShowCancelledAndExit()
+		}
+		errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("timed out waiting for the deploy lock after %s", timeout),
+			errsystem.WithUserMessage("The other deploy didn't finish within %s. Use --wait-timeout to wait longer or --force-takeover to take over its lock.", timeout)).ShowErrorAndExit()
+	}
+	return lock, true
+}
+
+var errDeployLockLost = errors.New("the deploy lock was lost")
+
+// holdDeployLock renews the lock until the returned function is called, which releases it. The
+// returned context is cancelled when the lock can't be renewed, such as when another deploy took it
+// over, so that the deploy stops instead of racing with the other one. A deploy which exits early
+// doesn't release its lock, which expires since it's no longer renewed.
+func holdDeployLock(ctx context.Context, logger logger.Logger, theproject iproject.ProjectContext, lock *iproject.DeployLock) (context.Context, func()) {
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(iproject.DeployLockRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if _, err := iproject.RenewDeployLock(lockCtx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, lock.ID); err != nil {
+				logger.Debug("failed to renew the deploy lock %s: %s", lock.ID, err)
+				cancel(errDeployLockLost)
+				return
+			}
+		}
+	}()
+	return lockCtx, func() {
+		close(done)
+		cancel(nil)
+		if err := iproject.ReleaseDeployLock(context.Background(), logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, lock.ID); err != nil {
+			logger.Debug("failed to release the deploy lock: %s", err)
+		}
+	}
+}
+
+// ensureDeployLockHeld exits when the deploy lock of the context from holdDeployLock was lost
+func ensureDeployLockHeld(ctx context.Context) {
+	if errors.Is(context.Cause(ctx), errDeployLockLost) {
+		errsystem.New(errsystem.ErrDeployProject, errDeployLockLost,
+			errsystem.WithUserMessage("The deploy lock was lost, another deploy may have taken it over with --force-takeover. The deploy was stopped so that it doesn't race with the other one.")).ShowErrorAndExit()
+	}
+}
+
+func updateDeploymentStatus(logger logger.Logger, apiUrl, token, deploymentId, status string) error {
+	client := util.NewAPIClient(context.Background(), logger, apiUrl, token)
+	payload := map[string]string{"state": status}
+	return client.Do("PUT", fmt.Sprintf("/cli/deploy/upload/%s", deploymentId), payload, nil)
+}
+
+func updateDeploymentStatusCompleted(logger logger.Logger, apiUrl, token, deploymentId string, preview bool) error {
+	client := util.NewAPIClient(context.Background(), logger, apiUrl, token)
+	payload := map[string]any{"state": "completed", "preview": preview}
+	return client.Do("PUT", fmt.Sprintf("/cli/deploy/upload/%s", deploymentId), payload, nil)
+}
+
+var cloudApproveCmd = &cobra.Command{
+	Use:   "approve [deploymentId]",
+	Short: "Approve a deployment which is pending approval",
+	Long: `Approve (or reject) a deployment which was created with --require-approval.
+
+The deployment must be approved by a different member of the organization than
+the one who requested it. Once approved, the deployment is activated.
+
+Arguments:
+  [deploymentId]    The deployment to approve
+
+Examples:
+  agentuity cloud approve <deploymentId>
+  agentuity cloud approve <deploymentId> --project <projectId>
+  agentuity cloud approve <deploymentId> --reject --comment "missing change ticket"
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		apiUrl := urls.API
+
+		deploymentId := args[0]
+		reject, _ := cmd.Flags().GetBool("reject")
+		comment, _ := cmd.Flags().GetString("comment")
+		force, _ := cmd.Flags().GetBool("force")
+
+		selectedProject, _ := cmd.Flags().GetString("project")
+		if selectedProject == "" {
+			if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+				selectedProject = iproject.EnsureProject(ctx, cmd).Project.ProjectId
+			} else {
+				selectedProject = cloudSelectProject(ctx, logger, apiUrl, apikey, "Select the project of the deployment to approve")
+			}
+		}
+		if selectedProject == "" {
+			return
+		}
+
+		what, spinner := "approve", "Approving deployment ..."
+		if reject {
+			what, spinner = "reject", "Rejecting deployment ..."
+		}
+		if !force && tui.HasTTY {
+			if !tui.Ask(logger, fmt.Sprintf("Are you sure you want to %s deployment %s?", what, deploymentId), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		var approval *iproject.DeploymentApproval
+		action := func() {
+			var err error
+			approval, err = iproject.ApproveDeployment(ctx, logger, apiUrl, apikey, selectedProject, deploymentId, !reject, comment)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to %s deployment", what))).ShowErrorAndExit()
+			}
+		}
+		tui.ShowSpinner(spinner, action)
+
+		if approval.State == iproject.DeploymentApprovalRejected {
+			tui.ShowSuccess("Deployment %s rejected", deploymentId)
+		} else {
+			tui.ShowSuccess("Deployment %s approved", deploymentId)
+		}
+	},
+}
+
+var cloudRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Rollback (undeploy) or delete a deployment from the cloud",
+	Long: `Rollback (undeploy) or delete a specific deployment for a project by selecting a project and deployment.
+
+Examples:
+  agentuity rollback
+  agentuity cloud rollback
+  agentuity rollback --tag name
+  agentuity rollback --delete
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		apiUrl := urls.API
+
+		deleteFlag, _ := cmd.Flags().GetBool("delete")
+		dir, _ := cmd.Flags().GetString("dir")
+
+		var selectedProject string
+		if dir != "" {
+			proj := iproject.EnsureProject(ctx, cmd)
+			if proj.Project == nil {
+				errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("project not found")).ShowErrorAndExit()
+			}
+			selectedProject = proj.Project.ProjectId
+		} else {
+			projectId, _ := cmd.Flags().GetString("project")
+			if projectId != "" {
+				// look up the project by id
+				projects, err := iproject.ListProjects(ctx, logger, apiUrl, apikey)
+				if err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err).ShowErrorAndExit()
+				}
+				for _, p := range projects {
+					if p.ID == projectId {
+						selectedProject = p.ID
+						break
+					}
+				}
+				if selectedProject == "" {
+					// this will never happen because we've already checked the project id
+					errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("project not found")).ShowErrorAndExit()
+				}
+			}
+		}
+
+		question := "Select a project to rollback a deployment"
+		if deleteFlag {
+			question = "Select a project to delete a deployment"
+		}
+
+		if selectedProject == "" {
+			selectedProject = cloudSelectProject(ctx, logger, apiUrl, apikey, question)
+		}
+
+		if selectedProject == "" {
+			return
+		}
+
+		if deleteFlag {
+			ensurePermission(ctx, logger, apiUrl, apikey, selectedProject, auth.PermissionDeploymentDelete, "delete deployments")
+		}
+
+		// Try to get tag flag
 		tag, _ := cmd.Flags().GetString("tag")
 		var selectedDeployment string
 		if tag != "" {
@@ -989,15 +1839,17 @@ func cloudSelectDeployment(ctx context.Context, logger logger.Logger, apiUrl, ap
 var cloudDeploymentsCmd = &cobra.Command{
 	Use:   "deployments",
 	Short: "List deployments for a project",
-	Long: `List all deployments for a selected project, showing which is active and their tags.
+	Long: `List all deployments for a selected project, showing which is active, their tags
+and their labels.
 
 Examples:
   agentuity cloud deployments
   agentuity cloud deployments --project <projectId>
+  agentuity cloud deployments get <deploymentId>
 `,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
@@ -1028,17 +1880,20 @@ Examples:
 		}
 		tui.ShowSpinner("fetching deployments ...", action)
 
-		if len(deployments) == 0 {
-			tui.ShowWarning("no deployments found for this project")
+		if format == "json" {
+			if deployments == nil {
+				deployments = []iproject.DeploymentListData{}
+			}
+			json.NewEncoder(os.Stdout).Encode(deployments)
 			return
 		}
 
-		if format == "json" {
-			json.NewEncoder(os.Stdout).Encode(deployments)
+		if len(deployments) == 0 {
+			tui.ShowWarning("no deployments found for this project")
 			return
 		}
 
-		headers := []string{"Active", "Deployment Id", "Tags", "Message", "Created At"}
+		headers := []string{"Active", "Deployment Id", "Tags", "Labels", "Message", "Created At"}
 		rows := [][]string{}
 		for _, d := range deployments {
 			active := ""
@@ -1051,62 +1906,973 @@ Examples:
 				msg = msg[:57] + "..."
 			}
 			created := d.CreatedAt
-			rows = append(rows, []string{active, tui.Muted(d.ID), tui.Bold(tags), tui.Text(msg), tui.Title(created)})
+			rows = append(rows, []string{active, tui.Muted(d.ID), tui.Bold(tags), tui.Muted(iproject.FormatDeploymentLabels(d.Labels)), tui.Text(msg), tui.Title(created)})
 		}
 		tui.Table(headers, rows)
 	},
 }
 
-// collectPromptsData collects prompts data from the project directory
-func collectPromptsData(logger logger.Logger, dir string) ([]DeployPrompt, error) {
-	// Find all prompt files
-	promptFiles := prompts.FindAllPromptFiles(dir)
-	if len(promptFiles) == 0 {
-		logger.Debug("No prompt files found")
-		return nil, nil
+// resolveDeployment returns the id of the deployment with the id or tag
+func resolveDeployment(deployments []iproject.DeploymentListData, idOrTag string) string {
+	for _, d := range deployments {
+		if d.ID == idOrTag {
+			return d.ID
+		}
+	}
+	for _, d := range deployments {
+		if slices.Contains(d.Tags, idOrTag) {
+			return d.ID
+		}
 	}
+	errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("deployment %s not found", idOrTag),
+		errsystem.WithUserMessage("No deployment with the id or tag %s was found in the project", idOrTag)).ShowErrorAndExit()
+	return ""
+}
 
-	logger.Debug("Found %d prompt files: %v", len(promptFiles), promptFiles)
+func printSetDiff(title string, diff iproject.SetDiff) {
+	if diff.Empty() {
+		return
+	}
+	fmt.Println(tui.Bold(title))
+	for _, v := range diff.Added {
+		fmt.Printf("  %s %s\n", tui.Secondary("+"), v)
+	}
+	for _, v := range diff.Removed {
+		fmt.Printf("  %s %s\n", tui.Warning("-"), v)
+	}
+	for _, v := range diff.Changed {
+		fmt.Printf("  %s %s\n", tui.Muted("~"), v)
+	}
+	fmt.Println()
+}
 
-	var allPrompts []DeployPrompt
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	if commit == "" {
+		return "none"
+	}
+	return commit
+}
 
-	// Parse all prompt files and combine prompts
-	for _, promptFile := range promptFiles {
-		data, err := os.ReadFile(promptFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read %s: %w", promptFile, err)
-		}
+var cloudDeploymentsGetCmd = &cobra.Command{
+	Use:   "get [id]",
+	Short: "Show a deployment",
+	Long: `Show the metadata of a deployment: its tags, labels, git commit, agents, scale and resources.
 
-		promptsList, err := prompts.ParsePromptsYAML(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", promptFile, err)
+Arguments:
+  [id]      The deployment id or tag, defaults to the active deployment
+
+Examples:
+  agentuity cloud deployments get <deploymentId>
+  agentuity cloud deployments get staging
+  agentuity cloud deployments get --format json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		format, _ := cmd.Flags().GetString("format")
+
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the deployment")
+		if projectId == "" {
+			return
 		}
 
-		// Convert to DeployPrompt format
-		for _, prompt := range promptsList {
-			deployPrompt := DeployPrompt{
-				Slug:        prompt.Slug,
-				Name:        prompt.Name,
-				Description: &prompt.Description,
+		var deployments []iproject.DeploymentListData
+		tui.ShowSpinner("fetching deployments ...", func() {
+			var err error
+			deployments, err = iproject.ListDeployments(ctx, logger, apiUrl, apikey, projectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
 			}
+		})
 
-			// Convert system prompt
-			if prompt.System != "" {
-				deployPrompt.System = &prompt.System
+		var deploymentId string
+		if len(args) > 0 {
+			deploymentId = resolveDeployment(deployments, args[0])
+		} else {
+			for _, d := range deployments {
+				if d.Active {
+					deploymentId = d.ID
+				}
+			}
+			if deploymentId == "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no active deployment"),
+					errsystem.WithUserMessage("The project has no active deployment, please specify the deployment to show")).ShowErrorAndExit()
 			}
+		}
 
-			// Convert user prompt
-			if prompt.Prompt != "" {
-				deployPrompt.Prompt = &prompt.Prompt
+		var deployment *iproject.DeploymentDetail
+		tui.ShowSpinner("fetching deployment details ...", func() {
+			var err error
+			deployment, err = iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, deploymentId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
 			}
+		})
 
-			// Convert variables from templates
-			var variables []PromptVariable
-			if prompt.SystemTemplate.Variables != nil {
-				for _, v := range prompt.SystemTemplate.Variables {
-					variables = append(variables, PromptVariable{
-						Name:     v.Name,
-						Required: v.IsRequired,
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(deployment)
+			return
+		}
+
+		rows := [][]string{
+			{"Deployment Id", deployment.ID},
+			{"Active", fmt.Sprintf("%v", deployment.Active)},
+			{"Tags", strings.Join(deployment.Tags, ", ")},
+			{"Labels", iproject.FormatDeploymentLabels(deployment.Labels)},
+			{"Message", deployment.Message},
+			{"Created At", deployment.CreatedAt},
+		}
+		if deployment.Git != nil {
+			commit := shortCommit(deployment.Git.Commit)
+			if checkout := deployment.Git.Checkout(); len(checkout) > 0 {
+				commit += tui.Muted(" (" + strings.Join(checkout, ", ") + ")")
+			}
+			rows = append(rows, []string{"Branch", deployment.Git.Branch}, []string{"Commit", commit})
+		}
+		agents := make([]string, len(deployment.Agents))
+		for i, a := range deployment.Agents {
+			agents[i] = a.Name
+		}
+		rows = append(rows, []string{"Agents", strings.Join(agents, ", ")})
+		if deployment.Scale != nil {
+			rows = append(rows, []string{"Scale", formatScale(deployment.Scale)})
+		}
+		if deployment.Digest != "" {
+			rows = append(rows, []string{"Digest", deployment.Digest})
+		}
+		for _, row := range rows {
+			fmt.Printf("%s %s\n", tui.Bold(tui.PadRight(row[0], 14, " ")), row[1])
+		}
+	},
+}
+
+var cloudDeploymentsDiffCmd = &cobra.Command{
+	Use:   "diff [from] [to]",
+	Short: "Compare two deployments",
+	Long: `Compare the metadata of two deployments: the git commit, labels, agents, environment variable
+and secret keys, resources and the digests of the bundled files.
+
+When the project is in a local git repository the commits between the two deployments
+are listed as well.
+
+Arguments:
+  [from]    The deployment id or tag to compare from, prompts for one if not provided
+  [to]      The deployment id or tag to compare to, defaults to the active deployment
+
+Flags:
+  --files   Show the changed files of the bundle
+
+Examples:
+  agentuity cloud deployments diff <deploymentId> <deploymentId>
+  agentuity cloud deployments diff staging latest
+  agentuity cloud deployments diff <deploymentId> --format json`,
+	Args: cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		format, _ := cmd.Flags().GetString("format")
+
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the deployments")
+		if projectId == "" {
+			return
+		}
+
+		var deployments []iproject.DeploymentListData
+		tui.ShowSpinner("fetching deployments ...", func() {
+			var err error
+			deployments, err = iproject.ListDeployments(ctx, logger, apiUrl, apikey, projectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
+			}
+		})
+
+		var fromId, toId string
+		if len(args) > 0 {
+			fromId = resolveDeployment(deployments, args[0])
+		} else {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please specify the deployments to compare")
+			}
+			fromId = cloudSelectDeployment(ctx, logger, apiUrl, apikey, projectId, "Select the deployment to compare from")
+		}
+		if len(args) > 1 {
+			toId = resolveDeployment(deployments, args[1])
+		} else {
+			for _, d := range deployments {
+				if d.Active {
+					toId = d.ID
+				}
+			}
+			if toId == "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no active deployment"),
+					errsystem.WithUserMessage("The project has no active deployment, please specify the deployment to compare to")).ShowErrorAndExit()
+			}
+		}
+
+		var from, to *iproject.DeploymentDetail
+		tui.ShowSpinner("fetching deployment details ...", func() {
+			var err error
+			from, err = iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, fromId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+			to, err = iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, toId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+		})
+
+		diff := iproject.DiffDeployments(from, to)
+		if diff.Commit != nil && diff.Commit.From != "" && diff.Commit.To != "" {
+			// the shortlog is best effort since the project may not be in the repository of the deployments
+			dir := iproject.ResolveProjectDir(logger, cmd, false)
+			if commits, err := deployer.GitShortlog(logger, dir, diff.Commit.From, diff.Commit.To, 50); err == nil {
+				diff.Commits = commits
+			} else {
+				logger.Debug("failed to get the commits between the deployments: %s", err)
+			}
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(diff)
+			return
+		}
+
+		fmt.Printf("Comparing %s → %s\n\n", tui.Bold(fromId), tui.Bold(toId))
+		if diff.Empty() {
+			tui.ShowSuccess("No differences between the deployments")
+			return
+		}
+		if diff.Commit != nil || diff.Branch != nil {
+			fmt.Println(tui.Bold("Git"))
+			if diff.Branch != nil {
+				fmt.Printf("  branch  %s → %s\n", diff.Branch.From, diff.Branch.To)
+			}
+			if diff.Commit != nil {
+				fmt.Printf("  commit  %s → %s\n", shortCommit(diff.Commit.From), shortCommit(diff.Commit.To))
+			}
+			for _, c := range diff.Commits {
+				fmt.Printf("    %s\n", tui.Muted(c))
+			}
+			fmt.Println()
+		}
+		if !diff.Labels.Empty() {
+			fmt.Println(tui.Bold("Labels"))
+			for _, k := range diff.Labels.Added {
+				fmt.Printf("  %s %s=%s\n", tui.Secondary("+"), k, to.Labels[k])
+			}
+			for _, k := range diff.Labels.Removed {
+				fmt.Printf("  %s %s=%s\n", tui.Warning("-"), k, from.Labels[k])
+			}
+			for _, k := range diff.Labels.Changed {
+				fmt.Printf("  %s %s  %s → %s\n", tui.Muted("~"), k, from.Labels[k], to.Labels[k])
+			}
+			fmt.Println()
+		}
+		printSetDiff("Agents", diff.Agents)
+		printSetDiff("Environment Variables", diff.Env)
+		printSetDiff("Secrets", diff.Secrets)
+		if len(diff.Resources) > 0 {
+			fmt.Println(tui.Bold("Resources"))
+			for _, name := range []string{"memory", "cpu", "disk"} {
+				if c, ok := diff.Resources[name]; ok {
+					fmt.Printf("  %s %s → %s\n", tui.PadRight(name, 7, " "), c.From, c.To)
+				}
+			}
+			fmt.Println()
+		}
+		if diff.Digest != nil || !diff.Files.Empty() {
+			fmt.Println(tui.Bold("Bundle"))
+			if diff.Digest != nil {
+				fmt.Printf("  digest  %s → %s\n", tui.Muted(diff.Digest.From), tui.Muted(diff.Digest.To))
+			}
+			if !diff.Files.Empty() {
+				fmt.Printf("  %d added, %d removed, %d changed files\n", len(diff.Files.Added), len(diff.Files.Removed), len(diff.Files.Changed))
+			}
+			fmt.Println()
+			if showFiles, _ := cmd.Flags().GetBool("files"); showFiles {
+				printSetDiff("Files", diff.Files)
+			}
+		}
+	},
+}
+
+var cloudReleaseNotesCmd = &cobra.Command{
+	Use:   "release-notes",
+	Short: "Generate the release notes of the changes between two deployments",
+	Long: `Generate the release notes of the changes between two deployments from their git metadata.
+
+The commits between the deployments are read from the local git repository with their
+messages and authors. When the commits aren't in the local repository, such as in a
+shallow clone in CI, the commits recorded with the deployments made in between are
+used instead.
+
+The release notes can be posted as the message of the deployment they lead to, which
+is shown when listing the deployments.
+
+Flags:
+  --from      The deployment id or tag to start from
+  --to        The deployment id or tag to end at (default latest)
+  --format    The output format (markdown or json)
+  --post      Set the release notes as the message of the --to deployment
+  --limit     The maximum number of commits to include
+
+Examples:
+  agentuity cloud release-notes --from v1.2.0 --to v1.3.0
+  agentuity cloud release-notes --from staging --format json | jq -r '.commits[].subject'
+  agentuity cloud release-notes --from v1.2.0 --to v1.3.0 --post`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		fromRef, _ := cmd.Flags().GetString("from")
+		toRef, _ := cmd.Flags().GetString("to")
+		format, _ := cmd.Flags().GetString("format")
+		post, _ := cmd.Flags().GetBool("post")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		if fromRef == "" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("missing --from"),
+				errsystem.WithUserMessage("Please specify the deployment to start from with --from")).ShowErrorAndExit()
+		}
+		if format != "markdown" && format != "json" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid format %s", format),
+				errsystem.WithUserMessage("Invalid format %s, must be either markdown or json", format)).ShowErrorAndExit()
+		}
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the deployments")
+		if projectId == "" {
+			return
+		}
+
+		var deployments []iproject.DeploymentListData
+		tui.ShowSpinner("fetching deployments ...", func() {
+			var err error
+			deployments, err = iproject.ListDeployments(ctx, logger, apiUrl, apikey, projectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
+			}
+		})
+		fromId := resolveDeployment(deployments, fromRef)
+		toId := resolveDeployment(deployments, toRef)
+		between, err := deployer.DeploymentsBetween(deployments, fromId, toId)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+				errsystem.WithUserMessage("Deployment %s must have been deployed before %s", fromRef, toRef)).ShowErrorAndExit()
+		}
+
+		var from, to *iproject.DeploymentDetail
+		tui.ShowSpinner("fetching deployment details ...", func() {
+			var err error
+			from, err = iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, fromId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+			to, err = iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, toId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+		})
+		var fromGit, toGit iproject.DeploymentGit
+		if from.Git != nil {
+			fromGit = *from.Git
+		}
+		if to.Git != nil {
+			toGit = *to.Git
+		}
+
+		source := deployer.ReleaseSourceGit
+		var commits []deployer.GitCommit
+		if fromGit.Commit != "" && toGit.Commit != "" {
+			dir := iproject.ResolveProjectDir(logger, cmd, false)
+			commits, err = deployer.GitLog(logger, dir, fromGit.Commit, toGit.Commit, limit)
+		} else {
+			err = fmt.Errorf("the deployments have no git commit")
+		}
+		if err != nil {
+			// fall back to the commits which were deployed in between
+			logger.Debug("failed to get the commits between the deployments from git: %s", err)
+			source = deployer.ReleaseSourceDeployments
+			details := []*iproject.DeploymentDetail{to}
+			tui.ShowSpinner("fetching the deployments in between ...", func() {
+				for _, d := range between {
+					if d.ID == toId {
+						continue
+					}
+					if len(details) >= limit {
+						break
+					}
+					detail, err := iproject.GetDeployment(ctx, logger, apiUrl, apikey, projectId, d.ID)
+					if err != nil {
+						errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+					}
+					details = append(details, detail)
+				}
+			})
+			commits = deployer.DeploymentCommits(details, fromGit.Commit)
+		}
+
+		fromTag, toTag := fromRef, toRef
+		if fromTag == fromId {
+			fromTag = ""
+		}
+		if toTag == toId {
+			toTag = ""
+		}
+		notes := deployer.NewReleaseNotes(deployer.NewReleaseDeployment(from, fromTag), deployer.NewReleaseDeployment(to, toTag), source, toGit.RemoteURL, commits)
+		markdown := notes.Markdown()
+
+		if post {
+			// the message of a deployment is set through one of its tags
+			tag := toTag
+			if tag == "" && len(to.Tags) > 0 {
+				tag = to.Tags[0]
+			}
+			if tag == "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("deployment %s has no tag", toId),
+					errsystem.WithUserMessage("Deployment %s has no tag to post the release notes with. Add one with %s.", toId, tui.Command("cloud tags add"))).ShowErrorAndExit()
+			}
+			updateDeploymentTag(ctx, logger, apiUrl, apikey, projectId, tag, iproject.DeploymentTagUpdate{Message: &markdown})
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(notes)
+		} else {
+			fmt.Print(markdown)
+		}
+		if post && tui.HasTTY {
+			fmt.Println()
+			tui.ShowSuccess("Posted the release notes to deployment %s", toId)
+		}
+	},
+}
+
+// cloudResolveProject returns the project from the --project flag, the project in the --dir flag or
+// prompts for one
+func cloudResolveProject(ctx context.Context, logger logger.Logger, cmd *cobra.Command, apiUrl, apikey string, prompt string) string {
+	if projectId, _ := cmd.Flags().GetString("project"); projectId != "" {
+		return projectId
+	}
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return iproject.EnsureProject(ctx, cmd).Project.ProjectId
+	}
+	if p := iproject.TryProject(ctx, cmd); p.Project != nil && p.Project.ProjectId != "" {
+		return p.Project.ProjectId
+	}
+	if !tui.HasTTY {
+		logger.Fatal("No TTY detected, please specify the project with --project or --dir")
+	}
+	return cloudSelectProject(ctx, logger, apiUrl, apikey, prompt)
+}
+
+// the latest tag marks the active deployment and is only changed by deploying or rolling back
+func ensureNotLatestTag(tag string) {
+	if tag == "latest" {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("the latest tag cannot be changed"),
+			errsystem.WithUserMessage("The latest tag marks the active deployment. Use %s or %s to change it.", tui.Command("deploy"), tui.Command("rollback"))).ShowErrorAndExit()
+	}
+}
+
+func findDeploymentTag(tags []iproject.DeploymentTag, name string) *iproject.DeploymentTag {
+	for i, t := range tags {
+		if t.Name == name {
+			return &tags[i]
+		}
+	}
+	return nil
+}
+
+func listDeploymentTags(ctx context.Context, logger logger.Logger, apiUrl, apikey, projectId string) []iproject.DeploymentTag {
+	var tags []iproject.DeploymentTag
+	tui.ShowSpinner("fetching tags ...", func() {
+		var err error
+		tags, err = iproject.ListDeploymentTags(ctx, logger, apiUrl, apikey, projectId)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployment tags")).ShowErrorAndExit()
+		}
+	})
+	return tags
+}
+
+func updateDeploymentTag(ctx context.Context, logger logger.Logger, apiUrl, apikey, projectId string, tag string, update iproject.DeploymentTagUpdate) *iproject.DeploymentTag {
+	var result *iproject.DeploymentTag
+	tui.ShowSpinner("updating tag ...", func() {
+		var err error
+		result, err = iproject.UpdateDeploymentTag(ctx, logger, apiUrl, apikey, projectId, tag, update)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to update the deployment tag")).ShowErrorAndExit()
+		}
+	})
+	return result
+}
+
+var cloudVerifyBundleCmd = &cobra.Command{
+	Use:   "verify-bundle <deploymentId>",
+	Short: "Verify that a deployment was built from its recorded git commit",
+	Long: `Verify that a deployment was built from its recorded git commit.
+
+This command checks out the git commit recorded with the deployment into a
+temporary worktree of the project repository, fetching it from origin when
+needed, and rebuilds the bundle the same way as a deploy. The digests of the
+files which would be deployed are compared with the digests of the files of
+the deployment, proving that what is running matches the source history.
+
+The files the deploy generates, such as the SBOM and the deployment manifest,
+aren't compared. Builds which aren't reproducible, such as dependencies
+installed without a lockfile, show up as changed files. The command exits with
+an error when the files don't match.
+
+Arguments:
+  <deploymentId>    The deployment id or tag to verify
+
+Flags:
+  --dir       The project directory
+  --format    The format to use for the output (text or json)
+
+Examples:
+  agentuity cloud verify-bundle deploy_123
+  agentuity cloud verify-bundle production --format json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		projectContext := iproject.EnsureProject(ctx, cmd)
+		logger := projectContext.Logger
+		format, _ := cmd.Flags().GetString("format")
+
+		var deployment *iproject.DeploymentDetail
+		tui.ShowSpinner("Fetching deployment ...", func() {
+			deployments, err := iproject.ListDeployments(ctx, logger, projectContext.APIURL, projectContext.Token, projectContext.Project.ProjectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list deployments")).ShowErrorAndExit()
+			}
+			deploymentId := resolveDeployment(deployments, args[0])
+			deployment, err = iproject.GetDeployment(ctx, logger, projectContext.APIURL, projectContext.Token, projectContext.Project.ProjectId, deploymentId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deployment")).ShowErrorAndExit()
+			}
+		})
+		if deployment.Git == nil || deployment.Git.Commit == "" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("deployment %s has no git commit", deployment.ID),
+				errsystem.WithUserMessage("The deployment %s wasn't deployed from a git repository so it can't be rebuilt", deployment.ID)).ShowErrorAndExit()
+		}
+		if deployment.Files == nil {
+			errsystem.New(errsystem.ErrApiRequest, fmt.Errorf("deployment %s has no file digests", deployment.ID),
+				errsystem.WithUserMessage("The deployment %s doesn't have the digests of its files so it can't be verified", deployment.ID)).ShowErrorAndExit()
+		}
+		commit := deployment.Git.Commit
+
+		var dir string
+		var cleanup func() error
+		var err error
+		tui.ShowSpinner(fmt.Sprintf("Checking out %s ...", shortCommit(commit)), func() {
+			dir, cleanup, err = deployer.CheckoutCommit(ctx, projectContext.Dir, commit)
+		})
+		if err != nil {
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to check out the commit %s of the deployment: %s", commit, err)).ShowErrorAndExit()
+		}
+		defer cleanup()
+
+		theproject := iproject.NewProject()
+		if err := theproject.Load(dir); err != nil {
+			cleanup()
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to load the project at the commit %s", commit))).ShowErrorAndExit()
+		}
+		tui.ShowSpinner("Bundling ...", func() {
+			err = bundler.Bundle(bundler.BundleContext{
+				Context:    ctx,
+				Logger:     logger,
+				ProjectDir: dir,
+				Production: true,
+				Project:    theproject,
+				Writer:     os.Stderr,
+			})
+		})
+		if err != nil {
+			cleanup()
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			exitOnSDKCompatibilityError(err)
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to bundle the project at the commit %s", commit))).ShowErrorAndExit()
+		}
+
+		// the same files as the deployment zip
+		rules := createProjectIgnoreRules(dir, theproject, false)
+		include := func(fn string, fi os.FileInfo) bool {
+			return !rules.Ignore(fn, fi)
+		}
+		externals, err := deployer.FindExternalFiles(dir, loadExternalizeConfig(cmd, dir), include)
+		if err != nil {
+			cleanup()
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Error finding the files to externalize")).ShowErrorAndExit()
+		}
+		externalized := make(map[string]bool)
+		for _, file := range externals {
+			externalized[file.Path] = true
+		}
+		digests, err := deployer.HashBundleFiles(dir, func(fn string, fi os.FileInfo) bool {
+			return !externalized[filepath.ToSlash(fn)] && include(fn, fi)
+		})
+		if err != nil {
+			cleanup()
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to hash the files of the bundle")).ShowErrorAndExit()
+		}
+
+		verification := deployer.VerifyBundle(deployment.Files, digests)
+		verification.DeploymentID = deployment.ID
+		verification.Commit = commit
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(verification)
+		} else if verification.Verified() {
+			tui.ShowSuccess("The deployment %s matches the commit %s (%s)", deployment.ID, shortCommit(commit), util.Pluralize(verification.Matched, "file", "files"))
+			fmt.Println(tui.Muted("Digest: " + verification.DeployedDigest))
+		} else {
+			printSetDiff("Files", verification.Files)
+			fmt.Printf("%d added, %d removed and %d changed files in the rebuilt bundle\n", len(verification.Files.Added), len(verification.Files.Removed), len(verification.Files.Changed))
+			fmt.Println()
+			tui.ShowError("The deployment %s doesn't match the commit %s", deployment.ID, shortCommit(commit))
+		}
+		if !verification.Verified() {
+			cleanup()
+			os.Exit(1)
+		}
+	},
+}
+
+var cloudTagsCmd = &cobra.Command{
+	Use:     "tags",
+	Aliases: []string{"tag"},
+	Short:   "Manage deployment tags",
+	Long: `Manage deployment tags independently of a deploy.
+
+Tags point to a single deployment of the project. Adding a tag which already points
+to another deployment moves it. The latest tag marks the active deployment and is
+only changed by deploying or rolling back.
+
+Examples:
+  agentuity cloud tags list
+  agentuity cloud tags add staging <deploymentId>
+  agentuity cloud tags rename staging qa
+  agentuity cloud tags describe qa --description "Used by the QA team"
+  agentuity cloud tags remove qa`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cloudTagsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the deployment tags of a project",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select a project to list the tags of")
+		if projectId == "" {
+			return
+		}
+		tags := listDeploymentTags(ctx, logger, apiUrl, apikey, projectId)
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			json.NewEncoder(os.Stdout).Encode(tags)
+			return
+		}
+		if len(tags) == 0 {
+			tui.ShowWarning("no tags found for this project")
+			return
+		}
+		headers := []string{"Tag", "Deployment Id", "Active", "Description", "Updated At"}
+		rows := [][]string{}
+		for _, t := range tags {
+			active := ""
+			if t.Active {
+				active = "✅"
+			}
+			description := t.Description
+			if len(description) > 60 {
+				description = description[:57] + "..."
+			}
+			rows = append(rows, []string{tui.Bold(t.Name), tui.Muted(t.DeploymentID), active, tui.Text(description), tui.Title(t.UpdatedAt)})
+		}
+		tui.Table(headers, rows)
+	},
+}
+
+var cloudTagsAddCmd = &cobra.Command{
+	Use:   "add [tag] [deploymentId]",
+	Short: "Add a tag to a deployment or move it from another deployment",
+	Long: `Add a tag to a deployment or move it from another deployment.
+
+Arguments:
+  [tag]             The tag to add
+  [deploymentId]    The deployment to add the tag to, prompts for one if not provided
+
+Examples:
+  agentuity cloud tags add staging <deploymentId>
+  agentuity cloud tags add staging --description "The staging environment"`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+
+		name := args[0]
+		ensureNotLatestTag(name)
+		if err := iproject.ValidateDeploymentTag(name); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the deployment")
+		if projectId == "" {
+			return
+		}
+		var deploymentId string
+		if len(args) > 1 {
+			deploymentId = args[1]
+		} else {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please specify the deployment id")
+			}
+			deploymentId = cloudSelectDeployment(ctx, logger, apiUrl, apikey, projectId, "Select the deployment to tag")
+		}
+
+		existing := findDeploymentTag(listDeploymentTags(ctx, logger, apiUrl, apikey, projectId), name)
+		if existing != nil && existing.DeploymentID == deploymentId && !cmd.Flags().Changed("description") {
+			tui.ShowWarning("Deployment %s is already tagged %s", deploymentId, name)
+			return
+		}
+		if existing != nil && existing.DeploymentID != deploymentId {
+			if force, _ := cmd.Flags().GetBool("force"); !force && tui.HasTTY {
+				if !tui.Ask(logger, fmt.Sprintf("Tag %s points to deployment %s. Move it to %s?", name, existing.DeploymentID, deploymentId), true) {
+					tui.ShowWarning("cancelled")
+					return
+				}
+			}
+		}
+		update := iproject.DeploymentTagUpdate{DeploymentID: &deploymentId}
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			update.Description = &description
+		}
+		updateDeploymentTag(ctx, logger, apiUrl, apikey, projectId, name, update)
+		if existing != nil && existing.DeploymentID != deploymentId {
+			tui.ShowSuccess("Moved tag %s from %s to %s", tui.Bold(name), existing.DeploymentID, deploymentId)
+		} else {
+			tui.ShowSuccess("Tagged deployment %s with %s", deploymentId, tui.Bold(name))
+		}
+	},
+}
+
+var cloudTagsRemoveCmd = &cobra.Command{
+	Use:     "remove [tag]",
+	Aliases: []string{"rm"},
+	Short:   "Remove a tag from its deployment",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+
+		name := args[0]
+		ensureNotLatestTag(name)
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the tag")
+		if projectId == "" {
+			return
+		}
+		existing := findDeploymentTag(listDeploymentTags(ctx, logger, apiUrl, apikey, projectId), name)
+		if existing == nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("tag %s not found", name),
+				errsystem.WithUserMessage("Tag %s was not found in the project", name)).ShowErrorAndExit()
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force && tui.HasTTY {
+			if !tui.Ask(logger, fmt.Sprintf("Are you sure you want to remove tag %s from deployment %s?", name, existing.DeploymentID), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		tui.ShowSpinner("removing tag ...", func() {
+			if err := iproject.DeleteDeploymentTag(ctx, logger, apiUrl, apikey, projectId, name); err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to remove the deployment tag")).ShowErrorAndExit()
+			}
+		})
+		tui.ShowSuccess("Removed tag %s from deployment %s", tui.Bold(name), existing.DeploymentID)
+	},
+}
+
+var cloudTagsRenameCmd = &cobra.Command{
+	Use:   "rename [tag] [name]",
+	Short: "Rename a tag",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+
+		name, newName := args[0], args[1]
+		ensureNotLatestTag(name)
+		ensureNotLatestTag(newName)
+		if err := iproject.ValidateDeploymentTag(newName); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the tag")
+		if projectId == "" {
+			return
+		}
+		tags := listDeploymentTags(ctx, logger, apiUrl, apikey, projectId)
+		if findDeploymentTag(tags, name) == nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("tag %s not found", name),
+				errsystem.WithUserMessage("Tag %s was not found in the project", name)).ShowErrorAndExit()
+		}
+		if findDeploymentTag(tags, newName) != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("tag %s already exists", newName),
+				errsystem.WithUserMessage("Tag %s already exists. Remove it first or choose another name.", newName)).ShowErrorAndExit()
+		}
+		updateDeploymentTag(ctx, logger, apiUrl, apikey, projectId, name, iproject.DeploymentTagUpdate{Name: &newName})
+		tui.ShowSuccess("Renamed tag %s to %s", name, tui.Bold(newName))
+	},
+}
+
+var cloudTagsDescribeCmd = &cobra.Command{
+	Use:   "describe [tag]",
+	Short: "Show a tag or set its description and deployment message",
+	Long: `Show a tag or set its description and deployment message.
+
+Without flags the tag and its deployment are shown. The description belongs to the
+tag while the message belongs to the deployment the tag points to, which allows
+attaching a message to a deployment after it was deployed.
+
+Examples:
+  agentuity cloud tags describe staging
+  agentuity cloud tags describe staging --description "The staging environment"
+  agentuity cloud tags describe staging --message "Fixes the order lookup timeout"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+
+		name := args[0]
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project of the tag")
+		if projectId == "" {
+			return
+		}
+		tag := findDeploymentTag(listDeploymentTags(ctx, logger, apiUrl, apikey, projectId), name)
+		if tag == nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("tag %s not found", name),
+				errsystem.WithUserMessage("Tag %s was not found in the project", name)).ShowErrorAndExit()
+		}
+		var update iproject.DeploymentTagUpdate
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			update.Description = &description
+		}
+		if cmd.Flags().Changed("message") {
+			message, _ := cmd.Flags().GetString("message")
+			update.Message = &message
+		}
+		if update.Description != nil || update.Message != nil {
+			tag = updateDeploymentTag(ctx, logger, apiUrl, apikey, projectId, name, update)
+		}
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			json.NewEncoder(os.Stdout).Encode(tag)
+			return
+		}
+		if update.Description != nil || update.Message != nil {
+			tui.ShowSuccess("Updated tag %s", tui.Bold(name))
+			fmt.Println()
+		}
+		fmt.Printf("%s %s\n", tui.PadRight("Tag:", 13, " "), tui.Bold(tag.Name))
+		fmt.Printf("%s %s\n", tui.PadRight("Deployment:", 13, " "), tag.DeploymentID)
+		fmt.Printf("%s %t\n", tui.PadRight("Active:", 13, " "), tag.Active)
+		if tag.Description != "" {
+			fmt.Printf("%s %s\n", tui.PadRight("Description:", 13, " "), tag.Description)
+		}
+		if tag.Message != "" {
+			fmt.Printf("%s %s\n", tui.PadRight("Message:", 13, " "), tag.Message)
+		}
+		if tag.UpdatedAt != "" {
+			fmt.Printf("%s %s\n", tui.PadRight("Updated At:", 13, " "), tui.Muted(tag.UpdatedAt))
+		}
+	},
+}
+
+// collectPromptsData collects prompts data from the project directory
+func collectPromptsData(logger logger.Logger, dir string) ([]DeployPrompt, error) {
+	// Find all prompt files
+	promptFiles := prompts.FindAllPromptFiles(dir)
+	if len(promptFiles) == 0 {
+		logger.Debug("No prompt files found")
+		return nil, nil
+	}
+
+	logger.Debug("Found %d prompt files: %v", len(promptFiles), promptFiles)
+
+	var allPrompts []DeployPrompt
+
+	// Parse all prompt files and combine prompts
+	for _, promptFile := range promptFiles {
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", promptFile, err)
+		}
+
+		promptsList, err := prompts.ParsePromptsYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", promptFile, err)
+		}
+
+		// Convert to DeployPrompt format
+		for _, prompt := range promptsList {
+			deployPrompt := DeployPrompt{
+				Slug:        prompt.Slug,
+				Name:        prompt.Name,
+				Description: &prompt.Description,
+			}
+
+			// Convert system prompt
+			if prompt.System != "" {
+				deployPrompt.System = &prompt.System
+			}
+
+			// Convert user prompt
+			if prompt.Prompt != "" {
+				deployPrompt.Prompt = &prompt.Prompt
+			}
+
+			// Convert variables from templates
+			var variables []PromptVariable
+			if prompt.SystemTemplate.Variables != nil {
+				for _, v := range prompt.SystemTemplate.Variables {
+					variables = append(variables, PromptVariable{
+						Name:     v.Name,
+						Required: v.IsRequired,
 						Default:  v.DefaultValue,
 					})
 				}
@@ -1164,6 +2930,7 @@ func init() {
 	cloudDeployCmd.Flags().String("ci-git-provider", "", "Used to set the git provider for your deployment metadata")
 	cloudDeployCmd.Flags().String("ci-logs-url", "", "Used to set the CI logs URL for your deployment metadata")
 	cloudDeployCmd.Flags().StringArray("tag", nil, "Tag(s) to associate with this deployment (can be specified multiple times)")
+	cloudDeployCmd.Flags().StringArray("label", nil, "A key=value label to store with the deployment, such as team=payments (can be specified multiple times)")
 	cloudDeployCmd.Flags().String("description", "", "Description for the deployment")
 	cloudDeployCmd.Flags().String("message", "", "A shorter description for the deployment")
 	cloudDeployCmd.Flags().Bool("force", false, "Force the processing of environment files")
@@ -1184,6 +2951,31 @@ func init() {
 	cloudDeployCmd.Flags().String("org-id", "", "The organization to create the project in")
 	cloudDeployCmd.Flags().String("templates-dir", "", "The directory to load the templates. Defaults to loading them from the github.com/agentuity/templates repository")
 
+	cloudDeployCmd.Flags().Bool("watch", false, "Watch the project for changes and redeploy automatically (requires a non-latest --tag)")
+	cloudDeployCmd.Flags().Duration("watch-debounce", 2*time.Second, "How long to wait for changes to settle before redeploying in watch mode")
+	cloudDeployCmd.Flags().String("env-profile", "", "The environment profile to use, which loads variables from .env.<profile> instead of .env")
+	cloudDeployCmd.Flags().Bool("require-approval", false, "Require a second member of the organization to approve the deployment before it is activated")
+	cloudDeployCmd.Flags().Bool("analyze", false, "Show a breakdown of the deployment size by directory and package")
+	cloudDeployCmd.Flags().Int("analyze-top", 10, "The number of the largest directories and packages to show with --analyze")
+	cloudDeployCmd.Flags().String("size-budget", "", "Warn when the deployment zip file exceeds the size such as 50Mi (overrides deployment.budget.warn)")
+	cloudDeployCmd.Flags().String("size-limit", "", "Fail when the deployment zip file exceeds the size such as 100Mi (overrides deployment.budget.limit)")
+	cloudDeployCmd.Flags().Bool("no-externalize", false, "Include the files in deployment.externalize in the deployment zip file instead of uploading them separately")
+	cloudDeployCmd.Flags().Bool("no-sbom", false, "Don't attach the software bill of materials (SBOM) of the dependencies to the deployment")
+	cloudDeployCmd.Flags().Duration("approval-timeout", 30*time.Minute, "How long to wait for the deployment to be approved (0 to not wait)")
+	cloudDeployCmd.Flags().Bool("wait", false, "Wait for another deploy of the same tags to finish instead of failing")
+	cloudDeployCmd.Flags().Duration("status-timeout", 5*time.Minute, "How long to wait for the deployment to start in the cloud and show why it failed (0 to not wait)")
+	cloudDeployCmd.Flags().Duration("wait-timeout", 30*time.Minute, "How long to wait for another deploy of the same tags with --wait")
+	cloudDeployCmd.Flags().Bool("force-takeover", false, "Take over the lock of another deploy of the same tags which is in progress")
+	cloudDeployCmd.Flags().Bool("skip-compat-check", false, "Don't check that the installed SDK is supported by this version of the CLI")
+	cloudDeployCmd.Flags().Bool("upload-via-api", false, "Upload the deployment through the Agentuity API instead of directly to the storage, for networks which block the storage")
+
+	cloudCmd.AddCommand(cloudApproveCmd)
+	cloudApproveCmd.Flags().String("project", "", "Project of the deployment to approve")
+	cloudApproveCmd.Flags().String("dir", "", "The directory to the project if project is not specified")
+	cloudApproveCmd.Flags().Bool("reject", false, "Reject the deployment instead of approving it")
+	cloudApproveCmd.Flags().String("comment", "", "A comment to record with the approval")
+	cloudApproveCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+
 	rootCmd.AddCommand(cloudRollbackCmd)
 	cloudCmd.AddCommand(cloudRollbackCmd)
 	cloudRollbackCmd.Flags().String("tag", "", "Tag of the deployment to rollback")
@@ -1195,4 +2987,46 @@ func init() {
 	cloudCmd.AddCommand(cloudDeploymentsCmd)
 	cloudDeploymentsCmd.Flags().String("project", "", "Project to list deployments for")
 	cloudDeploymentsCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	cloudDeploymentsCmd.AddCommand(cloudDeploymentsGetCmd)
+	cloudDeploymentsGetCmd.Flags().String("project", "", "The project of the deployment")
+	cloudDeploymentsGetCmd.Flags().String("dir", "", "The directory to the project if project is not specified")
+	cloudDeploymentsGetCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	cloudDeploymentsCmd.AddCommand(cloudDeploymentsDiffCmd)
+	cloudDeploymentsDiffCmd.Flags().String("project", "", "The project of the deployments")
+	cloudDeploymentsDiffCmd.Flags().String("dir", "", "The directory to the project if project is not specified")
+	cloudDeploymentsDiffCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	cloudDeploymentsDiffCmd.Flags().Bool("files", false, "Show the changed files of the bundle")
+
+	cloudCmd.AddCommand(cloudReleaseNotesCmd)
+	cloudReleaseNotesCmd.Flags().String("project", "", "The project of the deployments")
+	cloudReleaseNotesCmd.Flags().String("dir", "", "The directory to the project if project is not specified")
+	cloudReleaseNotesCmd.Flags().String("from", "", "The deployment id or tag to start from")
+	cloudReleaseNotesCmd.Flags().String("to", "latest", "The deployment id or tag to end at")
+	cloudReleaseNotesCmd.Flags().String("format", "markdown", "The output format to use for the release notes which can be either 'markdown' or 'json'")
+	cloudReleaseNotesCmd.Flags().Bool("post", false, "Set the release notes as the message of the --to deployment")
+	cloudReleaseNotesCmd.Flags().Int("limit", 200, "The maximum number of commits to include")
+
+	cloudCmd.AddCommand(cloudVerifyBundleCmd)
+	cloudVerifyBundleCmd.Flags().StringP("dir", "d", "", "The project directory")
+	cloudVerifyBundleCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+
+	cloudCmd.AddCommand(cloudTagsCmd)
+	cloudTagsCmd.AddCommand(cloudTagsListCmd)
+	cloudTagsCmd.AddCommand(cloudTagsAddCmd)
+	cloudTagsCmd.AddCommand(cloudTagsRemoveCmd)
+	cloudTagsCmd.AddCommand(cloudTagsRenameCmd)
+	cloudTagsCmd.AddCommand(cloudTagsDescribeCmd)
+	for _, cmd := range []*cobra.Command{cloudTagsListCmd, cloudTagsAddCmd, cloudTagsRemoveCmd, cloudTagsRenameCmd, cloudTagsDescribeCmd} {
+		cmd.Flags().String("project", "", "The project of the tags")
+		cmd.Flags().String("dir", "", "The directory to the project if project is not specified")
+	}
+	for _, cmd := range []*cobra.Command{cloudTagsListCmd, cloudTagsDescribeCmd} {
+		cmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	}
+	for _, cmd := range []*cobra.Command{cloudTagsAddCmd, cloudTagsRemoveCmd} {
+		cmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	}
+	cloudTagsAddCmd.Flags().String("description", "", "A description of the tag")
+	cloudTagsDescribeCmd.Flags().String("description", "", "Set the description of the tag")
+	cloudTagsDescribeCmd.Flags().String("message", "", "Set the message of the deployment the tag points to")
 }
@@ -13,7 +13,6 @@ import (
 	"github.com/agentuity/cli/internal/infrastructure"
 	"github.com/agentuity/cli/internal/organization"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/slice"
 	"github.com/agentuity/go-common/tui"
@@ -167,7 +166,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 
@@ -306,7 +305,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 
@@ -394,7 +393,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 
@@ -438,7 +437,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 
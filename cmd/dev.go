@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -21,11 +29,85 @@ import (
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+	cproject "github.com/agentuity/go-common/project"
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// devPayloadValidator returns the validator for the payloads sent to the agents in dev mode
+// or nil when none of the agents declare a payload schema
+func devPayloadValidator(logger logger.Logger, dir string) gravity.PayloadValidator {
+	validators := loadAgentValidators(logger, dir)
+	if len(validators) == 0 {
+		return nil
+	}
+	return func(agentID string, body []byte) []string {
+		return validateAgentPayload(validators, agentID, body)
+	}
+}
+
+// devAgentNames returns the names of the agents of the project by id, with and without the agent_
+// prefix which the requests proxied by gravity don't have
+func devAgentNames(theproject project.ProjectContext) map[string]string {
+	names := make(map[string]string)
+	for _, a := range theproject.Project.Agents {
+		names[a.ID] = a.Name
+		names[strings.TrimPrefix(a.ID, "agent_")] = a.Name
+	}
+	return names
+}
+
+// devSessionRecorder returns the recorder which appends the requests to the agents and their
+// responses to the named session so that conversations survive restarts of the dev server
+func devSessionRecorder(logger logger.Logger, theproject project.ProjectContext, name string, redactor *util.Redactor) gravity.Recorder {
+	store := dev.NewSessionStore(theproject.Dir)
+	names := devAgentNames(theproject)
+	return func(exchange gravity.Exchange) {
+		turn := dev.Turn{
+			At:         exchange.Started.UTC(),
+			AgentID:    exchange.AgentID,
+			AgentName:  names[exchange.AgentID],
+			Trigger:    exchange.Request.Header.Get("x-agentuity-trigger"),
+			Status:     exchange.Status,
+			DurationMs: exchange.Duration.Milliseconds(),
+			Request:    dev.NewMessage(exchange.Request.Header.Get("Content-Type"), exchange.RequestBody, false).Redact(redactor),
+			Response:   dev.NewMessage(exchange.Header.Get("Content-Type"), exchange.ResponseBody, exchange.Truncated).Redact(redactor),
+		}
+		if tok := strings.Split(exchange.Request.Header.Get("traceparent"), "-"); len(tok) > 1 {
+			turn.TraceID = tok[1]
+		}
+		if err := store.Append(name, turn); err != nil {
+			logger.Warn("failed to record the request to %s in session %s: %s", exchange.AgentID, name, err)
+		}
+	}
+}
+
+// loadLocalModels returns the local models of the project file served by the runtime at the
+// endpoint of the project file or the runtime detected on this machine
+func loadLocalModels(ctx context.Context, dir string) *dev.LocalModels {
+	config, err := project.LoadLocalModels(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the project file")).ShowErrorAndExit()
+	}
+	var runtime *dev.LocalRuntime
+	if config.Endpoint != "" {
+		runtime, err = dev.ProbeLocalRuntime(ctx, dev.LocalRuntime{Name: "the local model runtime", Endpoint: config.Endpoint})
+	} else {
+		runtime, err = dev.DetectLocalRuntime(ctx, dev.LocalRuntimes)
+	}
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithUserMessage("Failed to connect to a local model runtime: %s. Start Ollama (ollama serve) or the LM Studio server, or set development.local_models.endpoint in agentuity.yaml to the OpenAI compatible URL of your runtime.", err)).ShowErrorAndExit()
+	}
+	models := &dev.LocalModels{Runtime: *runtime, Default: config.Default, Models: config.Models}
+	if missing := models.Missing(); len(missing) > 0 {
+		tui.ShowWarning("The models %s aren't available in %s, pull them before your agents use them", strings.Join(missing, ", "), runtime.Name)
+	}
+	return models
+}
+
 var devCmd = &cobra.Command{
 	Use:   "dev",
 	Args:  cobra.NoArgs,
@@ -36,20 +118,178 @@ This command starts a local development server that connects to the Agentuity Cl
 for live development and testing of your agents. It watches for file changes and
 automatically rebuilds your project when changes are detected.
 
+Agents which declare a payload schema in agentuity.yaml (agents[].schema) have their
+incoming payloads validated against it and invalid payloads are rejected with a 400
+response listing the validation errors.
+
+The requests to your agents and their responses are recorded to a session under
+.agentuity/sessions so that multi-turn conversations survive restarts of the
+development server. Use the sessions command to list, show, export or clear them.
+
+The requests to your agents and their responses can be inspected and changed by a
+JavaScript, TypeScript or Python script declared as development.middleware in
+agentuity.yaml, for example to inject auth headers, simulate latency or fuzz the
+payloads. A JavaScript script exports onRequest(req) and onResponse(req, res) and a
+Python script defines on_request(req) and on_response(req, res); both hooks are
+optional. onRequest changes req in place and can return { delay: <ms> } to delay the
+request or { response: { status, headers, body } } to respond without calling the
+agent. onResponse changes res in place or returns a new one. The script is reloaded
+when it changes.
+
+With --chaos the calls your agents make to other agents and to the cloud services
+are delayed and failed at random so that you can verify your retry and timeout
+handling before production. The settings are a comma separated list of:
+latency=<duration>, jitter=<duration> (a random extra delay up to the duration),
+error-rate=<percent>, error-status=<status> (default 503), timeout-rate=<percent>
+and timeout=<duration> (how long timed out calls are held, default 1m).
+
+With --local-models the calls your agents make to the Agentuity AI gateway for the
+providers with an OpenAI compatible API (OpenAI, Groq, DeepSeek, xAI, Mistral and
+Perplexity) are served by a model runtime on this machine such as Ollama or LM Studio
+instead, so that development doesn't cost tokens. The runtime is detected on its
+default port unless development.local_models.endpoint is set in agentuity.yaml, and
+the cloud models are replaced by the local models mapped in development.local_models:
+
+  development:
+    local_models:
+      default: llama3.1
+      models:
+        gpt-4o: qwen2.5
+        gpt-4o-mini: llama3.2
+
+A cloud model which isn't mapped is replaced by the default or sent as is. The other
+providers and the agents which use their own API key still call the cloud.
+
+With --https the development server is also served over HTTPS for providers which
+only call back to HTTPS URLs such as OAuth redirects. The certificate is issued by a
+certificate authority created for this machine, which you're asked to trust the first
+time so that browsers and other clients accept it.
+
+With --remote the development server runs in an ephemeral cloud sandbox instead
+of on this machine, which is useful on an underpowered machine or with heavy
+Python dependencies. The project is uploaded to the sandbox, which installs the
+dependencies and builds it, and then only the changed files are synced as you
+save them. The output of the server is streamed back. The files ignored when
+deploying (such as .env files and node_modules) aren't synced: the sandbox uses
+the environment variables of the project in Agentuity Cloud. Sessions, the dev
+middleware, --chaos, --local-models and --https only apply to the local
+development server. The sandbox is removed when you stop the command.
+
+The output of the agents is formatted for reading: structured JSON logs are
+shown on one line with a colored level, the name of the agent and the other
+fields, and stack traces are collapsed to their first frames. While the server
+is running, type /filter followed by level=<level>, agent=<name> or text=<text>
+to only show the matching logs (for example /filter level=error agent=support)
+and /filter on its own to show them all again. Use --raw-logs to show the
+output as is.
+
+API keys, tokens, emails and the values of the secrets of the project are
+redacted from the output of the agents and the recorded sessions. The redaction
+section of agentuity.yaml adds patterns or disables the default ones:
+
+  redaction:
+    defaults: true
+    patterns:
+      - name: customer-id
+        pattern: cust_[a-z0-9]+
+
+With --headless the development server never prompts and doesn't read commands
+from the terminal, so that it can run unattended such as the service installed by
+the service install command. It exits when you need to login again.
+
+With --inspect the agents listen for a debugger: node --inspect for Node.js, the
+Bun inspector for Bun and debugpy for Python, which uv adds for the run. The default
+ports are 9229, 6499 and 5678 which the launch configurations written by ide setup
+attach to. The address the debugger attaches to is shown for each agent and stays
+the same when the agents restart after a change, so the debugger only needs to
+attach again. The development server doesn't start when the port is in use.
+
+With --coverage the coverage of the agents is collected while the development server
+runs, such as during a test session with agent test --local, and the lcov.info and
+coverage.xml (Cobertura) reports of the agent source directory are written to
+.agentuity/coverage when it stops. The coverage is collected with NODE_V8_COVERAGE
+and reported with c8 for Node.js and with coverage.py for Python, which are run with
+npx and uv. Bun can't collect the coverage of a server.
+
+With --events json the development server is headless and writes its lifecycle
+events to stdout as JSON lines, one per event, so that IDE extensions and other
+wrappers can show their own UI. Everything else, including the output of the
+agents, is written to stderr. The events have a type and a timestamp:
+
+  agent-started  an agent is ready, with its agentId, agentName, local url and the
+                 inspect address of the debugger with --inspect
+  request        a request to an agent, with its sessionId and redacted payload
+  response       the response of an agent, with its status and durationMs
+  error          the project failed to build or the agents failed, with a message
+  reload         a file has changed and the project is rebuilt, with its path
+
+The address of the running server is written to .agentuity/dev.json so that
+commands such as agent test --local, eval run --local and dev trigger send their
+requests to it without needing the port.
+
 Flags:
-  --dir            The directory to run the development server in
+  --dir              The directory to run the development server in
+  --port             The port to run on, which fails when it is already in use
+  --host             The address to bind to, use 0.0.0.0 to test from other devices
+  --https            Also serve over HTTPS with a locally-trusted certificate
+  --https-port       The port to serve HTTPS on (default random)
+  --env-profile      Load environment variables from .env.<profile> instead of .env.development
+  --session          The name of the session to record the conversation to (default "default")
+  --no-session       Do not record the conversation
+  --no-middleware    Do not run the middleware declared in the project file
+  --chaos            Inject latency and failures into the calls to agents and cloud services
+  --local-models     Serve the LLM calls of the agents with a local runtime such as Ollama
+  --remote           Run the development server in a cloud sandbox
+  --log-filter       Only show the logs matching the filter, like /filter
+  --raw-logs         Show the output of the agents as is, only redacted
+  --headless         Never prompt or read commands from the terminal
+  --inspect          Listen for a debugger in the agents (node --inspect, Bun inspector, debugpy)
+  --inspect-port     The port the debugger listens on (default by runtime)
+  --coverage         Collect the coverage of the agents and write the reports when stopped
+  --coverage-dir     The directory of the coverage reports (default .agentuity/coverage)
+  --events           Write the lifecycle events to stdout in the format, only json is supported
+  --no-install       Never install dependencies, even when they aren't installed
+  --frozen-lockfile  Fail instead of updating the lockfile when it is out of date
+  --sandbox          Install dependencies without running their scripts or exposing secrets
+  --skip-compat-check  Don't check that the installed SDK is supported by the CLI
 
 Examples:
   agentuity dev
   agentuity dev --dir /path/to/project
+  agentuity dev --env-profile staging
+  agentuity dev --session checkout-bug
+  agentuity dev --port 3500 --host 0.0.0.0
+  agentuity dev --https --https-port 3443
+  agentuity dev --chaos "latency=300ms,error-rate=5%"
+  agentuity dev --local-models
+  agentuity dev --remote
+  agentuity dev --log-filter "level=warn agent=support"
+  agentuity dev --headless
+  agentuity dev --inspect
+  agentuity dev --coverage
+  agentuity dev --events json
   agentuity dev --no-build`,
 	Run: func(cmd *cobra.Command, args []string) {
-		log := env.NewLogger(cmd)
+		log := util.NewLogger(cmd)
 		urls := util.GetURLs(log)
 		apiUrl := urls.API
 		appUrl := urls.App
 		gravityUrl := urls.Gravity
 		noBuild, _ := cmd.Flags().GetBool("no-build")
+		var events *dev.EventWriter
+		if format, _ := cmd.Flags().GetString("events"); format != "" {
+			if format != "json" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid events format: %s", format), errsystem.WithUserMessage("The events format %s isn't supported, use json.", format)).ShowErrorAndExit()
+			}
+			// the events are the only output on stdout, everything else is written to stderr
+			events = dev.NewEventWriter(os.Stdout)
+			os.Stdout = os.Stderr
+			tui.HasTTY = false
+		}
+		if headless, _ := cmd.Flags().GetBool("headless"); headless {
+			// never prompt or read commands from stdin so that it can run as a service
+			tui.HasTTY = false
+		}
 
 		promptsEvalsFF := CheckFeatureFlag(cmd, FeaturePromptsEvals, "enable-prompts-evals")
 
@@ -60,6 +300,61 @@ Examples:
 		theproject := project.EnsureProject(ctx, cmd)
 		dir := theproject.Dir
 
+		// the sensitive data is redacted from the output of the agents and the recorded sessions
+		redactor, err := project.LoadRedactor(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the redaction rules of the project file")).ShowErrorAndExit()
+		}
+
+		var recorder gravity.Recorder
+		if noSession, _ := cmd.Flags().GetBool("no-session"); !noSession {
+			session, _ := cmd.Flags().GetString("session")
+			if err := dev.ValidateSessionName(session); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			recorder = devSessionRecorder(log, theproject, session, redactor)
+		}
+
+		var observer gravity.RequestObserver
+		if events != nil {
+			names := devAgentNames(theproject)
+			observer = func(agentID string, r *http.Request, body []byte) {
+				events.Emit(dev.NewRequestEvent(agentID, names[agentID], r, body, redactor))
+			}
+			sessionRecorder := recorder
+			recorder = func(exchange gravity.Exchange) {
+				if sessionRecorder != nil {
+					sessionRecorder(exchange)
+				}
+				events.Emit(dev.NewResponseEvent(exchange, names[exchange.AgentID], redactor))
+			}
+		}
+
+		var chaos *dev.Chaos
+		if spec, _ := cmd.Flags().GetString("chaos"); spec != "" {
+			var err error
+			if chaos, err = dev.ParseChaos(spec); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
+
+		var logFilter *dev.LogFilter
+		if spec, _ := cmd.Flags().GetString("log-filter"); spec != "" {
+			var err error
+			if logFilter, err = dev.ParseLogFilter(spec); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
+
+		var middlewareScript string
+		if noMiddleware, _ := cmd.Flags().GetBool("no-middleware"); !noMiddleware {
+			script, err := project.LoadDevMiddleware(dir)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the project file")).ShowErrorAndExit()
+			}
+			middlewareScript = script
+		}
+
 		if theproject.NewProject {
 			var projectId string
 			if theproject.Project.ProjectId != "" {
@@ -73,6 +368,11 @@ Examples:
 			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to validate project (%s). This is most likely due to the API key being invalid or the project has been deleted.\n\nYou can import this project using the following command:\n\n"+tui.Command("project import"), theproject.Project.ProjectId), errsystem.WithContextMessage(fmt.Sprintf("Failed to get project: %s", err))).ShowErrorAndExit()
 		}
 
+		if remote, _ := cmd.Flags().GetBool("remote"); remote {
+			runRemoteDev(ctx, log, theproject, apiKey, redactor)
+			return
+		}
+
 		hostname := viper.GetString("devmode.hostname")
 
 		endpoint, err := dev.GetDevModeEndpoint(ctx, log, theproject.APIURL, apiKey, theproject.Project.ProjectId, hostname)
@@ -87,7 +387,41 @@ Examples:
 
 		var envfile *deployer.EnvFile
 
-		envfile, project = envutil.ProcessEnvFiles(ctx, log, dir, theproject.Project, project, theproject.APIURL, apiKey, false, true)
+		envProfile, _ := cmd.Flags().GetString("env-profile")
+		if envProfile != "" {
+			if err := envutil.WarnEnvProfileConflicts(dir, envProfile); err != nil {
+				log.Debug("failed to compare environment profiles: %s", err)
+			}
+		}
+
+		envfile, project = envutil.ProcessEnvFiles(ctx, log, dir, theproject.Project, project, theproject.APIURL, apiKey, false, true, envProfile)
+
+		if envProfile != "" {
+			if envfile == nil {
+				// without a TTY the env files aren't processed so load the profile directly
+				filename, _ := envutil.EnvProfileFilename(dir, envProfile)
+				le, err := env.ParseEnvFileWithComments(filename)
+				if err != nil {
+					errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to parse environment profile")).ShowErrorAndExit()
+				}
+				le, _, err = envutil.ResolveSecretReferences(ctx, log, le)
+				if err != nil {
+					errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to resolve secret reference")).ShowErrorAndExit()
+				}
+				envfile = &deployer.EnvFile{Filepath: filename, Env: le}
+			}
+			// the runtime only loads the default env files so pass the profile variables through the environment
+			for _, ev := range envfile.Env {
+				os.Setenv(ev.Key, ev.Val)
+			}
+		} else if envfile != nil {
+			// the runtime would otherwise load the unresolved secret references from the env file
+			for _, ev := range envfile.Env {
+				if envutil.IsSecretReference(ev.Raw) {
+					os.Setenv(ev.Key, ev.Val)
+				}
+			}
+		}
 
 		if envfile == nil {
 			// we don't have an env file so we need to create one since this likely means you have cloned a new project
@@ -113,18 +447,72 @@ Examples:
 			tui.ShowSuccess("Synchronized project to .env file: %s", tui.Muted(filename))
 		}
 
+		for key, value := range project.Secrets {
+			redactor.AddValues(key, value)
+		}
+
 		orgId := project.OrgId
 
-		agentPort, _ := cmd.Flags().GetInt("port")
-		agentPort, err = dev.FindAvailablePort(theproject, agentPort)
+		host, _ := cmd.Flags().GetString("host")
+		if net.ParseIP(host) == nil && host != "localhost" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid host: %s", host),
+				errsystem.WithUserMessage("The host %s is not an IP address. Use 127.0.0.1 to only accept connections from this machine or 0.0.0.0 to accept them from other devices.", host)).ShowErrorAndExit()
+		}
+		useHTTPS, _ := cmd.Flags().GetBool("https")
+		httpsPort, _ := cmd.Flags().GetInt("https-port")
+		requestedPort, _ := cmd.Flags().GetInt("port")
+		agentPort, err := dev.FindAvailablePort(theproject, requestedPort)
 		if err != nil {
 			log.Fatal("failed to find available port: %s", err)
 		}
+		if cmd.Flags().Changed("port") && agentPort != requestedPort {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("port %d is in use", requestedPort),
+				errsystem.WithUserMessage("Port %d is already in use. Stop the process using it or choose another port with --port.", requestedPort)).ShowErrorAndExit()
+		}
 		proxyPort, err := dev.FindAvailableOpenPort()
 		if err != nil {
 			log.Fatal("failed to find available port: %s", err)
 		}
 
+		// the agents call the AI gateway through the local models proxy and the other agents and the
+		// cloud services through the chaos proxies, which also delay and fail the calls to the models
+		runProject := theproject
+		if localModels, _ := cmd.Flags().GetBool("local-models"); localModels && runProject.TransportURL != "" {
+			models := loadLocalModels(ctx, dir)
+			proxy, err := dev.StartLocalModelsProxy(log, models, runProject.TransportURL)
+			if err != nil {
+				log.Fatal("failed to start the local models proxy: %s", err)
+			}
+			defer proxy.Close()
+			runProject.TransportURL = proxy.URL()
+			tui.ShowSuccess("Serving the LLM calls of the agents with %s at %s", models.Runtime.Name, models.Runtime.Endpoint)
+		}
+		if chaos != nil {
+			for _, u := range []*string{&runProject.APIURL, &runProject.TransportURL} {
+				if *u == "" {
+					continue
+				}
+				proxy, err := dev.StartChaosProxy(log, chaos, *u)
+				if err != nil {
+					log.Fatal("failed to start the chaos proxy: %s", err)
+				}
+				defer proxy.Close()
+				*u = proxy.URL()
+			}
+			tui.ShowWarning("Injecting chaos into the calls to agents and cloud services: %s", chaos)
+		}
+
+		var middleware gravity.Middleware
+		if middlewareScript != "" {
+			m, err := dev.NewScriptMiddleware(ctx, log, theproject, middlewareScript)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to start the dev middleware: %s", err)).ShowErrorAndExit()
+			}
+			defer m.Close()
+			middleware = m
+			log.Info("Using the dev middleware %s", middlewareScript)
+		}
+
 		server, err := dev.New(dev.ServerArgs{
 			APIURL:   apiUrl,
 			APIKey:   apiKey,
@@ -143,6 +531,10 @@ Examples:
 				Ephemeral:       true,
 				ClientName:      "cli/devmode",
 				DynamicHostname: true,
+				Validator:       devPayloadValidator(log, dir),
+				Recorder:        recorder,
+				Observer:        observer,
+				Middleware:      middleware,
 			},
 		})
 		if err != nil {
@@ -167,14 +559,97 @@ Examples:
 
 		publicUrl := server.PublicURL(appUrl)
 		consoleUrl := server.WebURL(appUrl)
-		devModeUrl := fmt.Sprintf("http://127.0.0.1:%d", agentPort)
-		infoBox := server.GenerateInfoBox(publicUrl, consoleUrl, devModeUrl)
+		state := dev.State{Host: host, Port: agentPort, ProxyPort: proxyPort, PID: os.Getpid(), StartedAt: time.Now()}
+		devModeUrl := state.URL()
+		var networkUrl string
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			if addr := dev.NetworkAddress(); addr != "" {
+				networkUrl = fmt.Sprintf("http://%s:%d", addr, agentPort)
+			}
+		}
+		var httpsUrl string
+		if useHTTPS {
+			proxy := startDevHTTPSProxy(log, host, httpsPort, devModeUrl)
+			defer proxy.Close()
+			state.HTTPSPort = proxy.Port()
+			httpsUrl = fmt.Sprintf("https://localhost:%d", proxy.Port())
+		}
+		infoBox := server.GenerateInfoBox(publicUrl, consoleUrl, devModeUrl, networkUrl, httpsUrl)
 		fmt.Println(infoBox)
 
-		projectServerCmd, err := dev.CreateRunProjectCmd(processCtx, log, theproject, server, dir, orgId, agentPort, os.Stdout, os.Stderr)
+		// other commands such as agent test --local read the address of the server from the state file
+		if err := dev.WriteState(dir, state); err != nil {
+			log.Warn("failed to write %s: %s", dev.StateFilename, err)
+		}
+		defer dev.RemoveState(dir)
+
+		// the output of the agents is redacted and formatted unless --raw-logs is used
+		logs := dev.NewLogRenderer(logFilter)
+		logs.Redactor = redactor
+		logs.Raw, _ = cmd.Flags().GetBool("raw-logs")
+		stdout, stderr := logs.Writer(os.Stdout), logs.Writer(os.Stderr)
+		defer stdout.Flush()
+		defer stderr.Flush()
+
+		// with --inspect the runtime listens for a debugger such as the one attached by the launch
+		// configuration written by ide setup. The port is the same after every restart so that the
+		// debugger can attach again.
+		var inspectPort int
+		var inspectEndpoint string
+		if inspect, _ := cmd.Flags().GetBool("inspect"); inspect {
+			var runtime string
+			if theproject.Project.Bundler != nil {
+				runtime = theproject.Project.Bundler.Runtime
+			}
+			if inspectPort, _ = cmd.Flags().GetInt("inspect-port"); inspectPort == 0 {
+				inspectPort = dev.DefaultInspectPort(runtime)
+			}
+			if inspectPort == 0 {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("debugging isn't supported for the %s runtime", runtime),
+					errsystem.WithUserMessage("Debugging isn't supported for the %s runtime. Use node, bun or uv to debug the agents.", runtime)).ShowErrorAndExit()
+			}
+			if err := dev.CheckInspectPort(inspectPort); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+					errsystem.WithUserMessage("The debugger port %d is already in use, such as by another development server. Stop it or choose another port with --inspect-port.", inspectPort)).ShowErrorAndExit()
+			}
+			inspectEndpoint = dev.InspectEndpoint(runtime, inspectPort)
+		}
+
+		agentsStarted := func() {
+			for _, a := range theproject.Project.Agents {
+				events.Emit(dev.Event{Type: dev.EventAgentStarted, AgentID: a.ID, AgentName: a.Name, URL: fmt.Sprintf("%s/%s", devModeUrl, a.ID), Inspect: inspectEndpoint})
+			}
+		}
+
+		// with --coverage the coverage of the agents is collected from every run of the project
+		// server and reported when the development server stops
+		var coverage *dev.Coverage
+		if enabled, _ := cmd.Flags().GetBool("coverage"); enabled {
+			coverage = newDevCoverage(cmd, theproject)
+			if inspectPort > 0 && coverage.Runtime == "uv" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("--coverage can't be used with --inspect for python"),
+					errsystem.WithUserMessage("The --coverage and --inspect flags can't be used together for Python projects")).ShowErrorAndExit()
+			}
+		}
+
+		instrument := func(projectServerCmd *exec.Cmd) {
+			if inspectPort > 0 {
+				if err := dev.EnableInspector(projectServerCmd, theproject.Project, inspectPort); err != nil {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to enable the debugger: %s", err)).ShowErrorAndExit()
+				}
+			}
+			if coverage != nil {
+				if err := coverage.Instrument(projectServerCmd); err != nil {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to collect the coverage: %s", err)).ShowErrorAndExit()
+				}
+			}
+		}
+
+		projectServerCmd, err := dev.CreateRunProjectCmd(processCtx, log, runProject, server, dir, orgId, host, agentPort, stdout, stderr)
 		if err != nil {
 			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
 		}
+		instrument(projectServerCmd)
 
 		var build func(initial bool) bool
 
@@ -187,7 +662,7 @@ Examples:
 			var ok bool
 
 			tui.ShowSpinner("Building project ...", func() {
-				if err := bundler.Bundle(bundler.BundleContext{
+				bundleCtx := bundler.BundleContext{
 					Context:        ctx,
 					Logger:         log,
 					ProjectDir:     dir,
@@ -195,34 +670,55 @@ Examples:
 					DevMode:        true,
 					Writer:         os.Stdout,
 					PromptsEvalsFF: promptsEvalsFF,
-				}); err != nil {
+				}
+				applyInstallFlags(cmd, &bundleCtx)
+				if err := bundler.Bundle(bundleCtx); err != nil {
 					if err == bundler.ErrBuildFailed {
 						return
 					}
+					exitOnSDKCompatibilityError(err)
 					errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to bundle project: %s", err))).ShowErrorAndExit()
 				}
 				ok = true
 			})
+			if !ok {
+				events.Emit(dev.Event{Type: dev.EventError, Message: "the project failed to build"})
+			}
 			if ok && !initial {
 				log.Info("✨ Built in %s", time.Since(started).Round(time.Millisecond))
 			}
 			return ok
 		}
 
+		var restartingLock sync.Mutex
+		var restarting atomic.Bool
+
 		runServer := func() {
-			projectServerCmd, err = dev.CreateRunProjectCmd(processCtx, log, theproject, server, dir, orgId, agentPort, os.Stdout, os.Stderr)
+			projectServerCmd, err = dev.CreateRunProjectCmd(processCtx, log, runProject, server, dir, orgId, host, agentPort, stdout, stderr)
 			if err != nil {
 				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
 			}
+			instrument(projectServerCmd)
 			if err := projectServerCmd.Start(); err != nil {
 				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to start project: %s", err))).ShowErrorAndExit()
 			}
 			atomic.StoreInt32(&pid, int32(projectServerCmd.Process.Pid))
 			// running = true
 			log.Trace("restarted project server (pid: %d)", projectServerCmd.Process.Pid)
+			if events != nil {
+				go func() {
+					if err := server.HealthCheck(devModeUrl); err == nil {
+						agentsStarted()
+					}
+				}()
+			}
 			log.Trace("waiting for project server to exit (pid: %d)", projectServerCmd.Process.Pid)
 			if err := projectServerCmd.Wait(); err != nil {
 				log.Error("project server (pid: %d) exited with error: %s", projectServerCmd.Process.Pid, err)
+				// the server is killed when restarting or stopping, which isn't an error
+				if !restarting.Load() && ctx.Err() == nil {
+					events.Emit(dev.Event{Type: dev.EventError, Message: fmt.Sprintf("the project server exited with error: %s", err)})
+				}
 			}
 			if projectServerCmd.ProcessState != nil {
 				log.Debug("project server (pid: %d) exited with code %d", projectServerCmd.Process.Pid, projectServerCmd.ProcessState.ExitCode())
@@ -237,13 +733,16 @@ Examples:
 			return
 		}
 
-		var restartingLock sync.Mutex
-
 		restart := func() {
 			// prevent multiple restarts from happening at once
 			restartingLock.Lock()
 			defer restartingLock.Unlock()
+			restarting.Store(true)
+			defer restarting.Store(false)
 			dev.KillProjectServer(log, projectServerCmd, int(atomic.LoadInt32(&pid)))
+			if inspectPort > 0 && !dev.WaitForInspectPort(ctx, inspectPort, 5*time.Second) {
+				log.Warn("the debugger port %d is still in use, the agents may restart without the debugger", inspectPort)
+			}
 			if build(false) {
 				log.Trace("build ready")
 				go runServer()
@@ -255,6 +754,7 @@ Examples:
 		// Watch for changes
 		watcher, err := dev.NewWatcher(log, dir, rules, func(path string) {
 			log.Trace("%s has changed", path)
+			events.Emit(dev.Event{Type: dev.EventReload, Path: path})
 			restart()
 		})
 		if err != nil {
@@ -273,15 +773,29 @@ Examples:
 
 			if err := server.HealthCheck(devModeUrl); err != nil {
 				log.Error("failed to health check connection: %s", err)
+				events.Emit(dev.Event{Type: dev.EventError, Message: fmt.Sprintf("the agents failed to start: %s", err)})
 				dev.KillProjectServer(log, projectServerCmd, projectServerCmd.Process.Pid)
 				return
 			}
+			agentsStarted()
 		}
 
 		tui.ShowSpinner("Starting Agents ...", initRun)
 
 		log.Info("🚀 DevMode ready")
 
+		if inspectEndpoint != "" {
+			printDevInspectEndpoints(theproject.Project.Agents, devModeUrl, inspectEndpoint)
+		}
+
+		if limit := devMemoryLimit(theproject.Project); limit > 0 {
+			go watchDevMemory(ctx, log, dir, limit)
+		}
+
+		if !logs.Raw && tui.HasTTY {
+			go readDevLogCommands(log, logs)
+		}
+
 		teardown := func() {
 			restartingLock.Lock()
 			defer restartingLock.Unlock()
@@ -291,6 +805,8 @@ Examples:
 				dev.KillProjectServer(log, projectServerCmd, int(atomic.LoadInt32(&pid)))
 				projectServerCmd.Wait()
 			}
+			dev.RemoveState(dir)
+			os.Remove(dev.RuntimeStatsPath(dir))
 		}
 
 		<-ctx.Done()
@@ -299,14 +815,849 @@ Examples:
 
 		teardown()
 
+		if coverage != nil {
+			reportDevCoverage(context.Background(), coverage)
+		}
+
 		log.Info("👋 See you next time!")
 	},
 }
 
+// newDevCoverage returns the coverage of the agents of the project written to the --coverage-dir
+func newDevCoverage(cmd *cobra.Command, theproject project.ProjectContext) *dev.Coverage {
+	reportDir, _ := cmd.Flags().GetString("coverage-dir")
+	coverage, err := dev.NewCoverage(theproject.Dir, theproject.Project, reportDir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to collect the coverage: %s", err)).ShowErrorAndExit()
+	}
+	return coverage
+}
+
+// reportDevCoverage writes the coverage reports of the agents, with the output of the coverage
+// tools going to stderr
+func reportDevCoverage(ctx context.Context, coverage *dev.Coverage) {
+	var files []string
+	var err error
+	tui.ShowSpinner("Writing the coverage reports ...", func() {
+		files, err = coverage.Report(ctx, os.Stderr, os.Stderr)
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to write the coverage reports: %s", err)).ShowErrorAndExit()
+	}
+	for _, f := range files {
+		if rel, err := filepath.Rel(coverage.Dir, f); err == nil {
+			f = rel
+		}
+		tui.ShowSuccess("Wrote the coverage report %s", f)
+	}
+}
+
+// printDevInspectEndpoints prints the address the debugger attaches to for each agent. The agents
+// run in the same process so they share the address, which stays the same after restarts.
+func printDevInspectEndpoints(agents []cproject.AgentConfig, devModeUrl string, endpoint string) {
+	tui.ShowSuccess("The agents are listening for a debugger at %s", tui.Bold(endpoint))
+	for _, a := range agents {
+		fmt.Printf("  %s %s  %s\n", tui.PadRight(a.Name, 20, " "), tui.Muted(fmt.Sprintf("%s/%s", devModeUrl, a.ID)), endpoint)
+	}
+}
+
+// readDevLogCommands reads the commands typed while the development server is running, such as
+// /filter level=error agent=foo to only show the matching lines of the agents
+func readDevLogCommands(log logger.Logger, logs *dev.LogRenderer) {
+	if filter := logs.Filter(); filter != nil {
+		log.Info("Showing the logs matching %s, type /filter to show all the logs", filter)
+	} else {
+		log.Info("Type /filter level=error agent=<name> to filter the logs")
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		command, spec, _ := strings.Cut(line, " ")
+		switch command {
+		case "":
+			continue
+		case "/filter":
+			filter, err := dev.ParseLogFilter(spec)
+			if err != nil {
+				log.Error("%s", err)
+				continue
+			}
+			logs.SetFilter(filter)
+			if filter == nil {
+				log.Info("Showing all the logs")
+			} else {
+				log.Info("Showing the logs matching %s", filter)
+			}
+		default:
+			log.Warn("Unknown command %s, use /filter level=<level> agent=<name> text=<text> or /filter to clear the filter", command)
+		}
+	}
+}
+
+// runRemoteDev runs the development server of the project in a cloud sandbox instead of locally. The
+// files of the project are synced to the sandbox as they change and its output is streamed back
+// until the command is interrupted, when the sandbox is removed.
+func runRemoteDev(ctx context.Context, log logger.Logger, theproject project.ProjectContext, apiKey string, redactor *util.Redactor) {
+	var sandbox *dev.Sandbox
+	var err error
+	tui.ShowSpinner("Creating sandbox ...", func() {
+		sandbox, err = dev.CreateSandbox(ctx, log, theproject.APIURL, apiKey, theproject.Project.ProjectId)
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to create the remote sandbox")).ShowErrorAndExit()
+	}
+	defer func() {
+		// the context is cancelled by now so the sandbox is removed with a new one
+		if err := dev.DeleteSandbox(context.Background(), log, theproject.APIURL, apiKey, sandbox.ID); err != nil {
+			log.Warn("failed to remove the sandbox %s, it will be removed when it expires: %s", sandbox.ID, err)
+		}
+	}()
+
+	// the sandbox installs the dependencies and builds the project itself
+	rules := createProjectIgnoreRules(theproject.Dir, theproject.Project, false)
+	rules.Add(fmt.Sprintf("**/%s/**", project.AgentuityDir))
+	sync := dev.NewRemoteSync(theproject.Dir, rules)
+	syncFiles := func() (int, error) {
+		changed, removed, err := sync.Scan()
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan the project: %w", err)
+		}
+		if len(changed) == 0 && len(removed) == 0 {
+			return 0, nil
+		}
+		if err := dev.SyncSandbox(ctx, log, theproject.APIURL, apiKey, sandbox.ID, theproject.Dir, changed, removed); err != nil {
+			return 0, err
+		}
+		sync.Commit()
+		return len(changed) + len(removed), nil
+	}
+
+	var synced int
+	tui.ShowSpinner("Uploading project ...", func() {
+		synced, err = syncFiles()
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to upload the project to the remote sandbox")).ShowErrorAndExit()
+	}
+	log.Debug("uploaded %d files to the sandbox %s", synced, sandbox.ID)
+
+	tui.ShowBanner("Remote DevMode", tui.Text("Your Agents are running in a cloud sandbox at ")+tui.Link("%s", sandbox.URL)+"\n\n"+tui.Muted("Changes are synced to the sandbox as you save them. Press Ctrl+C to stop."), false)
+
+	go func() {
+		var cursor string
+		for {
+			logs, next, err := dev.SandboxLogs(ctx, log, theproject.APIURL, apiKey, sandbox.ID, cursor)
+			if err != nil && ctx.Err() == nil {
+				log.Debug("failed to fetch the sandbox logs: %s", err)
+			}
+			cursor = next
+			for _, l := range logs {
+				fmt.Printf("%s %s %s\n", tui.Bold(fmt.Sprintf("%-7s", "["+l.Severity+"]")), tui.Title(l.Timestamp.Format(time.TimeOnly)), tui.Body(redactor.Redact(l.Body)))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	// the project is scanned instead of watched since the watcher ignores the manifests and the
+	// lockfiles, which the sandbox needs to install the dependencies again when they change
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Printf("\b\b\033[K") // remove the ^C
+			log.Info("👋 See you next time!")
+			return
+		case <-ticker.C:
+			started := time.Now()
+			synced, err := syncFiles()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error("%s", err)
+				}
+				continue
+			}
+			if synced > 0 {
+				log.Info("✨ Synced %s in %s", util.Pluralize(synced, "file", "files"), time.Since(started).Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// devCertsDir returns the directory of the certificate authority which issues the dev mode certificates
+func devCertsDir() string {
+	return filepath.Join(filepath.Dir(cfgFile), "certs")
+}
+
+// startDevHTTPSProxy serves the development server at target over HTTPS with a certificate issued
+// by the local certificate authority, offering to trust it when it isn't yet
+func startDevHTTPSProxy(logger logger.Logger, host string, port int, target string) *dev.HTTPSProxy {
+	ca, created, err := dev.LoadOrCreateCA(devCertsDir())
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the local certificate authority")).ShowErrorAndExit()
+	}
+	if created {
+		tui.ShowSuccess("Created the local certificate authority in %s", tui.Muted(devCertsDir()))
+	}
+	if !ca.Trusted() {
+		trust := ca.TrustCommand()
+		switch {
+		case trust == nil:
+			tui.ShowWarning("Add %s to the trusted certificates of this machine so that clients accept the HTTPS certificate", ca.CertFile)
+		case tui.HasTTY && tui.Ask(logger, "Trust the local certificate authority so that clients accept the HTTPS certificate? This may ask for your password.", true):
+			c := exec.Command(trust[0], trust[1:]...)
+			c.Stdin = os.Stdin
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			if err := c.Run(); err != nil {
+				tui.ShowWarning("Failed to trust the local certificate authority: %s", err)
+			} else {
+				tui.ShowSuccess("The local certificate authority is trusted")
+			}
+		default:
+			tui.ShowWarning("The local certificate authority is not trusted. Run %s to trust it", tui.Bold(strings.Join(trust, " ")))
+		}
+	}
+
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		if addr := dev.NetworkAddress(); addr != "" {
+			hosts = append(hosts, addr)
+		}
+	} else if !slices.Contains(hosts, host) {
+		hosts = append(hosts, host)
+	}
+	cert, err := ca.IssueCertificate(hosts)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to issue the HTTPS certificate")).ShowErrorAndExit()
+	}
+	proxy, err := dev.StartHTTPSProxy(logger, host, port, target, cert)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to serve HTTPS on port %d: %s", port, err)).ShowErrorAndExit()
+	}
+	return proxy
+}
+
+// selectProjectAgent returns the agent in the project matching the name or id in args or prompts for one
+func selectProjectAgent(logger logger.Logger, theproject project.ProjectContext, args []string, help string) cproject.AgentConfig {
+	agents := theproject.Project.Agents
+	if len(args) > 0 {
+		for _, a := range agents {
+			if a.ID == args[0] || strings.EqualFold(a.Name, args[0]) {
+				return a
+			}
+		}
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", args[0]),
+			errsystem.WithUserMessage("Agent %s was not found in the project", args[0])).ShowErrorAndExit()
+	}
+	if len(agents) == 0 {
+		errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("no agents found"),
+			errsystem.WithUserMessage("No Agents found in the project")).ShowErrorAndExit()
+	}
+	if len(agents) == 1 {
+		return agents[0]
+	}
+	if !tui.HasTTY {
+		logger.Fatal("No TTY detected, please specify an agent from the command line")
+	}
+	var options []tui.Option
+	for _, a := range agents {
+		options = append(options, tui.Option{ID: a.ID, Text: tui.PadRight(a.Name, 20, " ") + tui.Muted(a.ID)})
+	}
+	selected := tui.Select(logger, "Select an agent", help, options)
+	for _, a := range agents {
+		if a.ID == selected {
+			return a
+		}
+	}
+	return agents[0]
+}
+
+// sendDevTrigger sends the trigger to the agent running in the local development server
+func sendDevTrigger(cmd *cobra.Command, args []string, build func(agent cproject.AgentConfig) (*dev.Trigger, error)) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	logger := util.NewLogger(cmd)
+	theproject := project.EnsureProject(ctx, cmd)
+	theagent := selectProjectAgent(logger, theproject, args, "Select the agent to send the trigger to")
+
+	trigger, err := build(theagent)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithContextMessage("Failed to create the trigger payload")).ShowErrorAndExit()
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		metadata, _ := json.Marshal(trigger.Metadata)
+		fmt.Printf("x-agentuity-trigger: %s\n", trigger.Type)
+		fmt.Printf("x-agentuity-metadata: %s\n", metadata)
+		fmt.Printf("Content-Type: %s\n\n", trigger.ContentType)
+		fmt.Println(string(trigger.Body))
+		return
+	}
+
+	port, _ := cmd.Flags().GetInt("port")
+	endpoint := fmt.Sprintf("%s/%s", dev.LocalURL(theproject, port), theagent.ID)
+	req, err := trigger.NewRequest(ctx, endpoint)
+	if err != nil {
+		logger.Fatal("Failed to create request: %s", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithUserMessage("Failed to send the %s trigger to %s. Make sure the development server is running with %s", trigger.Type, endpoint, tui.Command("dev"))).ShowErrorAndExit()
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Fatal("Failed to read response: %s", err)
+	}
+	var jsonBody any
+	if json.Unmarshal(body, &jsonBody) == nil {
+		body, _ = json.MarshalIndent(jsonBody, "", "  ")
+	}
+	if resp.StatusCode > 299 {
+		tui.ShowError("Agent %s responded to the %s trigger with status %d: %s", theagent.Name, trigger.Type, resp.StatusCode, string(body))
+		os.Exit(1)
+	}
+	tui.ShowSuccess("Sent %s trigger to %s: %s", trigger.Type, theagent.Name, tui.Paragraph(tui.Bold(string(body))))
+}
+
+var devTriggerCmd = &cobra.Command{
+	Use:   "trigger",
+	Short: "Send a simulated trigger to an agent running in the development server",
+	Long: `Send a simulated trigger to an agent running in the development server.
+
+The payloads match the format the cloud delivers them to your agent in so that
+agents which handle email, SMS, cron or Slack triggers can be tested without
+deploying. Use --dry-run to print the payload instead of sending it.
+
+Examples:
+  agentuity dev trigger email my-agent --subject "Order #123" --text "Where is my order?"
+  agentuity dev trigger sms my-agent --text "STOP"
+  agentuity dev trigger cron my-agent --schedule "0 9 * * *"
+  agentuity dev trigger slack my-agent --event app_mention --text "<@U0123456789> summarize this channel"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var devTriggerEmailCmd = &cobra.Command{
+	Use:   "email [agent]",
+	Short: "Send a simulated email to an agent",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendDevTrigger(cmd, args, func(agent cproject.AgentConfig) (*dev.Trigger, error) {
+			opts := dev.EmailOptions{}
+			opts.From, _ = cmd.Flags().GetString("from")
+			opts.To, _ = cmd.Flags().GetString("to")
+			opts.Subject, _ = cmd.Flags().GetString("subject")
+			opts.Text, _ = cmd.Flags().GetString("text")
+			opts.HTML, _ = cmd.Flags().GetString("html")
+			opts.Attachments, _ = cmd.Flags().GetStringArray("attachment")
+			if opts.To == "" {
+				opts.To = strings.TrimPrefix(agent.ID, "agent_") + "@agentuity.run"
+			}
+			return dev.EmailTrigger(opts)
+		})
+	},
+}
+
+var devTriggerSMSCmd = &cobra.Command{
+	Use:   "sms [agent]",
+	Short: "Send a simulated SMS to an agent",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendDevTrigger(cmd, args, func(agent cproject.AgentConfig) (*dev.Trigger, error) {
+			opts := dev.SMSOptions{}
+			opts.From, _ = cmd.Flags().GetString("from")
+			opts.To, _ = cmd.Flags().GetString("to")
+			opts.Text, _ = cmd.Flags().GetString("text")
+			return dev.SMSTrigger(opts)
+		})
+	},
+}
+
+var devTriggerCronCmd = &cobra.Command{
+	Use:   "cron [agent]",
+	Short: "Send a simulated cron invocation to an agent",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendDevTrigger(cmd, args, func(agent cproject.AgentConfig) (*dev.Trigger, error) {
+			opts := dev.CronOptions{}
+			opts.Schedule, _ = cmd.Flags().GetString("schedule")
+			opts.Payload, _ = cmd.Flags().GetString("payload")
+			opts.ContentType, _ = cmd.Flags().GetString("content-type")
+			return dev.CronTrigger(opts)
+		})
+	},
+}
+
+var devTriggerSlackCmd = &cobra.Command{
+	Use:   "slack [agent]",
+	Short: "Send a simulated Slack event to an agent",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendDevTrigger(cmd, args, func(agent cproject.AgentConfig) (*dev.Trigger, error) {
+			opts := dev.SlackOptions{}
+			opts.Event, _ = cmd.Flags().GetString("event")
+			opts.Team, _ = cmd.Flags().GetString("team")
+			opts.Channel, _ = cmd.Flags().GetString("channel")
+			opts.User, _ = cmd.Flags().GetString("user")
+			opts.Text, _ = cmd.Flags().GetString("text")
+			return dev.SlackTrigger(opts)
+		})
+	},
+}
+
+// devSessionStore returns the session store of the project in the directory of the command
+func devSessionStore(cmd *cobra.Command) *dev.SessionStore {
+	logger := util.NewLogger(cmd)
+	return dev.NewSessionStore(project.ResolveProjectDir(logger, cmd, true))
+}
+
+// loadDevSession loads the session named in args (or the default session) and exits if it doesn't exist
+func loadDevSession(cmd *cobra.Command, args []string) *dev.Session {
+	name := dev.DefaultSession
+	if len(args) > 0 {
+		name = args[0]
+	}
+	store := devSessionStore(cmd)
+	defer store.Close()
+	session, err := store.Load(name)
+	if err != nil {
+		errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to load the session")).ShowErrorAndExit()
+	}
+	if session == nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("session %s not found", name),
+			errsystem.WithUserMessage("Session %s was not found. Use %s to see the recorded sessions.", name, tui.Command("dev sessions list"))).ShowErrorAndExit()
+	}
+	return session
+}
+
+func printDevMessage(prefix string, msg dev.Message) {
+	body := msg.Body
+	switch {
+	case msg.Encoding == "base64":
+		body = tui.Muted(fmt.Sprintf("<%s binary data>", msg.ContentType))
+	case strings.Contains(msg.ContentType, "json"):
+		var val any
+		if json.Unmarshal([]byte(body), &val) == nil {
+			buf, _ := json.MarshalIndent(val, "", "  ")
+			body = string(buf)
+		}
+	}
+	if msg.Truncated {
+		body += tui.Muted(" (truncated)")
+	}
+	fmt.Printf("%s %s\n", prefix, body)
+}
+
+var devSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage the conversations recorded by the development server",
+	Long: `Manage the conversations recorded by the development server.
+
+The development server records each request to your agents and the response to a
+session stored under .agentuity/sessions in the project. Sessions survive restarts
+of the development server and can be exported to JSON to share a reproduction.
+
+Examples:
+  agentuity dev sessions list
+  agentuity dev sessions show checkout-bug
+  agentuity dev sessions export checkout-bug --output checkout-bug.json
+  agentuity dev sessions clear`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var devSessionsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the recorded sessions",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := devSessionStore(cmd)
+		defer store.Close()
+		sessions, err := store.List()
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to list the sessions")).ShowErrorAndExit()
+		}
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			for _, session := range sessions {
+				session.Turns = nil
+			}
+			json.NewEncoder(os.Stdout).Encode(sessions)
+			return
+		}
+		if len(sessions) == 0 {
+			fmt.Println()
+			tui.ShowWarning("No sessions have been recorded")
+			tui.ShowBanner("Record a session", tui.Text("Run ")+tui.Command("dev")+tui.Text(" and send a message to one of your agents"), false)
+			return
+		}
+		headers := []string{tui.Title("Name"), tui.Title("Agents"), tui.Title("Turns"), tui.Title("Updated")}
+		rows := [][]string{}
+		for _, session := range sessions {
+			rows = append(rows, []string{
+				tui.Bold(session.Name),
+				tui.Text(strings.Join(session.Agents, ", ")),
+				tui.Text(fmt.Sprintf("%d", len(session.Turns))),
+				tui.Muted(session.UpdatedAt.Local().Format(time.DateTime)),
+			})
+		}
+		tui.Table(headers, rows)
+	},
+}
+
+var devSessionsShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the conversation of a session",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		session := loadDevSession(cmd, args)
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			session.Export(os.Stdout)
+			return
+		}
+		for i, turn := range session.Turns {
+			agent := turn.AgentName
+			if agent == "" {
+				agent = turn.AgentID
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			status := tui.Text(fmt.Sprintf("%d", turn.Status))
+			if turn.Status > 299 {
+				status = tui.Warning(fmt.Sprintf("%d", turn.Status))
+			}
+			fmt.Printf("%s %s %s %s\n", tui.Muted(turn.At.Local().Format(time.DateTime)), tui.Bold(agent), status, tui.Muted(fmt.Sprintf("%dms", turn.DurationMs)))
+			printDevMessage(tui.Secondary("→"), turn.Request)
+			printDevMessage(tui.Secondary("←"), turn.Response)
+		}
+	},
+}
+
+var devSessionsExportCmd = &cobra.Command{
+	Use:   "export [name]",
+	Short: "Export a session to JSON",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		session := loadDevSession(cmd, args)
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" || output == "-" {
+			session.Export(os.Stdout)
+			return
+		}
+		of, err := os.Create(output)
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to create the export file")).ShowErrorAndExit()
+		}
+		defer of.Close()
+		if err := session.Export(of); err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to write the export file")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Exported session %s with %d turns to %s", session.Name, len(session.Turns), output)
+	},
+}
+
+var devSessionsClearCmd = &cobra.Command{
+	Use:   "clear [name]",
+	Short: "Remove a session or all the sessions",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force && name == "" {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to remove all the sessions")
+			}
+			if !tui.Ask(logger, "Are you sure you want to remove all the recorded sessions?", false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		store := devSessionStore(cmd)
+		defer store.Close()
+		count, err := store.Clear(name)
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to remove the sessions")).ShowErrorAndExit()
+		}
+		switch {
+		case name != "" && count == 0:
+			tui.ShowWarning("Session %s was not found", name)
+		case name != "":
+			tui.ShowSuccess("Removed session %s", name)
+		default:
+			tui.ShowSuccess("Removed %d sessions", count)
+		}
+	},
+}
+
+// devMemoryLimit returns the memory of the deployment of the project in bytes or 0 when it isn't set
+func devMemoryLimit(p *cproject.Project) int64 {
+	if p.Deployment == nil || p.Deployment.Resources == nil {
+		return 0
+	}
+	return p.Deployment.Resources.MemoryQuantity.Value()
+}
+
+// watchDevMemory warns when the memory of the runtime approaches the memory of the deployment, which
+// would restart the deployment once deployed
+func watchDevMemory(ctx context.Context, log logger.Logger, dir string, limit int64) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	level := dev.MemoryOK
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats, err := dev.LoadRuntimeStats(dir)
+		if err != nil || stats == nil {
+			continue
+		}
+		percent, current := dev.MemoryPressure(stats.RSS, limit)
+		if current != level && current != dev.MemoryOK {
+			log.Warn("⚠️  The agents use %s of memory, %.0f%% of the %s of the deployment. Run %s for details.", deployer.FormatSize(stats.RSS), percent, deployer.FormatSize(limit), tui.Command("dev stats"))
+		}
+		level = current
+	}
+}
+
+// devStats is the resource usage of the running development server
+type devStats struct {
+	Agents        []string           `json:"agents"`
+	Processes     []dev.ProcessStats `json:"processes"`
+	Runtime       *dev.RuntimeStats  `json:"runtime,omitempty"`
+	MemoryUsed    int64              `json:"memoryUsed"`
+	MemoryLimit   int64              `json:"memoryLimit,omitempty"`
+	MemoryPercent float64            `json:"memoryPercent,omitempty"`
+	MemoryLevel   string             `json:"memoryLevel"`
+}
+
+func collectDevStats(dir string, state *dev.State, theproject *cproject.Project) devStats {
+	stats := devStats{Agents: []string{}, Processes: []dev.ProcessStats{}, MemoryLimit: devMemoryLimit(theproject)}
+	for _, a := range theproject.Agents {
+		stats.Agents = append(stats.Agents, a.Name)
+	}
+	if processes, err := dev.ProcessStatsTree(state.PID); err == nil {
+		stats.Processes = processes
+	}
+	stats.Runtime, _ = dev.LoadRuntimeStats(dir)
+	if stats.Runtime != nil {
+		stats.MemoryUsed = stats.Runtime.RSS
+	} else {
+		for _, p := range stats.Processes {
+			stats.MemoryUsed += p.RSS
+		}
+	}
+	stats.MemoryPercent, stats.MemoryLevel = dev.MemoryPressure(stats.MemoryUsed, stats.MemoryLimit)
+	return stats
+}
+
+func printDevStats(stats devStats) {
+	fmt.Println(tui.Title("Agents") + "  " + tui.Text(strings.Join(stats.Agents, ", ")) + tui.Muted(" (the agents share the runtime process)"))
+	fmt.Println()
+	if len(stats.Processes) > 0 {
+		headers := []string{tui.Title("PID"), tui.Title("Process"), tui.Title("CPU"), tui.Title("Memory")}
+		rows := [][]string{}
+		for _, p := range stats.Processes {
+			rows = append(rows, []string{
+				tui.Text(fmt.Sprintf("%d", p.PID)),
+				tui.Bold(p.Command),
+				tui.Text(fmt.Sprintf("%.1f%%", p.CPU)),
+				tui.Text(deployer.FormatSize(p.RSS)),
+			})
+		}
+		tui.Table(headers, rows)
+	}
+	if r := stats.Runtime; r != nil {
+		fmt.Println(tui.Title("Runtime") + "  " + tui.Muted(fmt.Sprintf("%s (pid %d)", r.Runtime, r.PID)))
+		fmt.Printf("  %s %s\n", tui.PadRight("CPU", 18, " "), tui.Text(fmt.Sprintf("%.1f%%", r.CPU)))
+		fmt.Printf("  %s %s\n", tui.PadRight("Heap", 18, " "), tui.Text(fmt.Sprintf("%s / %s", deployer.FormatSize(r.HeapUsed), deployer.FormatSize(r.HeapTotal))))
+		fmt.Printf("  %s %s\n", tui.PadRight("External", 18, " "), tui.Text(deployer.FormatSize(r.External)))
+		if d := r.EventLoopDelay; d != nil {
+			fmt.Printf("  %s %s\n", tui.PadRight("Event loop delay", 18, " "), tui.Text(fmt.Sprintf("mean %.1fms, p99 %.1fms, max %.1fms", d.Mean, d.P99, d.Max)))
+		}
+		if gc := r.GC; gc != nil {
+			fmt.Printf("  %s %s\n", tui.PadRight("GC", 18, " "), tui.Text(fmt.Sprintf("%d collections, %.0fms total", gc.Count, gc.Duration)))
+		}
+		fmt.Println()
+	}
+	memory := fmt.Sprintf("%s used", deployer.FormatSize(stats.MemoryUsed))
+	if stats.MemoryLimit > 0 {
+		memory = fmt.Sprintf("%s of %s (%.0f%%)", deployer.FormatSize(stats.MemoryUsed), deployer.FormatSize(stats.MemoryLimit), stats.MemoryPercent)
+	}
+	fmt.Println(tui.Title("Memory") + "  " + tui.Text(memory))
+	switch stats.MemoryLevel {
+	case dev.MemoryCritical:
+		tui.ShowWarning("The agents are about to run out of the memory of the deployment, which restarts the deployment. Increase deployment.resources.memory in agentuity.yaml or reduce the memory used.")
+	case dev.MemoryWarning:
+		tui.ShowWarning("The agents use most of the memory of the deployment. Consider increasing deployment.resources.memory in agentuity.yaml.")
+	}
+}
+
+var devStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the resource usage of the agents in the development server",
+	Long: `Show the resource usage of the agents running in the development server.
+
+The CPU and memory of the processes of the project server are shown with the
+heap, event loop delay and garbage collection stats of the JavaScript runtime,
+which are collected by the development build of the project. The agents of a
+project run in the same runtime process so the stats are those of the runtime.
+
+The memory used is compared to deployment.resources.memory in agentuity.yaml
+with a warning from 80% of it, since a deployment which runs out of memory is
+restarted. The view is refreshed every 2 seconds until interrupted unless
+--once or --format json is provided.
+
+Flags:
+  --dir       The project directory
+  --once      Show the stats once instead of refreshing them
+  --format    The format to use for the output (text or json)
+
+Examples:
+  agentuity dev stats
+  agentuity dev stats --once
+  agentuity dev stats --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		once, _ := cmd.Flags().GetBool("once")
+		format, _ := cmd.Flags().GetString("format")
+
+		theproject := project.NewProject()
+		if err := theproject.Load(dir); err != nil && err != cproject.ErrProjectMissingProjectId {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load project")).ShowErrorAndExit()
+		}
+		state, err := dev.LoadState(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to load the development server state")).ShowErrorAndExit()
+		}
+		if state == nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("the development server isn't running"),
+				errsystem.WithUserMessage("The development server isn't running. Start it with %s.", tui.Command("dev"))).ShowErrorAndExit()
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(collectDevStats(dir, state, theproject))
+			return
+		}
+		live := !once && tui.HasTTY
+		for {
+			stats := collectDevStats(dir, state, theproject)
+			if live {
+				fmt.Print("\033[H\033[2J")
+			}
+			printDevStats(stats)
+			if !live {
+				return
+			}
+			fmt.Println()
+			fmt.Println(tui.Muted("Refreshing every 2 seconds, press Ctrl+C to exit"))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(2 * time.Second):
+			}
+			if s, _ := dev.LoadState(dir); s == nil {
+				fmt.Println()
+				tui.ShowWarning("The development server stopped")
+				return
+			}
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(devCmd)
 	devCmd.Flags().StringP("dir", "d", ".", "The directory to run the development server in")
+	devCmd.Flags().String("env-profile", "", "The environment profile to use, which loads variables from .env.<profile> instead of .env.development")
 	devCmd.Flags().Int("port", 0, "The port to run the development server on (uses project default if not provided)")
+	devCmd.Flags().Bool("https", false, "Also serve the development server over HTTPS with a locally-trusted certificate")
+	devCmd.Flags().Int("https-port", 0, "The port to serve HTTPS on (uses a random port if not provided)")
+	devCmd.Flags().String("host", dev.DefaultHost, "The address to bind the development server to, use 0.0.0.0 to accept connections from other devices")
 	devCmd.Flags().Bool("no-build", false, "Do not build the project before running it (useful for debugging)")
 	devCmd.Flags().MarkHidden("no-build")
+	devCmd.Flags().String("session", dev.DefaultSession, "The name of the session to record the conversation to")
+	devCmd.Flags().Bool("no-session", false, "Do not record the conversation")
+	devCmd.Flags().String("chaos", "", "Inject latency and failures into the calls to agents and cloud services, for example \"latency=300ms,error-rate=5%\"")
+	devCmd.Flags().Bool("local-models", false, "Serve the LLM calls of the agents with a local model runtime such as Ollama or LM Studio")
+	devCmd.Flags().Bool("no-middleware", false, "Do not run the middleware declared in the project file")
+	devCmd.Flags().String("log-filter", "", "Only show the logs of the agents matching the filter, for example \"level=error agent=support\"")
+	devCmd.Flags().Bool("headless", false, "Never prompt or read commands from the terminal, such as when running as a service")
+	devCmd.Flags().Bool("inspect", false, "Listen for a debugger in the agents, such as node --inspect or debugpy")
+	devCmd.Flags().Int("inspect-port", 0, "The port the debugger listens on (defaults to 9229 for Node.js, 6499 for Bun and 5678 for Python)")
+	devCmd.Flags().Bool("coverage", false, "Collect the coverage of the agents and write the lcov and Cobertura reports when stopped")
+	devCmd.Flags().String("coverage-dir", dev.DefaultCoverageDir, "The directory to write the coverage reports to")
+	devCmd.Flags().String("events", "", "Write the lifecycle events to stdout as JSON lines (json), implies --headless")
+	devCmd.Flags().Bool("raw-logs", false, "Show the output of the agents as is instead of formatting structured logs and collapsing stack traces")
+	devCmd.Flags().Bool("remote", false, "Run the development server in a cloud sandbox, syncing the changed files to it")
+	addInstallFlags(devCmd)
+
+	devCmd.AddCommand(devTriggerCmd)
+	devTriggerCmd.AddCommand(devTriggerEmailCmd)
+	devTriggerCmd.AddCommand(devTriggerSMSCmd)
+	devTriggerCmd.AddCommand(devTriggerCronCmd)
+	devTriggerCmd.AddCommand(devTriggerSlackCmd)
+
+	devCmd.AddCommand(devStatsCmd)
+	devStatsCmd.Flags().StringP("dir", "d", "", "The project directory")
+	devStatsCmd.Flags().Bool("once", false, "Show the stats once instead of refreshing them")
+	devStatsCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+
+	devCmd.AddCommand(devSessionsCmd)
+	devSessionsCmd.AddCommand(devSessionsListCmd)
+	devSessionsCmd.AddCommand(devSessionsShowCmd)
+	devSessionsCmd.AddCommand(devSessionsExportCmd)
+	devSessionsCmd.AddCommand(devSessionsClearCmd)
+
+	for _, cmd := range []*cobra.Command{devSessionsListCmd, devSessionsShowCmd, devSessionsExportCmd, devSessionsClearCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+	}
+	devSessionsListCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	devSessionsShowCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	devSessionsExportCmd.Flags().StringP("output", "o", "", "The file to write the session to (defaults to stdout)")
+	devSessionsClearCmd.Flags().Bool("force", false, "Don't prompt for confirmation when removing all the sessions")
+
+	for _, cmd := range []*cobra.Command{devTriggerEmailCmd, devTriggerSMSCmd, devTriggerCronCmd, devTriggerSlackCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+		cmd.Flags().Int("port", 0, "The port of the development server (defaults to the port of the running development server)")
+		cmd.Flags().Bool("dry-run", false, "Print the trigger payload instead of sending it")
+	}
+
+	devTriggerEmailCmd.Flags().String("from", "Jane Doe <jane@example.com>", "The sender of the email")
+	devTriggerEmailCmd.Flags().String("to", "", "The recipient of the email (defaults to the agent email address)")
+	devTriggerEmailCmd.Flags().String("subject", "Hello", "The subject of the email")
+	devTriggerEmailCmd.Flags().String("text", "", "The plain text body of the email")
+	devTriggerEmailCmd.Flags().String("html", "", "The HTML body of the email")
+	devTriggerEmailCmd.Flags().StringArray("attachment", nil, "A file to attach to the email (can be repeated)")
+
+	devTriggerSMSCmd.Flags().String("from", "+15555550100", "The phone number the SMS is sent from")
+	devTriggerSMSCmd.Flags().String("to", "+15555550199", "The phone number the SMS is sent to")
+	devTriggerSMSCmd.Flags().String("text", "Hello", "The text of the SMS")
+
+	devTriggerCronCmd.Flags().String("schedule", "*/5 * * * *", "The cron schedule of the invocation")
+	devTriggerCronCmd.Flags().String("payload", "", "The payload of the cron invocation")
+	devTriggerCronCmd.Flags().String("content-type", "", "The content type of the payload, will try to detect if not provided")
+
+	devTriggerSlackCmd.Flags().String("event", "message", "The Slack event type which can be message or app_mention")
+	devTriggerSlackCmd.Flags().String("team", "T0123456789", "The Slack team (workspace) ID")
+	devTriggerSlackCmd.Flags().String("channel", "C0123456789", "The Slack channel ID")
+	devTriggerSlackCmd.Flags().String("user", "U0123456789", "The Slack user ID of the sender")
+	devTriggerSlackCmd.Flags().String("text", "Hello", "The text of the message")
 }
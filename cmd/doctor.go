@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/doctor"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Args:  cobra.NoArgs,
+	Short: "Diagnose and fix common problems with the project environment",
+	Long: `Diagnose and fix common problems with the environment of the project.
+
+The checks are:
+
+  lockfile   The runtime in agentuity.yaml matches the lockfile of the project
+  runtime    The runtime and package manager which the project needs are installed
+  state      The local state in .agentuity (such as dev.json and the sessions) isn't corrupted
+  env        The .env files don't use deprecated keys such as AGENTUITY_API_KEY
+
+With --fix, the problems which can be fixed are fixed. Installing a missing runtime
+runs its documented installer, which is only done after you confirm it (or with
+--yes). Corrupted state is moved aside or removed so that it is created again.
+
+Flags:
+  --dir       The project directory
+  --fix       Fix the problems which can be fixed automatically
+  --yes       Don't ask for confirmation before running an installer
+  --format    The format to use for the output (text or json)
+
+Examples:
+  agentuity doctor
+  agentuity doctor --fix
+  agentuity doctor --fix --yes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		fix, _ := cmd.Flags().GetBool("fix")
+		yes, _ := cmd.Flags().GetBool("yes")
+		format, _ := cmd.Flags().GetString("format")
+
+		theproject := project.NewProject()
+		if err := theproject.Load(dir); err != nil && err != cproject.ErrProjectMissingProjectId {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load project")).ShowErrorAndExit()
+		}
+		opts := doctor.Options{Dir: dir, Project: theproject, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+		problems, err := doctor.Diagnose(opts)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to diagnose the project")).ShowErrorAndExit()
+		}
+
+		type result struct {
+			*doctor.Problem
+			Fixable bool   `json:"fixable"`
+			Fixed   bool   `json:"fixed"`
+			Error   string `json:"error,omitempty"`
+		}
+		results := make([]result, 0, len(problems))
+		for _, p := range problems {
+			results = append(results, result{Problem: p, Fixable: p.Fixable()})
+		}
+
+		if format != "json" {
+			if len(problems) == 0 {
+				tui.ShowSuccess("No problems found")
+				return
+			}
+			for _, r := range results {
+				fmt.Printf("%s %s\n", tui.Warning(tui.PadRight("["+r.Check+"]", 11, " ")), r.Message)
+				if r.Fix != "" {
+					fmt.Printf("%s %s\n", tui.PadRight("", 11, " "), tui.Muted("fix: "+r.Fix))
+				}
+			}
+			fmt.Println()
+		}
+
+		if fix {
+			for i, r := range results {
+				if !r.Fixable {
+					continue
+				}
+				if r.Confirm && !yes {
+					if !tui.HasTTY || format == "json" {
+						results[i].Error = "skipped: use --yes to run the installer without confirmation"
+						continue
+					}
+					if !tui.Ask(logger, fmt.Sprintf("%s. Would you like to %s?", r.Message, r.Fix), true) {
+						results[i].Error = "skipped"
+						continue
+					}
+				}
+				if err := r.Apply(ctx); err != nil {
+					results[i].Error = err.Error()
+					continue
+				}
+				results[i].Fixed = true
+			}
+		}
+
+		var remaining, fixed int
+		for _, r := range results {
+			if r.Fixed {
+				fixed++
+			} else {
+				remaining++
+			}
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(results)
+		} else if fix {
+			for _, r := range results {
+				switch {
+				case r.Fixed:
+					fmt.Printf("%s %s\n", tui.Bold(tui.PadRight("fixed", 11, " ")), r.Message)
+				case r.Error != "":
+					fmt.Printf("%s %s: %s\n", tui.Warning(tui.PadRight("not fixed", 11, " ")), r.Message, r.Error)
+				}
+			}
+			fmt.Println()
+			if remaining == 0 {
+				tui.ShowSuccess("Fixed %s", util.Pluralize(fixed, "problem", "problems"))
+			} else {
+				tui.ShowWarning("Fixed %s, %s remaining", util.Pluralize(fixed, "problem", "problems"), util.Pluralize(remaining, "problem", "problems"))
+			}
+		} else {
+			var fixable int
+			for _, r := range results {
+				if r.Fixable {
+					fixable++
+				}
+			}
+			if fixable > 0 {
+				tui.ShowWarning("Found %s. Run agentuity doctor --fix to fix %d of them.", util.Pluralize(len(results), "problem", "problems"), fixable)
+			} else {
+				tui.ShowWarning("Found %s", util.Pluralize(len(results), "problem", "problems"))
+			}
+		}
+		if remaining > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringP("dir", "d", "", "The project directory")
+	doctorCmd.Flags().Bool("fix", false, "Fix the problems which can be fixed automatically")
+	doctorCmd.Flags().Bool("yes", false, "Don't ask for confirmation before running an installer")
+	doctorCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+}
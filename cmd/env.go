@@ -5,14 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/agentuity/cli/internal/auth"
 	"github.com/agentuity/cli/internal/envutil"
 	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/keys"
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/env"
@@ -29,7 +35,19 @@ var envCmd = &cobra.Command{
 	Short: "Environment related commands",
 	Long: `Environment related commands for managing environment variables and secrets.
 
-Use the subcommands to set, get, list, and delete environment variables and secrets.`,
+Use the subcommands to set, get, list, and delete environment variables and secrets.
+
+Values in your .env files can be references to an external secret manager which
+are resolved when you run dev or deploy, so the real secret never needs to be
+stored in the file:
+
+  OPENAI_API_KEY=op://vault/item/field        (1Password, requires op)
+  DATABASE_PASSWORD=aws-sm://prod/db#password  (AWS Secrets Manager, requires aws)
+  STRIPE_KEY=vault://secret/stripe#key        (HashiCorp Vault, requires vault)
+
+A snapshot of the environment variables and secrets of the project is saved
+before they're changed. Use env history to list the snapshots and env revert to
+undo a change.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -39,14 +57,15 @@ var (
 	hasTTY = tui.HasTTY
 )
 
-func loadEnvFile(le []env.EnvLineComment, forceSecret bool) (map[string]string, map[string]string) {
+func loadEnvFile(le []env.EnvLineComment, forceSecret bool, policy envutil.EnvPolicy) (map[string]string, map[string]string) {
 	envs := make(map[string]string)
 	secrets := make(map[string]string)
 	for _, ev := range le {
-		if envutil.IsAgentuityEnv.MatchString(ev.Key) {
+		if envutil.IsAgentuityEnv.MatchString(ev.Key) || policy.IsLocalOnly(ev.Key) {
 			continue
 		}
-		if envutil.LooksLikeSecret.MatchString(ev.Key) || forceSecret || envutil.DescriptionLookingLikeASecret(ev.Comment) {
+		_, declared := policy[ev.Key]
+		if policy.IsSecret(ev.Key) || forceSecret || (!declared && envutil.DescriptionLookingLikeASecret(ev.Comment)) {
 			secrets[ev.Key] = ev.Val
 		} else {
 			envs[ev.Key] = ev.Val
@@ -72,6 +91,10 @@ var envSetCmd = &cobra.Command{
 	Short:   "Set environment variables",
 	Long: `Set environment variables or secrets for your project.
 
+The variables are stored as secrets or environment variables following the env
+policy in agentuity.yaml, or by their name when they aren't in the policy. The
+variables which are local-only in the policy are never uploaded.
+
 Arguments:
   [key]    The name of the environment variable
   [value]  The value of the environment variable
@@ -80,22 +103,33 @@ Flags:
   --file      Path to a file containing environment variables to set
   --secret    Force the value(s) to be treated as a secret
   --force     Don't prompt for confirmation
+  --workspace           Set the variables for every project of the workspace
+  --workspace-project   Set the variables for the projects of the workspace with the names
 
 Examples:
   agentuity env set API_KEY "my-api-key"
   agentuity env set --secret TOKEN "secret-token"
-  agentuity env set --file .env`,
+  agentuity env set --file .env
+  agentuity env set --workspace LOG_LEVEL debug`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if runWorkspace(cmd, args) {
+			return
+		}
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		context := project.EnsureProject(ctx, cmd)
 		dir := context.Dir
 		apiUrl := context.APIURL
 		apiKey := context.Token
 		theproject := context.Project
 
+		policy, err := envutil.LoadEnvPolicy(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the env policy")).ShowErrorAndExit()
+		}
+
 		forceSecret, _ := cmd.Flags().GetBool("secret")
 		noConfirm, _ := cmd.Flags().GetBool("force")
 
@@ -113,7 +147,7 @@ Examples:
 		if setFromFile != "" {
 			if sys.Exists(setFromFile) {
 				le, _ := env.ParseEnvFileWithComments(setFromFile)
-				envs, secrets = loadEnvFile(le, forceSecret)
+				envs, secrets = loadEnvFile(le, forceSecret, policy)
 				if len(envs) > 0 || len(secrets) > 0 {
 					hasSetFromFile = true
 					setFromEnv = true
@@ -132,7 +166,7 @@ Examples:
 				le, _ := env.ParseEnvFile(envfile)
 				var added bool
 				for _, ev := range le {
-					if !envutil.IsAgentuityEnv.MatchString(ev.Key) {
+					if !envutil.IsAgentuityEnv.MatchString(ev.Key) && !policy.IsLocalOnly(ev.Key) {
 						localenv[ev.Key] = ev.Val
 						added = true
 					}
@@ -151,7 +185,7 @@ Examples:
 			results := tui.MultiSelect(logger, "Set environment variables from .env", "", options)
 			for _, result := range results {
 				val := localenv[result]
-				if envutil.LooksLikeSecret.MatchString(result) || forceSecret {
+				if policy.IsSecret(result) || forceSecret {
 					secrets[result] = val
 				} else {
 					envs[result] = val
@@ -186,7 +220,10 @@ Examples:
 				askMore = false
 			}
 		}
-		isSecret = envutil.LooksLikeSecret.MatchString(key) || forceSecret
+		if policy.IsLocalOnly(key) {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s is local-only", key), errsystem.WithUserMessage("%s is local-only in the env policy of agentuity.yaml and is never uploaded", key)).ShowErrorAndExit()
+		}
+		isSecret = policy.IsSecret(key) || forceSecret
 		if key != "" && value == "" && !noConfirm {
 			if len(envs) == 0 && len(secrets) == 0 {
 				fi, _ := os.Stdin.Stat()
@@ -215,6 +252,10 @@ Examples:
 			goto restart
 		}
 
+		if len(secrets) > 0 {
+			ensurePermission(ctx, logger, apiUrl, apiKey, theproject.ProjectId, auth.PermissionSecretWrite, "set secrets")
+		}
+
 		action := func() {
 			combined := make(map[string]string)
 			// make sure secrets are not in envs as duplicates since secrets take precedence
@@ -227,6 +268,7 @@ Examples:
 				delete(secrets, k)
 				combined[k] = envs[k]
 			}
+			envutil.SnapshotProjectEnv(ctx, logger, dir, apiUrl, apiKey, theproject.ProjectId, "env set")
 			_, err := project.SetProjectEnv(ctx, logger, apiUrl, apiKey, theproject.ProjectId, envs, secrets)
 			if err != nil {
 				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithUserMessage("Failed to save project settings")).ShowErrorAndExit()
@@ -337,10 +379,18 @@ var envListCmd = &cobra.Command{
 
 This command displays all environment variables and secrets set for your project.
 
+Flags:
+  --workspace           List the variables of every project of the workspace
+  --workspace-project   List the variables of the projects of the workspace with the names
+
 Examples:
   agentuity env list
-  agentuity env ls`,
+  agentuity env ls
+  agentuity env list --workspace`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if runWorkspace(cmd, args) {
+			return
+		}
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
@@ -402,12 +452,17 @@ Arguments:
 
 Flags:
   --force    Don't prompt for confirmation
+  --workspace           Delete the variables from every project of the workspace
+  --workspace-project   Delete the variables from the projects of the workspace with the names
 
 Examples:
   agentuity env delete API_KEY
   agentuity env delete API_KEY SECRET_TOKEN
   agentuity env delete --force API_KEY`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if runWorkspace(cmd, args) {
+			return
+		}
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
@@ -499,6 +554,7 @@ Examples:
 					return
 				}
 			}
+			envutil.SnapshotProjectEnv(ctx, logger, context.Dir, apiUrl, apiKey, theproject.ProjectId, "env delete")
 			err := project.DeleteProjectEnv(ctx, logger, apiUrl, apiKey, theproject.ProjectId, envsToDelete, secretsToDelete)
 			if err != nil {
 				errsystem.New(errsystem.ErrApiRequest, err).ShowErrorAndExit()
@@ -522,6 +578,398 @@ Examples:
 	},
 }
 
+var envProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Args:  cobra.NoArgs,
+	Short: "List environment profiles and report conflicts between them",
+	Long: `List the environment profiles in your project and report conflicts between them.
+
+An environment profile is a .env.<name> file (for example .env.staging or
+.env.production) which can be selected with --env-profile on the dev and
+deploy commands. A conflict is a variable which is defined in some profiles
+but missing from others.
+
+Flags:
+  --format   The output format (text or json)
+
+Examples:
+  agentuity env profiles
+  agentuity env profiles --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		format, _ := cmd.Flags().GetString("format")
+
+		profiles, err := envutil.ListEnvProfiles(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to list environment profiles")).ShowErrorAndExit()
+		}
+		conflicts, err := envutil.FindEnvProfileConflicts(dir, profiles)
+		if err != nil {
+			errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to compare environment profiles")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(map[string]any{
+				"profiles":  profiles,
+				"conflicts": conflicts,
+			})
+			return
+		}
+		if len(profiles) == 0 {
+			tui.ShowWarning("No environment profiles found. Create a .env.<name> file to add one.")
+			return
+		}
+		for _, profile := range profiles {
+			fmt.Println(tui.Title(tui.PadRight(profile, 20, " ")) + tui.Muted(".env."+profile))
+		}
+		fmt.Println()
+		if len(conflicts) == 0 {
+			tui.ShowSuccess("All profiles define the same variables")
+			return
+		}
+		tui.ShowWarning("%s not defined in every profile:", util.Pluralize(len(conflicts), "variable is", "variables are"))
+		fmt.Println()
+		for _, c := range conflicts {
+			fmt.Printf("  %s %s\n", tui.Bold(tui.PadRight(c.Key, 30, " ")), tui.Muted("missing in "+strings.Join(c.MissingIn, ", ")))
+		}
+	},
+}
+
+var envHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Args:  cobra.NoArgs,
+	Short: "List the snapshots of the environment variables and secrets of the project",
+	Long: `List the snapshots of the environment variables and secrets of your cloud project.
+
+A snapshot is saved before env set, env delete or the sync of a .env file during
+dev and deploy change the environment variables or secrets of the project, so an
+accidental change can be undone with env revert. The snapshots are kept in
+.agentuity/env-history (readable only by you) and the last 50 are kept.
+
+A snapshot only has a hash of each value, which is enough to see what changed, and
+the values encrypted with the active encryption key of the organization (see keys)
+when it has one, which is needed to set them back with env revert.
+
+Flags:
+  --format   The output format (text or json)
+
+Examples:
+  agentuity env history
+  agentuity env history --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		format, _ := cmd.Flags().GetString("format")
+
+		snapshots, err := envutil.ListEnvSnapshots(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to read the environment history")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			// only the names are shown so that the values don't end up in a terminal or a log
+			type snapshotKeys struct {
+				ID        string    `json:"id"`
+				CreatedAt time.Time `json:"createdAt"`
+				Reason    string    `json:"reason"`
+				Env       []string  `json:"env"`
+				Secrets   []string  `json:"secrets"`
+			}
+			result := []snapshotKeys{}
+			for _, s := range snapshots {
+				result = append(result, snapshotKeys{ID: s.ID, CreatedAt: s.CreatedAt, Reason: s.Reason, Env: slices.Sorted(maps.Keys(s.Env)), Secrets: slices.Sorted(maps.Keys(s.Secrets))})
+			}
+			json.NewEncoder(os.Stdout).Encode(result)
+			return
+		}
+		if len(snapshots) == 0 {
+			tui.ShowWarning("No environment snapshots found. A snapshot is saved before the environment of the project is changed.")
+			return
+		}
+		headers := []string{"Snapshot", "Created", "Before", "Variables", "Secrets"}
+		rows := [][]string{}
+		for _, s := range snapshots {
+			rows = append(rows, []string{tui.Bold(s.ID), tui.Title(s.CreatedAt.Local().Format(time.DateTime)), tui.Text(s.Reason), tui.Muted(strconv.Itoa(len(s.Env))), tui.Muted(strconv.Itoa(len(s.Secrets)))})
+		}
+		tui.Table(headers, rows)
+	},
+}
+
+var envRevertCmd = &cobra.Command{
+	Use:   "revert <snapshot>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Revert the environment variables and secrets of the project to a snapshot",
+	Long: `Revert the environment variables and secrets of your cloud project to a snapshot.
+
+The variables and secrets which were added since the snapshot are deleted and the
+ones which were changed or deleted are set back to their value in the snapshot.
+Setting values back needs the private key of the organization key the values of
+the snapshot were encrypted with. The changes are shown before they are made and the .env file is updated like
+with env set and env delete. A snapshot of the current values is saved first so
+that the revert can be undone too.
+
+Arguments:
+  <snapshot>  The id of the snapshot, or a unique prefix of it, from env history
+
+Flags:
+  --private-key   The private key to decrypt the values of the snapshot with
+  --force         Don't prompt for confirmation
+
+Examples:
+  agentuity env revert 20261015-093012 --private-key org.key
+  agentuity env revert 20261015-0930 --private-key org.key --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		projectId := theproject.Project.ProjectId
+
+		snapshot, err := envutil.FindEnvSnapshot(theproject.Dir, args[0])
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s. Use %s to list the snapshots.", err, tui.Command("env history"))).ShowErrorAndExit()
+		}
+		var values *envutil.EnvValues
+		if privateKeyFile, _ := cmd.Flags().GetString("private-key"); privateKeyFile != "" && snapshot.Values != nil {
+			buf, err := os.ReadFile(privateKeyFile)
+			if err != nil {
+				errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to read the private key")).ShowErrorAndExit()
+			}
+			priv, err := keys.ParsePrivateKey(buf)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			if values, err = snapshot.DecryptValues(priv); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
+		current, err := project.GetProject(ctx, logger, theproject.APIURL, theproject.Token, projectId, false, false)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the environment of the project")).ShowErrorAndExit()
+		}
+		changes := snapshot.RevertChanges(current.Env, current.Secrets, values)
+		if len(changes) == 0 {
+			tui.ShowSuccess("The environment of the project already matches the snapshot %s", snapshot.ID)
+			return
+		}
+		var missing []string
+		for _, c := range changes {
+			if c.Missing {
+				missing = append(missing, c.Key)
+			}
+		}
+		if len(missing) > 0 {
+			if snapshot.Values == nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("the snapshot has no values"),
+					errsystem.WithUserMessage("The snapshot %s only has the hashes of the values since the organization had no encryption key. Set %s again with %s.", snapshot.ID, strings.Join(missing, ", "), tui.Command("env set"))).ShowErrorAndExit()
+			}
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("missing private key"),
+				errsystem.WithUserMessage("Reverting %s needs the values of the snapshot. Use --private-key with the private key of the organization key %s.", strings.Join(missing, ", "), snapshot.Values.Fingerprint)).ShowErrorAndExit()
+		}
+
+		var envs, secrets map[string]string = map[string]string{}, map[string]string{}
+		var envsToDelete, secretsToDelete []string
+		for _, c := range changes {
+			label := "environment variable"
+			if c.Secret {
+				label = "secret"
+			}
+			switch c.Action {
+			case "delete":
+				fmt.Printf("%s %s %s\n", tui.Warning("-"), tui.Bold(c.Key), tui.Muted(label))
+				if c.Secret {
+					secretsToDelete = append(secretsToDelete, c.Key)
+				} else {
+					envsToDelete = append(envsToDelete, c.Key)
+				}
+			default:
+				value := c.Value
+				if c.Secret {
+					value = cstr.Mask(value)
+					secrets[c.Key] = c.Value
+				} else {
+					envs[c.Key] = c.Value
+				}
+				symbol := tui.Secondary("+")
+				if c.Action == "update" {
+					symbol = tui.Secondary("~")
+				}
+				fmt.Printf("%s %s=%s %s\n", symbol, tui.Bold(c.Key), util.MaxString(value, 40), tui.Muted(label))
+			}
+		}
+		fmt.Println()
+
+		if len(secrets) > 0 || len(secretsToDelete) > 0 {
+			ensurePermission(ctx, logger, theproject.APIURL, theproject.Token, projectId, auth.PermissionSecretWrite, "revert secrets")
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force {
+			if !tui.Ask(logger, fmt.Sprintf("Revert %s to the snapshot %s?", util.Pluralize(len(changes), "change", "changes"), snapshot.ID), false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		tui.ShowSpinner("Reverting ...", func() {
+			envutil.SnapshotProjectEnv(ctx, logger, theproject.Dir, theproject.APIURL, theproject.Token, projectId, "env revert to "+snapshot.ID)
+			// deleted first since a variable may have moved between the environment variables and the secrets
+			if len(envsToDelete) > 0 || len(secretsToDelete) > 0 {
+				if err = project.DeleteProjectEnv(ctx, logger, theproject.APIURL, theproject.Token, projectId, envsToDelete, secretsToDelete); err != nil {
+					return
+				}
+			}
+			if len(envs) > 0 || len(secrets) > 0 {
+				if _, err = project.SetProjectEnv(ctx, logger, theproject.APIURL, theproject.Token, projectId, envs, secrets); err != nil {
+					return
+				}
+			}
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to revert the environment of the project")).ShowErrorAndExit()
+		}
+
+		// keep the .env file in sync like env set and env delete do
+		combined := maps.Clone(envs)
+		maps.Copy(combined, secrets)
+		var removed []string
+		for _, key := range append(envsToDelete, secretsToDelete...) {
+			if _, ok := combined[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+		if len(removed) > 0 {
+			if err := project.RemoveEnvValues(ctx, logger, theproject.Dir, removed...); err != nil {
+				logger.Warn("failed to update the .env file: %s", err)
+			}
+		}
+		if len(combined) > 0 {
+			if err := project.SaveEnvValue(ctx, logger, theproject.Dir, combined); err != nil {
+				logger.Warn("failed to update the .env file: %s", err)
+			}
+		}
+		tui.ShowSuccess("Reverted the environment of the project to the snapshot %s", snapshot.ID)
+	},
+}
+
+var envScaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Args:  cobra.NoArgs,
+	Short: "Generate a .env.example from environment variables used in your project",
+	Long: `Generate or update a .env.example file from the environment variables referenced in your project.
+
+This command scans the project source (not just your agents) for environment
+variables and adds any which are missing to .env.example, grouped by agent.
+Existing entries in .env.example are left untouched.
+
+It also warns about variables which are referenced in code but not set in
+either your local .env file or your cloud project.
+
+Flags:
+  --dry-run   Print the changes without writing the file
+
+Examples:
+  agentuity env scaffold
+  agentuity env scaffold --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		context := project.EnsureProject(ctx, cmd)
+		logger := context.Logger
+		theproject := context.Project
+		dir := context.Dir
+
+		var agentsDir string
+		if theproject.Bundler != nil {
+			agentsDir = theproject.Bundler.AgentConfig.Dir
+		}
+
+		refs, err := envutil.ScanForEnvironmentVariables(dir, agentsDir)
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to scan project")).ShowErrorAndExit()
+		}
+		if len(refs) == 0 {
+			tui.ShowWarning("No environment variables found in your project")
+			return
+		}
+
+		templateFile := filepath.Join(dir, envutil.EnvTemplateFileNames[0])
+		for _, name := range envutil.EnvTemplateFileNames {
+			if f := filepath.Join(dir, name); util.Exists(f) {
+				templateFile = f
+				break
+			}
+		}
+		var existing string
+		var existingEnvs []env.EnvLineComment
+		if util.Exists(templateFile) {
+			buf, err := os.ReadFile(templateFile)
+			if err != nil {
+				errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to read env template file")).ShowErrorAndExit()
+			}
+			existing = string(buf)
+			existingEnvs, err = env.ParseEnvFileWithComments(templateFile)
+			if err != nil {
+				errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to parse env template file")).ShowErrorAndExit()
+			}
+		}
+
+		content, added := envutil.ScaffoldEnvTemplate(existing, existingEnvs, refs)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		name := filepath.Base(templateFile)
+		if len(added) == 0 {
+			tui.ShowSuccess("%s is up to date", name)
+		} else if dryRun {
+			fmt.Printf("The following would be added to %s:\n\n", tui.Bold(name))
+			for _, ref := range added {
+				fmt.Printf("  %s %s\n", tui.Title(tui.PadRight(ref.Key, 30, " ")), tui.Muted(ref.Group))
+			}
+			fmt.Println()
+		} else {
+			if err := os.WriteFile(templateFile, []byte(content), 0644); err != nil {
+				errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to write env template file")).ShowErrorAndExit()
+			}
+			tui.ShowSuccess("Added %s to %s", util.Pluralize(len(added), "environment variable", "environment variables"), name)
+		}
+
+		// check for variables which aren't configured anywhere
+		configured := make(map[string]bool)
+		if envfile := filepath.Join(dir, ".env"); util.Exists(envfile) {
+			if le, err := env.ParseEnvFileWithComments(envfile); err == nil {
+				for _, ev := range le {
+					configured[ev.Key] = true
+				}
+			} else {
+				logger.Debug("failed to parse %s: %s", envfile, err)
+			}
+		}
+		projectData, err := project.GetProject(ctx, logger, context.APIURL, context.Token, theproject.ProjectId, true, false)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch project environment")).ShowErrorAndExit()
+		}
+		for key := range projectData.Env {
+			configured[key] = true
+		}
+		for key := range projectData.Secrets {
+			configured[key] = true
+		}
+		var missing []envutil.EnvReference
+		for _, ref := range refs {
+			if !configured[ref.Key] {
+				missing = append(missing, ref)
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Println()
+			tui.ShowWarning("%s referenced in code but not set locally or in the cloud:", util.Pluralize(len(missing), "variable is", "variables are"))
+			fmt.Println()
+			for _, ref := range missing {
+				fmt.Printf("  %s %s\n", tui.Bold(tui.PadRight(ref.Key, 30, " ")), tui.Muted(strings.Join(ref.Files, ", ")))
+			}
+			fmt.Println()
+			fmt.Printf("You can set them with %s\n", tui.Command("env", "set", "<key>", "<value>"))
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(envCmd)
 
@@ -533,13 +981,24 @@ func init() {
 	envCmd.AddCommand(envListCmd)
 	envCmd.AddCommand(envGetCmd)
 	envCmd.AddCommand(envDeleteCmd)
+	envCmd.AddCommand(envScaffoldCmd)
+	envCmd.AddCommand(envProfilesCmd)
+	envCmd.AddCommand(envHistoryCmd)
+	envCmd.AddCommand(envRevertCmd)
+
+	envScaffoldCmd.Flags().Bool("dry-run", false, "Print the changes without writing the file")
 
 	envDeleteCmd.Flags().Bool("force", !hasTTY, "Don't prompt for confirmation")
+	envRevertCmd.Flags().Bool("force", !hasTTY, "Don't prompt for confirmation")
+	envRevertCmd.Flags().String("private-key", "", "The private key to decrypt the values of the snapshot with")
 
-	for _, cmd := range []*cobra.Command{envSetCmd, envListCmd, envGetCmd, envDeleteCmd} {
+	for _, cmd := range []*cobra.Command{envSetCmd, envListCmd, envGetCmd, envDeleteCmd, envScaffoldCmd, envProfilesCmd, envHistoryCmd, envRevertCmd} {
 		cmd.Flags().StringP("dir", "d", ".", "The directory to the project to deploy")
 	}
 
+	envProfilesCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	envHistoryCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+
 	for _, cmd := range []*cobra.Command{envListCmd, envGetCmd} {
 		cmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
 		cmd.Flags().Bool("mask", true, "Mask secrets in the output")
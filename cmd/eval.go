@@ -8,13 +8,14 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 
+	"github.com/agentuity/cli/internal/agent"
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/eval"
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
@@ -116,7 +117,7 @@ var evalCreateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		theproject := project.EnsureProject(ctx, cmd)
 		apikey := theproject.Token
 		urls := util.GetURLs(logger)
@@ -175,12 +176,266 @@ var evalCreateCmd = &cobra.Command{
 	},
 }
 
+const (
+	defaultEvalBaseline = ".agentuity/evals/baseline.json"
+	defaultEvalOutput   = ".agentuity/evals/last-run.json"
+)
+
+// evalTargetResolver returns the resolver for the agent endpoints the datasets are run against
+func evalTargetResolver(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, local bool, port int, tag string) eval.TargetResolver {
+	var agents []agent.Agent
+	var once sync.Once
+	var listErr error
+	return func(name string) (*eval.Target, error) {
+		once.Do(func() {
+			agents, listErr = agent.ListAgents(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+		})
+		if listErr != nil {
+			return nil, listErr
+		}
+		found := findAgent(agents, name)
+		if found == nil {
+			return nil, fmt.Errorf("agent %s not found in the project", name)
+		}
+		endpoint, apikey, err := agentEndpoint(ctx, logger, theproject, found, local, port, tag)
+		if err != nil {
+			return nil, err
+		}
+		return &eval.Target{Endpoint: endpoint, APIKey: apikey}, nil
+	}
+}
+
+// evalProviderResolver returns the resolver for the providers used by the llm graders
+func evalProviderResolver(ctx context.Context, logger logger.Logger, theproject project.ProjectContext) eval.ProviderResolver {
+	var sdkKey string
+	var once sync.Once
+	var keyErr error
+	loadKey := func() (string, error) {
+		once.Do(func() {
+			var data *project.ProjectData
+			data, keyErr = project.GetProject(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, false, true)
+			if keyErr == nil {
+				sdkKey = data.Secrets["AGENTUITY_SDK_KEY"]
+			}
+		})
+		return sdkKey, keyErr
+	}
+	var mu sync.Mutex
+	providers := make(map[string]eval.Provider)
+	return func(name string) (eval.Provider, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p, ok := providers[name]; ok {
+			return p, nil
+		}
+		p, err := eval.ResolveProvider(name, theproject.TransportURL, loadKey)
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = p
+		return p, nil
+	}
+}
+
+func printEvalReport(report *eval.Report, baseline string) {
+	var dataset string
+	for _, result := range report.Results {
+		if result.Dataset != dataset {
+			dataset = result.Dataset
+			fmt.Println()
+			fmt.Println(tui.Bold(dataset))
+		}
+		mark := tui.Warning("✕")
+		if result.Pass {
+			mark = tui.Secondary("✓")
+		}
+		var details []string
+		for _, grade := range result.Grades {
+			detail := fmt.Sprintf("%s=%.2f", grade.Grader, grade.Score)
+			if !grade.Pass && grade.Reason != "" {
+				detail += " (" + grade.Reason + ")"
+			}
+			details = append(details, detail)
+		}
+		if result.Error != "" {
+			details = append(details, result.Error)
+		}
+		fmt.Printf("  %s %s %s\n", mark, tui.PadRight(result.Case, 30, " "), tui.Muted(fmt.Sprintf("%dms %s", result.DurationMs, strings.Join(details, " "))))
+	}
+	fmt.Println()
+	summary := fmt.Sprintf("%d/%d passed (%.1f%%)", report.Passed, report.Total, report.PassRate*100)
+	if report.BaselinePassRate != nil {
+		summary += tui.Muted(fmt.Sprintf(" · baseline %.1f%%", *report.BaselinePassRate*100))
+	}
+	fmt.Println(tui.Bold("Pass rate: ") + summary)
+	if len(report.Fixed) > 0 {
+		fmt.Println(tui.Bold("Fixed: ") + strings.Join(report.Fixed, ", "))
+	}
+	if len(report.Regressions) > 0 {
+		fmt.Println(tui.Bold("Regressions: ") + tui.Warning(strings.Join(report.Regressions, ", ")))
+	}
+	if report.BaselinePassRate == nil {
+		fmt.Println(tui.Muted("No baseline found at " + baseline + ", use --update-baseline to record one"))
+	}
+	fmt.Println()
+}
+
+var evalRunCmd = &cobra.Command{
+	Use:   "run [datasets...]",
+	Short: "Run evaluation datasets against an agent",
+	Long: `Run evaluation datasets against a local or deployed agent.
+
+Each dataset is a YAML file with the agent to run against, the cases to send
+and the graders which score the outputs. The graders can be:
+
+  exact   The output must equal the expected value (JSON is compared structurally)
+  regex   The output must match the pattern
+  llm     An LLM judge scores the output against the criteria (openai or anthropic)
+
+The llm grader uses OPENAI_API_KEY or ANTHROPIC_API_KEY when set and otherwise
+the Agentuity AI Gateway of the project.
+
+The results are written to .agentuity/evals/last-run.json and compared with the
+baseline run to report the cases which regressed. The command fails when there
+are regressions or the pass rate is below --min-pass-rate.
+
+Example dataset:
+
+  agent: my-agent
+  graders:
+    - type: llm
+      criteria: The response is a friendly greeting which uses the name
+  cases:
+    - name: greets by name
+      input: {"name": "Jane"}
+    - name: plain text
+      input: Hello
+      graders:
+        - type: regex
+          pattern: (?i)hello
+
+Arguments:
+  [datasets...]        The dataset files or glob patterns (defaults to evals/*.yaml)
+
+Flags:
+  --local              Run against the local development server
+  --port               The port of the local development server
+  --tag                The deployment tag to run against
+  --baseline           The baseline run to compare with
+  --update-baseline    Save this run as the new baseline
+  --min-pass-rate      Fail when the pass rate (0-1) is below this value
+  --concurrency        The number of cases to run at the same time
+
+Examples:
+  agentuity eval run
+  agentuity eval run evals/greeting.yaml --local
+  agentuity eval run "evals/**/*.yaml" --update-baseline`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		local, _ := cmd.Flags().GetBool("local")
+		port, _ := cmd.Flags().GetInt("port")
+		tag, _ := cmd.Flags().GetString("tag")
+		baseline, _ := cmd.Flags().GetString("baseline")
+		output, _ := cmd.Flags().GetString("output")
+		updateBaseline, _ := cmd.Flags().GetBool("update-baseline")
+		minPassRate, _ := cmd.Flags().GetFloat64("min-pass-rate")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		format, _ := cmd.Flags().GetString("format")
+
+		if baseline == "" {
+			baseline = filepath.Join(theproject.Dir, defaultEvalBaseline)
+		}
+		if output == "" {
+			output = filepath.Join(theproject.Dir, defaultEvalOutput)
+		}
+		patterns := args
+		if len(patterns) == 0 {
+			patterns = []string{filepath.Join(theproject.Dir, "evals", "*.yaml")}
+		}
+
+		datasets, err := eval.LoadDatasets(patterns)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load eval datasets")).ShowErrorAndExit()
+		}
+
+		runner := &eval.Runner{
+			Targets:     evalTargetResolver(ctx, logger, theproject, local, port, tag),
+			Providers:   evalProviderResolver(ctx, logger, theproject),
+			Concurrency: concurrency,
+		}
+		var report *eval.Report
+		action := func() {
+			report, err = runner.Run(ctx, datasets)
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Running evaluations ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to run evaluations")).ShowErrorAndExit()
+		}
+
+		previous, err := eval.LoadReport(baseline)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the baseline run")).ShowErrorAndExit()
+		}
+		if previous != nil {
+			report.Compare(previous)
+		}
+		if err := report.Save(output); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the eval results")).ShowErrorAndExit()
+		}
+		if updateBaseline {
+			if err := report.Save(baseline); err != nil {
+				errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the eval baseline")).ShowErrorAndExit()
+			}
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(report)
+		} else {
+			printEvalReport(report, baseline)
+			if updateBaseline {
+				tui.ShowSuccess("Saved baseline %s", tui.Muted(baseline))
+			}
+		}
+
+		if len(report.Regressions) > 0 && !updateBaseline {
+			if format != "json" {
+				tui.ShowError("%d case(s) regressed since the baseline run", len(report.Regressions))
+			}
+			os.Exit(1)
+		}
+		if report.PassRate < minPassRate {
+			if format != "json" {
+				tui.ShowError("Pass rate %.1f%% is below the minimum of %.1f%%", report.PassRate*100, minPassRate*100)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(evalCmd)
 	evalCmd.AddCommand(evalCreateCmd)
+	evalCmd.AddCommand(evalRunCmd)
 
-	for _, cmd := range []*cobra.Command{evalCreateCmd} {
+	for _, cmd := range []*cobra.Command{evalCreateCmd, evalRunCmd} {
 		cmd.Flags().StringP("dir", "d", "", "The project directory")
 		cmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
 	}
+
+	evalRunCmd.Flags().Bool("local", false, "Run against the local development server")
+	evalRunCmd.Flags().Int("port", 0, "The port of the local development server (defaults to the port of the running development server)")
+	evalRunCmd.Flags().String("tag", "", "The deployment tag to run against")
+	evalRunCmd.Flags().String("baseline", "", "The baseline run to compare with (defaults to "+defaultEvalBaseline+")")
+	evalRunCmd.Flags().String("output", "", "The file to write the results to (defaults to "+defaultEvalOutput+")")
+	evalRunCmd.Flags().Bool("update-baseline", false, "Save this run as the new baseline")
+	evalRunCmd.Flags().Float64("min-pass-rate", 0, "Fail when the pass rate (0-1) is below this value")
+	evalRunCmd.Flags().Int("concurrency", 4, "The number of cases to run at the same time")
 }
@@ -0,0 +1,534 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/gateway"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var gatewayCmd = &cobra.Command{
+	Use:   "gateway",
+	Args:  cobra.NoArgs,
+	Short: "Manage the AI gateway of a project",
+	Long: `Manage the AI gateway of a project.
+
+The agents call the LLM providers through the Agentuity AI gateway unless they use
+their own API key for the provider. Use the subcommands to list the models of the
+gateway, set the default model of each provider for the project, view the usage and
+limits of each model and create gateway keys scoped to the project and some models.
+
+Examples:
+  agentuity gateway models --provider openai
+  agentuity gateway default openai/gpt-4o-mini
+  agentuity gateway usage --since 7d
+  agentuity gateway keys create ci --model openai/gpt-4o-mini --expires-in 30d`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// gatewayContext returns the logger, the context and the project of a gateway command
+func gatewayContext(cmd *cobra.Command) (context.Context, context.CancelFunc, logger.Logger, project.ProjectContext) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	logger := util.NewLogger(cmd)
+	theproject := project.EnsureProject(ctx, cmd)
+	return ctx, cancel, logger, theproject
+}
+
+func fetchGatewaySettings(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, spinner bool) *gateway.Settings {
+	var settings *gateway.Settings
+	var err error
+	action := func() {
+		settings, err = gateway.GetSettings(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+	}
+	if spinner {
+		tui.ShowSpinner("Fetching gateway settings ...", action)
+	} else {
+		action()
+	}
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the gateway settings")).ShowErrorAndExit()
+	}
+	return settings
+}
+
+func fetchGatewayModels(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, provider string, spinner bool) []gateway.Model {
+	var models []gateway.Model
+	var err error
+	action := func() {
+		models, err = gateway.ListModels(ctx, logger, theproject.APIURL, theproject.Token, provider)
+	}
+	if spinner {
+		tui.ShowSpinner("Fetching gateway models ...", action)
+	} else {
+		action()
+	}
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the gateway models")).ShowErrorAndExit()
+	}
+	return models
+}
+
+// formatPrice returns the price per million tokens or a dash when unknown
+func formatPrice(price float64) string {
+	if price <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", price)
+}
+
+// formatLimit returns the description of the limit of the usage such as "80% of 1000 requests/day"
+func formatLimit(usage gateway.Usage) string {
+	if usage.Limit == nil {
+		return "-"
+	}
+	var parts []string
+	if usage.Limit.Requests > 0 {
+		parts = append(parts, fmt.Sprintf("%d requests", usage.Limit.Requests))
+	}
+	if usage.Limit.Tokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens", usage.Limit.Tokens))
+	}
+	if usage.Limit.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", usage.Limit.Cost))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	limit := strings.Join(parts, ", ")
+	if usage.Limit.Period != "" {
+		limit += "/" + usage.Limit.Period
+	}
+	return fmt.Sprintf("%.0f%% of %s", usage.Percent(), limit)
+}
+
+var gatewayModelsCmd = &cobra.Command{
+	Use:     "models",
+	Aliases: []string{"model", "ls"},
+	Args:    cobra.NoArgs,
+	Short:   "List the models of the AI gateway",
+	Long: `List the models available through the AI gateway.
+
+The prices are in USD per million tokens and the default model of each provider for
+the project is marked.
+
+Flags:
+  --provider   Only list the models of the provider
+  --format     The format of the output, text or json
+
+Examples:
+  agentuity gateway models
+  agentuity gateway models --provider anthropic
+  agentuity gateway models --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		provider, _ := cmd.Flags().GetString("provider")
+		format, _ := cmd.Flags().GetString("format")
+
+		models := fetchGatewayModels(ctx, logger, theproject, provider, format != "json")
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(models)
+			return
+		}
+		if len(models) == 0 {
+			tui.ShowWarning("No models found")
+			return
+		}
+		settings := fetchGatewaySettings(ctx, logger, theproject, false)
+		rows := make([][]string, 0, len(models))
+		for _, m := range models {
+			var isDefault string
+			if settings.Defaults[m.Provider] == m.Name {
+				isDefault = "✓"
+			}
+			contextWindow := "-"
+			if m.ContextWindow > 0 {
+				contextWindow = strconv.Itoa(m.ContextWindow)
+			}
+			rows = append(rows, []string{m.ID, contextWindow, formatPrice(m.InputPrice), formatPrice(m.OutputPrice), isDefault})
+		}
+		tui.Table([]string{"Model", "Context", "Input", "Output", "Default"}, rows)
+	},
+}
+
+var gatewayDefaultCmd = &cobra.Command{
+	Use:   "default [model...]",
+	Args:  cobra.ArbitraryArgs,
+	Short: "Show or set the default models of the project",
+	Long: `Show or set the default model of each provider for the project.
+
+The AI gateway uses the default model of the provider for the requests of the agents
+which don't name a model. Each model is given as <provider>/<model> and replaces the
+default model of its provider. Without arguments the default models are shown.
+
+Arguments:
+  [model...]   The models to use by default such as openai/gpt-4o-mini
+
+Flags:
+  --unset      Remove the default model of the provider (can be repeated)
+  --format     The format of the output, text or json
+
+Examples:
+  agentuity gateway default
+  agentuity gateway default openai/gpt-4o-mini anthropic/claude-sonnet-4
+  agentuity gateway default --unset anthropic`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		unset, _ := cmd.Flags().GetStringSlice("unset")
+		format, _ := cmd.Flags().GetString("format")
+
+		settings := fetchGatewaySettings(ctx, logger, theproject, format != "json")
+		if len(args) > 0 || len(unset) > 0 {
+			updated := *settings
+			updated.Defaults = make(map[string]string)
+			for provider, model := range settings.Defaults {
+				updated.Defaults[provider] = model
+			}
+			if len(args) > 0 {
+				available := fetchGatewayModels(ctx, logger, theproject, "", format != "json")
+				for _, model := range args {
+					provider, name, err := gateway.ParseModel(model)
+					if err != nil {
+						errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+					}
+					if !slices.ContainsFunc(available, func(m gateway.Model) bool { return m.Provider == provider && m.Name == name }) {
+						errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("unknown model %s", model),
+							errsystem.WithUserMessage("The model %s isn't available through the AI gateway. Use %s to list the models.", model, tui.Command("gateway models"))).ShowErrorAndExit()
+					}
+					updated.Defaults[provider] = name
+				}
+			}
+			for _, provider := range unset {
+				delete(updated.Defaults, provider)
+			}
+			var err error
+			action := func() {
+				settings, err = gateway.SetSettings(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, updated)
+			}
+			if format == "json" {
+				action()
+			} else {
+				tui.ShowSpinner("Saving gateway settings ...", action)
+			}
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to save the gateway settings")).ShowErrorAndExit()
+			}
+			if format != "json" {
+				tui.ShowSuccess("Default models updated")
+			}
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(settings.Defaults)
+			return
+		}
+		if len(settings.Defaults) == 0 {
+			fmt.Println(tui.Muted("No default models, the requests must name a model"))
+			return
+		}
+		providers := make([]string, 0, len(settings.Defaults))
+		for provider := range settings.Defaults {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		for _, provider := range providers {
+			fmt.Printf("%s %s\n", tui.PadRight(provider+":", 20, " "), settings.Defaults[provider])
+		}
+	},
+}
+
+var gatewayUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Args:  cobra.NoArgs,
+	Short: "Show the usage and limits of each model",
+	Long: `Show the requests, tokens and cost of each model used by the project through the
+AI gateway, the most expensive first, and how much of the limit of each model is used
+in its current period.
+
+Flags:
+  --since    Show the usage since a time ago such as 24h or 30d (default 30d)
+  --format   The format of the output, text or json
+
+Examples:
+  agentuity gateway usage
+  agentuity gateway usage --since 7d
+  agentuity gateway usage --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		format, _ := cmd.Flags().GetString("format")
+		since, _ := cmd.Flags().GetString("since")
+		sinceDuration, err := parseFlexibleDuration(since)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid --since %s, use a duration such as 24h or 30d", since)).ShowErrorAndExit()
+		}
+
+		var usage []gateway.Usage
+		action := func() {
+			usage, err = gateway.GetUsage(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, time.Now().Add(-sinceDuration).Format(time.RFC3339))
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Fetching gateway usage ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the gateway usage")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(usage)
+			return
+		}
+		if len(usage) == 0 {
+			tui.ShowWarning("No gateway usage in the last %s", since)
+			return
+		}
+		var total float64
+		rows := make([][]string, 0, len(usage))
+		for _, u := range usage {
+			total += u.Cost
+			rows = append(rows, []string{u.Model, strconv.FormatInt(u.Requests, 10), strconv.FormatInt(u.InputTokens, 10), strconv.FormatInt(u.OutputTokens, 10), fmt.Sprintf("$%.2f", u.Cost), formatLimit(u)})
+		}
+		tui.Table([]string{"Model", "Requests", "Input Tokens", "Output Tokens", "Cost", "Limit"}, rows)
+		fmt.Printf("Total cost in the last %s: $%.2f\n", since, total)
+		for _, u := range usage {
+			if u.Percent() >= 80 {
+				tui.ShowWarning("%s has used %.0f%% of its limit", u.Model, u.Percent())
+			}
+		}
+	},
+}
+
+var gatewayKeysCmd = &cobra.Command{
+	Use:     "keys",
+	Aliases: []string{"key"},
+	Args:    cobra.NoArgs,
+	Short:   "List the gateway keys of the project",
+	Long: `List the gateway keys of the project.
+
+A gateway key can only call the AI gateway for the project, optionally only some of
+its models, so that it can be given to a script or a service which only needs the
+models. Use the create and delete subcommands to manage them.
+
+Examples:
+  agentuity gateway keys
+  agentuity gateway keys create ci --model openai/gpt-4o-mini --expires-in 30d
+  agentuity gateway keys delete <id>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		format, _ := cmd.Flags().GetString("format")
+
+		var keys []gateway.Key
+		var err error
+		action := func() {
+			keys, err = gateway.ListKeys(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Fetching gateway keys ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the gateway keys")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(keys)
+			return
+		}
+		if len(keys) == 0 {
+			tui.ShowWarning("No gateway keys found")
+			return
+		}
+		rows := make([][]string, 0, len(keys))
+		for _, k := range keys {
+			models := "all"
+			if len(k.Models) > 0 {
+				models = strings.Join(k.Models, ", ")
+			}
+			expires := k.ExpiresAt
+			if expires == "" {
+				expires = "never"
+			}
+			lastUsed := k.LastUsedAt
+			if lastUsed == "" {
+				lastUsed = "never"
+			}
+			rows = append(rows, []string{k.ID, k.Name, models, expires, lastUsed})
+		}
+		tui.Table([]string{"ID", "Name", "Models", "Expires At", "Last Used"}, rows)
+	},
+}
+
+var gatewayKeysCreateCmd = &cobra.Command{
+	Use:     "create [name]",
+	Aliases: []string{"new"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Create a gateway key",
+	Long: `Create a gateway key which can only call the AI gateway for the project.
+
+The key is only shown once so store it right away.
+
+Arguments:
+  [name]   The name of the key
+
+Flags:
+  --model        Only allow the model such as openai/gpt-4o-mini (can be repeated)
+  --expires-in   Expire the key after the duration such as 24h or 30d (default never)
+  --format       The format of the output, text or json
+
+Examples:
+  agentuity gateway keys create ci
+  agentuity gateway keys create batch --model openai/gpt-4o-mini --model anthropic/claude-haiku-3-5
+  agentuity gateway keys create preview --expires-in 7d --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		format, _ := cmd.Flags().GetString("format")
+		models, _ := cmd.Flags().GetStringSlice("model")
+		for _, model := range models {
+			if _, _, err := gateway.ParseModel(model); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		}
+		var expiresAt string
+		if expiresIn, _ := cmd.Flags().GetString("expires-in"); expiresIn != "" {
+			d, err := parseFlexibleDuration(expiresIn)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid --expires-in %s, use a duration such as 24h or 30d", expiresIn)).ShowErrorAndExit()
+			}
+			expiresAt = time.Now().Add(d).UTC().Format(time.RFC3339)
+		}
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" {
+			if !tui.HasTTY {
+				errsystem.New(errsystem.ErrMissingRequiredArgument, errors.New("missing name"), errsystem.WithUserMessage("The name of the key is required")).ShowErrorAndExit()
+			}
+			name = tui.InputWithValidation(logger, "Gateway Key Name", "The name to describe the gateway key", 100, func(name string) error {
+				if name == "" {
+					return errors.New("name is required")
+				}
+				return nil
+			})
+		}
+
+		var key *gateway.Key
+		var err error
+		action := func() {
+			key, err = gateway.CreateKey(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, name, models, expiresAt)
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Creating gateway key ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to create the gateway key")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(key)
+			return
+		}
+		tui.ShowSuccess("Gateway key %s created: %s", key.Name, key.Value)
+		fmt.Println(tui.Muted("The key won't be shown again, store it now."))
+	},
+}
+
+var gatewayKeysDeleteCmd = &cobra.Command{
+	Use:     "delete [id]",
+	Aliases: []string{"del", "rm"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Delete a gateway key",
+	Long: `Delete a gateway key, which can't call the AI gateway anymore.
+
+Arguments:
+  [id]   The id of the key, selected from a list when not given
+
+Examples:
+  agentuity gateway keys delete <id>
+  agentuity gateway keys delete <id> --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel, logger, theproject := gatewayContext(cmd)
+		defer cancel()
+		var id string
+		if len(args) > 0 {
+			id = args[0]
+		}
+		if id == "" {
+			if !tui.HasTTY {
+				errsystem.New(errsystem.ErrMissingRequiredArgument, errors.New("missing id"), errsystem.WithUserMessage("The id of the key is required")).ShowErrorAndExit()
+			}
+			keys, err := gateway.ListKeys(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the gateway keys")).ShowErrorAndExit()
+			}
+			if len(keys) == 0 {
+				tui.ShowWarning("No gateway keys found")
+				return
+			}
+			items := make([]tui.Option, len(keys))
+			for i, k := range keys {
+				items[i] = tui.Option{Text: k.Name, ID: k.ID}
+			}
+			id = tui.Select(logger, "Select a gateway key", "Select a gateway key to delete", items)
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force && tui.HasTTY {
+			if !tui.Ask(logger, "Are you sure you want to delete the gateway key?", true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		var err error
+		tui.ShowSpinner("Deleting gateway key ...", func() {
+			err = gateway.DeleteKey(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, id)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to delete the gateway key")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Gateway key deleted")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gatewayCmd)
+	gatewayCmd.AddCommand(gatewayModelsCmd)
+	gatewayCmd.AddCommand(gatewayDefaultCmd)
+	gatewayCmd.AddCommand(gatewayUsageCmd)
+	gatewayCmd.AddCommand(gatewayKeysCmd)
+	gatewayKeysCmd.AddCommand(gatewayKeysCreateCmd)
+	gatewayKeysCmd.AddCommand(gatewayKeysDeleteCmd)
+
+	for _, cmd := range []*cobra.Command{gatewayModelsCmd, gatewayDefaultCmd, gatewayUsageCmd, gatewayKeysCmd, gatewayKeysCreateCmd, gatewayKeysDeleteCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+	}
+	for _, cmd := range []*cobra.Command{gatewayModelsCmd, gatewayDefaultCmd, gatewayUsageCmd, gatewayKeysCmd, gatewayKeysCreateCmd} {
+		cmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	}
+	gatewayModelsCmd.Flags().String("provider", "", "Only list the models of the provider")
+	gatewayDefaultCmd.Flags().StringSlice("unset", nil, "Remove the default model of the provider (can be repeated)")
+	gatewayUsageCmd.Flags().String("since", "30d", "Show the usage since a time ago such as 24h or 30d")
+	gatewayKeysCreateCmd.Flags().StringSlice("model", nil, "Only allow the model such as openai/gpt-4o-mini (can be repeated)")
+	gatewayKeysCreateCmd.Flags().String("expires-in", "", "Expire the key after the duration such as 24h or 30d")
+	gatewayKeysDeleteCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+}
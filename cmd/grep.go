@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/search"
+	"github.com/agentuity/cli/internal/util"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var grepCmd = &cobra.Command{
+	Use:     "grep [pattern]",
+	Aliases: []string{"search"},
+	Args:    cobra.ExactArgs(1),
+	Short:   "Search the source code of your agents",
+	Long: `Search the source code of your agents.
+
+Only the agent source directories are searched and files matching the
+.gitignore and project ignore rules are skipped. Results are grouped by agent.
+The pattern is a regular expression unless --fixed-strings is used.
+
+Arguments:
+  [pattern]    The pattern to search for
+
+Flags:
+  --agent            Limit the search to the agent (can be specified multiple times)
+  --ignore-case      Match the pattern case insensitively
+  --fixed-strings    Treat the pattern as a literal string
+  --max-count        Stop after the number of matches
+  --format           The output format (text or json)
+
+Examples:
+  agentuity grep "fetch\("
+  agentuity grep -i openai --agent my-agent
+  agentuity grep -F "process.env." --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		theproject := project.NewProject()
+		if err := theproject.Load(dir); err != nil && err != cproject.ErrProjectMissingProjectId {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load project")).ShowErrorAndExit()
+		}
+		if theproject.Bundler == nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("missing bundler configuration"), errsystem.WithUserMessage("The project is missing the bundler configuration")).ShowErrorAndExit()
+		}
+
+		pattern := args[0]
+		if fixed, _ := cmd.Flags().GetBool("fixed-strings"); fixed {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if ignoreCase, _ := cmd.Flags().GetBool("ignore-case"); ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid search pattern: %s", args[0])).ShowErrorAndExit()
+		}
+
+		isPython := theproject.IsPython()
+		agentNames := make(map[string]string)
+		for _, agent := range theproject.Agents {
+			agentNames[normalAgentName(agent.Name, isPython)] = agent.Name
+		}
+		var agents []string
+		agentFlags, _ := cmd.Flags().GetStringSlice("agent")
+		for _, name := range agentFlags {
+			normalized := normalAgentName(name, isPython)
+			if _, ok := agentNames[normalized]; !ok {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", name), errsystem.WithUserMessage("Agent %s was not found in this project", name)).ShowErrorAndExit()
+			}
+			agents = append(agents, normalized)
+		}
+
+		maxCount, _ := cmd.Flags().GetInt("max-count")
+		rules := createProjectIgnoreRules(dir, theproject, false)
+		matches, err := search.Search(ctx, dir, theproject.Bundler.AgentConfig.Dir, rules, search.Options{
+			Pattern:    re,
+			AgentNames: agentNames,
+			Agents:     agents,
+			MaxMatches: maxCount,
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to search agent source")).ShowErrorAndExit()
+		}
+
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			if matches == nil {
+				matches = []search.Match{}
+			}
+			json.NewEncoder(os.Stdout).Encode(matches)
+			return
+		}
+		if len(matches) == 0 {
+			tui.ShowWarning("No matches found")
+			os.Exit(1)
+		}
+		var lastAgent string
+		for _, m := range matches {
+			if m.Agent != lastAgent {
+				if lastAgent != "" {
+					fmt.Println()
+				}
+				fmt.Println(tui.Title(m.Agent))
+				lastAgent = m.Agent
+			}
+			text := strings.TrimSpace(m.Text)
+			if m.Truncated {
+				text += tui.Muted(" … (line truncated)")
+			}
+			fmt.Printf("%s %s\n", tui.Muted(fmt.Sprintf("%s:%d:", m.File, m.Line)), text)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+	grepCmd.Flags().StringP("dir", "d", ".", "The directory to the project")
+	grepCmd.Flags().StringSlice("agent", nil, "Limit the search to the agent (can be specified multiple times)")
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "Match the pattern case insensitively")
+	grepCmd.Flags().BoolP("fixed-strings", "F", false, "Treat the pattern as a literal string")
+	grepCmd.Flags().Int("max-count", 0, "Show at most the number of matches (0 for no limit)")
+	grepCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/ide"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var ideCmd = &cobra.Command{
+	Use:   "ide",
+	Short: "Configure editors for a project",
+	Long: `Configure editors for a project.
+
+Examples:
+  agentuity ide setup
+  agentuity ide setup --editor cursor`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var ideSetupCmd = &cobra.Command{
+	Use:   "setup",
+	Args:  cobra.NoArgs,
+	Short: "Write the editor configuration of the project",
+	Long: `Write the editor configuration of the project so that everyone working on it
+has the same setup:
+
+  .vscode/tasks.json       tasks to run agentuity dev, agentuity dev --inspect and deploy
+  .vscode/launch.json      a configuration attaching the debugger to the agents run by
+                           agentuity dev --inspect (node, bun or debugpy)
+  .vscode/extensions.json  the recommended extensions for the runtime of the project
+  .vscode/mcp.json         the registration of the Agentuity MCP server, which is
+                           .cursor/mcp.json with --editor cursor
+
+The settings of existing files are kept. The Agentuity tasks, launch configuration
+and MCP server are added or replaced by name, and the recommended extensions are
+added. Comments in the changed files are removed. Files which are up to date are
+left as is.
+
+Flags:
+  --editor    The editor to configure: vscode or cursor (default vscode)
+  --dry-run   Show the files without writing them
+
+Examples:
+  agentuity ide setup
+  agentuity ide setup --editor cursor
+  agentuity ide setup --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		theproject := project.NewProject()
+		if err := theproject.Load(dir); err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithContextMessage("Error loading project from disk")).ShowErrorAndExit()
+		}
+		name, _ := cmd.Flags().GetString("editor")
+		editor, err := ide.ParseEditor(name)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		files, err := ide.Setup(dir, theproject, editor)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to configure the editor: %s", err)).ShowErrorAndExit()
+		}
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			for _, f := range files {
+				fmt.Println(tui.Bold(f.Path))
+				fmt.Println(string(f.Data))
+			}
+			return
+		}
+		if err := ide.Write(dir, files); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithUserMessage("Failed to write the editor configuration: %s", err)).ShowErrorAndExit()
+		}
+		for _, f := range files {
+			if f.Changed {
+				tui.ShowSuccess("Wrote %s", f.Path)
+			} else {
+				tui.ShowSuccess("%s is up to date", f.Path)
+			}
+		}
+		fmt.Println()
+		fmt.Println(tui.Muted("Run the agentuity: dev (debug) task and then the Agentuity: attach to agents configuration to debug the agents."))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ideCmd)
+	ideCmd.AddCommand(ideSetupCmd)
+	ideSetupCmd.Flags().StringP("dir", "d", "", "The project directory")
+	ideSetupCmd.Flags().String("editor", string(ide.VSCode), "The editor to configure: vscode or cursor")
+	ideSetupCmd.Flags().Bool("dry-run", false, "Show the files without writing them")
+}
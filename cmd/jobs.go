@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Track the asynchronous jobs of agents",
+	Long: `Track the asynchronous jobs of agents queued with agent enqueue.
+
+Use the subcommands to check the status of a job, wait for its result or cancel it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show the status of a job",
+	Args:  cobra.ExactArgs(1),
+	Long: `Show the status of a job of an agent.
+
+Arguments:
+  <id>    The ID of the job
+
+Flags:
+  --format    The output format (text or json)
+
+Examples:
+  agentuity jobs status job_123
+  agentuity jobs status job_123 --format json | jq -r .status`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		format, _ := cmd.Flags().GetString("format")
+
+		var job *agent.Job
+		tui.ShowSpinner(fmt.Sprintf("Fetching job %s ...", args[0]), func() {
+			var err error
+			job, err = agent.GetJob(ctx, logger, urls.API, apikey, args[0])
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the job")).ShowErrorAndExit()
+			}
+		})
+		if format == "json" {
+			outputJSON(job)
+			return
+		}
+		showJob(job)
+	},
+}
+
+var jobsWaitCmd = &cobra.Command{
+	Use:   "wait <id>",
+	Short: "Wait for a job to complete and write its result",
+	Args:  cobra.ExactArgs(1),
+	Long: `Wait for a job of an agent to complete and write its result.
+
+The result is written like the response of agent test: it's shown in the terminal
+unless it's binary, written as is when stdout isn't a terminal and written to a
+file with --output.
+
+The command exits with 7 when the job failed, and with 1 when it was cancelled or
+the timeout expired before the job completed, so that CI can branch on the result.
+
+Arguments:
+  <id>    The ID of the job
+
+Flags:
+  --timeout        How long to wait for the job (default 30m)
+  --interval       How often to check the status of the job
+  --output         Write the result to the file, or - for stdout
+  --output-binary  Write a binary result to stdout even when it's a terminal
+
+Examples:
+  agentuity jobs wait job_123
+  agentuity jobs wait job_123 --timeout 2h --output report.pdf`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		waitForAgentJob(ctx, logger, cmd, urls.API, apikey, args[0])
+	},
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a job",
+	Args:  cobra.ExactArgs(1),
+	Long: `Cancel a job of an agent which hasn't completed yet.
+
+Arguments:
+  <id>    The ID of the job
+
+Examples:
+  agentuity jobs cancel job_123`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+
+		var job *agent.Job
+		tui.ShowSpinner(fmt.Sprintf("Cancelling job %s ...", args[0]), func() {
+			var err error
+			job, err = agent.CancelJob(ctx, logger, urls.API, apikey, args[0])
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to cancel the job")).ShowErrorAndExit()
+			}
+		})
+		if job.Status != agent.JobCancelled {
+			tui.ShowWarning("Job %s was already %s", job.ID, job.Status)
+			return
+		}
+		tui.ShowSuccess("Job %s cancelled", job.ID)
+	},
+}
+
+// showJob shows the status of the job
+func showJob(job *agent.Job) {
+	fmt.Printf("Job ID: %s\n", tui.Bold(job.ID))
+	fmt.Printf("Agent ID: %s\n", job.AgentID)
+	fmt.Printf("Status: %s\n", job.Status)
+	fmt.Printf("Created: %s\n", job.CreatedAt.Local().Format(time.RFC1123))
+	if job.StartedAt != nil {
+		fmt.Printf("Duration: %s\n", job.Duration().Round(time.Second))
+	}
+	if job.Error != "" {
+		fmt.Printf("Error: %s\n", tui.Warning(job.Error))
+	}
+}
+
+// addJobWaitFlags adds the flags of waiting for a job to the command
+func addJobWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("timeout", 30*time.Minute, "How long to wait for the job")
+	cmd.Flags().Duration("interval", 2*time.Second, "How often to check the status of the job")
+	cmd.Flags().StringP("output", "o", "", "Write the result to the file, or - for stdout")
+	cmd.Flags().Bool("output-binary", false, "Write a binary result to stdout even when it's a terminal")
+}
+
+// waitForAgentJob waits for the job with the flags of addJobWaitFlags and writes its result, exiting
+// when the job didn't complete
+func waitForAgentJob(ctx context.Context, logger logger.Logger, cmd *cobra.Command, apiURL string, token string, jobID string) {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	output, _ := cmd.Flags().GetString("output")
+	outputBinary, _ := cmd.Flags().GetBool("output-binary")
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var job *agent.Job
+	var err error
+	action := func() {
+		job, err = agent.WaitForJob(waitCtx, interval, func(ctx context.Context) (*agent.Job, error) {
+			return agent.GetJob(ctx, logger, apiURL, token, jobID)
+		}, func(job *agent.Job) {
+			logger.Debug("job %s is %s", job.ID, job.Status)
+		})
+	}
+	if tui.HasTTY {
+		tui.ShowSpinner(fmt.Sprintf("Waiting for job %s ...", jobID), action)
+	} else {
+		action()
+	}
+	if isCancelled(ctx) {
+		errsystem.ShowCancelledAndExit()
+	}
+	if errors.Is(err, context.DeadlineExceeded) && job != nil {
+		tui.ShowError("Job %s is still %s after %s", jobID, job.Status, timeout)
+		os.Exit(util.ExitCodeGeneral)
+	}
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the job")).ShowErrorAndExit()
+	}
+
+	switch job.Status {
+	case agent.JobFailed:
+		tui.ShowError("Job %s failed: %s", job.ID, job.Error)
+		os.Exit(util.ExitCodeAgentServerError)
+	case agent.JobCancelled:
+		tui.ShowError("Job %s was cancelled", job.ID)
+		os.Exit(util.ExitCodeGeneral)
+	}
+	body, err := job.Response()
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to read the result of the job")).ShowErrorAndExit()
+	}
+	writeAgentResponse("Job "+job.ID, job.ContentType, body, output, outputBinary)
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsWaitCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+
+	jobsStatusCmd.Flags().String("format", "text", "The output format (text or json)")
+	addJobWaitFlags(jobsWaitCmd)
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/keys"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:     "keys",
+	Aliases: []string{"key"},
+	Args:    cobra.NoArgs,
+	Short:   "Manage deployment encryption keys",
+	Long: `Manage the encryption keys used to encrypt your deployments.
+
+When your organization has a public key configured, deployments are encrypted
+with it before they are uploaded. The private key never leaves your machine.
+
+Use the subcommands to generate, upload, rotate and list keys.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// defaultKeysDir returns the directory where generated keys are stored by default
+func defaultKeysDir() string {
+	return filepath.Join(filepath.Dir(cfgFile), "keys")
+}
+
+// writeKeyPair writes the key pair to the output directory and returns the private and public key filenames
+func writeKeyPair(logger logger.Logger, cmd *cobra.Command, orgId string, kp *keys.KeyPair) (string, string) {
+	dir, _ := cmd.Flags().GetString("output")
+	if dir == "" {
+		dir = defaultKeysDir()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		errsystem.New(errsystem.ErrCreateDirectory, err,
+			errsystem.WithContextMessage("Failed to create the keys directory")).ShowErrorAndExit()
+	}
+	basename := filepath.Join(dir, fmt.Sprintf("%s-%s", orgId, time.Now().UTC().Format("20060102150405")))
+	privateKeyFile := basename + ".key"
+	publicKeyFile := basename + ".pub"
+	if err := os.WriteFile(privateKeyFile, kp.PrivateKey, 0600); err != nil {
+		errsystem.New(errsystem.ErrOpenFile, err,
+			errsystem.WithContextMessage("Failed to write the private key")).ShowErrorAndExit()
+	}
+	if err := os.WriteFile(publicKeyFile, kp.PublicKey, 0644); err != nil {
+		errsystem.New(errsystem.ErrOpenFile, err,
+			errsystem.WithContextMessage("Failed to write the public key")).ShowErrorAndExit()
+	}
+	logger.Debug("wrote key pair %s to %s", kp.Fingerprint, basename)
+	return privateKeyFile, publicKeyFile
+}
+
+func uploadPublicKey(ctx context.Context, logger logger.Logger, apiUrl string, token string, orgId string, publicKey []byte, activate bool) *keys.OrgKey {
+	var key *keys.OrgKey
+	action := func() {
+		var err error
+		key, err = keys.Upload(ctx, logger, apiUrl, token, orgId, publicKey, activate)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err,
+				errsystem.WithContextMessage("Failed to upload the public key")).ShowErrorAndExit()
+		}
+	}
+	tui.ShowSpinner("uploading public key ...", action)
+	return key
+}
+
+func renderOrgKey(key keys.OrgKey) {
+	status := tui.Muted("inactive")
+	if key.Active {
+		status = tui.Bold("active")
+	}
+	fmt.Println(tui.Title(key.Fingerprint) + " " + tui.Muted("("+key.ID+")") + " " + status)
+	fmt.Println(tui.Text("Created at " + key.CreatedAt))
+	if key.RevokedAt != "" {
+		fmt.Println(tui.Text("Revoked at " + key.RevokedAt))
+	}
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:     "generate",
+	Aliases: []string{"gen", "new"},
+	Args:    cobra.NoArgs,
+	Short:   "Generate a new encryption key pair",
+	Long: `Generate a new encryption key pair locally.
+
+The private key is written with restricted permissions and is never uploaded.
+Use --upload to upload the public key to your organization once generated.
+
+Flags:
+  --org-id   The organization to generate the key for
+  --output   The directory to write the key pair (defaults to ~/.config/agentuity/keys)
+  --upload   Upload the public key after generating it
+
+Examples:
+  agentuity keys generate
+  agentuity keys generate --output ./keys --upload`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		kp, err := keys.GenerateKeyPair()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithContextMessage("Failed to generate key pair")).ShowErrorAndExit()
+		}
+		privateKeyFile, publicKeyFile := writeKeyPair(logger, cmd, orgId, kp)
+		if upload, _ := cmd.Flags().GetBool("upload"); upload {
+			uploadPublicKey(ctx, logger, apiUrl, apikey, orgId, kp.PublicKey, false)
+			tui.ShowSuccess("Public key %s uploaded", kp.Fingerprint)
+		} else {
+			tui.ShowSuccess("Key pair %s generated", kp.Fingerprint)
+		}
+		fmt.Println()
+		fmt.Println(tui.Bold(tui.PadRight("Private:", 10, " ")) + tui.Muted(privateKeyFile))
+		fmt.Println(tui.Bold(tui.PadRight("Public:", 10, " ")) + tui.Muted(publicKeyFile))
+		fmt.Println()
+		tui.ShowWarning("Keep the private key safe. Deployments encrypted with this key cannot be recovered without it.")
+	},
+}
+
+var keysUploadCmd = &cobra.Command{
+	Use:   "upload [public_key_file]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Upload a public key",
+	Long: `Upload a PEM encoded public key to your organization.
+
+Arguments:
+  [public_key_file]   The PEM encoded ECDSA P-256 public key to upload
+
+Flags:
+  --org-id     The organization to upload the key to
+  --activate   Use the key to encrypt new deployments
+
+Examples:
+  agentuity keys upload ./org.pub
+  agentuity keys upload ./org.pub --activate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		buf, err := os.ReadFile(args[0])
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err,
+				errsystem.WithContextMessage("Failed to read the public key")).ShowErrorAndExit()
+		}
+		_, fingerprint, err := keys.ParsePublicKey(buf)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+				errsystem.WithUserMessage("The file %s is not a valid ECDSA public key", args[0])).ShowErrorAndExit()
+		}
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		activate, _ := cmd.Flags().GetBool("activate")
+		uploadPublicKey(ctx, logger, apiUrl, apikey, orgId, buf, activate)
+		if activate {
+			tui.ShowSuccess("Public key %s uploaded and activated", fingerprint)
+		} else {
+			tui.ShowSuccess("Public key %s uploaded", fingerprint)
+		}
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Args:  cobra.NoArgs,
+	Short: "Rotate the organization encryption key",
+	Long: `Rotate the organization encryption key.
+
+This generates a new key pair locally, uploads the public key and makes it the
+active key for new deployments. Existing deployments stay encrypted with the
+previous key, so keep the previous private key until they are no longer needed.
+
+Flags:
+  --org-id   The organization to rotate the key for
+  --output   The directory to write the key pair (defaults to ~/.config/agentuity/keys)
+  --force    Don't prompt for confirmation
+
+Examples:
+  agentuity keys rotate
+  agentuity keys rotate --output ./keys --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && tui.HasTTY {
+			if !tui.Ask(logger, "Rotate the encryption key? New deployments will use the new key.", true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		kp, err := keys.GenerateKeyPair()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithContextMessage("Failed to generate key pair")).ShowErrorAndExit()
+		}
+		privateKeyFile, publicKeyFile := writeKeyPair(logger, cmd, orgId, kp)
+		uploadPublicKey(ctx, logger, apiUrl, apikey, orgId, kp.PublicKey, true)
+		tui.ShowSuccess("Encryption key rotated to %s", kp.Fingerprint)
+		fmt.Println()
+		fmt.Println(tui.Bold(tui.PadRight("Private:", 10, " ")) + tui.Muted(privateKeyFile))
+		fmt.Println(tui.Bold(tui.PadRight("Public:", 10, " ")) + tui.Muted(publicKeyFile))
+		fmt.Println()
+		tui.ShowWarning("Keep the previous private key to decrypt deployments made before this rotation.")
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	Short:   "List encryption keys",
+	Long: `List the encryption keys for your organization.
+
+Flags:
+  --org-id   The organization to list keys for
+  --format   The output format (text or json)
+
+Examples:
+  agentuity keys list
+  agentuity keys ls --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		format, _ := cmd.Flags().GetString("format")
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+		var orgKeys []keys.OrgKey
+		action := func() {
+			var err error
+			orgKeys, err = keys.List(ctx, logger, apiUrl, apikey, orgId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err,
+					errsystem.WithContextMessage("Failed to list encryption keys")).ShowErrorAndExit()
+			}
+		}
+		tui.ShowSpinner("fetching encryption keys ...", action)
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(orgKeys)
+			return
+		}
+		if len(orgKeys) == 0 {
+			tui.ShowWarning("No encryption keys found. Deployments are encrypted with the organization secret.")
+			return
+		}
+		for i, key := range orgKeys {
+			renderOrgKey(key)
+			if i < len(orgKeys)-1 {
+				fmt.Println()
+			}
+		}
+	},
+}
+
+var keysDeploymentCmd = &cobra.Command{
+	Use:   "deployment [deployment_id]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show the key used to encrypt a deployment",
+	Long: `Show which encryption key was used to encrypt a deployment.
+
+Arguments:
+  [deployment_id]   The deployment to inspect
+
+Flags:
+  --project   The project of the deployment
+  --format    The output format (text or json)
+
+Examples:
+  agentuity keys deployment <deploymentId>
+  agentuity keys deployment <deploymentId> --project <projectId>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		format, _ := cmd.Flags().GetString("format")
+		projectId, _ := cmd.Flags().GetString("project")
+		if projectId == "" {
+			projectId = cloudSelectProject(ctx, logger, apiUrl, apikey, "Select the project of the deployment")
+		}
+		if projectId == "" {
+			return
+		}
+		var key *keys.OrgKey
+		action := func() {
+			var err error
+			key, err = keys.GetDeploymentKey(ctx, logger, apiUrl, apikey, projectId, args[0])
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err,
+					errsystem.WithContextMessage("Failed to fetch the deployment encryption key")).ShowErrorAndExit()
+			}
+		}
+		tui.ShowSpinner("fetching deployment encryption key ...", action)
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(key)
+			return
+		}
+		if key == nil {
+			tui.ShowWarning("Deployment %s was encrypted with the organization secret", args[0])
+			return
+		}
+		renderOrgKey(*key)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysGenerateCmd)
+	keysCmd.AddCommand(keysUploadCmd)
+	keysCmd.AddCommand(keysRotateCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysDeploymentCmd)
+
+	for _, cmd := range []*cobra.Command{keysGenerateCmd, keysUploadCmd, keysRotateCmd, keysListCmd} {
+		cmd.Flags().String("org-id", "", "The organization to manage keys for")
+	}
+	for _, cmd := range []*cobra.Command{keysGenerateCmd, keysRotateCmd} {
+		cmd.Flags().String("output", "", "The directory to write the key pair")
+	}
+	for _, cmd := range []*cobra.Command{keysListCmd, keysDeploymentCmd} {
+		cmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+	}
+	keysGenerateCmd.Flags().Bool("upload", false, "Upload the public key after generating it")
+	keysUploadCmd.Flags().Bool("activate", false, "Use the key to encrypt new deployments")
+	keysRotateCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	keysDeploymentCmd.Flags().String("project", "", "The project of the deployment")
+}
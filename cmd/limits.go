@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/deployer"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/organization"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var limitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Show the quotas of the organization and their consumption",
+	Long: `Show the quotas of the plan of the organization, such as the deployments per day,
+the bundle size, the number of agents and the token budgets, with their current
+consumption.
+
+When run in a project directory, the quotas of the project are shown with the
+quotas of its organization. Quotas which are exhausted are highlighted since they
+fail the operations they limit, such as a deployment in CI.
+
+Flags:
+  --org-id    The organization to show the quotas of (defaults to the one of the project)
+  --dir       The project directory
+  --format    The output format (text or json)
+
+Examples:
+  agentuity limits
+  agentuity limits --org-id org_123
+  agentuity limits --format json | jq '.quotas[] | select(.used >= .limit and .limit > 0)'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		format, _ := cmd.Flags().GetString("format")
+		orgId, _ := cmd.Flags().GetString("org-id")
+
+		var projectId string
+		if p := project.TryProject(ctx, cmd); p.Project != nil && p.Project.ProjectId != "" {
+			projectId = p.Project.ProjectId
+			if orgId == "" {
+				tui.ShowSpinner("Fetching project ...", func() {
+					data, err := project.GetProject(ctx, logger, urls.API, apikey, projectId, true, false)
+					if err != nil {
+						errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the project")).ShowErrorAndExit()
+					}
+					orgId = data.OrgId
+				})
+			}
+		}
+		if orgId == "" {
+			orgId = promptForOrganization(ctx, logger, cmd, urls.API, apikey)
+		}
+
+		var limits *organization.Limits
+		tui.ShowSpinner("Fetching limits ...", func() {
+			var err error
+			limits, err = organization.GetLimits(ctx, logger, urls.API, apikey, orgId, projectId)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the limits")).ShowErrorAndExit()
+			}
+		})
+
+		if format == "json" {
+			outputJSON(limits)
+			return
+		}
+		showLimits(limits)
+	},
+}
+
+// showLimits shows the table of the quotas and warns about the exhausted ones
+func showLimits(limits *organization.Limits) {
+	fmt.Printf("Organization: %s %s\n", tui.Bold(limits.OrgName), tui.Muted(limits.OrgID))
+	if limits.Plan != "" {
+		fmt.Printf("Plan: %s\n", limits.Plan)
+	}
+	if limits.ProjectID != "" {
+		fmt.Printf("Project: %s\n", tui.Muted(limits.ProjectID))
+	}
+	fmt.Println()
+	if len(limits.Quotas) == 0 {
+		tui.ShowWarning("no quotas found")
+		return
+	}
+	rows := make([][]string, 0, len(limits.Quotas))
+	for _, q := range limits.Quotas {
+		limit, remaining, usage := "unlimited", "-", "-"
+		if !q.Unlimited() {
+			limit = formatQuotaValue(q.Unit, q.Limit)
+			remaining = formatQuotaValue(q.Unit, q.Remaining())
+			usage = fmt.Sprintf("%.0f%%", q.Percent())
+		}
+		switch {
+		case q.Exhausted():
+			usage = tui.Warning(usage)
+		case q.Percent() >= 80:
+			usage = tui.Title(usage)
+		}
+		resets := ""
+		if q.ResetsAt != nil {
+			resets = q.ResetsAt.Local().Format(time.RFC1123)
+		}
+		rows = append(rows, []string{q.Name, q.Scope, formatQuotaValue(q.Unit, q.Used), limit, remaining, usage, resets})
+	}
+	tui.Table([]string{"Quota", "Scope", "Used", "Limit", "Remaining", "Usage", "Resets"}, rows)
+
+	if exhausted := limits.Exhausted(); len(exhausted) > 0 {
+		names := make([]string, 0, len(exhausted))
+		for _, q := range exhausted {
+			names = append(names, q.Name)
+		}
+		fmt.Println()
+		tui.ShowWarning("Exhausted quotas: %s. The operations they limit fail until they reset or the plan is upgraded.", strings.Join(names, ", "))
+	}
+}
+
+// formatQuotaValue returns the value of a quota in its unit
+func formatQuotaValue(unit string, value int64) string {
+	switch unit {
+	case organization.UnitBytes:
+		return deployer.FormatSize(value)
+	case organization.UnitTokens:
+		return fmt.Sprintf("%d tokens", value)
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+func init() {
+	rootCmd.AddCommand(limitsCmd)
+	limitsCmd.Flags().String("org-id", "", "The organization to show the quotas of")
+	limitsCmd.Flags().StringP("dir", "d", "", "The project directory")
+	limitsCmd.Flags().String("format", "text", "The output format (text or json)")
+}
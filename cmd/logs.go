@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -13,8 +14,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
@@ -44,11 +45,13 @@ func parseFlexibleDuration(s string) (time.Duration, error) {
 }
 
 type Log struct {
-	ID        string    `json:"id"`
-	Body      string    `json:"body"`
-	Link      string    `json:"link"`
-	Severity  string    `json:"severity"`
-	Timestamp time.Time `json:"timestamp"`
+	ID           string    `json:"id"`
+	Body         string    `json:"body"`
+	Link         string    `json:"link"`
+	Severity     string    `json:"severity"`
+	Timestamp    time.Time `json:"timestamp"`
+	DeploymentID string    `json:"deploymentId,omitempty"`
+	AgentID      string    `json:"agentId,omitempty"`
 }
 
 type LogsResponse struct {
@@ -56,6 +59,71 @@ type LogsResponse struct {
 	Data    []Log `json:"data"`
 }
 
+type LogsSearchResult struct {
+	Logs       []Log  `json:"logs"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type LogsSearchResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    LogsSearchResult `json:"data"`
+}
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// logsSearchPageSize is the maximum number of logs requested per page
+const logsSearchPageSize = 100
+
+// searchLogs fetches the pages of logs matching the query until the limit is reached or there are no
+// more results. A limit of 0 fetches all the pages.
+func searchLogs(ctx context.Context, logger logger.Logger, cmd *cobra.Command, query url.Values, limit int) (*LogsSearchResult, error) {
+	urls := util.GetURLs(logger)
+	apiKey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+	client := util.NewAPIClient(ctx, logger, urls.API, apiKey)
+
+	result := &LogsSearchResult{Logs: []Log{}, NextCursor: query.Get("cursor")}
+	for {
+		pageSize := logsSearchPageSize
+		if limit > 0 {
+			pageSize = min(pageSize, limit-len(result.Logs))
+		}
+		query.Set("limit", strconv.Itoa(pageSize))
+		if result.NextCursor != "" {
+			query.Set("cursor", result.NextCursor)
+		}
+		var response LogsSearchResponse
+		if err := client.Do("GET", fmt.Sprintf("/cli/logs/search?%s", query.Encode()), nil, &response); err != nil {
+			return nil, err
+		}
+		if !response.Success {
+			return nil, fmt.Errorf("failed to search logs: %s", response.Message)
+		}
+		result.Logs = append(result.Logs, response.Data.Logs...)
+		result.NextCursor = response.Data.NextCursor
+		if result.NextCursor == "" || (limit > 0 && len(result.Logs) >= limit) || ctx.Err() != nil {
+			return result, nil
+		}
+	}
+}
+
+// highlightMatches highlights the case-insensitive matches of the search query in the log body
+func highlightMatches(body string, search string) string {
+	if search == "" {
+		return tui.Body(body)
+	}
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(search))
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(body, -1) {
+		sb.WriteString(tui.Body(body[last:m[0]]))
+		sb.WriteString(tui.Warning(body[m[0]:m[1]]))
+		last = m[1]
+	}
+	sb.WriteString(tui.Body(body[last:]))
+	return sb.String()
+}
+
 func printLogs(ctx context.Context, logger logger.Logger, cmd *cobra.Command, query url.Values, tail bool, hideDate bool, hideTime bool) {
 
 	urls := util.GetURLs(logger)
@@ -148,7 +216,7 @@ var logsCmd = &cobra.Command{
 	Short: "View logs for agents, deployments, and more.",
 	Run: func(cmd *cobra.Command, args []string) {
 
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 
 		query := url.Values{}
 
@@ -204,8 +272,148 @@ var logsCmd = &cobra.Command{
 	},
 }
 
+var logsSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search the logs for a specific message",
+	Long: `Search the logs for a specific message across deployments.
+
+The search is case-insensitive and filtered on the server so that it covers all the logs
+in the time range and not only the most recent ones. Results are fetched in pages until
+the limit is reached.
+
+Arguments:
+  [query]    The text to search for in the log messages
+
+Flags:
+  --project       Filter logs by project ID or name
+  --agent         Filter logs by agent ID or name
+  --deployment    Filter logs by deployment ID
+  --env           Filter logs by environment
+  --level         Filter logs by level (debug, info, warn or error)
+  --since         Search logs since a specific time (e.g., 1h, 30m, 1d)
+  --until         Search logs until a specific time (e.g., 10m for up to 10 minutes ago)
+  --limit         The maximum number of logs to return (0 for all)
+  --cursor        Continue a previous search from its next cursor
+  --format        The output format which can be either 'text' or 'json'
+
+Examples:
+  agentuity logs search "connection refused" --project my-project --since 1h --level error
+  agentuity logs search timeout --deployment deploy_123 --since 1d --limit 0
+  agentuity logs search "rate limit" --format json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		search := args[0]
+		if strings.TrimSpace(search) == "" {
+			logger.Fatal("the search query cannot be empty")
+		}
+
+		query := url.Values{}
+		query.Set("query", search)
+
+		for _, name := range []string{"agent", "deployment", "env", "project", "organization"} {
+			if val, _ := cmd.Flags().GetString(name); val != "" {
+				query.Set(name, val)
+			}
+		}
+
+		if level, _ := cmd.Flags().GetString("level"); level != "" {
+			level = strings.ToLower(level)
+			if !slices.Contains(logLevels, level) {
+				logger.Fatal("invalid level %q (must be one of %s)", level, strings.Join(logLevels, ", "))
+			}
+			query.Set("severity", level)
+		}
+
+		since, _ := cmd.Flags().GetString("since")
+		sinceDuration, err := parseFlexibleDuration(since)
+		if err != nil {
+			logger.Fatal("failed to parse since: %s", err)
+		}
+		query.Set("startDate", time.Now().Add(-1*sinceDuration).Format(time.RFC3339))
+
+		if until, _ := cmd.Flags().GetString("until"); until != "" {
+			untilDuration, err := parseFlexibleDuration(until)
+			if err != nil {
+				logger.Fatal("failed to parse until: %s", err)
+			}
+			if untilDuration >= sinceDuration {
+				logger.Fatal("until (%s) must be more recent than since (%s)", until, since)
+			}
+			query.Set("endDate", time.Now().Add(-1*untilDuration).Format(time.RFC3339))
+		}
+
+		if cursor, _ := cmd.Flags().GetString("cursor"); cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit < 0 {
+			logger.Fatal("the limit cannot be negative")
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		var result *LogsSearchResult
+		action := func() {
+			result, err = searchLogs(ctx, logger, cmd, query, limit)
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Searching logs ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to search logs")).ShowErrorAndExit()
+		}
+
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(result)
+			return
+		}
+
+		if len(result.Logs) == 0 {
+			tui.ShowWarning("No logs found matching %q since %s", search, since)
+			return
+		}
+		for _, log := range result.Logs {
+			deployment := ""
+			if log.DeploymentID != "" {
+				deployment = " " + tui.Muted(log.DeploymentID)
+			}
+			fmt.Printf("%s %s%s %s\n",
+				tui.Bold(fmt.Sprintf("%-7s", "["+log.Severity+"]")),
+				tui.Title(log.Timestamp.Format(time.DateTime)),
+				deployment,
+				highlightMatches(log.Body, search),
+			)
+		}
+		fmt.Println()
+		if result.NextCursor != "" {
+			tui.ShowSuccess("Found %d logs, more are available by repeating the search with %s", len(result.Logs), tui.Bold("--cursor "+result.NextCursor))
+		} else {
+			tui.ShowSuccess("Found %d logs", len(result.Logs))
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsSearchCmd)
+
+	logsSearchCmd.Flags().StringP("agent", "a", "", "Filter logs by agent ID or name")
+	logsSearchCmd.Flags().StringP("deployment", "d", "", "Filter logs by deployment ID")
+	logsSearchCmd.Flags().StringP("env", "e", "", "Filter logs by environment")
+	logsSearchCmd.Flags().StringP("project", "p", "", "Filter logs by project ID or name")
+	logsSearchCmd.Flags().StringP("organization", "o", "", "Filter logs by organization ID or name")
+	logsSearchCmd.Flags().StringP("level", "l", "", "Filter logs by level (debug, info, warn or error)")
+	logsSearchCmd.Flags().StringP("since", "s", "1h", "Search logs since a specific time (e.g., 1h, 30m, 1d)")
+	logsSearchCmd.Flags().String("until", "", "Search logs until a specific time (e.g., 10m for up to 10 minutes ago)")
+	logsSearchCmd.Flags().Int("limit", 100, "The maximum number of logs to return (0 for all)")
+	logsSearchCmd.Flags().String("cursor", "", "Continue a previous search from its next cursor")
+	logsSearchCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
 
 	logsCmd.Flags().StringP("agent", "a", "", "Filter logs by agent ID or name")
 	logsCmd.Flags().StringP("deployment", "d", "", "Filter logs by deployment ID")
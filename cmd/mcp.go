@@ -11,7 +11,7 @@ import (
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/mcp"
 	"github.com/agentuity/cli/internal/project"
-	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/tui"
 	mcp_golang "github.com/agentuity/mcp-golang/v2"
 	"github.com/agentuity/mcp-golang/v2/transport"
@@ -57,7 +57,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		if err := mcp.Install(ctx, logger); err != nil {
 			logger.Fatal("%s", err)
 		}
@@ -76,7 +76,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		if err := mcp.Uninstall(ctx, logger); err != nil {
 			logger.Fatal("%s", err)
 		}
@@ -93,7 +93,7 @@ var mcpListCmd = &cobra.Command{
 Examples:
   agentuity mcp list`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		detected, err := mcp.Detect(logger, true)
 		if err != nil {
 			logger.Fatal("%s", err)
@@ -144,7 +144,7 @@ Examples:
 		stdioTransport, _ := cmd.Flags().GetBool("stdio")
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		tmplDir, _, err := getConfigTemplateDir(cmd)
 		if err != nil {
 			errsystem.New(errsystem.ErrLoadTemplates, err, errsystem.WithContextMessage("Failed to load templates from directory")).ShowErrorAndExit()
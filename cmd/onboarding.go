@@ -1,6 +1,27 @@
 package cmd
 
-import "github.com/agentuity/go-common/tui"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
 
 const onboardingDocsURL = "https://agentuity.dev/"
 
@@ -12,3 +33,259 @@ func showAuthNextSteps() {
 	)
 	tui.ShowBanner("Keep building with Agentuity", body, false)
 }
+
+// onboardingTitles are the titles of the onboarding steps
+var onboardingTitles = map[string]string{
+	util.OnboardingLogin:        "Log in to Agentuity",
+	util.OnboardingOrganization: "Select your organization",
+	util.OnboardingProject:      "Create or import a project",
+	util.OnboardingAgent:        "Create your first Agent",
+	util.OnboardingTest:         "Test the Agent locally",
+	util.OnboardingDeploy:       "Deploy to the Agentuity Cloud",
+}
+
+// showOnboardingProgress shows the steps of the onboarding with the completed steps checked
+func showOnboardingProgress(progress util.OnboardingProgress, current string) {
+	fmt.Println()
+	for i, step := range util.OnboardingSteps {
+		title := fmt.Sprintf("%d. %s", i+1, onboardingTitles[step])
+		switch {
+		case step == current:
+			fmt.Println(tui.Bold("→ " + title))
+		case progress.Done(step):
+			fmt.Println(tui.Muted("✓ " + title))
+		default:
+			fmt.Println(tui.Muted("  " + title))
+		}
+	}
+	fmt.Println()
+}
+
+// runOnboardingCommand runs a command of the CLI in the directory with the terminal of the
+// onboarding and reloads the configuration which the command may have changed. The onboarding
+// exits with the exit code of the command when it fails so that it can be resumed from the step.
+func runOnboardingCommand(ctx context.Context, step string, dir string, args ...string) {
+	c := exec.CommandContext(ctx, getAgentuityCommand(), args...)
+	c.Dir = dir
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	err := c.Run()
+	if err := viper.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to reload the config file")).ShowErrorAndExit()
+	}
+	if err == nil {
+		return
+	}
+	if isCancelled(ctx) {
+		errsystem.ShowCancelledAndExit()
+	}
+	fmt.Println()
+	tui.ShowWarning("The %s step didn't complete. Run %s to resume the onboarding from it.", step, tui.Command("onboard"))
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		os.Exit(exitErr.ExitCode())
+	}
+	os.Exit(util.ExitCodeGeneral)
+}
+
+// loadOnboardingProject loads the project of the onboarding from its directory
+func loadOnboardingProject(progress util.OnboardingProgress) *cproject.Project {
+	theproject := project.NewProject()
+	if err := theproject.Load(progress.ProjectDir); err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithUserMessage("Failed to load the project in %s. Run %s to create or import it again.", progress.ProjectDir, tui.Command("onboard --step project"))).ShowErrorAndExit()
+	}
+	return theproject
+}
+
+func runOnboardingStep(ctx context.Context, logger logger.Logger, cmd *cobra.Command, progress *util.OnboardingProgress, step string) {
+	switch step {
+	case util.OnboardingLogin:
+		if !util.CurrentSession().ValidFor(util.DefaultSessionValidity) {
+			runOnboardingCommand(ctx, step, "", "login")
+		}
+		if !util.CurrentSession().ValidFor(util.DefaultSessionValidity) {
+			tui.ShowWarning("You are not logged in. Run %s to try again.", tui.Command("onboard"))
+			os.Exit(util.ExitCodeAuth)
+		}
+		tui.ShowSuccess("You are logged in")
+
+	case util.OnboardingOrganization:
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		urls := util.GetURLs(logger)
+		orgId := promptForOrganization(ctx, logger, cmd, urls.API, apikey)
+		viper.Set("preferences.orgId", orgId)
+		if err := viper.WriteConfig(); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the organization")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Your projects will be created in organization %s", orgId)
+
+	case util.OnboardingProject:
+		cwd, err := os.Getwd()
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to get current working directory")).ShowErrorAndExit()
+		}
+		existing := cproject.ProjectExists(cwd)
+		choice := tui.Select(logger, "Do you want to create a new project or import an existing one?", "", []tui.Option{
+			{ID: "create", Text: "Create a new project from a template", Selected: !existing},
+			{ID: "import", Text: "Import an existing project", Selected: existing},
+		})
+		var dir string
+		if choice == "create" {
+			createArgs := []string{"create"}
+			if orgId := viper.GetString("preferences.orgId"); orgId != "" {
+				createArgs = append(createArgs, "--org-id", orgId)
+			}
+			runOnboardingCommand(ctx, step, cwd, createArgs...)
+			// the create command remembers the directory of the project it created
+			dir = viper.GetString("preferences.project_dir")
+		} else {
+			dir = tui.InputWithPathCompletion(logger, "Which directory is the project in?", "The directory with the agentuity.yaml file of the project", cwd)
+			if dir, err = filepath.Abs(dir); err != nil {
+				errsystem.New(errsystem.ErrListFilesAndDirectories, err, errsystem.WithContextMessage("Failed to get absolute path")).ShowErrorAndExit()
+			}
+			if !cproject.ProjectExists(dir) {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no project found in %s", dir),
+					errsystem.WithUserMessage("There is no agentuity.yaml file in %s", dir)).ShowErrorAndExit()
+			}
+			runOnboardingCommand(ctx, step, dir, "project", "import", "--dir", dir)
+		}
+		if dir == "" || !cproject.ProjectExists(dir) {
+			tui.ShowWarning("No project was created. Run %s to try again.", tui.Command("onboard"))
+			os.Exit(util.ExitCodeGeneral)
+		}
+		progress.ProjectDir = dir
+
+	case util.OnboardingAgent:
+		theproject := loadOnboardingProject(*progress)
+		if len(theproject.Agents) > 0 {
+			names := make([]string, 0, len(theproject.Agents))
+			for _, a := range theproject.Agents {
+				names = append(names, a.Name)
+			}
+			tui.ShowSuccess("Your project has %s: %s", util.Pluralize(len(names), "Agent", "Agents"), strings.Join(names, ", "))
+			if !tui.Ask(logger, "Do you want to create another Agent?", false) {
+				return
+			}
+		}
+		runOnboardingCommand(ctx, step, progress.ProjectDir, "agent", "create")
+
+	case util.OnboardingTest:
+		theproject := loadOnboardingProject(*progress)
+		if len(theproject.Agents) == 0 {
+			tui.ShowWarning("The project has no Agents to test. Run %s to create one.", tui.Command("onboard --step agent"))
+			os.Exit(util.ExitCodeGeneral)
+		}
+		name := theproject.Agents[0].Name
+		if len(theproject.Agents) > 1 {
+			var opts []tui.Option
+			for _, a := range theproject.Agents {
+				opts = append(opts, tui.Option{ID: a.Name, Text: a.Name})
+			}
+			name = tui.Select(logger, "Which Agent do you want to test?", "", opts)
+		}
+		payload := tui.InputWithPlaceholder(logger, "What should we send to "+name+"?", "The payload of the request, either text or JSON", "Hello, who are you?")
+		tui.ShowSuccess("Running %s locally with your payload", name)
+		runOnboardingCommand(ctx, step, progress.ProjectDir, "run", name, "--payload", payload)
+
+	case util.OnboardingDeploy:
+		if !tui.Ask(logger, "Are you ready to deploy the project to the Agentuity Cloud?", true) {
+			tui.ShowWarning("Run %s when you are ready to deploy.", tui.Command("onboard"))
+			os.Exit(0)
+		}
+		runOnboardingCommand(ctx, step, progress.ProjectDir, "deploy", "--dir", progress.ProjectDir)
+	}
+}
+
+var onboardCmd = &cobra.Command{
+	Use:   "onboard",
+	Short: "Get started with a guided setup from login to your first deployment",
+	Long: `Get started with a guided setup from login to your first deployment.
+
+The onboarding walks you through the steps of building your first Agent:
+
+  1. Log in to Agentuity
+  2. Select your organization
+  3. Create a new project from a template or import an existing one
+  4. Create your first Agent
+  5. Test the Agent locally
+  6. Deploy the project to the Agentuity Cloud
+
+Each step runs the command you would use for it later. The progress is saved
+in the config file after each step, so running the command again resumes the
+onboarding where it stopped. Use --step to run a step and the steps after it
+again and --restart to start over.
+
+Flags:
+  --step       The step to resume the onboarding from
+  --restart    Start the onboarding over
+  --org-id     The organization to create the project in
+
+Examples:
+  agentuity onboard
+  agentuity onboard --step test
+  agentuity onboard --restart`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+
+		if !tui.HasTTY {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no TTY"),
+				errsystem.WithUserMessage("The onboarding is interactive and requires a terminal")).ShowErrorAndExit()
+		}
+
+		progress := util.CurrentOnboarding()
+		if restart, _ := cmd.Flags().GetBool("restart"); restart {
+			progress = util.OnboardingProgress{}
+		}
+		if step, _ := cmd.Flags().GetString("step"); step != "" {
+			if !slices.Contains(util.OnboardingSteps, step) {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid step %s", step),
+					errsystem.WithUserMessage("The step must be one of %s", strings.Join(util.OnboardingSteps, ", "))).ShowErrorAndExit()
+			}
+			if step != util.OnboardingLogin && step != util.OnboardingOrganization && step != util.OnboardingProject && progress.ProjectDir == "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no project"),
+					errsystem.WithUserMessage("The onboarding has no project yet. Run %s to create or import one.", tui.Command("onboard --step project"))).ShowErrorAndExit()
+			}
+			progress.ResetFrom(step)
+		}
+
+		initScreenWithLogo()
+		if progress.Next() == "" {
+			tui.ShowSuccess("You have completed the onboarding. Run %s to start over.", tui.Command("onboard --restart"))
+			return
+		}
+		if len(progress.Completed) > 0 {
+			tui.ShowSuccess("Welcome back! Resuming the onboarding at %s", tui.Bold(onboardingTitles[progress.Next()]))
+		}
+
+		for step := progress.Next(); step != ""; step = progress.Next() {
+			showOnboardingProgress(progress, step)
+			runOnboardingStep(ctx, logger, cmd, &progress, step)
+			progress.Complete(step)
+			if err := util.SaveOnboarding(progress); err != nil {
+				errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the onboarding progress")).ShowErrorAndExit()
+			}
+		}
+
+		fmt.Println()
+		tui.ShowBanner("You're all set!", tui.Paragraph(
+			tui.Secondary("Your first Agent is deployed. Here's what to do next:"),
+			tui.Secondary("1. Switch into the project directory at ")+tui.Directory(progress.ProjectDir),
+			tui.Secondary("2. Run ")+tui.Command("dev")+tui.Secondary(" to develop your Agents locally with live reload"),
+			tui.Secondary("3. Run ")+tui.Command("agent test")+tui.Secondary(" to send a payload to a deployed Agent"),
+			tui.Secondary("4. Explore the docs, see samples, and more: ")+tui.Link("%s", onboardingDocsURL),
+		), false)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(onboardCmd)
+	onboardCmd.Flags().String("step", "", "The step to resume the onboarding from ("+strings.Join(util.OnboardingSteps, ", ")+")")
+	onboardCmd.Flags().Bool("restart", false, "Start the onboarding over")
+	onboardCmd.Flags().String("org-id", "", "The organization to create the project in")
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/otel"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var otelCmd = &cobra.Command{
+	Use:   "otel",
+	Args:  cobra.NoArgs,
+	Short: "Export agent traces to your own OpenTelemetry endpoint",
+	Long: `Export agent traces to your own OpenTelemetry endpoint.
+
+The OTLP endpoint and headers are stored with the project in Agentuity Cloud and the
+traces of your deployed agents are exported to it in addition to Agentuity, so that
+they show up in your existing observability stack.
+
+Examples:
+  agentuity otel configure --endpoint https://otlp.example.com --header "x-api-key=secret"
+  agentuity otel show
+  agentuity otel test
+  agentuity otel remove`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func printOtelConfig(config *otel.Config) {
+	fmt.Printf("%s %s\n", tui.PadRight("Endpoint:", 12, " "), tui.Bold(config.Endpoint))
+	fmt.Printf("%s %s\n", tui.PadRight("Protocol:", 12, " "), config.Protocol)
+	if len(config.Headers) > 0 {
+		keys := make([]string, 0, len(config.Headers))
+		for k := range config.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Printf("%s\n", "Headers:")
+		for _, k := range keys {
+			fmt.Printf("  %s %s\n", k, tui.Muted(config.Headers[k]))
+		}
+	}
+	if config.UpdatedAt != "" {
+		fmt.Printf("%s %s\n", tui.PadRight("Updated:", 12, " "), tui.Muted(config.UpdatedAt))
+	}
+}
+
+var otelConfigureCmd = &cobra.Command{
+	Use:   "configure",
+	Args:  cobra.NoArgs,
+	Short: "Configure the OTLP endpoint the traces of the project are exported to",
+	Long: `Configure the OTLP endpoint the traces of the project are exported to.
+
+The endpoint is the base URL of your collector (like OTEL_EXPORTER_OTLP_ENDPOINT) and
+/v1/traces is appended for the HTTP protocols. The configuration applies to the agents
+of the project at runtime and replaces any previous configuration.
+
+Flags:
+  --endpoint    The OTLP endpoint URL
+  --protocol    The OTLP protocol (http/protobuf, http/json or grpc)
+  --header      A header sent with the traces in key=value form (can be repeated)
+
+Examples:
+  agentuity otel configure --endpoint https://api.honeycomb.io --header "x-honeycomb-team=KEY"
+  agentuity otel configure --endpoint https://otlp.example.com:4317 --protocol grpc`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		config := otel.Config{}
+		config.Endpoint, _ = cmd.Flags().GetString("endpoint")
+		config.Protocol, _ = cmd.Flags().GetString("protocol")
+		headers, _ := cmd.Flags().GetStringArray("header")
+
+		if config.Endpoint == "" {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please specify the endpoint with --endpoint")
+			}
+			config.Endpoint = tui.InputWithValidation(logger, "OTLP endpoint", "The base URL of your OpenTelemetry collector", 255, func(val string) error {
+				c := otel.Config{Endpoint: val}
+				return c.Validate()
+			})
+			if !cmd.Flags().Changed("protocol") {
+				var options []tui.Option
+				for _, p := range otel.Protocols {
+					options = append(options, tui.Option{ID: p, Text: p})
+				}
+				config.Protocol = tui.Select(logger, "OTLP protocol", "The protocol your collector accepts", options)
+			}
+		}
+
+		var err error
+		config.Headers, err = otel.ParseHeaders(headers)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		if err := config.Validate(); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+
+		var saved *otel.Config
+		tui.ShowSpinner("Saving OpenTelemetry configuration ...", func() {
+			saved, err = otel.SetConfig(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, config)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to save the OpenTelemetry configuration")).ShowErrorAndExit()
+		}
+		if saved == nil {
+			saved = &config
+		}
+		tui.ShowSuccess("Traces will be exported to %s", saved.Endpoint)
+		fmt.Println()
+		fmt.Printf("Send a test span with %s\n", tui.Command("otel test"))
+	},
+}
+
+var otelShowCmd = &cobra.Command{
+	Use:   "show",
+	Args:  cobra.NoArgs,
+	Short: "Show the OTLP endpoint the traces of the project are exported to",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		config, err := otel.GetConfig(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, true)
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the OpenTelemetry configuration")).ShowErrorAndExit()
+		}
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			json.NewEncoder(os.Stdout).Encode(config)
+			return
+		}
+		if config == nil {
+			tui.ShowWarning("No OpenTelemetry endpoint is configured for this project")
+			fmt.Println()
+			fmt.Printf("Configure one with %s\n", tui.Command("otel configure"))
+			return
+		}
+		printOtelConfig(config)
+	},
+}
+
+var otelRemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Aliases: []string{"rm"},
+	Args:    cobra.NoArgs,
+	Short:   "Stop exporting the traces of the project to your OTLP endpoint",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		if force, _ := cmd.Flags().GetBool("force"); !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to remove the OpenTelemetry configuration")
+			}
+			if !tui.Ask(logger, "Are you sure you want to stop exporting traces to your OpenTelemetry endpoint?", false) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		var err error
+		tui.ShowSpinner("Removing OpenTelemetry configuration ...", func() {
+			err = otel.DeleteConfig(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to remove the OpenTelemetry configuration")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Traces are no longer exported to your OpenTelemetry endpoint")
+	},
+}
+
+var otelTestCmd = &cobra.Command{
+	Use:   "test",
+	Args:  cobra.NoArgs,
+	Short: "Send a synthetic span to the OTLP endpoint",
+	Long: `Send a synthetic span to the OTLP endpoint.
+
+The span is sent from your machine with the configuration of the project, or with the
+endpoint, protocol and headers given as flags, to verify that the endpoint accepts
+traces before your agents send them. Look for the span named agentuity.otel.test with
+the trace id which is printed.
+
+Flags:
+  --endpoint    Test this OTLP endpoint instead of the configured one
+  --protocol    The OTLP protocol (http/protobuf, http/json or grpc)
+  --header      A header sent with the span in key=value form (can be repeated)
+
+Examples:
+  agentuity otel test
+  agentuity otel test --endpoint http://localhost:4318 --protocol http/json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+
+		var config *otel.Config
+		var err error
+		if endpoint, _ := cmd.Flags().GetString("endpoint"); endpoint != "" {
+			config = &otel.Config{Endpoint: endpoint}
+			headers, _ := cmd.Flags().GetStringArray("header")
+			config.Headers, err = otel.ParseHeaders(headers)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+		} else {
+			config, err = otel.GetConfig(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, false)
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the OpenTelemetry configuration")).ShowErrorAndExit()
+			}
+			if config == nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("no otel configuration"),
+					errsystem.WithUserMessage("No OpenTelemetry endpoint is configured for this project. Configure one with %s or pass --endpoint.", tui.Command("otel configure"))).ShowErrorAndExit()
+			}
+		}
+		if cmd.Flags().Changed("protocol") {
+			config.Protocol, _ = cmd.Flags().GetString("protocol")
+		}
+
+		resource := map[string]string{
+			"service.name":         theproject.Project.Name,
+			"agentuity.project.id": theproject.Project.ProjectId,
+		}
+		var result *otel.TestResult
+		action := func() {
+			result, err = otel.SendTestSpan(ctx, *config, resource, Version, nil)
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Sending test span ...", action)
+		}
+		if format == "json" {
+			if err != nil {
+				json.NewEncoder(os.Stdout).Encode(map[string]any{"success": false, "error": err.Error()})
+				os.Exit(1)
+			}
+			json.NewEncoder(os.Stdout).Encode(result)
+			return
+		}
+		if err != nil {
+			tui.ShowError("Failed to send the test span to %s: %s", config.Endpoint, err)
+			os.Exit(1)
+		}
+		if result.RejectedSpans > 0 {
+			tui.ShowWarning("The endpoint rejected the test span: %s", result.Message)
+			os.Exit(1)
+		}
+		tui.ShowSuccess("Sent test span to %s in %dms", result.URL, result.DurationMs)
+		fmt.Println()
+		fmt.Printf("%s %s\n", tui.PadRight("Trace ID:", 10, " "), tui.Bold(result.TraceID))
+		fmt.Printf("%s %s\n", tui.PadRight("Span ID:", 10, " "), result.SpanID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(otelCmd)
+	otelCmd.AddCommand(otelConfigureCmd)
+	otelCmd.AddCommand(otelShowCmd)
+	otelCmd.AddCommand(otelRemoveCmd)
+	otelCmd.AddCommand(otelTestCmd)
+
+	for _, cmd := range []*cobra.Command{otelConfigureCmd, otelShowCmd, otelRemoveCmd, otelTestCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+	}
+	for _, cmd := range []*cobra.Command{otelConfigureCmd, otelTestCmd} {
+		cmd.Flags().String("endpoint", "", "The OTLP endpoint URL")
+		cmd.Flags().String("protocol", otel.ProtocolHTTPProtobuf, "The OTLP protocol (http/protobuf, http/json or grpc)")
+		cmd.Flags().StringArray("header", nil, "A header in key=value form (can be repeated)")
+	}
+	otelShowCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	otelTestCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	otelRemoveCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+}
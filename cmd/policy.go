@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/deployer"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/policy"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Args:  cobra.NoArgs,
+	Short: "Manage the deploy policies of an organization or project",
+	Long: `Manage the deploy policies of an organization or project.
+
+A policy protects tags so that deploys which include them must meet its requirements,
+such as being run from CI with a clean git working tree. When no tags are protected the
+requirements apply to every deploy. Deploys must meet both the organization and the
+project policy and are refused before anything is uploaded when they don't.
+
+Examples:
+  agentuity policy set --protect-tag latest --require-ci --require-clean-git
+  agentuity policy set --org-id <orgId> --require-ci
+  agentuity policy show
+  agentuity policy remove`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// policyScope returns the scope and id of the policy the command applies to which is the org when
+// the --org-id flag is used and the project otherwise
+func policyScope(ctx context.Context, cmd *cobra.Command) (logger.Logger, string, string, string, string) {
+	logger := util.NewLogger(cmd)
+	if orgId, _ := cmd.Flags().GetString("org-id"); orgId != "" {
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		return logger, util.GetURLs(logger).API, apikey, policy.ScopeOrg, orgId
+	}
+	theproject := project.EnsureProject(ctx, cmd)
+	return logger, theproject.APIURL, theproject.Token, policy.ScopeProject, theproject.Project.ProjectId
+}
+
+func printPolicy(title string, p *policy.Policy) {
+	fmt.Println(tui.Bold(title))
+	if p.Empty() {
+		fmt.Println(tui.Muted("  No requirements"))
+		return
+	}
+	tags := "all tags"
+	if len(p.ProtectedTags) > 0 {
+		tags = strings.Join(p.ProtectedTags, ", ")
+	}
+	fmt.Printf("  %s %s\n", tui.PadRight("Protected tags:", 20, " "), tags)
+	fmt.Printf("  %s %t\n", tui.PadRight("Require CI:", 20, " "), p.RequireCI)
+	fmt.Printf("  %s %t\n", tui.PadRight("Require clean git:", 20, " "), p.RequireCleanGit)
+	if p.UpdatedAt != "" {
+		fmt.Printf("  %s %s\n", tui.PadRight("Updated:", 20, " "), tui.Muted(p.UpdatedAt))
+	}
+}
+
+var policyShowCmd = &cobra.Command{
+	Use:   "show",
+	Args:  cobra.NoArgs,
+	Short: "Show the deploy policies",
+	Long: `Show the deploy policies.
+
+For a project both the organization and the project policy are shown since a deploy
+must meet both.
+
+Examples:
+  agentuity policy show
+  agentuity policy show --org-id <orgId>
+  agentuity policy show --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger, apiUrl, token, scope, id := policyScope(ctx, cmd)
+		format, _ := cmd.Flags().GetString("format")
+
+		var policies *policy.Policies
+		var err error
+		action := func() {
+			if scope == policy.ScopeOrg {
+				policies = &policy.Policies{}
+				policies.Org, err = policy.GetPolicy(ctx, logger, apiUrl, token, scope, id)
+			} else {
+				policies, err = policy.GetProjectPolicies(ctx, logger, apiUrl, token, id)
+			}
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Fetching deploy policies ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deploy policies")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(policies)
+			return
+		}
+		printPolicy("Organization", policies.Org)
+		if scope == policy.ScopeProject {
+			fmt.Println()
+			printPolicy("Project", policies.Project)
+		}
+	},
+}
+
+var policySetCmd = &cobra.Command{
+	Use:   "set",
+	Args:  cobra.NoArgs,
+	Short: "Change the deploy policy",
+	Long: `Change the deploy policy of the project or, with --org-id, of the organization.
+
+Only the requirements given as flags are changed and the rest of the policy is kept.
+
+Flags:
+  --protect-tag         Protect the tag (can be repeated)
+  --unprotect-tag       Stop protecting the tag (can be repeated)
+  --require-ci          Require deploys of protected tags to be run from CI
+  --require-clean-git   Require deploys of protected tags to have no uncommitted changes
+  --org-id              Change the policy of the organization instead of the project
+
+Examples:
+  agentuity policy set --protect-tag latest --require-ci --require-clean-git
+  agentuity policy set --unprotect-tag staging
+  agentuity policy set --require-ci=false`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger, apiUrl, token, scope, id := policyScope(ctx, cmd)
+
+		protect, _ := cmd.Flags().GetStringSlice("protect-tag")
+		unprotect, _ := cmd.Flags().GetStringSlice("unprotect-tag")
+		if !cmd.Flags().Changed("require-ci") && !cmd.Flags().Changed("require-clean-git") && len(protect) == 0 && len(unprotect) == 0 {
+			errsystem.New(errsystem.ErrMissingRequiredArgument, fmt.Errorf("no policy changes"),
+				errsystem.WithUserMessage("Nothing to change. Use --protect-tag, --unprotect-tag, --require-ci or --require-clean-git.")).ShowErrorAndExit()
+		}
+
+		var current *policy.Policy
+		var err error
+		tui.ShowSpinner("Fetching deploy policy ...", func() {
+			current, err = policy.GetPolicy(ctx, logger, apiUrl, token, scope, id)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deploy policy")).ShowErrorAndExit()
+		}
+		var updated policy.Policy
+		if current != nil {
+			updated = *current
+			updated.ProtectedTags = slices.Clone(current.ProtectedTags)
+		}
+		for _, tag := range protect {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !slices.Contains(updated.ProtectedTags, tag) {
+				updated.ProtectedTags = append(updated.ProtectedTags, tag)
+			}
+		}
+		updated.ProtectedTags = slices.DeleteFunc(updated.ProtectedTags, func(tag string) bool {
+			return slices.Contains(unprotect, tag)
+		})
+		if cmd.Flags().Changed("require-ci") {
+			updated.RequireCI, _ = cmd.Flags().GetBool("require-ci")
+		}
+		if cmd.Flags().Changed("require-clean-git") {
+			updated.RequireCleanGit, _ = cmd.Flags().GetBool("require-clean-git")
+		}
+		if len(updated.ProtectedTags) > 0 && updated.Empty() {
+			tui.ShowWarning("The protected tags have no requirements. Use --require-ci or --require-clean-git to add them.")
+		}
+
+		var saved *policy.Policy
+		tui.ShowSpinner("Saving deploy policy ...", func() {
+			saved, err = policy.SetPolicy(ctx, logger, apiUrl, token, scope, id, updated)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to save the deploy policy")).ShowErrorAndExit()
+		}
+		if saved == nil {
+			saved = &updated
+		}
+		title := "Project"
+		if scope == policy.ScopeOrg {
+			title = "Organization"
+		}
+		tui.ShowSuccess("Deploy policy updated")
+		fmt.Println()
+		printPolicy(title, saved)
+	},
+}
+
+var policyRemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Aliases: []string{"rm", "delete"},
+	Args:    cobra.NoArgs,
+	Short:   "Remove the deploy policy",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger, apiUrl, token, scope, id := policyScope(ctx, cmd)
+
+		if force, _ := cmd.Flags().GetBool("force"); !force && tui.HasTTY {
+			if !tui.Ask(logger, "Are you sure you want to remove the deploy policy?", true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		var err error
+		tui.ShowSpinner("Removing deploy policy ...", func() {
+			err = policy.DeletePolicy(ctx, logger, apiUrl, token, scope, id)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to remove the deploy policy")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Deploy policy removed")
+	},
+}
+
+// checkDeployPolicy refuses the deploy when it doesn't meet the deploy policies of the project
+func checkDeployPolicy(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, tags []string, ci bool) {
+	var policies *policy.Policies
+	var err error
+	tui.ShowSpinner("Checking deploy policy ...", func() {
+		policies, err = policy.GetProjectPolicies(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the deploy policies")).ShowErrorAndExit()
+	}
+	deploy := policy.Deploy{Tags: tags, CI: policy.IsCI(ci)}
+	if (policies.Org != nil && policies.Org.RequireCleanGit) || (policies.Project != nil && policies.Project.RequireCleanGit) {
+		deploy.IsRepo, deploy.GitClean, err = deployer.GitStatus(logger, theproject.Dir)
+		if err != nil {
+			logger.Debug("failed to get the git status: %s", err)
+		}
+	}
+	violations := policies.Check(deploy)
+	if len(violations) == 0 {
+		return
+	}
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("The deploy of %s is not allowed by the deploy policy:\n", strings.Join(tags, ", ")))
+	for _, v := range violations {
+		msg.WriteString(fmt.Sprintf("\n  • %s", v))
+	}
+	errsystem.New(errsystem.ErrDeployProject, fmt.Errorf("deploy policy violated: %s", strings.Join(violations, "; ")),
+		errsystem.WithUserMessage("%s", msg.String())).ShowErrorAndExit()
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyShowCmd)
+	policyCmd.AddCommand(policySetCmd)
+	policyCmd.AddCommand(policyRemoveCmd)
+
+	for _, cmd := range []*cobra.Command{policyShowCmd, policySetCmd, policyRemoveCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+		cmd.Flags().String("org-id", "", "The organization to use instead of the project")
+	}
+	policyShowCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	policySetCmd.Flags().StringSlice("protect-tag", nil, "Protect the tag (can be repeated)")
+	policySetCmd.Flags().StringSlice("unprotect-tag", nil, "Stop protecting the tag (can be repeated)")
+	policySetCmd.Flags().Bool("require-ci", false, "Require deploys of protected tags to be run from CI")
+	policySetCmd.Flags().Bool("require-clean-git", false, "Require deploys of protected tags to have no uncommitted changes")
+	policyRemoveCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/logger"
 	"github.com/agentuity/go-common/sys"
 	"github.com/agentuity/go-common/tui"
@@ -101,7 +100,7 @@ Examples:
   agentuity profile use dev
   agentuity profile use`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		var name string
 		if len(args) > 0 {
 			name = args[0]
@@ -138,7 +137,7 @@ You will be prompted to enter a name for the profile.
 Examples:
   agentuity profile create`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		profiles := fetchProfiles()
 		name := tui.InputWithValidation(logger, "Name your profile", "Choose a short unique name", 0, func(val string) error {
 			if val == "" {
@@ -3,20 +3,31 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/cli/internal/backup"
+	"github.com/agentuity/cli/internal/bundler"
 	"github.com/agentuity/cli/internal/deployer"
 	"github.com/agentuity/cli/internal/envutil"
 	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/keys"
 	"github.com/agentuity/cli/internal/mcp"
+	"github.com/agentuity/cli/internal/openapi"
 	"github.com/agentuity/cli/internal/organization"
 	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/sbom"
 	"github.com/agentuity/cli/internal/templates"
 	"github.com/agentuity/cli/internal/ui"
 	"github.com/agentuity/cli/internal/util"
@@ -26,6 +37,7 @@ import (
 	"github.com/agentuity/go-common/tui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 var projectCmd = &cobra.Command{
@@ -46,18 +58,8 @@ func detectRuntime(dir string, defaultRuntime string) string {
 	if defaultRuntime != "nodejs" && defaultRuntime != "bunjs" {
 		return defaultRuntime
 	}
-
-	// Check for bun lockfiles - use bunjs runtime
-	if util.Exists(filepath.Join(dir, "bun.lockb")) ||
-		util.Exists(filepath.Join(dir, "bun.lock")) {
-		return "bunjs"
-	}
-
-	// Check for nodejs runtime
-	if util.Exists(filepath.Join(dir, "pnpm-lock.yaml")) ||
-		util.Exists(filepath.Join(dir, "package-lock.json")) ||
-		util.Exists(filepath.Join(dir, "yarn.lock")) {
-		return "nodejs"
+	if runtime := bundler.LockfileRuntime(dir, "javascript"); runtime != "" {
+		return runtime
 	}
 
 	// No lockfile found, use the template default
@@ -186,7 +188,7 @@ func initProject(ctx context.Context, logger logger.Logger, args InitProjectArgs
 	// set the agents from the result
 	proj.Agents = result.Agents
 
-	if err := proj.Save(args.Dir); err != nil {
+	if err := project.SaveProject(args.Dir, proj); err != nil {
 		errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("Failed to save project to disk")).ShowErrorAndExit()
 	}
 
@@ -332,7 +334,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
@@ -581,7 +583,7 @@ Examples:
 					os.Exit(1)
 				}
 			}
-			os.RemoveAll(projectDir)
+			util.RemoveAll(projectDir)
 			initScreenWithLogo()
 		}
 
@@ -748,7 +750,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
@@ -843,7 +845,7 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
 		urls := util.GetURLs(logger)
 		apiUrl := urls.API
@@ -899,10 +901,599 @@ Examples:
 		}
 
 		tui.ShowSpinner("Deleting projects ...", action)
+		if len(deleted) < len(selected) {
+			var failed []string
+			for _, id := range selected {
+				if !slices.Contains(deleted, id) {
+					failed = append(failed, id)
+				}
+			}
+			errsystem.New(errsystem.ErrOperationPartiallyCompleted, fmt.Errorf("failed to delete %s", strings.Join(failed, ", ")),
+				errsystem.WithUserMessage("%s deleted but %s could not be deleted: %s", util.Pluralize(len(deleted), "project was", "projects were"), util.Pluralize(len(failed), "project", "projects"), strings.Join(failed, ", "))).ShowErrorAndExit()
+		}
 		tui.ShowSuccess("%s deleted successfully", util.Pluralize(len(deleted), "project", "projects"))
 	},
 }
 
+var projectSetCmd = &cobra.Command{
+	Use:   "set",
+	Args:  cobra.NoArgs,
+	Short: "Update the name, description or tags of the project",
+	Long: `Update the name, description or tags of the project.
+
+The project is updated in Agentuity Cloud and in agentuity.yaml together. If the
+project file can't be written the change in the cloud is reverted.
+
+Flags:
+  --name           The new name of the project
+  --description    The new description of the project
+  --tags           The tags of the project, replacing the current tags (pass "" to remove them)
+
+Examples:
+  agentuity project set --name support-agents
+  agentuity project set --description "Agents for the support team" --tags support,prod
+  agentuity project set --tags ""`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		p := theproject.Project
+
+		if !cmd.Flags().Changed("name") && !cmd.Flags().Changed("description") && !cmd.Flags().Changed("tags") {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("nothing to update"),
+				errsystem.WithUserMessage("Nothing to update. Pass at least one of --name, --description or --tags.")).ShowErrorAndExit()
+		}
+
+		currentTags, err := project.LoadProjectTags(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to read the project tags")).ShowErrorAndExit()
+		}
+		if currentTags == nil {
+			currentTags = []string{}
+		}
+		// copied since the project is updated in place before it is saved
+		previousName, previousDescription := p.Name, p.Description
+		previous := project.ProjectUpdate{Name: &previousName, Description: &previousDescription, Tags: &currentTags}
+
+		var update project.ProjectUpdate
+		var changes []string
+		if cmd.Flags().Changed("name") {
+			name, _ := cmd.Flags().GetString("name")
+			name = strings.TrimSpace(name)
+			if name == "" {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("empty name"), errsystem.WithUserMessage("The project name cannot be empty")).ShowErrorAndExit()
+			}
+			if name != p.Name {
+				var exists bool
+				tui.ShowSpinner("Checking project name ...", func() {
+					var data *project.ProjectData
+					data, err = project.GetProject(ctx, logger, theproject.APIURL, theproject.Token, p.ProjectId, true, false)
+					if err == nil {
+						exists, err = project.ProjectWithNameExists(ctx, logger, theproject.APIURL, theproject.Token, data.OrgId, name)
+					}
+				})
+				if err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to validate the project name")).ShowErrorAndExit()
+				}
+				if exists {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("project %s already exists", name),
+						errsystem.WithUserMessage("A project named %s already exists in the organization", name)).ShowErrorAndExit()
+				}
+				update.Name = &name
+				changes = append(changes, fmt.Sprintf("name to %s", tui.Bold(name)))
+			}
+		}
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			if description != p.Description {
+				update.Description = &description
+				changes = append(changes, "description")
+			}
+		}
+		if cmd.Flags().Changed("tags") {
+			values, _ := cmd.Flags().GetStringSlice("tags")
+			tags, err := project.NormalizeTags(values)
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+			}
+			if !slices.Equal(tags, *previous.Tags) {
+				update.Tags = &tags
+				if len(tags) == 0 {
+					changes = append(changes, "tags to none")
+				} else {
+					changes = append(changes, fmt.Sprintf("tags to %s", tui.Bold(strings.Join(tags, ", "))))
+				}
+			}
+		}
+		if len(changes) == 0 {
+			tui.ShowWarning("The project is already up to date")
+			return
+		}
+
+		tui.ShowSpinner("Updating project ...", func() {
+			err = project.UpdateProject(ctx, logger, theproject.APIURL, theproject.Token, p.ProjectId, update)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to update the project")).ShowErrorAndExit()
+		}
+
+		if update.Name != nil {
+			p.Name = *update.Name
+		}
+		if update.Description != nil {
+			p.Description = *update.Description
+		}
+		if update.Tags != nil {
+			err = project.SaveProjectWithTags(theproject.Dir, p, *update.Tags)
+		} else {
+			err = project.SaveProject(theproject.Dir, p)
+		}
+		if err != nil {
+			// keep the cloud and the project file in sync by reverting the change we made
+			if rerr := project.UpdateProject(ctx, logger, theproject.APIURL, theproject.Token, p.ProjectId, previous); rerr != nil {
+				logger.Error("failed to revert the project update: %s", rerr)
+			}
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the project file")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Updated the project %s", strings.Join(changes, ", "))
+	},
+}
+
+var projectBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Args:  cobra.NoArgs,
+	Short: "Back up the cloud state of the project to a file",
+	Long: `Back up the cloud state of the project to a file.
+
+The backup contains the project, its agents and their tags, the keys of the environment
+variables and secrets, the schedules and the outbound webhooks. It can be restored into
+another project or organization with the project restore command.
+
+With --encrypt the values of the environment variables and secrets are included,
+encrypted with the active encryption key of the organization (see agentuity keys), so
+they can only be restored by someone who holds the private key.
+
+Flags:
+  --output    The file to write the backup to
+  --encrypt   Include the environment variable and secret values encrypted with the organization key
+
+Examples:
+  agentuity project backup --output backup.json
+  agentuity project backup --project <projectId> --output backup.json --encrypt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		output, _ := cmd.Flags().GetString("output")
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+
+		projectId := cloudResolveProject(ctx, logger, cmd, apiUrl, apikey, "Select the project to back up")
+		if projectId == "" {
+			return
+		}
+		if output == "" {
+			output = fmt.Sprintf("%s-backup.json", projectId)
+		}
+
+		b := &backup.Backup{Version: backup.Version, CreatedAt: time.Now().UTC(), EnvKeys: []string{}, SecretKeys: []string{}}
+		var data *project.ProjectData
+		var err error
+		tui.ShowSpinner("Backing up project ...", func() {
+			var found bool
+			for _, p := range listProjects(ctx, logger, apiUrl, apikey, "") {
+				if p.ID == projectId {
+					b.Project = backup.Project{ID: p.ID, Name: p.Name, Description: p.Description, OrgID: p.OrgId, Tags: p.Tags}
+					found = true
+				}
+			}
+			if !found {
+				err = fmt.Errorf("project %s not found", projectId)
+				return
+			}
+			if data, err = project.GetProject(ctx, logger, apiUrl, apikey, projectId, !encrypt, false); err != nil {
+				return
+			}
+			var agents []agent.Agent
+			if agents, err = agent.ListAgents(ctx, logger, apiUrl, apikey, projectId); err != nil {
+				return
+			}
+			for _, a := range agents {
+				b.Agents = append(b.Agents, backup.Agent{ID: a.ID, Name: a.Name, Description: a.Description, Tags: a.Tags})
+			}
+			if b.Schedules, err = agent.ListSchedules(ctx, logger, apiUrl, apikey, projectId); err != nil {
+				return
+			}
+			b.Webhooks, err = agent.ListWebhooks(ctx, logger, apiUrl, apikey, projectId)
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to back up the project")).ShowErrorAndExit()
+		}
+		if local := project.TryProject(ctx, cmd); local.Project != nil && local.Project.ProjectId == projectId {
+			b.Project.Provider = local.Project.Bundler.Identifier
+		}
+		for k := range data.Env {
+			if !strings.HasPrefix(k, "AGENTUITY_") {
+				b.EnvKeys = append(b.EnvKeys, k)
+			}
+		}
+		for k := range data.Secrets {
+			if !strings.HasPrefix(k, "AGENTUITY_") {
+				b.SecretKeys = append(b.SecretKeys, k)
+			}
+		}
+		sort.Strings(b.EnvKeys)
+		sort.Strings(b.SecretKeys)
+
+		if encrypt {
+			var orgKeys []keys.OrgKey
+			tui.ShowSpinner("Fetching the organization key ...", func() {
+				orgKeys, err = keys.List(ctx, logger, apiUrl, apikey, b.Project.OrgID)
+			})
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the organization encryption key")).ShowErrorAndExit()
+			}
+			var active *keys.OrgKey
+			for i, k := range orgKeys {
+				if k.Active {
+					active = &orgKeys[i]
+				}
+			}
+			if active == nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("no active encryption key"),
+					errsystem.WithUserMessage("The organization has no active encryption key. Create one with %s or back up without --encrypt.", tui.Command("keys generate"))).ShowErrorAndExit()
+			}
+			pub, fingerprint, err := keys.ParsePublicKey([]byte(active.PublicKey))
+			if err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to parse the organization encryption key")).ShowErrorAndExit()
+			}
+			values := backup.Values{Env: make(map[string]string), Secrets: make(map[string]string)}
+			for _, k := range b.EnvKeys {
+				values.Env[k] = data.Env[k]
+			}
+			for _, k := range b.SecretKeys {
+				values.Secrets[k] = data.Secrets[k]
+			}
+			if err := b.EncryptValues(pub, fingerprint, values); err != nil {
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to encrypt the environment")).ShowErrorAndExit()
+			}
+		}
+
+		if err := b.Write(output); err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to write the backup")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Backed up %s to %s", tui.Bold(b.Project.Name), output)
+		fmt.Println()
+		fmt.Printf("%s %d\n", tui.PadRight("Agents:", 22, " "), len(b.Agents))
+		fmt.Printf("%s %d\n", tui.PadRight("Environment variables:", 22, " "), len(b.EnvKeys))
+		fmt.Printf("%s %d\n", tui.PadRight("Secrets:", 22, " "), len(b.SecretKeys))
+		fmt.Printf("%s %d\n", tui.PadRight("Schedules:", 22, " "), len(b.Schedules))
+		fmt.Printf("%s %d\n", tui.PadRight("Webhooks:", 22, " "), len(b.Webhooks))
+		if b.Values == nil && len(b.EnvKeys)+len(b.SecretKeys) > 0 {
+			fmt.Println()
+			fmt.Println(tui.Muted("Only the keys of the environment were backed up. Use --encrypt to include the values."))
+		}
+	},
+}
+
+var projectRestoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Restore a project backup into a new or existing project",
+	Long: `Restore a project backup into a new or existing project.
+
+By default a new project is created in the organization given with --org-id (or the one
+you select). Use --project to restore into an existing project instead, in which case
+agents with the same name are reused.
+
+The values of the environment variables and secrets are restored when the backup was
+made with --encrypt and the private key of the organization key is given with
+--private-key. Otherwise the keys which need a value are listed.
+
+Arguments:
+  [file]    The backup file created with the project backup command
+
+Flags:
+  --org-id        The organization to create the new project in
+  --project       The existing project to restore into
+  --name          The name of the new project, defaults to the name in the backup
+  --private-key   The private key to decrypt the environment values with
+  --dry-run       Show what would be restored without changing anything
+
+Examples:
+  agentuity project restore backup.json --org-id <orgId>
+  agentuity project restore backup.json --project <projectId> --private-key org.key`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		projectId, _ := cmd.Flags().GetString("project")
+		name, _ := cmd.Flags().GetString("name")
+		privateKeyFile, _ := cmd.Flags().GetString("private-key")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		b, err := backup.Load(args[0])
+		if err != nil {
+			errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to read the backup")).ShowErrorAndExit()
+		}
+		var values *backup.Values
+		if privateKeyFile != "" {
+			if b.Values == nil {
+				tui.ShowWarning("The backup has no environment values, only the keys will be listed")
+			} else {
+				buf, err := os.ReadFile(privateKeyFile)
+				if err != nil {
+					errsystem.New(errsystem.ErrOpenFile, err, errsystem.WithContextMessage("Failed to read the private key")).ShowErrorAndExit()
+				}
+				priv, err := keys.ParsePrivateKey(buf)
+				if err != nil {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+				}
+				if values, err = b.DecryptValues(priv); err != nil {
+					errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+				}
+			}
+		}
+		if name == "" {
+			name = b.Project.Name
+		}
+
+		if dryRun {
+			target := "a new project named " + name
+			if projectId != "" {
+				target = "the project " + projectId
+			}
+			fmt.Printf("Would restore %s into %s:\n\n", tui.Bold(b.Project.Name), target)
+			for _, a := range b.Agents {
+				fmt.Printf("  agent     %s\n", a.Name)
+			}
+			for _, s := range b.Schedules {
+				fmt.Printf("  schedule  %s %s\n", s.Expression, tui.Muted(s.Description))
+			}
+			for _, w := range b.Webhooks {
+				fmt.Printf("  webhook   %s\n", w.URL)
+			}
+			fmt.Printf("  env       %d variables, %d secrets (values: %t)\n", len(b.EnvKeys), len(b.SecretKeys), values != nil)
+			return
+		}
+
+		// the ids of the agents in the backup mapped to the ids of the agents in the restored project
+		ids := make(map[string]string)
+		if projectId == "" {
+			orgId, _ := cmd.Flags().GetString("org-id")
+			if orgId == "" {
+				orgId = promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+			}
+			var exists bool
+			tui.ShowSpinner("Checking project name ...", func() {
+				exists, err = project.ProjectWithNameExists(ctx, logger, apiUrl, apikey, orgId, name)
+			})
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to validate the project name")).ShowErrorAndExit()
+			}
+			if exists {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("project %s already exists", name),
+					errsystem.WithUserMessage("A project named %s already exists in the organization. Use --name to choose another name or --project to restore into it.", name)).ShowErrorAndExit()
+			}
+			p := project.NewProject()
+			p.Name = name
+			p.Description = b.Project.Description
+			p.Bundler.Identifier = b.Project.Provider
+			for _, a := range b.Agents {
+				p.Agents = append(p.Agents, cproject.AgentConfig{ID: a.ID, Name: a.Name, Description: a.Description})
+			}
+			var result *project.ProjectImportResponse
+			tui.ShowSpinner("Creating project ...", func() {
+				result, err = project.ProjectImport(ctx, logger, apiUrl, apikey, orgId, p, false)
+			})
+			if err != nil {
+				errsystem.New(errsystem.ErrImportingProject, err, errsystem.WithContextMessage("Failed to create the project")).ShowErrorAndExit()
+			}
+			projectId = result.ID
+			for _, a := range b.Agents {
+				for _, created := range result.Agents {
+					if created.Name == a.Name {
+						ids[a.ID] = created.ID
+					}
+				}
+			}
+		} else {
+			var existing []agent.Agent
+			tui.ShowSpinner("Fetching agents ...", func() {
+				existing, err = agent.ListAgents(ctx, logger, apiUrl, apikey, projectId)
+			})
+			if err != nil {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to list the agents of the project")).ShowErrorAndExit()
+			}
+			for _, a := range b.Agents {
+				for _, e := range existing {
+					if e.Name == a.Name {
+						ids[a.ID] = e.ID
+					}
+				}
+				if _, ok := ids[a.ID]; ok {
+					continue
+				}
+				tui.ShowSpinner(fmt.Sprintf("Creating agent %s ...", a.Name), func() {
+					ids[a.ID], err = agent.CreateAgent(ctx, logger, apiUrl, apikey, projectId, a.Name, a.Description, "project")
+				})
+				if err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to create agent "+a.Name)).ShowErrorAndExit()
+				}
+			}
+		}
+
+		schedules, webhooks, missing := b.Remap(ids)
+		tui.ShowSpinner("Restoring project ...", func() {
+			if len(b.Project.Tags) > 0 {
+				tags := b.Project.Tags
+				if err = project.UpdateProject(ctx, logger, apiUrl, apikey, projectId, project.ProjectUpdate{Tags: &tags}); err != nil {
+					return
+				}
+			}
+			for _, a := range b.Agents {
+				if id, ok := ids[a.ID]; ok && len(a.Tags) > 0 {
+					tags := a.Tags
+					if err = agent.UpdateAgent(ctx, logger, apiUrl, apikey, projectId, id, agent.AgentUpdate{Tags: &tags}); err != nil {
+						return
+					}
+				}
+			}
+			if values != nil && len(values.Env)+len(values.Secrets) > 0 {
+				if _, err = project.SetProjectEnv(ctx, logger, apiUrl, apikey, projectId, values.Env, values.Secrets); err != nil {
+					return
+				}
+			}
+			for _, s := range schedules {
+				if _, err = agent.CreateSchedule(ctx, logger, apiUrl, apikey, projectId, s); err != nil {
+					return
+				}
+			}
+			for _, w := range webhooks {
+				if _, err = agent.CreateWebhook(ctx, logger, apiUrl, apikey, projectId, w); err != nil {
+					return
+				}
+			}
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to restore the project")).ShowErrorAndExit()
+		}
+
+		tui.ShowSuccess("Restored %s into project %s", tui.Bold(b.Project.Name), projectId)
+		fmt.Println()
+		headers := []string{"Agent", "Backup Id", "Restored Id"}
+		rows := [][]string{}
+		for _, a := range b.Agents {
+			rows = append(rows, []string{tui.Bold(a.Name), tui.Muted(a.ID), ids[a.ID]})
+		}
+		if len(rows) > 0 {
+			tui.Table(headers, rows)
+		}
+		fmt.Printf("Restored %d schedules and %d webhooks\n", len(schedules), len(webhooks))
+		if len(missing) > 0 {
+			tui.ShowWarning("The agents %s could not be restored so their schedules and webhooks were skipped", strings.Join(missing, ", "))
+		}
+		if values == nil && len(b.EnvKeys)+len(b.SecretKeys) > 0 {
+			fmt.Println()
+			fmt.Println("Set the values of these environment variables and secrets with " + tui.Command("env set") + ":")
+			for _, k := range append(append([]string{}, b.EnvKeys...), b.SecretKeys...) {
+				fmt.Printf("  %s\n", k)
+			}
+		}
+	},
+}
+
+// runProjectImportCommand runs a command of the CLI in the directory of the imported project and
+// exits when it fails
+func runProjectImportCommand(ctx context.Context, dir string, args ...string) {
+	c := exec.CommandContext(ctx, getAgentuityCommand(), args...)
+	c.Dir = dir
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	err := c.Run()
+	if err == nil {
+		return
+	}
+	if isCancelled(ctx) {
+		errsystem.ShowCancelledAndExit()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		os.Exit(exitErr.ExitCode())
+	}
+	os.Exit(util.ExitCodeGeneral)
+}
+
+// importProjectFromGitHub clones the repository of the project and imports it into the organization
+// without any prompts, then installs its dependencies and optionally deploys it
+func importProjectFromGitHub(ctx context.Context, cmd *cobra.Command, logger logger.Logger, val string) {
+	source, err := agent.ParseSource(val)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid GitHub repository: %s", err)).ShowErrorAndExit()
+	}
+	authType, _ := cmd.Flags().GetString("auth")
+	switch authType {
+	case "project", "bearer", "none":
+	default:
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid auth type %q", authType), errsystem.WithUserMessage("The --auth flag must be project, bearer or none")).ShowErrorAndExit()
+	}
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = source.Repo
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithContextMessage("Failed to resolve the directory")).ShowErrorAndExit()
+	}
+	if util.Exists(dir) {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s already exists", dir),
+			errsystem.WithUserMessage("The directory %s already exists. Use --dir to clone the repository into another directory", dir)).ShowErrorAndExit()
+	}
+	tui.ShowSpinner(fmt.Sprintf("Cloning %s ...", source), func() {
+		err = agent.CloneRepository(ctx, source, dir)
+	})
+	if err != nil {
+		if isCancelled(ctx) {
+			errsystem.ShowCancelledAndExit()
+		}
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Failed to clone %s: %s", source, err)).ShowErrorAndExit()
+	}
+
+	// the project may be in a directory of the repository
+	projectDir := filepath.Join(dir, filepath.FromSlash(source.Path))
+	if !util.Exists(cproject.GetProjectFilename(projectDir)) {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("no agentuity.yaml in %s", source),
+			errsystem.WithUserMessage("%s isn't an Agentuity project since it has no agentuity.yaml. The repository was cloned into %s", source, dir)).ShowErrorAndExit()
+	}
+	cmd.Flags().Set("dir", projectDir)
+	context := project.EnsureProject(ctx, cmd)
+
+	orgId := promptForOrganization(ctx, logger, cmd, context.APIURL, context.Token)
+	if name, _ := cmd.Flags().GetString("name"); name != "" {
+		context.Project.Name = name
+	}
+	if context.Project.Name == "" {
+		context.Project.Name = source.Repo
+	}
+	if description, _ := cmd.Flags().GetString("description"); description != "" {
+		context.Project.Description = description
+	}
+	if slices.Contains(invalidProjectNames, any(context.Project.Name)) {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid project name %s", context.Project.Name),
+			errsystem.WithUserMessage("%s is not a valid project name. Use --name to choose another name", context.Project.Name)).ShowErrorAndExit()
+	}
+	exists, err := project.ProjectWithNameExists(ctx, logger, context.APIURL, context.Token, orgId, context.Project.Name)
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to check if project name exists")).ShowErrorAndExit()
+	}
+	if exists {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("project %s already exists", context.Project.Name),
+			errsystem.WithUserMessage("A project named %s already exists in this organization. Use --name to choose another name", context.Project.Name)).ShowErrorAndExit()
+	}
+	tui.ShowSpinner("Importing project ...", func() {
+		importProject(ctx, logger, context.APIURL, context.Token, orgId, context.Project, context.Dir, authType == "bearer")
+	})
+	_, _ = envutil.ProcessEnvFiles(ctx, logger, context.Dir, context.Project, nil, context.APIURL, context.Token, true, false, "")
+	tui.ShowSuccess("Project %s imported into %s", context.Project.Name, dir)
+
+	if noInstall, _ := cmd.Flags().GetBool("no-install"); !noInstall {
+		runProjectImportCommand(ctx, context.Dir, "bundle", "--install", "--dir", context.Dir)
+		tui.ShowSuccess("Dependencies installed")
+	}
+	if deploy, _ := cmd.Flags().GetBool("deploy"); deploy {
+		runProjectImportCommand(ctx, context.Dir, "deploy", "--dir", context.Dir)
+		return
+	}
+	fmt.Println()
+	fmt.Printf("Run %s in %s to start the project locally or %s to deploy it.\n", tui.Command("dev"), tui.Bold(context.Dir), tui.Command("deploy"))
+}
+
 var projectImportCmd = &cobra.Command{
 	Use:   "import",
 	Short: "Import a project",
@@ -911,12 +1502,28 @@ var projectImportCmd = &cobra.Command{
 This command imports a project from the current directory into your organization.
 You will be prompted to select an organization and provide project details.
 
+With --from-github, the GitHub repository is cloned into the directory (which
+defaults to the name of the repository) and imported without any prompts using
+the organization, name, description and authentication from the flags. The
+dependencies are then installed and, with --deploy, the project is deployed.
+The URL may name a branch or tag and the directory of the project in the
+repository such as https://github.com/owner/repo/tree/main/path.
+
 Flags:
-  --dir    The directory containing the project to import
+  --dir            The directory containing the project to import or to clone into
+  --from-github    The GitHub repository of the project to clone and import
+  --org-id         The organization to import the project into
+  --name           The name of the project (defaults to the name in agentuity.yaml)
+  --description    The description of the project
+  --auth           The authentication of the agents (project, bearer or none)
+  --no-install     Don't install the dependencies after the import
+  --deploy         Deploy the project after the import
 
 Examples:
   agentuity project import
-  agentuity project import --dir /path/to/project`,
+  agentuity project import --dir /path/to/project
+  agentuity project import --from-github owner/repo --org-id org_123
+  agentuity project import --from-github https://github.com/owner/repo --name my-project --deploy`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
@@ -926,26 +1533,18 @@ Examples:
 
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
+		logger := util.NewLogger(cmd)
+		if fromGitHub, _ := cmd.Flags().GetString("from-github"); fromGitHub != "" {
+			importProjectFromGitHub(ctx, cmd, logger, fromGitHub)
+			return
+		}
 		context := project.EnsureProject(ctx, cmd)
-		logger := env.NewLogger(cmd)
 
 		// headless mode for nova
 		if apikey != "" && orgId != "" && name != "" && description != "" {
 			context.Project.Name = name
 			context.Project.Description = description
-			result, err := project.ProjectImport(ctx, logger, context.APIURL, apikey, orgId, context.Project, true)
-			if err != nil {
-				if isCancelled(ctx) {
-					os.Exit(1)
-				}
-				errsystem.New(errsystem.ErrImportingProject, err,
-					errsystem.WithContextMessage("Error importing project")).ShowErrorAndExit()
-			}
-			if err := context.Project.Save(context.Dir); err != nil {
-				errsystem.New(errsystem.ErrSaveProject, err,
-					errsystem.WithContextMessage("Error saving project after import")).ShowErrorAndExit()
-			}
-			saveEnv(context.Dir, result.APIKey, result.ProjectKey)
+			importProject(ctx, logger, context.APIURL, apikey, orgId, context.Project, context.Dir, true)
 			return
 		}
 
@@ -954,7 +1553,7 @@ Examples:
 		if !tui.HasTTY {
 			force = true
 		}
-		_, _ = envutil.ProcessEnvFiles(ctx, logger, context.Dir, context.Project, nil, context.APIURL, context.Token, force, false)
+		_, _ = envutil.ProcessEnvFiles(ctx, logger, context.Dir, context.Project, nil, context.APIURL, context.Token, force, false, "")
 
 	},
 }
@@ -976,6 +1575,510 @@ func getConfigTemplateDir(cmd *cobra.Command) (string, bool, error) {
 	return dir, false, nil
 }
 
+var projectValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the project file",
+	Long: `Validate the agentuity.yaml project file of the project.
+
+This command checks the project file (such as the bundler configuration, the
+agents and the deployment resources) against the JSON Schema embedded in the CLI
+and shows each problem with its line and column in the file. It doesn't need
+to be logged in so it can run in CI or a pre-commit hook.
+
+Flags:
+  --dir       The project directory
+  --format    The format to use for the output (text or json)
+
+Examples:
+  agentuity project validate
+  agentuity project validate --dir /path/to/project --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		filename := cproject.GetProjectFilename(dir)
+		format, _ := cmd.Flags().GetString("format")
+
+		errs, err := project.ValidateProjectFile(filename, project.Schema)
+		if err != nil {
+			errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to validate the project file")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(map[string]any{"file": filename, "valid": len(errs) == 0, "errors": append([]project.ValidationError{}, errs...)})
+			if len(errs) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		relname := filename
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, filename); err == nil {
+				relname = rel
+			}
+		}
+		if ok, _ := project.HasSchemaModeline(filename); !ok {
+			tui.ShowWarning("%s has no schema modeline for editors. Add this line to the top of the file:\n\n%s", relname, project.SchemaModeline)
+			fmt.Println()
+		}
+		if len(errs) == 0 {
+			tui.ShowSuccess("%s is valid", relname)
+			return
+		}
+		for _, e := range errs {
+			fmt.Printf("%s %s\n", tui.Warning(fmt.Sprintf("%s:%d:%d:", relname, e.Line, e.Column)), e.Message)
+		}
+		fmt.Println()
+		tui.ShowError("%s has %s", relname, util.Pluralize(len(errs), "problem", "problems"))
+		os.Exit(1)
+	},
+}
+
+// formatScaleSetting returns the value of a scale setting or "default" when it isn't set
+func formatScaleSetting(value *int) string {
+	if value == nil {
+		return "default"
+	}
+	return strconv.Itoa(*value)
+}
+
+// formatScale returns the autoscaling such as "min 1, max 10, concurrency 4"
+func formatScale(scale *project.ScaleConfig) string {
+	if scale.Empty() {
+		return "default"
+	}
+	return fmt.Sprintf("min %s, max %s, concurrency %s", formatScaleSetting(scale.Min), formatScaleSetting(scale.Max), formatScaleSetting(scale.Concurrency))
+}
+
+var projectScaleCmd = &cobra.Command{
+	Use:   "scale",
+	Args:  cobra.NoArgs,
+	Short: "Manage the autoscaling of the deployments",
+	Long: `Manage the autoscaling of the deployments of the project.
+
+The autoscaling is set in the deployment.scale section of agentuity.yaml, which can
+also be edited by hand, and applies to the deployments made after it changes:
+
+  deployment:
+    scale:
+      min: 1
+      max: 10
+      concurrency: 4
+
+min is the number of instances always running (0 scales the deployment to zero
+when it's idle), max is the maximum number of instances and concurrency is the
+number of requests an instance handles at once before more instances are
+started. The cloud defaults are used for the settings which aren't set. The
+settings are validated before anything is deployed and recorded in the metadata
+of the deployment.
+
+Examples:
+  agentuity project scale set --min 1 --max 10 --concurrency 4
+  agentuity project scale get`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var projectScaleSetCmd = &cobra.Command{
+	Use:   "set",
+	Args:  cobra.NoArgs,
+	Short: "Change the autoscaling of the deployments",
+	Long: `Change the autoscaling of the deployments in the project file.
+
+Only the settings given as flags are changed and the rest are kept. The change
+applies to the next deployment of the project.
+
+Flags:
+  --min           The number of instances always running, 0 to scale to zero when idle
+  --max           The maximum number of instances
+  --concurrency   The number of requests an instance handles at once
+  --reset         Remove the settings to use the cloud defaults
+
+Examples:
+  agentuity project scale set --min 1 --max 10 --concurrency 4
+  agentuity project scale set --min 0
+  agentuity project scale set --reset`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		theproject := project.EnsureProject(ctx, cmd)
+		reset, _ := cmd.Flags().GetBool("reset")
+
+		if !reset && !cmd.Flags().Changed("min") && !cmd.Flags().Changed("max") && !cmd.Flags().Changed("concurrency") {
+			errsystem.New(errsystem.ErrMissingRequiredArgument, fmt.Errorf("no scale changes"),
+				errsystem.WithUserMessage("Nothing to change. Use --min, --max, --concurrency or --reset.")).ShowErrorAndExit()
+		}
+		scale, err := project.LoadScaleConfig(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the project file")).ShowErrorAndExit()
+		}
+		if reset {
+			scale = &project.ScaleConfig{}
+		}
+		for _, setting := range []struct {
+			flag  string
+			value **int
+		}{{"min", &scale.Min}, {"max", &scale.Max}, {"concurrency", &scale.Concurrency}} {
+			if cmd.Flags().Changed(setting.flag) {
+				v, _ := cmd.Flags().GetInt(setting.flag)
+				*setting.value = &v
+			}
+		}
+		if err := scale.Validate(); err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("Invalid scale: %s", err)).ShowErrorAndExit()
+		}
+		if err := project.SaveProjectWithScale(theproject.Dir, theproject.Project, *scale); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to save the project file")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Updated the deployment scale to %s", formatScale(scale))
+		fmt.Printf("Run %s to apply it.\n", tui.Command("deploy"))
+	},
+}
+
+var projectScaleGetCmd = &cobra.Command{
+	Use:   "get",
+	Args:  cobra.NoArgs,
+	Short: "Show the autoscaling of the deployments",
+	Long: `Show the autoscaling in the project file and the effective autoscaling of the
+active deployment, which has the cloud defaults filled in.
+
+Flags:
+  --format   The format of the output, text or json
+
+Examples:
+  agentuity project scale get
+  agentuity project scale get --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		format, _ := cmd.Flags().GetString("format")
+
+		scale, err := project.LoadScaleConfig(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the project file")).ShowErrorAndExit()
+		}
+		var active *project.DeploymentDetail
+		action := func() {
+			var deployments []project.DeploymentListData
+			deployments, err = project.ListDeployments(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId)
+			if err != nil {
+				return
+			}
+			for _, d := range deployments {
+				if d.Active {
+					active, err = project.GetDeployment(ctx, logger, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, d.ID)
+					return
+				}
+			}
+		}
+		if format == "json" {
+			action()
+		} else {
+			tui.ShowSpinner("Fetching the active deployment ...", action)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the active deployment")).ShowErrorAndExit()
+		}
+
+		if format == "json" {
+			out := map[string]any{"project": scale}
+			if active != nil {
+				out["deploymentId"] = active.ID
+				out["active"] = active.Scale
+			}
+			json.NewEncoder(os.Stdout).Encode(out)
+			return
+		}
+		headers := []string{"Setting", "Project File"}
+		if active != nil {
+			headers = append(headers, "Active Deployment")
+		}
+		activeScale := &project.ScaleConfig{}
+		if active != nil && active.Scale != nil {
+			activeScale = active.Scale
+		}
+		var rows [][]string
+		for _, setting := range []struct {
+			name   string
+			local  *int
+			remote *int
+		}{{"min", scale.Min, activeScale.Min}, {"max", scale.Max, activeScale.Max}, {"concurrency", scale.Concurrency, activeScale.Concurrency}} {
+			row := []string{setting.name, formatScaleSetting(setting.local)}
+			if active != nil {
+				row = append(row, formatScaleSetting(setting.remote))
+			}
+			rows = append(rows, row)
+		}
+		tui.Table(headers, rows)
+		if active == nil {
+			tui.ShowWarning("The project has no active deployment")
+			return
+		}
+		fmt.Println(tui.Muted("Active deployment " + active.ID))
+		// the defaults of the cloud are only known for the active deployment so only the set values are compared
+		for _, setting := range [][2]*int{{scale.Min, activeScale.Min}, {scale.Max, activeScale.Max}, {scale.Concurrency, activeScale.Concurrency}} {
+			if setting[0] != nil && (setting[1] == nil || *setting[0] != *setting[1]) {
+				fmt.Printf("The project file differs from the active deployment. Run %s to apply it.\n", tui.Command("deploy"))
+				break
+			}
+		}
+	},
+}
+
+var projectLicensesCmd = &cobra.Command{
+	Use:   "licenses",
+	Short: "Check the licenses of the dependencies",
+	Long: `List the licenses of the installed dependencies of the project and check them
+against the license policy in the project file.
+
+The policy is the licenses section of agentuity.yaml. The licenses are SPDX
+identifiers which can contain wildcards:
+
+  licenses:
+    mode: fail          # warn (the default) or fail deploys and imports
+    allow: [MIT, Apache-2.0, BSD-*, ISC]
+    deny: [GPL-*, AGPL-*]
+    ignore: [some-package]
+
+The same policy is checked automatically by deploy (for the dependencies) and by
+agent import (for the license of the repository). This command exits with a
+non-zero status when a dependency violates the policy so it can be used in CI.
+
+Flags:
+  --dir       The project directory
+  --format    The format to use for the output (text or json)
+
+Examples:
+  agentuity project licenses
+  agentuity project licenses --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		format, _ := cmd.Flags().GetString("format")
+		policy := loadLicensePolicy(dir)
+
+		components, err := sbom.Collect(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to collect the dependencies of the project")).ShowErrorAndExit()
+		}
+		var violations []sbom.LicenseViolation
+		if policy != nil {
+			violations = policy.Check(components)
+		}
+		denied := make(map[string]string)
+		for _, v := range violations {
+			denied[v.Component.Ecosystem+":"+v.Component.Name+"@"+v.Component.Version] = v.Reason
+		}
+
+		if format == "json" {
+			type packageLicense struct {
+				sbom.Component
+				Allowed bool `json:"allowed"`
+			}
+			packages := make([]packageLicense, 0, len(components))
+			for _, c := range components {
+				_, bad := denied[c.Ecosystem+":"+c.Name+"@"+c.Version]
+				packages = append(packages, packageLicense{Component: c, Allowed: !bad})
+			}
+			json.NewEncoder(os.Stdout).Encode(map[string]any{"policy": policy, "packages": packages, "violations": append([]sbom.LicenseViolation{}, violations...)})
+			if len(violations) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(components) == 0 {
+			tui.ShowWarning("No installed dependencies were found. Install the dependencies (or run agentuity bundle) first.")
+			return
+		}
+		for _, c := range components {
+			license := c.License
+			if license == "" {
+				license = "unknown"
+			}
+			name := tui.PadRight(c.Name+"@"+c.Version, 40, " ")
+			if reason, ok := denied[c.Ecosystem+":"+c.Name+"@"+c.Version]; ok {
+				fmt.Printf("%s %s %s\n", tui.Warning(name), license, tui.Warning("("+reason+")"))
+			} else {
+				fmt.Printf("%s %s\n", name, tui.Muted(license))
+			}
+		}
+		fmt.Println()
+		switch {
+		case policy == nil:
+			tui.ShowSuccess("Found %s. Add a licenses section to the project file to check them.", util.Pluralize(len(components), "dependency", "dependencies"))
+		case len(violations) == 0:
+			tui.ShowSuccess("The licenses of %s are allowed by the license policy", util.Pluralize(len(components), "dependency", "dependencies"))
+		default:
+			tui.ShowError("%s not allowed by the license policy", util.Pluralize(len(violations), "dependency is", "dependencies are"))
+			os.Exit(1)
+		}
+	},
+}
+
+var projectSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the project file",
+	Long: `Print the JSON Schema of the agentuity.yaml project file.
+
+The schema matches this version of the CLI. Editors using the YAML language
+server read the schema from the modeline at the top of the project file which
+is added when the project is created. Use --output to write the schema to a file
+for editors which can't download it, or --url to print the published schema URL.
+
+Flags:
+  --output    Write the schema to the file instead of stdout
+  --url       Print the URL of the published schema
+
+Examples:
+  agentuity project schema
+  agentuity project schema --output .vscode/agentuity.schema.json
+  agentuity project schema --url`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if url, _ := cmd.Flags().GetBool("url"); url {
+			fmt.Println(project.SchemaURL)
+			return
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			os.Stdout.Write(project.Schema)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			errsystem.New(errsystem.ErrCreateDirectory, err, errsystem.WithContextMessage("Failed to create the schema directory")).ShowErrorAndExit()
+		}
+		if err := os.WriteFile(output, project.Schema, 0644); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to write the schema")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Wrote the project schema to %s", output)
+	},
+}
+
+// projectAgentSpec returns the webhooks of the Agents of the project with the authentication of
+// each Agent in Agentuity Cloud and their payload schemas
+func projectAgentSpec(ctx context.Context, logger logger.Logger, theproject project.ProjectContext) openapi.ProjectSpec {
+	remoteAgents, err := getAgentList(logger, theproject.APIURL, theproject.Token, theproject)
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to fetch the Agents")).ShowErrorAndExit()
+	}
+	remote := make(map[string]agent.Agent)
+	for _, a := range remoteAgents {
+		remote[a.ID] = a
+	}
+	validators := loadAgentValidators(logger, theproject.Dir)
+
+	spec := openapi.ProjectSpec{
+		Title:       theproject.Project.Name,
+		Description: theproject.Project.Description,
+		ServerURL:   theproject.TransportURL,
+	}
+	tui.ShowSpinner("Fetching the Agent authentication ...", func() {
+		for _, a := range theproject.Project.Agents {
+			endpoint := openapi.AgentEndpoint{ID: a.ID, Name: a.Name, Description: a.Description, AuthType: "project"}
+			if ra, ok := remote[a.ID]; ok {
+				endpoint.Tags = ra.Tags
+				route := "webhook"
+				if len(ra.Types) > 0 {
+					route = ra.Types[0]
+				}
+				if endpoint.AuthType, err = agent.GetAuthType(ctx, logger, theproject.APIURL, theproject.Token, a.ID, route); err != nil {
+					return
+				}
+			} else {
+				logger.Debug("agent %s is not in the cloud project, using the project authentication", a.Name)
+			}
+			if validator, ok := validators[a.ID]; ok {
+				if endpoint.Schema, err = validator.JSON(); err != nil {
+					err = fmt.Errorf("failed to encode the payload schema of agent %s: %w", a.Name, err)
+					return
+				}
+			}
+			spec.Agents = append(spec.Agents, endpoint)
+		}
+	})
+	if err != nil {
+		errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the Agent authentication")).ShowErrorAndExit()
+	}
+	return spec
+}
+
+var projectOpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Generate an OpenAPI document of the Agent webhooks",
+	Long: `Generate an OpenAPI 3.1 document describing the webhook of each Agent of the project.
+
+Each Agent is a POST operation on its webhook path. The security of the
+operation is derived from the authentication of the Agent in Agentuity Cloud:
+the Agent API key and the project API key are bearer tokens and the Agents
+without authentication have no security. The payload schemas declared in the
+agents section of agentuity.yaml are the request bodies of their Agents.
+
+The document can be used to generate clients of the Agents or be imported in
+an API gateway. The format is YAML unless --format is json or the output file
+has a .json extension.
+
+Flags:
+  --dir       The project directory
+  --output    Write the document to the file instead of stdout
+  --format    The format of the document (yaml or json)
+
+Examples:
+  agentuity project openapi
+  agentuity project openapi --output openapi.yaml
+  agentuity project openapi --format json > openapi.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		output, _ := cmd.Flags().GetString("output")
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = "yaml"
+			if strings.EqualFold(filepath.Ext(output), ".json") {
+				format = "json"
+			}
+		}
+		if format != "yaml" && format != "json" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid format %s", format),
+				errsystem.WithUserMessage("The format must be either yaml or json")).ShowErrorAndExit()
+		}
+
+		spec := projectAgentSpec(ctx, logger, theproject)
+
+		doc, err := openapi.GenerateProjectSpec(spec)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the OpenAPI document")).ShowErrorAndExit()
+		}
+		var buf []byte
+		if format == "json" {
+			buf, err = json.MarshalIndent(doc, "", "  ")
+			buf = append(buf, '\n')
+		} else {
+			buf, err = yaml.Marshal(doc)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to encode the OpenAPI document")).ShowErrorAndExit()
+		}
+		if output == "" {
+			os.Stdout.Write(buf)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			errsystem.New(errsystem.ErrCreateDirectory, err, errsystem.WithContextMessage("Failed to create the output directory")).ShowErrorAndExit()
+		}
+		if err := os.WriteFile(output, buf, 0644); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to write the OpenAPI document")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Wrote the OpenAPI document to %s", output)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(projectCmd)
 	rootCmd.AddCommand(projectNewCmd)
@@ -983,6 +2086,7 @@ func init() {
 	projectCmd.AddCommand(projectListCmd)
 	projectCmd.AddCommand(projectDeleteCmd)
 	projectCmd.AddCommand(projectImportCmd)
+	projectCmd.AddCommand(projectSetCmd)
 
 	for _, cmd := range []*cobra.Command{projectNewCmd, projectImportCmd} {
 		cmd.Flags().StringP("dir", "d", "", "The directory for the project")
@@ -1005,12 +2109,58 @@ func init() {
 	projectImportCmd.Flags().String("name", "", "The name of the project to import")
 	projectImportCmd.Flags().String("description", "", "The description of the project to import")
 	projectImportCmd.Flags().Bool("force", false, "Force the processing of environment files")
-
-	// hidden because they must be all passed together and we havent documented that
-	projectImportCmd.Flags().MarkHidden("name")
-	projectImportCmd.Flags().MarkHidden("description")
-	projectImportCmd.Flags().MarkHidden("org-id")
+	projectImportCmd.Flags().String("from-github", "", "The GitHub repository of the project to clone and import")
+	projectImportCmd.Flags().String("auth", "project", "The authentication type for the agents with --from-github (project, bearer, or none)")
+	projectImportCmd.Flags().Bool("no-install", false, "Don't install the dependencies of the project imported with --from-github")
+	projectImportCmd.Flags().Bool("deploy", false, "Deploy the project imported with --from-github")
+
+	projectSetCmd.Flags().StringP("dir", "d", "", "The project directory")
+	projectSetCmd.Flags().String("name", "", "The new name of the project")
+	projectSetCmd.Flags().String("description", "", "The new description of the project")
+	projectSetCmd.Flags().StringSlice("tags", nil, "The tags of the project, replacing the current tags")
+
+	projectCmd.AddCommand(projectBackupCmd)
+	projectBackupCmd.Flags().StringP("dir", "d", "", "The project directory")
+	projectBackupCmd.Flags().String("project", "", "The project to back up instead of the project in the directory")
+	projectBackupCmd.Flags().StringP("output", "o", "", "The file to write the backup to (defaults to <projectId>-backup.json)")
+	projectBackupCmd.Flags().Bool("encrypt", false, "Include the environment values encrypted with the organization key")
+
+	projectCmd.AddCommand(projectRestoreCmd)
+	projectRestoreCmd.Flags().String("org-id", "", "The organization to create the new project in")
+	projectRestoreCmd.Flags().String("project", "", "The existing project to restore into")
+	projectRestoreCmd.Flags().String("name", "", "The name of the new project")
+	projectRestoreCmd.Flags().String("private-key", "", "The private key file to decrypt the environment values with")
+	projectRestoreCmd.Flags().Bool("dry-run", false, "Show what would be restored without changing anything")
 
 	projectDeleteCmd.Flags().String("org-id", "", "Only delete the projects in the specified organization")
+
+	projectCmd.AddCommand(projectValidateCmd)
+	projectValidateCmd.Flags().StringP("dir", "d", "", "The project directory")
+	projectValidateCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+
+	projectCmd.AddCommand(projectScaleCmd)
+	projectScaleCmd.AddCommand(projectScaleSetCmd)
+	projectScaleCmd.AddCommand(projectScaleGetCmd)
+	for _, cmd := range []*cobra.Command{projectScaleSetCmd, projectScaleGetCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+	}
+	projectScaleSetCmd.Flags().Int("min", 0, "The number of instances always running, 0 to scale to zero when idle")
+	projectScaleSetCmd.Flags().Int("max", 0, "The maximum number of instances")
+	projectScaleSetCmd.Flags().Int("concurrency", 0, "The number of requests an instance handles at once before more instances are started")
+	projectScaleSetCmd.Flags().Bool("reset", false, "Remove the settings to use the cloud defaults")
+	projectScaleGetCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+
+	projectCmd.AddCommand(projectLicensesCmd)
+	projectLicensesCmd.Flags().StringP("dir", "d", "", "The project directory")
+	projectLicensesCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+
+	projectCmd.AddCommand(projectSchemaCmd)
+	projectSchemaCmd.Flags().StringP("output", "o", "", "Write the schema to the file instead of stdout")
+	projectSchemaCmd.Flags().Bool("url", false, "Print the URL of the published schema")
+
+	projectCmd.AddCommand(projectOpenAPICmd)
+	projectOpenAPICmd.Flags().StringP("dir", "d", "", "The project directory")
+	projectOpenAPICmd.Flags().StringP("output", "o", "", "Write the document to the file instead of stdout")
+	projectOpenAPICmd.Flags().String("format", "", "The format of the document, either 'yaml' or 'json' (defaults to the extension of --output or yaml)")
 	projectDeleteCmd.Flags().Bool("force", false, "Force the removal without confirmation")
 }
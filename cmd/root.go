@@ -69,11 +69,11 @@ Dashboard:      %s`,
 	fmt.Println()
 
 	// Group commands by category
-	coreCommands := []string{"dev", "create", "deploy", "rollback"}
-	projectCommands := []string{"project", "agent", "env", "logs"}
-	infraCommands := []string{"cluster", "machine"}
-	authCommands := []string{"auth", "login", "logout", "apikey"}
-	toolCommands := []string{"mcp", "upgrade", "version"}
+	coreCommands := []string{"onboard", "dev", "create", "deploy", "rollback"}
+	projectCommands := []string{"project", "agent", "jobs", "env", "logs", "otel", "gateway", "policy", "limits"}
+	infraCommands := []string{"cluster", "machine", "service"}
+	authCommands := []string{"auth", "login", "logout", "whoami", "apikey", "keys"}
+	toolCommands := []string{"alias", "clean", "grep", "ide", "mcp", "template", "upgrade", "version"}
 
 	var helpSectionCount int
 
@@ -176,9 +176,22 @@ Dashboard:      %s`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// the error is shown here so that it can be written as JSON instead
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	if args, ok, err := expandAlias(os.Args[1:]); err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+	} else if ok {
+		rootCmd.SetArgs(args)
+	}
+	cmd, err := rootCmd.ExecuteC()
 	if err != nil {
-		os.Exit(1)
+		// the commands show their own errors so this is an invalid command, flag or argument
+		if !errsystem.JSONErrors() {
+			cmd.PrintErrln(cmd.ErrPrefix(), err.Error())
+			cmd.Println(cmd.UsageString())
+		}
+		errsystem.ShowUsageErrorAndExit(err)
 	}
 }
 
@@ -217,6 +230,13 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/agentuity/config.yaml)")
 	rootCmd.PersistentFlags().String("log-level", "info", "The log level to use")
+	rootCmd.PersistentFlags().String("log-file", "", "Write structured (JSON) logs to a rotating file (default is "+util.DefaultLogFile+" when no value is provided)")
+	rootCmd.PersistentFlags().Lookup("log-file").NoOptDefVal = util.DefaultLogFile
+	rootCmd.PersistentFlags().String("log-file-level", "debug", "The log level to use for the log file")
+
+	rootCmd.PersistentFlags().String("error-format", "text", "The format of the errors, either 'text' or 'json' (written to stderr) for scripts and CI")
+	viper.BindPFlag(errsystem.ErrorFormatKey, rootCmd.PersistentFlags().Lookup("error-format"))
+	viper.BindEnv(errsystem.ErrorFormatKey, "AGENTUITY_ERROR_FORMAT")
 
 	rootCmd.PersistentFlags().String("app-url", "https://app.agentuity.com", "The base url of the Agentuity Console app")
 	rootCmd.PersistentFlags().MarkHidden("app-url")
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -9,169 +13,334 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/agentuity/cli/internal/bundler"
 	"github.com/agentuity/cli/internal/dev"
+	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/gravity"
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/run"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/sys"
+	"github.com/agentuity/go-common/tui"
 	"github.com/google/uuid"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
 var runCmd = &cobra.Command{
-	Hidden: true, // not working yet
-	Use:    "run",
-	Args:   cobra.NoArgs,
-	Short:  "Run the production server",
-	Long: `Run the production server for connecting to the Agentuity Cloud.
+	Use:   "run <agent>",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Run an agent once locally",
+	Long: `Run an agent of the project once locally and print its response.
 
-This command starts a local production server that connects to the Agentuity Cloud
-for live routing of your agents to the machine running the server.
+This command builds the project, starts the project server on its own (without
+the dev mode server or a connection to the Agentuity Cloud), sends a single
+request to the agent, prints the response to stdout and exits. The output of
+the project server is written to stderr so the response can be piped to other
+commands. The command fails when the agent responds with an error status.
+
+Arguments:
+  <agent>    The name or ID of the agent to run
+
+Flags:
+  --payload        The payload to send to the agent (use - to read it from stdin)
+  --content-type   The content type of the payload, detected if not provided
+  --timeout        How long to wait for the agent to respond
+  --no-build       Run the last build of the project without building it
 
 Examples:
-  agentuity run`,
+  agentuity run my-agent --payload '{"hello": "world"}'
+  echo "hello" | agentuity run agent_123 --payload - --content-type text/plain
+  agentuity run my-agent --payload '{"hello": "world"}' --no-build | jq .`,
 	Run: func(cmd *cobra.Command, args []string) {
-		log := env.NewLogger(cmd)
+		if len(args) == 0 {
+			// without an agent the production server is run which is not working yet
+			runProductionServer(cmd)
+			return
+		}
+		runAgentOnce(cmd, args[0])
+	},
+}
 
-		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-		defer cancel()
+// runProductionServer runs the production server for connecting to the Agentuity Cloud for live
+// routing of the agents to the machine running the server
+func runProductionServer(cmd *cobra.Command) {
+	log := util.NewLogger(cmd)
 
-		theproject := project.EnsureProject(ctx, cmd)
-		dir := theproject.Dir
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-		buildFolder := filepath.Join(dir, ".agentuity")
-		if !sys.Exists(buildFolder) {
-			log.Fatal("missing the build folder at %s. make sure you have run agentuity bundle --production", buildFolder)
-		}
+	theproject := project.EnsureProject(ctx, cmd)
+	dir := theproject.Dir
+
+	buildFolder := filepath.Join(dir, ".agentuity")
+	if !sys.Exists(buildFolder) {
+		log.Fatal("missing the build folder at %s. make sure you have run agentuity bundle --production", buildFolder)
+	}
+
+	var err error
+	agentPort, _ := cmd.Flags().GetInt("port")
+	agentPort, err = dev.FindAvailablePort(theproject, agentPort)
+	if err != nil {
+		log.Fatal("failed to find available port: %s", err)
+	}
+	proxyPort, err := dev.FindAvailableOpenPort()
+	if err != nil {
+		log.Fatal("failed to find available port: %s", err)
+	}
 
-		var err error
-		agentPort, _ := cmd.Flags().GetInt("port")
-		agentPort, err = dev.FindAvailablePort(theproject, agentPort)
+	var envfile []env.EnvLineComment
+	if sys.Exists(filepath.Join(dir, ".env.production")) {
+		envfile, err = env.ParseEnvFileWithComments(filepath.Join(dir, ".env.production"))
 		if err != nil {
-			log.Fatal("failed to find available port: %s", err)
+			log.Fatal("failed to parse env file: %s", err)
 		}
-		proxyPort, err := dev.FindAvailableOpenPort()
+	} else if sys.Exists(filepath.Join(dir, ".env")) {
+		envfile, err = env.ParseEnvFileWithComments(filepath.Join(dir, ".env"))
 		if err != nil {
-			log.Fatal("failed to find available port: %s", err)
+			log.Fatal("failed to parse env file: %s", err)
 		}
+	}
 
-		var envfile []env.EnvLineComment
-		if sys.Exists(filepath.Join(dir, ".env.production")) {
-			envfile, err = env.ParseEnvFileWithComments(filepath.Join(dir, ".env.production"))
-			if err != nil {
-				log.Fatal("failed to parse env file: %s", err)
-			}
-		} else if sys.Exists(filepath.Join(dir, ".env")) {
-			envfile, err = env.ParseEnvFileWithComments(filepath.Join(dir, ".env"))
-			if err != nil {
-				log.Fatal("failed to parse env file: %s", err)
+	sdkKey := os.Getenv("AGENTUITY_SDK_KEY")
+	if sdkKey == "" {
+		for _, line := range envfile {
+			if line.Key == "AGENTUITY_SDK_KEY" {
+				sdkKey = line.Val
+				break
 			}
 		}
+	}
+	if sdkKey == "" {
+		log.Fatal("missing AGENTUITY_SDK_KEY environment variable")
+	}
 
-		sdkKey := os.Getenv("AGENTUITY_SDK_KEY")
-		if sdkKey == "" {
-			for _, line := range envfile {
-				if line.Key == "AGENTUITY_SDK_KEY" {
-					sdkKey = line.Val
-					break
-				}
-			}
-		}
-		if sdkKey == "" {
-			log.Fatal("missing AGENTUITY_SDK_KEY environment variable")
-		}
+	for _, line := range envfile {
+		os.Setenv(line.Key, line.Val)
+	}
 
-		for _, line := range envfile {
-			os.Setenv(line.Key, line.Val)
-		}
+	gravityurl, _ := cmd.Flags().GetString("gravity-url")
+	transporturl, _ := cmd.Flags().GetString("transport-url")
 
-		gravityurl, _ := cmd.Flags().GetString("gravity-url")
-		transporturl, _ := cmd.Flags().GetString("transport-url")
+	var instanceId string
 
-		var instanceId string
+	if sys.Exists("/etc/machine-id") {
+		val, _ := os.ReadFile("/etc/machine-id")
+		instanceId = strings.TrimSpace(string(val))
+	}
 
-		if sys.Exists("/etc/machine-id") {
-			val, _ := os.ReadFile("/etc/machine-id")
-			instanceId = strings.TrimSpace(string(val))
-		}
+	if instanceId == "" {
+		instanceId = uuid.New().String()
+	}
 
-		if instanceId == "" {
-			instanceId = uuid.New().String()
-		}
+	client := gravity.New(gravity.Config{
+		Context:        ctx,
+		Logger:         log,
+		Version:        Version,
+		Project:        theproject,
+		URL:            gravityurl,
+		SDKKey:         sdkKey,
+		EndpointID:     instanceId,
+		ClientName:     "cli/run",
+		ProxyPort:      uint(proxyPort),
+		AgentPort:      uint(agentPort),
+		Ephemeral:      true,
+		DynamicProject: true,
+	})
 
-		client := gravity.New(gravity.Config{
-			Context:        ctx,
-			Logger:         log,
-			Version:        Version,
-			Project:        theproject,
-			URL:            gravityurl,
-			SDKKey:         sdkKey,
-			EndpointID:     instanceId,
-			ClientName:     "cli/run",
-			ProxyPort:      uint(proxyPort),
-			AgentPort:      uint(agentPort),
-			Ephemeral:      true,
-			DynamicProject: true,
-		})
-
-		if err := client.Start(); err != nil {
-			log.Fatal("failed to start client: %s", err)
-		}
+	if err := client.Start(); err != nil {
+		log.Fatal("failed to start client: %s", err)
+	}
+
+	defer client.Close()
+
+	thecmd, err := run.CreateRunProjectCmd(run.Config{
+		WorkingDir:      dir,
+		Project:         theproject,
+		OrgId:           client.OrgID(),
+		Context:         ctx,
+		Logger:          log,
+		TelemetryURL:    client.TelemetryURL(),
+		TelemetryAPIKey: client.TelemetryAPIKey(),
+		APIURL:          client.APIURL(),
+		AgentPort:       agentPort,
+		TransportURL:    transporturl,
+	})
+
+	if err != nil {
+		log.Fatal("failed to create run project command: %s", err)
+	}
+
+	if err := thecmd.Start(); err != nil {
+		log.Fatal("failed to start run project command: %s", err)
+	}
 
-		defer client.Close()
-
-		thecmd, err := run.CreateRunProjectCmd(run.Config{
-			WorkingDir:      dir,
-			Project:         theproject,
-			OrgId:           client.OrgID(),
-			Context:         ctx,
-			Logger:          log,
-			TelemetryURL:    client.TelemetryURL(),
-			TelemetryAPIKey: client.TelemetryAPIKey(),
-			APIURL:          client.APIURL(),
-			AgentPort:       agentPort,
-			TransportURL:    transporturl,
-		})
+	<-ctx.Done()
 
+	if thecmd.Process != nil {
+		log.Trace("sending SIGINT to agent process")
+		thecmd.Process.Signal(syscall.SIGINT)
+	}
+
+	wctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		thecmd.Wait()
+		log.Trace("agent exited")
+	}()
+
+	select {
+	case <-wctx.Done():
+	case <-time.After(30 * time.Second):
+		log.Trace("agent stop timed out")
+		util.ProcessKill(thecmd)
+	}
+}
+
+// runAgentOnce builds the project, starts the project server without the dev mode server, invokes
+// the agent once and exits with the response of the agent
+func runAgentOnce(cmd *cobra.Command, name string) {
+	log := util.NewLogger(cmd)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	theproject := project.EnsureProject(ctx, cmd)
+	dir := theproject.Dir
+
+	var agentID string
+	var names []string
+	for _, agent := range theproject.Project.Agents {
+		if agent.ID == name || strings.EqualFold(agent.Name, name) {
+			agentID = agent.ID
+			break
+		}
+		names = append(names, agent.Name)
+	}
+	if agentID == "" {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("agent %s not found", name),
+			errsystem.WithUserMessage("Agent %s was not found in the project. The agents are: %s", name, strings.Join(names, ", "))).ShowErrorAndExit()
+	}
+
+	payload, _ := cmd.Flags().GetString("payload")
+	body := []byte(payload)
+	if payload == "-" {
+		buf, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			log.Fatal("failed to create run project command: %s", err)
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithContextMessage("Failed to read the payload from stdin")).ShowErrorAndExit()
 		}
+		body = buf
+	}
+	if errs := validateAgentPayload(loadAgentValidators(log, dir), agentID, body); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, tui.Warning("✕ ")+e)
+		}
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid payload"),
+			errsystem.WithUserMessage("The payload does not match the schema for Agent %s", agentID)).ShowErrorAndExit()
+	}
+	contentType, _ := cmd.Flags().GetString("content-type")
+	if contentType == "" {
+		if json.Valid(body) {
+			contentType = "application/json"
+		} else {
+			contentType = "text/plain"
+		}
+	}
 
-		if err := thecmd.Start(); err != nil {
-			log.Fatal("failed to start run project command: %s", err)
+	if noBuild, _ := cmd.Flags().GetBool("no-build"); !noBuild {
+		started := time.Now()
+		if err := bundler.Bundle(bundler.BundleContext{
+			Context:    ctx,
+			Logger:     log,
+			ProjectDir: dir,
+			Production: false,
+			DevMode:    true,
+			Writer:     os.Stderr,
+		}); err != nil {
+			if err == bundler.ErrBuildFailed {
+				os.Exit(1)
+			}
+			exitOnSDKCompatibilityError(err)
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to bundle project: %s", err))).ShowErrorAndExit()
 		}
+		log.Debug("built in %s", time.Since(started))
+	}
 
-		<-ctx.Done()
+	port, _ := cmd.Flags().GetInt("port")
+	port, err := dev.FindAvailablePort(theproject, port)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to find an available port")).ShowErrorAndExit()
+	}
 
-		if thecmd.Process != nil {
-			log.Trace("sending SIGINT to agent process")
-			thecmd.Process.Signal(syscall.SIGINT)
-		}
+	processCtx, cancelProcess := context.WithCancel(ctx)
+	defer cancelProcess()
+	// the output of the project server goes to stderr so that stdout only has the response
+	projectServerCmd, err := dev.CreateStandaloneProjectCmd(processCtx, theproject, dir, "", port, os.Stderr, os.Stderr)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
+	}
+	if err := projectServerCmd.Start(); err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to start project: %s", err))).ShowErrorAndExit()
+	}
+	log.Debug("started project server (pid: %d) on port %d", projectServerCmd.Process.Pid, port)
+	exited := make(chan struct{})
+	go func() {
+		projectServerCmd.Wait()
+		close(exited)
+	}()
 
-		wctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	invoke := func() (*dev.AgentResult, error) {
+		url := fmt.Sprintf("http://%s:%d", dev.DefaultHost, port)
+		if err := dev.WaitForProject(ctx, url, 30*time.Second, exited); err != nil {
+			return nil, err
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		invokeCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
-
-		go func() {
-			defer cancel()
-			thecmd.Wait()
-			log.Trace("agent exited")
-		}()
-
-		select {
-		case <-wctx.Done():
-		case <-time.After(30 * time.Second):
-			log.Trace("agent stop timed out")
-			util.ProcessKill(thecmd)
+		started := time.Now()
+		result, err := dev.InvokeAgent(invokeCtx, url, agentID, contentType, body)
+		if err != nil {
+			if errors.Is(invokeCtx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("the agent didn't respond within %s", timeout)
+			}
+			return nil, err
 		}
-	},
+		log.Debug("agent %s responded with status %d in %s", agentID, result.Status, time.Since(started))
+		return result, nil
+	}
+	result, err := invoke()
+
+	dev.KillProjectServer(log, projectServerCmd, projectServerCmd.Process.Pid)
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		log.Debug("project server didn't stop in time")
+	}
+
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to run Agent %s: %s", name, err)).ShowErrorAndExit()
+	}
+	os.Stdout.Write(result.Body)
+	if len(result.Body) > 0 && result.Body[len(result.Body)-1] != '\n' && isatty.IsTerminal(os.Stdout.Fd()) {
+		fmt.Println()
+	}
+	if result.Status > 299 {
+		fmt.Fprintln(os.Stderr, tui.Warning(fmt.Sprintf("✕ Agent %s responded with status %d", name, result.Status)))
+		os.Exit(1)
+	}
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringP("dir", "d", ".", "The directory to run the server in")
 	runCmd.Flags().Int("port", 0, "The port to run the server on (uses project default if not provided)")
+	runCmd.Flags().String("payload", "", "The payload to send to the agent (use - to read it from stdin)")
+	runCmd.Flags().String("content-type", "", "The content type of the payload, detected if not provided")
+	runCmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the agent to respond")
+	runCmd.Flags().Bool("no-build", false, "Run the last build of the project without building it")
 	runCmd.Flags().String("gravity-url", "grpc://gravity.agentuity.com", "The URL to the gravity server")
 	runCmd.Flags().String("transport-url", "https://agentuity.ai", "The URL to the transport server")
 	runCmd.Flags().MarkHidden("gravity-url")
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/sbom"
+	"github.com/agentuity/cli/internal/util"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Args:  cobra.NoArgs,
+	Short: "Software bill of materials commands",
+	Long: `Software bill of materials (SBOM) commands.
+
+An SBOM lists the JavaScript and Python packages which are part of a deployment with
+their versions and licenses. Every deployment includes a CycloneDX SBOM in
+.agentuity/sbom.cdx.json unless it is deployed with --no-sbom.
+
+Examples:
+  agentuity sbom generate
+  agentuity sbom generate --format spdx --output sbom.spdx.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// generateSBOM returns the SBOM of the installed dependencies of the project in dir
+func generateSBOM(dir string, name string) (sbom.Document, error) {
+	components, err := sbom.Collect(dir)
+	if err != nil {
+		return sbom.Document{}, err
+	}
+	return sbom.Document{Name: name, ToolVersion: Version, Components: components}, nil
+}
+
+// loadLicensePolicy returns the license policy of the project in dir or nil when it doesn't have one
+func loadLicensePolicy(dir string) *sbom.LicensePolicy {
+	policy, err := sbom.LoadLicensePolicy(cproject.GetProjectFilename(dir))
+	if err != nil {
+		errsystem.New(errsystem.ErrReadConfigurationFile, err, errsystem.WithContextMessage("Failed to load the license policy")).ShowErrorAndExit()
+	}
+	return policy
+}
+
+// showLicenseViolations shows the violations of the license policy as a warning unless the
+// policy fails on violations, in which case it returns the violations as an error for the
+// caller to show
+func showLicenseViolations(policy *sbom.LicensePolicy, violations []sbom.LicenseViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, v := range violations {
+		name := v.Component.Name
+		if v.Component.Version != "" {
+			name += "@" + v.Component.Version
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s", tui.PadRight(name, 40, " "), v.Reason))
+	}
+	message := fmt.Sprintf("The license policy in the project file doesn't allow:\n\n%s", strings.Join(lines, "\n"))
+	if policy.Fail() {
+		return fmt.Errorf("%s", message)
+	}
+	tui.ShowWarning("%s", message)
+	return nil
+}
+
+var sbomGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Args:  cobra.NoArgs,
+	Short: "Generate the SBOM of the project",
+	Long: `Generate a software bill of materials (SBOM) of the project.
+
+The SBOM covers the installed packages which are part of the deployment: the
+dependencies (but not the development dependencies) in node_modules or the Python
+virtual environment and the native packages installed by the bundler. Install the
+dependencies (or run agentuity bundle) first.
+
+Flags:
+  --dir       The project directory
+  --format    The SBOM format (cyclonedx or spdx)
+  --output    The file to write the SBOM to instead of stdout
+
+Examples:
+  agentuity sbom generate
+  agentuity sbom generate --format spdx
+  agentuity sbom generate --output sbom.cdx.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		dir := project.ResolveProjectDir(logger, cmd, true)
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		if !slices.Contains(sbom.Formats, format) {
+			errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("invalid format: %s", format), errsystem.WithUserMessage("The format must be one of: %s", strings.Join(sbom.Formats, ", "))).ShowErrorAndExit()
+		}
+		theproject := project.NewProject()
+		if err := theproject.Load(dir); err != nil && err != cproject.ErrProjectMissingProjectId {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load project")).ShowErrorAndExit()
+		}
+
+		doc, err := generateSBOM(dir, theproject.Name)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to collect the dependencies of the project")).ShowErrorAndExit()
+		}
+		if len(doc.Components) == 0 {
+			logger.Warn("no installed dependencies were found in %s", dir)
+		}
+		buf, err := doc.Marshal(format)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the SBOM")).ShowErrorAndExit()
+		}
+		if output == "" {
+			fmt.Println(string(buf))
+			return
+		}
+		if err := os.WriteFile(output, append(buf, '\n'), 0644); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err, errsystem.WithContextMessage("Failed to write the SBOM")).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Wrote the SBOM with %d packages to %s", len(doc.Components), output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sbomCmd)
+	sbomCmd.AddCommand(sbomGenerateCmd)
+
+	sbomGenerateCmd.Flags().StringP("dir", "d", "", "The project directory")
+	sbomGenerateCmd.Flags().String("format", sbom.FormatCycloneDX, "The SBOM format (cyclonedx or spdx)")
+	sbomGenerateCmd.Flags().StringP("output", "o", "", "The file to write the SBOM to instead of stdout")
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/service"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run the development server of a project as a service",
+	Long: `Run the development server of a project as a service which starts when the machine
+boots, for teams sharing persistent development agents on a server.
+
+The service is managed by systemd or init.d on Linux and by launchd on macOS.
+
+Examples:
+  agentuity service install
+  agentuity service status
+  agentuity service uninstall`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// serviceKind returns the service manager of the --manager flag or of this machine
+func serviceKind(cmd *cobra.Command) service.Kind {
+	if name, _ := cmd.Flags().GetString("manager"); name != "" {
+		kind, err := service.ParseKind(name)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s", err)).ShowErrorAndExit()
+		}
+		return kind
+	}
+	kind, err := service.Detect()
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to detect the service manager: %s", err)).ShowErrorAndExit()
+	}
+	return kind
+}
+
+// serviceName returns the name of the --name flag or the name derived from the project
+func serviceName(name string, projectName string) string {
+	if name == "" {
+		name = "agentuity-" + strings.Trim(strings.ToLower(util.SafeProjectFilename(projectName, false)), "-")
+	}
+	if err := service.ValidateName(name); err != nil {
+		errsystem.New(errsystem.ErrInvalidArgumentProvided, err, errsystem.WithUserMessage("%s, use --name to choose another name", err)).ShowErrorAndExit()
+	}
+	return name
+}
+
+// loadServiceProject returns the directory and the name of the project of the service without
+// requiring a login
+func loadServiceProject(logger logger.Logger, cmd *cobra.Command) (string, string) {
+	dir := project.ResolveProjectDir(logger, cmd, true)
+	theproject := project.NewProject()
+	if err := theproject.Load(dir); err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithContextMessage("Error loading project from disk")).ShowErrorAndExit()
+	}
+	return dir, theproject.Name
+}
+
+// serviceLogsHint returns the command to follow the output of the service
+func serviceLogsHint(kind service.Kind, name string, logFile string) string {
+	if kind == service.Systemd {
+		return "journalctl -u " + name + " -f"
+	}
+	return "tail -f " + logFile
+}
+
+// runServiceCommands runs the commands to manage the service with sudo unless the CLI is running
+// as root. With keepGoing, a failed command is reported and the next ones are still run.
+func runServiceCommands(logger logger.Logger, commands [][]string, keepGoing bool) {
+	for _, args := range commands {
+		if os.Geteuid() != 0 {
+			args = append([]string{"sudo"}, args...)
+		}
+		logger.Debug("running %s", strings.Join(args, " "))
+		c := exec.Command(args[0], args[1:]...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			if keepGoing {
+				tui.ShowWarning("%s failed: %s", strings.Join(args, " "), err)
+				continue
+			}
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("Failed to run %s: %s", strings.Join(args, " "), err)).ShowErrorAndExit()
+		}
+	}
+}
+
+// printServiceCommands prints the commands which will be run to manage the service
+func printServiceCommands(commands [][]string) {
+	for _, args := range commands {
+		fmt.Println("  " + tui.Muted(strings.Join(args, " ")))
+	}
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install [-- dev flags]",
+	Args:  cobra.ArbitraryArgs,
+	Short: "Install the development server of the project as a service",
+	Long: `Install the development server of the project as a service which runs
+agentuity dev --headless in the project directory when the machine boots, and start it.
+
+The service runs as your user with your login and profile so login before installing it.
+The session is refreshed by the service until the refresh is rejected, in which case the
+service stops and you need to login again. The flags after -- are passed to the
+development server.
+
+The output goes to the journal with systemd and to .agentuity/service.log in the project
+directory with launchd and init.d. Installing the service requires root so the commands
+are run with sudo, which may ask for your password.
+
+Flags:
+  --name      The name of the service (default agentuity-<project name>)
+  --manager   The service manager: systemd, launchd or initd (default detected)
+  --dry-run   Show the service file and the commands without installing anything
+  --force     Replace an installed service and don't prompt for confirmation
+
+Examples:
+  agentuity service install
+  agentuity service install --dry-run
+  agentuity service install --name support-dev -- --port 3500 --host 0.0.0.0
+  agentuity service install --manager initd --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		nameFlag, _ := cmd.Flags().GetString("name")
+		kind := serviceKind(cmd)
+
+		theproject := project.EnsureProject(ctx, cmd)
+		if theproject.NewProject {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("project not imported"),
+				errsystem.WithUserMessage("The project must be imported before it can run as a service, run %s first", tui.Command("dev"))).ShowErrorAndExit()
+		}
+		name := serviceName(nameFlag, theproject.Project.Name)
+		dir, err := filepath.Abs(theproject.Dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to resolve the project directory")).ShowErrorAndExit()
+		}
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to find the path of the CLI")).ShowErrorAndExit()
+		}
+
+		// when run with sudo, the service runs as the user who ran sudo
+		current, err := user.Current()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to get the current user")).ShowErrorAndExit()
+		}
+		if sudoUser := os.Getenv("SUDO_USER"); os.Geteuid() == 0 && sudoUser != "" {
+			if u, err := user.Lookup(sudoUser); err == nil {
+				current = u
+			}
+		}
+
+		command := []string{executable, "dev", "--headless", "--dir", dir}
+		if cfg := viper.ConfigFileUsed(); cfg != "" {
+			command = append(command, "--config", cfg)
+		}
+		command = append(command, args...)
+		svc := service.Service{
+			Name:        name,
+			Description: fmt.Sprintf("Agentuity development server for %s", theproject.Project.Name),
+			Command:     command,
+			Dir:         dir,
+			User:        current.Username,
+			Env:         []string{"HOME=" + current.HomeDir, "PATH=" + os.Getenv("PATH")},
+			LogFile:     filepath.Join(dir, ".agentuity", "service.log"),
+		}
+		buf, err := svc.Render(kind)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to generate the service file")).ShowErrorAndExit()
+		}
+		path := service.Path(kind, name)
+
+		tmp, err := os.CreateTemp("", "agentuity-service-*")
+		if err != nil {
+			errsystem.New(errsystem.ErrCreateTemporaryFile, err, errsystem.WithContextMessage("Failed to create the service file")).ShowErrorAndExit()
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(buf); err != nil {
+			tmp.Close()
+			errsystem.New(errsystem.ErrCreateTemporaryFile, err, errsystem.WithContextMessage("Failed to write the service file")).ShowErrorAndExit()
+		}
+		tmp.Close()
+		commands := service.InstallCommands(kind, name, tmp.Name())
+		installed := util.Exists(path)
+		if installed {
+			if !force && !dryRun {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("service %s is already installed", name),
+					errsystem.WithUserMessage("The service %s is already installed at %s, use --force to replace it", name, path)).ShowErrorAndExit()
+			}
+			commands = append(service.UninstallCommands(kind, name), commands...)
+		}
+
+		if dryRun {
+			fmt.Println(tui.Bold(path))
+			fmt.Println()
+			fmt.Println(string(buf))
+			fmt.Println(tui.Bold("Commands"))
+			printServiceCommands(commands)
+			return
+		}
+		if !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to install the service")
+			}
+			fmt.Println("The service will be installed to " + tui.Bold(path) + " with the commands:")
+			printServiceCommands(commands)
+			fmt.Println()
+			if !tui.Ask(logger, "Install the service? This may ask for your password.", true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		runServiceCommands(logger, commands, false)
+		tui.ShowSuccess("Service %s installed and started", name)
+		fmt.Println(tui.Muted("Follow its output with ") + tui.Command(serviceLogsHint(kind, name, svc.LogFile)))
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Args:  cobra.NoArgs,
+	Short: "Show the status of the service of the project",
+	Long: `Show the status of the service of the project as reported by the service manager.
+
+Flags:
+  --name      The name of the service (default agentuity-<project name>)
+  --manager   The service manager: systemd, launchd or initd (default detected)
+
+Examples:
+  agentuity service status
+  agentuity service status --name support-dev`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		kind := serviceKind(cmd)
+		nameFlag, _ := cmd.Flags().GetString("name")
+		dir, projectName := loadServiceProject(logger, cmd)
+		name := serviceName(nameFlag, projectName)
+		if !util.Exists(service.Path(kind, name)) {
+			tui.ShowWarning("The service %s is not installed, use %s to install it", name, tui.Command("service install"))
+			os.Exit(1)
+		}
+		args = service.StatusCommand(kind, name)
+		c := exec.Command(args[0], args[1:]...)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		err := c.Run()
+		fmt.Println()
+		fmt.Println(tui.Muted("Follow its output with ") + tui.Command(serviceLogsHint(kind, name, filepath.Join(dir, ".agentuity", "service.log"))))
+		if err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:     "uninstall",
+	Aliases: []string{"remove", "rm"},
+	Args:    cobra.NoArgs,
+	Short:   "Stop and remove the service of the project",
+	Long: `Stop the service of the project and remove it so that it doesn't start at boot anymore.
+
+Removing the service requires root so the commands are run with sudo, which may ask
+for your password.
+
+Flags:
+  --name      The name of the service (default agentuity-<project name>)
+  --manager   The service manager: systemd, launchd or initd (default detected)
+  --force     Don't prompt for confirmation
+
+Examples:
+  agentuity service uninstall
+  agentuity service uninstall --name support-dev --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		kind := serviceKind(cmd)
+		force, _ := cmd.Flags().GetBool("force")
+		nameFlag, _ := cmd.Flags().GetString("name")
+		var name string
+		if nameFlag != "" {
+			name = serviceName(nameFlag, "")
+		} else {
+			_, projectName := loadServiceProject(logger, cmd)
+			name = serviceName("", projectName)
+		}
+		if !util.Exists(service.Path(kind, name)) {
+			tui.ShowWarning("The service %s is not installed", name)
+			return
+		}
+		commands := service.UninstallCommands(kind, name)
+		if !force {
+			if !tui.HasTTY {
+				logger.Fatal("No TTY detected, please pass --force to uninstall the service")
+			}
+			fmt.Println("The service will be removed with the commands:")
+			printServiceCommands(commands)
+			fmt.Println()
+			if !tui.Ask(logger, fmt.Sprintf("Uninstall the service %s? This may ask for your password.", name), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+		runServiceCommands(logger, commands, true)
+		tui.ShowSuccess("Service %s uninstalled", name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceStatusCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+
+	for _, cmd := range []*cobra.Command{serviceInstallCmd, serviceStatusCmd, serviceUninstallCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The project directory")
+		cmd.Flags().String("name", "", "The name of the service (default agentuity-<project name>)")
+		cmd.Flags().String("manager", "", "The service manager: systemd, launchd or initd (default detected)")
+	}
+	serviceInstallCmd.Flags().Bool("dry-run", false, "Show the service file and the commands without installing anything")
+	serviceInstallCmd.Flags().Bool("force", false, "Replace an installed service and don't prompt for confirmation")
+	serviceUninstallCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/bundler"
+	"github.com/agentuity/cli/internal/dev"
+	"github.com/agentuity/cli/internal/envutil"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Run the project locally the way the cloud runs its deployment",
+	Long: `Run the project locally the way the cloud runs its deployment.
+
+The project is bundled for production and the deployment command of the project
+file (deployment.command and deployment.args) runs in the project directory
+with the environment of the cloud: the variables and secrets of the project,
+AGENTUITY_ENV=production and the port to listen on in PORT. Unlike dev mode,
+the environment of your shell isn't passed to the command unless --inherit-env
+is provided and nothing is reloaded when the project changes. Use it to
+reproduce an issue which only happens once the project is deployed.
+
+The variables are read from the .env file, from an environment profile with
+--env-profile or from the cloud with --cloud-env. A warning is shown when the
+server doesn't listen on PORT within the boot timeout since the deployment
+would fail to start in the cloud.
+
+Flags:
+  --dir            The project directory
+  --port           The port to listen on
+  --env-profile    The environment profile to read the variables from
+  --cloud-env      Use the variables and secrets of the project in the cloud
+  --inherit-env    Pass the environment of the shell to the command
+  --no-build       Don't bundle the project before it is started
+  --boot-timeout   How long the server has to listen on the port
+
+Examples:
+  agentuity start
+  agentuity start --port 8080
+  agentuity start --cloud-env
+  agentuity start --env-profile staging --no-build`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		log := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		dir := theproject.Dir
+
+		if theproject.Project.Deployment == nil || theproject.Project.Deployment.Command == "" {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("missing deployment command"), errsystem.WithUserMessage("The project file has no deployment.command to start the project with")).ShowErrorAndExit()
+		}
+
+		cloudEnv, _ := cmd.Flags().GetBool("cloud-env")
+		envProfile, _ := cmd.Flags().GetString("env-profile")
+		if cloudEnv && envProfile != "" {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("--cloud-env and --env-profile are mutually exclusive"), errsystem.WithUserMessage("Use either --cloud-env or --env-profile")).ShowErrorAndExit()
+		}
+
+		var orgId string
+		vars := make(map[string]string)
+		projectData, err := project.GetProject(ctx, log, theproject.APIURL, theproject.Token, theproject.Project.ProjectId, false, false)
+		if err != nil {
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			if cloudEnv {
+				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithContextMessage("Failed to get the project")).ShowErrorAndExit()
+			}
+			log.Debug("failed to get the project: %s", err)
+		} else {
+			orgId = projectData.OrgId
+		}
+		if cloudEnv {
+			for k, v := range projectData.Env {
+				vars[k] = v
+			}
+			for k, v := range projectData.Secrets {
+				vars[k] = v
+			}
+		} else {
+			vars = loadStartEnv(ctx, log, dir, envProfile)
+		}
+
+		if noBuild, _ := cmd.Flags().GetBool("no-build"); !noBuild {
+			started := time.Now()
+			if err := bundler.Bundle(bundler.BundleContext{
+				Context:    ctx,
+				Logger:     log,
+				Project:    theproject.Project,
+				ProjectDir: dir,
+				Production: true,
+				Writer:     os.Stderr,
+			}); err != nil {
+				if err == bundler.ErrBuildFailed {
+					os.Exit(1)
+				}
+				exitOnSDKCompatibilityError(err)
+				errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage(fmt.Sprintf("Failed to bundle project: %s", err))).ShowErrorAndExit()
+			}
+			log.Debug("built in %s", time.Since(started))
+		}
+
+		port, _ := cmd.Flags().GetInt("port")
+		port, err = dev.FindAvailablePort(theproject, port)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to find an available port")).ShowErrorAndExit()
+		}
+		inheritEnv, _ := cmd.Flags().GetBool("inherit-env")
+
+		// the command isn't bound to the context so that it can shut down by itself when interrupted
+		serverCmd, err := dev.CreateProductionProjectCmd(context.Background(), dev.StartOptions{
+			Project:    theproject,
+			Dir:        dir,
+			OrgID:      orgId,
+			Port:       port,
+			Env:        vars,
+			InheritEnv: inheritEnv,
+		}, os.Stdout, os.Stderr)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
+		}
+		command := strings.Join(append([]string{theproject.Project.Deployment.Command}, theproject.Project.Deployment.Args...), " ")
+		if err := serverCmd.Start(); err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithUserMessage("Failed to run the deployment command %q. The deployment would fail to start in the cloud as well.", command)).ShowErrorAndExit()
+		}
+		log.Debug("started %s (pid: %d) on port %d", command, serverCmd.Process.Pid, port)
+
+		exited := make(chan struct{})
+		var waitErr error
+		go func() {
+			waitErr = serverCmd.Wait()
+			close(exited)
+		}()
+
+		bootTimeout, _ := cmd.Flags().GetDuration("boot-timeout")
+		if err := dev.WaitForPort(ctx, port, bootTimeout, exited); err == nil {
+			tui.ShowSuccess("Listening on http://%s:%d", dev.DefaultHost, port)
+		} else if ctx.Err() == nil {
+			select {
+			case <-exited:
+			default:
+				tui.ShowWarning("The server didn't listen on port %d within %s. The deployment would fail to start in the cloud unless the server listens on the port in the PORT environment variable.", port, bootTimeout)
+			}
+		}
+
+		select {
+		case <-exited:
+		case <-ctx.Done():
+			// pass the interrupt on like the cloud does when the deployment is stopped
+			serverCmd.Process.Signal(syscall.SIGTERM)
+			select {
+			case <-exited:
+			case <-time.After(10 * time.Second):
+				util.ProcessKill(serverCmd)
+				<-exited
+			}
+		}
+		if waitErr != nil {
+			var exitErr *exec.ExitError
+			if errors.As(waitErr, &exitErr) {
+				if ctx.Err() == nil {
+					tui.ShowWarning("The deployment command %q exited with code %d", command, exitErr.ExitCode())
+				}
+				os.Exit(exitErr.ExitCode())
+			}
+			errsystem.New(errsystem.ErrInvalidConfiguration, waitErr, errsystem.WithContextMessage("Failed to run project")).ShowErrorAndExit()
+		}
+	},
+}
+
+// loadStartEnv returns the variables of the .env file or of the environment profile with the
+// secret references resolved
+func loadStartEnv(ctx context.Context, log logger.Logger, dir string, envProfile string) map[string]string {
+	var filename string
+	var err error
+	if envProfile != "" {
+		filename, err = envutil.EnvProfileFilename(dir, envProfile)
+	} else {
+		filename, err = envutil.DetermineEnvFilename(dir, false)
+	}
+	if err != nil {
+		errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to determine the environment file")).ShowErrorAndExit()
+	}
+	vars := make(map[string]string)
+	if !util.Exists(filename) {
+		if envProfile != "" {
+			errsystem.New(errsystem.ErrParseEnvironmentFile, fmt.Errorf("%s not found", filename), errsystem.WithUserMessage("The environment profile %s doesn't exist", envProfile)).ShowErrorAndExit()
+		}
+		log.Debug("no environment file at %s", filename)
+		return vars
+	}
+	le, err := env.ParseEnvFileWithComments(filename)
+	if err != nil {
+		errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to parse the environment file")).ShowErrorAndExit()
+	}
+	le, _, err = envutil.ResolveSecretReferences(ctx, log, le)
+	if err != nil {
+		errsystem.New(errsystem.ErrParseEnvironmentFile, err, errsystem.WithContextMessage("Failed to resolve secret reference")).ShowErrorAndExit()
+	}
+	for _, ev := range le {
+		vars[ev.Key] = ev.Val
+	}
+	return vars
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	startCmd.Flags().StringP("dir", "d", "", "The project directory")
+	startCmd.Flags().Int("port", 0, "The port to listen on")
+	startCmd.Flags().String("env-profile", "", "The environment profile to read the variables from")
+	startCmd.Flags().Bool("cloud-env", false, "Use the variables and secrets of the project in the cloud")
+	startCmd.Flags().Bool("inherit-env", false, "Pass the environment of the shell to the command")
+	startCmd.Flags().Bool("no-build", false, "Don't bundle the project before it is started")
+	startCmd.Flags().Duration("boot-timeout", 30*time.Second, "How long the server has to listen on the port")
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/Masterminds/semver"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/ignore"
+	"github.com/agentuity/cli/internal/templates"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:     "template",
+	Aliases: []string{"templates"},
+	Args:    cobra.NoArgs,
+	Short:   "Manage community templates",
+	Long: `Manage community templates.
+
+Community templates add support for additional runtimes and frameworks
+beyond the templates maintained in the core templates repository.
+
+Use the subcommands to validate and publish templates.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var templatePublishCmd = &cobra.Command{
+	Use:   "publish [dir]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Validate and publish a community template",
+	Long: `Validate and publish a community template to the templates registry.
+
+The template directory must contain a template.yaml manifest along with the
+rules.yaml and templates.yaml files used to scaffold projects and agents.
+The manifest describes the template, the semantic version being published
+and the template authors. The template is published under your organization.
+
+Arguments:
+  [dir]    The directory of the template (defaults to the current directory)
+
+Flags:
+  --org-id     The organization which owns the template
+  --version    The version to publish (overrides the manifest version)
+  --tag        Tag the published version (can be specified multiple times, defaults to latest)
+  --dry-run    Validate and package the template without publishing it
+  --force      Don't prompt for confirmation
+  --format     The output format (text or json)
+
+Examples:
+  agentuity template publish
+  agentuity template publish ./deno --dry-run
+  agentuity template publish ./deno --version 1.1.0-beta.1 --tag beta`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := util.NewLogger(cmd)
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+				errsystem.WithUserMessage("Invalid template directory: %s", dir)).ShowErrorAndExit()
+		}
+		format, _ := cmd.Flags().GetString("format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		tags, _ := cmd.Flags().GetStringArray("tag")
+		tags = util.RemoveEmpty(util.RemoveDuplicates(tags))
+		if len(tags) == 0 {
+			tags = []string{"latest"}
+		}
+
+		pkg, err := templates.ValidateTemplate(ctx, logger, dir)
+		if err != nil {
+			if verr, ok := err.(*templates.ValidationError); ok {
+				if format == "json" {
+					json.NewEncoder(os.Stdout).Encode(map[string]any{"valid": false, "problems": verr.Problems})
+					os.Exit(1)
+				}
+				for _, problem := range verr.Problems {
+					fmt.Println(tui.Warning("✕ ") + problem)
+				}
+				fmt.Println()
+			}
+			errsystem.New(errsystem.ErrInvalidConfiguration, err,
+				errsystem.WithUserMessage("The template in %s is not valid", dir)).ShowErrorAndExit()
+		}
+		if cmd.Flags().Changed("version") {
+			version, _ := cmd.Flags().GetString("version")
+			if _, err := semver.NewVersion(version); err != nil {
+				errsystem.New(errsystem.ErrInvalidArgumentProvided, err,
+					errsystem.WithUserMessage("The version %s is not a valid semantic version", version)).ShowErrorAndExit()
+			}
+			pkg.Manifest.Version = version
+		}
+
+		tmpfile, err := os.CreateTemp("", "agentuity-template-*.zip")
+		if err != nil {
+			errsystem.New(errsystem.ErrCreateTemporaryFile, err,
+				errsystem.WithContextMessage("Error creating temp file")).ShowErrorAndExit()
+		}
+		tmpfile.Close()
+		defer os.Remove(tmpfile.Name())
+		rules := ignore.Empty()
+		if gitignore := filepath.Join(dir, ignore.Ignore); util.Exists(gitignore) {
+			if r, err := ignore.ParseFile(gitignore); err == nil {
+				rules = r
+			}
+		}
+		rules.AddDefaults()
+		if err := util.ZipDir(dir, tmpfile.Name(), util.WithMatcher(func(fn string, fi os.FileInfo) bool {
+			return !rules.Ignore(fn, fi)
+		})); err != nil {
+			errsystem.New(errsystem.ErrCreateZipFile, err,
+				errsystem.WithContextMessage("Error packaging template")).ShowErrorAndExit()
+		}
+
+		if dryRun {
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(map[string]any{"valid": true, "identifier": pkg.Manifest.Identifier, "version": pkg.Manifest.Version, "tags": tags})
+				return
+			}
+			tui.ShowSuccess("Template %s %s is valid and ready to publish", pkg.Manifest.Identifier, pkg.Manifest.Version)
+			return
+		}
+
+		apikey, _ := util.EnsureLoggedIn(ctx, logger, cmd)
+		apiUrl := util.GetURLs(logger).API
+		orgId := promptForOrganization(ctx, logger, cmd, apiUrl, apikey)
+
+		if !force && tui.HasTTY {
+			if !tui.Ask(logger, fmt.Sprintf("Publish %s %s to the templates registry?", pkg.Manifest.Identifier, pkg.Manifest.Version), true) {
+				tui.ShowWarning("cancelled")
+				return
+			}
+		}
+
+		var published *templates.PublishedTemplate
+		tui.ShowSpinner("Publishing template ...", func() {
+			published, err = templates.PublishTemplate(ctx, logger, apiUrl, apikey, templates.NewPublishRequest(pkg, orgId, tags), tmpfile.Name())
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrApiRequest, err,
+				errsystem.WithContextMessage("Failed to publish template")).ShowErrorAndExit()
+		}
+		if format == "json" {
+			json.NewEncoder(os.Stdout).Encode(published)
+			return
+		}
+		tui.ShowSuccess("Published %s %s", published.Identifier, published.Version)
+		if published.URL != "" {
+			fmt.Println()
+			fmt.Println(tui.Link("%s", published.URL))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templatePublishCmd)
+	templatePublishCmd.Flags().String("org-id", "", "The organization which owns the template")
+	templatePublishCmd.Flags().String("version", "", "The version to publish (overrides the version in the manifest)")
+	templatePublishCmd.Flags().StringArray("tag", nil, "Tag the published version (can be specified multiple times, defaults to latest)")
+	templatePublishCmd.Flags().Bool("dry-run", false, "Validate and package the template without publishing it")
+	templatePublishCmd.Flags().Bool("force", false, "Don't prompt for confirmation")
+	templatePublishCmd.Flags().String("format", "text", "The output format to use for results which can be either 'text' or 'json'")
+}
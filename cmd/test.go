@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/e2e"
+	"github.com/agentuity/cli/internal/envutil"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Test the project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var testE2ECmd = &cobra.Command{
+	Use:   "e2e [command...]",
+	Short: "Run end-to-end tests against a temporary deployment of the project",
+	Long: `Run end-to-end tests against a temporary deployment of the project.
+
+A copy of the project is imported as a new project in the organization (the
+project file of the project isn't changed), its environment variables and
+secrets are set from the .env file and it is deployed with a unique tag. Once
+the deployment is active, the test command runs in the project directory with
+the endpoints of the deployment in environment variables:
+
+  AGENTUITY_E2E_PROJECT_ID           The id of the temporary project
+  AGENTUITY_E2E_DEPLOYMENT_ID        The id of the deployment
+  AGENTUITY_E2E_TAG                  The unique tag of the deployment
+  AGENTUITY_E2E_URL                  The URL the webhooks are served from
+  AGENTUITY_E2E_PROJECT_KEY          The API key of the temporary project
+  AGENTUITY_E2E_WEBHOOK_TOKEN        The token of the webhooks
+  AGENTUITY_E2E_AGENT_<NAME>_URL     The webhook URL of each Agent
+  AGENTUITY_E2E_AGENTS               The id, name and URL of the Agents as JSON
+
+The temporary project is deleted when the test command finishes, fails or is
+interrupted, unless --keep is provided. The exit code is the exit code of the
+test command. A single argument is run with the shell, so use -- before the
+command when it has flags of its own.
+
+Flags:
+  --dir        The project directory
+  --org-id     The organization to create the temporary project in
+  --timeout    How long to wait for the deployment to be active
+  --keep       Don't delete the temporary project after the tests
+
+Examples:
+  agentuity test e2e "npm run test:e2e"
+  agentuity test e2e --org-id org_123 -- pytest tests/e2e
+  agentuity test e2e --keep -- ./scripts/e2e.sh`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		logger := util.NewLogger(cmd)
+		theproject := project.EnsureProject(ctx, cmd)
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		keep, _ := cmd.Flags().GetBool("keep")
+		orgId := promptForOrganization(ctx, logger, cmd, theproject.APIURL, theproject.Token)
+
+		exe, err := os.Executable()
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to get executable path")).ShowErrorAndExit()
+		}
+		tmpdir, err := os.MkdirTemp("", "agentuity-e2e-")
+		if err != nil {
+			errsystem.New(errsystem.ErrCreateTemporaryFile, err, errsystem.WithContextMessage("Failed to create the temporary directory")).ShowErrorAndExit()
+		}
+		dir := filepath.Join(tmpdir, filepath.Base(theproject.Dir))
+		if err := e2e.CopyProject(theproject.Dir, dir); err != nil {
+			os.RemoveAll(tmpdir)
+			errsystem.New(errsystem.ErrCreateDirectory, err, errsystem.WithContextMessage("Failed to copy the project")).ShowErrorAndExit()
+		}
+
+		tag := e2e.NewTag()
+		testproject := project.NewProject()
+		if err := testproject.Load(dir); err != nil {
+			os.RemoveAll(tmpdir)
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the project copy")).ShowErrorAndExit()
+		}
+		testproject.Name = theproject.Project.Name + "-" + tag
+
+		var result *project.ProjectImportResponse
+		tui.ShowSpinner("Creating the temporary project ...", func() {
+			result, err = project.ProjectImport(ctx, logger, theproject.APIURL, theproject.Token, orgId, testproject, true)
+		})
+		if err != nil {
+			os.RemoveAll(tmpdir)
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			errsystem.New(errsystem.ErrImportingProject, err, errsystem.WithContextMessage("Failed to create the temporary project")).ShowErrorAndExit()
+		}
+		logger.Debug("created the temporary project %s", result.ID)
+
+		code, err := runE2E(ctx, logger, exe, theproject, testproject, result, dir, tag, timeout, args)
+		os.RemoveAll(tmpdir)
+		if keep {
+			tui.ShowWarning("Kept the temporary project %s", result.ID)
+		} else {
+			// the tests may have been interrupted so the project is deleted without the context
+			tui.ShowSpinner("Deleting the temporary project ...", func() {
+				if _, derr := project.DeleteProjects(context.Background(), logger, theproject.APIURL, theproject.Token, []string{result.ID}); derr != nil {
+					tui.ShowWarning("Failed to delete the temporary project %s: %s", result.ID, derr)
+				}
+			})
+		}
+		if err != nil {
+			if isCancelled(ctx) {
+				errsystem.ShowCancelledAndExit()
+			}
+			errsystem.New(errsystem.ErrDeployProject, err, errsystem.WithContextMessage("Failed to deploy the temporary project")).ShowErrorAndExit()
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+		tui.ShowSuccess("The end-to-end tests passed")
+	},
+}
+
+// runE2E deploys the temporary project and runs the test command against it, returning the exit code
+// of the test command. The error is only returned when the tests couldn't be run.
+func runE2E(ctx context.Context, logger logger.Logger, exe string, theproject project.ProjectContext, testproject *cproject.Project, result *project.ProjectImportResponse, dir string, tag string, timeout time.Duration, args []string) (int, error) {
+	if err := project.SaveProject(dir, testproject); err != nil {
+		return 0, err
+	}
+	saveEnv(dir, result.APIKey, result.ProjectKey)
+	envutil.ProcessEnvFiles(ctx, logger, dir, testproject, nil, theproject.APIURL, theproject.Token, true, false, "")
+
+	// the temporary project has no other deployment so the deployment is the latest one as well
+	var stdout bytes.Buffer
+	deploy := exec.Command(exe, "deploy", "--dir", dir, "--tag", tag, "--tag", "latest", "--force", "--format", "json")
+	deploy.Stdout = io.MultiWriter(&stdout, logWriter{logger})
+	deploy.Stderr = os.Stderr
+	deploy.Env = os.Environ()
+	if err := deploy.Run(); err != nil {
+		return 0, fmt.Errorf("deploy failed: %w", err)
+	}
+	deploymentId, err := e2e.DeploymentID(stdout.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	tui.ShowSpinner("Waiting for the deployment to be active ...", func() {
+		err = waitForActiveDeployment(ctx, logger, theproject.APIURL, theproject.Token, testproject.ProjectId, deploymentId, timeout)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	env := e2e.Environment{
+		ProjectID:    testproject.ProjectId,
+		DeploymentID: deploymentId,
+		Tag:          tag,
+		URL:          theproject.TransportURL,
+		ProjectKey:   result.ProjectKey,
+		WebhookToken: result.IOAuthToken,
+	}
+	for _, a := range testproject.Agents {
+		env.Agents = append(env.Agents, e2e.Agent{ID: a.ID, Name: a.Name, URL: e2e.WebhookURL(theproject.TransportURL, a.ID)})
+	}
+
+	var c *exec.Cmd
+	switch {
+	case len(args) > 1:
+		c = exec.Command(args[0], args[1:]...)
+	case runtime.GOOS == "windows":
+		c = exec.Command("cmd", "/C", args[0])
+	default:
+		c = exec.Command("sh", "-c", args[0])
+	}
+	// the test command gets the interrupt as well so it is waited for before the project is deleted
+	c.Dir = theproject.Dir
+	c.Env = append(os.Environ(), env.Vars()...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	tui.ShowSuccess("Deployed %s, running the tests", deploymentId)
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+// waitForActiveDeployment waits until the deployment is the active deployment of the project
+func waitForActiveDeployment(ctx context.Context, logger logger.Logger, apiUrl, token, projectId, deploymentId string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		deployments, err := project.ListDeployments(ctx, logger, apiUrl, token, projectId)
+		if err != nil && ctx.Err() == nil {
+			logger.Debug("failed to list the deployments: %s", err)
+		}
+		for _, d := range deployments {
+			if d.ID == deploymentId && d.Active {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("the deployment %s wasn't active after %s", deploymentId, timeout)
+			}
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// logWriter writes the JSON output of the deploy command to the debug log
+type logWriter struct {
+	logger logger.Logger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w.logger.Debug("%s", bytes.TrimSpace(p))
+	return len(p), nil
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+	testCmd.AddCommand(testE2ECmd)
+	testE2ECmd.Flags().StringP("dir", "d", "", "The project directory")
+	testE2ECmd.Flags().String("org-id", "", "The organization to create the temporary project in")
+	testE2ECmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the deployment to be active")
+	testE2ECmd.Flags().Bool("keep", false, "Don't delete the temporary project after the tests")
+}
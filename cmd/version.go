@@ -2,18 +2,22 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/Masterminds/semver"
 	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/cli/internal/util"
-	"github.com/agentuity/go-common/env"
 	"github.com/agentuity/go-common/tui"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
@@ -23,13 +27,22 @@ var versionCmd = &cobra.Command{
 	Long: `Print the version of the Agentuity CLI.
 
 Flags:
-  --long    Print the long version including commit hash and build date
+  --long       Print the long version including commit hash and build date
+  --verbose    Print diagnostics about the CLI and environment for bug reports
+  --format     The output format for --verbose (text or json)
 
 Examples:
   agentuity version
-  agentuity version --long`,
+  agentuity version --long
+  agentuity version --verbose
+  agentuity version --verbose --format json`,
 	Run: func(cmd *cobra.Command, args []string) {
 		long, _ := cmd.Flags().GetBool("long")
+		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+			format, _ := cmd.Flags().GetString("format")
+			showDiagnostics(cmd, format)
+			return
+		}
 		if long {
 			fmt.Println("Version: " + Version)
 			fmt.Println("Commit: " + Commit)
@@ -45,6 +58,101 @@ Examples:
 	},
 }
 
+type diagnostics struct {
+	Version   string             `json:"version"`
+	Commit    string             `json:"commit"`
+	Date      string             `json:"date"`
+	GoVersion string             `json:"goVersion"`
+	OS        string             `json:"os"`
+	Arch      string             `json:"arch"`
+	Endpoints map[string]string  `json:"endpoints"`
+	Config    map[string]string  `json:"config"`
+	TTY       map[string]bool    `json:"tty"`
+	Tools     []util.ToolVersion `json:"tools"`
+}
+
+func showDiagnostics(cmd *cobra.Command, format string) {
+	logger := util.NewLogger(cmd)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	urls := util.GetURLs(logger)
+	configDir := filepath.Dir(cfgFile)
+	exe, _ := os.Executable()
+	result := diagnostics{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Endpoints: map[string]string{
+			"api":       urls.API,
+			"app":       urls.App,
+			"transport": urls.Transport,
+			"gravity":   urls.Gravity,
+		},
+		Config: map[string]string{
+			"executable": exe,
+			"file":       cfgFile,
+			"directory":  configDir,
+			"profile":    filepath.Join(configDir, "profile"),
+		},
+		TTY: map[string]bool{
+			"detected": tui.HasTTY,
+			"stdin":    isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd()),
+			"stdout":   isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()),
+		},
+	}
+	if format == "json" {
+		result.Tools = util.GetToolVersions(ctx)
+		json.NewEncoder(os.Stdout).Encode(result)
+		return
+	}
+	tui.ShowSpinner("Checking installed tools ...", func() {
+		result.Tools = util.GetToolVersions(ctx)
+	})
+	row := func(label string, value string) {
+		fmt.Printf("  %s %s\n", tui.PadRight(label, 12, " "), value)
+	}
+	section := func(label string, values map[string]string) {
+		fmt.Println(tui.Bold(label))
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			row(k, values[k])
+		}
+		fmt.Println()
+	}
+	fmt.Println(tui.Bold("CLI"))
+	row("version", result.Version)
+	row("commit", result.Commit)
+	row("date", result.Date)
+	row("go", result.GoVersion)
+	row("os/arch", result.OS+"/"+result.Arch)
+	fmt.Println()
+	section("Endpoints", result.Endpoints)
+	section("Config", result.Config)
+	fmt.Println(tui.Bold("TTY"))
+	row("detected", strconv.FormatBool(result.TTY["detected"]))
+	row("stdin", strconv.FormatBool(result.TTY["stdin"]))
+	row("stdout", strconv.FormatBool(result.TTY["stdout"]))
+	fmt.Println()
+	fmt.Println(tui.Bold("Tools"))
+	for _, tool := range result.Tools {
+		switch {
+		case !tool.Installed():
+			row(tool.Name, tui.Muted("not installed"))
+		case tool.Error != "":
+			row(tool.Name, fmt.Sprintf("%s %s", tui.Muted(tool.Path), tool.Error))
+		default:
+			row(tool.Name, fmt.Sprintf("%s %s", tool.Version, tui.Muted("("+tool.Path+")")))
+		}
+	}
+}
+
 var versionCheckCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check the latest version of the Agentuity CLI",
@@ -54,7 +162,7 @@ Examples:
   agentuity version check
   agentuity version check --upgrade`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		upgrade, _ := cmd.Flags().GetBool("upgrade")
 		if Version == "dev" {
 			tui.ShowWarning("You are using the development version of the Agentuity CLI.")
@@ -105,7 +213,7 @@ Examples:
 		return baseDesc
 	}(),
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := env.NewLogger(cmd)
+		logger := util.NewLogger(cmd)
 		force, _ := cmd.Flags().GetBool("force")
 		if Version == "dev" || strings.HasSuffix(Version, "-next") {
 			tui.ShowWarning("You are using the development version of the Agentuity CLI which cannot be upgraded.")
@@ -125,6 +233,8 @@ func init() {
 	versionCmd.AddCommand(versionCheckCmd)
 	versionCmd.AddCommand(upgradeCmd)
 	versionCmd.Flags().Bool("long", false, "Print the long version")
+	versionCmd.Flags().Bool("verbose", false, "Print diagnostics about the CLI and environment for bug reports")
+	versionCmd.Flags().String("format", "text", "The output format to use for --verbose which can be either 'text' or 'json'")
 	versionCheckCmd.Flags().Bool("upgrade", false, "Upgrade to the latest version if possible")
 	upgradeCmd.Flags().Bool("force", false, "Force upgrade even if already on the latest version")
 }
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the project for changes",
+	Long: `Watch the project for changes which would surprise you later.
+
+Use the subcommands to choose what to watch.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// loadDriftState loads the agents and environment of the project, locally and in the cloud, and its
+// active deployment
+func loadDriftState(ctx context.Context, logger logger.Logger, projectContext project.ProjectContext) (*project.DriftState, error) {
+	// agentuity.yaml is loaded again since it may have changed while watching
+	theproject := project.NewProject()
+	if err := theproject.Load(projectContext.Dir); err != nil {
+		return nil, fmt.Errorf("error loading the project: %w", err)
+	}
+	state := &project.DriftState{
+		LocalAgents: make(map[string]string),
+		CloudAgents: make(map[string]string),
+	}
+	for _, a := range theproject.Agents {
+		id := a.ID
+		if id == "" {
+			id = a.Name
+		}
+		state.LocalAgents[id] = a.Name
+	}
+	agents, err := agent.ListAgents(ctx, logger, projectContext.APIURL, projectContext.Token, theproject.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range agents {
+		state.CloudAgents[a.ID] = a.Name
+	}
+	lines, err := env.ParseEnvFile(filepath.Join(projectContext.Dir, ".env"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the .env file: %w", err)
+	}
+	local := make(map[string]string)
+	for _, line := range lines {
+		local[line.Key] = line.Val
+	}
+	state.LocalEnv = project.HashEnvValues(local)
+	data, err := project.GetProject(ctx, logger, projectContext.APIURL, projectContext.Token, theproject.ProjectId, false, false)
+	if err != nil {
+		return nil, err
+	}
+	cloud := make(map[string]string)
+	for k, v := range data.Env {
+		cloud[k] = v
+	}
+	for k, v := range data.Secrets {
+		cloud[k] = v
+	}
+	state.CloudEnv = project.HashEnvValues(cloud)
+	deployments, err := project.ListDeployments(ctx, logger, projectContext.APIURL, projectContext.Token, theproject.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments {
+		if deployments[i].Active {
+			state.Deployment = &deployments[i]
+			break
+		}
+	}
+	return state, nil
+}
+
+var watchDriftCmd = &cobra.Command{
+	Use:   "drift",
+	Args:  cobra.NoArgs,
+	Short: "Notify when the project drifts from the cloud",
+	Long: `Notify when the project drifts from the cloud.
+
+This command periodically compares the agents in agentuity.yaml and the variables
+in .env with the project in the cloud and prints a notification when they drift
+apart, such as an agent which was renamed or deleted in the cloud or a variable
+with a different value. It also notifies when a variable is changed in the cloud
+and when a new deployment becomes active, such as one deployed from elsewhere, so
+you aren't surprised at deploy time. Each drift is only notified once when it
+appears. The values of the variables are never printed.
+
+Run it in a separate terminal next to dev mode.
+
+Flags:
+  --dir         The project directory
+  --interval    How often to check for drift (at least 5s)
+  --format      The format of the notifications (text or json, one object per line)
+
+Examples:
+  agentuity watch drift
+  agentuity watch drift --interval 1m
+  agentuity watch drift --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		projectContext := project.EnsureProject(ctx, cmd)
+		logger := projectContext.Logger
+		interval, _ := cmd.Flags().GetDuration("interval")
+		format, _ := cmd.Flags().GetString("format")
+		if interval < 5*time.Second {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("invalid interval %s", interval), errsystem.WithUserMessage("The --interval must be at least 5s")).ShowErrorAndExit()
+		}
+
+		var prev *project.DriftState
+		var failing bool
+		for {
+			state, err := loadDriftState(ctx, logger, projectContext)
+			if err != nil {
+				if isCancelled(ctx) {
+					return
+				}
+				// only notify once until it recovers since the network may be down for a while
+				if !failing {
+					tui.ShowWarning("Failed to check for drift, retrying every %s: %s", interval, err)
+				}
+				failing = true
+			} else {
+				failing = false
+				drift := project.DetectDrift(prev, state)
+				now := time.Now()
+				for _, d := range drift {
+					if format == "json" {
+						json.NewEncoder(os.Stdout).Encode(struct {
+							project.Drift
+							Timestamp time.Time `json:"timestamp"`
+						}{d, now})
+						continue
+					}
+					fmt.Printf("%s %s\n", tui.Muted(now.Format(time.TimeOnly)), tui.Warning(d.Message))
+				}
+				if prev == nil && format != "json" {
+					if len(drift) == 0 {
+						tui.ShowSuccess("The project is in sync with the cloud")
+					}
+					fmt.Println(tui.Muted(fmt.Sprintf("Checking for drift every %s. Press Ctrl+C to stop.", interval)))
+				}
+				prev = state
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.AddCommand(watchDriftCmd)
+	watchDriftCmd.Flags().StringP("dir", "d", "", "The project directory")
+	watchDriftCmd.Flags().Duration("interval", 30*time.Second, "How often to check for drift")
+	watchDriftCmd.Flags().String("format", "text", "The format of the notifications. Can be either 'text' or 'json'")
+}
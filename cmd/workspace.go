@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentuity/cli/internal/errsystem"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Workspace related commands",
+	Long: `Workspace related commands for repositories with several projects, such as a monorepo.
+
+The projects of a workspace are listed in an agentuity.workspace.yaml file with
+the defaults shared by the projects, which each project can override:
+
+  defaults:
+    org_id: org_123
+    tags: [staging]
+    env_profile: staging
+  projects:
+    - path: services/support
+      name: support
+    - path: services/billing
+      tags: [latest]
+      env_profile: production
+
+The deploy, env set, env list, env delete and agent list commands run for every
+project of the workspace with --workspace, or for some of them with
+--workspace-project. The defaults are used when the --org-id, --tag and
+--env-profile flags aren't provided.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.NoArgs,
+	Short: "List the projects of the workspace",
+	Long: `List the projects of the workspace with their settings.
+
+Flags:
+  --dir      A directory of the workspace (defaults to the current directory)
+  --format   The output format (text or json)
+
+Examples:
+  agentuity workspace list
+  agentuity workspace list --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		members := loadWorkspaceMembers(cmd, nil)
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			json.NewEncoder(os.Stdout).Encode(members)
+			return
+		}
+		headers := []string{"Project", "Path", "Project ID", "Tags", "Env Profile"}
+		rows := [][]string{}
+		for _, m := range members {
+			rows = append(rows, []string{tui.Bold(m.Name), tui.Text(m.Path), tui.Muted(m.ProjectId), tui.Text(strings.Join(m.Tags, ", ")), tui.Text(m.EnvProfile)})
+		}
+		tui.Table(headers, rows)
+	},
+}
+
+var workspaceInitCmd = &cobra.Command{
+	Use:   "init",
+	Args:  cobra.NoArgs,
+	Short: "Create a workspace file listing the projects in the directory",
+	Long: `Create an agentuity.workspace.yaml file listing the Agentuity projects found in
+the directory and its subdirectories.
+
+Flags:
+  --dir      The directory of the workspace (defaults to the current directory)
+  --force    Overwrite an existing workspace file
+
+Examples:
+  agentuity workspace init
+  agentuity workspace init --dir ~/code/monorepo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			dir = "."
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, err).ShowErrorAndExit()
+		}
+		filename := filepath.Join(dir, project.WorkspaceFile)
+		if force, _ := cmd.Flags().GetBool("force"); util.Exists(filename) && !force {
+			errsystem.New(errsystem.ErrInvalidArgumentProvided, fmt.Errorf("%s already exists", filename),
+				errsystem.WithUserMessage("%s already exists. Use --force to overwrite it.", project.WorkspaceFile)).ShowErrorAndExit()
+		}
+		var ws project.Workspace
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			switch d.Name() {
+			case "node_modules", ".venv", ".git", project.AgentuityDir:
+				return filepath.SkipDir
+			}
+			if cproject.ProjectExists(path) {
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				ws.Projects = append(ws.Projects, project.WorkspaceProject{Path: filepath.ToSlash(rel)})
+				// a project doesn't contain other projects
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			errsystem.New(errsystem.ErrListFilesAndDirectories, err).ShowErrorAndExit()
+		}
+		if len(ws.Projects) == 0 {
+			tui.ShowWarning("No Agentuity projects found in %s", dir)
+			return
+		}
+		buf, err := yaml.Marshal(ws)
+		if err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err).ShowErrorAndExit()
+		}
+		if err := os.WriteFile(filename, buf, 0644); err != nil {
+			errsystem.New(errsystem.ErrWriteConfigurationFile, err).ShowErrorAndExit()
+		}
+		tui.ShowSuccess("Created %s with %s", project.WorkspaceFile, util.Pluralize(len(ws.Projects), "project", "projects"))
+	},
+}
+
+func loadWorkspaceMembers(cmd *cobra.Command, names []string) []project.WorkspaceMember {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = "."
+	}
+	ws, err := project.FindWorkspace(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err,
+			errsystem.WithUserMessage("%s. Use %s to create one.", err, tui.Command("workspace init"))).ShowErrorAndExit()
+	}
+	members, err := ws.Members(names)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err).ShowErrorAndExit()
+	}
+	return members
+}
+
+// forwardedFlags returns the flags which were provided to the command, except the skipped ones,
+// so that the command can be run again in a child process
+func forwardedFlags(cmd *cobra.Command, skip ...string) []string {
+	var args []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		for _, name := range skip {
+			if f.Name == name {
+				return
+			}
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				args = append(args, "--"+f.Name, v)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// runWorkspace runs the command for each project of the workspace when --workspace or
+// --workspace-project is provided and returns true, otherwise it returns false and the command
+// runs for the project as usual. The defaults of the workspace are used for the --org-id, --tag
+// and --env-profile flags of the command which weren't provided.
+func runWorkspace(cmd *cobra.Command, args []string) bool {
+	all, _ := cmd.Flags().GetBool("workspace")
+	names, _ := cmd.Flags().GetStringSlice("workspace-project")
+	if !all && len(names) == 0 {
+		return false
+	}
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		errsystem.New(errsystem.ErrInvalidCommandFlag, fmt.Errorf("--watch cannot be used with a workspace"),
+			errsystem.WithUserMessage("The --watch flag can only be used for a single project")).ShowErrorAndExit()
+	}
+	members := loadWorkspaceMembers(cmd, names)
+	exe, err := os.Executable()
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to get executable path")).ShowErrorAndExit()
+	}
+	format, _ := cmd.Flags().GetString("format")
+	base := append(strings.Fields(cmd.CommandPath())[1:], args...)
+	base = append(base, forwardedFlags(cmd, "workspace", "workspace-project", "dir")...)
+
+	var failed []string
+	for _, m := range members {
+		margs := append(append([]string{}, base...), "--dir", m.Dir)
+		if cmd.Flags().Lookup("org-id") != nil && !cmd.Flags().Changed("org-id") && m.OrgId != "" {
+			margs = append(margs, "--org-id", m.OrgId)
+		}
+		if cmd.Flags().Lookup("tag") != nil && !cmd.Flags().Changed("tag") {
+			for _, tag := range m.Tags {
+				margs = append(margs, "--tag", tag)
+			}
+		}
+		if cmd.Flags().Lookup("env-profile") != nil && !cmd.Flags().Changed("env-profile") && m.EnvProfile != "" {
+			margs = append(margs, "--env-profile", m.EnvProfile)
+		}
+		if format != "json" {
+			fmt.Println()
+			fmt.Println(tui.Title(m.Name) + " " + tui.Muted(m.Path))
+			fmt.Println()
+		}
+		c := exec.Command(exe, margs...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = os.Environ()
+		if err := c.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == util.ExitCodeCancelled {
+				errsystem.ShowCancelledAndExit()
+			}
+			failed = append(failed, m.Name)
+		}
+	}
+	switch {
+	case len(failed) == len(members):
+		errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("failed for %s", strings.Join(failed, ", ")),
+			errsystem.WithUserMessage("The command failed for every project of the workspace")).ShowErrorAndExit()
+	case len(failed) > 0:
+		errsystem.New(errsystem.ErrOperationPartiallyCompleted, fmt.Errorf("failed for %s", strings.Join(failed, ", ")),
+			errsystem.WithUserMessage("The command failed for %s of the workspace: %s", util.Pluralize(len(failed), "project", "projects"), strings.Join(failed, ", "))).ShowErrorAndExit()
+	}
+	return true
+}
+
+// addWorkspaceFlags adds the flags which run the commands for the projects of the workspace
+func addWorkspaceFlags(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.Flags().Bool("workspace", false, "Run the command for every project of the workspace ("+project.WorkspaceFile+")")
+		cmd.Flags().StringSlice("workspace-project", nil, "Run the command for the project of the workspace with the name or path (can be repeated)")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceInitCmd)
+
+	for _, cmd := range []*cobra.Command{workspaceListCmd, workspaceInitCmd} {
+		cmd.Flags().StringP("dir", "d", "", "The directory of the workspace")
+	}
+	workspaceListCmd.Flags().String("format", "text", "The format to use for the output. Can be either 'text' or 'json'")
+	workspaceInitCmd.Flags().Bool("force", false, "Overwrite an existing workspace file")
+
+	addWorkspaceFlags(cloudDeployCmd, envSetCmd, envListCmd, envDeleteCmd, agentListCmd)
+}
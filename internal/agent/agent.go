@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/logger"
@@ -14,6 +15,7 @@ type Agent struct {
 	Name        string   `json:"name" yaml:"name"`
 	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
 	Types       []string `json:"io_types,omitempty" yaml:"io_types,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
 }
 
 type Response[T any] struct {
@@ -52,6 +54,27 @@ func CreateAgent(ctx context.Context, logger logger.Logger, baseUrl string, toke
 	return resp.Data, nil
 }
 
+// AgentUpdate is the metadata of the agent to update in the cloud. Fields which are nil are left
+// unchanged.
+type AgentUpdate struct {
+	Description *string   `json:"description,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+}
+
+// UpdateAgent will update the metadata of the agent in the project
+func UpdateAgent(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, agentId string, update AgentUpdate) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[any]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/agent/%s/%s", url.PathEscape(projectId), url.PathEscape(agentId)), update, &resp); err != nil {
+		return fmt.Errorf("error updating agent: %s", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error updating agent: %s", resp.Message)
+	}
+	return nil
+}
+
 // DeleteAgent will delete one or more Agents from the project
 func DeleteAgents(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, agentIds []string) ([]string, error) {
 	client := util.NewAPIClient(ctx, logger, baseUrl, token)
@@ -99,3 +122,85 @@ func GetApiKey(ctx context.Context, logger logger.Logger, baseUrl string, token
 
 	return "", nil
 }
+
+// GetAuthType returns the authentication of the webhook of the agent which is project, bearer or none
+func GetAuthType(ctx context.Context, logger logger.Logger, baseUrl string, token string, agentId string, route string) (string, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*AgentAPIKey]
+	if err := client.Do("GET", fmt.Sprintf("/cli/agent/%s/io/source/%s", url.PathEscape(agentId), route), nil, &resp); err != nil {
+		return "", fmt.Errorf("error getting Agent authentication: %s", err)
+	}
+
+	if !resp.Success {
+		return "", fmt.Errorf("error getting Agent authentication: %s", resp.Message)
+	}
+
+	if resp.Data == nil {
+		return "none", nil
+	}
+
+	kv, ok := resp.Data.Config["authorization"].(map[string]any)
+	if !ok {
+		return "none", nil
+	}
+	if authType, ok := kv["type"].(string); ok && authType != "" {
+		return authType, nil
+	}
+	// the agents with their own API key have the token in the configuration
+	if token, ok := kv["token"].(string); ok && token != "" {
+		return "bearer", nil
+	}
+	return "project", nil
+}
+
+type AgentAPIKeyRotation struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+func expiresAtFromTTL(ttl time.Duration) string {
+	if ttl <= 0 {
+		return ""
+	}
+	return time.Now().Add(ttl).UTC().Format(time.RFC3339)
+}
+
+// RotateApiKey will replace the Agent's API key with a newly generated one. If ttl is greater than zero, the new key will expire after the ttl.
+func RotateApiKey(ctx context.Context, logger logger.Logger, baseUrl string, token string, agentId string, route string, ttl time.Duration) (*AgentAPIKeyRotation, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*AgentAPIKeyRotation]
+	payload := map[string]any{}
+	if expiresAt := expiresAtFromTTL(ttl); expiresAt != "" {
+		payload["expiresAt"] = expiresAt
+	}
+	if err := client.Do("PUT", fmt.Sprintf("/cli/agent/%s/io/source/%s/rotate", url.PathEscape(agentId), route), payload, &resp); err != nil {
+		return nil, fmt.Errorf("error rotating Agent API key: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error rotating Agent API key: %s", resp.Message)
+	}
+	if resp.Data == nil || resp.Data.Token == "" {
+		return nil, fmt.Errorf("error rotating Agent API key: no key returned")
+	}
+	return resp.Data, nil
+}
+
+// ExpireApiKey will set the expiration of the Agent's current API key. A ttl of zero will expire the key immediately.
+func ExpireApiKey(ctx context.Context, logger logger.Logger, baseUrl string, token string, agentId string, route string, ttl time.Duration) (string, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	expiresAt := expiresAtFromTTL(ttl)
+	if expiresAt == "" {
+		expiresAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	var resp Response[string]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/agent/%s/io/source/%s/expire", url.PathEscape(agentId), route), map[string]any{"expiresAt": expiresAt}, &resp); err != nil {
+		return "", fmt.Errorf("error expiring Agent API key: %s", err)
+	}
+	if !resp.Success {
+		return "", fmt.Errorf("error expiring Agent API key: %s", resp.Message)
+	}
+	return expiresAt, nil
+}
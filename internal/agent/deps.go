@@ -0,0 +1,391 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Dependency is an external package which the imported agents use but the project doesn't declare
+type Dependency struct {
+	Name string `json:"name"`
+	// Spec is the version range (JavaScript) or version specifier (Python) which the source project
+	// declares, or empty when the source doesn't declare the package
+	Spec string `json:"spec,omitempty"`
+}
+
+func (d Dependency) String() string {
+	if d.Spec == "" {
+		return d.Name
+	}
+	if strings.ContainsAny(d.Spec[:1], "<>=!~[;") {
+		return d.Name + d.Spec
+	}
+	return d.Name + "@" + d.Spec
+}
+
+// DependencyManifest returns the name of the file which declares the dependencies of a project
+// in the language
+func DependencyManifest(language string) string {
+	if language == "python" {
+		return "pyproject.toml"
+	}
+	return "package.json"
+}
+
+var (
+	jsImportFrom    = regexp.MustCompile(`(?m)^\s*(?:import|export)\s+(?:[^'";]*?\s+from\s+)?['"]([^'"\n]+)['"]`)
+	jsImportCall    = regexp.MustCompile(`\b(?:require|import)\(\s*['"]([^'"\n]+)['"]\s*\)`)
+	pyImport        = regexp.MustCompile(`(?m)^\s*import\s+([A-Za-z_][\w.]*(?:\s*,\s*[A-Za-z_][\w.]*)*)`)
+	pyFromImport    = regexp.MustCompile(`(?m)^\s*from\s+([A-Za-z_][\w.]*)\s+import\b`)
+	pyRequirement   = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)(.*)$`)
+	jsSourceExts    = map[string]bool{".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true, ".mts": true, ".cts": true}
+	pythonSeparator = regexp.MustCompile(`[-_.]+`)
+)
+
+// nodeBuiltins are the modules of Node.js which can be imported without the node: prefix
+var nodeBuiltins = map[string]bool{
+	"assert": true, "async_hooks": true, "buffer": true, "child_process": true, "cluster": true,
+	"console": true, "constants": true, "crypto": true, "dgram": true, "diagnostics_channel": true,
+	"dns": true, "domain": true, "events": true, "fs": true, "http": true, "http2": true,
+	"https": true, "inspector": true, "module": true, "net": true, "os": true, "path": true,
+	"perf_hooks": true, "process": true, "punycode": true, "querystring": true, "readline": true,
+	"repl": true, "stream": true, "string_decoder": true, "timers": true, "tls": true,
+	"trace_events": true, "tty": true, "url": true, "util": true, "v8": true, "vm": true,
+	"wasi": true, "worker_threads": true, "zlib": true,
+}
+
+// pythonImportAliases are the common packages whose import name differs from the distribution name
+var pythonImportAliases = map[string]string{
+	"bs4":      "beautifulsoup4",
+	"cv2":      "opencv-python",
+	"dateutil": "python-dateutil",
+	"dotenv":   "python-dotenv",
+	"jwt":      "pyjwt",
+	"PIL":      "pillow",
+	"sklearn":  "scikit-learn",
+	"yaml":     "pyyaml",
+}
+
+// jsPackageName returns the package of the import specifier or an empty string when the specifier
+// isn't an external package (a relative import, a builtin or a path alias)
+func jsPackageName(specifier string) string {
+	if specifier == "" || strings.ContainsAny(specifier[:1], "./#~") || strings.HasPrefix(specifier, "@/") || strings.Contains(specifier, ":") {
+		return ""
+	}
+	parts := strings.Split(specifier, "/")
+	if strings.HasPrefix(specifier, "@") {
+		if len(parts) < 2 {
+			return ""
+		}
+		return parts[0] + "/" + parts[1]
+	}
+	if nodeBuiltins[parts[0]] {
+		return ""
+	}
+	return parts[0]
+}
+
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonSeparator.ReplaceAllString(name, "-"))
+}
+
+// scanImports returns the external packages (JavaScript) or top level modules (Python) imported
+// by the files
+func scanImports(language string, files []string) (map[string]bool, error) {
+	imports := make(map[string]bool)
+	for _, file := range files {
+		ext := filepath.Ext(file)
+		if (language == "python" && ext != ".py") || (language != "python" && !jsSourceExts[ext]) {
+			continue
+		}
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if language == "python" {
+			for _, m := range pyImport.FindAllStringSubmatch(string(buf), -1) {
+				for _, module := range strings.Split(m[1], ",") {
+					module, _, _ = strings.Cut(strings.TrimSpace(module), ".")
+					imports[module] = true
+				}
+			}
+			for _, m := range pyFromImport.FindAllStringSubmatch(string(buf), -1) {
+				module, _, _ := strings.Cut(m[1], ".")
+				imports[module] = true
+			}
+			continue
+		}
+		for _, re := range []*regexp.Regexp{jsImportFrom, jsImportCall} {
+			for _, m := range re.FindAllStringSubmatch(string(buf), -1) {
+				if name := jsPackageName(m[1]); name != "" {
+					imports[name] = true
+				}
+			}
+		}
+	}
+	return imports, nil
+}
+
+// jsManifestDependencies returns the version range of each dependency declared in the package.json
+func jsManifestDependencies(buf []byte) (map[string]string, error) {
+	deps := make(map[string]string)
+	if len(buf) == 0 {
+		return deps, nil
+	}
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(buf, &pkg); err != nil {
+		return nil, fmt.Errorf("error parsing package.json: %w", err)
+	}
+	// the dependencies win over the other kinds when a package is declared more than once
+	for _, m := range []map[string]string{pkg.OptionalDependencies, pkg.PeerDependencies, pkg.DevDependencies, pkg.Dependencies} {
+		for name, spec := range m {
+			deps[name] = spec
+		}
+	}
+	return deps, nil
+}
+
+// pythonManifestDependencies returns the requirements declared in the pyproject.toml keyed by the
+// normalized name, with the specifier (everything after the name) as the value
+func pythonManifestDependencies(buf []byte) (map[string]Dependency, error) {
+	deps := make(map[string]Dependency)
+	if len(buf) == 0 {
+		return deps, nil
+	}
+	var pyproject struct {
+		Project struct {
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"project"`
+	}
+	if err := toml.Unmarshal(buf, &pyproject); err != nil {
+		return nil, fmt.Errorf("error parsing pyproject.toml: %w", err)
+	}
+	for _, requirement := range pyproject.Project.Dependencies {
+		if m := pyRequirement.FindStringSubmatch(requirement); m != nil {
+			deps[normalizePythonName(m[1])] = Dependency{Name: m[1], Spec: strings.TrimSpace(m[2])}
+		}
+	}
+	return deps, nil
+}
+
+// ResolveDependencies returns the external packages imported by the files which the project
+// manifest doesn't declare, with the version which the manifest of the source project declares.
+// For JavaScript, every imported package is returned (without a spec when the source doesn't
+// declare it). For Python, only the imported packages declared by the source are returned since
+// an import can't be told apart from the standard library otherwise.
+func ResolveDependencies(language string, files []string, projectManifest []byte, sourceManifest []byte) ([]Dependency, error) {
+	imports, err := scanImports(language, files)
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dependency
+	if language == "python" {
+		declared, err := pythonManifestDependencies(projectManifest)
+		if err != nil {
+			return nil, err
+		}
+		source, err := pythonManifestDependencies(sourceManifest)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the source project: %w", err)
+		}
+		for module := range imports {
+			name := module
+			if alias, ok := pythonImportAliases[module]; ok {
+				name = alias
+			}
+			key := normalizePythonName(name)
+			if _, ok := declared[key]; ok {
+				continue
+			}
+			if dep, ok := source[key]; ok {
+				deps = append(deps, dep)
+			}
+		}
+	} else {
+		declared, err := jsManifestDependencies(projectManifest)
+		if err != nil {
+			return nil, err
+		}
+		source, err := jsManifestDependencies(sourceManifest)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the source project: %w", err)
+		}
+		for name := range imports {
+			if _, ok := declared[name]; ok {
+				continue
+			}
+			spec := source[name]
+			// a workspace or local package of the source project can't be resolved in this project
+			if strings.Contains(spec, ":") {
+				spec = ""
+			}
+			deps = append(deps, Dependency{Name: name, Spec: spec})
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps, nil
+}
+
+// SourceManifestPaths returns the paths in the repository where the manifest of the source project
+// can be, from the closest parent of the agents directory to the root of the repository
+func SourceManifestPaths(source *Source, language string) []string {
+	filename := DependencyManifest(language)
+	var paths []string
+	for dir := path.Dir(source.Path); ; dir = path.Dir(dir) {
+		if dir == "." || dir == "/" || dir == "" {
+			paths = append(paths, filename)
+			break
+		}
+		paths = append(paths, dir+"/"+filename)
+	}
+	return paths
+}
+
+// DependencyReport is the dependencies of a project which aren't used and the imported packages
+// which the project doesn't declare
+type DependencyReport struct {
+	// Unused are the runtime dependencies which no file of the project imports
+	Unused []string `json:"unused"`
+	// Missing are the imported packages which the project doesn't declare. For Python, only the
+	// packages installed in the virtual environment are reported since an import can't be told
+	// apart from the standard library or a local module otherwise.
+	Missing []string `json:"missing"`
+}
+
+// Empty returns true when the dependencies of the project match its imports
+func (r *DependencyReport) Empty() bool {
+	return len(r.Unused) == 0 && len(r.Missing) == 0
+}
+
+// projectSkipDirs are the directories of a project which don't have its source
+var projectSkipDirs = map[string]bool{"node_modules": true, ".venv": true, ".agentuity": true, ".git": true, "__pycache__": true, "dist": true, "build": true}
+
+// ProjectSourceFiles returns the files of the project in dir without the installed dependencies
+// and the build output
+func ProjectSourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(fn string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if fn != dir && projectSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, fn)
+		return nil
+	})
+	return files, err
+}
+
+// jsRuntimeDependencies returns the names of the dependencies (not the dev, peer or optional
+// dependencies) declared in the package.json
+func jsRuntimeDependencies(buf []byte) ([]string, error) {
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(buf, &pkg); err != nil {
+		return nil, fmt.Errorf("error parsing package.json: %w", err)
+	}
+	var names []string
+	for name := range pkg.Dependencies {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// pythonSitePackages returns whether the top level module is installed in the virtual environment
+// of the project in dir
+func pythonSitePackages(dir string, module string) bool {
+	for _, pattern := range []string{
+		filepath.Join(dir, ".venv", "lib", "python*", "site-packages", module),
+		filepath.Join(dir, ".venv", "lib", "python*", "site-packages", module+".py"),
+		filepath.Join(dir, ".venv", "Lib", "site-packages", module),
+	} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditDependencies compares the dependencies declared in the manifest of the project in dir with
+// the packages imported by its files
+func AuditDependencies(dir string, language string, files []string, manifest []byte) (*DependencyReport, error) {
+	imports, err := scanImports(language, files)
+	if err != nil {
+		return nil, err
+	}
+	report := &DependencyReport{}
+	if language == "python" {
+		declared, err := pythonManifestDependencies(manifest)
+		if err != nil {
+			return nil, err
+		}
+		imported := make(map[string]bool)
+		for module := range imports {
+			name := module
+			if alias, ok := pythonImportAliases[module]; ok {
+				name = alias
+			}
+			key := normalizePythonName(name)
+			imported[key] = true
+			if _, ok := declared[key]; !ok && pythonSitePackages(dir, module) {
+				report.Missing = append(report.Missing, name)
+			}
+		}
+		for key, dep := range declared {
+			if !imported[key] {
+				report.Unused = append(report.Unused, dep.Name)
+			}
+		}
+	} else {
+		declared, err := jsManifestDependencies(manifest)
+		if err != nil {
+			return nil, err
+		}
+		runtime, err := jsRuntimeDependencies(manifest)
+		if err != nil {
+			return nil, err
+		}
+		for name := range imports {
+			if _, ok := declared[name]; ok {
+				continue
+			}
+			// an import of a directory of the project through the baseUrl of the tsconfig.json
+			if util.Exists(filepath.Join(dir, name)) || util.Exists(filepath.Join(dir, "src", name)) {
+				continue
+			}
+			report.Missing = append(report.Missing, name)
+		}
+		for _, name := range runtime {
+			// the type packages are only used by the compiler
+			if !imports[name] && !strings.HasPrefix(name, "@types/") {
+				report.Unused = append(report.Unused, name)
+			}
+		}
+	}
+	sort.Strings(report.Unused)
+	sort.Strings(report.Missing)
+	return report, nil
+}
@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSPackageName(t *testing.T) {
+	for specifier, expected := range map[string]string{
+		"zod":                   "zod",
+		"lodash/merge":          "lodash",
+		"@agentuity/sdk":        "@agentuity/sdk",
+		"@ai-sdk/openai/dist/x": "@ai-sdk/openai",
+		"./lib/util":            "",
+		"../shared":             "",
+		"/abs/path":             "",
+		"node:fs":               "",
+		"bun:test":              "",
+		"fs/promises":           "",
+		"crypto":                "",
+		"@/lib/util":            "",
+		"~/lib/util":            "",
+		"#internal":             "",
+		"@scope":                "",
+	} {
+		assert.Equal(t, expected, jsPackageName(specifier), specifier)
+	}
+}
+
+func TestResolveDependenciesJavaScript(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.ts"), []byte(`import type { AgentRequest } from '@agentuity/sdk';
+import { z } from "zod";
+import {
+	generateText,
+	streamText,
+} from 'ai';
+import './polyfill';
+import fs from 'node:fs';
+export * from "@ai-sdk/openai/internal";
+const lodash = require('lodash/merge');
+const mod = await import("date-fns");
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("import x from 'ignored'\n"), 0644))
+
+	projectManifest := []byte(`{"dependencies":{"@agentuity/sdk":"^0.0.100"},"devDependencies":{"ai":"^4.0.0"}}`)
+	sourceManifest := []byte(`{"dependencies":{"zod":"^3.22.0","lodash":"workspace:*","@ai-sdk/openai":"1.0.0"},"devDependencies":{"zod":"^3.0.0"}}`)
+	deps, err := ResolveDependencies("javascript", []string{filepath.Join(dir, "index.ts"), filepath.Join(dir, "README.md")}, projectManifest, sourceManifest)
+	require.NoError(t, err)
+	assert.Equal(t, []Dependency{
+		{Name: "@ai-sdk/openai", Spec: "1.0.0"},
+		{Name: "date-fns"},
+		{Name: "lodash"},
+		{Name: "zod", Spec: "^3.22.0"},
+	}, deps)
+	assert.Equal(t, "zod@^3.22.0", deps[3].String())
+	assert.Equal(t, "lodash", deps[2].String())
+
+	_, err = ResolveDependencies("javascript", []string{filepath.Join(dir, "index.ts")}, nil, []byte("{"))
+	assert.ErrorContains(t, err, "source project")
+}
+
+func TestResolveDependenciesPython(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agent.py"), []byte(`import os, json
+import httpx
+from agentuity import AgentRequest
+from openai.types import ChatCompletion
+from . import util
+import yaml
+from bs4 import BeautifulSoup
+`), 0644))
+
+	projectManifest := []byte("[project]\nname = \"mine\"\ndependencies = [\"agentuity>=0.0.100\"]\n")
+	sourceManifest := []byte(`[project]
+name = "theirs"
+dependencies = [
+	"agentuity>=0.0.90",
+	"httpx[http2]>=0.27",
+	"OpenAI>=1.0,<2",
+	"PyYAML==6.0.1",
+	"requests>=2",
+]
+`)
+	deps, err := ResolveDependencies("python", []string{filepath.Join(dir, "agent.py")}, projectManifest, sourceManifest)
+	require.NoError(t, err)
+	assert.Equal(t, []Dependency{
+		{Name: "OpenAI", Spec: ">=1.0,<2"},
+		{Name: "PyYAML", Spec: "==6.0.1"},
+		{Name: "httpx", Spec: "[http2]>=0.27"},
+	}, deps)
+	assert.Equal(t, "httpx[http2]>=0.27", deps[2].String())
+}
+
+func TestSourceManifestPaths(t *testing.T) {
+	assert.Equal(t, []string{"apps/bot/src/package.json", "apps/bot/package.json", "apps/package.json", "package.json"}, SourceManifestPaths(&Source{Path: "apps/bot/src/agents"}, "javascript"))
+	assert.Equal(t, []string{"pyproject.toml"}, SourceManifestPaths(&Source{Path: "agents"}, "python"))
+}
+
+func TestAuditDependenciesJavaScript(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "agents", "my-agent"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "lib"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "zod"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.ts"), []byte(`import { runner } from '@agentuity/sdk';`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "my-agent", "index.ts"), []byte(`import { z } from 'zod';
+import { openai } from '@ai-sdk/openai';
+import { helper } from 'lib/helper';
+import { generateText } from 'ai';
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "zod", "index.js"), []byte(`require('left-pad')`), 0644))
+	manifest := []byte(`{"dependencies":{"@agentuity/sdk":"^0.0.100","zod":"^3.0.0","lodash":"^4.0.0","@types/node":"^20"},"devDependencies":{"ai":"^4.0.0","typescript":"^5"}}`)
+
+	files, err := ProjectSourceFiles(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	report, err := AuditDependencies(dir, "javascript", files, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lodash"}, report.Unused)
+	assert.Equal(t, []string{"@ai-sdk/openai"}, report.Missing)
+	assert.False(t, report.Empty())
+
+	_, err = AuditDependencies(dir, "javascript", files, []byte("{"))
+	assert.Error(t, err)
+}
+
+func TestAuditDependenciesPython(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".venv", "lib", "python3.12", "site-packages", "httpx"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".venv", "lib", "python3.12", "site-packages", "yaml"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agent.py"), []byte(`import os, json
+import httpx
+from yaml import safe_load
+from agentuity import AgentRequest
+from agents.other import helper
+`), 0644))
+	manifest := []byte(`[project]
+dependencies = ["agentuity>=0.0.100", "httpx>=0.27", "requests"]
+`)
+	files, err := ProjectSourceFiles(dir)
+	require.NoError(t, err)
+	report, err := AuditDependencies(dir, "python", files, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"requests"}, report.Unused)
+	assert.Equal(t, []string{"pyyaml"}, report.Missing)
+}
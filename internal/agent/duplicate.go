@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// duplicateSkipDirs are the directories in an agent directory which are generated and not copied
+var duplicateSkipDirs = map[string]bool{
+	"node_modules": true,
+	"__pycache__":  true,
+	".venv":        true,
+	"dist":         true,
+}
+
+// Identity is the name and id of an agent
+type Identity struct {
+	ID   string
+	Name string
+}
+
+// DuplicatedFile is a file copied by DuplicateAgent
+type DuplicatedFile struct {
+	// Path is relative to the directory of the copy
+	Path string
+	// Rewritten is set when the name or id of the agent was replaced in the content
+	Rewritten bool
+}
+
+// identityReplacer returns a function which replaces the name of the agent (as written in the
+// source in kebab, snake, camel and pascal case) and its id with the ones of the copy. A name is
+// only replaced at the start of a word so that MyAgentHandler is renamed but not SomeMyAgent.
+func identityReplacer(from Identity, to Identity) func(string) string {
+	replacements := make(map[string]string)
+	for _, transform := range []func(string) string{
+		func(s string) string { return s },
+		strcase.ToKebab,
+		strcase.ToSnake,
+		strcase.ToScreamingSnake,
+		strcase.ToCamel,
+		strcase.ToLowerCamel,
+	} {
+		before, after := transform(from.Name), transform(to.Name)
+		// a very short name would replace too much unrelated code
+		if len(before) >= 3 && before != after {
+			replacements[before] = after
+		}
+	}
+	if from.ID != "" && to.ID != "" {
+		replacements[from.ID] = to.ID
+		// the webhook URL uses the id without its prefix
+		if before, after := strings.TrimPrefix(from.ID, "agent_"), strings.TrimPrefix(to.ID, "agent_"); before != from.ID {
+			replacements[before] = after
+		}
+	}
+	if len(replacements) == 0 {
+		return func(s string) string { return s }
+	}
+	var olds []string
+	for old := range replacements {
+		olds = append(olds, old)
+	}
+	// the longest first so that the alternation matches my_agent_id before my_agent
+	sort.Slice(olds, func(i, j int) bool {
+		if len(olds[i]) == len(olds[j]) {
+			return olds[i] < olds[j]
+		}
+		return len(olds[i]) > len(olds[j])
+	})
+	var quoted []string
+	for _, old := range olds {
+		quoted = append(quoted, regexp.QuoteMeta(old))
+	}
+	re := regexp.MustCompile(`(^|[^A-Za-z0-9_])(` + strings.Join(quoted, "|") + `)`)
+	return func(s string) string {
+		return re.ReplaceAllStringFunc(s, func(match string) string {
+			m := re.FindStringSubmatch(match)
+			return m[1] + replacements[m[2]]
+		})
+	}
+}
+
+func isBinary(buf []byte) bool {
+	if len(buf) > 8000 {
+		buf = buf[:8000]
+	}
+	return bytes.IndexByte(buf, 0) >= 0
+}
+
+// DuplicateAgent copies the source files of the agent in srcDir to destDir, which must not exist,
+// replacing the name and the id of the agent in the names and the contents of the files with the
+// ones of the copy. Binary files are copied as is.
+func DuplicateAgent(srcDir string, destDir string, from Identity, to Identity) ([]DuplicatedFile, error) {
+	return duplicateAgent(srcDir, destDir, from, to, true)
+}
+
+// PlanDuplicateAgent returns the files which DuplicateAgent would copy without writing them
+func PlanDuplicateAgent(srcDir string, destDir string, from Identity, to Identity) ([]DuplicatedFile, error) {
+	return duplicateAgent(srcDir, destDir, from, to, false)
+}
+
+func duplicateAgent(srcDir string, destDir string, from Identity, to Identity, write bool) ([]DuplicatedFile, error) {
+	if _, err := os.Stat(destDir); err == nil {
+		return nil, fmt.Errorf("%s already exists", destDir)
+	}
+	replace := identityReplacer(from, to)
+	var files []DuplicatedFile
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && duplicateSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file := DuplicatedFile{Path: filepath.ToSlash(replace(filepath.ToSlash(rel)))}
+		if !isBinary(buf) {
+			if content := replace(string(buf)); content != string(buf) {
+				buf = []byte(content)
+				file.Rewritten = true
+			}
+		}
+		if !write {
+			files = append(files, file)
+			return nil
+		}
+		dest := filepath.Join(destDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, buf, fi.Mode().Perm()); err != nil {
+			return err
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		if write {
+			os.RemoveAll(destDir)
+		}
+		return nil, fmt.Errorf("error copying the agent: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityReplacer(t *testing.T) {
+	replace := identityReplacer(Identity{ID: "agent_123abc", Name: "order-bot"}, Identity{ID: "agent_456def", Name: "refund-bot"})
+	assert.Equal(t, "refund-bot refund_bot REFUND_BOT RefundBot refundBot", replace("order-bot order_bot ORDER_BOT OrderBot orderBot"))
+	assert.Equal(t, "class RefundBotHandler: pass", replace("class OrderBotHandler: pass"))
+	assert.Equal(t, "const id = 'agent_456def'; // https://agentuity.ai/webhook/456def", replace("const id = 'agent_123abc'; // https://agentuity.ai/webhook/123abc"))
+	// only at the start of a word
+	assert.Equal(t, "NewOrderBot reorder-bot", replace("NewOrderBot reorder-bot"))
+
+	// a name too short to be replaced safely
+	replace = identityReplacer(Identity{Name: "a"}, Identity{Name: "b"})
+	assert.Equal(t, "const a = 1", replace("const a = 1"))
+}
+
+func TestDuplicateAgent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "order_bot")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "__pycache__"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "prompts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "agent.py"), []byte("async def run(request, response, context):\n    context.logger.info('order_bot')\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "prompts", "order_bot.txt"), []byte("You are helpful"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "logo.png"), []byte("\x89PNG\x00order_bot"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "__pycache__", "agent.pyc"), []byte("x"), 0644))
+
+	dest := filepath.Join(dir, "refund_bot")
+	planned, err := PlanDuplicateAgent(src, dest, Identity{Name: "order_bot"}, Identity{Name: "refund_bot"})
+	require.NoError(t, err)
+	assert.NoDirExists(t, dest)
+
+	files, err := DuplicateAgent(src, dest, Identity{Name: "order_bot"}, Identity{Name: "refund_bot"})
+	require.NoError(t, err)
+	assert.Equal(t, []DuplicatedFile{
+		{Path: "agent.py", Rewritten: true},
+		{Path: "logo.png"},
+		{Path: "prompts/refund_bot.txt"},
+	}, files)
+	assert.Equal(t, files, planned)
+
+	buf, err := os.ReadFile(filepath.Join(dest, "agent.py"))
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), "'refund_bot'")
+	buf, err = os.ReadFile(filepath.Join(dest, "logo.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\x00order_bot", string(buf))
+	assert.NoFileExists(t, filepath.Join(dest, "__pycache__", "agent.pyc"))
+
+	_, err = DuplicateAgent(src, dest, Identity{Name: "order_bot"}, Identity{Name: "refund_bot"})
+	assert.ErrorContains(t, err, "already exists")
+}
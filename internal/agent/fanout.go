@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FanOutTarget is an agent to send the payload to with its endpoint
+type FanOutTarget struct {
+	Agent    Agent
+	Endpoint string
+	APIKey   string
+}
+
+// FanOutResult is the response of one of the agents the payload was sent to
+type FanOutResult struct {
+	AgentID string
+	Name    string
+	Status  int
+	Latency time.Duration
+	Body    []byte
+	Error   string
+	// Same is true when the response is the same as the response of the first agent
+	Same bool
+}
+
+// Failed returns true when the request failed or the agent responded with an error
+func (r FanOutResult) Failed() bool {
+	return r.Error != "" || r.Status >= 400
+}
+
+// FanOutSender sends the payload to the target and returns the status and the body of the response
+type FanOutSender func(ctx context.Context, target FanOutTarget) (int, []byte, error)
+
+// FanOut sends the payload to the targets concurrently with send and returns the results in the
+// order of the targets
+func FanOut(ctx context.Context, targets []FanOutTarget, send FanOutSender) []FanOutResult {
+	results := make([]FanOutResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			status, body, err := send(ctx, target)
+			result := FanOutResult{AgentID: target.Agent.ID, Name: target.Agent.Name, Status: status, Latency: time.Since(started), Body: body}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	if len(results) > 0 {
+		first := normalizeResponse(results[0].Body)
+		for i := range results {
+			results[i].Same = results[i].Error == "" && results[i].Status == results[0].Status && bytes.Equal(normalizeResponse(results[i].Body), first)
+		}
+	}
+	return results
+}
+
+// normalizeResponse returns the JSON responses with the keys sorted and without whitespace so that
+// they can be compared
+func normalizeResponse(body []byte) []byte {
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		if buf, err := json.Marshal(v); err == nil {
+			return buf
+		}
+	}
+	return bytes.TrimSpace(body)
+}
+
+// ResponsePreview returns the response on a single line, shortened to width characters
+func ResponsePreview(body []byte, width int) string {
+	var preview string
+	var buf bytes.Buffer
+	if json.Compact(&buf, body) == nil {
+		preview = buf.String()
+	} else {
+		preview = strings.Join(strings.Fields(string(body)), " ")
+	}
+	runes := []rune(preview)
+	if width > 0 && len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return preview
+}
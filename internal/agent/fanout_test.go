@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOut(t *testing.T) {
+	targets := []FanOutTarget{
+		{Agent: Agent{ID: "agent_a", Name: "a"}},
+		{Agent: Agent{ID: "agent_b", Name: "b"}},
+		{Agent: Agent{ID: "agent_c", Name: "c"}},
+		{Agent: Agent{ID: "agent_d", Name: "d"}},
+	}
+	results := FanOut(context.Background(), targets, func(ctx context.Context, target FanOutTarget) (int, []byte, error) {
+		switch target.Agent.ID {
+		case "agent_a":
+			time.Sleep(20 * time.Millisecond)
+			return 200, []byte(`{"answer": 42, "ok": true}`), nil
+		case "agent_b":
+			return 200, []byte(`{"ok":true,"answer":42}`), nil
+		case "agent_c":
+			return 500, []byte(`boom`), nil
+		}
+		return 0, nil, errors.New("connection refused")
+	})
+	assert.Len(t, results, 4)
+	assert.Equal(t, "agent_a", results[0].AgentID)
+	assert.True(t, results[0].Same)
+	assert.True(t, results[1].Same)
+	assert.Less(t, results[1].Latency, results[0].Latency)
+	assert.False(t, results[2].Same)
+	assert.True(t, results[2].Failed())
+	assert.False(t, results[3].Same)
+	assert.True(t, results[3].Failed())
+	assert.Equal(t, "connection refused", results[3].Error)
+	assert.False(t, results[0].Failed())
+}
+
+func TestResponsePreview(t *testing.T) {
+	assert.Equal(t, `{"a":1,"b":[1,2]}`, ResponsePreview([]byte("{\n  \"a\": 1,\n  \"b\": [1, 2]\n}"), 40))
+	assert.Equal(t, "hello world", ResponsePreview([]byte("hello\n  world\n"), 40))
+	assert.Equal(t, "hello…", ResponsePreview([]byte("hello world"), 6))
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+)
+
+// BackupDir is the directory in the project's .agentuity directory where the source files of the
+// deleted agents are backed up
+const BackupDir = "backup"
+
+// The retention of the backups when the project file doesn't configure it
+const (
+	DefaultBackupKeep    = 10
+	DefaultBackupMaxSize = 100 * 1024 * 1024
+)
+
+// backupManifestFilename is the file in each backup which describes it
+const backupManifestFilename = "backup.json"
+
+// BackupAgent is an agent whose source directory is in a backup
+type BackupAgent struct {
+	Name string `json:"name"`
+	// Dir is the source directory of the agent relative to the project, with forward slashes
+	Dir string `json:"dir"`
+}
+
+// SourceBackup is a backup of the source files of the agents deleted together
+type SourceBackup struct {
+	ID        string        `json:"id"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Agents    []BackupAgent `json:"agents"`
+	Size      int64         `json:"-"`
+}
+
+// Agent returns the agent with the name in the backup or nil if it isn't in the backup
+func (b *SourceBackup) Agent(name string) *BackupAgent {
+	for i := range b.Agents {
+		if b.Agents[i].Name == name {
+			return &b.Agents[i]
+		}
+	}
+	return nil
+}
+
+func backupRoot(dir string) string {
+	return filepath.Join(dir, project.AgentuityDir, BackupDir)
+}
+
+// Path returns the directory of the backup in the project in dir
+func (b *SourceBackup) Path(dir string) string {
+	return filepath.Join(backupRoot(dir), b.ID)
+}
+
+// RemoveSourceFiles removes the source files of deleted agents from the project in dir, where files
+// are the names of the agents by their source file. The directory of each file is backed up once
+// to a new backup in .agentuity/backup before anything is removed and a directory left empty is
+// removed too. It returns the backup.
+func RemoveSourceFiles(dir string, files map[string]string) (*SourceBackup, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	backup := &SourceBackup{CreatedAt: time.Now().UTC()}
+	backup.ID = backup.CreatedAt.Format("20060102-150405")
+	for i := 2; backupExists(backups, backup.ID); i++ {
+		backup.ID = fmt.Sprintf("%s-%d", backup.CreatedAt.Format("20060102-150405"), i)
+	}
+	backupDir := backup.Path(dir)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating the backup directory: %w", err)
+	}
+	filenames := make([]string, 0, len(files))
+	for f := range files {
+		filenames = append(filenames, f)
+	}
+	sort.Strings(filenames)
+	seen := make(map[string]bool)
+	for _, f := range filenames {
+		fd := filepath.Dir(f)
+		if seen[fd] {
+			continue
+		}
+		seen[fd] = true
+		rel, err := filepath.Rel(dir, fd)
+		if err != nil || rel == "." || !isSubdir(rel) {
+			return nil, fmt.Errorf("the source file %s isn't in the project", f)
+		}
+		if err := util.CopyDir(fd, filepath.Join(backupDir, rel)); err != nil {
+			return nil, fmt.Errorf("error backing up %s: %w", fd, err)
+		}
+		backup.Agents = append(backup.Agents, BackupAgent{Name: files[f], Dir: filepath.ToSlash(rel)})
+	}
+	if err := backup.save(dir); err != nil {
+		return nil, err
+	}
+	for _, f := range filenames {
+		if err := util.Remove(f); err != nil && !os.IsNotExist(err) {
+			return backup, fmt.Errorf("error removing %s: %w", f, err)
+		}
+		fd := filepath.Dir(f)
+		if entries, err := os.ReadDir(fd); err == nil && len(entries) == 0 {
+			if err := util.Remove(fd); err != nil {
+				return backup, fmt.Errorf("error removing %s: %w", fd, err)
+			}
+		}
+	}
+	backup.Size = project.PathSize(backupDir)
+	return backup, nil
+}
+
+func (b *SourceBackup) save(dir string) error {
+	buf, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(b.Path(dir), backupManifestFilename), buf, 0644); err != nil {
+		return fmt.Errorf("error writing the backup manifest: %w", err)
+	}
+	return nil
+}
+
+func backupExists(backups []SourceBackup, id string) bool {
+	for _, b := range backups {
+		if b.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ListBackups returns the backups of the project in dir, the most recent first. The directories
+// without a manifest, such as the backups made by older versions, are ignored.
+func ListBackups(dir string) ([]SourceBackup, error) {
+	matches, err := filepath.Glob(filepath.Join(backupRoot(dir), "*", backupManifestFilename))
+	if err != nil {
+		return nil, err
+	}
+	backups := []SourceBackup{}
+	for _, filename := range matches {
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		var backup SourceBackup
+		if err := json.Unmarshal(buf, &backup); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+		}
+		backup.ID = filepath.Base(filepath.Dir(filename))
+		backup.Size = project.PathSize(filepath.Dir(filename))
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].ID > backups[j].ID
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// FindBackup returns the most recent backup with the agent or, when id isn't empty, the backup
+// with the id which must have the agent
+func FindBackup(dir string, name string, id string) (*SourceBackup, *BackupAgent, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range backups {
+		if id != "" && backups[i].ID != id {
+			continue
+		}
+		if a := backups[i].Agent(name); a != nil {
+			return &backups[i], a, nil
+		}
+		if id != "" {
+			return nil, nil, fmt.Errorf("the backup %s doesn't have the agent %s", id, name)
+		}
+	}
+	if id != "" {
+		return nil, nil, fmt.Errorf("backup %s not found", id)
+	}
+	return nil, nil, fmt.Errorf("no backup of the agent %s found", name)
+}
+
+// RestoreBackup copies the source directory of the agent in the backup back into the project in
+// dir. It fails if the directory exists unless overwrite is true, in which case the files of the
+// backup replace the existing files. The directory of the agent comes from the manifest of the
+// backup so it must be a directory of the project. It returns the restored directory.
+func RestoreBackup(dir string, backup *SourceBackup, agent *BackupAgent, overwrite bool) (string, error) {
+	dest := filepath.Join(dir, filepath.FromSlash(agent.Dir))
+	if rel, err := filepath.Rel(dir, dest); err != nil || rel == "." || filepath.IsAbs(agent.Dir) || !isSubdir(rel) {
+		return "", fmt.Errorf("the directory %s of the backup isn't in the project", agent.Dir)
+	}
+	if util.Exists(dest) && !overwrite {
+		return "", fmt.Errorf("the directory %s already exists", agent.Dir)
+	}
+	if err := util.CopyDir(filepath.Join(backup.Path(dir), filepath.FromSlash(agent.Dir)), dest); err != nil {
+		return "", fmt.Errorf("error restoring %s: %w", agent.Dir, err)
+	}
+	return dest, nil
+}
+
+// isSubdir returns true if the relative path doesn't go up out of the directory it's relative to
+func isSubdir(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// PruneBackups removes the oldest backups of the project in dir so no more than keep backups are
+// kept and their total size is no more than maxSize, where zero means no limit. The most recent
+// backup is always kept. It returns the removed backups.
+func PruneBackups(dir string, keep int, maxSize int64) ([]SourceBackup, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	var removed []SourceBackup
+	for i, b := range backups {
+		total += b.Size
+		if i == 0 || ((keep <= 0 || i < keep) && (maxSize <= 0 || total <= maxSize)) {
+			continue
+		}
+		if err := util.RemoveAll(b.Path(dir)); err != nil {
+			return removed, fmt.Errorf("error removing the backup %s: %w", b.ID, err)
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
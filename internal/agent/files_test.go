@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAgentFiles(t *testing.T, files ...string) {
+	for _, fn := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+		require.NoError(t, os.WriteFile(fn, []byte(filepath.Base(fn)), 0644))
+	}
+}
+
+func TestRemoveSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	agentDir := filepath.Join(dir, "src", "agents", "my-agent")
+	otherDir := filepath.Join(dir, "src", "agents", "other")
+	writeAgentFiles(t, filepath.Join(agentDir, "index.ts"), filepath.Join(agentDir, "helper.ts"), filepath.Join(otherDir, "index.ts"), filepath.Join(otherDir, "prompt.txt"))
+
+	backup, err := RemoveSourceFiles(dir, map[string]string{filepath.Join(agentDir, "index.ts"): "my-agent", filepath.Join(agentDir, "helper.ts"): "my-agent", filepath.Join(otherDir, "index.ts"): "other"})
+	require.NoError(t, err)
+	backupDir := backup.Path(dir)
+	assert.Equal(t, filepath.Join(dir, ".agentuity", "backup", backup.ID), backupDir)
+	assert.Equal(t, []BackupAgent{{Name: "my-agent", Dir: "src/agents/my-agent"}, {Name: "other", Dir: "src/agents/other"}}, backup.Agents)
+
+	// the directory left empty is removed while the one with other files is kept
+	assert.NoDirExists(t, agentDir)
+	assert.NoFileExists(t, filepath.Join(otherDir, "index.ts"))
+	assert.FileExists(t, filepath.Join(otherDir, "prompt.txt"))
+
+	// the backup is made before any file is removed
+	assert.FileExists(t, filepath.Join(backupDir, "src", "agents", "my-agent", "index.ts"))
+	assert.FileExists(t, filepath.Join(backupDir, "src", "agents", "my-agent", "helper.ts"))
+	assert.FileExists(t, filepath.Join(backupDir, "src", "agents", "other", "index.ts"))
+	assert.FileExists(t, filepath.Join(backupDir, "src", "agents", "other", "prompt.txt"))
+
+	backups, err := ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, backup.ID, backups[0].ID)
+	assert.Positive(t, backups[0].Size)
+}
+
+func TestRemoveSourceFilesDotDotName(t *testing.T) {
+	dir := t.TempDir()
+	agentDir := filepath.Join(dir, "..agent")
+	writeAgentFiles(t, filepath.Join(agentDir, "index.ts"))
+	backup, err := RemoveSourceFiles(dir, map[string]string{filepath.Join(agentDir, "index.ts"): "agent"})
+	require.NoError(t, err)
+	assert.Equal(t, []BackupAgent{{Name: "agent", Dir: "..agent"}}, backup.Agents)
+
+	_, err = RemoveSourceFiles(dir, map[string]string{filepath.Join(filepath.Dir(dir), "outside", "index.ts"): "outside"})
+	assert.ErrorContains(t, err, "isn't in the project")
+}
+
+func TestRestoreBackupOutsideProject(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	agentDir := filepath.Join(dir, "src", "agents", "my-agent")
+	writeAgentFiles(t, filepath.Join(agentDir, "index.ts"))
+	backup, err := RemoveSourceFiles(dir, map[string]string{filepath.Join(agentDir, "index.ts"): "my-agent"})
+	require.NoError(t, err)
+
+	// a crafted manifest can't restore the files outside of the project
+	for _, d := range []string{"../escaped", "src/../../escaped", ".", "/tmp/escaped"} {
+		_, err := RestoreBackup(dir, backup, &BackupAgent{Name: "my-agent", Dir: d}, true)
+		assert.ErrorContains(t, err, "isn't in the project", d)
+	}
+	assert.NoDirExists(t, filepath.Join(filepath.Dir(dir), "escaped"))
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	agentDir := filepath.Join(dir, "src", "agents", "my-agent")
+	writeAgentFiles(t, filepath.Join(agentDir, "index.ts"))
+	first, err := RemoveSourceFiles(dir, map[string]string{filepath.Join(agentDir, "index.ts"): "my-agent"})
+	require.NoError(t, err)
+	writeAgentFiles(t, filepath.Join(agentDir, "index.ts"), filepath.Join(agentDir, "second.ts"))
+	second, err := RemoveSourceFiles(dir, map[string]string{filepath.Join(agentDir, "index.ts"): "my-agent", filepath.Join(agentDir, "second.ts"): "my-agent"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	// the most recent backup is restored by default
+	backup, a, err := FindBackup(dir, "my-agent", "")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, backup.ID)
+	restored, err := RestoreBackup(dir, backup, a, false)
+	require.NoError(t, err)
+	assert.Equal(t, agentDir, restored)
+	assert.FileExists(t, filepath.Join(agentDir, "second.ts"))
+
+	_, err = RestoreBackup(dir, backup, a, false)
+	assert.ErrorContains(t, err, "already exists")
+
+	backup, a, err = FindBackup(dir, "my-agent", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, backup.ID)
+	_, err = RestoreBackup(dir, backup, a, true)
+	require.NoError(t, err)
+
+	_, _, err = FindBackup(dir, "other", "")
+	assert.ErrorContains(t, err, "no backup")
+	_, _, err = FindBackup(dir, "other", first.ID)
+	assert.ErrorContains(t, err, "doesn't have the agent")
+	_, _, err = FindBackup(dir, "my-agent", "20000101-000000")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	var ids []string
+	for i := range 4 {
+		fn := filepath.Join(dir, "src", "agents", "agent", "index.ts")
+		writeAgentFiles(t, fn)
+		require.NoError(t, os.WriteFile(fn, make([]byte, 1000), 0644))
+		backup, err := RemoveSourceFiles(dir, map[string]string{fn: "agent"})
+		require.NoError(t, err)
+		// make the backups ordered without waiting a second between them
+		backup.CreatedAt = backup.CreatedAt.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, backup.save(dir))
+		ids = append(ids, backup.ID)
+	}
+
+	removed, err := PruneBackups(dir, 3, 0)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, ids[0], removed[0].ID)
+
+	// the size of the manifest is counted too so only the two most recent fit in 2500 bytes
+	removed, err = PruneBackups(dir, 0, 2500)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, ids[1], removed[0].ID)
+
+	// the most recent backup is always kept
+	removed, err = PruneBackups(dir, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	backups, err := ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, ids[3], backups[0].ID)
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/project"
+)
+
+// FindOrphanedAgents returns the agents in the cloud whose source isn't in the local project, which
+// are the ids in local, and which aren't part of any of the deployments. It fails when one of the
+// deployments doesn't list its agents since they can't be told apart from orphaned agents.
+func FindOrphanedAgents(remote []Agent, local map[string]bool, deployments []project.DeploymentDetail) ([]Agent, error) {
+	deployed := make(map[string]bool)
+	for _, d := range deployments {
+		if d.Agents == nil {
+			return nil, fmt.Errorf("the deployment %s doesn't list its agents", d.ID)
+		}
+		for _, a := range d.Agents {
+			deployed[a.ID] = true
+		}
+	}
+	var orphans []Agent
+	for _, a := range remote {
+		if !local[a.ID] && !deployed[a.ID] {
+			orphans = append(orphans, a)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return strings.ToLower(orphans[i].Name) < strings.ToLower(orphans[j].Name)
+	})
+	return orphans, nil
+}
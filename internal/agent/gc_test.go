@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphanedAgents(t *testing.T) {
+	remote := []Agent{
+		{ID: "agent_1", Name: "support"},
+		{ID: "agent_2", Name: "billing"},
+		{ID: "agent_3", Name: "Legacy"},
+		{ID: "agent_4", Name: "archive"},
+	}
+	local := map[string]bool{"agent_1": true}
+	deployments := []project.DeploymentDetail{
+		{DeploymentListData: project.DeploymentListData{ID: "deploy_2"}, Agents: []project.DeploymentAgent{{ID: "agent_1"}}},
+		{DeploymentListData: project.DeploymentListData{ID: "deploy_1"}, Agents: []project.DeploymentAgent{{ID: "agent_1"}, {ID: "agent_2"}}},
+	}
+	orphans, err := FindOrphanedAgents(remote, local, deployments)
+	require.NoError(t, err)
+	assert.Equal(t, []Agent{{ID: "agent_4", Name: "archive"}, {ID: "agent_3", Name: "Legacy"}}, orphans)
+
+	orphans, err = FindOrphanedAgents(remote[:2], local, deployments)
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	// a deployment which doesn't list its agents could be using any of them
+	deployments = append(deployments, project.DeploymentDetail{DeploymentListData: project.DeploymentListData{ID: "deploy_0"}})
+	_, err = FindOrphanedAgents(remote, local, deployments)
+	assert.ErrorContains(t, err, "deploy_0")
+}
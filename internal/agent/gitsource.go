@@ -0,0 +1,312 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/sbom"
+	"github.com/agentuity/cli/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// GitFetcher fetches agents with a shallow, sparse clone of the repository which only downloads the
+// contents of the selected agent directories. Since the files are checked out by git, the
+// .gitattributes of the repository are respected and any git host is supported.
+type GitFetcher struct {
+	Source *Source
+	// Progress is called each time a file is copied with the number of files done out of the total
+	Progress func(done int, total int)
+
+	git        string
+	dir        string
+	executable map[string]bool
+}
+
+var _ ImportFetcher = (*GitFetcher)(nil)
+
+// NewGitFetcher returns a git fetcher for the source. The git executable must be installed.
+func NewGitFetcher(source *Source) (*GitFetcher, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git is required to import with git: %w", err)
+	}
+	return &GitFetcher{Source: source, git: git, executable: make(map[string]bool)}, nil
+}
+
+func (f *GitFetcher) run(ctx context.Context, args ...string) ([]byte, error) {
+	c := exec.CommandContext(ctx, f.git, args...)
+	util.ProcessSetup(c)
+	c.Dir = f.dir
+	// never prompt for credentials since the output isn't attached to the terminal
+	c.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// clone fetches the commit of the ref without any file contents and checks out the files at the
+// root of the repository
+func (f *GitFetcher) clone(ctx context.Context) error {
+	if f.dir != "" {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "agentuity-import-")
+	if err != nil {
+		return err
+	}
+	f.dir = dir
+	if err := ValidateRef(f.Source.Ref); err != nil {
+		return err
+	}
+	ref := f.Source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "--", "origin", f.Source.GitURL()},
+		{"fetch", "-q", "--depth", "1", "--filter=blob:none", "--", "origin", ref},
+		{"sparse-checkout", "set", "--cone"},
+		{"-c", "advice.detachedHead=false", "checkout", "-q", "FETCH_HEAD"},
+	} {
+		if _, err := f.run(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveAgentsDir sets the path of the source to the agents directory of the project in the
+// repository when it isn't set
+func (f *GitFetcher) ResolveAgentsDir(ctx context.Context) error {
+	if err := f.clone(ctx); err != nil {
+		return err
+	}
+	if f.Source.Path != "" {
+		return nil
+	}
+	buf, err := os.ReadFile(filepath.Join(f.dir, "agentuity.yaml"))
+	if err != nil {
+		return fmt.Errorf("no path was provided and the project file couldn't be read: %w", err)
+	}
+	var config struct {
+		Bundler struct {
+			Agents struct {
+				Dir string `yaml:"dir"`
+			} `yaml:"agents"`
+		} `yaml:"bundler"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return fmt.Errorf("error parsing the project file of %s: %w", f.Source, err)
+	}
+	if config.Bundler.Agents.Dir == "" {
+		return fmt.Errorf("the project file of %s doesn't have an agents directory", f.Source)
+	}
+	f.Source.Path = strings.Trim(path.Clean(filepath.ToSlash(config.Bundler.Agents.Dir)), "/")
+	return nil
+}
+
+// lsTree returns the entries of the tree of the fetched commit for the paths
+func (f *GitFetcher) lsTree(ctx context.Context, args ...string) ([]string, error) {
+	out, err := f.run(ctx, append([]string{"ls-tree", "-z"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// ListAgents returns the names of the agent directories in the source path
+func (f *GitFetcher) ListAgents(ctx context.Context) ([]string, error) {
+	if err := f.clone(ctx); err != nil {
+		return nil, err
+	}
+	entries, err := f.lsTree(ctx, "-d", "--name-only", "HEAD", "--", f.Source.Path+"/")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s is not a directory in %s", f.Source.Path, f.Source)
+	}
+	var names []string
+	for _, entry := range entries {
+		name := path.Base(entry)
+		if !strings.HasPrefix(name, ".") && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListFiles returns the files of the agents from the tree of the fetched commit
+func (f *GitFetcher) ListFiles(ctx context.Context, agents []string) ([]RemoteFile, error) {
+	if err := f.clone(ctx); err != nil {
+		return nil, err
+	}
+	args := []string{"-r", "HEAD", "--"}
+	for _, name := range agents {
+		args = append(args, path.Join(f.Source.Path, name)+"/")
+	}
+	entries, err := f.lsTree(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []RemoteFile
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		// <mode> SP <type> SP <object> TAB <file>
+		meta, filename, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git ls-tree output: %q", entry)
+		}
+		for _, name := range agents {
+			if strings.HasPrefix(filename, path.Join(f.Source.Path, name)+"/") {
+				found[name] = true
+			}
+		}
+		// only regular files are imported, not symlinks or submodules
+		if fields[1] != "blob" || (fields[0] != "100644" && fields[0] != "100755") {
+			continue
+		}
+		files = append(files, RemoteFile{Path: filename, SHA: fields[2]})
+		f.executable[filename] = fields[0] == "100755"
+	}
+	for _, name := range agents {
+		if !found[name] {
+			return nil, fmt.Errorf("%s was not found in %s", path.Join(f.Source.Path, name), f.Source)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// FetchFiles checks out only the directories of the files, which downloads their contents in a
+// single request, and copies the files to the filename returned by dest. Files recorded in the
+// state with the same SHA which still exist are skipped.
+func (f *GitFetcher) FetchFiles(ctx context.Context, files []RemoteFile, state *ImportState, dest func(file RemoteFile) string) (int, error) {
+	if err := f.clone(ctx); err != nil {
+		return 0, err
+	}
+	var todo []RemoteFile
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		if state.Files[file.Path] == file.SHA && util.Exists(dest(file)) {
+			continue
+		}
+		todo = append(todo, file)
+		dirs[path.Dir(file.Path)] = true
+	}
+	done := len(files) - len(todo)
+	if len(todo) > 0 {
+		args := []string{"sparse-checkout", "set", "--cone", "--"}
+		for dir := range dirs {
+			args = append(args, dir)
+		}
+		sort.Strings(args[4:])
+		if _, err := f.run(ctx, args...); err != nil {
+			return 0, err
+		}
+	}
+	for i, file := range todo {
+		filename := dest(file)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return i, err
+		}
+		if _, err := util.CopyFile(filepath.Join(f.dir, filepath.FromSlash(file.Path)), filename); err != nil {
+			return i, fmt.Errorf("%s: %w", file.Path, err)
+		}
+		if f.executable[file.Path] {
+			if err := os.Chmod(filename, 0755); err != nil {
+				return i, err
+			}
+		}
+		state.Files[file.Path] = file.SHA
+		done++
+		if f.Progress != nil {
+			f.Progress(done, len(files))
+		}
+	}
+	return len(todo), nil
+}
+
+// License returns the license detected from the license file at the root of the repository
+func (f *GitFetcher) License(ctx context.Context) (string, error) {
+	if err := f.clone(ctx); err != nil {
+		return "", err
+	}
+	for _, name := range sbom.LicenseFilenames {
+		buf, err := os.ReadFile(filepath.Join(f.dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		return sbom.DetectLicense(string(buf)), nil
+	}
+	return "", nil
+}
+
+// ReadFile returns the contents of the file in the repository or nil when it doesn't exist. The
+// file is read from the fetched commit so it doesn't need to be checked out.
+func (f *GitFetcher) ReadFile(ctx context.Context, file string) ([]byte, error) {
+	if err := f.clone(ctx); err != nil {
+		return nil, err
+	}
+	entries, err := f.lsTree(ctx, "HEAD", "--", file)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return f.run(ctx, "show", "HEAD:"+file)
+}
+
+// Close removes the clone
+func (f *GitFetcher) Close() error {
+	if f.dir == "" {
+		return nil
+	}
+	return util.RemoveAll(f.dir)
+}
+
+// CloneRepository clones the whole repository of the source into dir, which must not exist, with the
+// ref of the source checked out. Unlike the fetcher, the clone keeps its history and origin so it can
+// be used as the working copy of a project.
+func CloneRepository(ctx context.Context, source *Source, dir string) error {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return fmt.Errorf("git is required to clone the repository: %w", err)
+	}
+	if err := ValidateRef(source.Ref); err != nil {
+		return err
+	}
+	f := &GitFetcher{Source: source, git: git}
+	args := []string{"clone", "-q", "--depth", "1"}
+	if source.Ref != "" {
+		args = append(args, "--branch="+source.Ref)
+	}
+	if _, err := f.run(ctx, append(args, "--", source.GitURL(), dir)...); err != nil {
+		return err
+	}
+	return nil
+}
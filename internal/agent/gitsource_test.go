@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createGitRepo(t *testing.T, files map[string]string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		fn := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+		require.NoError(t, os.WriteFile(fn, []byte(content), 0644))
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+		{"config", "uploadpack.allowFilter", "true"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func TestGitFetcher(t *testing.T) {
+	repo := createGitRepo(t, map[string]string{
+		"agentuity.yaml":                "bundler:\n  agents:\n    dir: src/agents\n",
+		"src/agents/one/index.ts":       "one",
+		"src/agents/one/lib/util.ts":    "util",
+		"src/agents/two/index.ts":       "two",
+		"src/agents/.hidden/index.ts":   "hidden",
+		"src/other/ignored.ts":          "ignored",
+		"src/agents/one/.gitattributes": "*.ts text eol=lf\n",
+		"LICENSE":                       "                                 Apache License\n                           Version 2.0, January 2004\n",
+		"package.json":                  `{"dependencies":{"zod":"^3.0.0"}}`,
+	})
+
+	fetcher, err := NewGitFetcher(&Source{URL: "file://" + filepath.ToSlash(repo), Ref: "main"})
+	require.NoError(t, err)
+	defer fetcher.Close()
+	var progress []int
+	fetcher.Progress = func(done int, total int) {
+		progress = append(progress, done)
+	}
+	ctx := context.Background()
+
+	require.NoError(t, fetcher.ResolveAgentsDir(ctx))
+	assert.Equal(t, "src/agents", fetcher.Source.Path)
+
+	names, err := fetcher.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, names)
+
+	license, err := fetcher.License(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Apache-2.0", license)
+
+	// files outside of the sparse checkout can be read
+	buf, err := fetcher.ReadFile(ctx, "package.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"dependencies":{"zod":"^3.0.0"}}`, string(buf))
+	buf, err = fetcher.ReadFile(ctx, "src/package.json")
+	require.NoError(t, err)
+	assert.Nil(t, buf)
+
+	_, err = fetcher.ListFiles(ctx, []string{"missing"})
+	assert.Error(t, err)
+
+	files, err := fetcher.ListFiles(ctx, []string{"one"})
+	require.NoError(t, err)
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		assert.Len(t, file.SHA, 40)
+	}
+	assert.Equal(t, []string{"src/agents/one/.gitattributes", "src/agents/one/index.ts", "src/agents/one/lib/util.ts"}, paths)
+
+	dir := t.TempDir()
+	dest := func(file RemoteFile) string {
+		return filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(file.Path, "src/agents/")))
+	}
+	state := &ImportState{Source: fetcher.Source.String(), Files: map[string]string{}}
+	fetched, err := fetcher.FetchFiles(ctx, files, state, dest)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetched)
+	assert.Equal(t, []int{1, 2, 3}, progress)
+	buf, err = os.ReadFile(filepath.Join(dir, "one", "lib", "util.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "util", string(buf))
+	// only the selected agent is checked out
+	assert.NoFileExists(t, filepath.Join(fetcher.dir, "src", "agents", "two", "index.ts"))
+	assert.NoFileExists(t, filepath.Join(fetcher.dir, "src", "other", "ignored.ts"))
+
+	// the files in the state are skipped
+	fetched, err = fetcher.FetchFiles(ctx, files, state, dest)
+	require.NoError(t, err)
+	assert.Equal(t, 0, fetched)
+
+	clone := fetcher.dir
+	require.NoError(t, fetcher.Close())
+	assert.NoDirExists(t, clone)
+}
+
+func TestCloneRepository(t *testing.T) {
+	repo := createGitRepo(t, map[string]string{
+		"agentuity.yaml":          "bundler:\n  agents:\n    dir: src/agents\n",
+		"src/agents/one/index.ts": "one",
+	})
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, CloneRepository(ctx, &Source{URL: "file://" + filepath.ToSlash(repo), Ref: "main"}, dir))
+	buf, err := os.ReadFile(filepath.Join(dir, "src", "agents", "one", "index.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(buf))
+
+	// the directory already exists
+	assert.Error(t, CloneRepository(ctx, &Source{URL: "file://" + filepath.ToSlash(repo)}, dir))
+	assert.Error(t, CloneRepository(ctx, &Source{URL: "file://" + filepath.ToSlash(repo), Ref: "missing"}, filepath.Join(t.TempDir(), "project")))
+}
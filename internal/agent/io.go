@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// Schedule runs an agent on a cron expression with a fixed payload
+type Schedule struct {
+	ID          string `json:"id,omitempty"`
+	AgentID     string `json:"agentId"`
+	Expression  string `json:"expression"`
+	Description string `json:"description,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Payload     string `json:"payload,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Webhook is an outbound webhook the output of an agent is sent to
+type Webhook struct {
+	ID          string            `json:"id,omitempty"`
+	AgentID     string            `json:"agentId"`
+	URL         string            `json:"url"`
+	Description string            `json:"description,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Enabled     bool              `json:"enabled"`
+}
+
+// ListSchedules will list the schedules of the agents in the project
+func ListSchedules(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) ([]Schedule, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]Schedule]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/schedules", url.PathEscape(projectId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error listing schedules: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error listing schedules: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// CreateSchedule will create a schedule for an agent in the project
+func CreateSchedule(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, schedule Schedule) (*Schedule, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[Schedule]
+	if err := client.Do("POST", fmt.Sprintf("/cli/project/%s/schedules", url.PathEscape(projectId)), schedule, &resp); err != nil {
+		return nil, fmt.Errorf("error creating schedule: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error creating schedule: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// ListWebhooks will list the outbound webhooks of the agents in the project
+func ListWebhooks(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) ([]Webhook, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]Webhook]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/webhooks", url.PathEscape(projectId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error listing webhooks: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// CreateWebhook will create an outbound webhook for an agent in the project
+func CreateWebhook(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, webhook Webhook) (*Webhook, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[Webhook]
+	if err := client.Do("POST", fmt.Sprintf("/cli/project/%s/webhooks", url.PathEscape(projectId)), webhook, &resp); err != nil {
+		return nil, fmt.Errorf("error creating webhook: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error creating webhook: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
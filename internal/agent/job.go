@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// QueueType is the running type of the agents which are invoked asynchronously from a queue
+const QueueType = "queue"
+
+// The status of a job
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job is an asynchronous invocation of an agent
+type Job struct {
+	ID          string     `json:"id"`
+	AgentID     string     `json:"agent_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ContentType is the content type of the response of the agent
+	ContentType string `json:"content_type,omitempty"`
+	// Result is the base64 encoded response of the agent once the job is completed
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Done returns true when the job won't change anymore
+func (j *Job) Done() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// Duration returns how long the job ran, or has been running when it isn't done
+func (j *Job) Duration() time.Duration {
+	if j.StartedAt == nil {
+		return 0
+	}
+	if j.CompletedAt != nil {
+		return j.CompletedAt.Sub(*j.StartedAt)
+	}
+	return time.Since(*j.StartedAt)
+}
+
+// Response returns the decoded response of the agent
+func (j *Job) Response() ([]byte, error) {
+	body, err := base64.StdEncoding.DecodeString(j.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the result of job %s: %w", j.ID, err)
+	}
+	return body, nil
+}
+
+// IsQueued returns true if the agent is configured to be invoked from a queue
+func (a *Agent) IsQueued() bool {
+	for _, t := range a.Types {
+		if t == QueueType {
+			return true
+		}
+	}
+	return false
+}
+
+type enqueueRequest struct {
+	ContentType string `json:"content_type"`
+	Payload     string `json:"payload"`
+	Tag         string `json:"tag,omitempty"`
+}
+
+// EnqueueAgent queues an invocation of the agent with the payload and returns its job. The payload
+// is base64 encoded since the API only accepts JSON.
+func EnqueueAgent(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, agentId string, payload *Payload, tag string) (*Job, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	body := enqueueRequest{
+		ContentType: payload.ContentType,
+		Payload:     base64.StdEncoding.EncodeToString(payload.Body),
+		Tag:         tag,
+	}
+	var resp Response[Job]
+	if err := client.Do("POST", fmt.Sprintf("/cli/agent/%s/%s/jobs", url.PathEscape(projectId), url.PathEscape(agentId)), body, &resp); err != nil {
+		return nil, fmt.Errorf("error enqueuing agent: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error enqueuing agent: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// GetJob returns the job
+func GetJob(ctx context.Context, logger logger.Logger, baseUrl string, token string, jobId string) (*Job, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[Job]
+	if err := client.Do("GET", fmt.Sprintf("/cli/job/%s", url.PathEscape(jobId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching job: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching job: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// CancelJob cancels the job if it isn't done and returns it
+func CancelJob(ctx context.Context, logger logger.Logger, baseUrl string, token string, jobId string) (*Job, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[Job]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/job/%s", url.PathEscape(jobId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error cancelling job: %s", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error cancelling job: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// WaitForJob polls the job with get at the interval until it's done and returns it. The update
+// function is called when the status of the job changes.
+func WaitForJob(ctx context.Context, interval time.Duration, get func(ctx context.Context) (*Job, error), update func(job *Job)) (*Job, error) {
+	var status string
+	for {
+		job, err := get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status != status {
+			status = job.Status
+			if update != nil {
+				update(job)
+			}
+		}
+		if job.Done() {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
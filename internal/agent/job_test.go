@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForJob(t *testing.T) {
+	statuses := []string{JobQueued, JobQueued, JobRunning, JobCompleted}
+	var calls int
+	get := func(ctx context.Context) (*Job, error) {
+		job := &Job{ID: "job_123", Status: statuses[calls], Result: base64.StdEncoding.EncodeToString([]byte("done"))}
+		calls++
+		return job, nil
+	}
+	var updates []string
+	job, err := WaitForJob(context.Background(), time.Millisecond, get, func(job *Job) { updates = append(updates, job.Status) })
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []string{JobQueued, JobRunning, JobCompleted}, updates)
+	assert.True(t, job.Done())
+	body, err := job.Response()
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(body))
+
+	// the last status is returned when the wait times out
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	job, err = WaitForJob(ctx, 10*time.Millisecond, func(ctx context.Context) (*Job, error) {
+		return &Job{ID: "job_123", Status: JobRunning}, nil
+	}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, JobRunning, job.Status)
+
+	_, err = WaitForJob(context.Background(), time.Millisecond, func(ctx context.Context) (*Job, error) {
+		return nil, errors.New("boom")
+	}, nil)
+	assert.EqualError(t, err, "boom")
+}
+
+func TestJob(t *testing.T) {
+	started := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	completed := started.Add(90 * time.Second)
+	job := &Job{Status: JobFailed, StartedAt: &started, CompletedAt: &completed}
+	assert.True(t, job.Done())
+	assert.Equal(t, 90*time.Second, job.Duration())
+	assert.False(t, (&Job{Status: JobQueued}).Done())
+	assert.Zero(t, (&Job{Status: JobQueued}).Duration())
+
+	assert.True(t, (&Agent{Types: []string{"api", QueueType}}).IsQueued())
+	assert.False(t, (&Agent{Types: []string{"webhook"}}).IsQueued())
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	formContentType       = "multipart/form-data"
+	urlEncodedContentType = "application/x-www-form-urlencoded"
+)
+
+// Payload is the body of a request to an agent with its content type
+type Payload struct {
+	ContentType string
+	Body        []byte
+	// Base64 is set when the body is sent base64 encoded
+	Base64 bool
+}
+
+// PayloadOptions are the sources of the payload of a request to an agent. Only one of Data, File
+// and Form can be set.
+type PayloadOptions struct {
+	// Data is the payload as is
+	Data string
+	// File is the file to send as the payload or - for stdin
+	File string
+	// Form are the fields of a form as key=value, or key=@path to attach a file
+	Form []string
+	// ContentType is the content type of the payload, detected when empty
+	ContentType string
+	// Base64 sends the body base64 encoded for the agents and proxies which only accept text
+	Base64 bool
+	// Stdin is read when File is -
+	Stdin io.Reader
+}
+
+// NewPayload returns the payload of the options, detecting its content type when it isn't set
+func NewPayload(opts PayloadOptions) (*Payload, error) {
+	var sources int
+	for _, set := range []bool{opts.Data != "", opts.File != "", len(opts.Form) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("only one of the payload, a file or form fields can be sent")
+	}
+	var p *Payload
+	var err error
+	switch {
+	case opts.File != "":
+		p, err = filePayload(opts.File, opts.ContentType, opts.Stdin)
+	case len(opts.Form) > 0:
+		p, err = formPayload(opts.Form, opts.ContentType)
+	default:
+		p = &Payload{ContentType: opts.ContentType, Body: []byte(opts.Data)}
+		if p.ContentType == "" {
+			p.ContentType = detectTextContentType(p.Body)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Base64 {
+		p.Body = []byte(base64.StdEncoding.EncodeToString(p.Body))
+		p.Base64 = true
+	}
+	return p, nil
+}
+
+// detectTextContentType returns application/json for JSON and text/plain otherwise
+func detectTextContentType(body []byte) string {
+	if json.Valid(body) {
+		return "application/json"
+	}
+	return "text/plain"
+}
+
+// DetectContentType returns the content type of the file from its extension or else its content
+func DetectContentType(filename string, body []byte) string {
+	if ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); ct != "" {
+		return ct
+	}
+	ct := http.DetectContentType(body)
+	if strings.HasPrefix(ct, "text/plain") {
+		return detectTextContentType(body)
+	}
+	return ct
+}
+
+func filePayload(filename string, contentType string, stdin io.Reader) (*Payload, error) {
+	var body []byte
+	var err error
+	if filename == "-" {
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		body, err = io.ReadAll(stdin)
+	} else {
+		body, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	if contentType == "" {
+		contentType = DetectContentType(filename, body)
+	}
+	return &Payload{ContentType: contentType, Body: body}, nil
+}
+
+// formPayload returns the form as multipart/form-data, or URL encoded when that is the content type
+// and no files are attached. The boundary is derived from the fields so that the same form has the
+// same body, which keeps the names of its snapshots stable.
+func formPayload(fields []string, contentType string) (*Payload, error) {
+	if contentType == "" {
+		contentType = formContentType
+	}
+	mediaType := strings.Split(contentType, ";")[0]
+	if mediaType != formContentType && mediaType != urlEncodedContentType {
+		return nil, fmt.Errorf("form fields can only be sent as %s or %s", formContentType, urlEncodedContentType)
+	}
+	type field struct {
+		key, value, filename string
+		content              []byte
+	}
+	var parsed []field
+	hash := sha256.New()
+	for _, kv := range fields {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid form field %q, use key=value or key=@path", kv)
+		}
+		f := field{key: key, value: value}
+		if path, isFile := strings.CutPrefix(value, "@"); isFile {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			f.filename = path
+			f.content = content
+		}
+		parsed = append(parsed, f)
+		fmt.Fprintf(hash, "%s\x00%s\x00", key, value)
+		hash.Write(f.content)
+	}
+
+	if mediaType == urlEncodedContentType {
+		values := url.Values{}
+		for _, f := range parsed {
+			if f.filename != "" {
+				return nil, fmt.Errorf("files can't be attached to a %s form", urlEncodedContentType)
+			}
+			values.Add(f.key, f.value)
+		}
+		return &Payload{ContentType: contentType, Body: []byte(values.Encode())}, nil
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary("agentuity-" + hex.EncodeToString(hash.Sum(nil))[:32]); err != nil {
+		return nil, err
+	}
+	for _, f := range parsed {
+		if f.filename == "" {
+			if err := w.WriteField(f.key, f.value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": f.key, "filename": filepath.Base(f.filename)}))
+		header.Set("Content-Type", DetectContentType(f.filename, f.content))
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(f.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &Payload{ContentType: w.FormDataContentType(), Body: buf.Bytes()}, nil
+}
+
+// Text returns the payload as text with its encoding, which is base64 when the body isn't valid
+// UTF-8, such as to store it in a snapshot
+func (p *Payload) Text() (string, string) {
+	if utf8.Valid(p.Body) {
+		return string(p.Body), ""
+	}
+	return base64.StdEncoding.EncodeToString(p.Body), "base64"
+}
+
+// IsJSON returns true if the content type of the payload is JSON
+func (p *Payload) IsJSON() bool {
+	mediaType, _, _ := mime.ParseMediaType(p.ContentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Describe returns a short description of the payload for the output, which is the payload itself
+// when it's short text
+func (p *Payload) Describe() string {
+	if utf8.Valid(p.Body) && len(p.Body) <= 200 && !strings.HasPrefix(p.ContentType, formContentType) {
+		return string(p.Body)
+	}
+	return fmt.Sprintf("<%s, %d bytes>", strings.Split(p.ContentType, ";")[0], len(p.Body))
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestNewPayloadData(t *testing.T) {
+	p, err := NewPayload(PayloadOptions{Data: `{"hello": "world"}`})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", p.ContentType)
+	assert.True(t, p.IsJSON())
+
+	p, err = NewPayload(PayloadOptions{Data: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", p.ContentType)
+	assert.False(t, p.IsJSON())
+	assert.Equal(t, "hello", p.Describe())
+
+	p, err = NewPayload(PayloadOptions{Data: "{}", ContentType: "application/vnd.api+json"})
+	require.NoError(t, err)
+	assert.True(t, p.IsJSON())
+
+	_, err = NewPayload(PayloadOptions{Data: "hello", File: "image.png"})
+	assert.Error(t, err)
+}
+
+func TestNewPayloadFile(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	require.NoError(t, os.WriteFile(image, pngHeader, 0644))
+	p, err := NewPayload(PayloadOptions{File: image})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", p.ContentType)
+	assert.Equal(t, pngHeader, p.Body)
+	assert.Equal(t, "<image/png, 16 bytes>", p.Describe())
+	text, encoding := p.Text()
+	assert.Equal(t, "base64", encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pngHeader), text)
+
+	// the content is sniffed when the extension is unknown
+	p, err = NewPayload(PayloadOptions{File: "-", Stdin: bytes.NewReader(pngHeader)})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", p.ContentType)
+	p, err = NewPayload(PayloadOptions{File: "-", Stdin: strings.NewReader(`{"a": 1}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", p.ContentType)
+
+	p, err = NewPayload(PayloadOptions{File: image, ContentType: "application/octet-stream", Base64: true})
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", p.ContentType)
+	assert.True(t, p.Base64)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pngHeader), string(p.Body))
+
+	_, err = NewPayload(PayloadOptions{File: filepath.Join(dir, "missing.png")})
+	assert.ErrorContains(t, err, "missing.png")
+}
+
+func TestNewPayloadForm(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	require.NoError(t, os.WriteFile(image, pngHeader, 0644))
+	p, err := NewPayload(PayloadOptions{Form: []string{"prompt=describe it", "image=@" + image}})
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(p.ContentType)
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+	r := multipart.NewReader(bytes.NewReader(p.Body), params["boundary"])
+	part, err := r.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "prompt", part.FormName())
+	value, _ := io.ReadAll(part)
+	assert.Equal(t, "describe it", string(value))
+	part, err = r.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "image", part.FormName())
+	assert.Equal(t, "image.png", part.FileName())
+	assert.Equal(t, "image/png", part.Header.Get("Content-Type"))
+	content, _ := io.ReadAll(part)
+	assert.Equal(t, pngHeader, content)
+
+	// the same form has the same body so that its snapshot name is stable
+	again, err := NewPayload(PayloadOptions{Form: []string{"prompt=describe it", "image=@" + image}})
+	require.NoError(t, err)
+	assert.Equal(t, p, again)
+
+	p, err = NewPayload(PayloadOptions{Form: []string{"a=1", "b=two words"}, ContentType: "application/x-www-form-urlencoded"})
+	require.NoError(t, err)
+	assert.Equal(t, "a=1&b=two+words", string(p.Body))
+
+	_, err = NewPayload(PayloadOptions{Form: []string{"image=@" + image}, ContentType: "application/x-www-form-urlencoded"})
+	assert.Error(t, err)
+	_, err = NewPayload(PayloadOptions{Form: []string{"novalue"}})
+	assert.Error(t, err)
+	_, err = NewPayload(PayloadOptions{Form: []string{"a=1"}, ContentType: "application/json"})
+	assert.Error(t, err)
+}
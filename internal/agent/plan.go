@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"path"
+	"slices"
+)
+
+// The actions of the changes of a plan
+const (
+	PlanCreate = "create"
+	PlanModify = "modify"
+	PlanDelete = "delete"
+	PlanRun    = "run"
+)
+
+// manifestFiles are the files of the project which declare its configuration and dependencies
+var manifestFiles = []string{
+	"agentuity.yaml",
+	"package.json",
+	"package-lock.json",
+	"bun.lock",
+	"bun.lockb",
+	"pnpm-lock.yaml",
+	"yarn.lock",
+	"tsconfig.json",
+	"pyproject.toml",
+	"uv.lock",
+	"requirements.txt",
+	".env",
+}
+
+// PlanChange is a change which a command would make
+type PlanChange struct {
+	Action string `json:"action"`
+	// Target is the path of the file relative to the project directory, the name of the cloud
+	// entity or the command line
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Plan is the changes which a command would make to the project, its manifests and the cloud
+type Plan struct {
+	Files     []PlanChange `json:"files"`
+	Manifests []PlanChange `json:"manifests"`
+	Cloud     []PlanChange `json:"cloud"`
+	Commands  []PlanChange `json:"commands"`
+}
+
+// NewPlan returns an empty plan
+func NewPlan() *Plan {
+	return &Plan{
+		Files:     []PlanChange{},
+		Manifests: []PlanChange{},
+		Cloud:     []PlanChange{},
+		Commands:  []PlanChange{},
+	}
+}
+
+// IsManifest returns true if the file of the project is a manifest
+func IsManifest(filename string) bool {
+	return slices.Contains(manifestFiles, path.Base(filename))
+}
+
+// AddFile adds a change of a file of the project, which is a manifest change when the file is a
+// manifest. A file which is already in the plan keeps its first action unless it is deleted.
+func (p *Plan) AddFile(action string, filename string, detail string) {
+	changes := &p.Files
+	if IsManifest(filename) {
+		changes = &p.Manifests
+	}
+	for i, c := range *changes {
+		if c.Target == filename {
+			if action == PlanDelete {
+				(*changes)[i].Action = PlanDelete
+			}
+			if detail != "" && c.Detail == "" {
+				(*changes)[i].Detail = detail
+			}
+			return
+		}
+	}
+	*changes = append(*changes, PlanChange{Action: action, Target: filename, Detail: detail})
+}
+
+// AddCloud adds a change of an entity in the cloud
+func (p *Plan) AddCloud(action string, entity string, detail string) {
+	p.Cloud = append(p.Cloud, PlanChange{Action: action, Target: entity, Detail: detail})
+}
+
+// AddCommand adds a command which would run in the project directory
+func (p *Plan) AddCommand(command string, detail string) {
+	p.Commands = append(p.Commands, PlanChange{Action: PlanRun, Target: command, Detail: detail})
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	plan := NewPlan()
+	plan.AddFile(PlanCreate, "src/agents/my-agent/index.ts", "")
+	plan.AddFile(PlanModify, "src/agents/my-agent/index.ts", "the name of the agent")
+	plan.AddFile(PlanModify, "package.json", "")
+	plan.AddFile(PlanModify, "agentuity.yaml", "add agent my-agent")
+	plan.AddFile(PlanCreate, "src/agents/my-agent/old.ts", "")
+	plan.AddFile(PlanDelete, "src/agents/my-agent/old.ts", "")
+	plan.AddCloud(PlanCreate, "agent my-agent", "project authentication")
+	plan.AddCommand("bun install", "")
+
+	assert.Equal(t, []PlanChange{
+		{Action: PlanCreate, Target: "src/agents/my-agent/index.ts", Detail: "the name of the agent"},
+		{Action: PlanDelete, Target: "src/agents/my-agent/old.ts"},
+	}, plan.Files)
+	assert.Equal(t, []PlanChange{
+		{Action: PlanModify, Target: "package.json"},
+		{Action: PlanModify, Target: "agentuity.yaml", Detail: "add agent my-agent"},
+	}, plan.Manifests)
+	assert.Equal(t, []PlanChange{{Action: PlanCreate, Target: "agent my-agent", Detail: "project authentication"}}, plan.Cloud)
+	assert.Equal(t, []PlanChange{{Action: PlanRun, Target: "bun install"}}, plan.Commands)
+
+	assert.True(t, IsManifest("sub/pyproject.toml"))
+	assert.False(t, IsManifest("src/agents/package/index.ts"))
+
+	buf, err := json.Marshal(NewPlan())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"files":[],"manifests":[],"cloud":[],"commands":[]}`, string(buf))
+}
@@ -0,0 +1,369 @@
+package agent
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/agentuity/cli/internal/envutil"
+	"github.com/agentuity/cli/internal/ignore"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFilename is the name of the manifest of a published agent. The manifest in the agent
+// directory is optional and overrides what is derived from the project. The published bundle
+// always contains it.
+const ManifestFilename = "agent.yaml"
+
+const (
+	// VisibilityPublic lists the agent in the registry
+	VisibilityPublic = "public"
+	// VisibilityUnlisted makes the agent importable by anyone with its name but doesn't list it
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate only makes the agent importable by the members of the organization
+	VisibilityPrivate = "private"
+
+	// PricingFree is an agent which is free to import
+	PricingFree = "free"
+	// PricingPaid is an agent which requires a purchase to import
+	PricingPaid = "paid"
+)
+
+// EnvRequirement is an environment variable which the agent needs to run
+type EnvRequirement struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Optional    bool   `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// Manifest describes an agent published to the community registry
+type Manifest struct {
+	Name        string           `yaml:"name" json:"name"`
+	Version     string           `yaml:"version" json:"version"`
+	Description string           `yaml:"description" json:"description"`
+	Language    string           `yaml:"language" json:"language"`
+	Runtime     string           `yaml:"runtime" json:"runtime"`
+	Tags        []string         `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Env         []EnvRequirement `yaml:"env,omitempty" json:"env,omitempty"`
+	Visibility  string           `yaml:"visibility" json:"visibility"`
+	Pricing     string           `yaml:"pricing" json:"pricing"`
+	License     string           `yaml:"license,omitempty" json:"license,omitempty"`
+	Repository  string           `yaml:"repository,omitempty" json:"repository,omitempty"`
+	Homepage    string           `yaml:"homepage,omitempty" json:"homepage,omitempty"`
+}
+
+// LoadManifest loads the manifest in the agent directory and returns an empty manifest when there
+// is none
+func LoadManifest(agentDir string) (*Manifest, error) {
+	var manifest Manifest
+	buf, err := os.ReadFile(filepath.Join(agentDir, ManifestFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &manifest, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(buf, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", ManifestFilename, err)
+	}
+	return &manifest, nil
+}
+
+// ScanEnvRequirements adds the environment variables referenced in the source of the agent which
+// the manifest doesn't declare as required. The variables set by Agentuity are skipped.
+func (m *Manifest) ScanEnvRequirements(agentDir string) error {
+	refs, err := envutil.ScanForEnvironmentVariables(agentDir, "")
+	if err != nil {
+		return err
+	}
+	declared := make(map[string]bool)
+	for _, env := range m.Env {
+		declared[env.Name] = true
+	}
+	for _, ref := range refs {
+		if declared[ref.Key] || strings.HasPrefix(ref.Key, "AGENTUITY_") {
+			continue
+		}
+		m.Env = append(m.Env, EnvRequirement{Name: ref.Key})
+	}
+	sort.SliceStable(m.Env, func(i, j int) bool { return m.Env[i].Name < m.Env[j].Name })
+	return nil
+}
+
+// ValidationError is returned when the manifest of an agent fails validation
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("agent is invalid: %s", strings.Join(e.Problems, "; "))
+}
+
+var (
+	registryName = regexp.MustCompile(`^[a-z0-9][a-z0-9-_]{1,63}$`)
+	envName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Validate returns a ValidationError with all the problems of the manifest
+func (m *Manifest) Validate() error {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if m.Name == "" {
+		problem("name is required")
+	} else if !registryName.MatchString(m.Name) {
+		problem("name %s must be 2 to 64 lowercase letters, numbers, dashes or underscores", m.Name)
+	}
+	if m.Version == "" {
+		problem("version is required")
+	} else if _, err := semver.NewVersion(m.Version); err != nil {
+		problem("version %s is not a valid semantic version", m.Version)
+	}
+	if m.Description == "" {
+		problem("description is required")
+	}
+	if m.Language == "" {
+		problem("language is required")
+	}
+	if m.Runtime == "" {
+		problem("runtime is required")
+	}
+	switch m.Visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+	default:
+		problem("visibility %q must be %s, %s or %s", m.Visibility, VisibilityPublic, VisibilityUnlisted, VisibilityPrivate)
+	}
+	switch m.Pricing {
+	case PricingFree, PricingPaid:
+	default:
+		problem("pricing %q must be %s or %s", m.Pricing, PricingFree, PricingPaid)
+	}
+	if m.Pricing == PricingPaid && m.Visibility == VisibilityPrivate {
+		problem("a private agent can't be paid")
+	}
+	seen := make(map[string]bool)
+	for _, env := range m.Env {
+		if !envName.MatchString(env.Name) {
+			problem("env %q is not a valid environment variable name", env.Name)
+		}
+		if seen[env.Name] {
+			problem("env %s is declared more than once", env.Name)
+		}
+		seen[env.Name] = true
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// PublishedVersion is a version of an agent in the community registry
+type PublishedVersion struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Deprecated string `json:"deprecated,omitempty"`
+	UploadURL  string `json:"uploadUrl,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+// CheckVersion returns an error if the version can't be published since it was already published
+// or isn't greater than the latest published version
+func CheckVersion(version string, published []PublishedVersion) error {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("version %s is not a valid semantic version", version)
+	}
+	var latest *semver.Version
+	for _, p := range published {
+		pv, err := semver.NewVersion(p.Version)
+		if err != nil {
+			continue
+		}
+		if pv.Equal(v) {
+			return fmt.Errorf("version %s was already published", version)
+		}
+		if latest == nil || pv.GreaterThan(latest) {
+			latest = pv
+		}
+	}
+	if latest != nil && !v.GreaterThan(latest) {
+		return fmt.Errorf("version %s must be greater than the latest published version %s", version, latest)
+	}
+	return nil
+}
+
+// BumpVersion returns the next version after the latest published version where bump is one of
+// major, minor or patch. The first version is 0.1.0.
+func BumpVersion(bump string, published []PublishedVersion) (string, error) {
+	latest, _ := semver.NewVersion("0.0.0")
+	for _, p := range published {
+		if pv, err := semver.NewVersion(p.Version); err == nil && pv.GreaterThan(latest) {
+			latest = pv
+		}
+	}
+	var next semver.Version
+	switch bump {
+	case "major":
+		next = latest.IncMajor()
+	case "minor":
+		next = latest.IncMinor()
+	case "patch":
+		if latest.Equal(semver.MustParse("0.0.0")) {
+			next = latest.IncMinor()
+		} else {
+			next = latest.IncPatch()
+		}
+	default:
+		return "", fmt.Errorf("invalid bump %q: must be major, minor or patch", bump)
+	}
+	return next.String(), nil
+}
+
+// PackageAgent writes the agent directory and the manifest to zipfile. The files ignored by the
+// .gitignore of the project (and the default ignores) aren't packaged.
+func PackageAgent(projectDir string, agentDir string, manifest *Manifest, zipfile string) error {
+	rules := ignore.Empty()
+	if gitignore := filepath.Join(projectDir, ignore.Ignore); util.Exists(gitignore) {
+		if r, err := ignore.ParseFile(gitignore); err == nil {
+			rules = r
+		}
+	}
+	rules.AddDefaults()
+	buf, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return util.ZipDir(agentDir, zipfile, util.WithMatcher(func(fn string, fi os.FileInfo) bool {
+		return fn != ManifestFilename && !rules.Ignore(fn, fi)
+	}), util.WithMutator(func(zw *zip.Writer) error {
+		w, err := zw.Create(ManifestFilename)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}))
+}
+
+func registryPath(orgId string, name string, rest ...string) string {
+	val := fmt.Sprintf("/cli/registry/agents/%s/%s", url.PathEscape(orgId), url.PathEscape(name))
+	for _, r := range rest {
+		val += "/" + url.PathEscape(r)
+	}
+	return val
+}
+
+// ListPublishedVersions returns the versions of the agent in the registry or nil if the agent
+// was never published
+func ListPublishedVersions(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string, name string) ([]PublishedVersion, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[[]PublishedVersion]
+	if err := client.Do("GET", registryPath(orgId, name, "versions"), nil, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing the published versions: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error listing the published versions: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// PublishRequest is the request to publish a version of an agent to the registry
+type PublishRequest struct {
+	OrgId string `json:"orgId"`
+	Manifest
+}
+
+// PublishAgent creates the agent version in the registry and uploads the bundle
+func PublishAgent(ctx context.Context, logger logger.Logger, baseUrl string, token string, req PublishRequest, zipfile string) (*PublishedVersion, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[PublishedVersion]
+	if err := client.Do("POST", "/cli/registry/agents", req, &resp); err != nil {
+		return nil, fmt.Errorf("error publishing agent: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error publishing agent: %s", resp.Message)
+	}
+	published := resp.Data
+
+	of, err := os.Open(zipfile)
+	if err != nil {
+		return nil, err
+	}
+	defer of.Close()
+	fi, err := of.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: this is a one-time signed url so we don't use the api client
+	upload, err := http.NewRequestWithContext(ctx, "PUT", util.TransformUrl(published.UploadURL), of)
+	if err != nil {
+		return nil, fmt.Errorf("error creating upload request: %w", err)
+	}
+	upload.ContentLength = fi.Size()
+	upload.Header.Set("Content-Type", "application/zip")
+	upload.Header.Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+	uploadResp, err := http.DefaultClient.Do(upload)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading agent: %w", err)
+	}
+	uploadResp.Body.Close()
+	if uploadResp.StatusCode > 299 {
+		return nil, fmt.Errorf("error uploading agent: %s", uploadResp.Status)
+	}
+
+	var completeResp Response[PublishedVersion]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/registry/agents/%s/complete", url.PathEscape(published.ID)), nil, &completeResp); err != nil {
+		return nil, fmt.Errorf("error completing agent publish: %w", err)
+	}
+	if !completeResp.Success {
+		return nil, fmt.Errorf("error completing agent publish: %s", completeResp.Message)
+	}
+	return &completeResp.Data, nil
+}
+
+// UnpublishAgent removes the version of the agent from the registry. Projects which already
+// imported it keep their copy.
+func UnpublishAgent(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string, name string, version string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[any]
+	if err := client.Do("DELETE", registryPath(orgId, name, version), nil, &resp); err != nil {
+		return fmt.Errorf("error unpublishing agent: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error unpublishing agent: %s", resp.Message)
+	}
+	return nil
+}
+
+// DeprecateAgent marks the version of the agent (or every version when version is empty) as
+// deprecated with the message shown to the users who import it. An empty message removes the
+// deprecation.
+func DeprecateAgent(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string, name string, version string, message string) ([]PublishedVersion, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[[]PublishedVersion]
+	if err := client.Do("PUT", registryPath(orgId, name, "deprecate"), map[string]string{"version": version, "message": message}, &resp); err != nil {
+		return nil, fmt.Errorf("error deprecating agent: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error deprecating agent: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
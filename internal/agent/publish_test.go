@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestManifestValidate(t *testing.T) {
+	manifest := Manifest{Name: "order-lookup", Version: "1.0.0", Description: "Answers questions about orders", Language: "javascript", Runtime: "bunjs", Visibility: VisibilityPublic, Pricing: PricingFree}
+	assert.NoError(t, manifest.Validate())
+
+	invalid := Manifest{Name: "Order Lookup", Version: "one", Language: "javascript", Runtime: "bunjs", Visibility: "everyone", Pricing: PricingPaid, Env: []EnvRequirement{{Name: "API-KEY"}, {Name: "TOKEN"}, {Name: "TOKEN"}}}
+	err := invalid.Validate()
+	require.Error(t, err)
+	verr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	assert.Equal(t, []string{
+		"name Order Lookup must be 2 to 64 lowercase letters, numbers, dashes or underscores",
+		"version one is not a valid semantic version",
+		"description is required",
+		`visibility "everyone" must be public, unlisted or private`,
+		`env "API-KEY" is not a valid environment variable name`,
+		"env TOKEN is declared more than once",
+	}, verr.Problems)
+
+	private := manifest
+	private.Visibility = VisibilityPrivate
+	private.Pricing = PricingPaid
+	assert.EqualError(t, private.Validate(), "agent is invalid: a private agent can't be paid")
+}
+
+func TestCheckVersion(t *testing.T) {
+	published := []PublishedVersion{{Version: "1.0.0"}, {Version: "1.2.0"}, {Version: "1.1.0"}}
+	assert.NoError(t, CheckVersion("1.2.1", published))
+	assert.NoError(t, CheckVersion("2.0.0-beta.1", published))
+	assert.NoError(t, CheckVersion("0.1.0", nil))
+	assert.EqualError(t, CheckVersion("1.1.0", published), "version 1.1.0 was already published")
+	assert.EqualError(t, CheckVersion("1.1.5", published), "version 1.1.5 must be greater than the latest published version 1.2.0")
+	assert.EqualError(t, CheckVersion("latest", published), "version latest is not a valid semantic version")
+}
+
+func TestBumpVersion(t *testing.T) {
+	published := []PublishedVersion{{Version: "1.0.0"}, {Version: "1.2.3"}}
+	for bump, expected := range map[string]string{"major": "2.0.0", "minor": "1.3.0", "patch": "1.2.4"} {
+		version, err := BumpVersion(bump, published)
+		require.NoError(t, err)
+		assert.Equal(t, expected, version, bump)
+	}
+	version, err := BumpVersion("patch", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0.1.0", version)
+	_, err = BumpVersion("huge", published)
+	assert.Error(t, err)
+}
+
+func TestScanEnvRequirements(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.ts"), []byte("const a = process.env.SHOPIFY_TOKEN;\nconst b = process.env.AGENTUITY_SDK_KEY;\nconst c = process.env.OPENAI_API_KEY;\n"), 0644))
+	manifest := Manifest{Env: []EnvRequirement{{Name: "SHOPIFY_TOKEN", Description: "The Admin API token"}}}
+	require.NoError(t, manifest.ScanEnvRequirements(dir))
+	assert.Equal(t, []EnvRequirement{
+		{Name: "OPENAI_API_KEY"},
+		{Name: "SHOPIFY_TOKEN", Description: "The Admin API token"},
+	}, manifest.Env)
+}
+
+func TestPackageAgent(t *testing.T) {
+	projectDir := t.TempDir()
+	agentDir := filepath.Join(projectDir, "src", "agents", "order-lookup")
+	require.NoError(t, os.MkdirAll(filepath.Join(agentDir, "node_modules", "dep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "index.ts"), []byte("export default {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "debug.log"), []byte("log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "node_modules", "dep", "index.js"), []byte("\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, ManifestFilename), []byte("name: old\n"), 0644))
+
+	manifest := &Manifest{Name: "order-lookup", Version: "1.0.0"}
+	zipfile := filepath.Join(t.TempDir(), "agent.zip")
+	require.NoError(t, PackageAgent(projectDir, agentDir, manifest, zipfile))
+
+	zr, err := zip.OpenReader(zipfile)
+	require.NoError(t, err)
+	defer zr.Close()
+	var names []string
+	var packaged Manifest
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == ManifestFilename {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			require.NoError(t, yaml.NewDecoder(rc).Decode(&packaged))
+			rc.Close()
+		}
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{ManifestFilename, "index.ts"}, names)
+	assert.Equal(t, "order-lookup", packaged.Name)
+	assert.Equal(t, "1.0.0", packaged.Version)
+}
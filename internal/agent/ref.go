@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agentuity/cli/internal/project"
+)
+
+// Ref is a reference to an agent of any project the user has access to such as
+// my-org/my-project/my-agent. Each part is a name or an id, and the organization can be left out
+// when only one of the projects has the name.
+type Ref struct {
+	Org     string
+	Project string
+	Agent   string
+}
+
+// ParseRef parses a reference in the form org/project/agent or project/agent
+func ParseRef(s string) (Ref, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return Ref{}, fmt.Errorf("invalid agent %q, expected <org>/<project>/<agent>", s)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return Ref{Project: strings.TrimSpace(parts[0]), Agent: strings.TrimSpace(parts[1])}, nil
+	case 3:
+		return Ref{Org: strings.TrimSpace(parts[0]), Project: strings.TrimSpace(parts[1]), Agent: strings.TrimSpace(parts[2])}, nil
+	}
+	return Ref{}, fmt.Errorf("invalid agent %q, expected <org>/<project>/<agent>", s)
+}
+
+func (r Ref) String() string {
+	if r.Org == "" {
+		return r.Project + "/" + r.Agent
+	}
+	return r.Org + "/" + r.Project + "/" + r.Agent
+}
+
+// ResolveProject returns the project of the reference among the projects the user has access to
+func (r Ref) ResolveProject(projects []project.ProjectListData) (*project.ProjectListData, error) {
+	var found []*project.ProjectListData
+	for i, p := range projects {
+		if r.Org != "" && p.OrgId != r.Org && !strings.EqualFold(p.OrgName, r.Org) {
+			continue
+		}
+		if p.ID == r.Project || strings.EqualFold(p.Name, r.Project) {
+			found = append(found, &projects[i])
+		}
+	}
+	switch len(found) {
+	case 0:
+		if r.Org != "" {
+			return nil, fmt.Errorf("project %s not found in organization %s or you don't have access to it", r.Project, r.Org)
+		}
+		return nil, fmt.Errorf("project %s not found or you don't have access to it", r.Project)
+	case 1:
+		return found[0], nil
+	}
+	var names []string
+	for _, p := range found {
+		names = append(names, fmt.Sprintf("%s/%s (%s)", p.OrgName, p.Name, p.ID))
+	}
+	return nil, fmt.Errorf("project %s is ambiguous, it matches %s. Use the organization or the project id to choose one", r.Project, strings.Join(names, ", "))
+}
+
+// ResolveAgent returns the agent of the reference among the agents of its project
+func (r Ref) ResolveAgent(agents []Agent) (*Agent, error) {
+	var found *Agent
+	for i, a := range agents {
+		if a.ID == r.Agent {
+			return &agents[i], nil
+		}
+		if strings.EqualFold(a.Name, r.Agent) {
+			if found != nil {
+				return nil, fmt.Errorf("agent %s is ambiguous, use the agent id to choose one", r.Agent)
+			}
+			found = &agents[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("agent %s not found in project %s", r.Agent, r.Project)
+	}
+	return found, nil
+}
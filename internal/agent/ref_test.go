@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	ref, err := ParseRef("acme/support/triage")
+	require.NoError(t, err)
+	assert.Equal(t, Ref{Org: "acme", Project: "support", Agent: "triage"}, ref)
+	assert.Equal(t, "acme/support/triage", ref.String())
+
+	ref, err = ParseRef(" proj_1/agent_1 ")
+	require.NoError(t, err)
+	assert.Equal(t, Ref{Project: "proj_1", Agent: "agent_1"}, ref)
+	assert.Equal(t, "proj_1/agent_1", ref.String())
+
+	for _, s := range []string{"triage", "acme//triage", "a/b/c/d", "/support/triage", ""} {
+		_, err := ParseRef(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRefResolveProject(t *testing.T) {
+	projects := []project.ProjectListData{
+		{ID: "proj_1", Name: "Support", OrgId: "org_1", OrgName: "Acme"},
+		{ID: "proj_2", Name: "support", OrgId: "org_2", OrgName: "Globex"},
+		{ID: "proj_3", Name: "billing", OrgId: "org_2", OrgName: "Globex"},
+	}
+
+	p, err := Ref{Org: "acme", Project: "support"}.ResolveProject(projects)
+	require.NoError(t, err)
+	assert.Equal(t, "proj_1", p.ID)
+
+	p, err = Ref{Org: "org_2", Project: "support"}.ResolveProject(projects)
+	require.NoError(t, err)
+	assert.Equal(t, "proj_2", p.ID)
+
+	p, err = Ref{Project: "billing"}.ResolveProject(projects)
+	require.NoError(t, err)
+	assert.Equal(t, "proj_3", p.ID)
+
+	p, err = Ref{Project: "proj_2"}.ResolveProject(projects)
+	require.NoError(t, err)
+	assert.Equal(t, "proj_2", p.ID)
+
+	_, err = Ref{Project: "support"}.ResolveProject(projects)
+	assert.ErrorContains(t, err, "ambiguous")
+
+	_, err = Ref{Org: "acme", Project: "billing"}.ResolveProject(projects)
+	assert.ErrorContains(t, err, "not found in organization acme")
+}
+
+func TestRefResolveAgent(t *testing.T) {
+	agents := []Agent{{ID: "agent_1", Name: "Triage"}, {ID: "agent_2", Name: "router"}, {ID: "agent_3", Name: "Router"}}
+
+	a, err := Ref{Project: "support", Agent: "triage"}.ResolveAgent(agents)
+	require.NoError(t, err)
+	assert.Equal(t, "agent_1", a.ID)
+
+	a, err = Ref{Project: "support", Agent: "agent_3"}.ResolveAgent(agents)
+	require.NoError(t, err)
+	assert.Equal(t, "agent_3", a.ID)
+
+	_, err = Ref{Project: "support", Agent: "router"}.ResolveAgent(agents)
+	assert.ErrorContains(t, err, "ambiguous")
+
+	_, err = Ref{Project: "support", Agent: "billing"}.ResolveAgent(agents)
+	assert.ErrorContains(t, err, "not found in project support")
+}
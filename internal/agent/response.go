@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agentuity/cli/internal/util"
+)
+
+// IsBinaryResponse returns true if the response of an agent is an artifact such as an image, audio
+// or a PDF which can't be shown as text
+func IsBinaryResponse(contentType string, body []byte) bool {
+	if !utf8.Valid(body) {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) && mediaType != "image/svg+xml" {
+			return true
+		}
+	}
+	switch mediaType {
+	case "application/octet-stream", "application/pdf", "application/zip", "application/gzip":
+		return true
+	}
+	return false
+}
+
+// DescribeResponse returns a short description of a binary response for the output
+func DescribeResponse(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "unknown content type"
+	}
+	return fmt.Sprintf("%s, %d bytes", mediaType, len(body))
+}
+
+// ResponseExitCode returns the exit code of the CLI for the status of the response of an agent,
+// which is 0 unless the agent responded with an error
+func ResponseExitCode(status int) int {
+	switch {
+	case status >= 500:
+		return util.ExitCodeAgentServerError
+	case status >= 400:
+		return util.ExitCodeAgentClientError
+	}
+	return 0
+}
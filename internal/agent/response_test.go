@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBinaryResponse(t *testing.T) {
+	assert.True(t, IsBinaryResponse("image/png", pngHeader))
+	assert.True(t, IsBinaryResponse("audio/mpeg", []byte("ID3")))
+	assert.True(t, IsBinaryResponse("application/pdf", []byte("%PDF-1.7")))
+	assert.True(t, IsBinaryResponse("text/plain", []byte{0xff, 0xfe, 0x00}))
+	assert.False(t, IsBinaryResponse("application/json; charset=utf-8", []byte(`{"a": 1}`)))
+	assert.False(t, IsBinaryResponse("image/svg+xml", []byte("<svg/>")))
+	assert.False(t, IsBinaryResponse("", []byte("hello")))
+	assert.Equal(t, "image/png, 16 bytes", DescribeResponse("image/png", pngHeader))
+}
+
+func TestResponseExitCode(t *testing.T) {
+	assert.Equal(t, 0, ResponseExitCode(200))
+	assert.Equal(t, 0, ResponseExitCode(302))
+	assert.Equal(t, util.ExitCodeAgentClientError, ResponseExitCode(404))
+	assert.Equal(t, util.ExitCodeAgentServerError, ResponseExitCode(503))
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// SnapshotDir is the directory relative to the project where the agent test snapshots are stored
+const SnapshotDir = ".agentuity/snapshots"
+
+// Snapshot is a recorded response of an agent for a payload
+type Snapshot struct {
+	Agent       string `json:"agent"`
+	ContentType string `json:"contentType"`
+	Payload     string `json:"payload"`
+	// PayloadEncoding is base64 when the payload is binary, such as an image
+	PayloadEncoding string          `json:"payloadEncoding,omitempty"`
+	Status          int             `json:"status"`
+	Response        json.RawMessage `json:"response"`
+}
+
+// SnapshotName returns the default name of the snapshot for the payload
+func SnapshotName(contentType string, payload string) string {
+	sum := sha256.Sum256([]byte(contentType + "\n" + payload))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SnapshotFilename returns the filename of the named snapshot for the agent in the project
+func SnapshotFilename(dir string, agentID string, name string) string {
+	return filepath.Join(dir, SnapshotDir, agentID, name+".json")
+}
+
+// NewSnapshot returns a snapshot of the response. JSON responses are stored as JSON so that
+// the key order and formatting of the response don't cause the snapshot to drift.
+func NewSnapshot(agentID string, contentType string, payload string, status int, body []byte) *Snapshot {
+	var response json.RawMessage
+	var val any
+	if json.Unmarshal(body, &val) == nil {
+		response, _ = json.Marshal(val)
+	} else {
+		response, _ = json.Marshal(string(body))
+	}
+	return &Snapshot{
+		Agent:       agentID,
+		ContentType: contentType,
+		Payload:     payload,
+		Status:      status,
+		Response:    response,
+	}
+}
+
+// LoadSnapshot loads the snapshot from filename and returns nil if it doesn't exist
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(buf, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", filename, err)
+	}
+	return &snapshot, nil
+}
+
+// Save writes the snapshot to filename
+func (s *Snapshot) Save(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(buf, '\n'), 0644)
+}
+
+// text returns the comparable representation of the response
+func (s *Snapshot) text() string {
+	var val any
+	if err := json.Unmarshal(s.Response, &val); err != nil {
+		return string(s.Response)
+	}
+	var body string
+	if str, ok := val.(string); ok {
+		body = str
+	} else {
+		buf, _ := json.MarshalIndent(val, "", "  ")
+		body = string(buf)
+	}
+	return fmt.Sprintf("status: %d\n%s\n", s.Status, strings.TrimRight(body, "\n"))
+}
+
+// Diff returns a unified diff between the snapshot and the actual response or an empty string if they match
+func (s *Snapshot) Diff(actual *Snapshot) string {
+	expected, got := s.text(), actual.text()
+	if expected == got {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expected),
+		B:        difflib.SplitLines(got),
+		FromFile: "snapshot",
+		ToFile:   "response",
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	name := SnapshotName("application/json", `{"hello": "world"}`)
+	assert.Len(t, name, 16)
+	assert.NotEqual(t, name, SnapshotName("text/plain", `{"hello": "world"}`))
+
+	filename := SnapshotFilename(dir, "agent_123", name)
+	assert.Equal(t, filepath.Join(dir, ".agentuity", "snapshots", "agent_123", name+".json"), filename)
+
+	missing, err := LoadSnapshot(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	snapshot := NewSnapshot("agent_123", "application/json", `{"hello": "world"}`, 200, []byte(`{"b": 1, "a": [1, 2]}`))
+	assert.NoError(t, snapshot.Save(filename))
+	loaded, err := LoadSnapshot(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, loaded.Status)
+	assert.Empty(t, snapshot.Diff(loaded))
+
+	// key order and whitespace don't matter for JSON responses
+	assert.Empty(t, loaded.Diff(NewSnapshot("agent_123", "application/json", `{"hello": "world"}`, 200, []byte(`{"a":[1,2],"b":1}`))))
+
+	diff := loaded.Diff(NewSnapshot("agent_123", "application/json", `{"hello": "world"}`, 200, []byte(`{"a": [1, 3], "b": 1}`)))
+	assert.Contains(t, diff, "--- snapshot\n+++ response\n")
+	assert.Contains(t, diff, "-    2\n+    3\n")
+
+	text := NewSnapshot("agent_123", "text/plain", "hi", 200, []byte("hello there"))
+	assert.Contains(t, text.Diff(NewSnapshot("agent_123", "text/plain", "hi", 500, []byte("hello there"))), "-status: 200\n+status: 500\n")
+}
@@ -0,0 +1,600 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/agentuity/cli/internal/sbom"
+	"github.com/agentuity/cli/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	defaultGitHubRawURL = "https://raw.githubusercontent.com"
+	// DefaultFetchConcurrency is how many requests are made to GitHub at the same time
+	DefaultFetchConcurrency = 8
+)
+
+// Source is the location in a repository which agents are imported from
+type Source struct {
+	Owner string `json:"owner,omitempty"`
+	Repo  string `json:"repo,omitempty"`
+	// URL is the URL of a git repository on any host, which is only supported by the git fetcher
+	URL string `json:"url,omitempty"`
+	// Ref is the branch, tag or commit (the default branch when empty)
+	Ref string `json:"ref,omitempty"`
+	// Path is the directory which contains the agent directories (the agents directory of the
+	// project in the repository when empty)
+	Path string `json:"path,omitempty"`
+}
+
+func (s Source) String() string {
+	val := s.Owner + "/" + s.Repo
+	if s.URL != "" {
+		val = strings.TrimSuffix(s.URL, "/")
+	}
+	if s.Path != "" {
+		val += "/" + s.Path
+	}
+	if s.Ref != "" {
+		val += "@" + s.Ref
+	}
+	return val
+}
+
+var githubTreeURL = regexp.MustCompile(`^(?:https?://)?github\.com/([^/]+)/([^/]+?)(?:\.git)?(?:/tree/([^/]+)(?:/(.+))?)?/?$`)
+
+// ParseSource parses the source of an import which is either a GitHub URL such as
+// https://github.com/owner/repo/tree/main/src/agents or owner/repo[/path][@ref]
+func ParseSource(val string) (*Source, error) {
+	val = strings.TrimSpace(val)
+	if m := githubTreeURL.FindStringSubmatch(val); m != nil {
+		if err := ValidateRef(m[3]); err != nil {
+			return nil, err
+		}
+		return &Source{Owner: m[1], Repo: m[2], Ref: m[3], Path: strings.Trim(m[4], "/")}, nil
+	}
+	if strings.Contains(val, "://") {
+		return nil, fmt.Errorf("%s is not a GitHub repository URL", val)
+	}
+	val, ref, _ := strings.Cut(strings.TrimPrefix(val, "github:"), "@")
+	parts := strings.SplitN(strings.Trim(val, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("%s is not a GitHub repository such as owner/repo", val)
+	}
+	if err := ValidateRef(ref); err != nil {
+		return nil, err
+	}
+	source := &Source{Owner: parts[0], Repo: parts[1], Ref: ref}
+	if len(parts) == 3 {
+		source.Path = strings.Trim(parts[2], "/")
+	}
+	return source, nil
+}
+
+// GitURL returns the URL to clone the repository of the source
+func (s Source) GitURL() string {
+	if s.URL != "" {
+		return s.URL
+	}
+	return fmt.Sprintf("https://github.com/%s/%s.git", s.Owner, s.Repo)
+}
+
+var gitURL = regexp.MustCompile(`^(?:[a-z+]+://|[^@/]+@[^:/]+:)`)
+
+// ParseGitSource parses the source of an import from any git host. GitHub sources are parsed like
+// ParseSource while any other git URL (such as https://gitlab.com/owner/repo.git or
+// git@host:owner/repo.git) is used as is.
+func ParseGitSource(val string) (*Source, error) {
+	val = strings.TrimSpace(val)
+	if source, err := ParseSource(val); err == nil {
+		return source, nil
+	}
+	if !gitURL.MatchString(val) || strings.HasPrefix(val, "-") {
+		return nil, fmt.Errorf("%s is not a git repository URL", val)
+	}
+	return &Source{URL: val}, nil
+}
+
+// ValidateRef returns an error if the ref of a source isn't a branch, tag or commit name which can
+// be passed to git, such as a ref starting with - which git would read as an option
+func ValidateRef(ref string) error {
+	if strings.HasPrefix(ref, "-") || strings.ContainsFunc(ref, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return fmt.Errorf("%q is not a valid branch, tag or commit", ref)
+	}
+	return nil
+}
+
+// ImportFetcher fetches the agents of an import source
+type ImportFetcher interface {
+	// ResolveAgentsDir sets the path of the source to the agents directory of the project in the
+	// repository when it isn't set
+	ResolveAgentsDir(ctx context.Context) error
+	// ListAgents returns the names of the agent directories in the source path
+	ListAgents(ctx context.Context) ([]string, error)
+	// ListFiles returns the files of the agents
+	ListFiles(ctx context.Context, agents []string) ([]RemoteFile, error)
+	// FetchFiles writes each file to the filename returned by dest, skipping the files recorded in
+	// the state, and returns the number of files fetched
+	FetchFiles(ctx context.Context, files []RemoteFile, state *ImportState, dest func(file RemoteFile) string) (int, error)
+	// License returns the SPDX identifier of the license of the repository or an empty string when
+	// the repository doesn't have a license which is recognized
+	License(ctx context.Context) (string, error)
+	// ReadFile returns the contents of a file in the repository (outside of the agent directories)
+	// or nil when the file doesn't exist
+	ReadFile(ctx context.Context, file string) ([]byte, error)
+	// Close releases the resources of the fetcher
+	Close() error
+}
+
+var _ ImportFetcher = (*Fetcher)(nil)
+
+// RemoteFile is a file in the source repository
+type RemoteFile struct {
+	// Path is the path of the file in the repository
+	Path string `json:"path"`
+	// SHA is the git blob hash of the file which changes when the contents change
+	SHA  string `json:"sha"`
+	Size int64  `json:"size"`
+}
+
+// Fetcher fetches agents from a GitHub repository file by file over HTTP. Directory listings and
+// file contents are fetched concurrently.
+type Fetcher struct {
+	Source *Source
+	// Token is an optional GitHub token which raises the rate limit and allows private repositories
+	Token string
+	// Concurrency is the maximum number of concurrent requests (DefaultFetchConcurrency when zero)
+	Concurrency int
+	// Progress is called each time a file is fetched with the number of files done out of the total
+	Progress func(done int, total int)
+
+	apiURL string
+	rawURL string
+	client *http.Client
+}
+
+// NewFetcher returns a fetcher for the source
+func NewFetcher(source *Source, token string) *Fetcher {
+	return &Fetcher{
+		Source: source,
+		Token:  token,
+		apiURL: defaultGitHubAPIURL,
+		rawURL: defaultGitHubRawURL,
+		client: http.DefaultClient,
+	}
+}
+
+// Close is a no-op since the fetcher doesn't hold any resources
+func (f *Fetcher) Close() error {
+	return nil
+}
+
+func (f *Fetcher) concurrency() int {
+	if f.Concurrency > 0 {
+		return f.Concurrency
+	}
+	return DefaultFetchConcurrency
+}
+
+func (f *Fetcher) get(ctx context.Context, u string, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", util.UserAgent())
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if f.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.Token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		buf, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s was %w in %s", strings.TrimPrefix(u, f.apiURL), errNotFound, f.Source)
+		}
+		return nil, fmt.Errorf("GET %s failed (status %d): %s", u, resp.StatusCode, strings.TrimSpace(string(buf)))
+	}
+	return resp, nil
+}
+
+type contentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	SHA  string `json:"sha"`
+	Size int64  `json:"size"`
+	Type string `json:"type"`
+}
+
+func (f *Fetcher) listDir(ctx context.Context, dir string) ([]contentEntry, error) {
+	u := fmt.Sprintf("%s/repos/%s/%s/contents/%s", f.apiURL, url.PathEscape(f.Source.Owner), url.PathEscape(f.Source.Repo), escapePath(dir))
+	if f.Source.Ref != "" {
+		u += "?ref=" + url.QueryEscape(f.Source.Ref)
+	}
+	resp, err := f.get(ctx, u, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var entries []contentEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%s is not a directory in %s", dir, f.Source)
+	}
+	return entries, nil
+}
+
+func (f *Fetcher) rawFileURL(file string) string {
+	ref := f.Source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s", f.rawURL, url.PathEscape(f.Source.Owner), url.PathEscape(f.Source.Repo), escapePath(ref), escapePath(file))
+}
+
+func escapePath(val string) string {
+	parts := strings.Split(val, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+var errNotFound = errors.New("not found")
+
+type licenseResponse struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+	License  struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
+}
+
+// License returns the license of the repository which GitHub detected. When GitHub doesn't
+// recognize the license, the license file itself is checked.
+func (f *Fetcher) License(ctx context.Context) (string, error) {
+	u := fmt.Sprintf("%s/repos/%s/%s/license", f.apiURL, url.PathEscape(f.Source.Owner), url.PathEscape(f.Source.Repo))
+	if f.Source.Ref != "" {
+		u += "?ref=" + url.QueryEscape(f.Source.Ref)
+	}
+	resp, err := f.get(ctx, u, "application/vnd.github+json")
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result licenseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error decoding the license of %s: %w", f.Source, err)
+	}
+	if id := result.License.SPDXID; id != "" && id != "NOASSERTION" {
+		return id, nil
+	}
+	if result.Encoding == "base64" {
+		if buf, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(result.Content, "\n", "")); err == nil {
+			return sbom.DetectLicense(string(buf)), nil
+		}
+	}
+	return "", nil
+}
+
+// ReadFile returns the contents of the file in the repository or nil when it doesn't exist
+func (f *Fetcher) ReadFile(ctx context.Context, file string) ([]byte, error) {
+	resp, err := f.get(ctx, f.rawFileURL(file), "")
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// ResolveAgentsDir sets the path of the source to the agents directory of the project in the
+// repository when it isn't set
+func (f *Fetcher) ResolveAgentsDir(ctx context.Context) error {
+	if f.Source.Path != "" {
+		return nil
+	}
+	resp, err := f.get(ctx, f.rawFileURL("agentuity.yaml"), "")
+	if err != nil {
+		return fmt.Errorf("no path was provided and the project file couldn't be read: %w", err)
+	}
+	defer resp.Body.Close()
+	var config struct {
+		Bundler struct {
+			Agents struct {
+				Dir string `yaml:"dir"`
+			} `yaml:"agents"`
+		} `yaml:"bundler"`
+	}
+	if err := yaml.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return fmt.Errorf("error parsing the project file of %s: %w", f.Source, err)
+	}
+	if config.Bundler.Agents.Dir == "" {
+		return fmt.Errorf("the project file of %s doesn't have an agents directory", f.Source)
+	}
+	f.Source.Path = strings.Trim(path.Clean(filepath.ToSlash(config.Bundler.Agents.Dir)), "/")
+	return nil
+}
+
+// ListAgents returns the names of the agent directories in the source path
+func (f *Fetcher) ListAgents(ctx context.Context) ([]string, error) {
+	entries, err := f.listDir(ctx, f.Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type == "dir" && !strings.HasPrefix(entry.Name, ".") && !strings.HasPrefix(entry.Name, "__") {
+			names = append(names, entry.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListFiles returns the files of the agents, walking their directories concurrently
+func (f *Fetcher) ListFiles(ctx context.Context, agents []string) ([]RemoteFile, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu       sync.Mutex
+		files    []RemoteFile
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, f.concurrency())
+	var walk func(dir string)
+	walk = func(dir string) {
+		defer wg.Done()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		entries, err := f.listDir(ctx, dir)
+		<-sem
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+			return
+		}
+		for _, entry := range entries {
+			switch entry.Type {
+			case "file":
+				files = append(files, RemoteFile{Path: entry.Path, SHA: entry.SHA, Size: entry.Size})
+			case "dir":
+				wg.Add(1)
+				go walk(entry.Path)
+			}
+		}
+	}
+	for _, name := range agents {
+		wg.Add(1)
+		go walk(path.Join(f.Source.Path, name))
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// SourceEntrypoints returns the entrypoints which the project file of an import source declares
+// for the agent directories, relative to the directory of the agent and keyed by its name. The
+// entrypoints which aren't in the directory of their agent aren't imported with it so they are
+// returned separately, relative to the repository.
+func SourceEntrypoints(projectFile []byte, source *Source, agents []string) (map[string]string, map[string]string, error) {
+	var config struct {
+		Agents []struct {
+			Name       string `yaml:"name"`
+			Entrypoint string `yaml:"entrypoint"`
+		} `yaml:"agents"`
+	}
+	if err := yaml.Unmarshal(projectFile, &config); err != nil {
+		return nil, nil, fmt.Errorf("error parsing the project file of %s: %w", source, err)
+	}
+	entrypoints := make(map[string]string)
+	outside := make(map[string]string)
+	for _, a := range config.Agents {
+		if a.Entrypoint == "" {
+			continue
+		}
+		entrypoint := path.Clean(filepath.ToSlash(a.Entrypoint))
+		var found bool
+		for _, name := range agents {
+			if rel, ok := strings.CutPrefix(entrypoint, path.Join(source.Path, name)+"/"); ok {
+				entrypoints[name] = rel
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		for _, name := range agents {
+			if name == a.Name || name == util.SafeProjectFilename(a.Name, false) || name == util.SafeProjectFilename(a.Name, true) {
+				outside[name] = entrypoint
+				break
+			}
+		}
+	}
+	return entrypoints, outside, nil
+}
+
+// ImportState records the files of an import which have been fetched so that an import which
+// partially failed can be resumed without fetching them again
+type ImportState struct {
+	Source string `json:"source"`
+	// Files are the SHAs of the fetched files by their path in the repository
+	Files map[string]string `json:"files"`
+}
+
+// ImportStateFilename is the file in the .agentuity directory of the project which records the
+// progress of an import
+const ImportStateFilename = "import.json"
+
+func importStatePath(dir string) string {
+	return filepath.Join(dir, ".agentuity", ImportStateFilename)
+}
+
+// LoadImportState returns the state of a previous import of the source in the project in dir or a
+// new state when there isn't one
+func LoadImportState(dir string, source *Source) (*ImportState, error) {
+	state := &ImportState{Source: source.String(), Files: make(map[string]string)}
+	buf, err := os.ReadFile(importStatePath(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	var existing ImportState
+	if err := json.Unmarshal(buf, &existing); err != nil || existing.Source != state.Source || existing.Files == nil {
+		// the state of an import of another source (or a corrupt state) is ignored
+		return state, nil
+	}
+	return &existing, nil
+}
+
+// Save writes the state to the project in dir
+func (s *ImportState) Save(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(importStatePath(dir)), 0755); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(importStatePath(dir), buf, 0644)
+}
+
+// RemoveImportState removes the state once the import has completed
+func RemoveImportState(dir string) error {
+	if err := util.Remove(importStatePath(dir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FetchFiles downloads the files concurrently, writing each one to the filename returned by dest.
+// Files recorded in the state with the same SHA which still exist are skipped. A file which fails
+// doesn't stop the others so that resuming only has to fetch the files which failed. It returns the
+// number of files fetched.
+func (f *Fetcher) FetchFiles(ctx context.Context, files []RemoteFile, state *ImportState, dest func(file RemoteFile) string) (int, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		done    int
+		fetched int
+		errs    []error
+	)
+	total := len(files)
+	progress := func() {
+		done++
+		if f.Progress != nil {
+			f.Progress(done, total)
+		}
+	}
+	sem := make(chan struct{}, f.concurrency())
+	cancelled := false
+	for _, file := range files {
+		if cancelled {
+			break
+		}
+		filename := dest(file)
+		mu.Lock()
+		skip := state.Files[file.Path] == file.SHA && util.Exists(filename)
+		if skip {
+			progress()
+		}
+		mu.Unlock()
+		if skip {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			cancelled = true
+			continue
+		}
+		wg.Add(1)
+		go func(file RemoteFile, filename string) {
+			defer wg.Done()
+			err := f.fetchFile(ctx, file, filename)
+			<-sem
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", file.Path, err))
+				return
+			}
+			state.Files[file.Path] = file.SHA
+			fetched++
+			progress()
+		}(file, filename)
+	}
+	wg.Wait()
+	if cancelled {
+		return fetched, ctx.Err()
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return fetched, fmt.Errorf("failed to fetch %s: %w", util.Pluralize(len(errs), "file", "files"), errors.Join(errs...))
+	}
+	return fetched, nil
+}
+
+func (f *Fetcher) fetchFile(ctx context.Context, file RemoteFile, filename string) error {
+	resp, err := f.get(ctx, f.rawFileURL(file.Path), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	// write to a temporary file first so an interrupted download never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer util.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
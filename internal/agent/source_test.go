@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		val      string
+		expected Source
+	}{
+		{"https://github.com/owner/repo", Source{Owner: "owner", Repo: "repo"}},
+		{"https://github.com/owner/repo.git", Source{Owner: "owner", Repo: "repo"}},
+		{"https://github.com/owner/repo/tree/main/src/agents", Source{Owner: "owner", Repo: "repo", Ref: "main", Path: "src/agents"}},
+		{"github.com/owner/repo/tree/v1.0/", Source{Owner: "owner", Repo: "repo", Ref: "v1.0"}},
+		{"owner/repo", Source{Owner: "owner", Repo: "repo"}},
+		{"owner/repo/src/agents@dev", Source{Owner: "owner", Repo: "repo", Ref: "dev", Path: "src/agents"}},
+		{"github:owner/repo@abc123", Source{Owner: "owner", Repo: "repo", Ref: "abc123"}},
+	}
+	for _, tt := range tests {
+		source, err := ParseSource(tt.val)
+		require.NoError(t, err, tt.val)
+		assert.Equal(t, tt.expected, *source, tt.val)
+	}
+	for _, val := range []string{"repo", "https://gitlab.com/owner/repo", "/repo", "owner/repo@--upload-pack=touch", "https://github.com/owner/repo/tree/-oops", "owner/repo@has space"} {
+		_, err := ParseSource(val)
+		assert.Error(t, err, val)
+	}
+	assert.Equal(t, "owner/repo/src/agents@dev", Source{Owner: "owner", Repo: "repo", Ref: "dev", Path: "src/agents"}.String())
+}
+
+type fakeGitHub struct {
+	files map[string]string
+	mu    sync.Mutex
+	fail  map[string]bool
+	gets  map[string]int
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if dir, ok := strings.CutPrefix(r.URL.Path, "/api/repos/owner/repo/contents/"); ok {
+		var entries []contentEntry
+		seen := make(map[string]bool)
+		for fn, content := range g.files {
+			rest, ok := strings.CutPrefix(fn, dir+"/")
+			if !ok {
+				continue
+			}
+			name, _, isDir := strings.Cut(rest, "/")
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if isDir {
+				entries = append(entries, contentEntry{Name: name, Path: path.Join(dir, name), Type: "dir"})
+			} else {
+				entries = append(entries, contentEntry{Name: name, Path: fn, SHA: "sha-" + content, Size: int64(len(content)), Type: "file"})
+			}
+		}
+		if len(entries) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+		return
+	}
+	if r.URL.Path == "/api/repos/owner/repo/license" {
+		content, ok := g.files["LICENSE"]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// GitHub doesn't recognize every license so the content is checked too
+		json.NewEncoder(w).Encode(map[string]any{
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+			"encoding": "base64",
+			"license":  map[string]string{"spdx_id": "NOASSERTION"},
+		})
+		return
+	}
+	if fn, ok := strings.CutPrefix(r.URL.Path, "/raw/owner/repo/main/"); ok {
+		g.gets[fn]++
+		if g.fail[fn] {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		if content, ok := g.files[fn]; ok {
+			w.Write([]byte(content))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func TestFetcher(t *testing.T) {
+	github := &fakeGitHub{
+		files: map[string]string{
+			"agentuity.yaml":                  "bundler:\n  agents:\n    dir: src/agents\n",
+			"src/agents/one/index.ts":         "one",
+			"src/agents/one/lib/util.ts":      "util",
+			"src/agents/one/lib/deep/x.ts":    "x",
+			"src/agents/two/index.ts":         "two",
+			"src/agents/__shared__/common.ts": "common",
+			"src/agents/README.md":            "readme",
+			"LICENSE":                         "MIT License\n\nPermission is hereby granted, free of charge, to any person obtaining a copy",
+			"package.json":                    `{"dependencies":{"zod":"^3.0.0"}}`,
+		},
+		fail: map[string]bool{"src/agents/one/lib/util.ts": true},
+		gets: make(map[string]int),
+	}
+	server := httptest.NewServer(github)
+	defer server.Close()
+
+	fetcher := NewFetcher(&Source{Owner: "owner", Repo: "repo", Ref: "main"}, "")
+	fetcher.apiURL = server.URL + "/api"
+	fetcher.rawURL = server.URL + "/raw"
+	fetcher.Concurrency = 2
+	ctx := context.Background()
+
+	require.NoError(t, fetcher.ResolveAgentsDir(ctx))
+	assert.Equal(t, "src/agents", fetcher.Source.Path)
+
+	names, err := fetcher.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, names)
+
+	license, err := fetcher.License(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", license)
+
+	buf, err := fetcher.ReadFile(ctx, "package.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"dependencies":{"zod":"^3.0.0"}}`, string(buf))
+	buf, err = fetcher.ReadFile(ctx, "src/package.json")
+	require.NoError(t, err)
+	assert.Nil(t, buf)
+
+	files, err := fetcher.ListFiles(ctx, []string{"one"})
+	require.NoError(t, err)
+	assert.Equal(t, []RemoteFile{
+		{Path: "src/agents/one/index.ts", SHA: "sha-one", Size: 3},
+		{Path: "src/agents/one/lib/deep/x.ts", SHA: "sha-x", Size: 1},
+		{Path: "src/agents/one/lib/util.ts", SHA: "sha-util", Size: 4},
+	}, files)
+
+	_, err = fetcher.ListFiles(ctx, []string{"one", "missing"})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	dest := func(file RemoteFile) string {
+		return filepath.Join(dir, "src", "agents", filepath.FromSlash(strings.TrimPrefix(file.Path, "src/agents/")))
+	}
+	var progress []int
+	fetcher.Progress = func(done int, total int) {
+		assert.Equal(t, 3, total)
+		progress = append(progress, done)
+	}
+
+	state, err := LoadImportState(dir, fetcher.Source)
+	require.NoError(t, err)
+	fetched, err := fetcher.FetchFiles(ctx, files, state, dest)
+	assert.ErrorContains(t, err, "src/agents/one/lib/util.ts")
+	assert.Equal(t, 2, fetched)
+	assert.Equal(t, []int{1, 2}, progress)
+	assert.NoFileExists(t, filepath.Join(dir, "src", "agents", "one", "lib", "util.ts"))
+	require.NoError(t, state.Save(dir))
+
+	// resuming only fetches the file which failed
+	github.fail = nil
+	progress = nil
+	state, err = LoadImportState(dir, fetcher.Source)
+	require.NoError(t, err)
+	assert.Len(t, state.Files, 2)
+	fetched, err = fetcher.FetchFiles(ctx, files, state, dest)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched)
+	assert.Equal(t, []int{1, 2, 3}, progress)
+	assert.Equal(t, 1, github.gets["src/agents/one/index.ts"])
+	assert.Equal(t, 2, github.gets["src/agents/one/lib/util.ts"])
+	buf, err = os.ReadFile(filepath.Join(dir, "src", "agents", "one", "lib", "util.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "util", string(buf))
+
+	// the state of another source is ignored
+	state, err = LoadImportState(dir, &Source{Owner: "owner", Repo: "other"})
+	require.NoError(t, err)
+	assert.Empty(t, state.Files)
+
+	require.NoError(t, RemoveImportState(dir))
+	assert.NoFileExists(t, filepath.Join(dir, ".agentuity", ImportStateFilename))
+	require.NoError(t, RemoveImportState(dir))
+}
+
+func TestParseGitSource(t *testing.T) {
+	source, err := ParseGitSource("owner/repo/src/agents@main")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo.git", source.GitURL())
+	assert.Equal(t, "src/agents", source.Path)
+
+	for _, val := range []string{"https://gitlab.com/owner/repo.git", "git@bitbucket.org:owner/repo.git", "ssh://git@host/repo"} {
+		source, err := ParseGitSource(val)
+		require.NoError(t, err, val)
+		assert.Equal(t, val, source.GitURL())
+		assert.Equal(t, val, source.String())
+	}
+	for _, val := range []string{"repo", "owner/repo@--upload-pack=touch", "-oops@host:owner/repo.git"} {
+		_, err = ParseGitSource(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestValidateRef(t *testing.T) {
+	for _, ref := range []string{"", "main", "feature/x", "v1.0.0", "abc123"} {
+		assert.NoError(t, ValidateRef(ref), ref)
+	}
+	for _, ref := range []string{"--upload-pack=touch /tmp/x", "-b", "-", "has space", "tab\tref"} {
+		assert.Error(t, ValidateRef(ref), ref)
+	}
+}
+
+func TestSourceEntrypoints(t *testing.T) {
+	projectFile := []byte(`
+agents:
+  - id: agent_1
+    name: support
+    entrypoint: src/agents/support/handler.ts
+  - id: agent_2
+    name: Billing Agent
+    entrypoint: lib/billing.ts
+  - id: agent_3
+    name: other
+`)
+	source := &Source{Owner: "owner", Repo: "repo", Path: "src/agents"}
+	entrypoints, outside, err := SourceEntrypoints(projectFile, source, []string{"support", "Billing-Agent", "other"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"support": "handler.ts"}, entrypoints)
+	assert.Equal(t, map[string]string{"Billing-Agent": "lib/billing.ts"}, outside)
+
+	entrypoints, outside, err = SourceEntrypoints(nil, source, []string{"support"})
+	require.NoError(t, err)
+	assert.Empty(t, entrypoints)
+	assert.Empty(t, outside)
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// sourceSkipDirs are the directories in the agent directory which aren't part of its source
+var sourceSkipDirs = map[string]bool{"node_modules": true, "__pycache__": true, ".venv": true}
+
+// SourceFiles returns the files in the agent directory relative to the project directory with
+// forward slashes, like the files of the bundle of a deployment
+func SourceFiles(projectDir string, agentDir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(agentDir, func(fn string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if sourceSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, fn)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// SameDigest returns true when the digests are the same with or without the sha256: prefix
+func SameDigest(a, b string) bool {
+	return strings.TrimPrefix(a, "sha256:") == strings.TrimPrefix(b, "sha256:")
+}
+
+// SourceDiff returns a unified diff of a file of the agent from the deployed content to the local
+// content. A nil content is a file which doesn't exist.
+func SourceDiff(filename string, deployed []byte, local []byte) string {
+	from, to := "deployed/"+filename, "local/"+filename
+	if deployed == nil {
+		from = "/dev/null"
+	}
+	if local == nil {
+		to = "/dev/null"
+	}
+	if bytes.IndexByte(deployed, 0) >= 0 || bytes.IndexByte(local, 0) >= 0 {
+		return fmt.Sprintf("Binary files %s and %s differ\n", from, to)
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(deployed)),
+		B:        difflib.SplitLines(string(local)),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	agentDir := filepath.Join(dir, "src", "agents", "my-agent")
+	require.NoError(t, os.MkdirAll(filepath.Join(agentDir, "lib"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(agentDir, "__pycache__"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "index.ts"), []byte("export {}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "lib", "util.ts"), []byte("export {}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "__pycache__", "x.pyc"), []byte("x"), 0644))
+
+	files, err := SourceFiles(dir, agentDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/agents/my-agent/index.ts", "src/agents/my-agent/lib/util.ts"}, files)
+
+	files, err = SourceFiles(dir, filepath.Join(dir, "src", "agents", "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestSourceDiff(t *testing.T) {
+	assert.True(t, SameDigest("sha256:abc", "abc"))
+	assert.False(t, SameDigest("sha256:abc", "sha256:abd"))
+
+	diff := SourceDiff("src/agents/a/index.ts", []byte("one\ntwo\n"), []byte("one\nthree\n"))
+	assert.Contains(t, diff, "--- deployed/src/agents/a/index.ts")
+	assert.Contains(t, diff, "+++ local/src/agents/a/index.ts")
+	assert.Contains(t, diff, "-two")
+	assert.Contains(t, diff, "+three")
+
+	diff = SourceDiff("src/agents/a/new.ts", nil, []byte("new\n"))
+	assert.Contains(t, diff, "--- /dev/null")
+	assert.Contains(t, diff, "+new")
+
+	assert.Equal(t, "Binary files deployed/a.bin and local/a.bin differ\n", SourceDiff("a.bin", []byte{0, 1}, []byte{0, 2}))
+}
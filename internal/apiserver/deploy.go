@@ -0,0 +1,235 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The status of a deploy started through the API
+const (
+	DeployRunning   = "running"
+	DeploySucceeded = "succeeded"
+	DeployFailed    = "failed"
+	DeployCancelled = "cancelled"
+)
+
+// DeployRequest is the body of a request to start a deploy
+type DeployRequest struct {
+	Dir        string   `json:"dir,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	EnvProfile string   `json:"envProfile,omitempty"`
+	// Labels are the key=value labels stored with the deployment
+	Labels map[string]string `json:"labels,omitempty"`
+	// Wait waits for another deploy of the same tags to finish instead of failing
+	Wait bool `json:"wait,omitempty"`
+}
+
+// DeployJob is a deploy started through the API, which runs in the background
+type DeployJob struct {
+	ID         string     `json:"id"`
+	Dir        string     `json:"dir"`
+	Tags       []string   `json:"tags,omitempty"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExitCode   *int       `json:"exitCode,omitempty"`
+	// Result is the JSON result of the deploy command, with the id of the deployment
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+	Output string          `json:"output,omitempty"`
+
+	cancel context.CancelFunc
+	output *lockedBuffer
+}
+
+// lockedBuffer is a buffer written by the command while the clients read it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// snapshot returns a copy of the job which can be encoded without holding the lock. The output
+// is only included when withOutput is set since it can be long.
+func (j *DeployJob) snapshot(withOutput bool) DeployJob {
+	c := *j
+	c.cancel = nil
+	c.output = nil
+	if withOutput {
+		c.Output = j.output.String()
+	}
+	return c
+}
+
+// deploys returns the deploys of the project in dir (all of them when dir is empty), the most
+// recent first
+func (s *Server) deploys(dir string) []DeployJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := []DeployJob{}
+	for _, job := range s.jobs {
+		if dir == "" || job.Dir == dir {
+			jobs = append(jobs, job.snapshot(false))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.After(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+func (s *Server) listDeploys(w http.ResponseWriter, r *http.Request) {
+	var dir string
+	if r.URL.Query().Get("dir") != "" {
+		var err error
+		if dir, err = s.projectDir(r.URL.Query().Get("dir")); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.deploys(dir))
+}
+
+func (s *Server) startDeploy(w http.ResponseWriter, r *http.Request) {
+	var req DeployRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	}
+	dir, err := s.projectDir(req.Dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	args := []string{"deploy", "--dir", dir, "--format", "json"}
+	for _, tag := range req.Tags {
+		args = append(args, "--tag", tag)
+	}
+	if req.Message != "" {
+		args = append(args, "--message", req.Message)
+	}
+	if req.EnvProfile != "" {
+		args = append(args, "--env-profile", req.EnvProfile)
+	}
+	keys := make([]string, 0, len(req.Labels))
+	for k := range req.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--label", k+"="+req.Labels[k])
+	}
+	if req.Wait {
+		args = append(args, "--wait")
+	}
+
+	s.mu.Lock()
+	for _, job := range s.jobs {
+		if job.Dir == dir && job.Status == DeployRunning {
+			s.mu.Unlock()
+			writeJSON(w, http.StatusConflict, map[string]any{"error": map[string]any{"message": "a deploy of the project is already running"}, "deploy": job.snapshot(false)})
+			return
+		}
+	}
+	// the deploy continues after the request which started it
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &DeployJob{
+		ID:        "deploy_" + uuid.New().String(),
+		Dir:       dir,
+		Tags:      req.Tags,
+		Status:    DeployRunning,
+		StartedAt: time.Now().UTC(),
+		cancel:    cancel,
+		output:    &lockedBuffer{},
+	}
+	s.jobs[job.ID] = job
+	snapshot := job.snapshot(false)
+	s.mu.Unlock()
+
+	s.opts.Logger.Info("started deploy %s of %s", job.ID, dir)
+	go s.runDeploy(ctx, job, args)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *Server) runDeploy(ctx context.Context, job *DeployJob, args []string) {
+	var stdout, stderr bytes.Buffer
+	code, err := s.opts.Runner(ctx, args, io.MultiWriter(&stdout, job.output), io.MultiWriter(&stderr, job.output))
+	cancelled := ctx.Err() != nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	job.cancel()
+	switch {
+	case err != nil:
+		job.Status = DeployFailed
+		job.Error, _ = json.Marshal(map[string]any{"message": err.Error()})
+	case cancelled:
+		job.Status = DeployCancelled
+		job.ExitCode = &code
+	case code != 0:
+		job.Status = DeployFailed
+		job.ExitCode = &code
+		job.Error = commandError(stdout.Bytes(), stderr.Bytes())
+	default:
+		job.Status = DeploySucceeded
+		job.ExitCode = &code
+		job.Result = lastJSON(stdout.Bytes())
+	}
+	s.opts.Logger.Info("deploy %s of %s %s", job.ID, job.Dir, job.Status)
+}
+
+func (s *Server) getDeploy(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var snapshot DeployJob
+	if ok {
+		snapshot = job.snapshot(true)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "deploy not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *Server) cancelDeploy(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var snapshot DeployJob
+	if ok {
+		if job.Status == DeployRunning {
+			job.cancel()
+		}
+		snapshot = job.snapshot(false)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "deploy not found")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
@@ -0,0 +1,324 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/project"
+)
+
+// Runner runs the CLI with the arguments, writing its output to stdout and stderr, and returns its
+// exit code. The error is only returned when the CLI couldn't be run.
+type Runner func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (int, error)
+
+// ExecRunner returns a runner which runs the CLI executable in a child process with the errors
+// written as JSON so that they can be returned to the client
+func ExecRunner(exe string) Runner {
+	return func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (int, error) {
+		c := exec.CommandContext(ctx, exe, args...)
+		c.Env = append(os.Environ(), "AGENTUITY_ERROR_FORMAT=json")
+		c.Stdout = stdout
+		c.Stderr = stderr
+		// interrupt the command so that a deploy releases its lock before exiting
+		c.Cancel = func() error { return c.Process.Signal(os.Interrupt) }
+		c.WaitDelay = 10 * time.Second
+		util.ProcessSetup(c)
+		if err := c.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return exitErr.ExitCode(), nil
+			}
+			return 0, err
+		}
+		return 0, nil
+	}
+}
+
+// Options are the options of the server
+type Options struct {
+	Logger logger.Logger
+	// Token is required in the Authorization header of every request except the health check
+	Token string
+	// Dir is the project directory used when a request doesn't have one
+	Dir     string
+	Version string
+	Runner  Runner
+}
+
+// Server is the HTTP API of the CLI which runs the commands of the CLI for the clients which
+// can't or don't want to run the CLI and parse its output
+type Server struct {
+	opts Options
+	mu   sync.Mutex
+	jobs map[string]*DeployJob
+}
+
+// New returns a server with the options
+func New(opts Options) *Server {
+	return &Server{opts: opts, jobs: make(map[string]*DeployJob)}
+}
+
+// Handler returns the handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.health)
+	mux.HandleFunc("GET /v1/project", s.authorized(s.getProject))
+	mux.HandleFunc("GET /v1/agents", s.authorized(s.listAgents))
+	mux.HandleFunc("GET /v1/deployments", s.authorized(s.listDeployments))
+	mux.HandleFunc("GET /v1/status", s.authorized(s.status))
+	mux.HandleFunc("GET /v1/deploys", s.authorized(s.listDeploys))
+	mux.HandleFunc("POST /v1/deploys", s.authorized(s.startDeploy))
+	mux.HandleFunc("GET /v1/deploys/{id}", s.authorized(s.getDeploy))
+	mux.HandleFunc("DELETE /v1/deploys/{id}", s.authorized(s.cancelDeploy))
+	return s.localOnly(mux)
+}
+
+// Close cancels the deploys in progress
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.cancel != nil {
+			job.cancel()
+		}
+	}
+}
+
+// localOnly refuses the requests for another host, which a web page could send to the server
+// through DNS rebinding
+func (s *Server) localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host != "localhost" && host != "127.0.0.1" && host != "::1" && host != "[::1]" {
+			writeError(w, http.StatusForbidden, "the API only accepts requests to localhost")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"message": message}})
+}
+
+// projectDir returns the absolute project directory of the request, from the dir query parameter
+// or the default one, and fails when it doesn't contain a project
+func (s *Server) projectDir(dir string) (string, error) {
+	if dir == "" {
+		dir = s.opts.Dir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if !project.ProjectExists(abs) {
+		return "", fmt.Errorf("no Agentuity project found in %s", abs)
+	}
+	return abs, nil
+}
+
+// cliError returns the error written as JSON by the CLI to stderr or nil when there is none
+func cliError(stderr []byte) json.RawMessage {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var result struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal([]byte(lines[i]), &result) == nil && result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+// commandError returns the error of a command which failed, which is the error written as JSON
+// by the CLI or the output of the command for the errors which are only shown, such as a missing login
+func commandError(stdout []byte, stderr []byte) json.RawMessage {
+	if cerr := cliError(stderr); cerr != nil {
+		return cerr
+	}
+	message := strings.TrimSpace(string(stderr))
+	if message == "" {
+		message = strings.TrimSpace(string(stdout))
+	}
+	buf, _ := json.Marshal(map[string]any{"message": message})
+	return buf
+}
+
+// lastJSON returns the last line of the output which is a JSON value
+func lastJSON(stdout []byte) json.RawMessage {
+	lines := strings.Split(strings.TrimSpace(string(stdout)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" && json.Valid([]byte(line)) {
+			return json.RawMessage(line)
+		}
+	}
+	return nil
+}
+
+// statusForExitCode returns the HTTP status of a command which failed with the exit code
+func statusForExitCode(code int) int {
+	switch code {
+	case util.ExitCodeValidation:
+		return http.StatusBadRequest
+	case util.ExitCodeAuth:
+		return http.StatusUnauthorized
+	case util.ExitCodeAPI:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// run runs the command of the CLI and writes its JSON output or its error as the response
+func (s *Server) run(w http.ResponseWriter, r *http.Request, args ...string) {
+	var stdout, stderr bytes.Buffer
+	code, err := s.opts.Runner(r.Context(), args, &stdout, &stderr)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if code != 0 {
+		writeJSON(w, statusForExitCode(code), map[string]any{"error": commandError(stdout.Bytes(), stderr.Bytes())})
+		return
+	}
+	result := lastJSON(stdout.Bytes())
+	if result == nil {
+		writeError(w, http.StatusInternalServerError, "the command didn't return a JSON result")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "version": s.opts.Version})
+}
+
+// ProjectInfo is the project of a directory
+type ProjectInfo struct {
+	Dir         string   `json:"dir"`
+	ProjectId   string   `json:"projectId"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Runtime     string   `json:"runtime,omitempty"`
+	Agents      []string `json:"agents"`
+}
+
+func loadProjectInfo(dir string) (*ProjectInfo, error) {
+	var p project.Project
+	if err := p.Load(dir); err != nil {
+		return nil, err
+	}
+	info := &ProjectInfo{Dir: dir, ProjectId: p.ProjectId, Name: p.Name, Description: p.Description, Agents: []string{}}
+	if p.Bundler != nil {
+		info.Language = p.Bundler.Language
+		info.Runtime = p.Bundler.Runtime
+	}
+	for _, a := range p.Agents {
+		info.Agents = append(info.Agents, a.Name)
+	}
+	return info, nil
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.projectDir(r.URL.Query().Get("dir"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	info, err := loadProjectInfo(dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.projectDir(r.URL.Query().Get("dir"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.run(w, r, "agent", "list", "--dir", dir, "--format", "json")
+}
+
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.projectDir(r.URL.Query().Get("dir"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	info, err := loadProjectInfo(dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.run(w, r, "cloud", "deployments", "--project", info.ProjectId, "--format", "json")
+}
+
+// status returns the project, its active deployment and the deploys started through the API
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.projectDir(r.URL.Query().Get("dir"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	info, err := loadProjectInfo(dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := map[string]any{"project": info, "deploys": s.deploys(dir)}
+	var stdout, stderr bytes.Buffer
+	code, err := s.opts.Runner(r.Context(), []string{"cloud", "deployments", "--project", info.ProjectId, "--format", "json"}, &stdout, &stderr)
+	switch {
+	case err != nil:
+		result["deploymentsError"] = map[string]any{"message": err.Error()}
+	case code != 0:
+		result["deploymentsError"] = commandError(stdout.Bytes(), stderr.Bytes())
+	default:
+		var deployments []map[string]any
+		json.Unmarshal(lastJSON(stdout.Bytes()), &deployments)
+		for _, d := range deployments {
+			if active, _ := d["active"].(bool); active {
+				result["activeDeployment"] = d
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,177 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "secret-token"
+
+type fakeRunner struct {
+	mu    sync.Mutex
+	calls [][]string
+	run   func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+func (f *fakeRunner) Run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (int, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, args)
+	f.mu.Unlock()
+	return f.run(ctx, args, stdout, stderr), nil
+}
+
+func (f *fakeRunner) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func newTestServer(t *testing.T, run func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int) (*Server, *fakeRunner, string) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agentuity.yaml"), []byte("project_id: proj_123\nname: test\nbundler:\n  language: javascript\n  runtime: bunjs\n  agents:\n    dir: src/agents\nagents:\n  - id: agent_1\n    name: first\n"), 0644))
+	runner := &fakeRunner{run: run}
+	server := New(Options{Logger: logger.NewTestLogger(), Token: testToken, Dir: dir, Version: "1.2.3", Runner: runner.Run})
+	t.Cleanup(server.Close)
+	return server, runner, dir
+}
+
+func request(t *testing.T, server *Server, method string, path string, body string) (*httptest.ResponseRecorder, map[string]any) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, "http://localhost:8787"+path, reader)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	var result map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	return rec, result
+}
+
+func TestServerAuth(t *testing.T) {
+	server, _, _ := newTestServer(t, nil)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/v1/project", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest("GET", "http://localhost/v1/project", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/v1/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"version":"1.2.3"`)
+
+	req = httptest.NewRequest("GET", "http://attacker.example.com/v1/health", nil)
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServerProject(t *testing.T) {
+	server, _, dir := newTestServer(t, nil)
+	rec, result := request(t, server, "GET", "/v1/project", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "proj_123", result["projectId"])
+	assert.Equal(t, []any{"first"}, result["agents"])
+
+	rec, _ = request(t, server, "GET", "/v1/project?dir="+filepath.Join(dir, "missing"), "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServerRunsCommands(t *testing.T) {
+	server, runner, dir := newTestServer(t, func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+		if args[0] == "agent" {
+			fmt.Fprintln(stdout, `{"first":{"foundLocal":true}}`)
+			return 0
+		}
+		fmt.Fprintln(stderr, `{"error":{"code":"CLI-0010","category":"auth","message":"not logged in"}}`)
+		return util.ExitCodeAuth
+	})
+
+	rec, result := request(t, server, "GET", "/v1/agents", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, result, "first")
+	assert.Equal(t, []string{"agent", "list", "--dir", dir, "--format", "json"}, runner.Calls()[0])
+
+	rec, result = request(t, server, "GET", "/v1/deployments", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "CLI-0010", result["error"].(map[string]any)["code"])
+	assert.Equal(t, []string{"cloud", "deployments", "--project", "proj_123", "--format", "json"}, runner.Calls()[1])
+}
+
+func waitForDeploy(t *testing.T, server *Server, id string) map[string]any {
+	for i := 0; i < 100; i++ {
+		_, result := request(t, server, "GET", "/v1/deploys/"+id, "")
+		if result["status"] != DeployRunning {
+			return result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the deploy didn't finish")
+	return nil
+}
+
+func TestServerDeploy(t *testing.T) {
+	release := make(chan struct{})
+	server, runner, dir := newTestServer(t, func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+		fmt.Fprintln(stdout, "building ...")
+		<-release
+		fmt.Fprintln(stdout, `{"deployment_id":"deploy_abc"}`)
+		return 0
+	})
+
+	rec, job := request(t, server, "POST", "/v1/deploys", `{"tags":["staging"],"message":"hello","labels":{"ticket":"JIRA-123","team":"payments"}}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, DeployRunning, job["status"])
+	id := job["id"].(string)
+
+	rec, _ = request(t, server, "POST", "/v1/deploys", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	result := waitForDeploy(t, server, id)
+	assert.Equal(t, DeploySucceeded, result["status"])
+	assert.Equal(t, "deploy_abc", result["result"].(map[string]any)["deployment_id"])
+	assert.Contains(t, result["output"], "building ...")
+	assert.Equal(t, []string{"deploy", "--dir", dir, "--format", "json", "--tag", "staging", "--message", "hello", "--label", "team=payments", "--label", "ticket=JIRA-123"}, runner.Calls()[0])
+
+	_, status := request(t, server, "GET", "/v1/status", "")
+	assert.Len(t, status["deploys"], 1)
+
+	rec, _ = request(t, server, "GET", "/v1/deploys/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServerCancelDeploy(t *testing.T) {
+	server, _, _ := newTestServer(t, func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+		<-ctx.Done()
+		return util.ExitCodeCancelled
+	})
+	_, job := request(t, server, "POST", "/v1/deploys", "")
+	id := job["id"].(string)
+	rec, _ := request(t, server, "DELETE", "/v1/deploys/"+id, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	result := waitForDeploy(t, server, id)
+	assert.Equal(t, DeployCancelled, result["status"])
+}
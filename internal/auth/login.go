@@ -14,9 +14,10 @@ import (
 var ErrLoginTimeout = errors.New("timed out")
 
 type LoginResult struct {
-	APIKey  string
-	UserId  string
-	Expires time.Time
+	APIKey       string
+	UserId       string
+	Expires      time.Time
+	RefreshToken string
 }
 
 type OTPStartResponse struct {
@@ -50,9 +51,10 @@ type OTPCompleteResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Data    *struct {
-		APIKey  string `json:"apiKey"`
-		UserId  string `json:"userId"`
-		Expires int64  `json:"expires"`
+		APIKey       string `json:"apiKey"`
+		UserId       string `json:"userId"`
+		Expires      int64  `json:"expires"`
+		RefreshToken string `json:"refreshToken,omitempty"`
 	} `json:"data,omitempty"`
 }
 
@@ -77,17 +79,20 @@ func PollForLoginCompletion(ctx context.Context, logger logger.Logger, baseUrl s
 			continue
 		}
 		return &LoginResult{
-			APIKey:  resp.Data.APIKey,
-			UserId:  resp.Data.UserId,
-			Expires: time.UnixMilli(resp.Data.Expires),
+			APIKey:       resp.Data.APIKey,
+			UserId:       resp.Data.UserId,
+			Expires:      time.UnixMilli(resp.Data.Expires),
+			RefreshToken: resp.Data.RefreshToken,
 		}, nil
 	}
 	return nil, ErrLoginTimeout
 }
 
 type Organization struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 type User struct {
@@ -3,13 +3,17 @@ package auth
 import (
 	"time"
 
+	"github.com/agentuity/cli/internal/util"
 	"github.com/spf13/viper"
 )
 
+// Logout removes the session and the tokens cached for the projects
 func Logout() {
 	viper.Set("auth.api_key", "")
 	viper.Set("auth.user_id", "")
 	viper.Set("auth.expires", time.Now().UnixMilli())
+	viper.Set("auth.refresh_token", "")
 	viper.Set("preferences.orgId", "")
 	viper.WriteConfig()
+	util.ClearProjectTokens()
 }
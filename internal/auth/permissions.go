@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	PermissionDeploymentDelete = "deployment:delete"
+	PermissionAgentDelete      = "agent:delete"
+	PermissionSecretWrite      = "secret:write"
+)
+
+// rolePermissions are the permissions granted to the built-in roles. These are only used
+// when the API doesn't return the effective permissions for the organization.
+var rolePermissions = map[string][]string{
+	"owner":  {PermissionDeploymentDelete, PermissionAgentDelete, PermissionSecretWrite},
+	"admin":  {PermissionDeploymentDelete, PermissionAgentDelete, PermissionSecretWrite},
+	"member": {PermissionAgentDelete, PermissionSecretWrite},
+	"viewer": {},
+}
+
+// EffectivePermissions returns the permissions the user has in the organization. The second
+// return value is false if the permissions cannot be determined (for example an unknown role).
+func (o Organization) EffectivePermissions() ([]string, bool) {
+	if len(o.Permissions) > 0 {
+		return o.Permissions, true
+	}
+	perms, ok := rolePermissions[strings.ToLower(o.Role)]
+	return perms, ok
+}
+
+// Can returns true if the user has the permission in the organization. If the permissions
+// cannot be determined, it returns true and leaves the decision to the API.
+func (o Organization) Can(permission string) bool {
+	perms, ok := o.EffectivePermissions()
+	if !ok {
+		return true
+	}
+	return slices.Contains(perms, permission) || slices.Contains(perms, "*")
+}
+
+// FindOrganization returns the organization with the id or nil if the user isn't a member.
+func (u *User) FindOrganization(orgId string) *Organization {
+	for i := range u.Organizations {
+		if u.Organizations[i].Id == orgId {
+			return &u.Organizations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationCan(t *testing.T) {
+	tests := []struct {
+		name       string
+		org        Organization
+		permission string
+		expected   bool
+	}{
+		{"admin role", Organization{Role: "admin"}, PermissionDeploymentDelete, true},
+		{"role is case insensitive", Organization{Role: "Owner"}, PermissionSecretWrite, true},
+		{"member cannot delete deployments", Organization{Role: "member"}, PermissionDeploymentDelete, false},
+		{"member can delete agents", Organization{Role: "member"}, PermissionAgentDelete, true},
+		{"viewer", Organization{Role: "viewer"}, PermissionSecretWrite, false},
+		{"unknown role defers to api", Organization{Role: "custom"}, PermissionSecretWrite, true},
+		{"no role defers to api", Organization{}, PermissionAgentDelete, true},
+		{"explicit permissions", Organization{Role: "viewer", Permissions: []string{PermissionSecretWrite}}, PermissionSecretWrite, true},
+		{"explicit permissions missing", Organization{Role: "admin", Permissions: []string{PermissionSecretWrite}}, PermissionAgentDelete, false},
+		{"wildcard", Organization{Permissions: []string{"*"}}, PermissionDeploymentDelete, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.org.Can(tt.permission))
+		})
+	}
+}
+
+func TestFindOrganization(t *testing.T) {
+	user := &User{Organizations: []Organization{{Id: "org_1", Name: "One"}, {Id: "org_2", Name: "Two"}}}
+	assert.Equal(t, "Two", user.FindOrganization("org_2").Name)
+	assert.Nil(t, user.FindOrganization("org_3"))
+}
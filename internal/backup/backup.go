@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/go-common/crypto"
+)
+
+// Version is the version of the backup file format
+const Version = 1
+
+// Project is the project the backup was made from
+type Project struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	OrgID       string   `json:"orgId,omitempty"`
+	Provider    string   `json:"provider,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Agent is an agent of the project the backup was made from
+type Agent struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Values are the environment variable and secret values of the project
+type Values struct {
+	Env     map[string]string `json:"env"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// EncryptedValues are the values encrypted with the public key of the organization
+type EncryptedValues struct {
+	Fingerprint string `json:"fingerprint"`
+	Data        string `json:"data"`
+}
+
+// Backup is the cloud state of a project which can be restored into another project
+type Backup struct {
+	Version    int              `json:"version"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	Project    Project          `json:"project"`
+	Agents     []Agent          `json:"agents"`
+	EnvKeys    []string         `json:"envKeys"`
+	SecretKeys []string         `json:"secretKeys"`
+	Values     *EncryptedValues `json:"values,omitempty"`
+	Schedules  []agent.Schedule `json:"schedules"`
+	Webhooks   []agent.Webhook  `json:"webhooks"`
+}
+
+// EncryptValues stores the values in the backup encrypted with the public key
+func (b *Backup) EncryptValues(pub *ecdsa.PublicKey, fingerprint string, values Values) error {
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	var encrypted bytes.Buffer
+	if _, err := crypto.EncryptFIPSKEMDEMStream(pub, bytes.NewReader(buf), &encrypted); err != nil {
+		return fmt.Errorf("error encrypting the values: %w", err)
+	}
+	b.Values = &EncryptedValues{Fingerprint: fingerprint, Data: base64.StdEncoding.EncodeToString(encrypted.Bytes())}
+	return nil
+}
+
+// DecryptValues returns the values in the backup decrypted with the private key
+func (b *Backup) DecryptValues(priv *ecdsa.PrivateKey) (*Values, error) {
+	if b.Values == nil {
+		return nil, fmt.Errorf("the backup has no values")
+	}
+	data, err := base64.StdEncoding.DecodeString(b.Values.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the values: %w", err)
+	}
+	var decrypted bytes.Buffer
+	if _, err := crypto.DecryptFIPSKEMDEMStream(priv, bytes.NewReader(data), &decrypted); err != nil {
+		return nil, fmt.Errorf("error decrypting the values (was the backup made with the key %s?): %w", b.Values.Fingerprint, err)
+	}
+	var values Values
+	if err := json.Unmarshal(decrypted.Bytes(), &values); err != nil {
+		return nil, fmt.Errorf("error decoding the values: %w", err)
+	}
+	return &values, nil
+}
+
+// Remap returns the schedules and webhooks of the backup with the agent ids replaced by the ids in
+// the map and the names of the agents missing from it
+func (b *Backup) Remap(ids map[string]string) ([]agent.Schedule, []agent.Webhook, []string) {
+	var missing []string
+	names := make(map[string]string)
+	for _, a := range b.Agents {
+		names[a.ID] = a.Name
+		if _, ok := ids[a.ID]; !ok {
+			missing = append(missing, a.Name)
+		}
+	}
+	var schedules []agent.Schedule
+	for _, s := range b.Schedules {
+		if id, ok := ids[s.AgentID]; ok {
+			s.ID = ""
+			s.AgentID = id
+			schedules = append(schedules, s)
+		}
+	}
+	var webhooks []agent.Webhook
+	for _, w := range b.Webhooks {
+		if id, ok := ids[w.AgentID]; ok {
+			w.ID = ""
+			w.AgentID = id
+			webhooks = append(webhooks, w)
+		}
+	}
+	return schedules, webhooks, missing
+}
+
+// Write writes the backup to the file. The file is only readable by the user since it describes
+// the configuration of the project.
+func (b *Backup) Write(filename string) error {
+	buf, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(buf, '\n'), 0600)
+}
+
+// Load reads the backup from the file
+func Load(filename string) (*Backup, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var b Backup
+	if err := json.Unmarshal(buf, &b); err != nil {
+		return nil, fmt.Errorf("error parsing the backup %s: %w", filename, err)
+	}
+	if b.Version == 0 || b.Version > Version {
+		return nil, fmt.Errorf("unsupported backup version %d, please upgrade the CLI", b.Version)
+	}
+	return &b, nil
+}
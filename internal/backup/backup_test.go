@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/cli/internal/agent"
+	"github.com/agentuity/cli/internal/keys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptValues(t *testing.T) {
+	kp, err := keys.GenerateKeyPair()
+	assert.NoError(t, err)
+	pub, fingerprint, err := keys.ParsePublicKey(kp.PublicKey)
+	assert.NoError(t, err)
+	priv, err := keys.ParsePrivateKey(kp.PrivateKey)
+	assert.NoError(t, err)
+
+	b := &Backup{Version: Version}
+	values := Values{Env: map[string]string{"REGION": "us-east-1"}, Secrets: map[string]string{"OPENAI_API_KEY": "sk-123"}}
+	assert.NoError(t, b.EncryptValues(pub, fingerprint, values))
+	assert.Equal(t, fingerprint, b.Values.Fingerprint)
+	assert.NotContains(t, b.Values.Data, "sk-123")
+
+	decrypted, err := b.DecryptValues(priv)
+	assert.NoError(t, err)
+	assert.Equal(t, values, *decrypted)
+
+	other, err := keys.GenerateKeyPair()
+	assert.NoError(t, err)
+	otherPriv, err := keys.ParsePrivateKey(other.PrivateKey)
+	assert.NoError(t, err)
+	_, err = b.DecryptValues(otherPriv)
+	assert.Error(t, err)
+}
+
+func TestRemap(t *testing.T) {
+	b := &Backup{
+		Agents:    []Agent{{ID: "agent_1", Name: "hello"}, {ID: "agent_2", Name: "billing"}},
+		Schedules: []agent.Schedule{{ID: "sched_1", AgentID: "agent_1", Expression: "0 * * * *"}, {ID: "sched_2", AgentID: "agent_2", Expression: "@daily"}},
+		Webhooks:  []agent.Webhook{{ID: "wh_1", AgentID: "agent_1", URL: "https://example.com/hook"}},
+	}
+	schedules, webhooks, missing := b.Remap(map[string]string{"agent_1": "agent_new"})
+	assert.Equal(t, []agent.Schedule{{AgentID: "agent_new", Expression: "0 * * * *"}}, schedules)
+	assert.Equal(t, []agent.Webhook{{AgentID: "agent_new", URL: "https://example.com/hook"}}, webhooks)
+	assert.Equal(t, []string{"billing"}, missing)
+	assert.Equal(t, "agent_1", b.Schedules[0].AgentID)
+}
+
+func TestWriteLoad(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "backup.json")
+	b := &Backup{Version: Version, Project: Project{ID: "proj_1", Name: "support"}, EnvKeys: []string{"REGION"}}
+	assert.NoError(t, b.Write(fn))
+	info, err := os.Stat(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := Load(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, "support", loaded.Project.Name)
+	assert.Equal(t, []string{"REGION"}, loaded.EnvKeys)
+
+	assert.NoError(t, os.WriteFile(fn, []byte(`{"version":99}`), 0600))
+	_, err = Load(fn)
+	assert.ErrorContains(t, err, "unsupported backup version")
+}
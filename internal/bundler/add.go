@@ -0,0 +1,181 @@
+package bundler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// jsPackageManager returns the package manager of the JavaScript project, preferring bun for the
+// bunjs runtime when there isn't a lockfile yet
+func jsPackageManager(projectDir string, runtime string) string {
+	pm := DetectPackageManager(projectDir)
+	if pm == "npm" && runtime == "bunjs" && !util.Exists(filepath.Join(projectDir, "package-lock.json")) {
+		return "bun"
+	}
+	return pm
+}
+
+// Lockfile returns the name of the lockfile which the package manager of the project updates or
+// an empty string when the project doesn't have one (such as a Python project using pip)
+func Lockfile(projectDir string, language string, runtime string) string {
+	switch language {
+	case "javascript":
+		switch jsPackageManager(projectDir, runtime) {
+		case "bun":
+			if util.Exists(filepath.Join(projectDir, "bun.lockb")) {
+				return "bun.lockb"
+			}
+			return "bun.lock"
+		case "pnpm":
+			return "pnpm-lock.yaml"
+		case "yarn":
+			return "yarn.lock"
+		default:
+			return "package-lock.json"
+		}
+	case "python":
+		if runtime == "uv" {
+			return "uv.lock"
+		}
+	}
+	return ""
+}
+
+// addCommandSpec returns the command which adds the dependency to the manifest and the lockfile
+// of the project. The spec is a version range for JavaScript (such as ^1.2.0) and a PEP 508
+// specifier for Python (such as >=2,<3). The lifecycle scripts of the dependency aren't run: they
+// run when the dependencies are installed by dev or bundle, which can sandbox them.
+func addCommandSpec(projectDir string, language string, runtime string, name string, spec string) (string, []string, error) {
+	switch language {
+	case "javascript":
+		pkg := name
+		if spec != "" {
+			pkg += "@" + spec
+		}
+		switch pm := jsPackageManager(projectDir, runtime); pm {
+		case "bun":
+			return "bun", []string{"add", "--ignore-scripts", "--no-progress", "--no-summary", pkg}, nil
+		case "pnpm":
+			return "pnpm", []string{"add", "--ignore-scripts", "--silent", pkg}, nil
+		case "yarn":
+			return "yarn", []string{"add", "--ignore-scripts", "--silent", pkg}, nil
+		default:
+			return "npm", []string{"install", "--save", "--ignore-scripts", "--no-audit", "--no-fund", pkg}, nil
+		}
+	case "python":
+		if runtime != "uv" {
+			return "", nil, fmt.Errorf("the %s runtime doesn't have a lockfile, add %s%s to pyproject.toml", runtime, name, spec)
+		}
+		return "uv", []string{"add", "--quiet", "--no-progress", name + spec}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported language: %s", language)
+}
+
+// AddDependency adds the dependency with the package manager of the project, which updates both
+// the manifest (package.json or pyproject.toml) and the lockfile, and returns the locked version
+func AddDependency(ctx context.Context, projectDir string, language string, runtime string, name string, spec string) (string, error) {
+	command, args, err := addCommandSpec(projectDir, language, runtime, name, spec)
+	if err != nil {
+		return "", err
+	}
+	c := exec.CommandContext(ctx, command, args...)
+	util.ProcessSetup(c)
+	c.Dir = projectDir
+	c.Env = sandboxEnv(os.Environ())
+	if out, err := c.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%s failed: %w. %s", strings.Join(c.Args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return LockedVersion(projectDir, language, name)
+}
+
+// removeCommandSpec returns the command which removes the dependency from the manifest and the
+// lockfile of the project
+func removeCommandSpec(projectDir string, language string, runtime string, name string) (string, []string, error) {
+	switch language {
+	case "javascript":
+		switch pm := jsPackageManager(projectDir, runtime); pm {
+		case "bun":
+			return "bun", []string{"remove", "--no-progress", "--no-summary", name}, nil
+		case "pnpm":
+			return "pnpm", []string{"remove", "--silent", name}, nil
+		case "yarn":
+			return "yarn", []string{"remove", "--silent", name}, nil
+		default:
+			return "npm", []string{"uninstall", "--save", "--no-audit", "--no-fund", name}, nil
+		}
+	case "python":
+		if runtime != "uv" {
+			return "", nil, fmt.Errorf("the %s runtime doesn't have a lockfile, remove %s from pyproject.toml", runtime, name)
+		}
+		return "uv", []string{"remove", "--quiet", "--no-progress", name}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported language: %s", language)
+}
+
+// RemoveDependency removes the dependency with the package manager of the project, which updates
+// both the manifest (package.json or pyproject.toml) and the lockfile
+func RemoveDependency(ctx context.Context, projectDir string, language string, runtime string, name string) error {
+	command, args, err := removeCommandSpec(projectDir, language, runtime, name)
+	if err != nil {
+		return err
+	}
+	c := exec.CommandContext(ctx, command, args...)
+	util.ProcessSetup(c)
+	c.Dir = projectDir
+	c.Env = sandboxEnv(os.Environ())
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w. %s", strings.Join(c.Args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// LockedVersion returns the version of the dependency which was installed from the lockfile. For
+// JavaScript the version is read from node_modules (which the package managers keep in sync with
+// the lockfile) and for Python from uv.lock.
+func LockedVersion(projectDir string, language string, name string) (string, error) {
+	switch language {
+	case "javascript":
+		buf, err := os.ReadFile(filepath.Join(projectDir, "node_modules", filepath.FromSlash(name), "package.json"))
+		if err != nil {
+			return "", fmt.Errorf("%s was not installed: %w", name, err)
+		}
+		var pkg packageJSON
+		if err := json.Unmarshal(buf, &pkg); err != nil {
+			return "", fmt.Errorf("error parsing the package.json of %s: %w", name, err)
+		}
+		return pkg.Version, nil
+	case "python":
+		of, err := os.Open(filepath.Join(projectDir, "uv.lock"))
+		if err != nil {
+			return "", err
+		}
+		defer of.Close()
+		var lockfile UVLockfile
+		if err := toml.NewDecoder(of).Decode(&lockfile); err != nil {
+			return "", fmt.Errorf("error parsing uv.lock: %w", err)
+		}
+		for _, pkg := range lockfile.Packages {
+			if normalizePythonName(pkg.Name) == normalizePythonName(name) {
+				return pkg.Version, nil
+			}
+		}
+		return "", fmt.Errorf("%s was not found in uv.lock", name)
+	}
+	return "", fmt.Errorf("unsupported language: %s", language)
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes the name of a Python package as defined by PEP 503
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
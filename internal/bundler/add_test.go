@@ -0,0 +1,114 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddCommandSpec(t *testing.T) {
+	tests := []struct {
+		name         string
+		lockFile     string
+		runtime      string
+		expectedCmd  string
+		expectedArgs []string
+		expectedLock string
+	}{
+		{"bun without a lockfile", "", "bunjs", "bun", []string{"add", "--ignore-scripts", "--no-progress", "--no-summary", "zod@^3.22.0"}, "bun.lock"},
+		{"npm without a lockfile", "", "nodejs", "npm", []string{"install", "--save", "--ignore-scripts", "--no-audit", "--no-fund", "zod@^3.22.0"}, "package-lock.json"},
+		{"npm lockfile with bun", "package-lock.json", "bunjs", "npm", []string{"install", "--save", "--ignore-scripts", "--no-audit", "--no-fund", "zod@^3.22.0"}, "package-lock.json"},
+		{"pnpm", "pnpm-lock.yaml", "nodejs", "pnpm", []string{"add", "--ignore-scripts", "--silent", "zod@^3.22.0"}, "pnpm-lock.yaml"},
+		{"yarn", "yarn.lock", "nodejs", "yarn", []string{"add", "--ignore-scripts", "--silent", "zod@^3.22.0"}, "yarn.lock"},
+		{"binary bun lockfile", "bun.lockb", "bunjs", "bun", []string{"add", "--ignore-scripts", "--no-progress", "--no-summary", "zod@^3.22.0"}, "bun.lockb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.lockFile != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, tt.lockFile), []byte(""), 0644))
+			}
+			cmd, args, err := addCommandSpec(dir, "javascript", tt.runtime, "zod", "^3.22.0")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCmd, cmd)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedLock, Lockfile(dir, "javascript", tt.runtime))
+		})
+	}
+
+	dir := t.TempDir()
+	cmd, args, err := addCommandSpec(dir, "python", "uv", "httpx", ">=0.27")
+	require.NoError(t, err)
+	assert.Equal(t, "uv", cmd)
+	assert.Equal(t, []string{"add", "--quiet", "--no-progress", "httpx>=0.27"}, args)
+	assert.Equal(t, "uv.lock", Lockfile(dir, "python", "uv"))
+
+	_, _, err = addCommandSpec(dir, "python", "pip", "httpx", ">=0.27")
+	assert.Error(t, err)
+	assert.Empty(t, Lockfile(dir, "python", "pip"))
+}
+
+func TestRemoveCommandSpec(t *testing.T) {
+	tests := []struct {
+		lockFile     string
+		runtime      string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{"", "bunjs", "bun", []string{"remove", "--no-progress", "--no-summary", "zod"}},
+		{"", "nodejs", "npm", []string{"uninstall", "--save", "--no-audit", "--no-fund", "zod"}},
+		{"pnpm-lock.yaml", "nodejs", "pnpm", []string{"remove", "--silent", "zod"}},
+		{"yarn.lock", "nodejs", "yarn", []string{"remove", "--silent", "zod"}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.lockFile != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.lockFile), []byte(""), 0644))
+		}
+		cmd, args, err := removeCommandSpec(dir, "javascript", tt.runtime, "zod")
+		require.NoError(t, err)
+		assert.Equal(t, tt.expectedCmd, cmd)
+		assert.Equal(t, tt.expectedArgs, args)
+	}
+
+	cmd, args, err := removeCommandSpec(t.TempDir(), "python", "uv", "httpx")
+	require.NoError(t, err)
+	assert.Equal(t, "uv", cmd)
+	assert.Equal(t, []string{"remove", "--quiet", "--no-progress", "httpx"}, args)
+
+	_, _, err = removeCommandSpec(t.TempDir(), "python", "pip", "httpx")
+	assert.Error(t, err)
+}
+
+func TestLockedVersion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "@ai-sdk", "openai"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "@ai-sdk", "openai", "package.json"), []byte(`{"name":"@ai-sdk/openai","version":"1.3.22"}`), 0644))
+	version, err := LockedVersion(dir, "javascript", "@ai-sdk/openai")
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.22", version)
+	_, err = LockedVersion(dir, "javascript", "zod")
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "uv.lock"), []byte(`version = 1
+
+[[package]]
+name = "pyyaml"
+version = "6.0.1"
+
+[[package]]
+name = "typing-extensions"
+version = "4.12.2"
+`), 0644))
+	version, err = LockedVersion(dir, "python", "PyYAML")
+	require.NoError(t, err)
+	assert.Equal(t, "6.0.1", version)
+	version, err = LockedVersion(dir, "python", "typing_extensions")
+	require.NoError(t, err)
+	assert.Equal(t, "4.12.2", version)
+	_, err = LockedVersion(dir, "python", "httpx")
+	assert.Error(t, err)
+}
@@ -0,0 +1,372 @@
+package bundler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const (
+	// AssetsDir is the directory in the build output which the assets are copied to
+	AssetsDir = "assets"
+	// AssetManifestFilename is the file in AssetsDir which lists the assets with their integrity
+	AssetManifestFilename = "manifest.json"
+	// AssetTemplatesFilename is the file in AssetsDir with the inlined templates keyed by their path
+	AssetTemplatesFilename = "templates.json"
+)
+
+// Asset is a file copied verbatim into the bundle
+type Asset struct {
+	// Path is the path of the asset relative to the assets directory, with forward slashes
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Integrity is the subresource integrity of the asset such as sha256-<base64 hash>
+	Integrity string `json:"integrity"`
+	// Source is the path of the file relative to the project which the asset was processed from by
+	// the asset pipeline, whose path has the hash of the content of the asset
+	Source string `json:"source,omitempty"`
+}
+
+// AssetManifest lists the assets of the bundle
+type AssetManifest struct {
+	Assets []Asset `json:"assets"`
+	// Templates is the file in the assets directory with the inlined templates
+	Templates string `json:"templates,omitempty"`
+}
+
+// resolveAssets returns the files matching the asset patterns as paths relative to dir. A pattern
+// is a file, a directory which includes all the files in it or a glob pattern.
+func resolveAssets(dir string, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(rel string) {
+		rel = filepath.ToSlash(rel)
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(strings.TrimPrefix(strings.TrimSpace(pattern), "./"))
+		if pattern == "" || filepath.IsAbs(pattern) || !filepath.IsLocal(filepath.FromSlash(strings.TrimRight(pattern, "/"))) {
+			return nil, fmt.Errorf("asset %q must be a path inside the project", pattern)
+		}
+		matches, err := doublestar.Glob(os.DirFS(dir), strings.TrimRight(pattern, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+		matches = slices.DeleteFunc(matches, func(match string) bool {
+			return skipAssetMatch(pattern, match)
+		})
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("asset %q doesn't match any files", pattern)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(filepath.Join(dir, match))
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(filepath.Join(dir, match), func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				add(rel)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// assetExcludedDirs are not searched by the patterns unless the pattern starts with them
+var assetExcludedDirs = []string{".agentuity", ".git", ".venv", "node_modules"}
+
+func skipAssetMatch(pattern string, match string) bool {
+	first, _, _ := strings.Cut(filepath.ToSlash(match), "/")
+	patternFirst, _, _ := strings.Cut(pattern, "/")
+	return slices.Contains(assetExcludedDirs, first) && first != patternFirst
+}
+
+// copyAsset copies the file and returns its integrity
+func copyAsset(src string, dest string) (int64, string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, "", err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return 0, "", err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), in)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := out.Close(); err != nil {
+		return 0, "", err
+	}
+	return size, "sha256-" + base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyAssets copies the assets of the project in dir verbatim into the assets directory of outdir
+// and writes their manifest. It returns nil when the project has no assets.
+func copyAssets(dir string, outdir string, patterns []string) (*AssetManifest, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	files, err := resolveAssets(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(files, AssetManifestFilename) {
+		return nil, fmt.Errorf("asset %s conflicts with the manifest of the assets", AssetManifestFilename)
+	}
+	assetsDir := filepath.Join(outdir, AssetsDir)
+	manifest := &AssetManifest{Assets: []Asset{}}
+	for _, file := range files {
+		size, integrity, err := copyAsset(filepath.Join(dir, filepath.FromSlash(file)), filepath.Join(assetsDir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy asset %s: %w", file, err)
+		}
+		manifest.Assets = append(manifest.Assets, Asset{Path: file, Size: size, Integrity: integrity})
+	}
+	if err := writeAssetManifest(outdir, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func writeAssetManifest(outdir string, manifest *AssetManifest) error {
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	assetsDir := filepath.Join(outdir, AssetsDir)
+	if err := os.MkdirAll(assetsDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(assetsDir, AssetManifestFilename), buf, 0644)
+}
+
+// integrity returns the subresource integrity of the content
+func integrity(buf []byte) string {
+	sum := sha256.Sum256(buf)
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// hashedAssetPath returns the path of the asset with the start of the hash of its content before
+// its extension, such as ui/logo.3f2a1b9c.png
+func hashedAssetPath(rel string, buf []byte) string {
+	sum := sha256.Sum256(buf)
+	ext := path.Ext(rel)
+	return strings.TrimSuffix(rel, ext) + "." + hex.EncodeToString(sum[:])[:8] + ext
+}
+
+// cssAssetLoaders are the loaders of the files referenced by url() in the CSS assets, which are
+// copied next to the CSS with the hash of their content in their filename
+var cssAssetLoaders = map[string]api.Loader{
+	".png": api.LoaderFile, ".jpg": api.LoaderFile, ".jpeg": api.LoaderFile, ".gif": api.LoaderFile,
+	".svg": api.LoaderFile, ".webp": api.LoaderFile, ".avif": api.LoaderFile, ".ico": api.LoaderFile,
+	".woff": api.LoaderFile, ".woff2": api.LoaderFile, ".ttf": api.LoaderFile, ".otf": api.LoaderFile,
+	".eot": api.LoaderFile,
+}
+
+// runAssetPipeline processes the assets of the pipeline of the project in dir into the assets
+// directory of outdir and adds them to the manifest, which is created when it's nil. The static
+// assets are copied with the hash of their content in their path, the CSS assets are bundled with
+// esbuild and minified for production and the templates are inlined into AssetTemplatesFilename.
+func runAssetPipeline(dir string, outdir string, pipeline *iproject.AssetPipeline, manifest *AssetManifest, production bool) (*AssetManifest, error) {
+	if pipeline.Empty() {
+		return manifest, nil
+	}
+	if manifest == nil {
+		manifest = &AssetManifest{Assets: []Asset{}}
+	}
+	assetsDir := filepath.Join(outdir, AssetsDir)
+	seen := make(map[string]bool)
+	for _, a := range manifest.Assets {
+		seen[a.Path] = true
+	}
+	add := func(a Asset) error {
+		if seen[a.Path] || a.Path == AssetManifestFilename || a.Path == AssetTemplatesFilename {
+			return fmt.Errorf("asset %s conflicts with another asset", a.Path)
+		}
+		seen[a.Path] = true
+		manifest.Assets = append(manifest.Assets, a)
+		return nil
+	}
+
+	if len(pipeline.Static) > 0 {
+		files, err := resolveAssets(dir, pipeline.Static)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			buf, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read asset %s: %w", file, err)
+			}
+			rel := hashedAssetPath(file, buf)
+			dest := filepath.Join(assetsDir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(dest, buf, 0644); err != nil {
+				return nil, fmt.Errorf("failed to copy asset %s: %w", file, err)
+			}
+			if err := add(Asset{Path: rel, Size: int64(len(buf)), Integrity: integrity(buf), Source: file}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(pipeline.CSS) > 0 {
+		assets, err := bundleCSSAssets(dir, assetsDir, pipeline.CSS, production)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assets {
+			if err := add(a); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(pipeline.Templates) > 0 {
+		files, err := resolveAssets(dir, pipeline.Templates)
+		if err != nil {
+			return nil, err
+		}
+		templates := make(map[string]string, len(files))
+		for _, file := range files {
+			buf, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template %s: %w", file, err)
+			}
+			templates[file] = string(buf)
+		}
+		buf, err := json.MarshalIndent(templates, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(assetsDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(assetsDir, AssetTemplatesFilename), buf, 0644); err != nil {
+			return nil, err
+		}
+		manifest.Templates = AssetTemplatesFilename
+	}
+
+	sort.Slice(manifest.Assets, func(i, j int) bool { return manifest.Assets[i].Path < manifest.Assets[j].Path })
+	if err := writeAssetManifest(outdir, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// bundleCSSAssets bundles the CSS files with the files they import, copies the files they reference
+// and returns the assets written with the hash of their content in their path
+func bundleCSSAssets(dir string, assetsDir string, patterns []string, production bool) ([]Asset, error) {
+	files, err := resolveAssets(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	entrypoints := make([]string, 0, len(files))
+	for _, file := range files {
+		if path.Ext(file) != ".css" {
+			return nil, fmt.Errorf("CSS asset %s must be a .css file", file)
+		}
+		entrypoints = append(entrypoints, file)
+	}
+	result := api.Build(api.BuildOptions{
+		AbsWorkingDir:    dir,
+		EntryPoints:      entrypoints,
+		Bundle:           true,
+		Write:            true,
+		Outdir:           assetsDir,
+		Outbase:          dir,
+		EntryNames:       "[dir]/[name].[hash]",
+		AssetNames:       "[dir]/[name].[hash]",
+		Loader:           cssAssetLoaders,
+		MinifyWhitespace: production,
+		MinifySyntax:     production,
+		Metafile:         true,
+		LogLevel:         api.LogLevelSilent,
+		LegalComments:    api.LegalCommentsNone,
+	})
+	if len(result.Errors) > 0 {
+		msg := result.Errors[0]
+		if msg.Location != nil {
+			return nil, fmt.Errorf("failed to process CSS asset %s:%d: %s", msg.Location.File, msg.Location.Line, msg.Text)
+		}
+		return nil, fmt.Errorf("failed to process CSS assets: %s", msg.Text)
+	}
+	var metafile struct {
+		Outputs map[string]struct {
+			EntryPoint string                     `json:"entryPoint"`
+			Inputs     map[string]json.RawMessage `json:"inputs"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal([]byte(result.Metafile), &metafile); err != nil {
+		return nil, fmt.Errorf("failed to parse the CSS metafile: %w", err)
+	}
+	var assets []Asset
+	for output, info := range metafile.Outputs {
+		source := info.EntryPoint
+		if source == "" {
+			for input := range info.Inputs {
+				source = input
+			}
+		}
+		filename := filepath.Join(dir, filepath.FromSlash(output))
+		rel, err := filepath.Rel(assetsDir, filename)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, Asset{Path: filepath.ToSlash(rel), Size: int64(len(buf)), Integrity: integrity(buf), Source: source})
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Path < assets[j].Path })
+	return assets, nil
+}
+
+// jsAssetsShim points AGENTUITY_ASSETS_DIR at the assets next to the bundle unless it is already set
+var jsAssetsShim = `if (!process.env.AGENTUITY_ASSETS_DIR) {
+  process.env.AGENTUITY_ASSETS_DIR = __agentuity_dirname(__filename) + '/` + AssetsDir + `';
+}
+`
@@ -7,8 +7,10 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -80,7 +82,7 @@ func validateDiskRequest(ctx BundleContext, dir string) error {
 						}
 						fmt.Println()
 						ctx.Project.Deployment.Resources.Disk = millisValue
-						if err := ctx.Project.Save(ctx.ProjectDir); err != nil {
+						if err := iproject.SaveProject(ctx.ProjectDir, ctx.Project); err != nil {
 							return fmt.Errorf("error saving project: %w", err)
 						}
 						tui.ShowSuccess("Disk requirement adjusted to %s", millisValue)
@@ -123,7 +125,7 @@ func runTypecheck(ctx BundleContext, dir string, installDir string) error {
 	cmd.Stdout = ctx.Writer
 	cmd.Stderr = ctx.Writer
 	if err := cmd.Run(); err != nil {
-		if ctx.DevMode {
+		if ctx.DevMode || ctx.Watch {
 			ctx.Logger.Error("🚫 TypeScript check failed")
 			return ErrBuildFailed // output goes to the console so we don't need to show it
 		}
@@ -236,7 +238,7 @@ func isAgentInWorkspace(logger logger.Logger, agentDir string, workspace *Worksp
 	}
 
 	// Check if agent is outside workspace root
-	if strings.HasPrefix(relPath, "..") {
+	if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
 		logger.Debug("agent directory is outside workspace root")
 		return false
 	}
@@ -264,13 +266,13 @@ func matchesWorkspacePattern(path, pattern string) bool {
 	if strings.HasPrefix(normalizedPattern, "!") {
 		// This is a negation pattern - check if the path matches the pattern without "!"
 		innerPattern := strings.TrimPrefix(normalizedPattern, "!")
-		matched, err := doublestar.PathMatch(innerPattern, normalizedPath)
+		matched, err := doublestar.Match(innerPattern, normalizedPath)
 		// For negation patterns, we return the inverse of the match
 		return err == nil && !matched
 	}
 
 	// Use doublestar for robust glob matching that supports "**" and proper npm-style patterns
-	matched, err := doublestar.PathMatch(normalizedPattern, normalizedPath)
+	matched, err := doublestar.Match(normalizedPattern, normalizedPath)
 	return err == nil && matched
 }
 
@@ -296,8 +298,8 @@ func findWorkspaceInstallDir(logger logger.Logger, agentDir string) string {
 	return agentDir
 }
 
-// detectPackageManager detects which package manager to use based on lockfiles
-func detectPackageManager(projectDir string) string {
+// DetectPackageManager detects which package manager to use based on lockfiles
+func DetectPackageManager(projectDir string) string {
 	if util.Exists(filepath.Join(projectDir, "pnpm-lock.yaml")) {
 		return "pnpm"
 	} else if util.Exists(filepath.Join(projectDir, "bun.lockb")) || util.Exists(filepath.Join(projectDir, "bun.lock")) {
@@ -309,10 +311,31 @@ func detectPackageManager(projectDir string) string {
 	}
 }
 
+// LockfileRuntime returns the runtime implied by the lockfiles in the project directory for the
+// language or an empty string when there is no lockfile
+func LockfileRuntime(projectDir string, language string) string {
+	switch language {
+	case "javascript":
+		if util.Exists(filepath.Join(projectDir, "bun.lockb")) || util.Exists(filepath.Join(projectDir, "bun.lock")) {
+			return "bunjs"
+		}
+		if util.Exists(filepath.Join(projectDir, "pnpm-lock.yaml")) ||
+			util.Exists(filepath.Join(projectDir, "package-lock.json")) ||
+			util.Exists(filepath.Join(projectDir, "yarn.lock")) {
+			return "nodejs"
+		}
+	case "python":
+		if util.Exists(filepath.Join(projectDir, "uv.lock")) {
+			return "uv"
+		}
+	}
+	return ""
+}
+
 // jsInstallCommandSpec returns the base command name and arguments for installing JavaScript dependencies
 // This function returns the base command without CI-specific modifications
 func jsInstallCommandSpec(projectDir string, isWorkspace bool, production bool) (string, []string, error) {
-	packageManager := detectPackageManager(projectDir)
+	packageManager := DetectPackageManager(projectDir)
 
 	switch packageManager {
 	case "pnpm":
@@ -391,7 +414,7 @@ func getJSInstallCommand(ctx BundleContext, projectDir, runtime string, isWorksp
 	// For bun, we need to ensure the lockfile is up to date before we can run the install
 	// otherwise we'll get an error about the lockfile being out of date
 	// Only do this if we have a logger (i.e., not in tests)
-	if runtime == "bunjs" && ctx.Logger != nil {
+	if runtime == "bunjs" && ctx.Logger != nil && !ctx.FrozenLockfile {
 		if err := generateBunLockfile(ctx, ctx.Logger, projectDir); err != nil {
 			return "", nil, err
 		}
@@ -404,6 +427,7 @@ func getJSInstallCommand(ctx BundleContext, projectDir, runtime string, isWorksp
 
 	// Apply CI-specific modifications
 	args = applyCIModifications(ctx, cmd, runtime, args)
+	args = applyInstallOptions(ctx, cmd, args)
 
 	return cmd, args, nil
 }
@@ -418,7 +442,7 @@ var commonExternalsAutoInstalled = map[string][]string{
 	"playwright-core": {"chromium-bidi"},
 }
 
-func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *project.Project) error {
+func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *project.Project, entrypoints map[string]string) error {
 
 	// Generate prompts if prompts.yaml exists (before dependency installation)
 
@@ -432,30 +456,10 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 	installDir := findWorkspaceInstallDir(ctx.Logger, dir)
 	isWorkspace := installDir != dir // We're using workspace root if installDir differs from agent dir
 
-	if ctx.Install || !util.Exists(filepath.Join(installDir, "node_modules")) {
-		cmd, args, err := getJSInstallCommand(ctx, installDir, theproject.Bundler.Runtime, isWorkspace)
-		if err != nil {
+	if shouldInstall(ctx, util.Exists(filepath.Join(installDir, "node_modules"))) {
+		if err := installJSDependencies(ctx, installDir, theproject.Bundler.Runtime, isWorkspace); err != nil {
 			return err
 		}
-
-		install := exec.CommandContext(ctx.Context, cmd, args...)
-		util.ProcessSetup(install)
-		install.Dir = installDir
-		out, err := install.CombinedOutput()
-		var ec int
-		if install.ProcessState != nil {
-			ec = install.ProcessState.ExitCode()
-		}
-		ctx.Logger.Trace("install command: %s returned: %s, err: %s, exit code: %d", strings.Join(install.Args, " "), strings.TrimSpace(string(out)), err, ec)
-		if err != nil {
-			if install.ProcessState == nil || install.ProcessState.ExitCode() != 0 {
-				if install.ProcessState != nil {
-					return fmt.Errorf("failed to install dependencies (exit code %d): %w. %s", install.ProcessState.ExitCode(), err, string(out))
-				}
-				return fmt.Errorf("failed to install dependencies: %w. %s", err, string(out))
-			}
-		}
-		ctx.Logger.Debug("installed dependencies: %s", strings.TrimSpace(string(out)))
 	}
 
 	var shimSourceMap bool
@@ -471,6 +475,10 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 		return nil // Breaking change was handled gracefully
 	}
 
+	if err := CheckSDKCompatibility(ctx, "javascript", theproject.Bundler.Runtime); err != nil {
+		return err
+	}
+
 	if err := possiblyCreateDeclarationFile(ctx.Logger, dir); err != nil {
 		return err
 	}
@@ -492,6 +500,11 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 			entryPoints = append(entryPoints, file)
 		}
 	}
+	for _, entrypoint := range entrypoints {
+		if file := filepath.Join(dir, filepath.FromSlash(entrypoint)); !slices.Contains(entryPoints, file) {
+			entryPoints = append(entryPoints, file)
+		}
+	}
 	if len(entryPoints) == 0 {
 		return fmt.Errorf("no index.ts files found in %s", theproject.Bundler.AgentConfig.Dir)
 	}
@@ -535,7 +548,7 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 	if err != nil {
 		return fmt.Errorf("failed to load %s: %w", agentuitypkg, err)
 	}
-	agents := getAgents(theproject, "index.js")
+	agents := getAgents(theproject, entrypoints, "index.js")
 	defines := map[string]string{
 		"process.env.AGENTUITY_CLI_VERSION":     fmt.Sprintf("'%s'", Version),
 		"process.env.AGENTUITY_SDK_APP_NAME":    fmt.Sprintf("'%s'", pkg.Data["name"]),
@@ -554,6 +567,18 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 		}
 	}
 	defines["process.env.AGENTUITY_CLOUD_AGENTS_JSON"] = cstr.JSONStringify(cstr.JSONStringify(agents))
+	// the templates of the asset pipeline are inlined so that they don't have to be read at runtime
+	if templates, err := os.ReadFile(filepath.Join(outdir, AssetsDir, AssetTemplatesFilename)); err == nil {
+		defines["process.env.AGENTUITY_ASSET_TEMPLATES_JSON"] = cstr.JSONStringify(string(templates))
+	}
+
+	banner := []string{jsheader, jsshim}
+	if sys.Exists(filepath.Join(outdir, AssetsDir, AssetManifestFilename)) {
+		banner = append(banner, jsAssetsShim)
+	}
+	if ctx.DevMode {
+		banner = append(banner, jsRuntimeStatsShim)
+	}
 
 	ctx.Logger.Debug("starting build")
 	started := time.Now()
@@ -585,7 +610,7 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 		Define:        defines,
 		LegalComments: api.LegalCommentsNone,
 		Banner: map[string]string{
-			"js": strings.Join([]string{jsheader, jsshim}, "\n"),
+			"js": strings.Join(banner, "\n"),
 		},
 	})
 	ctx.Logger.Debug("finished build in %v", time.Since(started))
@@ -597,7 +622,7 @@ func bundleJavascript(ctx BundleContext, dir string, outdir string, theproject *
 			fmt.Fprintln(ctx.Writer, formattedError)
 		}
 
-		if ctx.DevMode {
+		if ctx.DevMode || ctx.Watch {
 			ctx.Logger.Debug("build failed: %v", result.Errors)
 			return ErrBuildFailed
 		}
@@ -696,40 +721,24 @@ func runUVPython(ctx BundleContext, dir string, args ...string) ([]byte, error)
 	return out, nil
 }*/
 
-func bundlePython(ctx BundleContext, dir string, outdir string, theproject *project.Project) error {
-
-	if ctx.Install || !util.Exists(filepath.Join(dir, ".venv", "lib")) {
-		var install *exec.Cmd
-		switch theproject.Bundler.Runtime {
-		case "uv":
-			install = exec.CommandContext(ctx.Context, "uv", "sync", "--no-dev", "--frozen", "--quiet", "--no-progress")
-		case "pip":
-			install = exec.CommandContext(ctx.Context, "uv", "pip", "install", "--quiet", "--no-progress")
-		case "poetry":
-			return fmt.Errorf("poetry is not supported yet")
-		default:
-			return fmt.Errorf("unsupported runtime: %s", theproject.Bundler.Runtime)
-		}
-		util.ProcessSetup(install)
-		install.Dir = dir
-		out, err := install.CombinedOutput()
-		if err != nil {
-			if install.ProcessState == nil || install.ProcessState.ExitCode() != 0 {
-				if install.ProcessState != nil {
-					return fmt.Errorf("failed to install dependencies (exit code %d): %w. %s", install.ProcessState.ExitCode(), err, string(out))
-				}
-				return fmt.Errorf("failed to install dependencies: %w. %s", err, string(out))
-			}
+func bundlePython(ctx BundleContext, dir string, outdir string, theproject *project.Project, entrypoints map[string]string) error {
+
+	if shouldInstall(ctx, util.Exists(filepath.Join(dir, ".venv", "lib"))) {
+		if err := installPythonDependencies(ctx, dir, theproject.Bundler.Runtime); err != nil {
+			return err
 		}
-		ctx.Logger.Debug("installed dependencies: %s", strings.TrimSpace(string(out)))
 	}
 
 	if CheckForBreakingChangesWithBanner(ctx, "python", theproject.Bundler.Runtime) {
 		return nil // Breaking change was handled gracefully
 	}
 
+	if err := CheckSDKCompatibility(ctx, "python", theproject.Bundler.Runtime); err != nil {
+		return err
+	}
+
 	config := map[string]any{
-		"agents":      getAgents(theproject, "agent.py"),
+		"agents":      getAgents(theproject, entrypoints, "agent.py"),
 		"cli_version": Version,
 		"environment": "development",
 	}
@@ -760,20 +769,38 @@ func bundlePython(ctx BundleContext, dir string, outdir string, theproject *proj
 	return os.WriteFile(filepath.Join(outdir, "config.json"), []byte(cstr.JSONStringify(config)), 0644)
 }
 
-func getAgents(theproject *project.Project, filename string) []AgentConfig {
+// getAgents returns the agents with the filename of their bundled entrypoint, which is the filename
+// in the agent directory unless the agent declares an entrypoint in the project file. The
+// entrypoints keep their path with the extension of the filename.
+func getAgents(theproject *project.Project, entrypoints map[string]string, filename string) []AgentConfig {
 	var agents []AgentConfig
 	for _, agent := range theproject.Agents {
-		var agentfilename string
-		agentfilename = util.SafeProjectFilename(agent.Name, theproject.IsPython())
+		// the config is read by the runtime in the cloud so the filename always uses forward slashes
+		agentfilename := iproject.AgentEntrypoint(theproject, agent, entrypoints, filename)
 		agents = append(agents, AgentConfig{
 			ID:       agent.ID,
 			Name:     agent.Name,
-			Filename: filepath.Join(theproject.Bundler.AgentConfig.Dir, agentfilename, filename),
+			Filename: strings.TrimSuffix(agentfilename, path.Ext(agentfilename)) + path.Ext(filename),
 		})
 	}
 	return agents
 }
 
+// loadAgentEntrypoints returns the entrypoints declared in the project file keyed by agent id
+// after checking that they exist
+func loadAgentEntrypoints(dir string, theproject *project.Project) (map[string]string, error) {
+	entrypoints, err := iproject.LoadAgentEntrypoints(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the entrypoints of the agents: %w", err)
+	}
+	for _, agent := range theproject.Agents {
+		if entrypoint, ok := entrypoints[agent.ID]; ok && !sys.Exists(filepath.Join(dir, filepath.FromSlash(entrypoint))) {
+			return nil, fmt.Errorf("the entrypoint %s of the agent %s doesn't exist", entrypoint, agent.Name)
+		}
+	}
+	return entrypoints, nil
+}
+
 func CreateDeploymentMutator(ctx BundleContext) util.ZipDirCallbackMutator {
 	return func(writer *zip.Writer) error {
 		// NOTE: for now we don't need to do anything here
@@ -783,6 +810,26 @@ func CreateDeploymentMutator(ctx BundleContext) util.ZipDirCallbackMutator {
 	}
 }
 
+// removeBuildOutput removes everything in the outdir except the local state of the CLI
+func removeBuildOutput(outdir string) error {
+	entries, err := os.ReadDir(outdir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && slices.Contains(iproject.StateDirs, entry.Name()) {
+			continue
+		}
+		if !entry.IsDir() && slices.Contains(iproject.StateFiles, entry.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(outdir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func Bundle(ctx BundleContext) error {
 	theproject := iproject.NewProject()
 	if err := theproject.Load(ctx.ProjectDir); err != nil {
@@ -792,22 +839,44 @@ func Bundle(ctx BundleContext) error {
 		return fmt.Errorf("project in the directory %s is not a valid agentuity project", ctx.ProjectDir)
 	}
 	dir := ctx.ProjectDir
-	outdir := filepath.Join(dir, ".agentuity")
+	outdir := filepath.Join(dir, iproject.AgentuityDir)
 	ctx.Logger.Debug("bundling project %s to %s", dir, outdir)
 	if sys.Exists(outdir) {
-		ctx.Logger.Debug("removing existing directory: %s", outdir)
-		if err := os.RemoveAll(outdir); err != nil {
-			return fmt.Errorf("failed to remove .agentuity directory: %w", err)
+		ctx.Logger.Debug("removing the previous build output from: %s", outdir)
+		if err := removeBuildOutput(outdir); err != nil {
+			return fmt.Errorf("failed to clean .agentuity directory: %w", err)
 		}
 	}
 	if err := os.MkdirAll(outdir, 0755); err != nil {
 		return fmt.Errorf("failed to create .agentuity directory: %w", err)
 	}
+	patterns, err := iproject.LoadProjectAssets(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load the assets of the project: %w", err)
+	}
+	manifest, err := copyAssets(dir, outdir, patterns)
+	if err != nil {
+		return err
+	}
+	pipeline, err := iproject.LoadAssetPipeline(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load the asset pipeline of the project: %w", err)
+	}
+	if manifest, err = runAssetPipeline(dir, outdir, pipeline, manifest, ctx.Production); err != nil {
+		return err
+	}
+	if manifest != nil {
+		ctx.Logger.Debug("copied %d assets to %s", len(manifest.Assets), filepath.Join(outdir, AssetsDir))
+	}
+	entrypoints, err := loadAgentEntrypoints(dir, theproject)
+	if err != nil {
+		return err
+	}
 	switch theproject.Bundler.Language {
 	case "javascript":
-		return bundleJavascript(ctx, dir, outdir, theproject)
+		return bundleJavascript(ctx, dir, outdir, theproject, entrypoints)
 	case "python":
-		return bundlePython(ctx, dir, outdir, theproject)
+		return bundlePython(ctx, dir, outdir, theproject, entrypoints)
 	}
 	return fmt.Errorf("unsupported runtime: %s", theproject.Bundler.Runtime)
 }
@@ -2,10 +2,14 @@ package bundler
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/go-common/project"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -237,3 +241,174 @@ func TestNpmStyleGlobPatterns(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveBuildOutput(t *testing.T) {
+	outdir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outdir, "index.js"), []byte("x"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(outdir, "src", "agents"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(outdir, "sessions"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outdir, "sessions", "default.jsonl"), []byte("{}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outdir, "dev.json"), []byte("{}"), 0644))
+
+	require.NoError(t, removeBuildOutput(outdir))
+	entries, err := os.ReadDir(outdir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "dev.json", entries[0].Name())
+	assert.Equal(t, "sessions", entries[1].Name())
+	assert.FileExists(t, filepath.Join(outdir, "sessions", "default.jsonl"))
+}
+
+func TestCopyAssets(t *testing.T) {
+	dir := t.TempDir()
+	outdir := filepath.Join(dir, ".agentuity")
+	wasm := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "models", "tokenizer"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "models", "model.onnx"), []byte("onnx"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "models", "tokenizer", "vocab.bin"), []byte{0xff, 0xfe}, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "module.wasm"), wasm, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "dep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "dep", "dep.wasm"), wasm, 0644))
+
+	manifest, err := copyAssets(dir, outdir, []string{"models/", "**/*.wasm", "./models/model.onnx"})
+	require.NoError(t, err)
+	require.Len(t, manifest.Assets, 3)
+	assert.Equal(t, "models/model.onnx", manifest.Assets[0].Path)
+	assert.Equal(t, "models/tokenizer/vocab.bin", manifest.Assets[1].Path)
+	assert.Equal(t, "module.wasm", manifest.Assets[2].Path)
+	assert.Equal(t, int64(8), manifest.Assets[2].Size)
+	assert.Equal(t, "sha256-h+k/ifK+DbNk6L4FL3nziebC2iOYMZIuJFEyiK9SKkM=", manifest.Assets[0].Integrity)
+
+	buf, err := os.ReadFile(filepath.Join(outdir, AssetsDir, "module.wasm"))
+	require.NoError(t, err)
+	assert.Equal(t, wasm, buf)
+	assert.FileExists(t, filepath.Join(outdir, AssetsDir, AssetManifestFilename))
+
+	manifest, err = copyAssets(dir, outdir, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, manifest)
+	_, err = copyAssets(dir, outdir, []string{"missing/*.onnx"})
+	assert.ErrorContains(t, err, "doesn't match any files")
+	_, err = copyAssets(dir, outdir, []string{"../outside.bin"})
+	assert.ErrorContains(t, err, "must be a path inside the project")
+}
+
+func TestNodeModulesFilter(t *testing.T) {
+	tests := []struct {
+		module   string
+		filename string
+		path     string
+		expected bool
+	}{
+		{"openai", "index", "/app/node_modules/openai/index.mjs", true},
+		{"openai", "index", `C:\app\node_modules\openai\index.mjs`, true},
+		{"openai", "index", "/app/node_modules/openai/core.mjs", false},
+		{"@anthropic-ai", "sdk/index", "/app/node_modules/@anthropic-ai/sdk/index.mjs", true},
+		{"@anthropic-ai", "sdk/index", `C:\app\node_modules\@anthropic-ai\sdk\index.mjs`, true},
+		{"@anthropic-ai", "sdk/index", "/app/node_modules/@anthropic-ai/tokenizer/index.mjs", false},
+		{"@ai-sdk/openai", "", `C:\app\node_modules\@ai-sdk\openai\dist\index.js`, true},
+		{"@ai-sdk/openai", "", "/app/node_modules/@ai-sdk/openai-compatible/dist/index.js", false},
+	}
+	for _, tt := range tests {
+		filter := regexp.MustCompile(nodeModulesFilter(tt.module, tt.filename))
+		assert.Equal(t, tt.expected, filter.MatchString(tt.path), "filter %q for path %q", filter, tt.path)
+	}
+}
+
+func TestGetAgentsFilename(t *testing.T) {
+	theproject := &project.Project{
+		Bundler: &project.Bundler{AgentConfig: project.AgentBundlerConfig{Dir: filepath.Join("src", "agents")}},
+		Agents:  []project.AgentConfig{{ID: "agent_1", Name: "my-agent"}},
+	}
+	agents := getAgents(theproject, nil, "index.js")
+	require.Len(t, agents, 1)
+	assert.Equal(t, "src/agents/my-agent/index.js", agents[0].Filename)
+
+	// the declared entrypoint is bundled next to its source
+	agents = getAgents(theproject, map[string]string{"agent_1": "lib/support/handler.ts"}, "index.js")
+	require.Len(t, agents, 1)
+	assert.Equal(t, "lib/support/handler.js", agents[0].Filename)
+}
+
+func TestLoadAgentEntrypoints(t *testing.T) {
+	dir := t.TempDir()
+	theproject := &project.Project{
+		ProjectId: "proj_123",
+		Name:      "test",
+		Bundler:   &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}},
+		Agents:    []project.AgentConfig{{ID: "agent_1", Name: "support"}},
+	}
+	require.NoError(t, iproject.SaveProject(dir, theproject))
+	require.NoError(t, iproject.SaveProjectWithAgentEntrypoints(dir, theproject, map[string]string{"agent_1": "lib/handler.ts"}))
+
+	_, err := loadAgentEntrypoints(dir, theproject)
+	assert.ErrorContains(t, err, "the entrypoint lib/handler.ts of the agent support doesn't exist")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "handler.ts"), []byte("export default {}"), 0644))
+	entrypoints, err := loadAgentEntrypoints(dir, theproject)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"agent_1": "lib/handler.ts"}, entrypoints)
+}
+
+func TestRunAssetPipeline(t *testing.T) {
+	dir := t.TempDir()
+	outdir := filepath.Join(dir, ".agentuity")
+	png := []byte("\x89PNG\r\n\x1a\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ui", "fonts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "emails"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "logo.png"), png, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "fonts", "inter.woff2"), []byte("wOF2"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "base.css"), []byte("body {\n  margin: 0;\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "app.css"), []byte("@import \"./base.css\";\n@font-face {\n  font-family: Inter;\n  src: url(\"./fonts/inter.woff2\");\n}\n.logo {\n  background: url(./logo.png);\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "emails", "welcome.html"), []byte("<p>Hello {{name}}</p>\n"), 0644))
+
+	verbatim, err := copyAssets(dir, outdir, []string{"ui/logo.png"})
+	require.NoError(t, err)
+	manifest, err := runAssetPipeline(dir, outdir, &iproject.AssetPipeline{
+		Static:    []string{"ui/*.png"},
+		CSS:       []string{"ui/app.css"},
+		Templates: []string{"emails/"},
+	}, verbatim, true)
+	require.NoError(t, err)
+
+	// the verbatim asset is kept next to the hashed ones of the static assets and of the CSS
+	var paths []string
+	sources := make(map[string]Asset)
+	for _, a := range manifest.Assets {
+		paths = append(paths, a.Path)
+		sources[a.Source] = a
+	}
+	require.Len(t, paths, 5)
+	assert.Equal(t, "ui/logo.png", sources[""].Path)
+	assert.Regexp(t, `^ui/logo\.[0-9a-f]{8}\.png$`, paths[2])
+	assert.Regexp(t, `^ui/fonts/inter\.[A-Z0-9]{8}\.woff2$`, sources["ui/fonts/inter.woff2"].Path)
+	css := sources["ui/app.css"]
+	assert.Regexp(t, `^ui/app\.[A-Z0-9]{8}\.css$`, css.Path)
+	buf, err := os.ReadFile(filepath.Join(outdir, AssetsDir, filepath.FromSlash(css.Path)))
+	require.NoError(t, err)
+	// the import is bundled, the CSS is minified and the references point at the hashed files
+	assert.Contains(t, string(buf), "body{margin:0}")
+	assert.Contains(t, string(buf), "fonts/inter.")
+	assert.Equal(t, integrity(buf), css.Integrity)
+
+	assert.Equal(t, AssetTemplatesFilename, manifest.Templates)
+	buf, err = os.ReadFile(filepath.Join(outdir, AssetsDir, AssetTemplatesFilename))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"emails/welcome.html": "<p>Hello {{name}}</p>\n"}`, string(buf))
+
+	var saved AssetManifest
+	buf, err = os.ReadFile(filepath.Join(outdir, AssetsDir, AssetManifestFilename))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(buf, &saved))
+	assert.Equal(t, *manifest, saved)
+
+	manifest, err = runAssetPipeline(dir, outdir, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, manifest)
+	_, err = runAssetPipeline(dir, outdir, &iproject.AssetPipeline{CSS: []string{"ui/logo.png"}}, nil, false)
+	assert.ErrorContains(t, err, "must be a .css file")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "broken.css"), []byte("@import \"./missing.css\";\n"), 0644))
+	_, err = runAssetPipeline(dir, outdir, &iproject.AssetPipeline{CSS: []string{"ui/broken.css"}}, nil, false)
+	assert.ErrorContains(t, err, "ui/broken.css")
+}
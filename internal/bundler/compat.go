@@ -0,0 +1,130 @@
+package bundler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// SDKCompatibility is the range of the versions of the SDK of a language which this version of
+// the CLI supports. The bundler injects code which relies on the internals of the SDK, such as
+// the shim and the patches of the prompts, so an SDK outside of the range may build and then fail
+// in subtle ways at runtime.
+type SDKCompatibility struct {
+	Language string
+	Package  string
+	// Range is the semver constraint of the supported versions
+	Range string
+}
+
+// SDKCompatibilityMatrix is the SDK versions supported by this version of the CLI
+var SDKCompatibilityMatrix = []SDKCompatibility{
+	{Language: "javascript", Package: "@agentuity/sdk", Range: ">= 0.0.157, < 1.0.0"},
+	{Language: "python", Package: "agentuity", Range: ">= 0.0.84, < 1.0.0"},
+}
+
+// SDKCompatibilityError is returned when the installed SDK isn't supported by the CLI
+type SDKCompatibilityError struct {
+	Package string
+	Version string
+	Range   string
+	// TooNew is true when the SDK is newer than the CLI supports and the CLI must be upgraded
+	TooNew bool
+	// Upgrade is the command which upgrades the SDK or the CLI
+	Upgrade string
+}
+
+func (e *SDKCompatibilityError) Error() string {
+	return fmt.Sprintf("%s %s is not supported by this version of the CLI (supported: %s)", e.Package, e.Version, e.Range)
+}
+
+// Advice returns the error with how to fix it
+func (e *SDKCompatibilityError) Advice() string {
+	if e.TooNew {
+		return fmt.Sprintf("%s. The SDK is newer than this version of the CLI supports, run `%s` to upgrade the CLI or use --skip-compat-check to continue anyway.", e.Error(), e.Upgrade)
+	}
+	return fmt.Sprintf("%s. Run `%s` to upgrade the SDK or use --skip-compat-check to continue anyway.", e.Error(), e.Upgrade)
+}
+
+// sdkUpgradeCommand returns the command which upgrades the SDK with the package manager of the project
+func sdkUpgradeCommand(projectDir string, language string, runtime string) string {
+	switch language {
+	case "javascript":
+		switch jsPackageManager(projectDir, runtime) {
+		case "bun":
+			return "bun update @agentuity/sdk --latest"
+		case "pnpm":
+			return "pnpm update @agentuity/sdk --latest"
+		case "yarn":
+			return "yarn upgrade @agentuity/sdk --latest"
+		default:
+			return "npm install @agentuity/sdk@latest"
+		}
+	case "python":
+		if runtime == "uv" {
+			return "uv add agentuity -U"
+		}
+		return "pip install -U agentuity"
+	}
+	return ""
+}
+
+// checkSDKCompatibility returns an error if the version of the SDK of the language isn't supported.
+// Prerelease versions are local builds of the SDK and are always allowed.
+func checkSDKCompatibility(language string, version *semver.Version) (*SDKCompatibilityError, error) {
+	if strings.Contains(version.String(), "-pre") {
+		return nil, nil
+	}
+	for _, compat := range SDKCompatibilityMatrix {
+		if compat.Language != language {
+			continue
+		}
+		c, err := semver.NewConstraint(compat.Range)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing semver constraint %s: %w", compat.Range, err)
+		}
+		if c.Check(version) {
+			return nil, nil
+		}
+		min, err := semver.NewConstraint(strings.TrimSpace(strings.Split(compat.Range, ",")[0]))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing semver constraint %s: %w", compat.Range, err)
+		}
+		return &SDKCompatibilityError{
+			Package: compat.Package,
+			Version: version.String(),
+			Range:   compat.Range,
+			TooNew:  min.Check(version),
+		}, nil
+	}
+	return nil, nil
+}
+
+// CheckSDKCompatibility returns a *SDKCompatibilityError if the installed SDK of the project isn't
+// supported by this version of the CLI, unless the check is skipped
+func CheckSDKCompatibility(ctx BundleContext, language string, runtime string) error {
+	if ctx.SkipCompatCheck {
+		return nil
+	}
+	version, err := GetSDKVersion(language, ctx)
+	if err != nil {
+		// the bundle fails later with a better error when the SDK isn't installed
+		ctx.Logger.Debug("failed to get the version of the SDK: %s", err)
+		return nil
+	}
+	cerr, err := checkSDKCompatibility(language, version)
+	if err != nil {
+		return err
+	}
+	if cerr == nil {
+		ctx.Logger.Debug("the %s SDK %s is supported", language, version)
+		return nil
+	}
+	if cerr.TooNew {
+		cerr.Upgrade = "agentuity upgrade"
+	} else {
+		cerr.Upgrade = sdkUpgradeCommand(ctx.ProjectDir, language, runtime)
+	}
+	return cerr
+}
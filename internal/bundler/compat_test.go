@@ -0,0 +1,59 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSDKCompatibility(t *testing.T) {
+	tests := []struct {
+		language string
+		version  string
+		ok       bool
+		tooNew   bool
+	}{
+		{"javascript", "0.0.157", true, false},
+		{"javascript", "0.0.200", true, false},
+		{"javascript", "0.0.150", false, false},
+		{"javascript", "1.0.0", false, true},
+		{"javascript", "0.0.100-prerelease", true, false},
+		{"python", "0.0.84", true, false},
+		{"python", "0.0.80", false, false},
+		{"python", "2.1.0", false, true},
+	}
+	for _, tt := range tests {
+		cerr, err := checkSDKCompatibility(tt.language, semver.MustParse(tt.version))
+		require.NoError(t, err)
+		if tt.ok {
+			assert.Nil(t, cerr, tt.version)
+			continue
+		}
+		require.NotNil(t, cerr, tt.version)
+		assert.Equal(t, tt.tooNew, cerr.TooNew, tt.version)
+		assert.Equal(t, tt.version, cerr.Version)
+	}
+}
+
+func TestCheckSDKCompatibilityProject(t *testing.T) {
+	dir := t.TempDir()
+	pkgdir := filepath.Join(dir, "node_modules", "@agentuity", "sdk")
+	require.NoError(t, os.MkdirAll(pkgdir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pkgdir, "package.json"), []byte(`{"version":"0.0.120"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), nil, 0644))
+
+	ctx := BundleContext{Logger: logger.NewTestLogger(), ProjectDir: dir}
+	err := CheckSDKCompatibility(ctx, "javascript", "nodejs")
+	var cerr *SDKCompatibilityError
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, "pnpm update @agentuity/sdk --latest", cerr.Upgrade)
+	assert.Contains(t, cerr.Advice(), "--skip-compat-check")
+
+	ctx.SkipCompatCheck = true
+	assert.NoError(t, CheckSDKCompatibility(ctx, "javascript", "nodejs"))
+}
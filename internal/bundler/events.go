@@ -0,0 +1,87 @@
+package bundler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The types of the events written by the watch mode of the bundler
+const (
+	BuildStartEvent   = "build-start"
+	BuildSuccessEvent = "build-success"
+	BuildErrorEvent   = "build-error"
+)
+
+// BuildEvent is an event of the watch mode of the bundler, written as a line of JSON so that
+// other build tools such as IDE tasks can follow the builds
+type BuildEvent struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Build is the number of the build, starting at 1, which is the same for the events of a build
+	Build int `json:"build"`
+	// Changed are the files which changed since the previous build
+	Changed []string `json:"changed,omitempty"`
+	// Request is the id of the request which started the build
+	Request any `json:"request,omitempty"`
+	// Duration is how long the build took in milliseconds
+	Duration int64  `json:"duration,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Output is the output of the build tool when the build failed, such as the type errors
+	Output string `json:"output,omitempty"`
+}
+
+// EventWriter writes the events as JSON lines. It is safe to use from multiple goroutines.
+type EventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEventWriter returns a writer of the events to w
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event, setting its time if it isn't set
+func (w *EventWriter) Emit(event BuildEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(event)
+}
+
+// The methods which can be sent to the watch mode of the bundler on stdin
+const (
+	WatchMethodBuild = "build"
+	WatchMethodStop  = "stop"
+)
+
+// WatchRequest is a request sent to the watch mode of the bundler as a line of JSON, in the style
+// of JSON-RPC such as {"id": 1, "method": "build"}. A line with only the method is accepted as well.
+type WatchRequest struct {
+	ID     any    `json:"id,omitempty"`
+	Method string `json:"method"`
+}
+
+// ParseWatchRequest parses a line sent to the watch mode of the bundler
+func ParseWatchRequest(line string) (*WatchRequest, error) {
+	line = strings.TrimSpace(line)
+	var req WatchRequest
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+	} else {
+		req.Method = line
+	}
+	switch req.Method {
+	case WatchMethodBuild, WatchMethodStop:
+		return &req, nil
+	}
+	return nil, fmt.Errorf("unknown method %q (must be %s or %s)", req.Method, WatchMethodBuild, WatchMethodStop)
+}
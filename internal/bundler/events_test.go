@@ -0,0 +1,48 @@
+package bundler
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEventWriter(&buf)
+	require.NoError(t, w.Emit(BuildEvent{Type: BuildStartEvent, Build: 1, Changed: []string{"src/index.ts"}}))
+	require.NoError(t, w.Emit(BuildEvent{Type: BuildErrorEvent, Build: 1, Duration: 12, Error: "build failed", Output: "error TS2322"}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var start, failed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &start))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &failed))
+	assert.Equal(t, "build-start", start["type"])
+	assert.Equal(t, []any{"src/index.ts"}, start["changed"])
+	assert.NotEmpty(t, start["time"])
+	assert.NotContains(t, start, "error")
+	assert.Equal(t, "build-error", failed["type"])
+	assert.Equal(t, float64(12), failed["duration"])
+	assert.Equal(t, "error TS2322", failed["output"])
+}
+
+func TestParseWatchRequest(t *testing.T) {
+	req, err := ParseWatchRequest(`{"id": 7, "method": "build"}`)
+	require.NoError(t, err)
+	assert.Equal(t, WatchMethodBuild, req.Method)
+	assert.Equal(t, float64(7), req.ID)
+
+	req, err = ParseWatchRequest(" stop\n")
+	require.NoError(t, err)
+	assert.Equal(t, WatchMethodStop, req.Method)
+	assert.Nil(t, req.ID)
+
+	_, err = ParseWatchRequest(`{"method": "deploy"}`)
+	assert.Error(t, err)
+	_, err = ParseWatchRequest(`{"method": `)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+)
+
+// applyInstallOptions applies the frozen lockfile and sandbox options to the install command arguments
+func applyInstallOptions(ctx BundleContext, cmd string, args []string) []string {
+	add := func(flags ...string) {
+		for _, flag := range flags {
+			if !slices.Contains(args, flag) {
+				args = append(args, flag)
+			}
+		}
+	}
+	if ctx.FrozenLockfile {
+		switch cmd {
+		case "npm":
+			// npm ci installs exactly what is in package-lock.json and fails if it is out of date
+			args = append([]string{"ci"}, args[1:]...)
+		case "pnpm", "bun", "yarn":
+			add("--frozen-lockfile")
+		}
+	}
+	if ctx.Sandbox {
+		// never run the lifecycle scripts (such as postinstall) of the dependencies
+		add("--ignore-scripts")
+	}
+	return args
+}
+
+// pythonInstallCommand returns the command name and arguments for installing Python dependencies
+func pythonInstallCommand(ctx BundleContext, runtime string) (string, []string, error) {
+	var args []string
+	switch runtime {
+	case "uv":
+		args = []string{"sync", "--no-dev", "--frozen", "--quiet", "--no-progress"}
+		if ctx.FrozenLockfile {
+			// fail instead of installing when uv.lock is out of date with pyproject.toml
+			args = []string{"sync", "--no-dev", "--locked", "--quiet", "--no-progress"}
+		}
+	case "pip":
+		args = []string{"pip", "install", "--quiet", "--no-progress"}
+	case "poetry":
+		return "", nil, fmt.Errorf("poetry is not supported yet")
+	default:
+		return "", nil, fmt.Errorf("unsupported runtime: %s", runtime)
+	}
+	if ctx.Sandbox {
+		// only install pre-built wheels so that no package build scripts are run
+		args = append(args, "--no-build")
+	}
+	return "uv", args, nil
+}
+
+// sandboxSecretMarkers are the parts of environment variable names which are withheld from the
+// package manager in sandbox mode
+var sandboxSecretMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL"}
+
+// sandboxEnv returns the environment without the Agentuity variables and the variables which look
+// like secrets so that the code of a dependency can't read them
+func sandboxEnv(env []string) []string {
+	var result []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		upper := strings.ToUpper(name)
+		if strings.HasPrefix(upper, "AGENTUITY_") || slices.ContainsFunc(sandboxSecretMarkers, func(marker string) bool {
+			return strings.Contains(upper, marker)
+		}) {
+			continue
+		}
+		result = append(result, kv)
+	}
+	return result
+}
+
+// runInstall runs the install command. The output is only shown if the install fails, in which
+// case it is written to the writer of the context.
+func runInstall(ctx BundleContext, dir string, name string, args ...string) error {
+	install := exec.CommandContext(ctx.Context, name, args...)
+	util.ProcessSetup(install)
+	install.Dir = dir
+	if ctx.Sandbox {
+		install.Env = sandboxEnv(os.Environ())
+	}
+	out, err := install.CombinedOutput()
+	var ec int
+	if install.ProcessState != nil {
+		ec = install.ProcessState.ExitCode()
+	}
+	if ctx.Logger != nil {
+		ctx.Logger.Trace("install command: %s returned: %s, err: %s, exit code: %d", strings.Join(install.Args, " "), strings.TrimSpace(string(out)), err, ec)
+	}
+	if err != nil && (install.ProcessState == nil || ec != 0) {
+		var detail string
+		if ctx.Writer != nil && len(out) > 0 {
+			fmt.Fprintf(ctx.Writer, "\n%s failed:\n\n%s\n\n", strings.Join(install.Args, " "), strings.TrimSpace(string(out)))
+		} else {
+			detail = ". " + string(out)
+		}
+		if install.ProcessState != nil {
+			return fmt.Errorf("failed to install dependencies (exit code %d): %w%s", ec, err, detail)
+		}
+		return fmt.Errorf("failed to install dependencies: %w%s", err, detail)
+	}
+	if ctx.Logger != nil {
+		ctx.Logger.Debug("installed dependencies: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// shouldInstall returns true if the dependencies should be installed given whether they already are
+func shouldInstall(ctx BundleContext, installed bool) bool {
+	if ctx.NoInstall {
+		if !installed && ctx.Logger != nil {
+			ctx.Logger.Warn("dependencies are not installed and installation was skipped")
+		}
+		return false
+	}
+	return ctx.Install || !installed
+}
+
+// installJSDependencies installs the dependencies of the JavaScript project in installDir
+func installJSDependencies(ctx BundleContext, installDir string, runtime string, isWorkspace bool) error {
+	cmd, args, err := getJSInstallCommand(ctx, installDir, runtime, isWorkspace)
+	if err != nil {
+		return err
+	}
+	return runInstall(ctx, installDir, cmd, args...)
+}
+
+// installPythonDependencies installs the dependencies of the Python project in dir
+func installPythonDependencies(ctx BundleContext, dir string, runtime string) error {
+	cmd, args, err := pythonInstallCommand(ctx, runtime)
+	if err != nil {
+		return err
+	}
+	return runInstall(ctx, dir, cmd, args...)
+}
@@ -0,0 +1,99 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		lockFile     string
+		frozen       bool
+		sandbox      bool
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{"npm frozen uses ci", "package-lock.json", true, false, "npm", []string{"ci", "--no-audit", "--no-fund", "--omit=dev", "--ignore-scripts"}},
+		{"pnpm frozen", "pnpm-lock.yaml", true, false, "pnpm", []string{"install", "--prod", "--ignore-scripts", "--silent", "--frozen-lockfile"}},
+		{"bun frozen", "bun.lock", true, false, "bun", []string{"install", "--production", "--ignore-scripts", "--no-progress", "--no-summary", "--silent", "--frozen-lockfile"}},
+		{"yarn frozen is not duplicated", "yarn.lock", true, false, "yarn", []string{"install", "--frozen-lockfile"}},
+		{"yarn sandbox ignores scripts", "yarn.lock", false, true, "yarn", []string{"install", "--frozen-lockfile", "--ignore-scripts"}},
+		{"npm sandbox is not duplicated", "package-lock.json", false, true, "npm", []string{"install", "--no-audit", "--no-fund", "--omit=dev", "--ignore-scripts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.lockFile), []byte(""), 0644))
+			ctx := BundleContext{Context: context.Background(), FrozenLockfile: tt.frozen, Sandbox: tt.sandbox}
+			cmd, args, err := getJSInstallCommand(ctx, dir, "nodejs", false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCmd, cmd)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestPythonInstallCommand(t *testing.T) {
+	cmd, args, err := pythonInstallCommand(BundleContext{}, "uv")
+	require.NoError(t, err)
+	assert.Equal(t, "uv", cmd)
+	assert.Equal(t, []string{"sync", "--no-dev", "--frozen", "--quiet", "--no-progress"}, args)
+
+	_, args, err = pythonInstallCommand(BundleContext{FrozenLockfile: true, Sandbox: true}, "uv")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sync", "--no-dev", "--locked", "--quiet", "--no-progress", "--no-build"}, args)
+
+	_, args, err = pythonInstallCommand(BundleContext{Sandbox: true}, "pip")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pip", "install", "--quiet", "--no-progress", "--no-build"}, args)
+
+	_, _, err = pythonInstallCommand(BundleContext{}, "poetry")
+	assert.Error(t, err)
+}
+
+func TestSandboxEnv(t *testing.T) {
+	env := sandboxEnv([]string{
+		"PATH=/usr/bin",
+		"HOME=/home/user",
+		"AGENTUITY_SDK_KEY=secret",
+		"AGENTUITY_URL=https://example.com",
+		"OPENAI_API_KEY=secret",
+		"GITHUB_TOKEN=secret",
+		"DB_PASSWORD=secret",
+		"aws_secret_access_key=secret",
+		"NODE_ENV=production",
+	})
+	assert.Equal(t, []string{"PATH=/usr/bin", "HOME=/home/user", "NODE_ENV=production"}, env)
+}
+
+func TestShouldInstall(t *testing.T) {
+	assert.True(t, shouldInstall(BundleContext{}, false))
+	assert.False(t, shouldInstall(BundleContext{}, true))
+	assert.True(t, shouldInstall(BundleContext{Install: true}, true))
+	assert.False(t, shouldInstall(BundleContext{NoInstall: true}, false))
+}
+
+func TestRunInstallFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	var out bytes.Buffer
+	ctx := BundleContext{Context: context.Background(), Writer: &out}
+	err := runInstall(ctx, t.TempDir(), "sh", "-c", "echo resolving; echo 'no matching version' >&2; exit 3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 3")
+	// the output of the failed install is written to the writer instead of being buried in the error
+	assert.NotContains(t, err.Error(), "no matching version")
+	assert.Contains(t, out.String(), "resolving")
+	assert.Contains(t, out.String(), "no matching version")
+
+	assert.NoError(t, runInstall(ctx, t.TempDir(), "sh", "-c", "exit 0"))
+}
@@ -3,7 +3,8 @@ package bundler
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/agentuity/go-common/logger"
@@ -74,12 +75,23 @@ func searchBackwards(contents string, offset int, val byte) int {
 	return -1
 }
 
+// nodeModulesFilter returns the esbuild filter for the files of the module (or only the file of the
+// module when filename is set, which is a regular expression). esbuild uses the native path
+// separator so either separator matches a "/" in the module or filename.
+func nodeModulesFilter(module string, filename string) string {
+	filter := "node_modules/" + regexp.QuoteMeta(module) + "/.*"
+	if filename != "" {
+		filter = "node_modules/" + regexp.QuoteMeta(module) + "/" + filename + ".*"
+	}
+	return strings.ReplaceAll(filter, "/", `[\\/]`)
+}
+
 func createPlugin(logger logger.Logger, dir string, shimSourceMap bool) api.Plugin {
 	return api.Plugin{
 		Name: "inject-agentuity",
 		Setup: func(build api.PluginBuild) {
 			if shimSourceMap {
-				build.OnLoad(api.OnLoadOptions{Filter: path.Join(dir, "index.ts"), Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				build.OnLoad(api.OnLoadOptions{Filter: regexp.QuoteMeta(filepath.Join(dir, "index.ts")), Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
 					logger.Debug("adding source map import to %s", args.Path)
 					buf, err := os.ReadFile(args.Path)
 					if err != nil {
@@ -94,11 +106,7 @@ func createPlugin(logger logger.Logger, dir string, shimSourceMap bool) api.Plug
 				})
 			}
 			for name, mod := range patches {
-				path := "node_modules/" + mod.Module + "/.*"
-				if mod.Filename != "" {
-					path = "node_modules/" + mod.Module + "/" + mod.Filename + ".*"
-				}
-				build.OnLoad(api.OnLoadOptions{Filter: path, Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				build.OnLoad(api.OnLoadOptions{Filter: nodeModulesFilter(mod.Module, mod.Filename), Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
 					logger.Debug("re-writing %s for %s", args.Path, name)
 					buf, err := os.ReadFile(args.Path)
 					if err != nil {
@@ -111,3 +111,77 @@ Error.prepareStackTrace = function (err, stack) {
 };
 })();
 `
+
+// RuntimeStatsEnv is the environment variable with the file which the runtime writes its stats to
+// in dev mode
+const RuntimeStatsEnv = "AGENTUITY_RUNTIME_STATS_FILE"
+
+// jsRuntimeStatsShim writes the memory, CPU, event loop and GC stats of the runtime to the file in
+// AGENTUITY_RUNTIME_STATS_FILE every 2 seconds. It is only added to the bundle in dev mode and only
+// runs once per process since each agent is a separate entry point with the same banner.
+var jsRuntimeStatsShim = `(function () {
+const file = process.env.` + RuntimeStatsEnv + `;
+if (!file || globalThis.__agentuity_runtime_stats) {
+	return;
+}
+globalThis.__agentuity_runtime_stats = true;
+const { writeFileSync } = require('fs');
+let delay;
+try {
+	delay = require('perf_hooks').monitorEventLoopDelay({ resolution: 20 });
+	delay.enable();
+} catch (e) {
+	delay = undefined;
+}
+const gc = { count: 0, duration: 0 };
+let gcSupported = false;
+try {
+	const { PerformanceObserver } = require('perf_hooks');
+	const observer = new PerformanceObserver((list) => {
+		for (const entry of list.getEntries()) {
+			gc.count++;
+			gc.duration += entry.duration;
+		}
+	});
+	observer.observe({ entryTypes: ['gc'] });
+	gcSupported = true;
+} catch (e) {
+	gcSupported = false;
+}
+let lastCPU = process.cpuUsage();
+let lastTime = process.hrtime.bigint();
+const timer = setInterval(() => {
+	try {
+		const cpu = process.cpuUsage(lastCPU);
+		const now = process.hrtime.bigint();
+		const elapsed = Number(now - lastTime) / 1000;
+		lastCPU = process.cpuUsage();
+		lastTime = now;
+		const mem = process.memoryUsage();
+		const stats = {
+			pid: process.pid,
+			time: new Date().toISOString(),
+			runtime: process.versions.bun ? 'bun ' + process.versions.bun : 'node ' + process.versions.node,
+			rss: mem.rss,
+			heapUsed: mem.heapUsed,
+			heapTotal: mem.heapTotal,
+			external: mem.external,
+			cpu: elapsed > 0 ? ((cpu.user + cpu.system) / elapsed) * 100 : 0,
+		};
+		if (delay && delay.count > 0) {
+			// the samples include the resolution of the timer
+			const ms = (ns) => Math.max(0, ns / 1e6 - 20);
+			stats.eventLoopDelay = { mean: ms(delay.mean), p99: ms(delay.percentile(99)), max: ms(delay.max) };
+			delay.reset();
+		}
+		if (gcSupported) {
+			stats.gc = { count: gc.count, duration: gc.duration };
+		}
+		writeFileSync(file, JSON.stringify(stats));
+	} catch (e) {
+		// the stats are best effort and must never break the agents
+	}
+}, 2000);
+timer.unref();
+})();
+`
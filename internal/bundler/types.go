@@ -10,14 +10,25 @@ import (
 
 // BundleContext holds the context for bundling operations
 type BundleContext struct {
-	Context        context.Context
-	Logger         logger.Logger
-	Project        *project.Project
-	ProjectDir     string
-	Production     bool
-	Install        bool
-	CI             bool
-	DevMode        bool
+	Context    context.Context
+	Logger     logger.Logger
+	Project    *project.Project
+	ProjectDir string
+	Production bool
+	Install    bool
+	// NoInstall skips installing the dependencies even when they aren't installed
+	NoInstall bool
+	// FrozenLockfile fails the install instead of updating the lockfile when it is out of date
+	FrozenLockfile bool
+	// Sandbox installs the dependencies without running any of their scripts and without the
+	// secrets in the environment, for third-party code which isn't trusted
+	Sandbox bool
+	// SkipCompatCheck skips the check of the version of the SDK against the versions supported by the CLI
+	SkipCompatCheck bool
+	CI              bool
+	DevMode         bool
+	// Watch returns ErrBuildFailed when the build fails instead of exiting, so that the next change can be built
+	Watch          bool
 	Writer         io.Writer
 	PromptsEvalsFF bool
 }
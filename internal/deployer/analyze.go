@@ -0,0 +1,153 @@
+package deployer
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// BundleEntry is the size of a directory or package within the deployment zip file
+type BundleEntry struct {
+	Name             string `json:"name"`
+	Files            int    `json:"files"`
+	Size             int64  `json:"size"`
+	CompressedSize   int64  `json:"compressedSize"`
+	PercentOfArchive int    `json:"percent"`
+}
+
+// BundleAnalysis is the size breakdown of a deployment zip file
+type BundleAnalysis struct {
+	Files          int           `json:"files"`
+	Size           int64         `json:"size"`
+	CompressedSize int64         `json:"compressedSize"`
+	ArchiveSize    int64         `json:"archiveSize"`
+	Entries        []BundleEntry `json:"entries"`
+}
+
+// AnalyzeBundle breaks down the zip file by directory and package and returns the entries
+// sorted by compressed size (largest first).
+func AnalyzeBundle(zipfile string) (*BundleAnalysis, error) {
+	fi, err := os.Stat(zipfile)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.OpenReader(zipfile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening zip file: %w", err)
+	}
+	defer r.Close()
+	analysis := &BundleAnalysis{ArchiveSize: fi.Size()}
+	groups := make(map[string]*BundleEntry)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := bundleGroup(f.Name)
+		entry, ok := groups[name]
+		if !ok {
+			entry = &BundleEntry{Name: name}
+			groups[name] = entry
+		}
+		entry.Files++
+		entry.Size += int64(f.UncompressedSize64)
+		entry.CompressedSize += int64(f.CompressedSize64)
+		analysis.Files++
+		analysis.Size += int64(f.UncompressedSize64)
+		analysis.CompressedSize += int64(f.CompressedSize64)
+	}
+	for _, entry := range groups {
+		if analysis.CompressedSize > 0 {
+			entry.PercentOfArchive = int(entry.CompressedSize * 100 / analysis.CompressedSize)
+		}
+		analysis.Entries = append(analysis.Entries, *entry)
+	}
+	sort.Slice(analysis.Entries, func(i, j int) bool {
+		if analysis.Entries[i].CompressedSize != analysis.Entries[j].CompressedSize {
+			return analysis.Entries[i].CompressedSize > analysis.Entries[j].CompressedSize
+		}
+		return analysis.Entries[i].Name < analysis.Entries[j].Name
+	})
+	return analysis, nil
+}
+
+// bundleGroup returns the name to group a file under. Installed packages are grouped by package
+// (node_modules/<pkg>, node_modules/@scope/<pkg> and site-packages/<pkg>) and everything else is
+// grouped by the top level directory.
+func bundleGroup(name string) string {
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	for i, part := range parts {
+		switch part {
+		case "node_modules":
+			if i+1 < len(parts)-1 {
+				if strings.HasPrefix(parts[i+1], "@") && i+2 < len(parts)-1 {
+					return strings.Join(parts[i:i+3], "/")
+				}
+				return strings.Join(parts[i:i+2], "/")
+			}
+		case "site-packages":
+			if i+1 < len(parts)-1 {
+				return strings.Join(parts[i:i+2], "/")
+			}
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[0] + "/"
+}
+
+// SizeBudget is the deployment size budget configured in the project file. The sizes are
+// quantities such as 50Mi or 100M and are compared against the size of the deployment zip file.
+type SizeBudget struct {
+	// Warn will show a warning when the deployment exceeds the size
+	Warn string `yaml:"warn,omitempty" json:"warn,omitempty"`
+	// Limit will fail the deployment when it exceeds the size
+	Limit string `yaml:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// LoadSizeBudget reads the deployment.budget section of the project file. The budget is read
+// separately since it isn't part of the project configuration shared with the runtime.
+func LoadSizeBudget(filename string) (*SizeBudget, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Deployment struct {
+			Budget *SizeBudget `yaml:"budget"`
+		} `yaml:"deployment"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Base(filename), err)
+	}
+	return config.Deployment.Budget, nil
+}
+
+// ParseSize parses a size quantity (such as 50Mi, 100M or 1048576) and returns the number of bytes
+func ParseSize(val string) (int64, error) {
+	q, err := resource.ParseQuantity(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", val, err)
+	}
+	return q.Value(), nil
+}
+
+// FormatSize returns a human readable size
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,91 @@
+package deployer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"package.json", "package.json"},
+		{"src/agents/weather/index.ts", "src/"},
+		{".agentuity/index.js", ".agentuity/"},
+		{"node_modules/lodash/index.js", "node_modules/lodash"},
+		{"node_modules/@aws-sdk/client-s3/dist/index.js", "node_modules/@aws-sdk/client-s3"},
+		{"node_modules/.bin", "node_modules/"},
+		{".venv/lib/python3.12/site-packages/openai/__init__.py", "site-packages/openai"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, bundleGroup(tt.name))
+		})
+	}
+}
+
+func TestAnalyzeBundle(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "deploy.zip")
+	of, err := os.Create(filename)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(of)
+	files := map[string]string{
+		"package.json":               "{}",
+		"src/index.ts":               "export {}",
+		"node_modules/big/index.js":  strings.Repeat("a", 10000),
+		"node_modules/big/other.js":  strings.Repeat("b", 10000),
+		"node_modules/@s/small/x.js": "x",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, of.Close())
+
+	analysis, err := AnalyzeBundle(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, analysis.Files)
+	assert.Equal(t, int64(20012), analysis.Size)
+	assert.Len(t, analysis.Entries, 4)
+	assert.Equal(t, "node_modules/big", analysis.Entries[0].Name)
+	assert.Equal(t, 2, analysis.Entries[0].Files)
+	assert.Equal(t, int64(20000), analysis.Entries[0].Size)
+}
+
+func TestLoadSizeBudget(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "agentuity.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("deployment:\n  command: bun\n  budget:\n    warn: 50Mi\n    limit: 100Mi\n"), 0644))
+	budget, err := LoadSizeBudget(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, &SizeBudget{Warn: "50Mi", Limit: "100Mi"}, budget)
+
+	assert.NoError(t, os.WriteFile(filename, []byte("deployment:\n  command: bun\n"), 0644))
+	budget, err = LoadSizeBudget(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, budget)
+}
+
+func TestParseAndFormatSize(t *testing.T) {
+	size, err := ParseSize("50Mi")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50*1024*1024), size)
+	size, err = ParseSize("1M")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000000), size)
+	_, err = ParseSize("big")
+	assert.Error(t, err)
+
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KiB", FormatSize(1536))
+	assert.Equal(t, "50.0 MiB", FormatSize(50*1024*1024))
+}
@@ -67,6 +67,8 @@ type DeployPreflightCheckData struct {
 	PromptHelpers PromptHelpers
 	// OS Environment as a map
 	OSEnvironment map[string]string
+	// SkipCompatCheck skips the check of the version of the SDK against the versions supported by the CLI
+	SkipCompatCheck bool
 }
 
 func PreflightCheck(ctx context.Context, logger logger.Logger, data DeployPreflightCheckData, noBuild bool) (util.ZipDirCallbackMutator, error) {
@@ -78,6 +80,8 @@ func PreflightCheck(ctx context.Context, logger logger.Logger, data DeployPrefli
 		Production: true,
 		Project:    data.Project,
 		Writer:     os.Stderr,
+
+		SkipCompatCheck: data.SkipCompatCheck,
 	}
 	if !noBuild {
 		if err := bundler.Bundle(bundleCtx); err != nil {
@@ -0,0 +1,282 @@
+package deployer
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// ExternalsManifestFilename is the file in the .agentuity directory of the deployment which
+// references the externalized files
+const ExternalsManifestFilename = "externals.json"
+
+// ExternalizeConfig is the deployment.externalize section of the project file
+type ExternalizeConfig struct {
+	// Files are the files, directories or glob patterns to upload separately from the deployment
+	Files []string `yaml:"files,omitempty" json:"files,omitempty"`
+	// Threshold externalizes any file larger than the size such as 50Mi
+	Threshold string `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+}
+
+// ExternalFile is a file uploaded to object storage once and referenced by the deployment
+type ExternalFile struct {
+	// Path is the path of the file relative to the project, with forward slashes
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Digest is the content hash of the file such as sha256:<hex hash>
+	Digest string `json:"digest"`
+	// URL is where the runtime downloads the file from
+	URL string `json:"url,omitempty"`
+}
+
+// ExternalsManifest lists the externalized files of the deployment
+type ExternalsManifest struct {
+	Files []ExternalFile `json:"files"`
+}
+
+// LoadExternalizeConfig reads the deployment.externalize section of the project file
+func LoadExternalizeConfig(filename string) (*ExternalizeConfig, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Deployment struct {
+			Externalize *ExternalizeConfig `yaml:"externalize"`
+		} `yaml:"deployment"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Base(filename), err)
+	}
+	return config.Deployment.Externalize, nil
+}
+
+// FindExternalFiles returns the files of the project in dir which should be externalized, either
+// because they match one of the patterns or because they are larger than the threshold. Only the
+// files for which include returns true (the files which would be in the deployment) are considered.
+func FindExternalFiles(dir string, config *ExternalizeConfig, include func(fn string, fi os.FileInfo) bool) ([]ExternalFile, error) {
+	if config == nil || (len(config.Files) == 0 && config.Threshold == "") {
+		return nil, nil
+	}
+	var threshold int64
+	if config.Threshold != "" {
+		val, err := ParseSize(config.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		threshold = val
+	}
+	for _, pattern := range config.Files {
+		if !doublestar.ValidatePattern(strings.TrimPrefix(filepath.ToSlash(pattern), "./")) {
+			return nil, fmt.Errorf("invalid externalize pattern %q", pattern)
+		}
+	}
+	var files []ExternalFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() || (include != nil && !include(rel, fi)) {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if (threshold > 0 && fi.Size() > threshold) || matchesExternalPattern(config.Files, rel) {
+			files = append(files, ExternalFile{Path: rel, Size: fi.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// matchesExternalPattern returns true if the file matches a pattern or is inside a directory
+// matching a pattern
+func matchesExternalPattern(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimRight(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/")
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := doublestar.Match(pattern+"/**", rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HashFile returns the digest of the file such as sha256:<hex hash>
+func HashFile(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type externalsRequest struct {
+	Files []externalBlob `json:"files"`
+}
+
+type externalBlob struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+type externalsResponse struct {
+	Success bool    `json:"success"`
+	Message *string `json:"message,omitempty"`
+	Data    struct {
+		Files []struct {
+			Digest string `json:"digest"`
+			// URL is where the blob is downloaded from
+			URL string `json:"url"`
+			// UploadURL is set when the blob isn't stored yet and must be uploaded
+			UploadURL *string `json:"uploadUrl,omitempty"`
+		} `json:"files"`
+	} `json:"data"`
+}
+
+// UploadExternalFiles hashes the files and uploads the ones which aren't already in object storage.
+// Files are stored by their digest so unchanged files are only uploaded once. The returned
+// manifest references every file by its digest and URL.
+func UploadExternalFiles(ctx context.Context, logger logger.Logger, client *util.APIClient, projectId string, dir string, files []ExternalFile, progress func(util.Progress)) (*ExternalsManifest, error) {
+	manifest := &ExternalsManifest{Files: make([]ExternalFile, 0, len(files))}
+	var request externalsRequest
+	seen := make(map[string]bool)
+	for _, file := range files {
+		digest, err := HashFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", file.Path, err)
+		}
+		file.Digest = digest
+		manifest.Files = append(manifest.Files, file)
+		if !seen[digest] {
+			seen[digest] = true
+			request.Files = append(request.Files, externalBlob{Digest: digest, Size: file.Size})
+		}
+	}
+	var response externalsResponse
+	if err := client.Do("POST", fmt.Sprintf("/cli/deploy/externals/%s", projectId), request, &response); err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		if response.Message != nil {
+			return nil, fmt.Errorf("%s", *response.Message)
+		}
+		return nil, fmt.Errorf("unknown error")
+	}
+	var total int64
+	for _, blob := range response.Data.Files {
+		if blob.UploadURL != nil {
+			for _, b := range request.Files {
+				if b.Digest == blob.Digest {
+					total += b.Size
+				}
+			}
+		}
+	}
+	tracker := util.NewProgressTracker(total, progress)
+	urls := make(map[string]string)
+	for _, blob := range response.Data.Files {
+		urls[blob.Digest] = blob.URL
+		if blob.UploadURL == nil {
+			logger.Debug("external file %s already uploaded", blob.Digest)
+			continue
+		}
+		for _, file := range manifest.Files {
+			if file.Digest == blob.Digest {
+				if err := uploadExternalFile(ctx, logger, filepath.Join(dir, filepath.FromSlash(file.Path)), file, *blob.UploadURL, tracker); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	tracker.Finish()
+	for i, file := range manifest.Files {
+		url, ok := urls[file.Digest]
+		if !ok {
+			return nil, fmt.Errorf("no storage location returned for %s", file.Path)
+		}
+		manifest.Files[i].URL = url
+	}
+	return manifest, nil
+}
+
+func uploadExternalFile(ctx context.Context, logger logger.Logger, filename string, file ExternalFile, uploadURL string, tracker *util.ProgressTracker) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// NOTE: this is a one-time signed url so we don't need to add authorization header
+	req, err := http.NewRequestWithContext(ctx, "PUT", util.TransformUrl(uploadURL), tracker.Reader(f))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = file.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", file.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		buf, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upload %s (status %d): %s", file.Path, resp.StatusCode, string(buf))
+	}
+	logger.Debug("uploaded external file %s (%d bytes)", file.Path, file.Size)
+	return nil
+}
+
+// ExternalsMutator returns a mutator which adds the manifest of the externalized files to the
+// deployment zip after running next
+func ExternalsMutator(manifest *ExternalsManifest, next util.ZipDirCallbackMutator) util.ZipDirCallbackMutator {
+	return func(writer *zip.Writer) error {
+		if next != nil {
+			if err := next(writer); err != nil {
+				return err
+			}
+		}
+		w, err := writer.Create(".agentuity/" + ExternalsManifestFilename)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(manifest)
+	}
+}
@@ -0,0 +1,127 @@
+package deployer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExternalsProject(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/index.ts":              "export {}",
+		"models/small.onnx":         "model",
+		"data/large.csv":            strings.Repeat("a", 2048),
+		"data/copy.csv":             strings.Repeat("a", 2048),
+		"node_modules/big/index.js": strings.Repeat("b", 4096),
+	}
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+		require.NoError(t, os.WriteFile(fn, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestFindExternalFiles(t *testing.T) {
+	dir := writeExternalsProject(t)
+	include := func(fn string, fi os.FileInfo) bool {
+		return !strings.HasPrefix(filepath.ToSlash(fn), "node_modules/")
+	}
+
+	files, err := FindExternalFiles(dir, nil, include)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	files, err = FindExternalFiles(dir, &ExternalizeConfig{Files: []string{"./models"}, Threshold: "1Ki"}, include)
+	assert.NoError(t, err)
+	assert.Equal(t, []ExternalFile{
+		{Path: "data/copy.csv", Size: 2048},
+		{Path: "data/large.csv", Size: 2048},
+		{Path: "models/small.onnx", Size: 5},
+	}, files)
+
+	files, err = FindExternalFiles(dir, &ExternalizeConfig{Files: []string{"**/*.onnx"}}, include)
+	assert.NoError(t, err)
+	assert.Equal(t, []ExternalFile{{Path: "models/small.onnx", Size: 5}}, files)
+
+	_, err = FindExternalFiles(dir, &ExternalizeConfig{Threshold: "big"}, include)
+	assert.Error(t, err)
+}
+
+func TestUploadExternalFiles(t *testing.T) {
+	dir := writeExternalsProject(t)
+	files, err := FindExternalFiles(dir, &ExternalizeConfig{Files: []string{"data", "models"}}, nil)
+	require.NoError(t, err)
+	csvDigest, err := HashFile(filepath.Join(dir, "data/large.csv"))
+	require.NoError(t, err)
+	modelDigest, err := HashFile(filepath.Join(dir, "models/small.onnx"))
+	require.NoError(t, err)
+
+	var uploads []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/cli/deploy/externals/proj_1":
+			var req externalsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			// the copies share a digest so they are only sent once
+			assert.Equal(t, []externalBlob{{Digest: csvDigest, Size: 2048}, {Digest: modelDigest, Size: 5}}, req.Files)
+			// an IPv4-mapped address so the url isn't rewritten when the tests run in a container
+			uploadURL := strings.Replace(server.URL, "127.0.0.1", "[::ffff:127.0.0.1]", 1) + "/upload/model"
+			json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data": map[string]any{"files": []map[string]any{
+					{"digest": csvDigest, "url": "https://blobs/csv"},
+					{"digest": modelDigest, "url": "https://blobs/model", "uploadUrl": uploadURL},
+				}},
+			})
+		case r.Method == "PUT" && r.URL.Path == "/upload/model":
+			buf, _ := io.ReadAll(r.Body)
+			uploads = append(uploads, string(buf))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := util.NewAPIClient(context.Background(), logger.NewTestLogger(), server.URL, "token")
+	var progress util.Progress
+	manifest, err := UploadExternalFiles(context.Background(), logger.NewTestLogger(), client, "proj_1", dir, files, func(p util.Progress) {
+		progress = p
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"model"}, uploads)
+	// only the file which wasn't already uploaded is counted
+	assert.Equal(t, util.Progress{Done: 5, Total: 5, Elapsed: progress.Elapsed, Finished: true}, progress)
+	assert.Equal(t, []ExternalFile{
+		{Path: "data/copy.csv", Size: 2048, Digest: csvDigest, URL: "https://blobs/csv"},
+		{Path: "data/large.csv", Size: 2048, Digest: csvDigest, URL: "https://blobs/csv"},
+		{Path: "models/small.onnx", Size: 5, Digest: modelDigest, URL: "https://blobs/model"},
+	}, manifest.Files)
+
+	filename := filepath.Join(t.TempDir(), "deploy.zip")
+	of, err := os.Create(filename)
+	require.NoError(t, err)
+	zw := zip.NewWriter(of)
+	require.NoError(t, ExternalsMutator(manifest, nil)(zw))
+	require.NoError(t, zw.Close())
+	require.NoError(t, of.Close())
+	zr, err := zip.OpenReader(filename)
+	require.NoError(t, err)
+	defer zr.Close()
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, ".agentuity/externals.json", zr.File[0].Name)
+}
@@ -1,12 +1,20 @@
 package deployer
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/go-common/logger"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
 type CIInfo struct {
@@ -19,8 +27,16 @@ type GitInfo struct {
 	Branch        *string `json:"branch"`
 	Commit        *string `json:"commit"`
 	CommitMessage *string `json:"commitMessage"`
+	CommitAuthor  *string `json:"commitAuthor,omitempty"`
 	IsRepo        bool    `json:"isRepo"`
 	GitProvider   *string `json:"gitProvider"`
+	// Detached is true when HEAD isn't a branch, as in most CI checkouts, in which case the branch
+	// is resolved from the CI environment or from the branch pointing to the commit
+	Detached bool `json:"detached,omitempty"`
+	// Worktree is true when the directory is a linked worktree created with git worktree add
+	Worktree bool `json:"worktree,omitempty"`
+	// Shallow is true when the history of the repository is truncated, as in a shallow clone
+	Shallow bool `json:"shallow,omitempty"`
 }
 
 type MetadataOrigin struct {
@@ -29,7 +45,10 @@ type MetadataOrigin struct {
 }
 
 type Metadata struct {
-	Origin MetadataOrigin `json:"origin,omitempty"`
+	Origin MetadataOrigin    `json:"origin,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Scale is the autoscaling of the deployment from the project file
+	Scale *project.ScaleConfig `json:"scale,omitempty"`
 }
 
 type MachineInfo struct {
@@ -64,10 +83,12 @@ func GetMachineInfo() *MachineInfo {
 func GetGitInfo(logger logger.Logger, dir string) (*GitInfo, error) {
 	info := &GitInfo{}
 
-	repo, err := git.PlainOpen(dir)
+	// the common dir holds the refs and the config of a linked worktree
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
 	if err != nil {
 		return info, nil
 	}
+	info.Worktree = isLinkedWorktree(dir)
 
 	// Get remote URL
 	remote, err := repo.Remote("origin")
@@ -85,25 +106,143 @@ func GetGitInfo(logger logger.Logger, dir string) (*GitInfo, error) {
 
 	// Get current branch and commit
 	head, err := repo.Head()
-	if err == nil {
+	if err != nil {
+		logger.Trace(err.Error())
+		return info, nil
+	}
+	commitHash := head.Hash().String()
+	info.Commit = &commitHash
+	info.IsRepo = true
+	if head.Name().IsBranch() {
 		branch := head.Name().Short()
-		commitHash := head.Hash().String()
 		info.Branch = &branch
-		info.Commit = &commitHash
-		info.IsRepo = true
-		commit, err := repo.CommitObject(head.Hash())
-		if err == nil {
-			msg := strings.TrimSpace(commit.Message)
-			info.CommitMessage = &msg
+	} else {
+		info.Detached = true
+		branch := ciBranch(os.Getenv)
+		if branch == "" {
+			branch = branchAt(repo, head.Hash())
 		}
+		if branch != "" {
+			info.Branch = &branch
+		}
+		logger.Debug("HEAD is detached at %s, resolved the branch to %q", commitHash, branch)
 	}
-	if err != nil {
+	if shallow, err := repo.Storer.Shallow(); err == nil && len(shallow) > 0 {
+		info.Shallow = true
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err == nil {
+		msg := strings.TrimSpace(commit.Message)
+		info.CommitMessage = &msg
+		author := commit.Author.Name
+		info.CommitAuthor = &author
+	} else {
 		logger.Trace(err.Error())
 	}
 
 	return info, nil
 }
 
+// isLinkedWorktree returns true if dir is the root of a linked worktree, whose .git is a file pointing
+// to a git dir with a commondir file. Submodules also have a .git file but no commondir.
+func isLinkedWorktree(dir string) bool {
+	buf, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(buf)), "gitdir: ")
+	if !ok {
+		return false
+	}
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(dir, gitdir)
+	}
+	_, err = os.Stat(filepath.Join(gitdir, "commondir"))
+	return err == nil
+}
+
+// ciBranchEnvs are the environment variables in which CI providers other than GitHub Actions set the
+// branch being built, in order of precedence
+var ciBranchEnvs = []string{
+	"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", // gitlab merge requests
+	"CI_COMMIT_BRANCH",                    // gitlab
+	"BITBUCKET_BRANCH",
+	"CIRCLE_BRANCH",
+	"BUILDKITE_BRANCH",
+	"VERCEL_GIT_COMMIT_REF",
+	"CF_PAGES_BRANCH",
+	"BUILD_SOURCEBRANCH", // azure pipelines, as refs/heads/<branch>
+	"BRANCH_NAME",        // jenkins multibranch pipelines
+	"GIT_BRANCH",         // jenkins, as origin/<branch>
+}
+
+// ciBranch returns the branch being built from the environment variables of the CI providers, since
+// CI checkouts are usually a detached HEAD. It's empty when not building a branch, such as for a tag.
+func ciBranch(getenv func(string) string) string {
+	if getenv("GITHUB_ACTIONS") != "" {
+		// GITHUB_HEAD_REF is the source branch of a pull request and GITHUB_REF_NAME is the tag of a tag push
+		if branch := getenv("GITHUB_HEAD_REF"); branch != "" {
+			return branch
+		}
+		if getenv("GITHUB_REF_TYPE") == "branch" {
+			return getenv("GITHUB_REF_NAME")
+		}
+		return ""
+	}
+	for _, name := range ciBranchEnvs {
+		branch := getenv(name)
+		if branch == "" {
+			continue
+		}
+		if b, ok := strings.CutPrefix(branch, "refs/heads/"); ok {
+			return b
+		}
+		if strings.HasPrefix(branch, "refs/") {
+			return ""
+		}
+		return strings.TrimPrefix(branch, "origin/")
+	}
+	return ""
+}
+
+// branchAt returns the branch pointing to the commit, preferring the local branches over the remote
+// ones. It's empty when no branch or more than one branch points to the commit.
+func branchAt(repo *git.Repository, hash plumbing.Hash) string {
+	refs, err := repo.References()
+	if err != nil {
+		return ""
+	}
+	local := map[string]bool{}
+	remote := map[string]bool{}
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || ref.Hash() != hash {
+			return nil
+		}
+		switch {
+		case ref.Name().IsBranch():
+			local[ref.Name().Short()] = true
+		case ref.Name().IsRemote():
+			// refs/remotes/<remote>/<branch>
+			parts := strings.SplitN(ref.Name().String(), "/", 4)
+			if len(parts) == 4 && parts[3] != "HEAD" {
+				remote[parts[3]] = true
+			}
+		}
+		return nil
+	})
+	for _, branches := range []map[string]bool{local, remote} {
+		if len(branches) == 1 {
+			for name := range branches {
+				return name
+			}
+		}
+		if len(branches) > 1 {
+			return ""
+		}
+	}
+	return ""
+}
+
 // GetGitInfoRecursive walks up directories until it finds a git repo and returns its info
 func GetGitInfoRecursive(logger logger.Logger, startDir string) (*GitInfo, error) {
 	depth := 0
@@ -147,3 +286,109 @@ func parentDir(path string) string {
 	}
 	return cleaned[:idx]
 }
+
+// GitCommit is a commit of the git repository
+type GitCommit struct {
+	Hash    string    `json:"hash"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body,omitempty"`
+	Author  string    `json:"author,omitempty"`
+	Email   string    `json:"email,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// ShortHash returns the abbreviated hash of the commit
+func (c GitCommit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// GitLog returns the commits after from up to and including to, newest first, from the git repository
+// containing dir. At most limit commits are returned.
+func GitLog(logger logger.Logger, dir string, from string, to string, limit int) ([]GitCommit, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	toHash, err := repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found: %w", to, err)
+	}
+	fromHash, err := repo.ResolveRevision(plumbing.Revision(from))
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found: %w", from, err)
+	}
+	iter, err := repo.Log(&git.LogOptions{From: *toHash})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var commits []GitCommit
+	found := false
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.Hash == *fromHash {
+			found = true
+			return storer.ErrStop
+		}
+		if len(commits) < limit {
+			subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+			commits = append(commits, GitCommit{
+				Hash:    c.Hash.String(),
+				Subject: strings.TrimSpace(subject),
+				Body:    strings.TrimSpace(body),
+				Author:  c.Author.Name,
+				Email:   c.Author.Email,
+				Date:    c.Author.When,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		logger.Debug("commit %s is not an ancestor of %s", from, to)
+		return nil, fmt.Errorf("commit %s is not an ancestor of %s", from, to)
+	}
+	return commits, nil
+}
+
+// GitShortlog returns the short hash and subject of the commits after from up to and including to,
+// newest first, from the git repository containing dir. At most limit commits are returned.
+func GitShortlog(logger logger.Logger, dir string, from string, to string, limit int) ([]string, error) {
+	commits, err := GitLog(logger, dir, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(commits))
+	for _, c := range commits {
+		lines = append(lines, c.ShortHash()+" "+c.Subject)
+	}
+	return lines, nil
+}
+
+// GitStatus returns whether dir is in a git repository and if the working tree of the repository
+// has no uncommitted changes
+func GitStatus(logger logger.Logger, dir string) (isRepo bool, clean bool, err error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return true, false, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return true, false, err
+	}
+	if !status.IsClean() {
+		logger.Debug("git status: %s", status.String())
+	}
+	return true, status.IsClean(), nil
+}
@@ -0,0 +1,124 @@
+package deployer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitShortlog(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	wt, err := repo.Worktree()
+	assert.NoError(t, err)
+	var commits []string
+	for _, msg := range []string{"initial commit", "add the billing agent\n\nlonger description", "fix the order lookup"} {
+		hash, err := wt.Commit(msg, &git.CommitOptions{
+			AllowEmptyCommits: true,
+			Author:            &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		commits = append(commits, hash.String())
+	}
+
+	lines, err := GitShortlog(logger.NewTestLogger(), dir, commits[0], commits[2], 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{commits[2][:7] + " fix the order lookup", commits[1][:7] + " add the billing agent"}, lines)
+
+	lines, err = GitShortlog(logger.NewTestLogger(), dir, commits[0], commits[2], 1)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+
+	_, err = GitShortlog(logger.NewTestLogger(), dir, commits[2], commits[0], 10)
+	assert.Error(t, err)
+}
+
+func TestGitStatus(t *testing.T) {
+	isRepo, _, err := GitStatus(logger.NewTestLogger(), t.TempDir())
+	assert.NoError(t, err)
+	assert.False(t, isRepo)
+
+	dir := t.TempDir()
+	_, err = git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	isRepo, clean, err := GitStatus(logger.NewTestLogger(), dir)
+	assert.NoError(t, err)
+	assert.True(t, isRepo)
+	assert.True(t, clean)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "index.ts"), []byte("export {}"), 0644))
+	_, clean, err = GitStatus(logger.NewTestLogger(), dir)
+	assert.NoError(t, err)
+	assert.False(t, clean)
+}
+
+func TestGetGitInfoDetachedWorktree(t *testing.T) {
+	// the branch of a detached HEAD is resolved from the CI environment first
+	for _, name := range append(ciBranchEnvs, "GITHUB_ACTIONS") {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	wt, err := repo.Worktree()
+	assert.NoError(t, err)
+	hash, err := wt.Commit("initial commit", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	info, err := GetGitInfo(logger.NewTestLogger(), dir)
+	assert.NoError(t, err)
+	assert.True(t, info.IsRepo)
+	assert.Equal(t, "master", *info.Branch)
+	assert.Equal(t, "test", *info.CommitAuthor)
+	assert.False(t, info.Detached)
+	assert.False(t, info.Worktree)
+	assert.False(t, info.Shallow)
+
+	// a linked worktree with a detached HEAD, laid out like git worktree add --detach
+	gitdir := filepath.Join(dir, ".git", "worktrees", "ci")
+	assert.NoError(t, os.MkdirAll(gitdir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(gitdir, "HEAD"), []byte(hash.String()+"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(gitdir, "commondir"), []byte("../..\n"), 0644))
+	wtdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(gitdir, "gitdir"), []byte(filepath.Join(wtdir, ".git")+"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(wtdir, ".git"), []byte("gitdir: "+gitdir+"\n"), 0644))
+
+	info, err = GetGitInfo(logger.NewTestLogger(), wtdir)
+	assert.NoError(t, err)
+	assert.True(t, info.IsRepo)
+	assert.True(t, info.Worktree)
+	assert.True(t, info.Detached)
+	assert.Equal(t, hash.String(), *info.Commit)
+	assert.Equal(t, "initial commit", *info.CommitMessage)
+	// master is the only branch pointing to the commit
+	assert.Equal(t, "master", *info.Branch)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "shallow"), []byte(hash.String()+"\n"), 0644))
+	info, err = GetGitInfo(logger.NewTestLogger(), dir)
+	assert.NoError(t, err)
+	assert.True(t, info.Shallow)
+}
+
+func TestCIBranch(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	assert.Equal(t, "feature/login", ciBranch(env(map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_HEAD_REF": "feature/login", "GITHUB_REF_NAME": "42/merge", "GITHUB_REF_TYPE": "branch"})))
+	assert.Equal(t, "main", ciBranch(env(map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_NAME": "main", "GITHUB_REF_TYPE": "branch"})))
+	assert.Equal(t, "", ciBranch(env(map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_NAME": "v1.0.0", "GITHUB_REF_TYPE": "tag"})))
+	assert.Equal(t, "main", ciBranch(env(map[string]string{"CI_COMMIT_BRANCH": "main"})))
+	assert.Equal(t, "release", ciBranch(env(map[string]string{"BUILD_SOURCEBRANCH": "refs/heads/release"})))
+	assert.Equal(t, "", ciBranch(env(map[string]string{"BUILD_SOURCEBRANCH": "refs/tags/v1.0.0"})))
+	assert.Equal(t, "main", ciBranch(env(map[string]string{"GIT_BRANCH": "origin/main"})))
+	assert.Equal(t, "", ciBranch(env(nil)))
+}
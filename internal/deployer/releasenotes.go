@@ -0,0 +1,180 @@
+package deployer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+)
+
+// The sources of the commits of release notes
+const (
+	// ReleaseSourceGit is when the commits were read from the local git repository
+	ReleaseSourceGit = "git"
+	// ReleaseSourceDeployments is when the commits were read from the git metadata stored with the deployments
+	ReleaseSourceDeployments = "deployments"
+)
+
+// ReleaseDeployment is the deployment at one end of release notes
+type ReleaseDeployment struct {
+	ID        string `json:"id"`
+	Tag       string `json:"tag,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// Name returns the tag of the deployment or its id when it wasn't referenced by a tag
+func (d ReleaseDeployment) Name() string {
+	if d.Tag != "" {
+		return d.Tag
+	}
+	return d.ID
+}
+
+// NewReleaseDeployment returns the end of release notes for the deployment referenced by tag, which
+// is empty when the deployment was referenced by its id
+func NewReleaseDeployment(d *project.DeploymentDetail, tag string) ReleaseDeployment {
+	rd := ReleaseDeployment{ID: d.ID, Tag: tag, CreatedAt: d.CreatedAt}
+	if d.Git != nil {
+		rd.Commit = d.Git.Commit
+		rd.Branch = d.Git.Branch
+	}
+	return rd
+}
+
+// ReleaseNotes are the changes between two deployments
+type ReleaseNotes struct {
+	From      ReleaseDeployment `json:"from"`
+	To        ReleaseDeployment `json:"to"`
+	Source    string            `json:"source"`
+	RemoteURL string            `json:"remoteUrl,omitempty"`
+	Commits   []GitCommit       `json:"commits"`
+	Authors   []string          `json:"authors"`
+}
+
+// NewReleaseNotes returns the release notes of the commits, newest first, with their authors
+func NewReleaseNotes(from, to ReleaseDeployment, source string, remoteURL string, commits []GitCommit) *ReleaseNotes {
+	authors := []string{}
+	seen := map[string]bool{}
+	for _, c := range commits {
+		if c.Author != "" && !seen[c.Author] {
+			seen[c.Author] = true
+			authors = append(authors, c.Author)
+		}
+	}
+	sort.Strings(authors)
+	if commits == nil {
+		commits = []GitCommit{}
+	}
+	return &ReleaseNotes{
+		From:      from,
+		To:        to,
+		Source:    source,
+		RemoteURL: remoteURL,
+		Commits:   commits,
+		Authors:   authors,
+	}
+}
+
+// DeploymentsBetween returns the deployments created after the deployment from up to and including
+// the deployment to, newest first. It returns an error if from wasn't created before to.
+func DeploymentsBetween(deployments []project.DeploymentListData, from string, to string) ([]project.DeploymentListData, error) {
+	created := make(map[string]time.Time, len(deployments))
+	for _, d := range deployments {
+		t, err := time.Parse(time.RFC3339, d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid creation date of deployment %s: %w", d.ID, err)
+		}
+		created[d.ID] = t
+	}
+	fromTime, ok := created[from]
+	if !ok {
+		return nil, fmt.Errorf("deployment %s not found", from)
+	}
+	toTime, ok := created[to]
+	if !ok {
+		return nil, fmt.Errorf("deployment %s not found", to)
+	}
+	if !fromTime.Before(toTime) {
+		return nil, fmt.Errorf("deployment %s was not created before deployment %s", from, to)
+	}
+	var between []project.DeploymentListData
+	for _, d := range deployments {
+		if t := created[d.ID]; t.After(fromTime) && !t.After(toTime) {
+			between = append(between, d)
+		}
+	}
+	sort.SliceStable(between, func(i, j int) bool {
+		return created[between[i].ID].After(created[between[j].ID])
+	})
+	return between, nil
+}
+
+// DeploymentCommits returns the commits recorded in the git metadata of the deployments, in the order
+// of the deployments. A commit deployed more than once is only returned once and deployments without
+// git metadata or with the commit of the deployment excluded are skipped.
+func DeploymentCommits(deployments []*project.DeploymentDetail, exclude string) []GitCommit {
+	var commits []GitCommit
+	seen := map[string]bool{exclude: true}
+	for _, d := range deployments {
+		if d.Git == nil || d.Git.Commit == "" || seen[d.Git.Commit] {
+			continue
+		}
+		seen[d.Git.Commit] = true
+		subject, body, _ := strings.Cut(strings.TrimSpace(d.Git.CommitMessage), "\n")
+		commit := GitCommit{
+			Hash:    d.Git.Commit,
+			Subject: strings.TrimSpace(subject),
+			Body:    strings.TrimSpace(body),
+			Author:  d.Git.CommitAuthor,
+		}
+		if t, err := time.Parse(time.RFC3339, d.CreatedAt); err == nil {
+			commit.Date = t
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// commitLink returns the short hash of the commit as a markdown link to the commit when the remote
+// is hosted on github
+func (n *ReleaseNotes) commitLink(c GitCommit) string {
+	if strings.HasPrefix(n.RemoteURL, "https://github.com/") {
+		return fmt.Sprintf("[`%s`](%s/commit/%s)", c.ShortHash(), strings.TrimSuffix(n.RemoteURL, ".git"), c.Hash)
+	}
+	return "`" + c.ShortHash() + "`"
+}
+
+// Markdown returns the release notes formatted as markdown
+func (n *ReleaseNotes) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Release notes: %s → %s\n\n", n.From.Name(), n.To.Name())
+	fmt.Fprintf(&sb, "Deployment `%s` → `%s`", n.From.ID, n.To.ID)
+	if n.To.CreatedAt != "" {
+		fmt.Fprintf(&sb, ", deployed %s", n.To.CreatedAt)
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString("### Changes\n\n")
+	if len(n.Commits) == 0 {
+		sb.WriteString("No changes.\n")
+	}
+	for _, c := range n.Commits {
+		fmt.Fprintf(&sb, "- %s (%s", c.Subject, n.commitLink(c))
+		if c.Author != "" {
+			fmt.Fprintf(&sb, " by %s", c.Author)
+		}
+		sb.WriteString(")\n")
+	}
+
+	if len(n.Authors) > 0 {
+		sb.WriteString("\n### Contributors\n\n")
+		for _, a := range n.Authors {
+			fmt.Fprintf(&sb, "- %s\n", a)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentsBetween(t *testing.T) {
+	deployments := []project.DeploymentListData{
+		{ID: "dep_3", CreatedAt: "2025-03-03T10:00:00Z"},
+		{ID: "dep_1", CreatedAt: "2025-03-01T10:00:00Z"},
+		{ID: "dep_4", CreatedAt: "2025-03-04T10:00:00Z"},
+		{ID: "dep_2", CreatedAt: "2025-03-02T10:00:00Z"},
+	}
+	between, err := DeploymentsBetween(deployments, "dep_1", "dep_3")
+	require.NoError(t, err)
+	var ids []string
+	for _, d := range between {
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []string{"dep_3", "dep_2"}, ids)
+
+	_, err = DeploymentsBetween(deployments, "dep_3", "dep_1")
+	assert.Error(t, err)
+	_, err = DeploymentsBetween(deployments, "dep_1", "dep_9")
+	assert.Error(t, err)
+}
+
+func TestDeploymentCommits(t *testing.T) {
+	deployments := []*project.DeploymentDetail{
+		{DeploymentListData: project.DeploymentListData{ID: "dep_3"}, Git: &project.DeploymentGit{Commit: "ccccccc123", CommitMessage: "fix the order lookup\n\ndetails", CommitAuthor: "Sam"}},
+		{DeploymentListData: project.DeploymentListData{ID: "dep_2"}, Git: &project.DeploymentGit{Commit: "ccccccc123", CommitMessage: "fix the order lookup"}},
+		{DeploymentListData: project.DeploymentListData{ID: "dep_x"}},
+		{DeploymentListData: project.DeploymentListData{ID: "dep_1"}, Git: &project.DeploymentGit{Commit: "aaaaaaa123", CommitMessage: "initial"}},
+	}
+	commits := DeploymentCommits(deployments, "aaaaaaa123")
+	require.Len(t, commits, 1)
+	assert.Equal(t, "fix the order lookup", commits[0].Subject)
+	assert.Equal(t, "details", commits[0].Body)
+	assert.Equal(t, "Sam", commits[0].Author)
+}
+
+func TestReleaseNotesMarkdown(t *testing.T) {
+	from := ReleaseDeployment{ID: "dep_1", Tag: "staging"}
+	to := ReleaseDeployment{ID: "dep_2", CreatedAt: "2025-03-02T10:00:00Z"}
+	commits := []GitCommit{
+		{Hash: "bbbbbbbbbb", Subject: "add the billing agent", Author: "Sam"},
+		{Hash: "cccccccccc", Subject: "fix the order lookup", Author: "Alex"},
+		{Hash: "dddddddddd", Subject: "update the prompts", Author: "Sam"},
+	}
+	notes := NewReleaseNotes(from, to, ReleaseSourceGit, "https://github.com/acme/agents", commits)
+	assert.Equal(t, []string{"Alex", "Sam"}, notes.Authors)
+	assert.Equal(t, "## Release notes: staging → dep_2\n\n"+
+		"Deployment `dep_1` → `dep_2`, deployed 2025-03-02T10:00:00Z\n\n"+
+		"### Changes\n\n"+
+		"- add the billing agent ([`bbbbbbb`](https://github.com/acme/agents/commit/bbbbbbbbbb) by Sam)\n"+
+		"- fix the order lookup ([`ccccccc`](https://github.com/acme/agents/commit/cccccccccc) by Alex)\n"+
+		"- update the prompts ([`ddddddd`](https://github.com/acme/agents/commit/dddddddddd) by Sam)\n"+
+		"\n### Contributors\n\n- Alex\n- Sam\n", notes.Markdown())
+
+	empty := NewReleaseNotes(from, to, ReleaseSourceDeployments, "", nil)
+	assert.NotNil(t, empty.Commits)
+	assert.Contains(t, empty.Markdown(), "No changes.\n")
+	assert.NotContains(t, empty.Markdown(), "Contributors")
+}
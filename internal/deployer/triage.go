@@ -0,0 +1,150 @@
+package deployer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	iproject "github.com/agentuity/cli/internal/project"
+)
+
+// TriageReport is the explanation of a failed deployment with the suggested fixes
+type TriageReport struct {
+	Phase       string   `json:"phase,omitempty"`
+	Reason      string   `json:"reason"`
+	Excerpt     []string `json:"excerpt,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+type triageRule struct {
+	pattern *regexp.Regexp
+	suggest func(match []string, language string, command []string) string
+}
+
+func dependencyFile(language string) string {
+	if language == "python" {
+		return "pyproject.toml"
+	}
+	return "package.json"
+}
+
+// packageName returns the package of an import path such as lodash for lodash/fp and @scope/pkg
+// for @scope/pkg/sub
+func packageName(path string) string {
+	parts := strings.Split(path, "/")
+	if strings.HasPrefix(path, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+var triageRules = []triageRule{
+	{
+		pattern: regexp.MustCompile(`Cannot find (?:module|package) '([^'./][^']*)'`),
+		suggest: func(m []string, language string, command []string) string {
+			return fmt.Sprintf("The package %s is missing. Add it to the dependencies (not the devDependencies) in package.json and deploy again.", packageName(m[1]))
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`No module named '([^']+)'`),
+		suggest: func(m []string, language string, command []string) string {
+			return fmt.Sprintf("The module %s is missing. Add its package to the dependencies in pyproject.toml and deploy again.", strings.Split(m[1], ".")[0])
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(?:command not found|executable file not found|exec: "[^"]+": not found|spawn \S+ ENOENT)`),
+		suggest: func(m []string, language string, command []string) string {
+			return fmt.Sprintf("The start command %q couldn't be run. Check deployment.command and deployment.args in agentuity.yaml.", strings.Join(command, " "))
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(?:no such file or directory|cannot find module '[./][^']*')`),
+		suggest: func(m []string, language string, command []string) string {
+			return fmt.Sprintf("A file used by the start command %q is missing from the deployment. Check deployment.command and deployment.args in agentuity.yaml and that the file isn't excluded by bundler.ignore.", strings.Join(command, " "))
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(?:heap out of memory|OOMKilled|out of memory|MemoryError)`),
+		suggest: func(m []string, language string, command []string) string {
+			return "The deployment ran out of memory. Increase deployment.resources.memory in agentuity.yaml or use agentuity dev stats to find what uses the memory."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`EADDRINUSE|address already in use`),
+		suggest: func(m []string, language string, command []string) string {
+			return "The port is already in use. The server must listen on the port in the PORT environment variable."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?:[Mm]issing|[Rr]equired) environment variable:? '?([A-Z][A-Z0-9_]+)'?|\b([A-Z][A-Z0-9_]{2,}) (?:is not set|is required)`),
+		suggest: func(m []string, language string, command []string) string {
+			name := m[1]
+			if name == "" {
+				name = m[2]
+			}
+			return fmt.Sprintf("The environment variable %s is missing. Set it with agentuity env set %s and deploy again.", name, name)
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`SyntaxError|IndentationError`),
+		suggest: func(m []string, language string, command []string) string {
+			if language == "python" {
+				return "The code has a syntax error. Run agentuity dev to find it locally."
+			}
+			return "The code has a syntax error. Run agentuity bundle to find it locally."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(?:npm ERR!|error: lockfile|lockfile had changes|frozen-lockfile|No solution found when resolving)`),
+		suggest: func(m []string, language string, command []string) string {
+			return fmt.Sprintf("The dependencies couldn't be installed. Check that the lockfile matches %s and is committed.", dependencyFile(language))
+		},
+	},
+}
+
+var errorLine = regexp.MustCompile(`(?i)(error|exception|fatal|failed|cannot|traceback|killed|not found)`)
+
+// excerptLines is the number of lines of the logs in the excerpt of the report
+const excerptLines = 20
+
+// logExcerpt returns the lines around the first error of the logs or the last lines when no line
+// looks like an error
+func logExcerpt(logs []string) []string {
+	for i, line := range logs {
+		if errorLine.MatchString(line) {
+			start := max(0, i-2)
+			end := min(len(logs), start+excerptLines)
+			return logs[start:end]
+		}
+	}
+	return logs[max(0, len(logs)-excerptLines):]
+}
+
+// Triage explains the failure of a deployment and suggests how to fix it from its reason and logs.
+// The language and start command are those of the deployment configuration.
+func Triage(failure iproject.DeploymentFailure, language string, command []string) TriageReport {
+	report := TriageReport{Phase: failure.Phase, Reason: failure.Reason, Excerpt: logExcerpt(failure.Logs)}
+	if report.Reason == "" {
+		report.Reason = "The deployment failed"
+	}
+	text := failure.Reason + "\n" + strings.Join(failure.Logs, "\n")
+	seen := make(map[string]bool)
+	for _, rule := range triageRules {
+		for _, m := range rule.pattern.FindAllStringSubmatch(text, -1) {
+			suggestion := rule.suggest(m, language, command)
+			if !seen[suggestion] {
+				seen[suggestion] = true
+				report.Suggestions = append(report.Suggestions, suggestion)
+			}
+		}
+	}
+	if len(report.Suggestions) == 0 {
+		switch failure.Phase {
+		case iproject.FailureBuild:
+			report.Suggestions = append(report.Suggestions, "Run agentuity bundle --production to reproduce the build locally.")
+		case iproject.FailureBoot:
+			report.Suggestions = append(report.Suggestions, fmt.Sprintf("Run agentuity dev to check that the agents start locally with the start command %q.", strings.Join(command, " ")))
+		}
+	}
+	return report
+}
@@ -0,0 +1,62 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriageMissingDependency(t *testing.T) {
+	report := Triage(iproject.DeploymentFailure{
+		Phase:  iproject.FailureBoot,
+		Reason: "the deployment exited with code 1",
+		Logs: []string{
+			"starting",
+			"node:internal/modules/esm/resolve:854",
+			"Error [ERR_MODULE_NOT_FOUND]: Cannot find package '@ai-sdk/openai' imported from /app/.agentuity/index.js",
+			"    at packageResolve (node:internal/modules/esm/resolve:854:9)",
+		},
+	}, "javascript", []string{"bun", "run", ".agentuity/index.js"})
+	assert.Equal(t, iproject.FailureBoot, report.Phase)
+	assert.Equal(t, "the deployment exited with code 1", report.Reason)
+	assert.Equal(t, []string{"The package @ai-sdk/openai is missing. Add it to the dependencies (not the devDependencies) in package.json and deploy again."}, report.Suggestions)
+	assert.Equal(t, "starting", report.Excerpt[0])
+
+	report = Triage(iproject.DeploymentFailure{Logs: []string{"Traceback (most recent call last):", "ModuleNotFoundError: No module named 'openai.types'"}}, "python", nil)
+	assert.Equal(t, "The deployment failed", report.Reason)
+	assert.Equal(t, []string{"The module openai is missing. Add its package to the dependencies in pyproject.toml and deploy again."}, report.Suggestions)
+}
+
+func TestTriageStartCommand(t *testing.T) {
+	report := Triage(iproject.DeploymentFailure{Phase: iproject.FailureBoot, Logs: []string{`exec: "uvx": executable file not found in $PATH`}}, "python", []string{"uvx", "main.py"})
+	assert.Equal(t, []string{`The start command "uvx main.py" couldn't be run. Check deployment.command and deployment.args in agentuity.yaml.`}, report.Suggestions)
+}
+
+func TestTriageSuggestions(t *testing.T) {
+	report := Triage(iproject.DeploymentFailure{Logs: []string{
+		"FATAL ERROR: Reached heap limit Allocation failed - JavaScript heap out of memory",
+		"Error: OPENAI_API_KEY is not set",
+		"the value is required",
+	}}, "javascript", nil)
+	assert.Len(t, report.Suggestions, 2)
+	assert.Contains(t, report.Suggestions[0], "ran out of memory")
+	assert.Contains(t, report.Suggestions[1], "agentuity env set OPENAI_API_KEY")
+}
+
+func TestTriageFallback(t *testing.T) {
+	report := Triage(iproject.DeploymentFailure{Phase: iproject.FailureBuild, Reason: "build failed"}, "javascript", nil)
+	assert.Equal(t, []string{"Run agentuity bundle --production to reproduce the build locally."}, report.Suggestions)
+	assert.Empty(t, report.Excerpt)
+}
+
+func TestLogExcerpt(t *testing.T) {
+	var logs []string
+	for i := 0; i < 50; i++ {
+		logs = append(logs, fmt.Sprintf("line %d", i))
+	}
+	assert.Equal(t, logs[30:], logExcerpt(logs))
+	logs[10] = "Error: boom"
+	assert.Equal(t, logs[8:28], logExcerpt(logs))
+}
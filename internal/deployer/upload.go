@@ -0,0 +1,118 @@
+package deployer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// UploadRequest is the encrypted deployment zip to upload and where to upload it
+type UploadRequest struct {
+	// Filename is the encrypted deployment zip
+	Filename string
+	// SignedURL is the one-time signed URL of the storage returned when the deployment was started
+	SignedURL    string
+	APIURL       string
+	Token        string
+	DeploymentID string
+	// ViaAPI streams the deployment through the Agentuity API instead of the signed URL for the
+	// networks which block the storage
+	ViaAPI bool
+	// Progress is called with the progress of the upload, which starts again when it falls back to
+	// the API
+	Progress func(util.Progress)
+}
+
+// networkError is the error of an upload which didn't get a response
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string {
+	return e.err.Error()
+}
+
+func (e *networkError) Unwrap() error {
+	return e.err
+}
+
+// UploadResult is how the deployment was uploaded
+type UploadResult struct {
+	// ViaAPI is true when the deployment was streamed through the Agentuity API
+	ViaAPI bool
+	// DirectError is the network error of the direct upload when it fell back to the API
+	DirectError error
+}
+
+// UploadDeployment uploads the deployment to the signed URL of the storage or through the Agentuity
+// API. When the direct upload fails with a network error, rather than an error response of the
+// storage, the deployment is uploaded through the API instead.
+func UploadDeployment(ctx context.Context, logger logger.Logger, req UploadRequest) (*UploadResult, error) {
+	if req.ViaAPI {
+		return &UploadResult{ViaAPI: true}, uploadViaAPI(ctx, logger, req)
+	}
+	err := uploadFile(ctx, logger, util.TransformUrl(req.SignedURL), req.Filename, nil, req.Progress)
+	var nerr *networkError
+	if err == nil || !errors.As(err, &nerr) || ctx.Err() != nil {
+		return &UploadResult{}, err
+	}
+	logger.Debug("the direct upload failed, uploading through the API: %s", err)
+	return &UploadResult{ViaAPI: true, DirectError: err}, uploadViaAPI(ctx, logger, req)
+}
+
+func uploadViaAPI(ctx context.Context, logger logger.Logger, req UploadRequest) error {
+	u, err := url.Parse(req.APIURL)
+	if err != nil {
+		return fmt.Errorf("error parsing the API URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, "/cli/deploy/upload", req.DeploymentID, "content")
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+req.Token)
+	header.Set("User-Agent", util.UserAgent())
+	return uploadFile(ctx, logger, u.String(), req.Filename, header, req.Progress)
+}
+
+func uploadFile(ctx context.Context, logger logger.Logger, uploadURL string, filename string, header http.Header, progress func(util.Progress)) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	logger.Trace("uploading to %s", uploadURL)
+	tracker := util.NewProgressTracker(fi.Size(), progress)
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadURL, tracker.Reader(f))
+	if err != nil {
+		return fmt.Errorf("error creating PUT request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.ContentLength = fi.Size()
+	req.Header.Set("Content-Type", "application/zip")
+	req.Header.Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &networkError{err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		buf, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, string(buf))
+	}
+	tracker.Finish()
+	logger.Debug("uploaded %d bytes", fi.Size())
+	return nil
+}
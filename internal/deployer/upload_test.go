@@ -0,0 +1,129 @@
+package deployer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type uploadServer struct {
+	*httptest.Server
+	paths  []string
+	auth   string
+	bodies []string
+}
+
+func newUploadServer(t *testing.T, status int) *uploadServer {
+	s := &uploadServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		s.paths = append(s.paths, r.Method+" "+r.URL.Path)
+		s.auth = r.Header.Get("Authorization")
+		s.bodies = append(s.bodies, string(buf))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// signedURL returns the URL of the storage with an IPv4-mapped address so the url isn't rewritten
+// when the tests run in a container
+func (s *uploadServer) signedURL(path string) string {
+	return strings.Replace(s.URL, "127.0.0.1", "[::ffff:127.0.0.1]", 1) + path
+}
+
+func writeUploadFile(t *testing.T) string {
+	filename := filepath.Join(t.TempDir(), "deploy.zip")
+	require.NoError(t, os.WriteFile(filename, []byte("encrypted"), 0644))
+	return filename
+}
+
+func TestUploadDeploymentDirect(t *testing.T) {
+	storage := newUploadServer(t, http.StatusOK)
+	api := newUploadServer(t, http.StatusOK)
+	var reports []util.Progress
+	result, err := UploadDeployment(context.Background(), logger.NewTestLogger(), UploadRequest{
+		Filename:     writeUploadFile(t),
+		SignedURL:    storage.signedURL("/bucket/deploy.zip?signature=abc"),
+		APIURL:       api.URL,
+		Token:        "token",
+		DeploymentID: "deploy_1",
+		Progress: func(p util.Progress) {
+			reports = append(reports, p)
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.ViaAPI)
+	require.NotEmpty(t, reports)
+	last := reports[len(reports)-1]
+	assert.True(t, last.Finished)
+	assert.Equal(t, int64(len("encrypted")), last.Done)
+	assert.Equal(t, int64(len("encrypted")), last.Total)
+	assert.Equal(t, []string{"PUT /bucket/deploy.zip"}, storage.paths)
+	assert.Equal(t, []string{"encrypted"}, storage.bodies)
+	assert.Empty(t, storage.auth)
+	assert.Empty(t, api.paths)
+}
+
+func TestUploadDeploymentViaAPI(t *testing.T) {
+	storage := newUploadServer(t, http.StatusOK)
+	api := newUploadServer(t, http.StatusOK)
+	result, err := UploadDeployment(context.Background(), logger.NewTestLogger(), UploadRequest{
+		Filename:     writeUploadFile(t),
+		SignedURL:    storage.signedURL("/bucket/deploy.zip"),
+		APIURL:       api.URL,
+		Token:        "token",
+		DeploymentID: "deploy_1",
+		ViaAPI:       true,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.ViaAPI)
+	assert.NoError(t, result.DirectError)
+	assert.Empty(t, storage.paths)
+	assert.Equal(t, []string{"PUT /cli/deploy/upload/deploy_1/content"}, api.paths)
+	assert.Equal(t, []string{"encrypted"}, api.bodies)
+	assert.Equal(t, "Bearer token", api.auth)
+}
+
+func TestUploadDeploymentFallback(t *testing.T) {
+	// the storage can't be reached
+	storage := newUploadServer(t, http.StatusOK)
+	storage.Close()
+	api := newUploadServer(t, http.StatusOK)
+	result, err := UploadDeployment(context.Background(), logger.NewTestLogger(), UploadRequest{
+		Filename:     writeUploadFile(t),
+		SignedURL:    storage.signedURL("/bucket/deploy.zip"),
+		APIURL:       api.URL,
+		Token:        "token",
+		DeploymentID: "deploy_1",
+	})
+	require.NoError(t, err)
+	assert.True(t, result.ViaAPI)
+	assert.Error(t, result.DirectError)
+	assert.Equal(t, []string{"encrypted"}, api.bodies)
+}
+
+func TestUploadDeploymentNoFallbackOnErrorResponse(t *testing.T) {
+	storage := newUploadServer(t, http.StatusForbidden)
+	api := newUploadServer(t, http.StatusOK)
+	result, err := UploadDeployment(context.Background(), logger.NewTestLogger(), UploadRequest{
+		Filename:     writeUploadFile(t),
+		SignedURL:    storage.signedURL("/bucket/deploy.zip"),
+		APIURL:       api.URL,
+		Token:        "token",
+		DeploymentID: "deploy_1",
+	})
+	assert.ErrorContains(t, err, "status 403")
+	assert.False(t, result.ViaAPI)
+	assert.Empty(t, api.paths)
+}
@@ -0,0 +1,173 @@
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/sbom"
+	"github.com/agentuity/cli/internal/util"
+)
+
+// GeneratedBundleFiles are the files which are added to the bundle when it is deployed and whose
+// content depends on the deploy rather than the source, so they can't be verified
+var GeneratedBundleFiles = []string{
+	iproject.AgentuityDir + "/" + sbom.Filename,
+	iproject.AgentuityDir + "/" + ExternalsManifestFilename,
+	iproject.AgentuityDir + "/.manifest.yaml",
+}
+
+// BundleVerification is the result of comparing the files of a bundle rebuilt from the source with
+// the files of a deployment
+type BundleVerification struct {
+	DeploymentID string `json:"deploymentId"`
+	Commit       string `json:"commit"`
+	// Matched is the number of files with the same digest
+	Matched int `json:"matched"`
+	// Files are the files only in the rebuilt bundle (added), only in the deployment (removed) or
+	// with a different digest (changed)
+	Files iproject.SetDiff `json:"files"`
+	// Skipped are the generated files of the deployment which weren't compared
+	Skipped []string `json:"skipped,omitempty"`
+	// LocalDigest and DeployedDigest are the digests of the digests of all the compared files
+	LocalDigest    string `json:"localDigest"`
+	DeployedDigest string `json:"deployedDigest"`
+}
+
+// Verified returns true if the rebuilt bundle has the same files as the deployment
+func (v *BundleVerification) Verified() bool {
+	return v.Files.Empty()
+}
+
+// normalizeDigest returns the digest with the sha256: prefix
+func normalizeDigest(digest string) string {
+	return "sha256:" + strings.TrimPrefix(digest, "sha256:")
+}
+
+// VerifyBundle compares the digests of the files of the rebuilt bundle with the digests of the
+// files of the deployment. The generated files are skipped.
+func VerifyBundle(deployed map[string]string, rebuilt map[string]string) *BundleVerification {
+	v := &BundleVerification{}
+	from := make(map[string]string)
+	for fn, digest := range deployed {
+		if slices.Contains(GeneratedBundleFiles, fn) {
+			v.Skipped = append(v.Skipped, fn)
+			continue
+		}
+		from[fn] = normalizeDigest(digest)
+	}
+	to := make(map[string]string)
+	for fn, digest := range rebuilt {
+		if !slices.Contains(GeneratedBundleFiles, fn) {
+			to[fn] = normalizeDigest(digest)
+		}
+	}
+	for fn, digest := range to {
+		if d, ok := from[fn]; !ok {
+			v.Files.Added = append(v.Files.Added, fn)
+		} else if d != digest {
+			v.Files.Changed = append(v.Files.Changed, fn)
+		} else {
+			v.Matched++
+		}
+	}
+	for fn := range from {
+		if _, ok := to[fn]; !ok {
+			v.Files.Removed = append(v.Files.Removed, fn)
+		}
+	}
+	sort.Strings(v.Files.Added)
+	sort.Strings(v.Files.Removed)
+	sort.Strings(v.Files.Changed)
+	sort.Strings(v.Skipped)
+	v.LocalDigest = iproject.FilesDigest(to)
+	v.DeployedDigest = iproject.FilesDigest(from)
+	return v
+}
+
+// HashBundleFiles returns the digests of the files of the project in dir which would be in the
+// deployment, which are the files for which include returns true, by their name relative to dir
+func HashBundleFiles(dir string, include func(fn string, fi os.FileInfo) bool) (map[string]string, error) {
+	files, err := util.ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	digests := make(map[string]string)
+	for _, file := range files {
+		fn, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		// the same as the zip of the deployment which follows symlinks
+		fi, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if include != nil && !include(fn, fi) {
+			continue
+		}
+		digest, err := HashFile(file)
+		if err != nil {
+			return nil, err
+		}
+		digests[filepath.ToSlash(fn)] = digest
+	}
+	return digests, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("git is required to check out the commit: %w", err)
+	}
+	c := exec.CommandContext(ctx, git, args...)
+	util.ProcessSetup(c)
+	c.Dir = dir
+	c.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CheckoutCommit checks out the commit of the git repository of the project in dir into a new
+// worktree, fetching it from origin when it isn't in the repository. It returns the directory of
+// the project in the worktree and a function which removes the worktree.
+func CheckoutCommit(ctx context.Context, dir string, commit string) (string, func() error, error) {
+	prefix, err := runGit(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", nil, fmt.Errorf("the project isn't in a git repository: %w", err)
+	}
+	if _, err := runGit(ctx, dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		if _, err := runGit(ctx, dir, "fetch", "-q", "--depth", "1", "origin", commit); err != nil {
+			return "", nil, fmt.Errorf("the commit %s isn't in the repository: %w", commit, err)
+		}
+	}
+	tmpdir, err := os.MkdirTemp("", "agentuity-verify-")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := runGit(ctx, dir, "worktree", "add", "-q", "--detach", tmpdir, commit); err != nil {
+		util.RemoveAll(tmpdir)
+		return "", nil, err
+	}
+	cleanup := func() error {
+		_, err := runGit(context.Background(), dir, "worktree", "remove", "--force", tmpdir)
+		util.RemoveAll(tmpdir)
+		return err
+	}
+	return filepath.Join(tmpdir, filepath.FromSlash(prefix)), cleanup, nil
+}
@@ -0,0 +1,95 @@
+package deployer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBundle(t *testing.T) {
+	deployed := map[string]string{
+		"index.js":                   "aaa",
+		"package.json":               "sha256:bbb",
+		"old.js":                     "ccc",
+		".agentuity/sbom.cdx.json":   "ddd",
+		".agentuity/.manifest.yaml":  "eee",
+		".agentuity/agents/index.js": "fff",
+	}
+	rebuilt := map[string]string{
+		"index.js":                   "sha256:aaa",
+		"package.json":               "sha256:bbb",
+		"new.js":                     "sha256:ggg",
+		".agentuity/.manifest.yaml":  "sha256:hhh",
+		".agentuity/agents/index.js": "sha256:iii",
+	}
+	v := VerifyBundle(deployed, rebuilt)
+	assert.False(t, v.Verified())
+	assert.Equal(t, 2, v.Matched)
+	assert.Equal(t, iproject.SetDiff{Added: []string{"new.js"}, Removed: []string{"old.js"}, Changed: []string{".agentuity/agents/index.js"}}, v.Files)
+	assert.Equal(t, []string{".agentuity/.manifest.yaml", ".agentuity/sbom.cdx.json"}, v.Skipped)
+	assert.NotEqual(t, v.LocalDigest, v.DeployedDigest)
+
+	delete(deployed, "old.js")
+	deployed["new.js"] = "ggg"
+	deployed[".agentuity/agents/index.js"] = "iii"
+	v = VerifyBundle(deployed, rebuilt)
+	assert.True(t, v.Verified())
+	assert.Equal(t, 4, v.Matched)
+	assert.Equal(t, v.LocalDigest, v.DeployedDigest)
+}
+
+func TestHashBundleFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "agents"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "index.ts"), []byte("agent"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1"), 0644))
+	digests, err := HashBundleFiles(dir, func(fn string, fi os.FileInfo) bool {
+		return fn != ".env"
+	})
+	require.NoError(t, err)
+	digest, err := HashFile(filepath.Join(dir, "src", "agents", "index.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"src/agents/index.ts": digest}, digests)
+}
+
+func TestCheckoutCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		c := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		c.Dir = repo
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	project := filepath.Join(repo, "services", "agents")
+	require.NoError(t, os.MkdirAll(project, 0755))
+	fn := filepath.Join(project, "index.ts")
+	require.NoError(t, os.WriteFile(fn, []byte("first"), 0644))
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	commit := git("rev-parse", "HEAD")
+	require.NoError(t, os.WriteFile(fn, []byte("second"), 0644))
+	git("commit", "-q", "-am", "second")
+
+	dir, cleanup, err := CheckoutCommit(context.Background(), project, commit)
+	require.NoError(t, err)
+	buf, err := os.ReadFile(filepath.Join(dir, "index.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf))
+	require.NoError(t, cleanup())
+	assert.NoDirExists(t, dir)
+
+	_, _, err = CheckoutCommit(context.Background(), t.TempDir(), commit)
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package dev
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// CACertFilename is the certificate of the local certificate authority which must be trusted
+	CACertFilename = "rootCA.pem"
+	// CAKeyFilename is the private key of the local certificate authority which never leaves the machine
+	CAKeyFilename = "rootCA-key.pem"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 825 * 24 * time.Hour // the longest validity browsers accept for a leaf certificate
+)
+
+// CertificateAuthority is the local certificate authority which issues the certificates of the
+// development server. Once it is trusted by the machine the certificates it issues are too.
+type CertificateAuthority struct {
+	Cert     *x509.Certificate
+	Key      crypto.Signer
+	CertFile string
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// LoadOrCreateCA loads the certificate authority from dir, creating it when it doesn't exist yet.
+// It returns true when the certificate authority was created.
+func LoadOrCreateCA(dir string) (*CertificateAuthority, bool, error) {
+	certFile := filepath.Join(dir, CACertFilename)
+	keyFile := filepath.Join(dir, CAKeyFilename)
+	if _, err := os.Stat(certFile); err == nil {
+		ca, err := loadCA(certFile, keyFile)
+		return ca, false, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, false, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"Agentuity development CA"},
+			OrganizationalUnit: []string{hostname},
+			CommonName:         "Agentuity development CA " + hostname,
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, false, err
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, false, err
+	}
+	ca, err := loadCA(certFile, keyFile)
+	return ca, true, err
+}
+
+func loadCA(certFile string, keyFile string) (*CertificateAuthority, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s is not a PEM encoded certificate", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s is not a PEM encoded private key", keyFile)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the private key of the certificate authority can't sign")
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, fmt.Errorf("the certificate authority in %s expired on %s, remove it to create a new one", certFile, cert.NotAfter.Format(time.DateOnly))
+	}
+	return &CertificateAuthority{Cert: cert, Key: signer, CertFile: certFile}, nil
+}
+
+// IssueCertificate returns a certificate for the hosts, which are host names or IP addresses,
+// signed by the certificate authority
+func (ca *CertificateAuthority) IssueCertificate(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"Agentuity development certificate"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		template.NotAfter = ca.Cert.NotAfter
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der, ca.Cert.Raw}, PrivateKey: key}, nil
+}
+
+// Trusted returns true when the certificate authority is trusted by the machine
+func (ca *CertificateAuthority) Trusted() bool {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return false
+	}
+	_, err = ca.Cert.Verify(x509.VerifyOptions{Roots: pool})
+	return err == nil
+}
+
+// TrustCommand returns the command which adds the certificate authority to the trust store of the
+// machine or nil when the platform isn't supported
+func (ca *CertificateAuthority) TrustCommand() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"sudo", "security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", ca.CertFile}
+	case "linux":
+		if _, err := os.Stat("/usr/local/share/ca-certificates"); err == nil {
+			return []string{"sh", "-c", fmt.Sprintf("sudo cp %q /usr/local/share/ca-certificates/agentuity-rootCA.crt && sudo update-ca-certificates", ca.CertFile)}
+		}
+		if _, err := os.Stat("/etc/pki/ca-trust/source/anchors"); err == nil {
+			return []string{"sh", "-c", fmt.Sprintf("sudo cp %q /etc/pki/ca-trust/source/anchors/agentuity-rootCA.pem && sudo update-ca-trust extract", ca.CertFile)}
+		}
+	case "windows":
+		return []string{"certutil", "-addstore", "-user", "Root", ca.CertFile}
+	}
+	return nil
+}
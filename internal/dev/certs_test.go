@@ -0,0 +1,61 @@
+package dev
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrCreateCA(t *testing.T) {
+	dir := t.TempDir()
+	ca, created, err := LoadOrCreateCA(dir)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, ca.Cert.IsCA)
+	assert.Equal(t, filepath.Join(dir, CACertFilename), ca.CertFile)
+	assert.FileExists(t, filepath.Join(dir, CAKeyFilename))
+
+	// the same certificate authority is used the next time so it only has to be trusted once
+	again, created, err := LoadOrCreateCA(dir)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, ca.Cert.Raw, again.Cert.Raw)
+}
+
+func TestHTTPSProxy(t *testing.T) {
+	ca, _, err := LoadOrCreateCA(t.TempDir())
+	require.NoError(t, err)
+	cert, err := ca.IssueCertificate([]string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost"}, leaf.DNSNames)
+	assert.Len(t, leaf.IPAddresses, 1)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.URL.Path, r.Header.Get("X-Forwarded-Proto"))
+	}))
+	defer upstream.Close()
+
+	proxy, err := StartHTTPSProxy(logger.NewTestLogger(), "127.0.0.1", 0, upstream.URL, cert)
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(fmt.Sprintf("https://localhost:%d/agent_123", proxy.Port()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "/agent_123 https", string(body))
+}
@@ -0,0 +1,198 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+)
+
+// Chaos are the failures and delays injected into the calls the agents make to other agents and
+// the cloud services while running in dev mode
+type Chaos struct {
+	// Latency is added to every call
+	Latency time.Duration
+	// Jitter is the maximum random latency added on top of Latency
+	Jitter time.Duration
+	// ErrorRate is the fraction of the calls which fail with ErrorStatus
+	ErrorRate float64
+	// ErrorStatus is the status of the failed calls
+	ErrorStatus int
+	// TimeoutRate is the fraction of the calls which never respond until the caller gives up
+	TimeoutRate float64
+	// Timeout is how long a call which never responds is held before it fails with a 504
+	Timeout time.Duration
+
+	random func() float64
+}
+
+// ParseChaos parses a comma separated list of key=value settings such as "latency=300ms,error-rate=5%"
+func ParseChaos(spec string) (*Chaos, error) {
+	chaos := &Chaos{ErrorStatus: http.StatusServiceUnavailable, Timeout: time.Minute}
+	for _, setting := range strings.Split(spec, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chaos setting %q, expected key=value", setting)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "latency":
+			chaos.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			chaos.Jitter, err = time.ParseDuration(value)
+		case "timeout":
+			chaos.Timeout, err = time.ParseDuration(value)
+		case "error-rate":
+			chaos.ErrorRate, err = parseRate(value)
+		case "timeout-rate":
+			chaos.TimeoutRate, err = parseRate(value)
+		case "error-status":
+			chaos.ErrorStatus, err = strconv.Atoi(value)
+			if err == nil && (chaos.ErrorStatus < 400 || chaos.ErrorStatus > 599) {
+				err = errors.New("must be between 400 and 599")
+			}
+		default:
+			return nil, fmt.Errorf("unknown chaos setting %q, expected latency, jitter, error-rate, error-status, timeout-rate or timeout", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid chaos setting %s=%s: %w", key, value, err)
+		}
+	}
+	if chaos.Latency < 0 || chaos.Jitter < 0 || chaos.Timeout <= 0 {
+		return nil, errors.New("invalid chaos setting: durations can't be negative")
+	}
+	if chaos.ErrorRate+chaos.TimeoutRate > 1 {
+		return nil, errors.New("invalid chaos setting: error-rate and timeout-rate add up to more than 100%")
+	}
+	return chaos, nil
+}
+
+// parseRate parses a percentage such as 5% or a fraction such as 0.05
+func parseRate(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate < 0 || rate > 1 {
+		return 0, errors.New("must be between 0% and 100%")
+	}
+	return rate, nil
+}
+
+// String returns the settings in the format ParseChaos accepts
+func (c *Chaos) String() string {
+	var settings []string
+	if c.Latency > 0 {
+		settings = append(settings, "latency="+c.Latency.String())
+	}
+	if c.Jitter > 0 {
+		settings = append(settings, "jitter="+c.Jitter.String())
+	}
+	if c.ErrorRate > 0 {
+		settings = append(settings, fmt.Sprintf("error-rate=%s%%", strconv.FormatFloat(c.ErrorRate*100, 'f', -1, 64)))
+		settings = append(settings, fmt.Sprintf("error-status=%d", c.ErrorStatus))
+	}
+	if c.TimeoutRate > 0 {
+		settings = append(settings, fmt.Sprintf("timeout-rate=%s%%", strconv.FormatFloat(c.TimeoutRate*100, 'f', -1, 64)))
+		settings = append(settings, "timeout="+c.Timeout.String())
+	}
+	return strings.Join(settings, ",")
+}
+
+func (c *Chaos) float() float64 {
+	if c.random != nil {
+		return c.random()
+	}
+	return rand.Float64()
+}
+
+// Handler returns the handler which injects the delays and failures before calling next
+func (c *Chaos) Handler(logger logger.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := c.Latency
+		if c.Jitter > 0 {
+			delay += time.Duration(c.float() * float64(c.Jitter))
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		roll := c.float()
+		switch {
+		case roll < c.ErrorRate:
+			logger.Debug("chaos: failing %s %s with %d", r.Method, r.URL.Path, c.ErrorStatus)
+			http.Error(w, "failure injected by agentuity dev --chaos", c.ErrorStatus)
+		case roll < c.ErrorRate+c.TimeoutRate:
+			logger.Debug("chaos: holding %s %s until it times out", r.Method, r.URL.Path)
+			select {
+			case <-time.After(c.Timeout):
+				http.Error(w, "timeout injected by agentuity dev --chaos", http.StatusGatewayTimeout)
+			case <-r.Context().Done():
+			}
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// ChaosProxy forwards the calls to a cloud service through the chaos
+type ChaosProxy struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// URL returns the URL to use in place of the URL of the cloud service
+func (p *ChaosProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Close stops the proxy
+func (p *ChaosProxy) Close() error {
+	return p.server.Close()
+}
+
+// StartChaosProxy starts a proxy on a random local port which forwards the calls to target
+func StartChaosProxy(logger logger.Logger, chaos *Chaos, target string) (*ChaosProxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+		},
+		FlushInterval: -1,
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: chaos.Handler(logger, proxy)}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("chaos proxy for %s stopped: %s", target, err)
+		}
+	}()
+	return &ChaosProxy{server: server, listener: listener}, nil
+}
@@ -0,0 +1,71 @@
+package dev
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChaos(t *testing.T) {
+	chaos, err := ParseChaos("latency=300ms, error-rate=5%")
+	require.NoError(t, err)
+	assert.Equal(t, 300*time.Millisecond, chaos.Latency)
+	assert.Equal(t, 0.05, chaos.ErrorRate)
+	assert.Equal(t, http.StatusServiceUnavailable, chaos.ErrorStatus)
+	assert.Equal(t, "latency=300ms,error-rate=5%,error-status=503", chaos.String())
+
+	chaos, err = ParseChaos("jitter=1s,error-rate=0.1,error-status=500,timeout-rate=2%,timeout=5s")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, chaos.Jitter)
+	assert.Equal(t, 0.1, chaos.ErrorRate)
+	assert.Equal(t, 500, chaos.ErrorStatus)
+	assert.Equal(t, 0.02, chaos.TimeoutRate)
+	assert.Equal(t, 5*time.Second, chaos.Timeout)
+
+	for _, spec := range []string{"latency", "latency=fast", "error-rate=150%", "error-status=200", "retries=3", "error-rate=60%,timeout-rate=60%"} {
+		_, err := ParseChaos(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestChaosProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	rolls := []float64{0.5, 0.01, 0.06}
+	chaos := &Chaos{Latency: 10 * time.Millisecond, ErrorRate: 0.05, ErrorStatus: 503, TimeoutRate: 0.05, Timeout: 10 * time.Millisecond, random: func() float64 {
+		roll := rolls[0]
+		rolls = rolls[1:]
+		return roll
+	}}
+	proxy, err := StartChaosProxy(logger.NewTestLogger(), chaos, upstream.URL)
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	started := time.Now()
+	resp, err := http.Get(proxy.URL() + "/kv/get")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/kv/get", string(body))
+	assert.GreaterOrEqual(t, time.Since(started), 10*time.Millisecond)
+
+	resp, err = http.Get(proxy.URL() + "/kv/get")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, err = http.Get(proxy.URL() + "/kv/get")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
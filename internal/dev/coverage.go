@@ -0,0 +1,193 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentuity/cli/internal/project"
+	cproject "github.com/agentuity/go-common/project"
+)
+
+// DefaultCoverageDir is the directory of the coverage reports relative to the project directory
+var DefaultCoverageDir = filepath.Join(project.AgentuityDir, "coverage")
+
+const (
+	// LcovReport is the filename of the lcov report
+	LcovReport = "lcov.info"
+	// CoberturaReport is the filename of the Cobertura XML report
+	CoberturaReport = "coverage.xml"
+)
+
+// nodeCoverageShim makes node write the coverage when the project server is stopped with a signal,
+// which otherwise terminates node without writing it. The process exits like it would without the
+// shim unless the project handles the signal itself.
+const nodeCoverageShim = `const v8 = require('node:v8');
+for (const [signal, code] of [['SIGINT', 130], ['SIGTERM', 143]]) {
+	const handler = () => {
+		v8.takeCoverage();
+		if (process.listenerCount(signal) === 1) {
+			process.exit(code);
+		}
+	};
+	process.on(signal, handler);
+}
+`
+
+// Coverage collects the coverage of the agents from the runs of the project server and writes
+// the lcov and Cobertura reports of the agent source directory. The data of Node.js is collected
+// with NODE_V8_COVERAGE and reported with c8, the data of Python with coverage.py.
+type Coverage struct {
+	// Dir is the project directory
+	Dir string
+	// ReportDir is the directory the reports are written to
+	ReportDir string
+	// AgentsDir is the directory of the sources of the agents, relative to the project directory
+	AgentsDir string
+	Runtime   string
+}
+
+// NewCoverage returns the coverage of the agents of the project and removes the data of previous runs.
+// The reports are written to reportDir, which is relative to the project directory unless absolute.
+func NewCoverage(dir string, p *cproject.Project, reportDir string) (*Coverage, error) {
+	if p.Bundler == nil {
+		return nil, fmt.Errorf("the project has no bundler configuration")
+	}
+	switch p.Bundler.Runtime {
+	case "nodejs", "uv":
+	case "bunjs":
+		return nil, fmt.Errorf("coverage isn't supported for the Bun runtime, which can only collect the coverage of bun test")
+	default:
+		return nil, fmt.Errorf("coverage isn't supported for the %s runtime", p.Bundler.Runtime)
+	}
+	if reportDir == "" {
+		reportDir = DefaultCoverageDir
+	}
+	if !filepath.IsAbs(reportDir) {
+		reportDir = filepath.Join(dir, reportDir)
+	}
+	c := &Coverage{Dir: dir, ReportDir: reportDir, AgentsDir: filepath.ToSlash(p.Bundler.AgentConfig.Dir), Runtime: p.Bundler.Runtime}
+	if err := os.RemoveAll(c.dataDir()); err != nil {
+		return nil, fmt.Errorf("failed to remove the coverage data of the previous run: %w", err)
+	}
+	for _, d := range []string{c.dataDir(), c.setupDir()} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", d, err)
+		}
+	}
+	var err error
+	if c.Runtime == "nodejs" {
+		err = os.WriteFile(c.shimFilename(), []byte(nodeCoverageShim), 0644)
+	} else {
+		err = os.WriteFile(c.rcFilename(), []byte(c.rcFile()), 0644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the coverage setup: %w", err)
+	}
+	return c, nil
+}
+
+// dataDir is the directory of the raw coverage data of the runs of the project server
+func (c *Coverage) dataDir() string {
+	return filepath.Join(c.ReportDir, "data")
+}
+
+// setupDir is the directory of the files which configure the collection of the coverage
+func (c *Coverage) setupDir() string {
+	return filepath.Join(c.ReportDir, "setup")
+}
+
+func (c *Coverage) shimFilename() string {
+	return filepath.Join(c.setupDir(), "coverage.cjs")
+}
+
+func (c *Coverage) rcFilename() string {
+	return filepath.Join(c.setupDir(), "coveragerc")
+}
+
+// rcFile returns the configuration of coverage.py which measures the agents only and saves the
+// data of every run of the project server, including when it is stopped with SIGTERM
+func (c *Coverage) rcFile() string {
+	return fmt.Sprintf(`[run]
+source = %s
+data_file = %s
+parallel = true
+sigterm = true
+`, filepath.Join(c.Dir, c.AgentsDir), filepath.Join(c.dataDir(), ".coverage"))
+}
+
+// Instrument changes the command of the project server so that the coverage of the run is collected
+func (c *Coverage) Instrument(cmd *exec.Cmd) error {
+	switch c.Runtime {
+	case "nodejs":
+		setEnv(cmd, "NODE_V8_COVERAGE", c.dataDir())
+		setEnv(cmd, "NODE_OPTIONS", `--require="`+c.shimFilename()+`"`)
+	case "uv":
+		args, err := pythonModuleArgs(cmd.Args, "coverage", "coverage", "run", "--rcfile="+c.rcFilename())
+		if err != nil {
+			return err
+		}
+		cmd.Args = args
+	}
+	return nil
+}
+
+// ReportCommands returns the commands which write the reports from the collected data
+func (c *Coverage) ReportCommands() [][]string {
+	lcov := filepath.Join(c.ReportDir, LcovReport)
+	cobertura := filepath.Join(c.ReportDir, CoberturaReport)
+	if c.Runtime == "nodejs" {
+		// c8 maps the coverage of the bundle back to the sources with its source maps
+		return [][]string{{
+			"npx", "--yes", "c8", "report",
+			"--temp-directory", c.dataDir(),
+			"--reports-dir", c.ReportDir,
+			"--reporter", "lcovonly",
+			"--reporter", "cobertura",
+			"--include", c.AgentsDir + "/**",
+			"--exclude-after-remap",
+		}}
+	}
+	coverage := func(args ...string) []string {
+		return append([]string{"uv", "run", "--with", "coverage", "python", "-m", "coverage"}, args...)
+	}
+	rcfile := "--rcfile=" + c.rcFilename()
+	return [][]string{
+		coverage("combine", rcfile),
+		coverage("lcov", rcfile, "-o", lcov),
+		coverage("xml", rcfile, "-o", cobertura),
+	}
+}
+
+// HasData returns true if any of the runs of the project server wrote its coverage
+func (c *Coverage) HasData() bool {
+	entries, err := os.ReadDir(c.dataDir())
+	return err == nil && len(entries) > 0
+}
+
+// Report writes the lcov and Cobertura reports and returns their filenames
+func (c *Coverage) Report(ctx context.Context, stdout io.Writer, stderr io.Writer) ([]string, error) {
+	if !c.HasData() {
+		return nil, fmt.Errorf("no coverage was collected, the agents may not have been stopped gracefully")
+	}
+	for _, args := range c.ReportCommands() {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = c.Dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to run %s: %w", strings.Join(args, " "), err)
+		}
+	}
+	if c.Runtime == "nodejs" {
+		// c8 names the Cobertura report after itself
+		if err := os.Rename(filepath.Join(c.ReportDir, "cobertura-coverage.xml"), filepath.Join(c.ReportDir, CoberturaReport)); err != nil {
+			return nil, fmt.Errorf("failed to rename the Cobertura report: %w", err)
+		}
+	}
+	return []string{filepath.Join(c.ReportDir, LcovReport), filepath.Join(c.ReportDir, CoberturaReport)}, nil
+}
@@ -0,0 +1,78 @@
+package dev
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func coverageProject(runtime string) *cproject.Project {
+	return &cproject.Project{Bundler: &cproject.Bundler{Runtime: runtime, AgentConfig: cproject.AgentBundlerConfig{Dir: "src/agents"}}}
+}
+
+func TestNewCoverage(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewCoverage(dir, coverageProject("bunjs"), "")
+	assert.ErrorContains(t, err, "Bun")
+
+	// the data of the previous run is removed
+	stale := filepath.Join(dir, DefaultCoverageDir, "data", "coverage-1.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	require.NoError(t, os.WriteFile(stale, []byte("{}"), 0644))
+	c, err := NewCoverage(dir, coverageProject("uv"), "")
+	require.NoError(t, err)
+	assert.NoFileExists(t, stale)
+	assert.False(t, c.HasData())
+	rc, err := os.ReadFile(c.rcFilename())
+	require.NoError(t, err)
+	assert.Contains(t, string(rc), "source = "+filepath.Join(dir, "src", "agents")+"\n")
+	assert.Contains(t, string(rc), "sigterm = true\n")
+
+	cmd := exec.Command("uv", "run", "--env-file", ".env", "server.py")
+	require.NoError(t, c.Instrument(cmd))
+	assert.Equal(t, []string{"uv", "run", "--with", "coverage", "--env-file", ".env", "python", "-m", "coverage", "run", "--rcfile=" + c.rcFilename(), "server.py"}, cmd.Args)
+
+	commands := c.ReportCommands()
+	require.Len(t, commands, 3)
+	assert.Equal(t, []string{"uv", "run", "--with", "coverage", "python", "-m", "coverage", "xml", "--rcfile=" + c.rcFilename(), "-o", filepath.Join(dir, DefaultCoverageDir, CoberturaReport)}, commands[2])
+
+	c, err = NewCoverage(dir, coverageProject("nodejs"), "reports")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "reports"), c.ReportDir)
+	assert.Contains(t, c.ReportCommands()[0], "src/agents/**")
+}
+
+func TestCoverageNodeSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals aren't supported on windows")
+	}
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node isn't installed")
+	}
+	dir := t.TempDir()
+	c, err := NewCoverage(dir, coverageProject("nodejs"), "")
+	require.NoError(t, err)
+
+	// node writes the coverage when it is stopped with SIGTERM like the project server
+	cmd := exec.Command("node", "-e", "console.log('ready'); setInterval(() => {}, 1000)")
+	cmd.Env = os.Environ()
+	require.NoError(t, c.Instrument(cmd))
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ready\n", line)
+	require.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+	assert.Error(t, cmd.Wait())
+	assert.Equal(t, 143, cmd.ProcessState.ExitCode())
+	assert.True(t, c.HasData())
+}
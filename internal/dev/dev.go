@@ -8,12 +8,15 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"time"
 
+	"github.com/agentuity/cli/internal/bundler"
 	"github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/cli/internal/util"
 	"github.com/agentuity/go-common/logger"
+	"github.com/google/uuid"
 )
 
 func KillProjectServer(logger logger.Logger, projectServerCmd *exec.Cmd, pid int) {
@@ -56,9 +59,9 @@ func KillProjectServer(logger logger.Logger, projectServerCmd *exec.Cmd, pid int
 	}
 }
 
-func isPortAvailable(port int) bool {
+func isPortAvailable(host string, port int) bool {
 	timeout := time.Second
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("0.0.0.0:%d", port), timeout)
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
 	if err != nil {
 		return true
 	}
@@ -77,7 +80,7 @@ func FindAvailableOpenPort() (int, error) {
 
 func FindAvailablePort(p project.ProjectContext, tryPort int) (int, error) {
 	if tryPort > 0 {
-		if isPortAvailable(tryPort) {
+		if isPortAvailable("0.0.0.0", tryPort) {
 			return tryPort, nil
 		}
 	}
@@ -86,7 +89,7 @@ func FindAvailablePort(p project.ProjectContext, tryPort int) (int, error) {
 		if err != nil {
 			return 0, err
 		}
-		if isPortAvailable(p) {
+		if isPortAvailable("0.0.0.0", p) {
 			return p, nil
 		}
 	}
@@ -95,34 +98,45 @@ func FindAvailablePort(p project.ProjectContext, tryPort int) (int, error) {
 		if err != nil {
 			return 0, err
 		}
-		if isPortAvailable(p) {
+		if isPortAvailable("0.0.0.0", p) {
 			return p, nil
 		}
 	}
-	if isPortAvailable(p.Project.Development.Port) {
+	if isPortAvailable("0.0.0.0", p.Project.Development.Port) {
 		return p.Project.Development.Port, nil
 	}
 	return FindAvailableOpenPort()
 }
 
-func CreateRunProjectCmd(ctx context.Context, log logger.Logger, theproject project.ProjectContext, server *Server, dir string, orgId string, port int, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
+func CreateRunProjectCmd(ctx context.Context, log logger.Logger, theproject project.ProjectContext, server *Server, dir string, orgId string, host string, port int, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
+	return createProjectCmd(ctx, theproject, server.TelemetryURL(), server.TelemetryAPIKey(), server.client.EndpointID(), dir, orgId, host, port, stdout, stderr)
+}
+
+// CreateStandaloneProjectCmd creates the command to run the project server on its own without
+// the dev mode server, such as to invoke an agent once. Telemetry isn't exported.
+func CreateStandaloneProjectCmd(ctx context.Context, theproject project.ProjectContext, dir string, orgId string, port int, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
+	return createProjectCmd(ctx, theproject, "", "", "local_"+uuid.New().String(), dir, orgId, DefaultHost, port, stdout, stderr)
+}
+
+func createProjectCmd(ctx context.Context, theproject project.ProjectContext, telemetryURL string, telemetryAPIKey string, endpointID string, dir string, orgId string, host string, port int, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
 	// set the vars
 	projectServerCmd := exec.CommandContext(ctx, theproject.Project.Development.Command, theproject.Project.Development.Args...)
 	projectServerCmd.Env = os.Environ()[:]
-	telemetryURL := server.TelemetryURL()
-	telemetryAPIKey := server.TelemetryAPIKey()
-	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_OTLP_URL=%s", telemetryURL))
-	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_OTLP_BEARER_TOKEN=%s", telemetryAPIKey))
+	if telemetryURL != "" {
+		projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_OTLP_URL=%s", telemetryURL))
+		projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_OTLP_BEARER_TOKEN=%s", telemetryAPIKey))
+	}
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_URL=%s", theproject.APIURL))
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_TRANSPORT_URL=%s", theproject.TransportURL))
-	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_CLOUD_DEPLOYMENT_ID=%s", server.client.EndpointID()))
-	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_ENDPOINT_ID=%s", server.client.EndpointID()))
+	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_CLOUD_DEPLOYMENT_ID=%s", endpointID))
+	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_ENDPOINT_ID=%s", endpointID))
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_CLOUD_PROJECT_ID=%s", theproject.Project.ProjectId))
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_CLOUD_ORG_ID=%s", orgId))
 
 	projectServerCmd.Env = append(projectServerCmd.Env, "AGENTUITY_SDK_DEV_MODE=true")
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_SDK_DIR=%s", dir))
 	projectServerCmd.Env = append(projectServerCmd.Env, "AGENTUITY_ENV=development")
+	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_ASSETS_DIR=%s", filepath.Join(dir, project.AgentuityDir, "assets")))
 
 	if theproject.Project.Bundler.Language == "javascript" {
 		projectServerCmd.Env = append(projectServerCmd.Env, "NODE_ENV=development")
@@ -137,11 +151,17 @@ func CreateRunProjectCmd(ctx context.Context, log logger.Logger, theproject proj
 		} else {
 			nodeOptions = fmt.Sprintf("%s --enable-source-maps", nodeOptions)
 		}
-		projectServerCmd.Env = append(projectServerCmd.Env, nodeOptions)
+		projectServerCmd.Env = append(projectServerCmd.Env, "NODE_OPTIONS="+nodeOptions)
 	}
 
+	// the bundle of the dev mode writes the stats of the runtime which agentuity dev stats shows
+	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("%s=%s", bundler.RuntimeStatsEnv, RuntimeStatsPath(dir)))
+
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("AGENTUITY_CLOUD_PORT=%d", port))
 	projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("PORT=%d", port))
+	if host != "" {
+		projectServerCmd.Env = append(projectServerCmd.Env, fmt.Sprintf("HOST=%s", host))
+	}
 
 	projectServerCmd.Stdout = stdout
 	projectServerCmd.Stderr = stderr
@@ -98,3 +98,15 @@ func kill(logger logger.Logger, pid int) error {
 	logger.Debug("killing process (pid: %d)", pid)
 	return syscall.Kill(pid, syscall.SIGTERM)
 }
+
+// ProcessStatsTree returns the memory and CPU of the descendants of the process, which are the
+// processes of the project server when it is the development server
+func ProcessStatsTree(parentPID int) ([]ProcessStats, error) {
+	cmd := exec.Command("ps", "-eo", "pid,ppid,rss,%cpu,comm")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run ps: %w", err)
+	}
+	return parseProcessStats(out.String(), parentPID), nil
+}
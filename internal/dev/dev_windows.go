@@ -155,3 +155,9 @@ func terminateProcess(logger logger.Logger, cmd *exec.Cmd) error {
 	}
 	return nil
 }
+
+// ProcessStatsTree returns the memory and CPU of the descendants of the process. It isn't
+// supported on Windows where only the stats written by the runtime are available.
+func ProcessStatsTree(parentPID int) ([]ProcessStats, error) {
+	return nil, fmt.Errorf("process stats are not supported on Windows")
+}
@@ -0,0 +1,108 @@
+package dev
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentuity/cli/internal/gravity"
+	"github.com/agentuity/cli/internal/util"
+)
+
+// EventType is the type of a lifecycle event of the development server
+type EventType string
+
+const (
+	// EventAgentStarted is emitted for each agent when the project server has started
+	EventAgentStarted EventType = "agent-started"
+	// EventRequest is emitted when a request to an agent is received
+	EventRequest EventType = "request"
+	// EventResponse is emitted when an agent has responded to a request
+	EventResponse EventType = "response"
+	// EventError is emitted when the project fails to build or the project server fails
+	EventError EventType = "error"
+	// EventReload is emitted when a file has changed and the project is rebuilt
+	EventReload EventType = "reload"
+)
+
+// Event is a lifecycle event of the development server emitted by dev --events json
+type Event struct {
+	Type      EventType `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	AgentID   string    `json:"agentId,omitempty"`
+	AgentName string    `json:"agentName,omitempty"`
+	// SessionID is the id of the session of the request, which is the trace id of its traceparent
+	SessionID string `json:"sessionId,omitempty"`
+	// URL is the local URL of the agent
+	URL string `json:"url,omitempty"`
+	// Inspect is the address the debugger attaches to with dev --inspect
+	Inspect string `json:"inspect,omitempty"`
+	// Path is the file which changed
+	Path       string   `json:"path,omitempty"`
+	Status     int      `json:"status,omitempty"`
+	DurationMs int64    `json:"durationMs,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	Payload    *Message `json:"payload,omitempty"`
+}
+
+// EventWriter writes the events as JSON lines. A nil writer drops the events so that they can be
+// emitted whether or not they were requested.
+type EventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEventWriter returns a writer of the events to w
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event, setting its timestamp when it has none
+func (w *EventWriter) Emit(event Event) {
+	if w == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(event)
+}
+
+// sessionID returns the trace id of the traceparent header of the request
+func sessionID(header http.Header) string {
+	if tok := strings.Split(header.Get("traceparent"), "-"); len(tok) > 1 {
+		return tok[1]
+	}
+	return ""
+}
+
+// NewRequestEvent returns the event of a request to the agent with its body redacted
+func NewRequestEvent(agentID string, agentName string, r *http.Request, body []byte, redactor *util.Redactor) Event {
+	payload := NewMessage(r.Header.Get("Content-Type"), body, false).Redact(redactor)
+	return Event{
+		Type:      EventRequest,
+		AgentID:   agentID,
+		AgentName: agentName,
+		SessionID: sessionID(r.Header),
+		Payload:   &payload,
+	}
+}
+
+// NewResponseEvent returns the event of the response of the agent with its body redacted
+func NewResponseEvent(exchange gravity.Exchange, agentName string, redactor *util.Redactor) Event {
+	payload := NewMessage(exchange.Header.Get("Content-Type"), exchange.ResponseBody, exchange.Truncated).Redact(redactor)
+	return Event{
+		Type:       EventResponse,
+		AgentID:    exchange.AgentID,
+		AgentName:  agentName,
+		SessionID:  sessionID(exchange.Request.Header),
+		Status:     exchange.Status,
+		DurationMs: exchange.Duration.Milliseconds(),
+		Payload:    &payload,
+	}
+}
@@ -0,0 +1,71 @@
+package dev
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/gravity"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEventWriter(&buf)
+	w.Emit(Event{Type: EventAgentStarted, AgentID: "agent_1", AgentName: "hello", URL: "http://localhost:3500/agent_1"})
+	w.Emit(Event{Type: EventReload, Path: "src/agents/hello/index.ts"})
+
+	var events []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, EventAgentStarted, events[0].Type)
+	assert.Equal(t, "http://localhost:3500/agent_1", events[0].URL)
+	assert.False(t, events[0].Timestamp.IsZero())
+	assert.Equal(t, EventReload, events[1].Type)
+	assert.Equal(t, "src/agents/hello/index.ts", events[1].Path)
+	assert.NotContains(t, buf.String(), "status")
+
+	// a nil writer drops the events
+	var nilWriter *EventWriter
+	nilWriter.Emit(Event{Type: EventError, Message: "build failed"})
+}
+
+func TestRequestResponseEvents(t *testing.T) {
+	redactor := util.NewRedactor(util.DefaultRedactionRules...)
+	r := httptest.NewRequest(http.MethodPost, "/agent_1", strings.NewReader(""))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	event := NewRequestEvent("agent_1", "hello", r, []byte(`{"email":"jane@example.com"}`), redactor)
+	assert.Equal(t, EventRequest, event.Type)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", event.SessionID)
+	assert.NotContains(t, event.Payload.Body, "jane@example.com")
+	assert.True(t, event.Payload.Redacted)
+
+	event = NewResponseEvent(gravity.Exchange{
+		AgentID:      "agent_1",
+		Request:      r,
+		Status:       200,
+		Header:       http.Header{"Content-Type": []string{"text/plain"}},
+		ResponseBody: []byte("hello!"),
+		Duration:     1500 * time.Millisecond,
+	}, "hello", redactor)
+	assert.Equal(t, EventResponse, event.Type)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", event.SessionID)
+	assert.Equal(t, 200, event.Status)
+	assert.Equal(t, int64(1500), event.DurationMs)
+	assert.Equal(t, "hello!", event.Payload.Body)
+	assert.Equal(t, "text/plain", event.Payload.ContentType)
+}
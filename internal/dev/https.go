@@ -0,0 +1,63 @@
+package dev
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/agentuity/go-common/logger"
+)
+
+// HTTPSProxy terminates TLS in front of the development server so that providers which only
+// call back to HTTPS URLs, such as OAuth redirects, can reach the agents
+type HTTPSProxy struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// Port returns the port the proxy is listening on
+func (p *HTTPSProxy) Port() int {
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the proxy
+func (p *HTTPSProxy) Close() error {
+	return p.server.Close()
+}
+
+// StartHTTPSProxy listens on host and port, a random port when 0, and forwards the requests to
+// the development server at target
+func StartHTTPSProxy(logger logger.Logger, host string, port int, target string, cert tls.Certificate) (*HTTPSProxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Header.Set("X-Forwarded-Proto", "https")
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		logger.Debug("https proxy error for %s: %s", req.URL.Path, err)
+		http.Error(w, "the development server is not running", http.StatusBadGateway)
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		Handler:   proxy,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	go func() {
+		if err := server.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("https proxy stopped: %s", err)
+		}
+	}()
+	return &HTTPSProxy{server: server, listener: listener}, nil
+}
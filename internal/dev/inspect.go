@@ -0,0 +1,132 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cproject "github.com/agentuity/go-common/project"
+)
+
+// DefaultInspectPort returns the default port of the debugger of the runtime, which is the port the
+// launch configurations written by ide setup attach to
+func DefaultInspectPort(runtime string) int {
+	switch runtime {
+	case "nodejs":
+		return 9229
+	case "bunjs":
+		return 6499
+	case "uv":
+		return 5678
+	}
+	return 0
+}
+
+// InspectEndpoint returns the address the debugger attaches to, which is the WebSocket URL of the
+// Bun inspector and the address of node --inspect and debugpy
+func InspectEndpoint(runtime string, port int) string {
+	if runtime == "bunjs" {
+		return fmt.Sprintf("ws://%s:%d/agentuity", DefaultHost, port)
+	}
+	return fmt.Sprintf("%s:%d", DefaultHost, port)
+}
+
+// CheckInspectPort returns an error if the port of the debugger is in use, such as by another
+// development server, since the runtime would otherwise start without the debugger
+func CheckInspectPort(port int) error {
+	if !isPortAvailable(DefaultHost, port) {
+		return fmt.Errorf("the debugger port %d is already in use", port)
+	}
+	return nil
+}
+
+// WaitForInspectPort waits until the port of the debugger is released by the stopped project
+// server so that the restarted one listens on the same port. It returns false after the timeout.
+func WaitForInspectPort(ctx context.Context, port int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !isPortAvailable(DefaultHost, port) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// EnableInspector changes the command of the project server so that the runtime listens for a
+// debugger on the port: node --inspect for Node.js, the Bun inspector and debugpy for Python
+func EnableInspector(cmd *exec.Cmd, p *cproject.Project, port int) error {
+	if p.Bundler == nil {
+		return fmt.Errorf("the project has no bundler configuration")
+	}
+	addr := fmt.Sprintf("%s:%d", DefaultHost, port)
+	switch p.Bundler.Runtime {
+	case "nodejs":
+		setEnv(cmd, "NODE_OPTIONS", "--inspect="+addr)
+	case "bunjs":
+		setEnv(cmd, "BUN_INSPECT", addr+"/agentuity")
+	case "uv":
+		args, err := pythonModuleArgs(cmd.Args, "debugpy", "debugpy", "--listen", addr)
+		if err != nil {
+			return err
+		}
+		cmd.Args = args
+	default:
+		return fmt.Errorf("debugging isn't supported for the %s runtime", p.Bundler.Runtime)
+	}
+	return nil
+}
+
+// setEnv adds the value to the environment variable of the command, appending it to the options
+// already set such as --enable-source-maps
+func setEnv(cmd *exec.Cmd, key string, value string) {
+	for i, kv := range cmd.Env {
+		if k, v, _ := strings.Cut(kv, "="); k == key {
+			if v != "" && key == "NODE_OPTIONS" {
+				value = v + " " + value
+			}
+			cmd.Env[i] = key + "=" + value
+			return
+		}
+	}
+	cmd.Env = append(cmd.Env, key+"="+value)
+}
+
+// pythonModuleArgs returns the arguments which run the Python script of the command with the module,
+// such as debugpy or coverage, which uv adds to the environment of the run as the package
+func pythonModuleArgs(args []string, pkg string, module ...string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("the project has no development command")
+	}
+	script := -1
+	for i, arg := range args[1:] {
+		if strings.HasSuffix(arg, ".py") {
+			script = i + 1
+			break
+		}
+	}
+	if script < 0 {
+		return nil, fmt.Errorf("the development command %s doesn't run a Python script", strings.Join(args, " "))
+	}
+	var result []string
+	switch name := filepath.Base(args[0]); {
+	case name == "uv" && len(args) > 1 && args[1] == "run":
+		result = append(result, args[0], "run", "--with", pkg)
+		result = append(result, args[2:script]...)
+		result = append(result, "python")
+	case strings.HasPrefix(name, "python"):
+		result = append(result, args[:script]...)
+	default:
+		return nil, fmt.Errorf("the development command %s isn't run with uv or python", args[0])
+	}
+	result = append(result, "-m")
+	result = append(result, module...)
+	return append(result, args[script:]...), nil
+}
@@ -0,0 +1,61 @@
+package dev
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableInspectorNode(t *testing.T) {
+	p := &cproject.Project{Bundler: &cproject.Bundler{Language: "javascript", Runtime: "nodejs"}}
+	cmd := exec.Command("node", ".agentuity/index.mjs")
+	cmd.Env = []string{"PATH=/usr/bin", "NODE_OPTIONS=--enable-source-maps"}
+	require.NoError(t, EnableInspector(cmd, p, 9229))
+	assert.Equal(t, []string{"PATH=/usr/bin", "NODE_OPTIONS=--enable-source-maps --inspect=127.0.0.1:9229"}, cmd.Env)
+
+	p.Bundler.Runtime = "bunjs"
+	cmd = exec.Command("bun", "run", ".agentuity/index.js")
+	require.NoError(t, EnableInspector(cmd, p, 6499))
+	assert.Contains(t, cmd.Env, "BUN_INSPECT=127.0.0.1:6499/agentuity")
+
+	p.Bundler.Runtime = "deno"
+	assert.Error(t, EnableInspector(exec.Command("deno"), p, 9229))
+}
+
+func TestEnableInspectorPython(t *testing.T) {
+	p := &cproject.Project{Bundler: &cproject.Bundler{Language: "python", Runtime: "uv"}}
+	cmd := exec.Command("uv", "run", "--env-file", ".env", "server.py", "--reload")
+	require.NoError(t, EnableInspector(cmd, p, 5678))
+	assert.Equal(t, []string{"uv", "run", "--with", "debugpy", "--env-file", ".env", "python", "-m", "debugpy", "--listen", "127.0.0.1:5678", "server.py", "--reload"}, cmd.Args)
+
+	cmd = exec.Command(".venv/bin/python3", "-u", "server.py")
+	require.NoError(t, EnableInspector(cmd, p, 5678))
+	assert.Equal(t, []string{".venv/bin/python3", "-u", "-m", "debugpy", "--listen", "127.0.0.1:5678", "server.py"}, cmd.Args)
+
+	assert.Error(t, EnableInspector(exec.Command("uv", "run", "main"), p, 5678))
+	assert.Error(t, EnableInspector(exec.Command("poetry", "run", "server.py"), p, 5678))
+}
+
+func TestInspectPort(t *testing.T) {
+	assert.Equal(t, "ws://127.0.0.1:6499/agentuity", InspectEndpoint("bunjs", 6499))
+	assert.Equal(t, "127.0.0.1:9229", InspectEndpoint("nodejs", 9229))
+	assert.Equal(t, "127.0.0.1:5678", InspectEndpoint("uv", 5678))
+	assert.Equal(t, 0, DefaultInspectPort("deno"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.Error(t, CheckInspectPort(port))
+	assert.False(t, WaitForInspectPort(context.Background(), port, 200*time.Millisecond))
+
+	// the port is released while waiting, like when the project server has been killed
+	time.AfterFunc(200*time.Millisecond, func() { listener.Close() })
+	assert.True(t, WaitForInspectPort(context.Background(), port, 5*time.Second))
+	assert.NoError(t, CheckInspectPort(port))
+}
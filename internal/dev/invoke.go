@@ -0,0 +1,63 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WaitForProject waits until the project server at url responds to health checks or the timeout elapses.
+// It returns early with an error when exited is closed, meaning the project server stopped.
+func WaitForProject(ctx context.Context, url string, timeout time.Duration, exited <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		req, err := http.NewRequestWithContext(ctx, "GET", url+"/_health", nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("the project server exited before it was ready")
+		case <-ctx.Done():
+			return fmt.Errorf("the project server wasn't ready after %s", timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// AgentResult is the response of an agent invocation
+type AgentResult struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// InvokeAgent sends the payload to the agent on the project server at url and returns its response
+func InvokeAgent(ctx context.Context, url string, agentID string, contentType string, payload []byte) (*AgentResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/%s", strings.TrimRight(url, "/"), agentID), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &AgentResult{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: body}, nil
+}
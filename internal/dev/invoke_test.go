@@ -0,0 +1,48 @@
+package dev
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeAgent(t *testing.T) {
+	var ready bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_health" {
+			if !ready {
+				ready = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			return
+		}
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/agent_1", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		buf, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(append([]byte("echo "), buf...))
+	}))
+	defer server.Close()
+
+	require.NoError(t, WaitForProject(context.Background(), server.URL, time.Second, nil))
+	result, err := InvokeAgent(context.Background(), server.URL, "agent_1", "application/json", []byte(`{"hello":"world"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.Equal(t, "text/plain", result.ContentType)
+	assert.Equal(t, `echo {"hello":"world"}`, string(result.Body))
+}
+
+func TestWaitForProjectExited(t *testing.T) {
+	exited := make(chan struct{})
+	close(exited)
+	err := WaitForProject(context.Background(), "http://127.0.0.1:1", 5*time.Second, exited)
+	assert.ErrorContains(t, err, "exited")
+}
@@ -0,0 +1,526 @@
+package dev
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MaxStackFrames is the number of frames of a stack trace shown before the rest is collapsed
+const MaxStackFrames = 3
+
+// logFlushDelay is how long a partial line or a collapsed stack trace waits for more output
+const logFlushDelay = 100 * time.Millisecond
+
+// pythonTraceback is the line which starts a python traceback
+const pythonTraceback = "Traceback (most recent call last):"
+
+// LogLevel is the level of a log line written by the agents
+type LogLevel int
+
+const (
+	LogLevelTrace LogLevel = iota
+	LogLevelDebug
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelFatal
+)
+
+var logLevelNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (l LogLevel) String() string {
+	if l < LogLevelTrace || l > LogLevelFatal {
+		return "INFO"
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel parses the name of a level as written by the runtimes, such as warn, WARNING or err
+func ParseLogLevel(name string) (LogLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace":
+		return LogLevelTrace, true
+	case "debug":
+		return LogLevelDebug, true
+	case "info", "information", "log":
+		return LogLevelInfo, true
+	case "warn", "warning":
+		return LogLevelWarn, true
+	case "error", "err":
+		return LogLevelError, true
+	case "fatal", "critical", "panic":
+		return LogLevelFatal, true
+	}
+	return LogLevelInfo, false
+}
+
+// pinoLevel converts the numeric levels of pino and similar javascript loggers
+func pinoLevel(level float64) LogLevel {
+	switch {
+	case level >= 60:
+		return LogLevelFatal
+	case level >= 50:
+		return LogLevelError
+	case level >= 40:
+		return LogLevelWarn
+	case level >= 30:
+		return LogLevelInfo
+	case level >= 20:
+		return LogLevelDebug
+	}
+	return LogLevelTrace
+}
+
+// LogEntry is a line written by the agents, parsed from a structured JSON log or from a plain text
+// line with an optional [LEVEL] prefix
+type LogEntry struct {
+	Time  time.Time
+	Level LogLevel
+	// Structured is set when the line was a JSON object
+	Structured bool
+	Agent      string
+	Message    string
+	Fields     map[string]any
+	Stack      []string
+}
+
+var (
+	logMessageKeys = []string{"msg", "message"}
+	logTimeKeys    = []string{"time", "timestamp", "ts", "@timestamp"}
+	logLevelKeys   = []string{"level", "severity", "levelname", "lvl"}
+	logAgentKeys   = []string{"agent", "agentName", "agent_name", "@agentuity/agentName"}
+	logStackKeys   = []string{"stack", "stack_trace", "exc_info"}
+	logErrorKeys   = []string{"err", "error"}
+
+	logLevelPrefix = regexp.MustCompile(`^\s*\[?(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|CRITICAL)\]?(:|\s)`)
+	stackFrameLine = regexp.MustCompile(`^\s+(at\s|File ")`)
+)
+
+func takeString(fields map[string]any, keys []string) string {
+	for _, key := range keys {
+		if v, ok := fields[key]; ok {
+			if s, ok := v.(string); ok {
+				delete(fields, key)
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func splitStack(stack string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	return lines
+}
+
+// ParseLogLine parses a line written by the agents
+func ParseLogLine(line string) LogEntry {
+	entry := LogEntry{Level: LogLevelInfo, Message: line}
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			return parseStructuredLog(fields)
+		}
+	}
+	if m := logLevelPrefix.FindStringSubmatch(line); m != nil {
+		entry.Level, _ = ParseLogLevel(m[1])
+	}
+	return entry
+}
+
+func parseStructuredLog(fields map[string]any) LogEntry {
+	entry := LogEntry{Level: LogLevelInfo, Structured: true}
+	for _, key := range logLevelKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if level, ok := ParseLogLevel(v); ok {
+				entry.Level = level
+				delete(fields, key)
+			}
+		case float64:
+			entry.Level = pinoLevel(v)
+			delete(fields, key)
+		}
+	}
+	entry.Message = takeString(fields, logMessageKeys)
+	entry.Agent = takeString(fields, logAgentKeys)
+	for _, key := range logTimeKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				entry.Time = t
+				delete(fields, key)
+			}
+		case float64:
+			// milliseconds since the epoch
+			entry.Time = time.UnixMilli(int64(v))
+			delete(fields, key)
+		}
+	}
+	if stack := takeString(fields, logStackKeys); stack != "" {
+		entry.Stack = splitStack(stack)
+	}
+	for _, key := range logErrorKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if entry.Message == "" {
+				entry.Message = v
+				delete(fields, key)
+			}
+		case map[string]any:
+			// the serialized error of pino and similar loggers
+			message, _ := v["message"].(string)
+			stack, _ := v["stack"].(string)
+			if entry.Message == "" {
+				entry.Message = message
+			} else if message != "" && !strings.Contains(entry.Message, message) {
+				entry.Message += ": " + message
+			}
+			if len(entry.Stack) == 0 && stack != "" {
+				entry.Stack = splitStack(stack)
+				// the stack starts with the message which is already shown
+				if len(entry.Stack) > 0 && !stackFrameLine.MatchString(entry.Stack[0]) {
+					entry.Stack = entry.Stack[1:]
+				}
+			}
+			delete(fields, key)
+		}
+	}
+	entry.Fields = fields
+	return entry
+}
+
+// LogFilter selects the log lines which are shown
+type LogFilter struct {
+	// Level is the minimum level shown
+	Level LogLevel
+	// Agent is part of the name of the agent, the lines of the other agents and the lines without
+	// an agent are hidden
+	Agent string
+	// Text is part of the message
+	Text string
+}
+
+// ParseLogFilter parses a filter such as "level=error agent=foo text=timeout". An empty filter
+// returns nil which shows every line.
+func ParseLogFilter(spec string) (*LogFilter, error) {
+	filter := &LogFilter{Level: LogLevelTrace}
+	var set bool
+	for _, setting := range strings.Fields(spec) {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid filter %q, expected key=value", setting)
+		}
+		switch key {
+		case "level":
+			level, ok := ParseLogLevel(value)
+			if !ok {
+				return nil, fmt.Errorf("invalid level %q, expected one of trace, debug, info, warn, error or fatal", value)
+			}
+			filter.Level = level
+		case "agent":
+			filter.Agent = value
+		case "text":
+			filter.Text = value
+		default:
+			return nil, fmt.Errorf("unknown filter %q, expected level, agent or text", key)
+		}
+		set = true
+	}
+	if !set {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Match returns true when the line is shown
+func (f *LogFilter) Match(entry LogEntry) bool {
+	if f == nil {
+		return true
+	}
+	if entry.Level < f.Level {
+		return false
+	}
+	if f.Agent != "" && !strings.Contains(strings.ToLower(entry.Agent), strings.ToLower(f.Agent)) {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(f.Text)) {
+		return false
+	}
+	return true
+}
+
+func (f *LogFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	if f.Level > LogLevelTrace {
+		parts = append(parts, "level="+strings.ToLower(f.Level.String()))
+	}
+	if f.Agent != "" {
+		parts = append(parts, "agent="+f.Agent)
+	}
+	if f.Text != "" {
+		parts = append(parts, "text="+f.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	logTimeStyle  = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999999", Dark: "#777777"})
+	logAgentStyle = lipgloss.NewStyle().Foreground(logoColor)
+	logFieldStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#777777", Dark: "#999999"})
+	logLevelStyle = map[LogLevel]lipgloss.Style{
+		LogLevelTrace: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999999", Dark: "#777777"}),
+		LogLevelDebug: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#5f5fd7", Dark: "#8787ff"}),
+		LogLevelInfo:  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#008700", Dark: "#5fd75f"}),
+		LogLevelWarn:  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#af8700", Dark: "#ffd700"}),
+		LogLevelError: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#d70000", Dark: "#ff5f5f"}),
+		LogLevelFatal: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#d70000", Dark: "#ff5f5f"}).Bold(true),
+	}
+)
+
+func formatLogField(v any) string {
+	switch v := v.(type) {
+	case string:
+		if strings.ContainsAny(v, " \t\"") {
+			return fmt.Sprintf("%q", v)
+		}
+		return v
+	case nil:
+		return "null"
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
+
+// formatLogEntry formats a structured line, the stack trace is written separately
+func formatLogEntry(entry LogEntry) string {
+	var sb strings.Builder
+	if !entry.Time.IsZero() {
+		sb.WriteString(logTimeStyle.Render(entry.Time.Local().Format("15:04:05.000")))
+		sb.WriteString(" ")
+	}
+	sb.WriteString(logLevelStyle[entry.Level].Render(fmt.Sprintf("%-5s", entry.Level)))
+	if entry.Agent != "" {
+		sb.WriteString(" ")
+		sb.WriteString(logAgentStyle.Render("[" + entry.Agent + "]"))
+	}
+	if entry.Message != "" {
+		sb.WriteString(" ")
+		sb.WriteString(entry.Message)
+	}
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, k := range keys {
+			fields = append(fields, k+"="+formatLogField(entry.Fields[k]))
+		}
+		sb.WriteString(" ")
+		sb.WriteString(logFieldStyle.Render(strings.Join(fields, " ")))
+	}
+	return sb.String()
+}
+
+// formatPlainLine colors the level prefix of a plain text line
+func formatPlainLine(line string, entry LogEntry) string {
+	loc := logLevelPrefix.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return line
+	}
+	return line[:loc[0]] + logLevelStyle[entry.Level].Render(line[loc[0]:loc[1]]) + line[loc[1]:]
+}
+
+// LogRenderer renders the output of the agents in dev mode with the filter which can be changed
+// while the agents are running
+type LogRenderer struct {
+	// Redactor replaces the sensitive data in the lines before they're shown
+	Redactor *util.Redactor
+	// Raw shows the lines as is, only redacted
+	Raw bool
+
+	mu     sync.RWMutex
+	filter *LogFilter
+}
+
+// NewLogRenderer returns a renderer with the filter, which can be nil to show every line
+func NewLogRenderer(filter *LogFilter) *LogRenderer {
+	return &LogRenderer{filter: filter}
+}
+
+// SetFilter changes the filter of the lines written from now on
+func (r *LogRenderer) SetFilter(filter *LogFilter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filter = filter
+}
+
+// Filter returns the current filter
+func (r *LogRenderer) Filter() *LogFilter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.filter
+}
+
+// Writer returns a writer which renders the lines written to it to out. Each output stream of the
+// agents needs its own writer so that their lines aren't mixed up.
+func (r *LogRenderer) Writer(out io.Writer) *LogWriter {
+	return &LogWriter{renderer: r, out: out, visible: true}
+}
+
+// LogWriter renders the lines of an output stream of the agents
+type LogWriter struct {
+	renderer *LogRenderer
+	out      io.Writer
+
+	mu  sync.Mutex
+	buf []byte
+	// visible is whether the last line was shown, the frames of a stack trace follow it
+	visible bool
+	// frames is the number of frames of the current stack trace and hidden the ones not shown
+	frames int
+	hidden int
+	// pyFrame is set after the frame of a python traceback, which is followed by its source line,
+	// and pyStack until the line with the exception which ends the traceback
+	pyFrame bool
+	pyStack bool
+	timer   *time.Timer
+}
+
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buf[:idx]), "\r")
+		w.buf = w.buf[idx+1:]
+		w.writeLine(line)
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if len(w.buf) > 0 || w.hidden > 0 {
+		w.timer = time.AfterFunc(logFlushDelay, w.Flush)
+	}
+	return len(p), nil
+}
+
+// Flush writes the partial line and the summary of the collapsed stack trace
+func (w *LogWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.endStack()
+	if len(w.buf) > 0 {
+		// a prompt or a progress bar without a newline
+		if w.visible {
+			io.WriteString(w.out, w.renderer.Redactor.Redact(string(w.buf)))
+		}
+		w.buf = nil
+	}
+}
+
+func (w *LogWriter) endStack() {
+	if w.hidden > 0 && w.visible {
+		fmt.Fprintln(w.out, logTimeStyle.Render("    … "+util.Pluralize(w.hidden, "more frame", "more frames")))
+	}
+	w.frames = 0
+	w.hidden = 0
+	w.pyFrame = false
+	w.pyStack = false
+}
+
+// writeFrame writes a frame of a stack trace unless it's collapsed
+func (w *LogWriter) writeFrame(line string, continuation bool) {
+	if !continuation {
+		w.frames++
+	}
+	if w.frames > MaxStackFrames {
+		if !continuation {
+			w.hidden++
+		}
+		return
+	}
+	if w.visible {
+		fmt.Fprintln(w.out, logTimeStyle.Render(line))
+	}
+}
+
+func (w *LogWriter) writeLine(line string) {
+	line = w.renderer.Redactor.Redact(line)
+	if w.renderer.Raw {
+		fmt.Fprintln(w.out, line)
+		return
+	}
+	if stackFrameLine.MatchString(line) {
+		w.pyFrame = strings.HasPrefix(strings.TrimSpace(line), "File \"")
+		w.pyStack = w.pyStack || w.pyFrame
+		w.writeFrame(line, false)
+		return
+	}
+	// the source line which follows the frame of a python traceback
+	if w.pyFrame && strings.HasPrefix(line, "    ") {
+		w.pyFrame = false
+		w.writeFrame(line, true)
+		return
+	}
+	// a python traceback follows the line which logged it and ends with the exception
+	if strings.TrimSpace(line) == pythonTraceback || w.pyStack {
+		end := w.pyStack
+		w.endStack()
+		if w.visible {
+			fmt.Fprintln(w.out, line)
+		}
+		w.pyStack = !end
+		return
+	}
+	w.endStack()
+	entry := ParseLogLine(line)
+	// a line which continues a message, such as the lines of a multiline string, follows it
+	if !entry.Structured && entry.Level == LogLevelInfo && line != "" && (line[0] == ' ' || line[0] == '\t') && logLevelPrefix.FindStringIndex(line) == nil {
+		if w.visible {
+			fmt.Fprintln(w.out, line)
+		}
+		return
+	}
+	w.visible = w.renderer.Filter().Match(entry)
+	if !w.visible {
+		return
+	}
+	if !entry.Structured {
+		fmt.Fprintln(w.out, formatPlainLine(line, entry))
+		return
+	}
+	fmt.Fprintln(w.out, formatLogEntry(entry))
+	for _, frame := range entry.Stack {
+		w.writeFrame("    "+strings.TrimSpace(frame), false)
+	}
+	w.endStack()
+}
@@ -0,0 +1,172 @@
+package dev
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLine(t *testing.T) {
+	t.Run("structured", func(t *testing.T) {
+		entry := ParseLogLine(`{"level":"warn","msg":"slow response","agent":"support","time":"2025-01-02T03:04:05Z","latency":1200}`)
+		assert.True(t, entry.Structured)
+		assert.Equal(t, LogLevelWarn, entry.Level)
+		assert.Equal(t, "slow response", entry.Message)
+		assert.Equal(t, "support", entry.Agent)
+		assert.Equal(t, 2025, entry.Time.Year())
+		assert.Equal(t, map[string]any{"latency": float64(1200)}, entry.Fields)
+	})
+
+	t.Run("pino error", func(t *testing.T) {
+		entry := ParseLogLine(`{"level":50,"msg":"request failed","err":{"message":"boom","stack":"Error: boom\n    at a (a.js:1:1)\n    at b (b.js:2:2)"}}`)
+		assert.Equal(t, LogLevelError, entry.Level)
+		assert.Equal(t, "request failed: boom", entry.Message)
+		assert.Equal(t, []string{"    at a (a.js:1:1)", "    at b (b.js:2:2)"}, entry.Stack)
+		assert.Empty(t, entry.Fields)
+	})
+
+	t.Run("level prefix", func(t *testing.T) {
+		assert.Equal(t, LogLevelError, ParseLogLine("[ERROR] something broke").Level)
+		assert.Equal(t, LogLevelWarn, ParseLogLine("WARNING:root:careful").Level)
+		assert.Equal(t, LogLevelDebug, ParseLogLine("DEBUG details").Level)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		entry := ParseLogLine("server started {port}")
+		assert.False(t, entry.Structured)
+		assert.Equal(t, LogLevelInfo, entry.Level)
+		assert.Equal(t, "server started {port}", entry.Message)
+	})
+}
+
+func TestParseLogFilter(t *testing.T) {
+	filter, err := ParseLogFilter("level=error agent=sup")
+	require.NoError(t, err)
+	assert.Equal(t, &LogFilter{Level: LogLevelError, Agent: "sup"}, filter)
+	assert.Equal(t, "level=error agent=sup", filter.String())
+
+	assert.True(t, filter.Match(LogEntry{Level: LogLevelFatal, Agent: "Support"}))
+	assert.False(t, filter.Match(LogEntry{Level: LogLevelWarn, Agent: "support"}))
+	assert.False(t, filter.Match(LogEntry{Level: LogLevelError, Agent: "billing"}))
+	assert.False(t, filter.Match(LogEntry{Level: LogLevelError}))
+
+	filter, err = ParseLogFilter("  ")
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+	assert.True(t, filter.Match(LogEntry{Level: LogLevelTrace}))
+
+	_, err = ParseLogFilter("level=loud")
+	assert.Error(t, err)
+	_, err = ParseLogFilter("color=red")
+	assert.Error(t, err)
+	_, err = ParseLogFilter("agent")
+	assert.Error(t, err)
+}
+
+func TestLogWriterCollapsesStackTraces(t *testing.T) {
+	var out bytes.Buffer
+	w := NewLogRenderer(nil).Writer(&out)
+	lines := []string{"Error: boom"}
+	for i := 0; i < 6; i++ {
+		lines = append(lines, "    at frame"+string(rune('a'+i))+" (file.js:1:1)")
+	}
+	lines = append(lines, "next line")
+	w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	w.Flush()
+
+	result := out.String()
+	assert.Contains(t, result, "at framea")
+	assert.Contains(t, result, "at framec")
+	assert.NotContains(t, result, "at framed")
+	assert.Contains(t, result, "… 3 more frames")
+	assert.True(t, strings.Index(result, "more frames") < strings.Index(result, "next line"))
+}
+
+func TestLogWriterCollapsesPythonTracebacks(t *testing.T) {
+	var out bytes.Buffer
+	w := NewLogRenderer(nil).Writer(&out)
+	w.Write([]byte(strings.Join([]string{
+		"ERROR:agent:failed",
+		"Traceback (most recent call last):",
+		`  File "a.py", line 1, in a`,
+		"    a()",
+		`  File "b.py", line 2, in b`,
+		"    b()",
+		`  File "c.py", line 3, in c`,
+		"    c()",
+		`  File "d.py", line 4, in d`,
+		"    d()",
+		"ValueError: bad",
+		"",
+	}, "\n")))
+	w.Flush()
+
+	result := out.String()
+	assert.Contains(t, result, "c()")
+	assert.NotContains(t, result, "d.py")
+	assert.NotContains(t, result, "d()")
+	assert.Contains(t, result, "… 1 more frame")
+	assert.Contains(t, result, "ValueError: bad")
+}
+
+func TestLogWriterFilter(t *testing.T) {
+	var out bytes.Buffer
+	renderer := NewLogRenderer(nil)
+	w := renderer.Writer(&out)
+	filter, err := ParseLogFilter("level=error")
+	require.NoError(t, err)
+	renderer.SetFilter(filter)
+	w.Write([]byte("[INFO] hidden\n    at hidden (x.js:1:1)\n[ERROR] shown\n    at shown (x.js:1:1)\n"))
+	w.Write([]byte(`{"level":"info","msg":"quiet"}` + "\n"))
+	w.Write([]byte(`{"level":"error","msg":"loud","agent":"billing"}` + "\n"))
+	w.Flush()
+
+	result := out.String()
+	assert.NotContains(t, result, "hidden")
+	assert.NotContains(t, result, "quiet")
+	assert.Contains(t, result, "shown")
+	assert.Contains(t, result, "at shown")
+	assert.Contains(t, result, "[billing] loud")
+
+	renderer.SetFilter(nil)
+	w.Write([]byte("[INFO] visible again\n"))
+	assert.Contains(t, out.String(), "visible again")
+}
+
+func TestLogWriterPartialLines(t *testing.T) {
+	var out bytes.Buffer
+	w := NewLogRenderer(nil).Writer(&out)
+	w.Write([]byte(`{"level":"info",`))
+	assert.Empty(t, out.String())
+	w.Write([]byte(`"msg":"joined"}` + "\n" + "prompt> "))
+	assert.Contains(t, out.String(), "INFO  joined")
+	w.Flush()
+	assert.True(t, strings.HasSuffix(out.String(), "prompt> "))
+}
+
+func TestLogWriterRedacts(t *testing.T) {
+	var out bytes.Buffer
+	renderer := NewLogRenderer(nil)
+	renderer.Redactor = util.NewRedactor(util.DefaultRedactionRules...)
+	renderer.Redactor.AddValues("OPENAI_API_KEY", "supersecretvalue")
+	w := renderer.Writer(&out)
+	w.Write([]byte(`{"level":"info","msg":"calling with supersecretvalue","user":"jane@example.com"}` + "\n"))
+	w.Write([]byte("partial supersecretvalue"))
+	w.Flush()
+
+	result := out.String()
+	assert.NotContains(t, result, "supersecretvalue")
+	assert.NotContains(t, result, "jane@example.com")
+	assert.Contains(t, result, "calling with [REDACTED:OPENAI_API_KEY]")
+	assert.Contains(t, result, "user=[REDACTED:email]")
+	assert.Contains(t, result, "partial [REDACTED:OPENAI_API_KEY]")
+
+	out.Reset()
+	renderer.Raw = true
+	w.Write([]byte(`{"level":"info","msg":"jane@example.com"}` + "\n"))
+	assert.Equal(t, `{"level":"info","msg":"[REDACTED:email]"}`+"\n", out.String())
+}
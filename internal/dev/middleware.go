@@ -0,0 +1,390 @@
+package dev
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agentuity/cli/internal/gravity"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/go-common/logger"
+)
+
+// the harnesses load the middleware script of the user and exchange one JSON message per line with
+// the CLI over stdin and stdout. The output of the script is sent to stderr so it can't corrupt them.
+const jsMiddlewareHarness = `import { createInterface } from 'node:readline';
+import { pathToFileURL } from 'node:url';
+const out = process.stdout.write.bind(process.stdout);
+console.log = console.info = console.debug = (...args) => console.error(...args);
+const mod = await import(pathToFileURL(process.argv[2]).href);
+const hooks = { ...(mod.default ?? {}), ...mod };
+const write = (msg) => out(JSON.stringify(msg) + '\n');
+write({ ready: true, onRequest: typeof hooks.onRequest === 'function', onResponse: typeof hooks.onResponse === 'function' });
+for await (const line of createInterface({ input: process.stdin })) {
+  const msg = JSON.parse(line);
+  try {
+    if (msg.hook === 'request') {
+      const result = (await hooks.onRequest(msg.request)) ?? {};
+      write({ id: msg.id, request: msg.request, response: result.response, delay: result.delay });
+    } else {
+      const result = await hooks.onResponse(msg.request, msg.response);
+      write({ id: msg.id, response: result ?? msg.response });
+    }
+  } catch (err) {
+    write({ id: msg.id, error: String(err?.stack ?? err) });
+  }
+}
+`
+
+const pyMiddlewareHarness = `import asyncio, importlib.util, inspect, json, sys, traceback
+out = sys.stdout
+sys.stdout = sys.stderr
+spec = importlib.util.spec_from_file_location("agentuity_dev_middleware", sys.argv[1])
+mod = importlib.util.module_from_spec(spec)
+spec.loader.exec_module(mod)
+on_request = getattr(mod, "on_request", None)
+on_response = getattr(mod, "on_response", None)
+def write(msg):
+    out.write(json.dumps(msg) + "\n")
+    out.flush()
+def call(fn, *args):
+    result = fn(*args)
+    return asyncio.run(result) if inspect.isawaitable(result) else result
+write({"ready": True, "onRequest": callable(on_request), "onResponse": callable(on_response)})
+for line in sys.stdin:
+    msg = json.loads(line)
+    try:
+        if msg["hook"] == "request":
+            result = call(on_request, msg["request"]) or {}
+            write({"id": msg["id"], "request": msg["request"], "response": result.get("response"), "delay": result.get("delay")})
+        else:
+            result = call(on_response, msg["request"], msg["response"])
+            write({"id": msg["id"], "response": result if result is not None else msg["response"]})
+    except Exception:
+        write({"id": msg["id"], "error": traceback.format_exc()})
+`
+
+// middlewareMessage is the request or response in the messages exchanged with the script. The body
+// is a string when it is valid UTF-8 and base64 encoded otherwise.
+type middlewareMessage struct {
+	AgentID      string            `json:"agentId,omitempty"`
+	Method       string            `json:"method,omitempty"`
+	Path         string            `json:"path,omitempty"`
+	Status       int               `json:"status,omitempty"`
+	Headers      map[string]string `json:"headers"`
+	Body         string            `json:"body"`
+	BodyEncoding string            `json:"bodyEncoding,omitempty"`
+}
+
+type middlewareCall struct {
+	ID       int                `json:"id"`
+	Hook     string             `json:"hook"`
+	Request  *middlewareMessage `json:"request"`
+	Response *middlewareMessage `json:"response,omitempty"`
+}
+
+type middlewareResult struct {
+	ID       int                `json:"id"`
+	Request  *middlewareMessage `json:"request"`
+	Response *middlewareMessage `json:"response"`
+	Delay    int                `json:"delay"`
+	Error    string             `json:"error"`
+	// sent once when the script is loaded
+	Ready      bool `json:"ready"`
+	OnRequest  bool `json:"onRequest"`
+	OnResponse bool `json:"onResponse"`
+}
+
+func newMiddlewareMessage(header http.Header, body []byte) *middlewareMessage {
+	msg := &middlewareMessage{Headers: make(map[string]string)}
+	for key := range header {
+		msg.Headers[key] = strings.Join(header.Values(key), ", ")
+	}
+	if utf8.Valid(body) {
+		msg.Body = string(body)
+	} else {
+		msg.Body = base64.StdEncoding.EncodeToString(body)
+		msg.BodyEncoding = "base64"
+	}
+	return msg
+}
+
+func (m *middlewareMessage) header() http.Header {
+	header := make(http.Header)
+	for key, value := range m.Headers {
+		header.Set(key, value)
+	}
+	return header
+}
+
+func (m *middlewareMessage) body() ([]byte, error) {
+	if m.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(m.Body)
+	}
+	return []byte(m.Body), nil
+}
+
+// ScriptMiddleware runs the middleware script declared in the project file. The script is reloaded
+// when it changes.
+type ScriptMiddleware struct {
+	ctx     context.Context
+	logger  logger.Logger
+	command []string
+	script  string
+	harness string
+
+	mu         sync.Mutex
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stdout     *bufio.Reader
+	modTime    time.Time
+	nextID     int
+	onRequest  bool
+	onResponse bool
+}
+
+var _ gravity.Middleware = (*ScriptMiddleware)(nil)
+
+// middlewareCommand returns the interpreter which runs the script
+func middlewareCommand(theproject project.ProjectContext, script string) ([]string, string, error) {
+	switch filepath.Ext(script) {
+	case ".py":
+		python := filepath.Join(theproject.Dir, ".venv", "bin", "python")
+		if runtime.GOOS == "windows" {
+			python = filepath.Join(theproject.Dir, ".venv", "Scripts", "python.exe")
+		}
+		if _, err := os.Stat(python); err != nil {
+			python = "python3"
+			if runtime.GOOS == "windows" {
+				python = "python"
+			}
+		}
+		return []string{python}, pyMiddlewareHarness, nil
+	case ".ts":
+		return []string{"bun"}, jsMiddlewareHarness, nil
+	case ".js", ".mjs", ".cjs":
+		if theproject.Project.Bundler != nil && theproject.Project.Bundler.Runtime == "bunjs" {
+			return []string{"bun"}, jsMiddlewareHarness, nil
+		}
+		return []string{"node"}, jsMiddlewareHarness, nil
+	}
+	return nil, "", fmt.Errorf("the middleware %s must be a JavaScript, TypeScript or Python file", script)
+}
+
+// NewScriptMiddleware returns the middleware which runs the script with the interpreter of the project
+func NewScriptMiddleware(ctx context.Context, logger logger.Logger, theproject project.ProjectContext, script string) (*ScriptMiddleware, error) {
+	command, harness, err := middlewareCommand(theproject, script)
+	if err != nil {
+		return nil, err
+	}
+	ext := ".mjs"
+	if harness == pyMiddlewareHarness {
+		ext = ".py"
+	}
+	tmp, err := os.CreateTemp("", "agentuity-middleware-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+	if _, err := tmp.WriteString(harness); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	m := &ScriptMiddleware{ctx: ctx, logger: logger, command: command, script: script, harness: tmp.Name()}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.start(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return m, nil
+}
+
+// start runs the script and waits until it is loaded
+func (m *ScriptMiddleware) start() error {
+	info, err := os.Stat(m.script)
+	if err != nil {
+		return fmt.Errorf("failed to load the middleware: %w", err)
+	}
+	args := append(append([]string{}, m.command[1:]...), m.harness, m.script)
+	cmd := exec.CommandContext(m.ctx, m.command[0], args...)
+	cmd.Dir = filepath.Dir(m.script)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run the middleware with %s: %w", m.command[0], err)
+	}
+	m.cmd = cmd
+	m.stdin = stdin
+	m.stdout = bufio.NewReader(stdout)
+	m.modTime = info.ModTime()
+	var ready middlewareResult
+	if err := m.read(&ready); err != nil || !ready.Ready {
+		m.stop()
+		if err == nil {
+			err = errors.New("unexpected message")
+		}
+		return fmt.Errorf("failed to load the middleware %s: %w", m.script, err)
+	}
+	m.onRequest = ready.OnRequest
+	m.onResponse = ready.OnResponse
+	m.logger.Debug("loaded middleware %s (onRequest: %t, onResponse: %t)", m.script, m.onRequest, m.onResponse)
+	return nil
+}
+
+func (m *ScriptMiddleware) stop() {
+	if m.cmd == nil {
+		return
+	}
+	m.stdin.Close()
+	m.cmd.Process.Kill()
+	m.cmd.Wait()
+	m.cmd = nil
+}
+
+func (m *ScriptMiddleware) read(result *middlewareResult) error {
+	line, err := m.stdout.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("the middleware exited: %w", err)
+	}
+	return json.Unmarshal(line, result)
+}
+
+// call sends the message to the script, restarting it first when it changed or exited
+func (m *ScriptMiddleware) call(msg middlewareCall) (*middlewareResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if info, err := os.Stat(m.script); m.cmd == nil || (err == nil && !info.ModTime().Equal(m.modTime)) {
+		m.stop()
+		if err := m.start(); err != nil {
+			return nil, err
+		}
+		m.logger.Info("reloaded middleware %s", m.script)
+	}
+	m.nextID++
+	msg.ID = m.nextID
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.stdin.Write(append(buf, '\n')); err != nil {
+		m.stop()
+		return nil, fmt.Errorf("the middleware exited: %w", err)
+	}
+	var result middlewareResult
+	if err := m.read(&result); err != nil {
+		m.stop()
+		return nil, err
+	}
+	if result.ID != msg.ID {
+		m.stop()
+		return nil, fmt.Errorf("the middleware responded to message %d instead of %d", result.ID, msg.ID)
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	return &result, nil
+}
+
+func (m *ScriptMiddleware) hooks() (bool, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.onRequest, m.onResponse
+}
+
+func newMiddlewareRequest(req *gravity.MiddlewareRequest) *middlewareMessage {
+	msg := newMiddlewareMessage(req.Header, req.Body)
+	msg.AgentID = req.AgentID
+	msg.Method = req.Method
+	msg.Path = req.Path
+	return msg
+}
+
+// OnRequest passes the request to the onRequest hook of the script which can change it, delay it
+// or respond in place of the agent
+func (m *ScriptMiddleware) OnRequest(req *gravity.MiddlewareRequest) (*gravity.MiddlewareResponse, error) {
+	if onRequest, _ := m.hooks(); !onRequest {
+		return nil, nil
+	}
+	result, err := m.call(middlewareCall{Hook: "request", Request: newMiddlewareRequest(req)})
+	if err != nil {
+		return nil, err
+	}
+	if result.Delay > 0 {
+		time.Sleep(time.Duration(result.Delay) * time.Millisecond)
+	}
+	if result.Request != nil {
+		body, err := result.Request.body()
+		if err != nil {
+			return nil, fmt.Errorf("invalid request body from the middleware: %w", err)
+		}
+		req.Header = result.Request.header()
+		req.Body = body
+	}
+	if result.Response == nil {
+		return nil, nil
+	}
+	body, err := result.Response.body()
+	if err != nil {
+		return nil, fmt.Errorf("invalid response body from the middleware: %w", err)
+	}
+	return &gravity.MiddlewareResponse{Status: result.Response.Status, Header: result.Response.header(), Body: body}, nil
+}
+
+// OnResponse passes the response of the agent to the onResponse hook of the script which can change it
+func (m *ScriptMiddleware) OnResponse(req *gravity.MiddlewareRequest, resp *gravity.MiddlewareResponse) error {
+	msg := newMiddlewareMessage(resp.Header, resp.Body)
+	msg.Status = resp.Status
+	result, err := m.call(middlewareCall{Hook: "response", Request: newMiddlewareRequest(req), Response: msg})
+	if err != nil {
+		return err
+	}
+	if result.Response == nil {
+		return nil
+	}
+	body, err := result.Response.body()
+	if err != nil {
+		return fmt.Errorf("invalid response body from the middleware: %w", err)
+	}
+	if result.Response.Status > 0 {
+		resp.Status = result.Response.Status
+	}
+	resp.Header = result.Response.header()
+	resp.Body = body
+	return nil
+}
+
+// HandlesResponses returns true when the script has an onResponse hook. It is checked for each
+// request since the hook can be added while the development server is running.
+func (m *ScriptMiddleware) HandlesResponses() bool {
+	_, onResponse := m.hooks()
+	return onResponse
+}
+
+// Close stops the script
+func (m *ScriptMiddleware) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stop()
+	return os.Remove(m.harness)
+}
@@ -0,0 +1,106 @@
+package dev
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/cli/internal/gravity"
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/go-common/logger"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSMiddleware = `export function onRequest(req) {
+  console.log('seen', req.path);
+  if (req.agentId === 'agent_blocked') {
+    return { response: { status: 403, headers: { 'Content-Type': 'text/plain' }, body: 'blocked' } };
+  }
+  req.headers['Authorization'] = 'Bearer test';
+  req.body = JSON.stringify({ ...JSON.parse(req.body), injected: true });
+}
+
+export function onResponse(req, res) {
+  res.headers['X-Middleware'] = req.agentId;
+  res.body = res.body.toUpperCase();
+  return res;
+}
+`
+
+const testPyMiddleware = `def on_request(req):
+    print("seen", req["path"])
+    req["headers"]["Authorization"] = "Bearer test"
+    req["body"] = req["body"].replace("}", ', "injected": true}')
+`
+
+func testMiddleware(t *testing.T, interpreter string, filename string, script string) *ScriptMiddleware {
+	if _, err := exec.LookPath(interpreter); err != nil {
+		t.Skipf("%s is not installed", interpreter)
+	}
+	dir := t.TempDir()
+	fn := filepath.Join(dir, filename)
+	require.NoError(t, os.WriteFile(fn, []byte(script), 0644))
+	theproject := project.ProjectContext{Dir: dir, Project: &cproject.Project{Bundler: &cproject.Bundler{Runtime: "nodejs"}}}
+	m, err := NewScriptMiddleware(context.Background(), logger.NewTestLogger(), theproject, fn)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestScriptMiddlewareJS(t *testing.T) {
+	m := testMiddleware(t, "node", "middleware.js", testJSMiddleware)
+	assert.True(t, m.HandlesResponses())
+
+	req := &gravity.MiddlewareRequest{AgentID: "agent_1", Method: "POST", Path: "/agent_1", Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"hello":"world"}`)}
+	resp, err := m.OnRequest(req)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, "Bearer test", req.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"hello":"world","injected":true}`, string(req.Body))
+
+	agentResp := &gravity.MiddlewareResponse{Status: 200, Header: http.Header{}, Body: []byte("hi")}
+	require.NoError(t, m.OnResponse(req, agentResp))
+	assert.Equal(t, 200, agentResp.Status)
+	assert.Equal(t, "HI", string(agentResp.Body))
+	assert.Equal(t, "agent_1", agentResp.Header.Get("X-Middleware"))
+
+	resp, err = m.OnRequest(&gravity.MiddlewareRequest{AgentID: "agent_blocked", Method: "POST", Path: "/agent_blocked", Header: http.Header{}, Body: []byte{0xff}})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, 403, resp.Status)
+	assert.Equal(t, "blocked", string(resp.Body))
+}
+
+func TestScriptMiddlewarePython(t *testing.T) {
+	m := testMiddleware(t, "python3", "middleware.py", testPyMiddleware)
+	assert.False(t, m.HandlesResponses())
+
+	req := &gravity.MiddlewareRequest{AgentID: "agent_1", Method: "POST", Path: "/agent_1", Header: http.Header{}, Body: []byte(`{"hello":"world"}`)}
+	resp, err := m.OnRequest(req)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, "Bearer test", req.Header.Get("Authorization"))
+	assert.JSONEq(t, `{"hello":"world","injected":true}`, string(req.Body))
+
+	// the script is reloaded when it changes
+	require.NoError(t, os.WriteFile(m.script, []byte("def on_request(req):\n    raise ValueError('broken')\n"), 0644))
+	future := m.modTime.Add(1e9)
+	require.NoError(t, os.Chtimes(m.script, future, future))
+	_, err = m.OnRequest(req)
+	assert.ErrorContains(t, err, "broken")
+}
+
+func TestMiddlewareCommand(t *testing.T) {
+	theproject := project.ProjectContext{Dir: t.TempDir(), Project: &cproject.Project{Bundler: &cproject.Bundler{Runtime: "bunjs"}}}
+	command, _, err := middlewareCommand(theproject, "middleware.js")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bun"}, command)
+	_, _, err = middlewareCommand(theproject, "middleware.rb")
+	assert.Error(t, err)
+}
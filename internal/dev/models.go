@@ -0,0 +1,263 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+)
+
+// LocalRuntime is a local model runtime with an OpenAI compatible API
+type LocalRuntime struct {
+	Name string
+	// Endpoint is the base URL of the OpenAI compatible API such as http://127.0.0.1:11434/v1
+	Endpoint string
+	// Models are the models available in the runtime
+	Models []string
+}
+
+// LocalRuntimes are the local model runtimes detected by DetectLocalRuntime, in order
+var LocalRuntimes = []LocalRuntime{
+	{Name: "Ollama", Endpoint: "http://127.0.0.1:11434/v1"},
+	{Name: "LM Studio", Endpoint: "http://127.0.0.1:1234/v1"},
+}
+
+// LocalModelProviders are the providers of the AI gateway whose API is OpenAI compatible, which
+// are the only ones the local models can serve
+var LocalModelProviders = []string{"openai", "groq", "deepseek", "grok", "mistral", "perplexity-ai"}
+
+// localRuntimeTimeout is how long a local runtime has to list its models when it's probed
+const localRuntimeTimeout = 2 * time.Second
+
+// ProbeLocalRuntime returns the runtime with the models it lists or an error if it doesn't respond
+func ProbeLocalRuntime(ctx context.Context, runtime LocalRuntime) (*LocalRuntime, error) {
+	ctx, cancel := context.WithTimeout(ctx, localRuntimeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(runtime.Endpoint, "/")+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s isn't running at %s: %w", runtime.Name, runtime.Endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s at %s responded with %s", runtime.Name, runtime.Endpoint, resp.Status)
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("%s at %s didn't list its models: %w", runtime.Name, runtime.Endpoint, err)
+	}
+	runtime.Models = nil
+	for _, m := range list.Data {
+		runtime.Models = append(runtime.Models, m.ID)
+	}
+	sort.Strings(runtime.Models)
+	return &runtime, nil
+}
+
+// DetectLocalRuntime returns the first of the runtimes which is running on this machine
+func DetectLocalRuntime(ctx context.Context, runtimes []LocalRuntime) (*LocalRuntime, error) {
+	var names []string
+	for _, runtime := range runtimes {
+		if found, err := ProbeLocalRuntime(ctx, runtime); err == nil {
+			return found, nil
+		}
+		names = append(names, runtime.Name)
+	}
+	return nil, fmt.Errorf("no local model runtime found, tried %s", strings.Join(names, " and "))
+}
+
+// LocalModels serves the calls the agents make to the AI gateway with the models of a local runtime
+type LocalModels struct {
+	Runtime LocalRuntime
+	// Default is the local model used in place of the cloud models which aren't in Models
+	Default string
+	// Models maps the names of the cloud models, optionally prefixed by the provider such as
+	// openai/gpt-4o, to the local models
+	Models map[string]string
+}
+
+// Model returns the local model to use in place of the model of the provider. The model is used as
+// is when it isn't mapped and there is no default.
+func (m *LocalModels) Model(provider string, model string) string {
+	if local, ok := m.Models[provider+"/"+model]; ok {
+		return local
+	}
+	if local, ok := m.Models[model]; ok {
+		return local
+	}
+	if m.Default != "" {
+		return m.Default
+	}
+	return model
+}
+
+// Missing returns the sorted local models of the mapping which the runtime doesn't have. Ollama
+// lists the models with their tag so a model without a tag matches its latest tag.
+func (m *LocalModels) Missing() []string {
+	if len(m.Runtime.Models) == 0 {
+		return nil
+	}
+	var missing []string
+	for _, model := range append(slices.Collect(maps.Values(m.Models)), m.Default) {
+		if model == "" || slices.Contains(missing, model) {
+			continue
+		}
+		if !slices.Contains(m.Runtime.Models, model) && !slices.Contains(m.Runtime.Models, model+":latest") {
+			missing = append(missing, model)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// localModelPath returns the provider of a call to the AI gateway such as /gateway/openai/chat/completions
+// and the path of the call in the OpenAI API, or false if the call isn't to a provider the local
+// models can serve. The path of the Groq SDK starts with /openai/v1 and the others with /v1 or nothing.
+func localModelPath(p string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(p, "/gateway/")
+	if !ok {
+		return "", "", false
+	}
+	provider, rest, _ := strings.Cut(rest, "/")
+	if !slices.Contains(LocalModelProviders, provider) {
+		return "", "", false
+	}
+	rest = "/" + rest
+	if provider == "groq" {
+		rest = strings.TrimPrefix(rest, "/openai")
+	}
+	if trimmed, ok := strings.CutPrefix(rest, "/v1/"); ok {
+		rest = "/" + trimmed
+	}
+	return provider, rest, true
+}
+
+// Handler returns the handler which sends the calls to the AI gateway for the providers the local
+// models can serve to the runtime with the mapped model and the other calls to next
+func (m *LocalModels) Handler(logger logger.Logger, next http.Handler) (http.Handler, error) {
+	target, err := url.Parse(m.Runtime.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local model endpoint %s: %w", m.Runtime.Endpoint, err)
+	}
+	local := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			// the runtime doesn't need the SDK key of the project
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Del("X-Api-Key")
+		},
+		FlushInterval: -1,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider, p, ok := localModelPath(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = m.rewriteModel(logger, provider, body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Del("Content-Length")
+		}
+		r.URL.Path = p
+		r.URL.RawPath = ""
+		local.ServeHTTP(w, r)
+	}), nil
+}
+
+// rewriteModel replaces the model in the JSON body of a call, leaving any other body as is
+func (m *LocalModels) rewriteModel(logger logger.Logger, provider string, body []byte) []byte {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return body
+	}
+	var model string
+	if err := json.Unmarshal(payload["model"], &model); err != nil || model == "" {
+		return body
+	}
+	local := m.Model(provider, model)
+	logger.Debug("local models: serving %s/%s with %s from %s", provider, model, local, m.Runtime.Name)
+	if local == model {
+		return body
+	}
+	payload["model"], _ = json.Marshal(local)
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return buf
+}
+
+// LocalModelsProxy serves the calls to the AI gateway with the local models and forwards the
+// other calls to the cloud service
+type LocalModelsProxy struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// URL returns the URL to use in place of the URL of the cloud service
+func (p *LocalModelsProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Close stops the proxy
+func (p *LocalModelsProxy) Close() error {
+	return p.server.Close()
+}
+
+// StartLocalModelsProxy starts a proxy on a random local port which serves the calls to the AI
+// gateway with the local models and forwards the other calls to target
+func StartLocalModelsProxy(logger logger.Logger, models *LocalModels, target string) (*LocalModelsProxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+		},
+		FlushInterval: -1,
+	}
+	handler, err := models.Handler(logger, proxy)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("local models proxy for %s stopped: %s", target, err)
+		}
+	}()
+	return &LocalModelsProxy{server: server, listener: listener}, nil
+}
@@ -0,0 +1,95 @@
+package dev
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLocalRuntime(t *testing.T) {
+	runtime := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"object":"list","data":[{"id":"qwen2.5:latest"},{"id":"llama3.1:8b"}]}`)
+	}))
+	defer runtime.Close()
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	found, err := DetectLocalRuntime(context.Background(), []LocalRuntime{{Name: "Stopped", Endpoint: stopped.URL + "/v1"}, {Name: "Ollama", Endpoint: runtime.URL + "/v1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Ollama", found.Name)
+	assert.Equal(t, []string{"llama3.1:8b", "qwen2.5:latest"}, found.Models)
+
+	_, err = DetectLocalRuntime(context.Background(), []LocalRuntime{{Name: "Stopped", Endpoint: stopped.URL + "/v1"}, {Name: "Other", Endpoint: runtime.URL}})
+	assert.ErrorContains(t, err, "tried Stopped and Other")
+}
+
+func TestLocalModels(t *testing.T) {
+	models := &LocalModels{
+		Runtime: LocalRuntime{Name: "Ollama", Models: []string{"llama3.1:8b", "qwen2.5:latest"}},
+		Models:  map[string]string{"gpt-4o": "qwen2.5", "groq/gpt-4o": "llama3.1:8b", "gpt-4o-mini": "phi3"},
+	}
+	assert.Equal(t, "qwen2.5", models.Model("openai", "gpt-4o"))
+	assert.Equal(t, "llama3.1:8b", models.Model("groq", "gpt-4o"))
+	assert.Equal(t, "o3", models.Model("openai", "o3"))
+	models.Default = "gemma3"
+	assert.Equal(t, "gemma3", models.Model("openai", "o3"))
+	assert.Equal(t, []string{"gemma3", "phi3"}, models.Missing())
+
+	for path, expected := range map[string]string{
+		"/gateway/openai/chat/completions":         "/chat/completions",
+		"/gateway/openai/v1/chat/completions":      "/chat/completions",
+		"/gateway/groq/openai/v1/chat/completions": "/chat/completions",
+		"/gateway/perplexity-ai/chat/completions":  "/chat/completions",
+		"/gateway/anthropic/v1/messages":           "",
+		"/kv/get":                                  "",
+	} {
+		_, p, ok := localModelPath(path)
+		assert.Equal(t, expected != "", ok, path)
+		assert.Equal(t, expected, p, path)
+	}
+}
+
+func TestLocalModelsProxy(t *testing.T) {
+	runtime := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, "local "+r.URL.Path+" "+body["model"].(string)+" "+r.Header.Get("Authorization"))
+	}))
+	defer runtime.Close()
+	cloud := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "cloud "+r.URL.Path)
+	}))
+	defer cloud.Close()
+
+	models := &LocalModels{Runtime: LocalRuntime{Name: "Ollama", Endpoint: runtime.URL + "/v1"}, Models: map[string]string{"gpt-4o": "llama3.1"}}
+	proxy, err := StartLocalModelsProxy(logger.NewTestLogger(), models, cloud.URL)
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	call := func(path string, body string) string {
+		req, err := http.NewRequest(http.MethodPost, proxy.URL()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer sdk_key")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, _ := io.ReadAll(resp.Body)
+		return string(buf)
+	}
+	assert.Equal(t, "local /v1/chat/completions llama3.1 ", call("/gateway/openai/chat/completions", `{"model":"gpt-4o","messages":[]}`))
+	assert.Equal(t, "local /v1/chat/completions o3 ", call("/gateway/groq/openai/v1/chat/completions", `{"model":"o3"}`))
+	assert.Equal(t, "cloud /gateway/anthropic/v1/messages", call("/gateway/anthropic/v1/messages", `{"model":"claude-sonnet-4"}`))
+	assert.Equal(t, "cloud /kv/get", call("/kv/get", ""))
+}
@@ -0,0 +1,244 @@
+package dev
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agentuity/cli/internal/ignore"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// RemoteSyncBatchSize is the maximum number of bytes of file content sent to the sandbox per request
+const RemoteSyncBatchSize = 4 * 1024 * 1024
+
+// Sandbox is an ephemeral cloud environment which runs the development server of a project
+type Sandbox struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SandboxLog is a line of the output of the development server running in a sandbox
+type SandboxLog struct {
+	Timestamp time.Time `json:"timestamp"`
+	Severity  string    `json:"severity"`
+	Body      string    `json:"body"`
+}
+
+// SyncFile is a file sent to the sandbox
+type SyncFile struct {
+	Path    string      `json:"path"`
+	Mode    fs.FileMode `json:"mode"`
+	Content []byte      `json:"content"`
+}
+
+type syncRequest struct {
+	Files   []SyncFile `json:"files"`
+	Removed []string   `json:"removed,omitempty"`
+}
+
+type sandboxLogs struct {
+	Logs   []SandboxLog `json:"logs"`
+	Cursor string       `json:"cursor"`
+}
+
+// CreateSandbox creates a sandbox for the project which is removed with DeleteSandbox or when it
+// has been idle for a while
+func CreateSandbox(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) (*Sandbox, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[Sandbox]
+	if err := client.Do("POST", fmt.Sprintf("/cli/devmode/sandbox/%s", url.PathEscape(projectId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error creating the sandbox: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error creating the sandbox: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// DeleteSandbox stops and removes the sandbox
+func DeleteSandbox(ctx context.Context, logger logger.Logger, baseUrl string, token string, sandboxId string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[any]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/devmode/sandbox/%s", url.PathEscape(sandboxId)), nil, &resp); err != nil {
+		return fmt.Errorf("error deleting the sandbox: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error deleting the sandbox: %s", resp.Message)
+	}
+	return nil
+}
+
+// SandboxLogs returns the output of the sandbox after the cursor and the cursor to use for the next call
+func SandboxLogs(ctx context.Context, logger logger.Logger, baseUrl string, token string, sandboxId string, cursor string) ([]SandboxLog, string, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[sandboxLogs]
+	path := fmt.Sprintf("/cli/devmode/sandbox/%s/logs", url.PathEscape(sandboxId))
+	if cursor != "" {
+		path += "?cursor=" + url.QueryEscape(cursor)
+	}
+	if err := client.Do("GET", path, nil, &resp); err != nil {
+		return nil, cursor, fmt.Errorf("error fetching the sandbox logs: %w", err)
+	}
+	if !resp.Success {
+		return nil, cursor, fmt.Errorf("error fetching the sandbox logs: %s", resp.Message)
+	}
+	if resp.Data.Cursor == "" {
+		return resp.Data.Logs, cursor, nil
+	}
+	return resp.Data.Logs, resp.Data.Cursor, nil
+}
+
+type syncEntry struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// RemoteSync tracks the files of the project which were sent to the sandbox so that only the files
+// which changed since are sent again
+type RemoteSync struct {
+	dir   string
+	rules *ignore.Rules
+	files map[string]syncEntry
+	next  map[string]syncEntry
+}
+
+// NewRemoteSync returns a sync of the project directory which skips the files matching the rules
+func NewRemoteSync(dir string, rules *ignore.Rules) *RemoteSync {
+	return &RemoteSync{dir: dir, rules: rules, files: make(map[string]syncEntry)}
+}
+
+// Scan returns the relative paths of the files which were changed and removed since the last
+// commit. A file whose size or modification time changed is only reported when its content did.
+// The changes are reported again by the next scan until they're committed.
+func (s *RemoteSync) Scan() ([]string, []string, error) {
+	var changed []string
+	next := make(map[string]syncEntry)
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if s.rules.Ignore(rel, fi) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		prev, ok := s.files[rel]
+		if ok && prev.size == fi.Size() && prev.modTime.Equal(fi.ModTime()) {
+			next[rel] = prev
+			return nil
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		next[rel] = syncEntry{size: fi.Size(), modTime: fi.ModTime(), hash: hash}
+		if !ok || prev.hash != hash {
+			changed = append(changed, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var removed []string
+	for rel := range s.files {
+		if _, ok := next[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	s.next = next
+	return changed, removed, nil
+}
+
+// Commit records the changes of the last scan as sent to the sandbox
+func (s *RemoteSync) Commit() {
+	if s.next != nil {
+		s.files = s.next
+		s.next = nil
+	}
+}
+
+func hashFile(filename string) (string, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// syncBatches splits the changed files into batches of about RemoteSyncBatchSize bytes of content.
+// The removed files are sent with the first batch.
+func syncBatches(dir string, changed []string, removed []string) ([]syncRequest, error) {
+	batches := []syncRequest{{Removed: removed}}
+	var size int
+	for _, rel := range changed {
+		filename := filepath.Join(dir, filepath.FromSlash(rel))
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		last := &batches[len(batches)-1]
+		if size > 0 && size+len(buf) > RemoteSyncBatchSize {
+			batches = append(batches, syncRequest{})
+			last = &batches[len(batches)-1]
+			size = 0
+		}
+		last.Files = append(last.Files, SyncFile{Path: rel, Mode: fi.Mode().Perm(), Content: buf})
+		size += len(buf)
+	}
+	return batches, nil
+}
+
+// SyncSandbox sends the changed files and the removed paths to the sandbox, which restarts the
+// development server once the files are applied
+func SyncSandbox(ctx context.Context, logger logger.Logger, baseUrl string, token string, sandboxId string, dir string, changed []string, removed []string) error {
+	batches, err := syncBatches(dir, changed, removed)
+	if err != nil {
+		return fmt.Errorf("error reading the changed files: %w", err)
+	}
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	for _, batch := range batches {
+		var resp Response[any]
+		if err := client.Do("PUT", fmt.Sprintf("/cli/devmode/sandbox/%s/files", url.PathEscape(sandboxId)), batch, &resp); err != nil {
+			return fmt.Errorf("error syncing the files to the sandbox: %w", err)
+		}
+		if !resp.Success {
+			return fmt.Errorf("error syncing the files to the sandbox: %s", resp.Message)
+		}
+	}
+	return nil
+}
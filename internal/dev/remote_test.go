@@ -0,0 +1,83 @@
+package dev
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/ignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSyncScan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "agents", "one"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "dep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "one", "index.ts"), []byte("one"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "dep", "index.js"), []byte("dep"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1"), 0644))
+
+	rules := ignore.Empty()
+	rules.AddDefaults()
+	sync := NewRemoteSync(dir, rules)
+
+	changed, removed, err := sync.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"package.json", "src/agents/one/index.ts"}, changed)
+	assert.Empty(t, removed)
+
+	// the changes are reported again until they're committed
+	changed, _, err = sync.Scan()
+	require.NoError(t, err)
+	assert.Len(t, changed, 2)
+	sync.Commit()
+	changed, removed, err = sync.Scan()
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, removed)
+
+	// touching a file without changing it isn't a change
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "package.json"), later, later))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "one", "index.ts"), []byte("one!"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "one", "util.ts"), []byte("util"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "package.json")))
+	changed, removed, err = sync.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/agents/one/index.ts", "src/agents/one/util.ts"}, changed)
+	assert.Equal(t, []string{"package.json"}, removed)
+	sync.Commit()
+	changed, removed, err = sync.Scan()
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, removed)
+}
+
+func TestSyncBatches(t *testing.T) {
+	dir := t.TempDir()
+	big := strings.Repeat("x", RemoteSyncBatchSize-1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.bin"), []byte(big), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.ts"), []byte("c"), 0644))
+
+	batches, err := syncBatches(dir, []string{"a.ts", "big.bin", "c.ts"}, []string{"old.ts"})
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"old.ts"}, batches[0].Removed)
+	require.Len(t, batches[0].Files, 2)
+	assert.Equal(t, SyncFile{Path: "a.ts", Mode: 0644, Content: []byte("a")}, batches[0].Files[0])
+	assert.Equal(t, "big.bin", batches[0].Files[1].Path)
+	assert.Equal(t, os.FileMode(0600), batches[0].Files[1].Mode)
+	assert.Empty(t, batches[1].Removed)
+	require.Len(t, batches[1].Files, 1)
+	assert.Equal(t, "c.ts", batches[1].Files[0].Path)
+
+	// only the removed files
+	batches, err = syncBatches(dir, nil, []string{"old.ts"})
+	require.NoError(t, err)
+	assert.Equal(t, []syncRequest{{Removed: []string{"old.ts"}}}, batches)
+}
@@ -64,7 +64,7 @@ func label(s string) string {
 	return labelStyle.Render(tui.PadRight(s, 10, " "))
 }
 
-func (s *Server) GenerateInfoBox(publicUrl string, appUrl string, devModeUrl string) string {
+func (s *Server) GenerateInfoBox(publicUrl string, appUrl string, devModeUrl string, networkUrl string, httpsUrl string) string {
 	var devmodeBox = lipgloss.NewStyle().
 		Width(100).
 		Border(lipgloss.NormalBorder()).
@@ -89,6 +89,12 @@ func (s *Server) GenerateInfoBox(publicUrl string, appUrl string, devModeUrl str
 		label("Local"), tui.Link("%s", devModeUrl),
 		label("Public"), url,
 	)
+	if networkUrl != "" {
+		content += fmt.Sprintf("\n%s  %s", label("Network"), tui.Link("%s", networkUrl))
+	}
+	if httpsUrl != "" {
+		content += fmt.Sprintf("\n%s  %s", label("HTTPS"), tui.Link("%s", httpsUrl))
+	}
 	return devmodeBox.Render(content)
 }
 
@@ -0,0 +1,321 @@
+package dev
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agentuity/cli/internal/util"
+	_ "modernc.org/sqlite"
+)
+
+// SessionsDir is the directory in the project where the dev mode sessions are stored
+const SessionsDir = ".agentuity/sessions"
+
+// DefaultSession is the name of the session used when none is provided
+const DefaultSession = "default"
+
+var sessionNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateSessionName returns an error if the name can't be used as a session name
+func ValidateSessionName(name string) error {
+	if !sessionNameRegex.MatchString(name) || len(name) > 64 {
+		return fmt.Errorf("invalid session name %q (must be at most 64 letters, digits, '.', '_' or '-')", name)
+	}
+	return nil
+}
+
+// Message is the body of a request or response. Bodies which aren't valid UTF-8 are base64 encoded.
+type Message struct {
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+	Encoding    string `json:"encoding,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	// Redacted is set when sensitive data was replaced in the body so it can't be replayed as is
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// NewMessage returns the message for the body
+func NewMessage(contentType string, body []byte, truncated bool) Message {
+	msg := Message{ContentType: contentType, Truncated: truncated}
+	if utf8.Valid(body) {
+		msg.Body = string(body)
+	} else {
+		msg.Body = base64.StdEncoding.EncodeToString(body)
+		msg.Encoding = "base64"
+	}
+	return msg
+}
+
+// Redact returns the message with the sensitive data replaced. Base64 encoded bodies are kept as is.
+func (m Message) Redact(redactor *util.Redactor) Message {
+	if m.Encoding != "" {
+		return m
+	}
+	if body := redactor.Redact(m.Body); body != m.Body {
+		m.Body = body
+		m.Redacted = true
+	}
+	return m
+}
+
+// Bytes returns the decoded body of the message
+func (m Message) Bytes() ([]byte, error) {
+	if m.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(m.Body)
+	}
+	return []byte(m.Body), nil
+}
+
+// Turn is a single request to an agent and the response of the agent
+type Turn struct {
+	At         time.Time `json:"at"`
+	AgentID    string    `json:"agentId"`
+	AgentName  string    `json:"agentName,omitempty"`
+	TraceID    string    `json:"traceId,omitempty"`
+	Trigger    string    `json:"trigger,omitempty"`
+	Status     int       `json:"status"`
+	DurationMs int64     `json:"durationMs"`
+	Request    Message   `json:"request"`
+	Response   Message   `json:"response"`
+}
+
+// Session is a conversation with the agents of the project in dev mode
+type Session struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Agents    []string  `json:"agents"`
+	Turns     []Turn    `json:"turns"`
+}
+
+// SessionsDB is the SQLite database of the sessions in SessionsDir
+const SessionsDB = "sessions.db"
+
+const sessionsSchema = `
+CREATE TABLE IF NOT EXISTS turns (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session TEXT NOT NULL,
+	at TEXT NOT NULL,
+	turn TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS turns_session ON turns (session, id);
+`
+
+// SessionStore persists the dev mode sessions of a project in a SQLite database with a row per
+// turn, so that turns are appended without rewriting the session and the dev server and the dev
+// sessions commands can use the store at the same time. The driver is pure Go so the CLI is still
+// built without cgo.
+type SessionStore struct {
+	filename string
+	db       *sql.DB
+	mu       sync.Mutex
+}
+
+// NewSessionStore returns the session store for the project in dir. The database is only created
+// when a turn is appended.
+func NewSessionStore(dir string) *SessionStore {
+	return &SessionStore{filename: filepath.Join(dir, SessionsDir, SessionsDB)}
+}
+
+// open returns the database, or nil when it doesn't exist and create is false
+func (s *SessionStore) open(create bool) (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db != nil {
+		return s.db, nil
+	}
+	if !create && !util.Exists(s.filename) {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.filename), 0755); err != nil {
+		return nil, err
+	}
+	// the dev server and the dev sessions commands are separate processes so the writes wait for
+	// each other instead of failing while the database is locked
+	dsn := "file:" + filepath.ToSlash(s.filename) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the sessions database: %w", err)
+	}
+	if _, err := db.Exec(sessionsSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the sessions database: %w", err)
+	}
+	s.db = db
+	return db, nil
+}
+
+// Close closes the database of the store
+func (s *SessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// Append adds the turn to the session, creating the session if it doesn't exist
+func (s *SessionStore) Append(name string, turn Turn) error {
+	if err := ValidateSessionName(name); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(turn)
+	if err != nil {
+		return err
+	}
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO turns (session, at, turn) VALUES (?, ?, ?)", name, turn.At.UTC().Format(time.RFC3339Nano), string(buf))
+	return err
+}
+
+// Load returns the session by name or nil if it doesn't exist
+func (s *SessionStore) Load(name string) (*Session, error) {
+	if err := ValidateSessionName(name); err != nil {
+		return nil, err
+	}
+	db, err := s.open(false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT turn FROM turns WHERE session = ? ORDER BY id", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	session := &Session{Name: name, Agents: []string{}, Turns: []Turn{}}
+	for rows.Next() {
+		var buf string
+		if err := rows.Scan(&buf); err != nil {
+			return nil, err
+		}
+		var turn Turn
+		if err := json.Unmarshal([]byte(buf), &turn); err != nil {
+			return nil, fmt.Errorf("failed to parse a turn of session %s: %w", name, err)
+		}
+		session.Turns = append(session.Turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(session.Turns) == 0 {
+		return nil, nil
+	}
+	for _, turn := range session.Turns {
+		if session.CreatedAt.IsZero() || turn.At.Before(session.CreatedAt) {
+			session.CreatedAt = turn.At
+		}
+		if turn.At.After(session.UpdatedAt) {
+			session.UpdatedAt = turn.At
+		}
+		agent := turn.AgentName
+		if agent == "" {
+			agent = turn.AgentID
+		}
+		if !slices.Contains(session.Agents, agent) {
+			session.Agents = append(session.Agents, agent)
+		}
+	}
+	return session, nil
+}
+
+// names returns the names of the sessions in the store
+func (s *SessionStore) names() ([]string, error) {
+	db, err := s.open(false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT DISTINCT session FROM turns ORDER BY session")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// List returns the sessions in the store with the most recently updated first
+func (s *SessionStore) List() ([]*Session, error) {
+	names, err := s.names()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(names))
+	for _, name := range names {
+		if ValidateSessionName(name) != nil {
+			continue
+		}
+		session, err := s.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Clear removes the session by name or all the sessions if name is empty and returns the number of
+// sessions removed
+func (s *SessionStore) Clear(name string) (int, error) {
+	if name != "" {
+		if err := ValidateSessionName(name); err != nil {
+			return 0, err
+		}
+	}
+	names, err := s.names()
+	if err != nil || len(names) == 0 {
+		return 0, err
+	}
+	db, err := s.open(false)
+	if err != nil {
+		return 0, err
+	}
+	if name == "" {
+		if _, err := db.Exec("DELETE FROM turns"); err != nil {
+			return 0, err
+		}
+		return len(names), nil
+	}
+	res, err := db.Exec("DELETE FROM turns WHERE session = ?", name)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// Export writes the session as indented JSON
+func (s *Session) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,129 @@
+package dev
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSessionStore(dir)
+	defer store.Close()
+
+	session, err := store.Load("default")
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, store.Append("default", Turn{At: started, AgentID: "agent_1", AgentName: "hello", Status: 200,
+		Request: NewMessage("text/plain", []byte("hi"), false), Response: NewMessage("text/plain", []byte("hello!"), false)}))
+	assert.NoError(t, store.Append("default", Turn{At: started.Add(time.Minute), AgentID: "agent_2", Status: 200,
+		Request: NewMessage("application/octet-stream", []byte{0xff, 0x00}, false), Response: NewMessage("application/json", []byte(`{"ok":true}`), false)}))
+	assert.NoError(t, store.Append("bug-123", Turn{At: started.Add(time.Hour), AgentID: "agent_1", AgentName: "hello", Status: 500}))
+
+	// a new store over the same directory sees the turns from before the restart
+	assert.NoError(t, store.Close())
+	store = NewSessionStore(dir)
+	session, err = store.Load("default")
+	assert.NoError(t, err)
+	assert.Len(t, session.Turns, 2)
+	assert.Equal(t, started, session.CreatedAt)
+	assert.Equal(t, started.Add(time.Minute), session.UpdatedAt)
+	assert.Equal(t, []string{"hello", "agent_2"}, session.Agents)
+	assert.Equal(t, "base64", session.Turns[1].Request.Encoding)
+	body, err := session.Turns[1].Request.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0x00}, body)
+
+	sessions, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "bug-123", sessions[0].Name)
+	assert.Equal(t, "default", sessions[1].Name)
+
+	var buf bytes.Buffer
+	assert.NoError(t, session.Export(&buf))
+	var exported Session
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, "default", exported.Name)
+	assert.Equal(t, "hello!", exported.Turns[0].Response.Body)
+
+	count, err := store.Clear("bug-123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = store.Clear("")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	sessions, err = store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSessionStoreConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	// the dev server and the dev sessions commands each have their own store over the database
+	stores := []*SessionStore{NewSessionStore(dir), NewSessionStore(dir)}
+	for _, store := range stores {
+		t.Cleanup(func() { store.Close() })
+	}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, stores[i%2].Append("default", Turn{AgentID: fmt.Sprintf("agent_%d", i), Status: 200}))
+		}()
+	}
+	wg.Wait()
+	session, err := stores[0].Load("default")
+	require.NoError(t, err)
+	assert.Len(t, session.Turns, 20)
+	assert.FileExists(t, filepath.Join(dir, SessionsDir, SessionsDB))
+}
+
+func TestSessionStoreReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSessionStore(dir)
+	defer store.Close()
+	sessions, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+	count, err := store.Clear("")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	// reading the sessions doesn't create the database
+	assert.NoDirExists(t, filepath.Join(dir, SessionsDir))
+}
+
+func TestValidateSessionName(t *testing.T) {
+	assert.NoError(t, ValidateSessionName("default"))
+	assert.NoError(t, ValidateSessionName("bug-123_v2.1"))
+	assert.Error(t, ValidateSessionName(""))
+	assert.Error(t, ValidateSessionName("../etc"))
+	assert.Error(t, ValidateSessionName("a/b"))
+	assert.Error(t, ValidateSessionName(".hidden"))
+}
+
+func TestMessageRedact(t *testing.T) {
+	redactor := util.NewRedactor(util.DefaultRedactionRules...)
+	msg := NewMessage("application/json", []byte(`{"email":"jane@example.com"}`), false).Redact(redactor)
+	assert.Equal(t, `{"email":"[REDACTED:email]"}`, msg.Body)
+	assert.True(t, msg.Redacted)
+
+	msg = NewMessage("text/plain", []byte("hello"), false).Redact(redactor)
+	assert.Equal(t, "hello", msg.Body)
+	assert.False(t, msg.Redacted)
+
+	msg = NewMessage("application/octet-stream", []byte{0xff, 0x00}, false).Redact(redactor)
+	assert.Equal(t, "base64", msg.Encoding)
+	assert.False(t, msg.Redacted)
+}
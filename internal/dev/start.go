@@ -0,0 +1,115 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/google/uuid"
+)
+
+// StartOptions are the options to run the deployment command of the project locally
+type StartOptions struct {
+	Project project.ProjectContext
+	Dir     string
+	OrgID   string
+	Port    int
+	// Env are the environment variables of the project, such as the ones of the .env file
+	Env map[string]string
+	// InheritEnv passes the environment of the CLI to the command, which the cloud doesn't do
+	InheritEnv bool
+}
+
+// systemEnv are the variables of the environment of the CLI which are passed to the command even
+// when the environment isn't inherited, since the runtimes can't be found or run without them
+var systemEnv = []string{"PATH", "HOME", "USER", "TMPDIR", "LANG", "SYSTEMROOT", "COMSPEC", "PATHEXT", "TEMP", "TMP"}
+
+// productionEnv returns the environment of the deployment command, which is the environment the
+// cloud runs the deployment with: the variables of the project, the Agentuity variables and the
+// port which the server must listen on
+func productionEnv(opts StartOptions, environ []string) []string {
+	var env []string
+	if opts.InheritEnv {
+		env = append(env, environ...)
+	} else {
+		for _, kv := range environ {
+			name, _, _ := strings.Cut(kv, "=")
+			for _, sys := range systemEnv {
+				if strings.EqualFold(name, sys) {
+					env = append(env, kv)
+					break
+				}
+			}
+		}
+	}
+	keys := make([]string, 0, len(opts.Env))
+	for k := range opts.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, opts.Env[k]))
+	}
+	p := opts.Project
+	env = append(env,
+		fmt.Sprintf("AGENTUITY_URL=%s", p.APIURL),
+		fmt.Sprintf("AGENTUITY_TRANSPORT_URL=%s", p.TransportURL),
+		fmt.Sprintf("AGENTUITY_CLOUD_DEPLOYMENT_ID=local_%s", uuid.New().String()),
+		fmt.Sprintf("AGENTUITY_CLOUD_PROJECT_ID=%s", p.Project.ProjectId),
+		fmt.Sprintf("AGENTUITY_CLOUD_ORG_ID=%s", opts.OrgID),
+		"AGENTUITY_ENV=production",
+	)
+	if p.Project.Bundler != nil && p.Project.Bundler.Language == "javascript" {
+		env = append(env, "NODE_ENV=production")
+	}
+	env = append(env, fmt.Sprintf("AGENTUITY_CLOUD_PORT=%d", opts.Port), fmt.Sprintf("PORT=%d", opts.Port))
+	return env
+}
+
+// CreateProductionProjectCmd creates the command to run the deployment command of the project in
+// the project directory with the environment of the cloud
+func CreateProductionProjectCmd(ctx context.Context, opts StartOptions, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
+	deployment := opts.Project.Project.Deployment
+	if deployment == nil || deployment.Command == "" {
+		return nil, fmt.Errorf("the project has no deployment command")
+	}
+	cmd := exec.CommandContext(ctx, deployment.Command, deployment.Args...)
+	cmd.Env = productionEnv(opts, os.Environ())
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Dir = opts.Dir
+	util.ProcessSetup(cmd)
+	return cmd, nil
+}
+
+// WaitForPort waits until the server listens on the port or the timeout elapses. The deployment
+// fails to start in the cloud when its server doesn't listen on the port in the PORT environment
+// variable. It returns early with an error when exited is closed, meaning the server stopped.
+func WaitForPort(ctx context.Context, port int, timeout time.Duration, exited <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addr := net.JoinHostPort(DefaultHost, strconv.Itoa(port))
+	for {
+		var dialer net.Dialer
+		if conn, err := dialer.DialContext(ctx, "tcp", addr); err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("the server exited before it listened on port %d", port)
+		case <-ctx.Done():
+			return fmt.Errorf("the server didn't listen on port %d after %s", port, timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package dev
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func TestProductionEnv(t *testing.T) {
+	opts := StartOptions{
+		Project: project.ProjectContext{
+			APIURL:       "https://api.agentuity.com",
+			TransportURL: "https://agentuity.ai",
+			Project: &cproject.Project{
+				ProjectId: "proj_123",
+				Bundler:   &cproject.Bundler{Language: "javascript"},
+			},
+		},
+		OrgID: "org_123",
+		Port:  3500,
+		Env:   map[string]string{"OPENAI_API_KEY": "sk-123", "FOO": "bar"},
+	}
+	environ := []string{"PATH=/usr/bin", "HOME=/home/me", "SECRET_OF_THE_SHELL=1", "NODE_ENV=development"}
+
+	env := envMap(productionEnv(opts, environ))
+	assert.Equal(t, "/usr/bin", env["PATH"])
+	assert.Equal(t, "/home/me", env["HOME"])
+	assert.NotContains(t, env, "SECRET_OF_THE_SHELL")
+	assert.NotContains(t, env, "HOST")
+	assert.NotContains(t, env, "AGENTUITY_SDK_DEV_MODE")
+	assert.Equal(t, "sk-123", env["OPENAI_API_KEY"])
+	assert.Equal(t, "bar", env["FOO"])
+	assert.Equal(t, "production", env["AGENTUITY_ENV"])
+	assert.Equal(t, "production", env["NODE_ENV"])
+	assert.Equal(t, "3500", env["PORT"])
+	assert.Equal(t, "3500", env["AGENTUITY_CLOUD_PORT"])
+	assert.Equal(t, "proj_123", env["AGENTUITY_CLOUD_PROJECT_ID"])
+	assert.Equal(t, "org_123", env["AGENTUITY_CLOUD_ORG_ID"])
+	assert.True(t, strings.HasPrefix(env["AGENTUITY_CLOUD_DEPLOYMENT_ID"], "local_"))
+
+	opts.InheritEnv = true
+	env = envMap(productionEnv(opts, environ))
+	assert.Equal(t, "1", env["SECRET_OF_THE_SHELL"])
+	// the variables of the cloud come last so they win over the inherited ones
+	assert.Equal(t, "production", env["NODE_ENV"])
+
+	opts.Project.Project.Bundler.Language = "python"
+	env = envMap(productionEnv(opts, nil))
+	assert.NotContains(t, env, "NODE_ENV")
+}
+
+func TestWaitForPort(t *testing.T) {
+	ln, err := net.Listen("tcp", DefaultHost+":0")
+	require.NoError(t, err)
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, WaitForPort(context.Background(), port, time.Second, nil))
+
+	ln.Close()
+	exited := make(chan struct{})
+	close(exited)
+	assert.ErrorContains(t, WaitForPort(context.Background(), port, 5*time.Second, exited), "exited")
+}
@@ -0,0 +1,116 @@
+package dev
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+)
+
+// StateFilename is the file in the .agentuity directory which the running development server
+// writes its address to so that other commands can find it
+const StateFilename = "dev.json"
+
+// DefaultHost is the address the development server binds to unless another one is given
+const DefaultHost = "127.0.0.1"
+
+// State is the address of the running development server
+type State struct {
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	ProxyPort int       `json:"proxyPort"`
+	HTTPSPort int       `json:"httpsPort,omitempty"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// URL returns the base URL to reach the development server from this machine
+func (s *State) URL() string {
+	return fmt.Sprintf("http://%s", net.JoinHostPort(clientHost(s.Host), fmt.Sprint(s.Port)))
+}
+
+// clientHost returns the host to connect to for a server bound to host
+func clientHost(host string) string {
+	if host == "" || net.ParseIP(host) != nil && net.ParseIP(host).IsUnspecified() {
+		return DefaultHost
+	}
+	return host
+}
+
+func statePath(dir string) string {
+	return filepath.Join(dir, project.AgentuityDir, StateFilename)
+}
+
+// WriteState writes the address of the development server for the project in dir
+func WriteState(dir string, state State) error {
+	buf, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, project.AgentuityDir), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(statePath(dir), buf, 0644)
+}
+
+// RemoveState removes the address of the development server for the project in dir
+func RemoveState(dir string) error {
+	if err := os.Remove(statePath(dir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// LoadState returns the address of the development server for the project in dir or nil when it
+// isn't running. A state file left behind by a development server which exited is ignored.
+func LoadState(dir string) (*State, error) {
+	buf, err := os.ReadFile(statePath(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", statePath(dir), err)
+	}
+	if state.Port <= 0 || isPortAvailable(clientHost(state.Host), state.Port) {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// LocalURL returns the base URL of the local development server of the project. The port is used
+// when given, otherwise the port of the running development server and finally the development
+// port of the project.
+func LocalURL(p project.ProjectContext, port int) string {
+	if port > 0 {
+		return fmt.Sprintf("http://%s:%d", DefaultHost, port)
+	}
+	if state, err := LoadState(p.Dir); err == nil && state != nil {
+		return state.URL()
+	}
+	return fmt.Sprintf("http://%s:%d", DefaultHost, p.Project.Development.Port)
+}
+
+// NetworkAddress returns the IPv4 address of this machine on the local network which other devices
+// can use to reach a server bound to all interfaces
+func NetworkAddress() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && !ipnet.IP.IsLinkLocalUnicast() {
+			if ip := ipnet.IP.To4(); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
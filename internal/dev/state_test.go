@@ -0,0 +1,57 @@
+package dev
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateURL(t *testing.T) {
+	assert.Equal(t, "http://127.0.0.1:3500", (&State{Host: "127.0.0.1", Port: 3500}).URL())
+	assert.Equal(t, "http://127.0.0.1:3500", (&State{Host: "0.0.0.0", Port: 3500}).URL())
+	assert.Equal(t, "http://127.0.0.1:3500", (&State{Port: 3500}).URL())
+	assert.Equal(t, "http://[::1]:3500", (&State{Host: "::1", Port: 3500}).URL())
+	assert.Equal(t, "http://192.168.1.10:3500", (&State{Host: "192.168.1.10", Port: 3500}).URL())
+}
+
+func TestState(t *testing.T) {
+	dir := t.TempDir()
+	theproject := project.ProjectContext{Dir: dir, Project: &cproject.Project{Development: &cproject.Development{Port: 3500}}}
+
+	state, err := LoadState(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+	assert.Equal(t, "http://127.0.0.1:3500", LocalURL(theproject, 0))
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	require.NoError(t, WriteState(dir, State{Host: "0.0.0.0", Port: port, PID: os.Getpid(), StartedAt: time.Now()}))
+	assert.FileExists(t, filepath.Join(dir, project.AgentuityDir, StateFilename))
+	state, err = LoadState(dir)
+	assert.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, port, state.Port)
+	assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", port), LocalURL(theproject, 0))
+	assert.Equal(t, "http://127.0.0.1:4000", LocalURL(theproject, 4000))
+
+	// the state of a development server which is no longer running is ignored
+	listener.Close()
+	state, err = LoadState(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+	assert.Equal(t, "http://127.0.0.1:3500", LocalURL(theproject, 0))
+
+	assert.NoError(t, RemoveState(dir))
+	assert.NoFileExists(t, filepath.Join(dir, project.AgentuityDir, StateFilename))
+	assert.NoError(t, RemoveState(dir))
+}
@@ -0,0 +1,140 @@
+package dev
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+)
+
+// RuntimeStatsFilename is the file in the .agentuity directory which the JavaScript runtime writes
+// its stats to in dev mode
+const RuntimeStatsFilename = "runtime-stats.json"
+
+// runtimeStatsMaxAge is how old the stats can be before the runtime is considered stopped. The
+// runtime writes them every 2 seconds.
+const runtimeStatsMaxAge = 10 * time.Second
+
+// EventLoopDelay is the delay of the event loop in milliseconds since the previous stats
+type EventLoopDelay struct {
+	Mean float64 `json:"mean"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// GCStats are the garbage collections since the runtime started with their total duration in milliseconds
+type GCStats struct {
+	Count    int64   `json:"count"`
+	Duration float64 `json:"duration"`
+}
+
+// RuntimeStats are the stats written by the runtime of the agents
+type RuntimeStats struct {
+	PID       int       `json:"pid"`
+	Time      time.Time `json:"time"`
+	Runtime   string    `json:"runtime"`
+	RSS       int64     `json:"rss"`
+	HeapUsed  int64     `json:"heapUsed"`
+	HeapTotal int64     `json:"heapTotal"`
+	External  int64     `json:"external"`
+	// CPU is the percentage of a core used since the previous stats
+	CPU            float64         `json:"cpu"`
+	EventLoopDelay *EventLoopDelay `json:"eventLoopDelay,omitempty"`
+	GC             *GCStats        `json:"gc,omitempty"`
+}
+
+// RuntimeStatsPath returns the file which the runtime of the project in dir writes its stats to
+func RuntimeStatsPath(dir string) string {
+	return filepath.Join(dir, project.AgentuityDir, RuntimeStatsFilename)
+}
+
+// LoadRuntimeStats returns the latest stats of the runtime of the project in dir or nil when there
+// are none or they are too old, such as after the runtime stopped
+func LoadRuntimeStats(dir string) (*RuntimeStats, error) {
+	buf, err := os.ReadFile(RuntimeStatsPath(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var stats RuntimeStats
+	if err := json.Unmarshal(buf, &stats); err != nil {
+		// the runtime may be writing the file
+		return nil, nil
+	}
+	if time.Since(stats.Time) > runtimeStatsMaxAge {
+		return nil, nil
+	}
+	return &stats, nil
+}
+
+// ProcessStats is the memory and CPU of a process of the project server
+type ProcessStats struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	Command string `json:"command"`
+	// RSS is the resident memory in bytes
+	RSS int64 `json:"rss"`
+	// CPU is the percentage of a core used by the process
+	CPU float64 `json:"cpu"`
+}
+
+// parseProcessStats returns the descendants of the parent from the output of
+// ps -eo pid,ppid,rss,%cpu,comm where the resident memory is in kilobytes
+func parseProcessStats(output string, parent int) []ProcessStats {
+	children := make(map[int][]ProcessStats)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rss, err3 := strconv.ParseInt(fields[2], 10, 64)
+		cpu, err4 := strconv.ParseFloat(fields[3], 64)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue // the header
+		}
+		command := filepath.Base(strings.Join(fields[4:], " "))
+		children[ppid] = append(children[ppid], ProcessStats{PID: pid, PPID: ppid, Command: command, RSS: rss * 1024, CPU: cpu})
+	}
+	var result []ProcessStats
+	var collect func(ppid int)
+	collect = func(ppid int) {
+		for _, p := range children[ppid] {
+			result = append(result, p)
+			collect(p.PID)
+		}
+	}
+	collect(parent)
+	return result
+}
+
+// The levels of the memory pressure of the runtime compared to the memory of the deployment
+const (
+	MemoryOK       = "ok"
+	MemoryWarning  = "warning"
+	MemoryCritical = "critical"
+)
+
+// MemoryPressure returns the percentage of the limit which is used and its level. The level is a
+// warning from 80% of the limit and critical from 95% since the deployment is restarted when it
+// runs out of memory.
+func MemoryPressure(used int64, limit int64) (float64, string) {
+	if limit <= 0 {
+		return 0, MemoryOK
+	}
+	percent := float64(used) / float64(limit) * 100
+	switch {
+	case percent >= 95:
+		return percent, MemoryCritical
+	case percent >= 80:
+		return percent, MemoryWarning
+	}
+	return percent, MemoryOK
+}
@@ -0,0 +1,70 @@
+package dev
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcessStats(t *testing.T) {
+	output := `  PID  PPID   RSS %CPU COMMAND
+    1     0  1024  0.0 /sbin/init
+  100     1  2048  1.5 /usr/local/bin/agentuity
+  101   100  4096 12.5 /usr/bin/npm
+  102   101 81920 45.0 /usr/bin/node
+  200     1  1024  0.0 bash
+`
+	processes := parseProcessStats(output, 100)
+	require.Len(t, processes, 2)
+	assert.Equal(t, ProcessStats{PID: 101, PPID: 100, Command: "npm", RSS: 4096 * 1024, CPU: 12.5}, processes[0])
+	assert.Equal(t, "node", processes[1].Command)
+	assert.Equal(t, int64(81920*1024), processes[1].RSS)
+
+	assert.Empty(t, parseProcessStats(output, 200))
+}
+
+func TestMemoryPressure(t *testing.T) {
+	percent, level := MemoryPressure(512, 1024)
+	assert.Equal(t, 50.0, percent)
+	assert.Equal(t, MemoryOK, level)
+
+	_, level = MemoryPressure(850, 1000)
+	assert.Equal(t, MemoryWarning, level)
+
+	_, level = MemoryPressure(990, 1000)
+	assert.Equal(t, MemoryCritical, level)
+
+	_, level = MemoryPressure(990, 0)
+	assert.Equal(t, MemoryOK, level)
+}
+
+func TestLoadRuntimeStats(t *testing.T) {
+	dir := t.TempDir()
+	stats, err := LoadRuntimeStats(dir)
+	require.NoError(t, err)
+	assert.Nil(t, stats)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, project.AgentuityDir), 0755))
+	write := func(s RuntimeStats) {
+		buf, _ := json.Marshal(s)
+		require.NoError(t, os.WriteFile(RuntimeStatsPath(dir), buf, 0644))
+	}
+
+	write(RuntimeStats{PID: 10, Time: time.Now(), RSS: 1024, GC: &GCStats{Count: 3}})
+	stats, err = LoadRuntimeStats(dir)
+	require.NoError(t, err)
+	require.NotNil(t, stats)
+	assert.Equal(t, int64(1024), stats.RSS)
+	assert.Equal(t, int64(3), stats.GC.Count)
+
+	write(RuntimeStats{PID: 10, Time: time.Now().Add(-time.Minute)})
+	stats, err = LoadRuntimeStats(dir)
+	require.NoError(t, err)
+	assert.Nil(t, stats)
+}
@@ -0,0 +1,320 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+)
+
+// Trigger is a simulated trigger payload which is sent to an agent running in dev mode the same
+// way the cloud transport delivers it to the agent
+type Trigger struct {
+	Type        string
+	ContentType string
+	Body        []byte
+	Metadata    map[string]any
+}
+
+// NewRequest returns the request which delivers the trigger to the agent endpoint
+func (t *Trigger) NewRequest(ctx context.Context, endpoint string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(t.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", t.ContentType)
+	req.Header.Set("x-agentuity-trigger", t.Type)
+	if len(t.Metadata) > 0 {
+		buf, err := json.Marshal(t.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("x-agentuity-metadata", string(buf))
+	}
+	return req, nil
+}
+
+// EmailOptions are the options for a simulated email trigger
+type EmailOptions struct {
+	From        string
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []string
+	Date        time.Time
+}
+
+func writePart(w *multipart.Writer, contentType string, encoding string, body []byte, extra textproto.MIMEHeader) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", encoding)
+	for k, v := range extra {
+		header[k] = v
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if encoding == "base64" {
+		encoded := base64.StdEncoding.EncodeToString(body)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+		return err
+	}
+	_, err = part.Write(body)
+	return err
+}
+
+func crlf(val string) []byte {
+	return []byte(strings.ReplaceAll(strings.ReplaceAll(val, "\r\n", "\n"), "\n", "\r\n"))
+}
+
+// writeAlternative writes the text and html bodies as a multipart/alternative part
+func writeAlternative(w *multipart.Writer, text string, html string) error {
+	var alt bytes.Buffer
+	aw := multipart.NewWriter(&alt)
+	if err := writePart(aw, "text/plain; charset=utf-8", "8bit", crlf(text), nil); err != nil {
+		return err
+	}
+	if err := writePart(aw, "text/html; charset=utf-8", "8bit", crlf(html), nil); err != nil {
+		return err
+	}
+	if err := aw.Close(); err != nil {
+		return err
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "multipart/alternative; boundary="+aw.Boundary())
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(alt.Bytes())
+	return err
+}
+
+// EmailTrigger returns an email trigger with the message in RFC 822 format
+func EmailTrigger(opts EmailOptions) (*Trigger, error) {
+	if opts.Date.IsZero() {
+		opts.Date = time.Now()
+	}
+	if opts.Text == "" && opts.HTML == "" {
+		opts.Text = "Hello from the Agentuity CLI"
+	}
+	messageID := fmt.Sprintf("<%s@agentuity.run>", util.RandStringBytes(24))
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", opts.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", opts.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", opts.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", opts.Date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case len(opts.Attachments) == 0 && opts.HTML == "":
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		buf.Write(crlf(opts.Text))
+		buf.WriteString("\r\n")
+	case len(opts.Attachments) == 0:
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		if err := writePart(w, "text/plain; charset=utf-8", "8bit", crlf(opts.Text), nil); err != nil {
+			return nil, err
+		}
+		if err := writePart(w, "text/html; charset=utf-8", "8bit", crlf(opts.HTML), nil); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+		buf.Write(body.Bytes())
+	default:
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		var err error
+		if opts.HTML != "" {
+			err = writeAlternative(w, opts.Text, opts.HTML)
+		} else {
+			err = writePart(w, "text/plain; charset=utf-8", "8bit", crlf(opts.Text), nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range opts.Attachments {
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read attachment: %w", err)
+			}
+			contentType := mime.TypeByExtension(filepath.Ext(filename))
+			if contentType == "" {
+				contentType = http.DetectContentType(content)
+			}
+			name := filepath.Base(filename)
+			extra := textproto.MIMEHeader{}
+			extra.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+			if err := writePart(w, mime.FormatMediaType(strings.Split(contentType, ";")[0], map[string]string{"name": name}), "base64", content, extra); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+		buf.Write(body.Bytes())
+	}
+
+	return &Trigger{
+		Type:        "email",
+		ContentType: "message/rfc822",
+		Body:        buf.Bytes(),
+		Metadata: map[string]any{
+			"from":      opts.From,
+			"to":        opts.To,
+			"subject":   opts.Subject,
+			"messageId": messageID,
+		},
+	}, nil
+}
+
+// SMSOptions are the options for a simulated SMS trigger
+type SMSOptions struct {
+	From string
+	To   string
+	Text string
+}
+
+// SMSTrigger returns an SMS trigger
+func SMSTrigger(opts SMSOptions) (*Trigger, error) {
+	messageID := "SM" + util.RandStringBytes(32)
+	body, err := json.Marshal(map[string]any{
+		"messageId": messageID,
+		"from":      opts.From,
+		"to":        opts.To,
+		"text":      opts.Text,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Trigger{
+		Type:        "sms",
+		ContentType: "application/json",
+		Body:        body,
+		Metadata: map[string]any{
+			"from":      opts.From,
+			"to":        opts.To,
+			"messageId": messageID,
+		},
+	}, nil
+}
+
+// CronOptions are the options for a simulated cron trigger
+type CronOptions struct {
+	Schedule    string
+	Payload     string
+	ContentType string
+	ScheduledAt time.Time
+}
+
+// CronTrigger returns a cron trigger
+func CronTrigger(opts CronOptions) (*Trigger, error) {
+	if opts.ScheduledAt.IsZero() {
+		opts.ScheduledAt = time.Now()
+	}
+	contentType := opts.ContentType
+	if contentType == "" {
+		if opts.Payload != "" && !json.Valid([]byte(opts.Payload)) {
+			contentType = "text/plain"
+		} else {
+			contentType = "application/json"
+		}
+	}
+	return &Trigger{
+		Type:        "cron",
+		ContentType: contentType,
+		Body:        []byte(opts.Payload),
+		Metadata: map[string]any{
+			"schedule":    opts.Schedule,
+			"scheduledAt": opts.ScheduledAt.UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// SlackOptions are the options for a simulated Slack trigger
+type SlackOptions struct {
+	Event   string
+	Team    string
+	Channel string
+	User    string
+	Text    string
+	Time    time.Time
+}
+
+// SlackTrigger returns a Slack trigger with the payload in the Slack Events API format
+func SlackTrigger(opts SlackOptions) (*Trigger, error) {
+	if opts.Time.IsZero() {
+		opts.Time = time.Now()
+	}
+	switch opts.Event {
+	case "":
+		opts.Event = "message"
+	case "message", "app_mention":
+	default:
+		return nil, fmt.Errorf("unsupported slack event %q (must be message or app_mention)", opts.Event)
+	}
+	ts := fmt.Sprintf("%d.%06d", opts.Time.Unix(), opts.Time.Nanosecond()/1000)
+	channelType := "channel"
+	if strings.HasPrefix(opts.Channel, "D") {
+		channelType = "im"
+	}
+	event := map[string]any{
+		"type":         opts.Event,
+		"channel":      opts.Channel,
+		"user":         opts.User,
+		"text":         opts.Text,
+		"ts":           ts,
+		"event_ts":     ts,
+		"channel_type": channelType,
+	}
+	eventID := "Ev" + strings.ToUpper(util.RandStringBytes(10))
+	body, err := json.Marshal(map[string]any{
+		"token":      util.RandStringBytes(24),
+		"team_id":    opts.Team,
+		"api_app_id": "A" + strings.ToUpper(util.RandStringBytes(10)),
+		"event":      event,
+		"type":       "event_callback",
+		"event_id":   eventID,
+		"event_time": opts.Time.Unix(),
+		"authorizations": []map[string]any{
+			{"team_id": opts.Team, "user_id": opts.User, "is_bot": false},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Trigger{
+		Type:        "slack",
+		ContentType: "application/json",
+		Body:        body,
+		Metadata: map[string]any{
+			"event":   opts.Event,
+			"channel": opts.Channel,
+			"eventId": eventID,
+		},
+	}, nil
+}
@@ -0,0 +1,96 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailTrigger(t *testing.T) {
+	trigger, err := EmailTrigger(EmailOptions{From: "Jane <jane@example.com>", To: "agent@agentuity.run", Subject: "Héllo", Text: "line one\nline two"})
+	assert.NoError(t, err)
+	assert.Equal(t, "email", trigger.Type)
+	assert.Equal(t, "message/rfc822", trigger.ContentType)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(trigger.Body))
+	assert.NoError(t, err)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Equal(t, "Héllo", subject)
+	assert.Equal(t, trigger.Metadata["messageId"], msg.Header.Get("Message-ID"))
+	body, _ := io.ReadAll(msg.Body)
+	assert.Equal(t, "line one\r\nline two\r\n", string(body))
+
+	attachment := filepath.Join(t.TempDir(), "notes.txt")
+	assert.NoError(t, os.WriteFile(attachment, []byte("attached"), 0644))
+	trigger, err = EmailTrigger(EmailOptions{From: "jane@example.com", To: "agent@agentuity.run", Subject: "Files", HTML: "<p>hi</p>", Attachments: []string{attachment}})
+	assert.NoError(t, err)
+	msg, err = mail.ReadMessage(bytes.NewReader(trigger.Body))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	part, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Contains(t, part.Header.Get("Content-Type"), "multipart/alternative")
+	part, err = reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "notes.txt", part.FileName())
+	assert.Equal(t, "base64", part.Header.Get("Content-Transfer-Encoding"))
+
+	_, err = EmailTrigger(EmailOptions{Attachments: []string{filepath.Join(t.TempDir(), "missing")}})
+	assert.Error(t, err)
+}
+
+func TestSMSAndCronTriggers(t *testing.T) {
+	trigger, err := SMSTrigger(SMSOptions{From: "+15555550100", To: "+15555550199", Text: "hi"})
+	assert.NoError(t, err)
+	var sms map[string]string
+	assert.NoError(t, json.Unmarshal(trigger.Body, &sms))
+	assert.Equal(t, "hi", sms["text"])
+	assert.Equal(t, trigger.Metadata["messageId"], sms["messageId"])
+
+	at := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	trigger, err = CronTrigger(CronOptions{Schedule: "0 9 * * *", ScheduledAt: at})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", trigger.ContentType)
+	assert.Equal(t, map[string]any{"schedule": "0 9 * * *", "scheduledAt": "2025-01-02T09:00:00Z"}, trigger.Metadata)
+	trigger, _ = CronTrigger(CronOptions{Payload: "run report"})
+	assert.Equal(t, "text/plain", trigger.ContentType)
+}
+
+func TestSlackTrigger(t *testing.T) {
+	at := time.Date(2025, 1, 2, 9, 0, 0, 500000000, time.UTC)
+	trigger, err := SlackTrigger(SlackOptions{Event: "app_mention", Team: "T1", Channel: "C1", User: "U1", Text: "hi", Time: at})
+	assert.NoError(t, err)
+	var payload struct {
+		Type  string `json:"type"`
+		Event struct {
+			Type string `json:"type"`
+			TS   string `json:"ts"`
+			Text string `json:"text"`
+		} `json:"event"`
+	}
+	assert.NoError(t, json.Unmarshal(trigger.Body, &payload))
+	assert.Equal(t, "event_callback", payload.Type)
+	assert.Equal(t, "app_mention", payload.Event.Type)
+	assert.Equal(t, "1735808400.500000", payload.Event.TS)
+
+	_, err = SlackTrigger(SlackOptions{Event: "reaction_added"})
+	assert.Error(t, err)
+
+	req, err := trigger.NewRequest(context.Background(), "http://127.0.0.1:3500/agent_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "slack", req.Header.Get("x-agentuity-trigger"))
+	assert.Contains(t, req.Header.Get("x-agentuity-metadata"), `"event":"app_mention"`)
+}
@@ -0,0 +1,401 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/bundler"
+	"github.com/agentuity/cli/internal/envutil"
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/project"
+)
+
+const (
+	CheckLockfile = "lockfile"
+	CheckRuntime  = "runtime"
+	CheckState    = "state"
+	CheckEnv      = "env"
+)
+
+// Problem is a problem with the environment of the project found by Diagnose
+type Problem struct {
+	// Check is the name of the check which found the problem
+	Check   string `json:"check"`
+	Message string `json:"message"`
+	// Fix describes the fix or is empty when the problem can't be fixed automatically
+	Fix string `json:"fix,omitempty"`
+	// Confirm is true when the fix changes the machine (such as running an installer) instead of
+	// the project and must be confirmed by the user
+	Confirm bool `json:"confirm,omitempty"`
+
+	apply func(ctx context.Context) error
+}
+
+// Fixable returns true if the problem can be fixed automatically
+func (p *Problem) Fixable() bool {
+	return p.apply != nil
+}
+
+// Apply fixes the problem
+func (p *Problem) Apply(ctx context.Context) error {
+	if p.apply == nil {
+		return fmt.Errorf("%s can't be fixed automatically", p.Message)
+	}
+	return p.apply(ctx)
+}
+
+// Options are the project and the terminal which Diagnose checks and fixes
+type Options struct {
+	Dir     string
+	Project *project.Project
+	// Stdin, Stdout and Stderr are attached to the installers which are run by the fixes
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Diagnose returns the problems with the environment of the project. The problems are in the order
+// they should be fixed since the runtime which is required depends on the lockfile.
+func Diagnose(opts Options) ([]*Problem, error) {
+	var problems []*Problem
+	for _, check := range []func(Options) ([]*Problem, error){checkLockfile, checkRuntimes, checkState, checkEnvFiles} {
+		p, err := check(opts)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, p...)
+	}
+	return problems, nil
+}
+
+// expectedRuntime is the runtime of the project after the lockfile check is fixed
+func expectedRuntime(opts Options) string {
+	if opts.Project.Bundler == nil {
+		return ""
+	}
+	if runtime := bundler.LockfileRuntime(opts.Dir, opts.Project.Bundler.Language); runtime != "" {
+		return runtime
+	}
+	return opts.Project.Bundler.Runtime
+}
+
+// checkLockfile checks that the runtime in the project file matches the lockfile of the project,
+// which happens when the package manager was changed after the project was created
+func checkLockfile(opts Options) ([]*Problem, error) {
+	if opts.Project.Bundler == nil {
+		return nil, nil
+	}
+	current := opts.Project.Bundler.Runtime
+	runtime := expectedRuntime(opts)
+	if runtime == current {
+		return nil, nil
+	}
+	return []*Problem{{
+		Check:   CheckLockfile,
+		Message: fmt.Sprintf("the project uses the %s runtime but its lockfile is for %s", current, runtime),
+		Fix:     fmt.Sprintf("change bundler.runtime in %s to %s", filepath.Base(project.GetProjectFilename(opts.Dir)), runtime),
+		apply: func(ctx context.Context) error {
+			opts.Project.Bundler.Runtime = runtime
+			return iproject.SaveProject(opts.Dir, opts.Project)
+		},
+	}}, nil
+}
+
+// installer is the documented installer of a tool
+type installer struct {
+	unix    string
+	windows string
+	// docs are the installation instructions for the tools which can't be installed automatically
+	docs string
+}
+
+var installers = map[string]installer{
+	"bun": {
+		unix:    "curl -fsSL https://bun.sh/install | bash",
+		windows: `powershell -c "irm bun.sh/install.ps1 | iex"`,
+	},
+	"uv": {
+		unix:    "curl -LsSf https://astral.sh/uv/install.sh | sh",
+		windows: `powershell -ExecutionPolicy ByPass -c "irm https://astral.sh/uv/install.ps1 | iex"`,
+	},
+	"pnpm": {
+		unix:    "curl -fsSL https://get.pnpm.io/install.sh | sh -",
+		windows: `powershell -c "iwr https://get.pnpm.io/install.ps1 -useb | iex"`,
+	},
+	"yarn": {
+		unix:    "corepack enable yarn",
+		windows: "corepack enable yarn",
+	},
+	"node": {docs: "https://nodejs.org/en/download"},
+}
+
+func (i installer) command() string {
+	if runtime.GOOS == "windows" {
+		return i.windows
+	}
+	return i.unix
+}
+
+// requiredTools returns the tools which the project needs to build and run
+func requiredTools(opts Options) []string {
+	if opts.Project.Bundler == nil {
+		return nil
+	}
+	var tools []string
+	switch expectedRuntime(opts) {
+	case "bunjs":
+		tools = append(tools, "bun")
+	case "nodejs":
+		tools = append(tools, "node")
+		if pm := bundler.DetectPackageManager(opts.Dir); pm != "npm" {
+			tools = append(tools, pm)
+		}
+	case "uv":
+		tools = append(tools, "uv")
+	}
+	return tools
+}
+
+// checkRuntimes checks that the tools which the project needs are installed
+func checkRuntimes(opts Options) ([]*Problem, error) {
+	var problems []*Problem
+	for _, tool := range requiredTools(opts) {
+		if _, err := exec.LookPath(tool); err == nil {
+			continue
+		}
+		problem := &Problem{Check: CheckRuntime, Message: fmt.Sprintf("%s is required by the project but is not installed", tool)}
+		inst := installers[tool]
+		if command := inst.command(); command != "" {
+			problem.Fix = "run the installer: " + command
+			problem.Confirm = true
+			problem.apply = func(ctx context.Context) error {
+				return runInstaller(ctx, opts, command)
+			}
+		} else if inst.docs != "" {
+			problem.Message += fmt.Sprintf(". See %s", inst.docs)
+		}
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+func runInstaller(ctx context.Context, opts Options, command string) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	util.ProcessSetup(c)
+	c.Stdin = opts.Stdin
+	c.Stdout = opts.Stdout
+	c.Stderr = opts.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+	return nil
+}
+
+// validJSONL returns the valid lines of the JSON lines file and whether any line (other than a
+// partially written last line, which is ignored when the file is read) is invalid
+func validJSONL(buf []byte) ([]byte, bool) {
+	lines := bytes.Split(bytes.TrimRight(buf, "\r\n"), []byte("\n"))
+	var valid bytes.Buffer
+	var corrupt bool
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			if i < len(lines)-1 {
+				corrupt = true
+			}
+			continue
+		}
+		valid.Write(line)
+		valid.WriteByte('\n')
+	}
+	return valid.Bytes(), corrupt
+}
+
+// checkState checks the local state in the .agentuity directory for files which can't be parsed,
+// such as the ones left behind by a command which was killed while writing them
+func checkState(opts Options) ([]*Problem, error) {
+	var problems []*Problem
+	stateDir := filepath.Join(opts.Dir, iproject.AgentuityDir)
+	for _, name := range iproject.StateFiles {
+		filename := filepath.Join(stateDir, name)
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if json.Valid(buf) {
+			continue
+		}
+		problems = append(problems, &Problem{
+			Check:   CheckState,
+			Message: fmt.Sprintf("%s/%s is corrupted", iproject.AgentuityDir, name),
+			Fix:     "remove it so that it is created again",
+			apply: func(ctx context.Context) error {
+				return util.Remove(filename)
+			},
+		})
+	}
+	for _, name := range iproject.StateDirs {
+		dir := filepath.Join(stateDir, name)
+		err := filepath.WalkDir(dir, func(filename string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(opts.Dir, filename)
+			rel = filepath.ToSlash(rel)
+			switch filepath.Ext(filename) {
+			case ".json":
+				buf, err := os.ReadFile(filename)
+				if err != nil {
+					return err
+				}
+				if !json.Valid(buf) {
+					problems = append(problems, &Problem{
+						Check:   CheckState,
+						Message: fmt.Sprintf("%s is corrupted", rel),
+						Fix:     fmt.Sprintf("move it to %s.corrupt so that it is created again", rel),
+						apply: func(ctx context.Context) error {
+							return os.Rename(filename, filename+".corrupt")
+						},
+					})
+				}
+			case ".jsonl":
+				buf, err := os.ReadFile(filename)
+				if err != nil {
+					return err
+				}
+				if valid, corrupt := validJSONL(buf); corrupt {
+					problems = append(problems, &Problem{
+						Check:   CheckState,
+						Message: fmt.Sprintf("%s has corrupted lines", rel),
+						Fix:     fmt.Sprintf("remove the corrupted lines, keeping the original in %s.corrupt", rel),
+						apply: func(ctx context.Context) error {
+							if err := os.WriteFile(filename+".corrupt", buf, 0644); err != nil {
+								return err
+							}
+							return os.WriteFile(filename, valid, 0644)
+						},
+					})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+// deprecatedEnvKeys are the environment variables which were renamed, mapped to their new name
+var deprecatedEnvKeys = map[string]string{
+	"AGENTUITY_API_KEY": "AGENTUITY_SDK_KEY",
+}
+
+var envKeyLine = regexp.MustCompile(`^\s*(export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+// envFiles returns the .env file and the environment profiles of the project
+func envFiles(dir string) ([]string, error) {
+	var files []string
+	if util.Exists(filepath.Join(dir, ".env")) {
+		files = append(files, filepath.Join(dir, ".env"))
+	}
+	profiles, err := envutil.ListEnvProfiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		files = append(files, filepath.Join(dir, ".env."+profile))
+	}
+	return files, nil
+}
+
+// migrateEnvKeys renames the deprecated keys in the env file to their new name, or removes them
+// when the new key is already set like the CLI does when it writes the .env file. The other lines
+// (including the comments) are kept as is. It returns the deprecated keys found and the changes.
+func migrateEnvKeys(buf []byte) ([]byte, []string, []string) {
+	lines := strings.SplitAfter(string(buf), "\n")
+	keys := make(map[string]bool)
+	for _, line := range lines {
+		if m := envKeyLine.FindStringSubmatch(line); m != nil {
+			keys[m[2]] = true
+		}
+	}
+	var result strings.Builder
+	var deprecated, changes []string
+	for _, line := range lines {
+		if m := envKeyLine.FindStringSubmatchIndex(line); m != nil {
+			key := line[m[4]:m[5]]
+			if replacement, ok := deprecatedEnvKeys[key]; ok {
+				if !slices.Contains(deprecated, key) {
+					deprecated = append(deprecated, key)
+				}
+				if keys[replacement] {
+					changes = append(changes, fmt.Sprintf("remove %s since %s is set", key, replacement))
+					continue
+				}
+				keys[replacement] = true
+				changes = append(changes, fmt.Sprintf("rename %s to %s", key, replacement))
+				line = line[:m[4]] + replacement + line[m[5]:]
+			}
+		}
+		result.WriteString(line)
+	}
+	sort.Strings(deprecated)
+	return []byte(result.String()), deprecated, changes
+}
+
+// checkEnvFiles checks the env files of the project for deprecated keys
+func checkEnvFiles(opts Options) ([]*Problem, error) {
+	files, err := envFiles(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	for _, filename := range files {
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		fixed, deprecated, changes := migrateEnvKeys(buf)
+		if len(deprecated) == 0 {
+			continue
+		}
+		problems = append(problems, &Problem{
+			Check:   CheckEnv,
+			Message: fmt.Sprintf("%s uses the deprecated %s", filepath.Base(filename), strings.Join(deprecated, ", ")),
+			Fix:     strings.Join(changes, ", "),
+			apply: func(ctx context.Context) error {
+				return os.WriteFile(filename, fixed, 0644)
+			},
+		})
+	}
+	return problems, nil
+}
@@ -0,0 +1,123 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, filename string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+}
+
+func readFile(t *testing.T, filename string) string {
+	t.Helper()
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	return string(buf)
+}
+
+func fixAll(t *testing.T, problems []*Problem) {
+	t.Helper()
+	for _, p := range problems {
+		require.True(t, p.Fixable(), p.Message)
+		require.NoError(t, p.Apply(context.Background()), p.Message)
+	}
+}
+
+func TestCheckLockfile(t *testing.T) {
+	dir := t.TempDir()
+	p := &project.Project{ProjectId: "proj_1", Name: "test", Bundler: &project.Bundler{Language: "javascript", Runtime: "nodejs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}}
+	require.NoError(t, p.Save(dir))
+	writeFile(t, filepath.Join(dir, "bun.lock"), "{}")
+
+	opts := Options{Dir: dir, Project: p}
+	problems, err := checkLockfile(opts)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "the project uses the nodejs runtime but its lockfile is for bunjs", problems[0].Message)
+	assert.Equal(t, []string{"bun"}, requiredTools(opts))
+	fixAll(t, problems)
+
+	var saved project.Project
+	require.NoError(t, saved.Load(dir))
+	assert.Equal(t, "bunjs", saved.Bundler.Runtime)
+	problems, err = checkLockfile(opts)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestCheckRuntimes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pnpm-lock.yaml"), "")
+	t.Setenv("PATH", t.TempDir())
+	opts := Options{Dir: dir, Project: &project.Project{Bundler: &project.Bundler{Language: "javascript", Runtime: "nodejs"}}}
+	problems, err := checkRuntimes(opts)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "node is required by the project but is not installed. See https://nodejs.org/en/download", problems[0].Message)
+	assert.False(t, problems[0].Fixable())
+	assert.Equal(t, "pnpm is required by the project but is not installed", problems[1].Message)
+	assert.True(t, problems[1].Fixable())
+	assert.True(t, problems[1].Confirm)
+}
+
+func TestCheckState(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".agentuity", "dev.json"), `{"port": 35`)
+	writeFile(t, filepath.Join(dir, ".agentuity", "import.json"), `{"source": "owner/repo"}`)
+	writeFile(t, filepath.Join(dir, ".agentuity", "evals", "baseline.json"), `{"runs": [`)
+	writeFile(t, filepath.Join(dir, ".agentuity", "sessions", "good.jsonl"), "{\"a\":1}\n{\"a\":2")
+	writeFile(t, filepath.Join(dir, ".agentuity", "sessions", "bad.jsonl"), "{\"a\":1}\n{\"a\n{\"a\":3}\n")
+
+	problems, err := checkState(Options{Dir: dir})
+	require.NoError(t, err)
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.Message)
+	}
+	assert.Equal(t, []string{
+		".agentuity/dev.json is corrupted",
+		".agentuity/evals/baseline.json is corrupted",
+		".agentuity/sessions/bad.jsonl has corrupted lines",
+	}, messages)
+	fixAll(t, problems)
+
+	assert.NoFileExists(t, filepath.Join(dir, ".agentuity", "dev.json"))
+	assert.FileExists(t, filepath.Join(dir, ".agentuity", "import.json"))
+	assert.FileExists(t, filepath.Join(dir, ".agentuity", "evals", "baseline.json.corrupt"))
+	assert.Equal(t, "{\"a\":1}\n{\"a\":3}\n", readFile(t, filepath.Join(dir, ".agentuity", "sessions", "bad.jsonl")))
+	assert.Equal(t, "{\"a\":1}\n{\"a\n{\"a\":3}\n", readFile(t, filepath.Join(dir, ".agentuity", "sessions", "bad.jsonl.corrupt")))
+
+	problems, err = checkState(Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestCheckEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "# the keys\nAGENTUITY_API_KEY=sk_1\nOTHER=1\n")
+	writeFile(t, filepath.Join(dir, ".env.production"), "AGENTUITY_SDK_KEY=sk_2\nexport AGENTUITY_API_KEY=sk_old\n")
+	writeFile(t, filepath.Join(dir, ".env.staging"), "AGENTUITY_SDK_KEY=sk_3\n")
+	writeFile(t, filepath.Join(dir, ".env.example"), "AGENTUITY_API_KEY=\n")
+
+	problems, err := checkEnvFiles(Options{Dir: dir})
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.Equal(t, ".env uses the deprecated AGENTUITY_API_KEY", problems[0].Message)
+	assert.Equal(t, "rename AGENTUITY_API_KEY to AGENTUITY_SDK_KEY", problems[0].Fix)
+	assert.Equal(t, ".env.production uses the deprecated AGENTUITY_API_KEY", problems[1].Message)
+	assert.Equal(t, "remove AGENTUITY_API_KEY since AGENTUITY_SDK_KEY is set", problems[1].Fix)
+	fixAll(t, problems)
+
+	assert.Equal(t, "# the keys\nAGENTUITY_SDK_KEY=sk_1\nOTHER=1\n", readFile(t, filepath.Join(dir, ".env")))
+	assert.Equal(t, "AGENTUITY_SDK_KEY=sk_2\n", readFile(t, filepath.Join(dir, ".env.production")))
+	assert.Equal(t, "AGENTUITY_API_KEY=\n", readFile(t, filepath.Join(dir, ".env.example")))
+}
@@ -0,0 +1,145 @@
+// Package e2e supports the end-to-end tests of a project against a temporary deployment of the
+// project in Agentuity Cloud
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/iancoleman/strcase"
+)
+
+// TagPrefix is the prefix of the tag of the deployments of the end-to-end tests
+const TagPrefix = "e2e-"
+
+// NewTag returns a unique tag for the deployment of a test run
+func NewTag() string {
+	return TagPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
+
+// skippedDirs aren't copied with the project since they are generated. The dependency directories
+// are linked instead of copied so that the copy doesn't need to install the dependencies again.
+var skippedDirs = map[string]bool{
+	".git":        true,
+	".agentuity":  true,
+	"__pycache__": true,
+}
+
+var linkedDirs = map[string]bool{
+	"node_modules": true,
+	".venv":        true,
+}
+
+// CopyProject copies the project in src to dst, which is where the test project is imported so
+// that the project file of src isn't changed
+func CopyProject(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if rel != "." && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			if filepath.Dir(rel) == "." && linkedDirs[d.Name()] {
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return err
+				}
+				if err := os.Symlink(abs, target); err != nil {
+					return fmt.Errorf("error linking %s: %w", rel, err)
+				}
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, buf, info.Mode().Perm())
+	})
+}
+
+// DeploymentID returns the id of the deployment from the JSON output of the deploy command
+func DeploymentID(output []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var result struct {
+			DeploymentID string `json:"deployment_id"`
+		}
+		if json.Unmarshal([]byte(strings.TrimSpace(lines[i])), &result) == nil && result.DeploymentID != "" {
+			return result.DeploymentID, nil
+		}
+	}
+	return "", fmt.Errorf("the deploy command didn't return the deployment")
+}
+
+// Agent is an agent of the test project
+type Agent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// WebhookURL returns the URL of the webhook of the agent
+func WebhookURL(transportURL string, agentID string) string {
+	return fmt.Sprintf("%s/webhook/%s", strings.TrimRight(transportURL, "/"), strings.Replace(agentID, "agent_", "", 1))
+}
+
+// Environment is the test project which is passed to the test command in environment variables
+type Environment struct {
+	ProjectID    string
+	DeploymentID string
+	Tag          string
+	// URL is the URL which the webhooks of the agents are served from
+	URL          string
+	ProjectKey   string
+	WebhookToken string
+	Agents       []Agent
+}
+
+// Vars returns the environment variables of the test command. Each agent has a variable with the
+// URL of its webhook such as AGENTUITY_E2E_AGENT_MY_AGENT_URL for the agent named my-agent and
+// AGENTUITY_E2E_AGENTS has all of the agents as JSON.
+func (e Environment) Vars() []string {
+	agents := append([]Agent{}, e.Agents...)
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	buf, _ := json.Marshal(agents)
+	vars := []string{
+		"AGENTUITY_E2E=true",
+		"AGENTUITY_E2E_PROJECT_ID=" + e.ProjectID,
+		"AGENTUITY_E2E_DEPLOYMENT_ID=" + e.DeploymentID,
+		"AGENTUITY_E2E_TAG=" + e.Tag,
+		"AGENTUITY_E2E_URL=" + e.URL,
+		"AGENTUITY_E2E_PROJECT_KEY=" + e.ProjectKey,
+		"AGENTUITY_E2E_WEBHOOK_TOKEN=" + e.WebhookToken,
+		"AGENTUITY_E2E_AGENTS=" + string(buf),
+	}
+	for _, a := range agents {
+		vars = append(vars, fmt.Sprintf("AGENTUITY_E2E_AGENT_%s_URL=%s", strcase.ToScreamingSnake(a.Name), a.URL))
+	}
+	return vars
+}
@@ -0,0 +1,65 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTag(t *testing.T) {
+	tag := NewTag()
+	assert.True(t, strings.HasPrefix(tag, TagPrefix))
+	assert.Len(t, tag, len(TagPrefix)+12)
+	assert.NotEqual(t, tag, NewTag())
+}
+
+func TestCopyProject(t *testing.T) {
+	src := t.TempDir()
+	for _, fn := range []string{"agentuity.yaml", ".env", "src/agents/a/index.ts", "node_modules/x/index.js", ".git/HEAD", ".agentuity/index.js", "src/node_modules/y.js"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(src, filepath.Dir(fn)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(src, fn), []byte(fn), 0644))
+	}
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, CopyProject(src, dst))
+
+	assert.FileExists(t, filepath.Join(dst, "agentuity.yaml"))
+	assert.FileExists(t, filepath.Join(dst, ".env"))
+	assert.FileExists(t, filepath.Join(dst, "src/agents/a/index.ts"))
+	assert.FileExists(t, filepath.Join(dst, "src/node_modules/y.js"))
+	assert.NoDirExists(t, filepath.Join(dst, ".git"))
+	assert.NoDirExists(t, filepath.Join(dst, ".agentuity"))
+
+	fi, err := os.Lstat(filepath.Join(dst, "node_modules"))
+	require.NoError(t, err)
+	assert.NotZero(t, fi.Mode()&os.ModeSymlink)
+	assert.FileExists(t, filepath.Join(dst, "node_modules/x/index.js"))
+}
+
+func TestDeploymentID(t *testing.T) {
+	id, err := DeploymentID([]byte("Deploying ...\n{\"deployment_id\":\"deploy_123\",\"project_url\":\"x\"}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "deploy_123", id)
+
+	_, err = DeploymentID([]byte("error"))
+	assert.Error(t, err)
+}
+
+func TestEnvironmentVars(t *testing.T) {
+	env := Environment{
+		ProjectID: "proj_1",
+		Tag:       "e2e-1",
+		URL:       "https://agentuity.ai",
+		Agents: []Agent{
+			{ID: "agent_2", Name: "my-agent", URL: WebhookURL("https://agentuity.ai/", "agent_2")},
+		},
+	}
+	vars := env.Vars()
+	assert.Contains(t, vars, "AGENTUITY_E2E_PROJECT_ID=proj_1")
+	assert.Contains(t, vars, "AGENTUITY_E2E_TAG=e2e-1")
+	assert.Contains(t, vars, "AGENTUITY_E2E_AGENT_MY_AGENT_URL=https://agentuity.ai/webhook/2")
+	assert.Contains(t, vars, `AGENTUITY_E2E_AGENTS=[{"id":"agent_2","name":"my-agent","url":"https://agentuity.ai/webhook/2"}]`)
+}
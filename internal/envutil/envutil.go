@@ -79,11 +79,22 @@ func ShouldSyncToProduction(isLocalDev bool) bool {
 	return !isLocalDev
 }
 
-// ProcessEnvFiles handles .env and template env processing
-func ProcessEnvFiles(ctx context.Context, logger logger.Logger, dir string, theproject *project.Project, projectData *iproject.ProjectData, apiUrl, token string, force bool, isLocalDev bool) (*deployer.EnvFile, *iproject.ProjectData) {
-	envfilename, err := DetermineEnvFilename(dir, isLocalDev)
-	if err != nil {
-		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to create .env.development file")).ShowErrorAndExit()
+// ProcessEnvFiles handles .env and template env processing. If profile is not empty, the
+// .env.<profile> file is used instead of the default env file.
+func ProcessEnvFiles(ctx context.Context, logger logger.Logger, dir string, theproject *project.Project, projectData *iproject.ProjectData, apiUrl, token string, force bool, isLocalDev bool, profile string) (*deployer.EnvFile, *iproject.ProjectData) {
+	var envfilename string
+	var err error
+	if profile != "" {
+		envfilename, err = EnvProfileFilename(dir, profile)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidCommandFlag, err, errsystem.WithUserMessage("Invalid environment profile: %s", profile)).ShowErrorAndExit()
+		}
+		logger.Debug("using environment profile %s (%s)", profile, envfilename)
+	} else {
+		envfilename, err = DetermineEnvFilename(dir, isLocalDev)
+		if err != nil {
+			errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to create .env.development file")).ShowErrorAndExit()
+		}
 	}
 
 	var envFile *deployer.EnvFile
@@ -93,6 +104,12 @@ func ProcessEnvFiles(ctx context.Context, logger logger.Logger, dir string, thep
 			errsystem.New(errsystem.ErrParseEnvironmentFile, err,
 				errsystem.WithContextMessage("Error parsing .env file")).ShowErrorAndExit()
 		}
+		// resolve any secret references (op://, aws-sm://, vault://) so the real values are used
+		envFile.Env, _, err = ResolveSecretReferences(ctx, logger, envFile.Env)
+		if err != nil {
+			errsystem.New(errsystem.ErrParseEnvironmentFile, err,
+				errsystem.WithContextMessage("Error resolving secret reference")).ShowErrorAndExit()
+		}
 
 		if ShouldSyncToProduction(isLocalDev) {
 			projectData = HandleMissingProjectEnvs(ctx, logger, envFile.Env, projectData, theproject, apiUrl, token, force, envfilename)
@@ -183,19 +200,56 @@ func HandleMissingProjectEnvs(ctx context.Context, logger logger.Logger, le []en
 	if projectData == nil {
 		projectData = &iproject.ProjectData{}
 	}
+	dir := filepath.Dir(envFilename)
+	policy, err := LoadEnvPolicy(dir)
+	if err != nil {
+		errsystem.New(errsystem.ErrInvalidConfiguration, err, errsystem.WithContextMessage("Failed to load the env policy")).ShowErrorAndExit()
+	}
 	keyvalue := map[string]string{}
+	var newKeys []string
 	for _, ev := range le {
-		if isAgentuityEnv.MatchString(ev.Key) {
+		if isAgentuityEnv.MatchString(ev.Key) || policy.IsLocalOnly(ev.Key) {
 			continue
 		}
-		if projectData.Env != nil && projectData.Env[ev.Key] == ev.Val {
+		// a secret which is stored as a plain variable is uploaded again to store it encrypted
+		if projectData.Env != nil && projectData.Env[ev.Key] == ev.Val && policy[ev.Key] != PolicySecret {
 			continue
 		}
 		if projectData.Secrets != nil && projectData.Secrets[ev.Key] == ev.Val {
 			continue
 		}
+		if _, ok := projectData.Env[ev.Key]; !ok {
+			if _, ok := projectData.Secrets[ev.Key]; !ok {
+				newKeys = append(newKeys, ev.Key)
+			}
+		}
 		keyvalue[ev.Key] = ev.Val
 	}
+	if unclassified := policy.Unclassified(newKeys); len(unclassified) > 0 {
+		if force || !tui.HasTTY {
+			errsystem.New(errsystem.ErrInvalidConfiguration, fmt.Errorf("unclassified environment variables: %s", strings.Join(unclassified, ", ")),
+				errsystem.WithUserMessage("The environment variables %s from %s aren't in the env policy of agentuity.yaml. Add them to env.policy as secret, env or local-only before deploying.", strings.Join(unclassified, ", "), filepath.Base(envFilename))).ShowErrorAndExit()
+		}
+		classes := make(map[string]string)
+		for _, key := range unclassified {
+			options := []tui.Option{
+				{ID: PolicyEnv, Text: "env - upload it as an environment variable"},
+				{ID: PolicySecret, Text: "secret - upload it as an encrypted secret"},
+				{ID: PolicyLocalOnly, Text: "local-only - never upload it"},
+			}
+			if looksLikeSecret.MatchString(key) {
+				options[0], options[1] = options[1], options[0]
+			}
+			classes[key] = tui.Select(logger, fmt.Sprintf("%s isn't in the env policy. How should it be classified?", key), "The class is saved to the env policy in agentuity.yaml", options)
+			policy[key] = classes[key]
+			if classes[key] == PolicyLocalOnly {
+				delete(keyvalue, key)
+			}
+		}
+		if err := iproject.SaveProjectWithEnvPolicy(dir, theproject, classes); err != nil {
+			errsystem.New(errsystem.ErrSaveProject, err, errsystem.WithContextMessage("Failed to save the env policy")).ShowErrorAndExit()
+		}
+	}
 	if len(keyvalue) > 0 {
 		if !force {
 			var title string
@@ -231,12 +285,23 @@ func HandleMissingProjectEnvs(ctx context.Context, logger logger.Logger, le []en
 			force = tui.Ask(logger, question, true)
 		}
 		if force {
+			references := make(map[string]bool)
+			for _, ev := range le {
+				if IsSecretReference(ev.Raw) {
+					references[ev.Key] = true
+				}
+			}
+			var encrypted []string
 			for key, val := range keyvalue {
-				if looksLikeSecret.MatchString(key) {
+				if policy.IsSecret(key) || references[key] {
 					if projectData.Secrets == nil {
 						projectData.Secrets = make(map[string]string)
 					}
 					projectData.Secrets[key] = val
+					if _, ok := projectData.Env[key]; ok {
+						delete(projectData.Env, key)
+						encrypted = append(encrypted, key)
+					}
 				} else {
 					if projectData.Env == nil {
 						projectData.Env = make(map[string]string)
@@ -244,10 +309,17 @@ func HandleMissingProjectEnvs(ctx context.Context, logger logger.Logger, le []en
 					projectData.Env[key] = val
 				}
 			}
+			SnapshotProjectEnv(ctx, logger, filepath.Dir(envFilename), apiUrl, token, theproject.ProjectId, "sync of "+filepath.Base(envFilename))
 			_, err := iproject.SetProjectEnv(ctx, logger, apiUrl, token, theproject.ProjectId, projectData.Env, projectData.Secrets)
 			if err != nil {
 				errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithUserMessage("Failed to save project settings")).ShowErrorAndExit()
 			}
+			// the secrets which were stored as plain variables are only kept encrypted
+			if len(encrypted) > 0 {
+				if err := iproject.DeleteProjectEnv(ctx, logger, apiUrl, token, theproject.ProjectId, encrypted, nil); err != nil {
+					errsystem.New(errsystem.ErrApiRequest, err, errsystem.WithUserMessage("Failed to save project settings")).ShowErrorAndExit()
+				}
+			}
 		}
 	}
 	return projectData
@@ -0,0 +1,334 @@
+package envutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/keys"
+	iproject "github.com/agentuity/cli/internal/project"
+	"github.com/agentuity/go-common/crypto"
+	"github.com/agentuity/go-common/logger"
+)
+
+// EnvHistoryDir is the directory in the project's .agentuity directory where the snapshots of the
+// environment variables and secrets of the cloud project are kept
+const EnvHistoryDir = "env-history"
+
+// MaxEnvSnapshots is the number of snapshots kept, the oldest ones are removed first
+const MaxEnvSnapshots = 50
+
+// EnvSnapshot is the environment variables and secrets of the cloud project before a change. Only a
+// keyed hash of each value is kept, which is enough to compare snapshots and to find what changed,
+// so that the secrets aren't written in clear to the project. The values are also kept encrypted with
+// the public key of the organization when it has one so that env revert can set them back.
+type EnvSnapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Reason    string    `json:"reason"`
+	// Salt is the random key of the hashes of the values of the snapshot
+	Salt string `json:"salt"`
+	// Env and Secrets are the hash of the value of each key
+	Env     map[string]string   `json:"env"`
+	Secrets map[string]string   `json:"secrets"`
+	Values  *EncryptedEnvValues `json:"values,omitempty"`
+}
+
+// EnvValues are the values of the environment variables and secrets of a snapshot
+type EnvValues struct {
+	Env     map[string]string `json:"env"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// EncryptedEnvValues are the values of a snapshot encrypted with the public key of the organization
+type EncryptedEnvValues struct {
+	Fingerprint string `json:"fingerprint"`
+	Data        string `json:"data"`
+}
+
+// EnvSnapshotKey is the public key of the organization the values of the snapshots are encrypted with
+type EnvSnapshotKey struct {
+	PublicKey   *ecdsa.PublicKey
+	Fingerprint string
+}
+
+// EnvChange is the change of a variable when a snapshot is reverted
+type EnvChange struct {
+	Key    string `json:"key"`
+	Secret bool   `json:"secret"`
+	// Action is add, update or delete
+	Action string `json:"action"`
+	Value  string `json:"-"`
+	// Missing is true when the value to set back isn't known since the values of the snapshot
+	// weren't decrypted
+	Missing bool `json:"missing,omitempty"`
+}
+
+func envHistoryDir(dir string) string {
+	return filepath.Join(dir, iproject.AgentuityDir, EnvHistoryDir)
+}
+
+func withoutAgentuityEnv(values map[string]string) map[string]string {
+	result := make(map[string]string)
+	for k, v := range values {
+		if !isAgentuityEnv.MatchString(k) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// hashValues returns the hash of the value of each key keyed with the salt of the snapshot
+func (s *EnvSnapshot) hashValues(values map[string]string) map[string]string {
+	hashes := make(map[string]string)
+	for k, v := range withoutAgentuityEnv(values) {
+		mac := hmac.New(sha256.New, []byte(s.Salt))
+		mac.Write([]byte(k))
+		mac.Write([]byte{0})
+		mac.Write([]byte(v))
+		hashes[k] = hex.EncodeToString(mac.Sum(nil))
+	}
+	return hashes
+}
+
+// encryptValues stores the values in the snapshot encrypted with the key
+func (s *EnvSnapshot) encryptValues(key *EnvSnapshotKey, values EnvValues) error {
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	var encrypted bytes.Buffer
+	if _, err := crypto.EncryptFIPSKEMDEMStream(key.PublicKey, bytes.NewReader(buf), &encrypted); err != nil {
+		return fmt.Errorf("error encrypting the values: %w", err)
+	}
+	s.Values = &EncryptedEnvValues{Fingerprint: key.Fingerprint, Data: base64.StdEncoding.EncodeToString(encrypted.Bytes())}
+	return nil
+}
+
+// DecryptValues returns the values of the snapshot decrypted with the private key of the organization
+func (s *EnvSnapshot) DecryptValues(priv *ecdsa.PrivateKey) (*EnvValues, error) {
+	if s.Values == nil {
+		return nil, fmt.Errorf("the snapshot %s has no values", s.ID)
+	}
+	data, err := base64.StdEncoding.DecodeString(s.Values.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the values: %w", err)
+	}
+	var decrypted bytes.Buffer
+	if _, err := crypto.DecryptFIPSKEMDEMStream(priv, bytes.NewReader(data), &decrypted); err != nil {
+		return nil, fmt.Errorf("error decrypting the values (was the snapshot made with the key %s?): %w", s.Values.Fingerprint, err)
+	}
+	var values EnvValues
+	if err := json.Unmarshal(decrypted.Bytes(), &values); err != nil {
+		return nil, fmt.Errorf("error decoding the values: %w", err)
+	}
+	return &values, nil
+}
+
+// SaveEnvSnapshot saves a snapshot of the environment variables and secrets in the project in dir.
+// The values are encrypted with the key when it isn't nil. No snapshot is saved when they're the
+// same as the latest snapshot, which is returned instead.
+func SaveEnvSnapshot(dir string, reason string, env map[string]string, secrets map[string]string, key *EnvSnapshotKey) (*EnvSnapshot, error) {
+	snapshots, err := ListEnvSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		latest := &snapshots[0]
+		if maps.Equal(latest.Env, latest.hashValues(env)) && maps.Equal(latest.Secrets, latest.hashValues(secrets)) {
+			return latest, nil
+		}
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	snapshot := &EnvSnapshot{
+		CreatedAt: time.Now().UTC(),
+		Reason:    reason,
+		Salt:      hex.EncodeToString(salt),
+	}
+	snapshot.Env = snapshot.hashValues(env)
+	snapshot.Secrets = snapshot.hashValues(secrets)
+	if key != nil {
+		if err := snapshot.encryptValues(key, EnvValues{Env: withoutAgentuityEnv(env), Secrets: withoutAgentuityEnv(secrets)}); err != nil {
+			return nil, err
+		}
+	}
+	snapshot.ID = snapshot.CreatedAt.Format("20060102-150405")
+	for i := 2; envSnapshotExists(snapshots, snapshot.ID); i++ {
+		snapshot.ID = fmt.Sprintf("%s-%d", snapshot.CreatedAt.Format("20060102-150405"), i)
+	}
+	historyDir := envHistoryDir(dir)
+	if err := os.MkdirAll(historyDir, 0700); err != nil {
+		return nil, err
+	}
+	buf, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(historyDir, snapshot.ID+".json"), buf, 0600); err != nil {
+		return nil, err
+	}
+	// the new snapshot is the first one so the oldest are at the end
+	if len(snapshots)+1 > MaxEnvSnapshots {
+		for _, old := range snapshots[MaxEnvSnapshots-1:] {
+			os.Remove(filepath.Join(historyDir, old.ID+".json"))
+		}
+	}
+	return snapshot, nil
+}
+
+func envSnapshotExists(snapshots []EnvSnapshot, id string) bool {
+	for _, s := range snapshots {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ListEnvSnapshots returns the snapshots of the project in dir, the most recent first
+func ListEnvSnapshots(dir string) ([]EnvSnapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(envHistoryDir(dir), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []EnvSnapshot{}
+	for _, filename := range matches {
+		buf, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		var snapshot EnvSnapshot
+		if err := json.Unmarshal(buf, &snapshot); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].ID > snapshots[j].ID
+		}
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// FindEnvSnapshot returns the snapshot with the id or a unique prefix of the id
+func FindEnvSnapshot(dir string, id string) (*EnvSnapshot, error) {
+	snapshots, err := ListEnvSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []EnvSnapshot
+	for _, s := range snapshots {
+		if s.ID == id {
+			return &s, nil
+		}
+		if strings.HasPrefix(s.ID, id) {
+			found = append(found, s)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	case 1:
+		return &found[0], nil
+	}
+	return nil, fmt.Errorf("snapshot %s is ambiguous, it matches %d snapshots", id, len(found))
+}
+
+// RevertChanges returns the changes which make the current environment variables and secrets the
+// same as the snapshot, sorted by key. The values to set back are taken from the decrypted values of
+// the snapshot, and the changes whose value isn't known are marked as missing when values is nil.
+func (s *EnvSnapshot) RevertChanges(env map[string]string, secrets map[string]string, values *EnvValues) []EnvChange {
+	if values == nil {
+		values = &EnvValues{}
+	}
+	var changes []EnvChange
+	diff := func(snapshot map[string]string, current map[string]string, previous map[string]string, secret bool) {
+		hashes := s.hashValues(current)
+		for k, hash := range snapshot {
+			cur, ok := hashes[k]
+			if ok && cur == hash {
+				continue
+			}
+			change := EnvChange{Key: k, Secret: secret, Action: "add"}
+			if ok {
+				change.Action = "update"
+			}
+			change.Value, ok = previous[k]
+			change.Missing = !ok
+			changes = append(changes, change)
+		}
+		for k := range hashes {
+			if _, ok := snapshot[k]; !ok {
+				changes = append(changes, EnvChange{Key: k, Secret: secret, Action: "delete"})
+			}
+		}
+	}
+	diff(s.Env, env, values.Env, false)
+	diff(s.Secrets, secrets, values.Secrets, true)
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Key == changes[j].Key {
+			return !changes[i].Secret && changes[j].Secret
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// SnapshotProjectEnv saves a snapshot of the environment variables and secrets of the cloud project
+// before they're changed, with the values encrypted with the active key of the organization when it
+// has one. A failure is logged instead of returned so that it doesn't prevent the change.
+func SnapshotProjectEnv(ctx context.Context, logger logger.Logger, dir string, apiUrl string, token string, projectId string, reason string) {
+	projectData, err := iproject.GetProject(ctx, logger, apiUrl, token, projectId, false, false)
+	if err != nil {
+		logger.Warn("failed to snapshot the environment of the project before the change: %s", err)
+		return
+	}
+	key := activeEnvSnapshotKey(ctx, logger, apiUrl, token, projectData.OrgId)
+	snapshot, err := SaveEnvSnapshot(dir, reason, projectData.Env, projectData.Secrets, key)
+	if err != nil {
+		logger.Warn("failed to snapshot the environment of the project before the change: %s", err)
+		return
+	}
+	logger.Debug("saved the environment snapshot %s before %s", snapshot.ID, reason)
+}
+
+// activeEnvSnapshotKey returns the active encryption key of the organization or nil when it has none,
+// in which case only the hashes of the values are kept
+func activeEnvSnapshotKey(ctx context.Context, logger logger.Logger, apiUrl string, token string, orgId string) *EnvSnapshotKey {
+	orgKeys, err := keys.List(ctx, logger, apiUrl, token, orgId)
+	if err != nil {
+		logger.Debug("failed to list the encryption keys of the organization, the snapshot won't have the values: %s", err)
+		return nil
+	}
+	for _, k := range orgKeys {
+		if !k.Active {
+			continue
+		}
+		pub, fingerprint, err := keys.ParsePublicKey([]byte(k.PublicKey))
+		if err != nil {
+			logger.Debug("failed to parse the encryption key %s of the organization: %s", k.Fingerprint, err)
+			return nil
+		}
+		return &EnvSnapshotKey{PublicKey: pub, Fingerprint: fingerprint}
+	}
+	logger.Debug("the organization has no active encryption key, the snapshot won't have the values")
+	return nil
+}
@@ -0,0 +1,154 @@
+package envutil
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agentuity/cli/internal/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveEnvSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	first, err := SaveEnvSnapshot(dir, "env set", map[string]string{"FOO": "1", "AGENTUITY_SDK_KEY": "x"}, map[string]string{"TOKEN": "tok-7f3a9c"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.CreatedAt.Format("20060102-150405"), first.ID)
+	assert.NotEmpty(t, first.Salt)
+	assert.Equal(t, []string{"FOO"}, slices.Collect(maps.Keys(first.Env)))
+	assert.NotEqual(t, "1", first.Env["FOO"])
+	assert.Nil(t, first.Values)
+
+	filename := filepath.Join(dir, ".agentuity", EnvHistoryDir, first.ID+".json")
+	fi, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(buf), "tok-7f3a9c")
+
+	// the same values as the latest snapshot aren't saved again
+	same, err := SaveEnvSnapshot(dir, "env delete", map[string]string{"FOO": "1"}, map[string]string{"TOKEN": "tok-7f3a9c"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, same.ID)
+	assert.Equal(t, "env set", same.Reason)
+
+	second, err := SaveEnvSnapshot(dir, "env delete", map[string]string{"FOO": "2"}, nil, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.NotEqual(t, first.Salt, second.Salt)
+
+	snapshots, err := ListEnvSnapshots(dir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, second.ID, snapshots[0].ID)
+	assert.Equal(t, first.ID, snapshots[1].ID)
+}
+
+func TestSaveEnvSnapshotEncrypted(t *testing.T) {
+	pair, err := keys.GenerateKeyPair()
+	require.NoError(t, err)
+	pub, fingerprint, err := keys.ParsePublicKey(pair.PublicKey)
+	require.NoError(t, err)
+	priv, err := keys.ParsePrivateKey(pair.PrivateKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	snapshot, err := SaveEnvSnapshot(dir, "env set", map[string]string{"FOO": "1"}, map[string]string{"TOKEN": "tok-7f3a9c", "AGENTUITY_SDK_KEY": "x"}, &EnvSnapshotKey{PublicKey: pub, Fingerprint: fingerprint})
+	require.NoError(t, err)
+	require.NotNil(t, snapshot.Values)
+	assert.Equal(t, fingerprint, snapshot.Values.Fingerprint)
+	buf, err := os.ReadFile(filepath.Join(dir, ".agentuity", EnvHistoryDir, snapshot.ID+".json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(buf), "tok-7f3a9c")
+
+	snapshots, err := ListEnvSnapshots(dir)
+	require.NoError(t, err)
+	values, err := snapshots[0].DecryptValues(priv)
+	require.NoError(t, err)
+	assert.Equal(t, &EnvValues{Env: map[string]string{"FOO": "1"}, Secrets: map[string]string{"TOKEN": "tok-7f3a9c"}}, values)
+
+	other, err := keys.GenerateKeyPair()
+	require.NoError(t, err)
+	otherPriv, err := keys.ParsePrivateKey(other.PrivateKey)
+	require.NoError(t, err)
+	_, err = snapshots[0].DecryptValues(otherPriv)
+	assert.ErrorContains(t, err, fingerprint)
+}
+
+func TestSaveEnvSnapshotPrunes(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, ".agentuity", EnvHistoryDir)
+	require.NoError(t, os.MkdirAll(historyDir, 0700))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range MaxEnvSnapshots {
+		createdAt := start.Add(time.Duration(i) * time.Minute)
+		id := createdAt.Format("20060102-150405")
+		buf := fmt.Sprintf(`{"id":%q,"createdAt":%q,"reason":"env set","env":{"N":"%d"}}`, id, createdAt.Format(time.RFC3339), i)
+		require.NoError(t, os.WriteFile(filepath.Join(historyDir, id+".json"), []byte(buf), 0600))
+	}
+
+	snapshot, err := SaveEnvSnapshot(dir, "env set", map[string]string{"N": "new"}, nil, nil)
+	require.NoError(t, err)
+	snapshots, err := ListEnvSnapshots(dir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, MaxEnvSnapshots)
+	assert.Equal(t, snapshot.ID, snapshots[0].ID)
+	assert.Equal(t, "20260101-000100", snapshots[len(snapshots)-1].ID)
+}
+
+func TestFindEnvSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, ".agentuity", EnvHistoryDir)
+	require.NoError(t, os.MkdirAll(historyDir, 0700))
+	for _, id := range []string{"20261015-093012", "20261015-093012-2", "20261014-120000"} {
+		require.NoError(t, os.WriteFile(filepath.Join(historyDir, id+".json"), []byte(fmt.Sprintf(`{"id":%q}`, id)), 0600))
+	}
+
+	snapshot, err := FindEnvSnapshot(dir, "20261015-093012")
+	require.NoError(t, err)
+	assert.Equal(t, "20261015-093012", snapshot.ID)
+	snapshot, err = FindEnvSnapshot(dir, "20261014")
+	require.NoError(t, err)
+	assert.Equal(t, "20261014-120000", snapshot.ID)
+	_, err = FindEnvSnapshot(dir, "20261015")
+	assert.ErrorContains(t, err, "ambiguous")
+	_, err = FindEnvSnapshot(dir, "2025")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestRevertChanges(t *testing.T) {
+	env := map[string]string{"SAME": "1", "CHANGED": "old", "REMOVED": "x"}
+	secrets := map[string]string{"TOKEN": "secret", "MOVED": "m"}
+	snapshot := &EnvSnapshot{Salt: "salt"}
+	snapshot.Env = snapshot.hashValues(env)
+	snapshot.Secrets = snapshot.hashValues(secrets)
+	currentEnv := map[string]string{"SAME": "1", "CHANGED": "new", "ADDED": "y", "MOVED": "m", "AGENTUITY_SDK_KEY": "k"}
+	currentSecrets := map[string]string{"TOKEN": "other"}
+
+	changes := snapshot.RevertChanges(currentEnv, currentSecrets, &EnvValues{Env: env, Secrets: secrets})
+	assert.Equal(t, []EnvChange{
+		{Key: "ADDED", Action: "delete"},
+		{Key: "CHANGED", Action: "update", Value: "old"},
+		{Key: "MOVED", Action: "delete"},
+		{Key: "MOVED", Secret: true, Action: "add", Value: "m"},
+		{Key: "REMOVED", Action: "add", Value: "x"},
+		{Key: "TOKEN", Secret: true, Action: "update", Value: "secret"},
+	}, changes)
+	assert.Empty(t, snapshot.RevertChanges(env, secrets, nil))
+
+	// without the values only the deletes can be made
+	changes = snapshot.RevertChanges(currentEnv, currentSecrets, nil)
+	var missing []string
+	for _, c := range changes {
+		if c.Missing {
+			missing = append(missing, c.Key)
+		}
+	}
+	assert.Equal(t, []string{"CHANGED", "MOVED", "REMOVED", "TOKEN"}, missing)
+}
@@ -0,0 +1,73 @@
+package envutil
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	iproject "github.com/agentuity/cli/internal/project"
+)
+
+// The classes of the variables in the env policy of the project
+const (
+	// PolicyEnv is a variable which is uploaded as a plain environment variable
+	PolicyEnv = "env"
+	// PolicySecret is a variable which is always uploaded as an encrypted secret
+	PolicySecret = "secret"
+	// PolicyLocalOnly is a variable which is never uploaded to the cloud
+	PolicyLocalOnly = "local-only"
+)
+
+// PolicyClasses are the valid classes of a variable in the env policy
+var PolicyClasses = []string{PolicySecret, PolicyEnv, PolicyLocalOnly}
+
+// EnvPolicy is the class of each variable declared in the env policy of the project. A nil
+// policy means the project has no env policy and the variables are classified by their name.
+type EnvPolicy map[string]string
+
+// LoadEnvPolicy loads the env policy from the project file in dir
+func LoadEnvPolicy(dir string) (EnvPolicy, error) {
+	policy, err := iproject.LoadEnvPolicy(dir)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, nil
+	}
+	for key, class := range policy {
+		if !slices.Contains(PolicyClasses, class) {
+			return nil, fmt.Errorf("invalid env policy %q for %s, must be one of secret, env or local-only", class, key)
+		}
+	}
+	return EnvPolicy(policy), nil
+}
+
+// IsSecret returns true if the variable must be stored as a secret, which is its class when it's
+// declared in the policy or otherwise whether its name looks like a secret
+func (p EnvPolicy) IsSecret(key string) bool {
+	if class, ok := p[key]; ok {
+		return class == PolicySecret
+	}
+	return looksLikeSecret.MatchString(key)
+}
+
+// IsLocalOnly returns true if the variable must never be uploaded to the cloud
+func (p EnvPolicy) IsLocalOnly(key string) bool {
+	return p[key] == PolicyLocalOnly
+}
+
+// Unclassified returns the sorted keys which aren't declared in the policy, or nil if the project
+// has no env policy. The variables of Agentuity are never unclassified.
+func (p EnvPolicy) Unclassified(keys []string) []string {
+	if p == nil {
+		return nil
+	}
+	var unclassified []string
+	for _, key := range keys {
+		if _, ok := p[key]; !ok && !isAgentuityEnv.MatchString(key) {
+			unclassified = append(unclassified, key)
+		}
+	}
+	sort.Strings(unclassified)
+	return unclassified
+}
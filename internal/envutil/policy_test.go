@@ -0,0 +1,32 @@
+package envutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEnvPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFiles(t, dir, map[string]string{"agentuity.yaml": "name: test\n"})
+	policy, err := LoadEnvPolicy(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+	assert.Nil(t, policy.Unclassified([]string{"DEBUG"}))
+	assert.True(t, policy.IsSecret("OPENAI_API_KEY"))
+	assert.False(t, policy.IsLocalOnly("DEBUG"))
+
+	writeEnvFiles(t, dir, map[string]string{"agentuity.yaml": "name: test\nenv:\n  policy:\n    OPENAI_API_KEY: env\n    DATABASE_URL: secret\n    DEBUG: local-only\n"})
+	policy, err = LoadEnvPolicy(dir)
+	assert.NoError(t, err)
+	assert.False(t, policy.IsSecret("OPENAI_API_KEY"))
+	assert.True(t, policy.IsSecret("DATABASE_URL"))
+	assert.True(t, policy.IsSecret("STRIPE_SECRET"))
+	assert.True(t, policy.IsLocalOnly("DEBUG"))
+	assert.False(t, policy.IsLocalOnly("DATABASE_URL"))
+	assert.Equal(t, []string{"REGION", "STRIPE_SECRET"}, policy.Unclassified([]string{"STRIPE_SECRET", "DEBUG", "AGENTUITY_SDK_KEY", "REGION"}))
+
+	writeEnvFiles(t, dir, map[string]string{"agentuity.yaml": "name: test\nenv:\n  policy:\n    DEBUG: public\n"})
+	_, err = LoadEnvPolicy(dir)
+	assert.ErrorContains(t, err, "invalid env policy")
+}
@@ -0,0 +1,131 @@
+package envutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/tui"
+)
+
+var envProfileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// EnvProfileFilename returns the env file for the profile (.env.<profile>) in dir.
+// It returns an error if the profile name is invalid or the file doesn't exist.
+func EnvProfileFilename(dir string, profile string) (string, error) {
+	if !envProfileName.MatchString(profile) {
+		return "", fmt.Errorf("invalid environment profile name: %s", profile)
+	}
+	if slices.Contains(EnvTemplateFileNames, ".env."+profile) {
+		return "", fmt.Errorf("%s is a template file and cannot be used as an environment profile", ".env."+profile)
+	}
+	filename := filepath.Join(dir, ".env."+profile)
+	if _, err := os.Stat(filename); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("environment profile %s not found (expected %s)", profile, filename)
+		}
+		return "", err
+	}
+	return filename, nil
+}
+
+// ListEnvProfiles returns the sorted names of the environment profiles (.env.<name> files) in dir.
+func ListEnvProfiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".env.*"))
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, match := range matches {
+		name := filepath.Base(match)
+		if slices.Contains(EnvTemplateFileNames, name) {
+			continue
+		}
+		profile := strings.TrimPrefix(name, ".env.")
+		if !envProfileName.MatchString(profile) {
+			continue
+		}
+		if fi, err := os.Stat(match); err != nil || fi.IsDir() {
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// EnvProfileConflict is a variable which is defined in some environment profiles but not others
+type EnvProfileConflict struct {
+	Key       string   `json:"key"`
+	DefinedIn []string `json:"definedIn"`
+	MissingIn []string `json:"missingIn"`
+}
+
+// FindEnvProfileConflicts compares the profiles in dir and returns the variables which are not
+// defined in every profile, sorted by key.
+func FindEnvProfileConflicts(dir string, profiles []string) ([]EnvProfileConflict, error) {
+	definedIn := make(map[string][]string)
+	for _, profile := range profiles {
+		le, err := env.ParseEnvFileWithComments(filepath.Join(dir, ".env."+profile))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing .env.%s: %w", profile, err)
+		}
+		seen := make(map[string]bool)
+		for _, ev := range le {
+			if seen[ev.Key] || isAgentuityEnv.MatchString(ev.Key) {
+				continue
+			}
+			seen[ev.Key] = true
+			definedIn[ev.Key] = append(definedIn[ev.Key], profile)
+		}
+	}
+	var conflicts []EnvProfileConflict
+	for key, defined := range definedIn {
+		if len(defined) == len(profiles) {
+			continue
+		}
+		var missing []string
+		for _, profile := range profiles {
+			if !slices.Contains(defined, profile) {
+				missing = append(missing, profile)
+			}
+		}
+		conflicts = append(conflicts, EnvProfileConflict{Key: key, DefinedIn: defined, MissingIn: missing})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return conflicts, nil
+}
+
+// WarnEnvProfileConflicts prints a warning for each variable which is defined in another
+// profile but missing from the selected profile.
+func WarnEnvProfileConflicts(dir string, profile string) error {
+	profiles, err := ListEnvProfiles(dir)
+	if err != nil {
+		return err
+	}
+	conflicts, err := FindEnvProfileConflicts(dir, profiles)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, c := range conflicts {
+		if slices.Contains(c.MissingIn, profile) {
+			missing = append(missing, fmt.Sprintf("%s (defined in %s)", tui.Bold(c.Key), strings.Join(c.DefinedIn, ", ")))
+		}
+	}
+	if len(missing) > 0 {
+		tui.ShowWarning("The environment profile %s is missing variables defined in other profiles:", tui.Bold(profile))
+		for _, m := range missing {
+			fmt.Println("  " + m)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package envutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeEnvFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+}
+
+func TestEnvProfileFilename(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFiles(t, dir, map[string]string{".env.staging": "A=1\n", ".env.example": "A=\n"})
+
+	filename, err := EnvProfileFilename(dir, "staging")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".env.staging"), filename)
+
+	_, err = EnvProfileFilename(dir, "production")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = EnvProfileFilename(dir, "../secrets")
+	assert.ErrorContains(t, err, "invalid")
+
+	_, err = EnvProfileFilename(dir, "example")
+	assert.ErrorContains(t, err, "template")
+}
+
+func TestListEnvProfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFiles(t, dir, map[string]string{
+		".env":             "A=1\n",
+		".env.staging":     "A=1\n",
+		".env.production":  "A=1\n",
+		".env.development": "A=1\n",
+		".env.example":     "A=\n",
+		".env.template":    "A=\n",
+	})
+	profiles, err := ListEnvProfiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"development", "production", "staging"}, profiles)
+}
+
+func TestFindEnvProfileConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFiles(t, dir, map[string]string{
+		".env.staging":    "A=1\nB=2\nAGENTUITY_SDK_KEY=x\n",
+		".env.production": "A=3\nC=4\n",
+	})
+	conflicts, err := FindEnvProfileConflicts(dir, []string{"production", "staging"})
+	assert.NoError(t, err)
+	assert.Equal(t, []EnvProfileConflict{
+		{Key: "B", DefinedIn: []string{"staging"}, MissingIn: []string{"production"}},
+		{Key: "C", DefinedIn: []string{"production"}, MissingIn: []string{"staging"}},
+	}, conflicts)
+}
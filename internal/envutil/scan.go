@@ -0,0 +1,246 @@
+package envutil
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/env"
+)
+
+// ProjectGroup is the group used for variables which are not referenced from within an agent directory
+const ProjectGroup = "project"
+
+var envReferencePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:process\.env|import\.meta\.env|Bun\.env)\.([A-Za-z_][A-Za-z0-9_]*)`),
+	regexp.MustCompile(`(?:process\.env|import\.meta\.env|Bun\.env)\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+	regexp.MustCompile(`os\.environ\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+	regexp.MustCompile(`os\.(?:environ\.get|getenv)\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+}
+
+var scanFileExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".py": true,
+}
+
+var scanSkipDirs = map[string]bool{
+	"node_modules": true, ".venv": true, "venv": true, "__pycache__": true,
+	".git": true, ".agentuity": true, "dist": true, "build": true, ".next": true,
+}
+
+// wellKnownEnvs are runtime provided variables which should never be scaffolded
+var wellKnownEnvs = map[string]bool{
+	"NODE_ENV": true, "PATH": true, "HOME": true, "PWD": true, "USER": true,
+	"SHELL": true, "TMPDIR": true, "PORT": true, "HOSTNAME": true, "CI": true,
+	"DEV": true, "PROD": true, "MODE": true, "BASE_URL": true, "SSR": true,
+}
+
+// EnvReference is an environment variable referenced in the project source
+type EnvReference struct {
+	Key   string
+	Group string
+	Files []string
+	// Description is inferred from the comment next to the first reference or else the code of
+	// the first reference
+	Description string
+}
+
+// IsSecret returns true if the name of the variable looks like a secret
+func (r EnvReference) IsSecret() bool {
+	return looksLikeSecret.MatchString(r.Key)
+}
+
+// envUsage is the first reference to a variable in a file
+type envUsage struct {
+	key     string
+	line    int
+	code    string
+	comment string
+}
+
+var commentPrefixes = []string{"//", "#", "/*", "*"}
+
+// lineComment returns the text of the line if it is a comment
+func lineComment(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, prefix), "*/")), true
+		}
+	}
+	return "", false
+}
+
+// trailingComment returns the comment at the end of a line of code
+func trailingComment(line string) string {
+	for _, sep := range []string{" // ", " # "} {
+		if i := strings.LastIndex(line, sep); i >= 0 {
+			return strings.TrimSpace(line[i+len(sep):])
+		}
+	}
+	return ""
+}
+
+// ScanForEnvironmentVariables walks the project source and returns the environment variables
+// referenced in code. Variables referenced from a file under agentsDir are grouped by the
+// agent directory name, everything else is grouped under ProjectGroup. A variable referenced
+// by more than one agent is grouped under ProjectGroup.
+func ScanForEnvironmentVariables(dir string, agentsDir string) ([]EnvReference, error) {
+	found := make(map[string]*EnvReference)
+	commented := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && scanSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !scanFileExtensions[filepath.Ext(path)] {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		group := ProjectGroup
+		if agentsDir != "" {
+			prefix := strings.TrimSuffix(filepath.ToSlash(filepath.Clean(agentsDir)), "/") + "/"
+			if strings.HasPrefix(rel, prefix) {
+				if name, _, ok := strings.Cut(strings.TrimPrefix(rel, prefix), "/"); ok {
+					group = name
+				}
+			}
+		}
+		usages, err := scanFileForEnvs(path)
+		if err != nil {
+			return err
+		}
+		for _, usage := range usages {
+			ref, ok := found[usage.key]
+			if !ok {
+				ref = &EnvReference{Key: usage.key, Group: group}
+				found[usage.key] = ref
+			} else if ref.Group != group {
+				ref.Group = ProjectGroup
+			}
+			ref.Files = append(ref.Files, rel)
+			// a comment describes the variable better than the code which uses it
+			if usage.comment != "" && !commented[usage.key] {
+				ref.Description = usage.comment
+				commented[usage.key] = true
+			} else if ref.Description == "" {
+				ref.Description = fmt.Sprintf("used in %s:%d: %s", rel, usage.line, util.MaxString(usage.code, 80))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning project for environment variables: %w", err)
+	}
+	results := make([]EnvReference, 0, len(found))
+	for _, ref := range found {
+		results = append(results, *ref)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Group != results[j].Group {
+			if results[i].Group == ProjectGroup {
+				return true
+			}
+			if results[j].Group == ProjectGroup {
+				return false
+			}
+			return results[i].Group < results[j].Group
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results, nil
+}
+
+func scanFileForEnvs(filename string) ([]envUsage, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	seen := make(map[string]bool)
+	var usages []envUsage
+	var comment string
+	var lineno int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineno++
+		if c, ok := lineComment(line); ok {
+			// a block of comment lines is joined up to the code which follows it
+			if comment != "" && c != "" {
+				comment += " "
+			}
+			comment += c
+			continue
+		}
+		for _, re := range envReferencePatterns {
+			for _, match := range re.FindAllStringSubmatch(line, -1) {
+				key := match[1]
+				if seen[key] || wellKnownEnvs[key] || isAgentuityEnv.MatchString(key) {
+					continue
+				}
+				seen[key] = true
+				usage := envUsage{key: key, line: lineno, code: strings.TrimSpace(line), comment: trailingComment(line)}
+				if usage.comment == "" {
+					usage.comment = comment
+				}
+				usages = append(usages, usage)
+			}
+		}
+		comment = ""
+	}
+	return usages, scanner.Err()
+}
+
+// ScaffoldEnvTemplate returns the content of the env template file with any referenced variables
+// which are missing appended in a section per group, and the references which were added.
+// Existing content is preserved as is.
+func ScaffoldEnvTemplate(existing string, existingEnvs []env.EnvLineComment, refs []EnvReference) (string, []EnvReference) {
+	have := make(map[string]bool)
+	for _, ev := range existingEnvs {
+		have[ev.Key] = true
+	}
+	var added []EnvReference
+	var buf strings.Builder
+	buf.WriteString(existing)
+	var group string
+	for _, ref := range refs {
+		if have[ref.Key] {
+			continue
+		}
+		if len(added) == 0 && existing != "" && !strings.HasSuffix(existing, "\n") {
+			buf.WriteString("\n")
+		}
+		if len(added) == 0 || group != ref.Group {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			if ref.Group == ProjectGroup {
+				buf.WriteString("# Project\n")
+			} else {
+				buf.WriteString(fmt.Sprintf("# Agent: %s\n", ref.Group))
+			}
+			group = ref.Group
+		}
+		buf.WriteString(ref.Key + "=\n")
+		added = append(added, ref)
+	}
+	return buf.String(), added
+}
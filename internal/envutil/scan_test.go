@@ -0,0 +1,100 @@
+package envutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/go-common/env"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanForEnvironmentVariables(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/index.ts":                  "const url = process.env.DATABASE_URL;\nconst mode = process.env.NODE_ENV;",
+		"src/agents/weather/index.ts":   "const key = process.env['WEATHER_API_KEY'];\nconst sdk = process.env.AGENTUITY_SDK_KEY;",
+		"src/agents/news/index.ts":      "const key = import.meta.env.NEWS_TOKEN;\nconst url = process.env.DATABASE_URL;",
+		"agents/helper/agent.py":        "import os\nkey = os.environ.get('OPENAI_API_KEY')\nregion = os.getenv(\"REGION\")",
+		"node_modules/pkg/index.js":     "process.env.IGNORED",
+		"src/agents/weather/readme.md":  "process.env.NOT_CODE",
+		"src/agents/weather/client.mjs": "Bun.env.WEATHER_API_KEY",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+
+	refs, err := ScanForEnvironmentVariables(dir, "src/agents")
+	assert.NoError(t, err)
+
+	groups := make(map[string]string)
+	for _, ref := range refs {
+		groups[ref.Key] = ref.Group
+	}
+	assert.Equal(t, map[string]string{
+		"DATABASE_URL":    ProjectGroup,
+		"OPENAI_API_KEY":  ProjectGroup,
+		"REGION":          ProjectGroup,
+		"NEWS_TOKEN":      "news",
+		"WEATHER_API_KEY": "weather",
+	}, groups)
+
+	// project variables are sorted first followed by each agent
+	assert.Equal(t, "DATABASE_URL", refs[0].Key)
+	assert.Equal(t, "NEWS_TOKEN", refs[3].Key)
+	assert.Equal(t, "WEATHER_API_KEY", refs[4].Key)
+	assert.Len(t, refs[4].Files, 2)
+}
+
+func TestScanForEnvironmentVariablesDescription(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/index.ts": "// the key of the OpenAI API\n// from the dashboard\nconst key = process.env.OPENAI_API_KEY;\nconst url = process.env.DATABASE_URL;\nconst region = process.env.REGION // the region of the bucket",
+		"b/agent.py": "import os\n\n# the url of the database\nurl = os.getenv('DATABASE_URL')\nkey = os.environ['OPENAI_API_KEY']",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+	refs, err := ScanForEnvironmentVariables(dir, "")
+	assert.NoError(t, err)
+	descriptions := make(map[string]string)
+	for _, ref := range refs {
+		descriptions[ref.Key] = ref.Description
+	}
+	assert.Equal(t, map[string]string{
+		"OPENAI_API_KEY": "the key of the OpenAI API from the dashboard",
+		"DATABASE_URL":   "the url of the database",
+		"REGION":         "the region of the bucket",
+	}, descriptions)
+	assert.True(t, refs[1].IsSecret())
+	assert.False(t, refs[0].IsSecret())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a/index.ts"), []byte("const url = process.env.QUEUE_URL;"), 0644))
+	refs, err = ScanForEnvironmentVariables(filepath.Join(dir, "a"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "used in index.ts:1: const url = process.env.QUEUE_URL;", refs[0].Description)
+}
+
+func TestScaffoldEnvTemplate(t *testing.T) {
+	refs := []EnvReference{
+		{Key: "DATABASE_URL", Group: ProjectGroup},
+		{Key: "REGION", Group: ProjectGroup},
+		{Key: "WEATHER_API_KEY", Group: "weather"},
+	}
+	existing := "# the region\nREGION=us-east-1"
+	content, added := ScaffoldEnvTemplate(existing, []env.EnvLineComment{{EnvLine: env.EnvLine{Key: "REGION"}}}, refs)
+	assert.Len(t, added, 2)
+	assert.Equal(t, "# the region\nREGION=us-east-1\n\n# Project\nDATABASE_URL=\n\n# Agent: weather\nWEATHER_API_KEY=\n", content)
+
+	content, added = ScaffoldEnvTemplate("", nil, refs[2:])
+	assert.Len(t, added, 1)
+	assert.Equal(t, "# Agent: weather\nWEATHER_API_KEY=\n", content)
+
+	content, added = ScaffoldEnvTemplate(existing, []env.EnvLineComment{{EnvLine: env.EnvLine{Key: "REGION"}}}, refs[1:2])
+	assert.Empty(t, added)
+	assert.Equal(t, existing, content)
+}
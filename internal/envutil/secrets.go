@@ -0,0 +1,191 @@
+package envutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+)
+
+// SecretResolver resolves an env value declared as a reference (such as op://vault/item/field)
+// to the actual secret value from an external secret manager.
+type SecretResolver interface {
+	// Scheme is the URL scheme handled by the resolver (for example "op")
+	Scheme() string
+	// Resolve returns the secret value for the reference, which is passed as written in the env file
+	// since references such as op://My Vault/item/field aren't valid URLs
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+var (
+	secretResolvers     = make(map[string]SecretResolver)
+	secretResolversLock sync.RWMutex
+)
+
+// RegisterSecretResolver registers a resolver for its scheme, replacing any existing resolver.
+func RegisterSecretResolver(resolver SecretResolver) {
+	secretResolversLock.Lock()
+	defer secretResolversLock.Unlock()
+	secretResolvers[resolver.Scheme()] = resolver
+}
+
+func getSecretResolver(scheme string) SecretResolver {
+	secretResolversLock.RLock()
+	defer secretResolversLock.RUnlock()
+	return secretResolvers[scheme]
+}
+
+func init() {
+	RegisterSecretResolver(&onePasswordResolver{})
+	RegisterSecretResolver(&awsSecretsManagerResolver{})
+	RegisterSecretResolver(&vaultResolver{})
+}
+
+// IsSecretReference returns true if the value is a reference handled by a registered resolver.
+func IsSecretReference(val string) bool {
+	scheme, _, ok := strings.Cut(val, "://")
+	if !ok {
+		return false
+	}
+	return getSecretResolver(scheme) != nil
+}
+
+// ResolveSecretReference resolves a single reference to its secret value.
+func ResolveSecretReference(ctx context.Context, val string) (string, error) {
+	scheme, _, ok := strings.Cut(val, "://")
+	if !ok {
+		return "", fmt.Errorf("invalid secret reference: missing scheme")
+	}
+	resolver := getSecretResolver(scheme)
+	if resolver == nil {
+		return "", fmt.Errorf("no secret resolver registered for %s://", scheme)
+	}
+	return resolver.Resolve(ctx, val)
+}
+
+// parseSecretReference parses a reference for the resolvers which use the parts of its URL
+func parseSecretReference(val string) (*url.URL, error) {
+	ref, err := url.Parse(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret reference: %w", err)
+	}
+	return ref, nil
+}
+
+// ResolveSecretReferences returns a copy of the envs with any secret references replaced by the
+// resolved value. The Raw value is left as the reference so the env file is never rewritten with
+// the secret. The returned map contains the keys which were resolved and their values.
+func ResolveSecretReferences(ctx context.Context, logger logger.Logger, envs []env.EnvLineComment) ([]env.EnvLineComment, map[string]string, error) {
+	result := make([]env.EnvLineComment, len(envs))
+	resolved := make(map[string]string)
+	for i, ev := range envs {
+		result[i] = ev
+		if !IsSecretReference(ev.Val) {
+			continue
+		}
+		val, err := ResolveSecretReference(ctx, ev.Val)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error resolving %s: %w", ev.Key, err)
+		}
+		logger.Debug("resolved secret reference for %s", ev.Key)
+		result[i].Val = val
+		resolved[ev.Key] = val
+	}
+	return result, resolved, nil
+}
+
+// runResolverCommand runs an external secret manager CLI and returns its trimmed output
+var runResolverCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("the %s command is required to resolve this secret but was not found in your PATH", name)
+	}
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, name, args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s failed: %s", name, msg)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// onePasswordResolver resolves op://vault/item/field using the 1Password CLI
+type onePasswordResolver struct{}
+
+func (r *onePasswordResolver) Scheme() string { return "op" }
+
+func (r *onePasswordResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	return runResolverCommand(ctx, "op", "read", "--no-newline", ref)
+}
+
+// awsSecretsManagerResolver resolves aws-sm://name[#key][?region=region] using the AWS CLI.
+// If a key is provided, the secret is parsed as JSON and the value of the key is returned.
+type awsSecretsManagerResolver struct{}
+
+func (r *awsSecretsManagerResolver) Scheme() string { return "aws-sm" }
+
+func (r *awsSecretsManagerResolver) Resolve(ctx context.Context, val string) (string, error) {
+	ref, err := parseSecretReference(val)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimPrefix(ref.Host+ref.Path, "/")
+	if name == "" {
+		return "", fmt.Errorf("missing secret name in %s", ref.Redacted())
+	}
+	args := []string{"secretsmanager", "get-secret-value", "--secret-id", name, "--query", "SecretString", "--output", "text"}
+	if region := ref.Query().Get("region"); region != "" {
+		args = append(args, "--region", region)
+	}
+	secret, err := runResolverCommand(ctx, "aws", args...)
+	if err != nil {
+		return "", err
+	}
+	if ref.Fragment == "" {
+		return secret, nil
+	}
+	return jsonField(secret, ref.Fragment)
+}
+
+// vaultResolver resolves vault://path#key using the Vault CLI
+type vaultResolver struct{}
+
+func (r *vaultResolver) Scheme() string { return "vault" }
+
+func (r *vaultResolver) Resolve(ctx context.Context, val string) (string, error) {
+	ref, err := parseSecretReference(val)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimPrefix(ref.Host+ref.Path, "/")
+	if path == "" || ref.Fragment == "" {
+		return "", fmt.Errorf("vault references must be in the format vault://path#key")
+	}
+	return runResolverCommand(ctx, "vault", "kv", "get", "-field="+ref.Fragment, path)
+}
+
+func jsonField(val string, key string) (string, error) {
+	var kv map[string]any
+	if err := json.Unmarshal([]byte(val), &kv); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object so the key %s cannot be read", key)
+	}
+	v, ok := kv[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret", key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
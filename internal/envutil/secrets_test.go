@@ -0,0 +1,97 @@
+package envutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecretReference(t *testing.T) {
+	assert.True(t, IsSecretReference("op://vault/item/field"))
+	assert.True(t, IsSecretReference("aws-sm://prod/db#password"))
+	assert.True(t, IsSecretReference("vault://secret/app#token"))
+	assert.False(t, IsSecretReference("https://example.com"))
+	assert.False(t, IsSecretReference("plain value"))
+	assert.False(t, IsSecretReference(""))
+}
+
+func TestResolveSecretReferences(t *testing.T) {
+	var calls []string
+	orig := runResolverCommand
+	defer func() { runResolverCommand = orig }()
+	runResolverCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		switch name {
+		case "op":
+			return "op-secret", nil
+		case "aws":
+			return `{"password":"aws-secret","port":5432}`, nil
+		case "vault":
+			return "vault-secret", nil
+		}
+		return "", nil
+	}
+
+	envs := []env.EnvLineComment{
+		{EnvLine: env.EnvLine{Key: "PLAIN", Val: "value", Raw: "value"}},
+		{EnvLine: env.EnvLine{Key: "OP", Val: "op://vault/item/field", Raw: "op://vault/item/field"}},
+		{EnvLine: env.EnvLine{Key: "AWS", Val: "aws-sm://prod/db#password", Raw: "aws-sm://prod/db#password"}},
+		{EnvLine: env.EnvLine{Key: "VAULT", Val: "vault://secret/app#token", Raw: "vault://secret/app#token"}},
+	}
+	result, resolved, err := ResolveSecretReferences(context.Background(), logger.NewTestLogger(), envs)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", result[0].Val)
+	assert.Equal(t, "op-secret", result[1].Val)
+	assert.Equal(t, "op://vault/item/field", result[1].Raw)
+	assert.Equal(t, "vault-secret", result[3].Val)
+	assert.Equal(t, map[string]string{"OP": "op-secret", "AWS": "aws-secret", "VAULT": "vault-secret"}, resolved)
+	assert.Equal(t, "op read --no-newline op://vault/item/field", calls[0])
+	assert.Equal(t, "vault kv get -field=token secret/app", calls[2])
+	// the input is not modified
+	assert.Equal(t, "op://vault/item/field", envs[1].Val)
+}
+
+func TestOnePasswordResolverWithSpaces(t *testing.T) {
+	orig := runResolverCommand
+	defer func() { runResolverCommand = orig }()
+	var args []string
+	runResolverCommand = func(ctx context.Context, name string, a ...string) (string, error) {
+		args = a
+		return "op-secret", nil
+	}
+	for _, ref := range []string{"op://My Vault/item/field", "op://vault/My Item/field", "op://My Vault/My Item/api key"} {
+		val, err := ResolveSecretReference(context.Background(), ref)
+		assert.NoError(t, err)
+		assert.Equal(t, "op-secret", val)
+		assert.Equal(t, []string{"read", "--no-newline", ref}, args)
+	}
+	assert.True(t, IsSecretReference("op://My Vault/item/field"))
+}
+
+func TestAWSSecretsManagerResolver(t *testing.T) {
+	orig := runResolverCommand
+	defer func() { runResolverCommand = orig }()
+	var args []string
+	runResolverCommand = func(ctx context.Context, name string, a ...string) (string, error) {
+		args = a
+		return `{"password":"hunter2","port":5432}`, nil
+	}
+	val, err := ResolveSecretReference(context.Background(), "aws-sm://prod/db?region=us-west-2#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", val)
+	assert.Equal(t, []string{"secretsmanager", "get-secret-value", "--secret-id", "prod/db", "--query", "SecretString", "--output", "text", "--region", "us-west-2"}, args)
+
+	val, err = ResolveSecretReference(context.Background(), "aws-sm://prod/db#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", val)
+
+	_, err = ResolveSecretReference(context.Background(), "aws-sm://prod/db#missing")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = ResolveSecretReference(context.Background(), "vault://secret/app")
+	assert.Error(t, err)
+}
@@ -62,12 +62,36 @@ func (e *errSystem) writeCrashReportFile(stackTrace string) string {
 	report.Attributes = e.attributes
 	report.CLIVersion = Version
 	report.StackTrace = stackTrace
-	enc := json.NewEncoder(tmp)
-	enc.SetIndent(" ", " ")
-	enc.Encode(report)
+	buf, err := json.MarshalIndent(report, " ", " ")
+	if err != nil {
+		return ""
+	}
+	// the report is uploaded so the error messages mustn't leak keys or personal data
+	tmp.WriteString(util.NewRedactor(util.DefaultRedactionRules...).Redact(string(buf)) + "\n")
 	return tmp.Name()
 }
 
+// writeLogFile records the error in the structured log file (if enabled) so it can be
+// reviewed later without having to re-run the command
+func (e *errSystem) writeLogFile() {
+	log := util.FileLogger()
+	if log == nil {
+		return
+	}
+	fields := map[string]interface{}{
+		"error_id":   e.id,
+		"error_code": e.code.Code,
+	}
+	for k, v := range e.attributes {
+		fields[k] = v
+	}
+	var errmsg string
+	if e.err != nil {
+		errmsg = e.err.Error()
+	}
+	log.With(fields).Error("%s: %s", e.code.Message, errmsg)
+}
+
 func (e *errSystem) sendReport(filename string) {
 	u, err := url.Parse(e.apiurl)
 	if err != nil {
@@ -98,9 +122,12 @@ func (e *errSystem) sendReport(filename string) {
 // If the program is running in a terminal, it will wait for a key press
 // and then upload the error report to the Agentuity team.
 // If the program is not running in a terminal, it will just exit with a non-zero exit code.
+// The exit code depends on the category of the error, see ExitCode. When the errors are written as
+// JSON (--error-format json), the error is written to stderr as a JSON object instead.
 func (e *errSystem) ShowErrorAndExit() {
 	tui.CancelSpinner() // cancel in case we get an error inside a spinner action
 	stackTrace := string(debug.Stack())
+	exitCode := e.ExitCode()
 	var body strings.Builder
 	if e.message != "" {
 		body.WriteString(e.message + "\n\n")
@@ -135,6 +162,11 @@ func (e *errSystem) ShowErrorAndExit() {
 	detail = append(detail, tui.Bold(tui.PadRight("Help:", 10, " "))+tui.Link(discordURL))
 	detail = append(detail, tui.Bold(tui.PadRight("", 10, " "))+tui.Link("support@agentuity.com"))
 	crashReportFile := e.writeCrashReportFile(stackTrace)
+	e.writeLogFile()
+	if JSONErrors() {
+		e.writeJSON()
+		os.Exit(exitCode)
+	}
 	for _, d := range detail {
 		body.WriteString(tui.Muted(d) + "\n")
 	}
@@ -143,7 +175,7 @@ func (e *errSystem) ShowErrorAndExit() {
 		for k, v := range e.attributes {
 			fmt.Printf("%s: %v\n", k, v)
 		}
-		os.Exit(1)
+		os.Exit(exitCode)
 	}
 	tui.ShowBanner(tui.Warning("☹ Error Detected"), body.String(), false)
 	if isatty.IsTerminal(os.Stdout.Fd()) && Version != "dev" {
@@ -155,5 +187,5 @@ func (e *errSystem) ShowErrorAndExit() {
 		tui.ShowSpinner("Uploading error report...", action)
 		tui.ShowSuccess("We will process the report as soon as possible! 🏃")
 	}
-	os.Exit(1)
+	os.Exit(exitCode)
 }
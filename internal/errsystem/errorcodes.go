@@ -3,135 +3,183 @@ package errsystem
 
 var (
 	ErrDeleteAgents = errorType{
-		Code:    "CLI-0001",
-		Message: "Failed to delete agents",
+		Code:     "CLI-0001",
+		Message:  "Failed to delete agents",
+		Category: "api",
 	}
 	ErrCreateProject = errorType{
-		Code:    "CLI-0002",
-		Message: "Failed to create project",
+		Code:     "CLI-0002",
+		Message:  "Failed to create project",
+		Category: "general",
 	}
 	ErrAuthenticateUser = errorType{
-		Code:    "CLI-0003",
-		Message: "Unable to authenticate user",
+		Code:     "CLI-0003",
+		Message:  "Unable to authenticate user",
+		Category: "auth",
 	}
 	ErrEnvironmentVariablesNotSet = errorType{
-		Code:    "CLI-0004",
-		Message: "Environment variables not set",
+		Code:     "CLI-0004",
+		Message:  "Environment variables not set",
+		Category: "validation",
 	}
 	ErrApiRequest = errorType{
-		Code:    "CLI-0005",
-		Message: "API request failed",
+		Code:     "CLI-0005",
+		Message:  "API request failed",
+		Category: "api",
 	}
 	ErrInvalidConfiguration = errorType{
-		Code:    "CLI-0006",
-		Message: "Invalid configuration",
+		Code:     "CLI-0006",
+		Message:  "Invalid configuration",
+		Category: "validation",
 	}
 	ErrSaveProject = errorType{
-		Code:    "CLI-0007",
-		Message: "Failed to save project",
+		Code:     "CLI-0007",
+		Message:  "Failed to save project",
+		Category: "general",
 	}
 	ErrDeployProject = errorType{
-		Code:    "CLI-0008",
-		Message: "Failed to deploy project",
+		Code:     "CLI-0008",
+		Message:  "Failed to deploy project",
+		Category: "general",
 	}
 	ErrUploadProject = errorType{
-		Code:    "CLI-0009",
-		Message: "Failed to upload project",
+		Code:     "CLI-0009",
+		Message:  "Failed to upload project",
+		Category: "api",
 	}
 	ErrParseEnvironmentFile = errorType{
-		Code:    "CLI-0010",
-		Message: "Failed to parse environment file",
+		Code:     "CLI-0010",
+		Message:  "Failed to parse environment file",
+		Category: "validation",
 	}
 	ErrInvalidCommandFlag = errorType{
-		Code:    "CLI-0011",
-		Message: "Invalid command flag error",
+		Code:     "CLI-0011",
+		Message:  "Invalid command flag error",
+		Category: "validation",
 	}
 	ErrListFilesAndDirectories = errorType{
-		Code:    "CLI-0012",
-		Message: "Failed to list files and directories",
+		Code:     "CLI-0012",
+		Message:  "Failed to list files and directories",
+		Category: "general",
 	}
 	ErrWriteConfigurationFile = errorType{
-		Code:    "CLI-0013",
-		Message: "Failed to write configuration file",
+		Code:     "CLI-0013",
+		Message:  "Failed to write configuration file",
+		Category: "general",
 	}
 	ErrReadConfigurationFile = errorType{
-		Code:    "CLI-0014",
-		Message: "Failed to read configuration file",
+		Code:     "CLI-0014",
+		Message:  "Failed to read configuration file",
+		Category: "general",
 	}
 	ErrCreateDirectory = errorType{
-		Code:    "CLI-0015",
-		Message: "Failed to create directory",
+		Code:     "CLI-0015",
+		Message:  "Failed to create directory",
+		Category: "general",
 	}
 	ErrCreateTemporaryFile = errorType{
-		Code:    "CLI-0016",
-		Message: "Failed to create temporary file",
+		Code:     "CLI-0016",
+		Message:  "Failed to create temporary file",
+		Category: "general",
 	}
 	ErrCreateZipFile = errorType{
-		Code:    "CLI-0017",
-		Message: "Failed to create zip file",
+		Code:     "CLI-0017",
+		Message:  "Failed to create zip file",
+		Category: "general",
 	}
 	ErrOpenFile = errorType{
-		Code:    "CLI-0018",
-		Message: "Failed to open file",
+		Code:     "CLI-0018",
+		Message:  "Failed to open file",
+		Category: "general",
 	}
 	ErrLoadTemplates = errorType{
-		Code:    "CLI-0019",
-		Message: "Failed to load templates",
+		Code:     "CLI-0019",
+		Message:  "Failed to load templates",
+		Category: "general",
 	}
 	ErrAuthenticateOtelServer = errorType{
-		Code:    "CLI-0020",
-		Message: "Failed to authenticate with otel server",
+		Code:     "CLI-0020",
+		Message:  "Failed to authenticate with otel server",
+		Category: "auth",
 	}
 	ErrInstallDependencies = errorType{
-		Code:    "CLI-0021",
-		Message: "Failed to install dependencies",
+		Code:     "CLI-0021",
+		Message:  "Failed to install dependencies",
+		Category: "general",
 	}
 	ErrImportingProject = errorType{
-		Code:    "CLI-0022",
-		Message: "Error importing project",
+		Code:     "CLI-0022",
+		Message:  "Error importing project",
+		Category: "general",
 	}
 	ErrEncryptingDeploymentZipFile = errorType{
-		Code:    "CLI-0023",
-		Message: "Error encrypting deployment zip file",
+		Code:     "CLI-0023",
+		Message:  "Error encrypting deployment zip file",
+		Category: "general",
 	}
 	ErrAddingGithubActionWorkflowProject = errorType{
-		Code:    "CLI-0024",
-		Message: "Error adding GitHub Action Workflow to the project",
+		Code:     "CLI-0024",
+		Message:  "Error adding GitHub Action Workflow to the project",
+		Category: "general",
 	}
 	ErrUpgradeCli = errorType{
-		Code:    "CLI-0025",
-		Message: "Failed to upgrade CLI",
+		Code:     "CLI-0025",
+		Message:  "Failed to upgrade CLI",
+		Category: "general",
 	}
 	ErrFetchApiKeys = errorType{
-		Code:    "CLI-0026",
-		Message: "Failed to fetch API keys",
+		Code:     "CLI-0026",
+		Message:  "Failed to fetch API keys",
+		Category: "api",
 	}
 	ErrCreateApiKey = errorType{
-		Code:    "CLI-0027",
-		Message: "Failed to create API key",
+		Code:     "CLI-0027",
+		Message:  "Failed to create API key",
+		Category: "api",
 	}
 	ErrDeleteApiKey = errorType{
-		Code:    "CLI-0028",
-		Message: "Failed to delete API key",
+		Code:     "CLI-0028",
+		Message:  "Failed to delete API key",
+		Category: "api",
 	}
 	ErrRetrieveDevmodeEndpoint = errorType{
-		Code:    "CLI-0029",
-		Message: "Failed to retrieve devmode endpoint",
+		Code:     "CLI-0029",
+		Message:  "Failed to retrieve devmode endpoint",
+		Category: "api",
 	}
 	ErrBreakingChangeMigrationRequired = errorType{
-		Code:    "CLI-0030",
-		Message: "Breaking change migration required",
+		Code:     "CLI-0030",
+		Message:  "Breaking change migration required",
+		Category: "validation",
 	}
 	ErrSdkUpdateRequired = errorType{
-		Code:    "CLI-0031",
-		Message: "SDK update required",
+		Code:     "CLI-0031",
+		Message:  "SDK update required",
+		Category: "validation",
 	}
 	ErrInvalidArgumentProvided = errorType{
-		Code:    "CLI-0032",
-		Message: "Invalid argument provided",
+		Code:     "CLI-0032",
+		Message:  "Invalid argument provided",
+		Category: "validation",
 	}
 	ErrMissingRequiredArgument = errorType{
-		Code:    "CLI-0033",
-		Message: "Missing required argument",
+		Code:     "CLI-0033",
+		Message:  "Missing required argument",
+		Category: "validation",
+	}
+	ErrInsufficientPermission = errorType{
+		Code:     "CLI-0034",
+		Message:  "Insufficient permission",
+		Category: "auth",
+	}
+	ErrOperationCancelledByUser = errorType{
+		Code:     "CLI-0035",
+		Message:  "Operation cancelled by user",
+		Category: "cancelled",
+	}
+	ErrOperationPartiallyCompleted = errorType{
+		Code:     "CLI-0036",
+		Message:  "Operation partially completed",
+		Category: "partial",
 	}
 )
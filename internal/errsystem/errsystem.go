@@ -6,7 +6,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/agentuity/cli/internal/util"
 	"github.com/google/uuid"
@@ -14,8 +13,9 @@ import (
 )
 
 type errorType struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Category string `json:"category"`
 }
 
 type errSystem struct {
@@ -35,11 +35,11 @@ func New(code errorType, err error, opts ...option) *errSystem {
 	// instead of showing the error message since this is likely a user
 	// interruption
 	if errors.Is(err, context.Canceled) {
-		os.Exit(1)
+		ShowCancelledAndExit()
 	}
 	var apiErr *util.APIError
 	if errors.As(err, &apiErr) && apiErr != nil && errors.Is(apiErr.TheError, context.Canceled) {
-		os.Exit(1)
+		ShowCancelledAndExit()
 	}
 	res := &errSystem{
 		id:         uuid.New().String(),
@@ -66,6 +66,11 @@ func (e *errSystem) Error() string {
 	return fmt.Sprintf("%s: %s", e.code, e.err.Error())
 }
 
+// ExitCode returns the exit code of the CLI for the error
+func (e *errSystem) ExitCode() int {
+	return ExitCode(e.category())
+}
+
 // WithUserMessage adds a user-friendly message to the error.
 func WithUserMessage(message string, args ...any) option {
 	return func(e *errSystem) {
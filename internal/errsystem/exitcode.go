@@ -0,0 +1,110 @@
+package errsystem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/viper"
+)
+
+// The categories of the error codes in error_codes.yaml
+const (
+	CategoryGeneral    = "general"
+	CategoryValidation = "validation"
+	CategoryAuth       = "auth"
+	CategoryAPI        = "api"
+	CategoryCancelled  = "cancelled"
+	CategoryPartial    = "partial"
+)
+
+// ErrorFormatKey is the configuration key of the format of the errors, which is text or json and
+// is set with the --error-format flag
+const ErrorFormatKey = "preferences.error_format"
+
+// ExitCode returns the exit code of the CLI for the category of an error
+func ExitCode(category string) int {
+	switch category {
+	case CategoryValidation:
+		return util.ExitCodeValidation
+	case CategoryAuth:
+		return util.ExitCodeAuth
+	case CategoryAPI:
+		return util.ExitCodeAPI
+	case CategoryCancelled:
+		return util.ExitCodeCancelled
+	case CategoryPartial:
+		return util.ExitCodePartial
+	}
+	return util.ExitCodeGeneral
+}
+
+// category returns the category of the error. A request which the API refused because of the
+// login or the permissions is an auth error whatever the code used by the command.
+func (e *errSystem) category() string {
+	var apiErr *util.APIError
+	if errors.As(e.err, &apiErr) && apiErr != nil && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden) {
+		return CategoryAuth
+	}
+	if e.code.Category == "" {
+		return CategoryGeneral
+	}
+	return e.code.Category
+}
+
+// JSONErrors returns true when the errors are written as JSON instead of being shown
+func JSONErrors() bool {
+	return viper.GetString(ErrorFormatKey) == "json"
+}
+
+type jsonError struct {
+	ID         string         `json:"id"`
+	Code       string         `json:"code"`
+	Category   string         `json:"category"`
+	ExitCode   int            `json:"exit_code"`
+	Message    string         `json:"message"`
+	Error      string         `json:"error,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+func (e *errSystem) writeJSON() {
+	category := e.category()
+	res := jsonError{
+		ID:         e.id,
+		Code:       e.code.Code,
+		Category:   category,
+		ExitCode:   ExitCode(category),
+		Message:    e.code.Message,
+		Attributes: e.attributes,
+	}
+	if e.message != "" {
+		res.Message = e.message
+	}
+	if e.err != nil {
+		res.Error = e.err.Error()
+	}
+	json.NewEncoder(os.Stderr).Encode(map[string]any{"error": res})
+}
+
+// ShowCancelledAndExit exits the program because the user cancelled the command, such as with
+// Ctrl+C. Nothing is shown unless the errors are written as JSON.
+func ShowCancelledAndExit() {
+	tui.CancelSpinner()
+	if JSONErrors() {
+		e := New(ErrOperationCancelledByUser, nil)
+		e.writeJSON()
+	}
+	os.Exit(util.ExitCodeCancelled)
+}
+
+// ShowUsageErrorAndExit exits the program because of an invalid command, flag or argument which
+// has already been shown by cobra. The error is only written again when the errors are written as JSON.
+func ShowUsageErrorAndExit(err error) {
+	if JSONErrors() {
+		New(ErrInvalidArgumentProvided, err).writeJSON()
+	}
+	os.Exit(util.ExitCodeValidation)
+}
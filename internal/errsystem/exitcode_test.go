@@ -0,0 +1,34 @@
+package errsystem
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 1, ExitCode(CategoryGeneral))
+	assert.Equal(t, 2, ExitCode(CategoryValidation))
+	assert.Equal(t, 3, ExitCode(CategoryAuth))
+	assert.Equal(t, 4, ExitCode(CategoryAPI))
+	assert.Equal(t, 5, ExitCode(CategoryPartial))
+	assert.Equal(t, 130, ExitCode(CategoryCancelled))
+	assert.Equal(t, 1, ExitCode("unknown"))
+}
+
+func TestErrorExitCode(t *testing.T) {
+	assert.Equal(t, util.ExitCodeAPI, New(ErrApiRequest, errors.New("boom")).ExitCode())
+	assert.Equal(t, util.ExitCodeValidation, New(ErrInvalidCommandFlag, errors.New("bad flag")).ExitCode())
+	assert.Equal(t, util.ExitCodeGeneral, New(ErrSaveProject, errors.New("disk full")).ExitCode())
+	assert.Equal(t, util.ExitCodePartial, New(ErrOperationPartiallyCompleted, errors.New("some failed")).ExitCode())
+
+	// a request refused because of the login is an auth error whatever the code
+	assert.Equal(t, util.ExitCodeAuth, New(ErrDeployProject, &util.APIError{Status: 401}).ExitCode())
+	assert.Equal(t, util.ExitCodeAuth, New(ErrApiRequest, &util.APIError{Status: 403}).ExitCode())
+	assert.Equal(t, util.ExitCodeAPI, New(ErrApiRequest, &util.APIError{Status: 500}).ExitCode())
+
+	// an error type without a category
+	assert.Equal(t, util.ExitCodeGeneral, New(errorType{Code: "CLI-9999"}, errors.New("boom")).ExitCode())
+}
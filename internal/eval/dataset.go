@@ -0,0 +1,200 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is a set of cases which are sent to an agent with the outputs scored by the graders
+type Dataset struct {
+	Filename    string         `yaml:"-"`
+	Name        string         `yaml:"name"`
+	Agent       string         `yaml:"agent"`
+	ContentType string         `yaml:"contentType"`
+	Graders     []GraderConfig `yaml:"graders"`
+	Cases       []Case         `yaml:"cases"`
+}
+
+// Case is a single input to send to the agent
+type Case struct {
+	Name        string         `yaml:"name"`
+	Input       any            `yaml:"input"`
+	Expected    any            `yaml:"expected"`
+	ContentType string         `yaml:"contentType"`
+	Graders     []GraderConfig `yaml:"graders"`
+}
+
+// GraderConfig is the configuration of a grader in a dataset
+type GraderConfig struct {
+	Type       string   `yaml:"type"`
+	Name       string   `yaml:"name"`
+	Value      any      `yaml:"value"`
+	Pattern    string   `yaml:"pattern"`
+	IgnoreCase bool     `yaml:"ignoreCase"`
+	Criteria   string   `yaml:"criteria"`
+	Provider   string   `yaml:"provider"`
+	Model      string   `yaml:"model"`
+	Threshold  *float64 `yaml:"threshold"`
+}
+
+// Payload returns the payload and content type to send to the agent for the case. String inputs
+// are sent as is and any other input is sent as JSON.
+func (d *Dataset) Payload(c *Case) (string, string, error) {
+	contentType := c.ContentType
+	if contentType == "" {
+		contentType = d.ContentType
+	}
+	var payload string
+	if str, ok := c.Input.(string); ok {
+		payload = str
+		if contentType == "" {
+			if json.Valid([]byte(str)) {
+				contentType = "application/json"
+			} else {
+				contentType = "text/plain"
+			}
+		}
+	} else {
+		buf, err := json.Marshal(c.Input)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to encode the input of %s: %w", c.Name, err)
+		}
+		payload = string(buf)
+		if contentType == "" {
+			contentType = "application/json"
+		}
+	}
+	return payload, contentType, nil
+}
+
+// GradersFor returns the graders for the case which override the graders of the dataset
+func (d *Dataset) GradersFor(c *Case) []GraderConfig {
+	if len(c.Graders) > 0 {
+		return c.Graders
+	}
+	if len(d.Graders) > 0 {
+		return d.Graders
+	}
+	return []GraderConfig{{Type: "exact"}}
+}
+
+func (d *Dataset) validate() error {
+	if d.Agent == "" {
+		return fmt.Errorf("missing agent")
+	}
+	if len(d.Cases) == 0 {
+		return fmt.Errorf("no cases defined")
+	}
+	names := make(map[string]bool)
+	for i := range d.Cases {
+		c := &d.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case %d", i+1)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate case name %q", c.Name)
+		}
+		names[c.Name] = true
+		if c.Input == nil {
+			return fmt.Errorf("case %q is missing an input", c.Name)
+		}
+		for _, g := range d.GradersFor(c) {
+			if err := g.validate(c); err != nil {
+				return fmt.Errorf("case %q: %w", c.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g GraderConfig) validate(c *Case) error {
+	switch g.Type {
+	case "exact":
+		if g.Value == nil && c.Expected == nil {
+			return fmt.Errorf("the exact grader requires an expected value")
+		}
+	case "regex":
+		if g.Pattern == "" {
+			return fmt.Errorf("the regex grader requires a pattern")
+		}
+		if _, err := regexp.Compile(g.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", g.Pattern, err)
+		}
+	case "llm":
+		if g.Criteria == "" {
+			return fmt.Errorf("the llm grader requires criteria")
+		}
+		if g.Provider != "" {
+			if _, ok := defaultModels[g.Provider]; !ok {
+				return fmt.Errorf("unsupported provider %q", g.Provider)
+			}
+		}
+	case "":
+		return fmt.Errorf("missing grader type")
+	default:
+		return fmt.Errorf("unsupported grader type %q (must be exact, regex or llm)", g.Type)
+	}
+	if g.Threshold != nil && (*g.Threshold < 0 || *g.Threshold > 1) {
+		return fmt.Errorf("the grader threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// LoadDataset loads and validates the dataset file
+func LoadDataset(filename string) (*Dataset, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var dataset Dataset
+	if err := yaml.Unmarshal(buf, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	dataset.Filename = filename
+	if dataset.Name == "" {
+		dataset.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if err := dataset.validate(); err != nil {
+		return nil, fmt.Errorf("invalid dataset %s: %w", filename, err)
+	}
+	return &dataset, nil
+}
+
+// LoadDatasets loads the datasets matching the file patterns
+func LoadDatasets(patterns []string) ([]*Dataset, error) {
+	var datasets []*Dataset
+	seen := make(map[string]string)
+	for _, pattern := range patterns {
+		matches, err := doublestar.FilepathGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no datasets found matching %s", pattern)
+		}
+		for _, filename := range matches {
+			if _, ok := seen[filename]; ok {
+				continue
+			}
+			dataset, err := LoadDataset(filename)
+			if err != nil {
+				return nil, err
+			}
+			for other, name := range seen {
+				if name == dataset.Name {
+					return nil, fmt.Errorf("datasets %s and %s have the same name %q", other, filename, name)
+				}
+			}
+			seen[filename] = dataset.Name
+			datasets = append(datasets, dataset)
+		}
+	}
+	return datasets, nil
+}
@@ -0,0 +1,163 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Grade is the score a grader gave to the output of a case
+type Grade struct {
+	Grader string  `json:"grader"`
+	Score  float64 `json:"score"`
+	Pass   bool    `json:"pass"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// ProviderResolver returns the provider for the llm grader by name
+type ProviderResolver func(name string) (Provider, error)
+
+func (g GraderConfig) label() string {
+	if g.Name != "" {
+		return g.Name
+	}
+	return g.Type
+}
+
+func (g GraderConfig) threshold(def float64) float64 {
+	if g.Threshold != nil {
+		return *g.Threshold
+	}
+	return def
+}
+
+func normalize(val any) (string, bool) {
+	var v any
+	switch x := val.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(x), &v); err != nil {
+			return strings.TrimSpace(x), false
+		}
+	default:
+		v = x
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(val), false
+	}
+	return string(buf), true
+}
+
+func gradeExact(g GraderConfig, c *Case, output string) *Grade {
+	expected := g.Value
+	if expected == nil {
+		expected = c.Expected
+	}
+	var want, got string
+	if str, ok := expected.(string); ok {
+		// string expectations are compared as text unless both sides are JSON
+		want, got = strings.TrimSpace(str), strings.TrimSpace(output)
+		if w, ok := normalize(str); ok {
+			if o, ok := normalize(output); ok {
+				want, got = w, o
+			}
+		}
+	} else {
+		want, _ = normalize(expected)
+		got, _ = normalize(output)
+	}
+	pass := want == got || (g.IgnoreCase && strings.EqualFold(want, got))
+	grade := &Grade{Grader: g.label(), Pass: pass}
+	if pass {
+		grade.Score = 1
+	} else {
+		grade.Reason = fmt.Sprintf("expected %s", truncate(want, 80))
+	}
+	return grade
+}
+
+func gradeRegex(g GraderConfig, output string) (*Grade, error) {
+	pattern := g.Pattern
+	if g.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	grade := &Grade{Grader: g.label(), Pass: re.MatchString(output)}
+	if grade.Pass {
+		grade.Score = 1
+	} else {
+		grade.Reason = fmt.Sprintf("output does not match %s", g.Pattern)
+	}
+	return grade, nil
+}
+
+const judgeSystemPrompt = `You are an impartial judge grading the output of an AI agent against the given criteria.
+Respond only with a JSON object of the form {"score": <number between 0 and 1>, "reason": "<one sentence explaining the score>"}.`
+
+var jsonObjectRegex = regexp.MustCompile(`(?s)\{.*\}`)
+
+func gradeLLM(ctx context.Context, g GraderConfig, c *Case, input string, output string, providers ProviderResolver) (*Grade, error) {
+	name := g.Provider
+	if name == "" {
+		name = "openai"
+	}
+	provider, err := providers(name)
+	if err != nil {
+		return nil, err
+	}
+	model := g.Model
+	if model == "" {
+		model = DefaultModel(name)
+	}
+	var prompt strings.Builder
+	prompt.WriteString("Criteria:\n" + strings.TrimSpace(g.Criteria) + "\n\n")
+	prompt.WriteString("Input:\n" + input + "\n\n")
+	if c.Expected != nil {
+		expected, _ := normalize(c.Expected)
+		prompt.WriteString("Expected output:\n" + expected + "\n\n")
+	}
+	prompt.WriteString("Output:\n" + output + "\n")
+	completion, err := provider.Complete(ctx, model, judgeSystemPrompt, prompt.String())
+	if err != nil {
+		return nil, err
+	}
+	var verdict struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(jsonObjectRegex.FindString(completion)), &verdict); err != nil {
+		return nil, fmt.Errorf("failed to parse the judge response %q: %w", truncate(completion, 80), err)
+	}
+	verdict.Score = max(0, min(1, verdict.Score))
+	return &Grade{
+		Grader: g.label(),
+		Score:  verdict.Score,
+		Pass:   verdict.Score >= g.threshold(0.7),
+		Reason: verdict.Reason,
+	}, nil
+}
+
+// GradeOutput scores the output of the case with the grader
+func GradeOutput(ctx context.Context, g GraderConfig, c *Case, input string, output string, providers ProviderResolver) (*Grade, error) {
+	switch g.Type {
+	case "exact":
+		return gradeExact(g, c, output), nil
+	case "regex":
+		return gradeRegex(g, output)
+	case "llm":
+		return gradeLLM(ctx, g, c, input, output, providers)
+	}
+	return nil, fmt.Errorf("unsupported grader type %q", g.Type)
+}
+
+func truncate(val string, n int) string {
+	if len(val) <= n {
+		return val
+	}
+	return val[:n] + "..."
+}
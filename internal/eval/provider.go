@@ -0,0 +1,168 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Provider completes prompts with a large language model and is used by the llm grader
+type Provider interface {
+	Complete(ctx context.Context, model string, system string, prompt string) (string, error)
+}
+
+var defaultModels = map[string]string{
+	"openai":    "gpt-4o-mini",
+	"anthropic": "claude-3-5-haiku-latest",
+}
+
+var providerEnv = map[string][2]string{
+	"openai":    {"OPENAI_API_KEY", "OPENAI_BASE_URL"},
+	"anthropic": {"ANTHROPIC_API_KEY", "ANTHROPIC_BASE_URL"},
+}
+
+var providerURLs = map[string]string{
+	"openai":    "https://api.openai.com/v1",
+	"anthropic": "https://api.anthropic.com",
+}
+
+// DefaultModel returns the model used by the provider when the grader doesn't specify one
+func DefaultModel(provider string) string {
+	return defaultModels[provider]
+}
+
+// NewProvider returns the provider which sends requests to baseURL
+func NewProvider(name string, baseURL string, apiKey string) (Provider, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	switch name {
+	case "openai":
+		return &openaiProvider{baseURL, apiKey}, nil
+	case "anthropic":
+		return &anthropicProvider{baseURL, apiKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported provider %q", name)
+}
+
+// ResolveProvider returns the provider using the provider API key from the environment when set,
+// the same as the agents do, and otherwise the Agentuity AI Gateway using the project SDK key
+func ResolveProvider(name string, transportURL string, sdkKey func() (string, error)) (Provider, error) {
+	env, ok := providerEnv[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider %q", name)
+	}
+	if apiKey := os.Getenv(env[0]); apiKey != "" {
+		baseURL := os.Getenv(env[1])
+		if baseURL == "" {
+			baseURL = providerURLs[name]
+		}
+		return NewProvider(name, baseURL, apiKey)
+	}
+	key, err := sdkKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("set %s or use a project with an SDK key to use the %s provider", env[0], name)
+	}
+	return NewProvider(name, strings.TrimRight(transportURL, "/")+"/gateway/"+name, key)
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, payload any, result any) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+type openaiProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+func (p *openaiProvider) Complete(ctx context.Context, model string, system string, prompt string) (string, error) {
+	payload := map[string]any{
+		"model":       model,
+		"temperature": 0,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		},
+		"response_format": map[string]string{"type": "json_object"},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, p.baseURL+"/chat/completions", map[string]string{"Authorization": "Bearer " + p.apiKey}, payload, &resp); err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("openai: no completion returned")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+type anthropicProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, model string, system string, prompt string) (string, error) {
+	payload := map[string]any{
+		"model":       model,
+		"max_tokens":  1024,
+		"temperature": 0,
+		"system":      system,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	headers := map[string]string{"x-api-key": p.apiKey, "anthropic-version": "2023-06-01"}
+	if err := postJSON(ctx, p.baseURL+"/v1/messages", headers, payload, &resp); err != nil {
+		return "", fmt.Errorf("anthropic: %w", err)
+	}
+	for _, c := range resp.Content {
+		if c.Type == "text" {
+			return c.Text, nil
+		}
+	}
+	return "", fmt.Errorf("anthropic: no completion returned")
+}
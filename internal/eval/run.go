@@ -0,0 +1,228 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target is the endpoint of the agent a dataset is run against
+type Target struct {
+	Endpoint string
+	APIKey   string
+}
+
+// TargetResolver returns the target for the agent (by name or id) of a dataset
+type TargetResolver func(agent string) (*Target, error)
+
+// Runner runs datasets against agents
+type Runner struct {
+	Targets     TargetResolver
+	Providers   ProviderResolver
+	Concurrency int
+	Client      *http.Client
+}
+
+// CaseResult is the result of running a single case
+type CaseResult struct {
+	Dataset    string  `json:"dataset"`
+	Case       string  `json:"case"`
+	Status     int     `json:"status,omitempty"`
+	Output     string  `json:"output"`
+	DurationMs int64   `json:"durationMs"`
+	Grades     []Grade `json:"grades,omitempty"`
+	Pass       bool    `json:"pass"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Key returns the key which identifies the case across runs
+func (r *CaseResult) Key() string {
+	return r.Dataset + "/" + r.Case
+}
+
+// Report is the result of an eval run
+type Report struct {
+	StartedAt        time.Time    `json:"startedAt"`
+	Results          []CaseResult `json:"results"`
+	Total            int          `json:"total"`
+	Passed           int          `json:"passed"`
+	PassRate         float64      `json:"passRate"`
+	BaselinePassRate *float64     `json:"baselinePassRate,omitempty"`
+	Regressions      []string     `json:"regressions,omitempty"`
+	Fixed            []string     `json:"fixed,omitempty"`
+}
+
+func (r *Runner) invoke(ctx context.Context, target *Target, contentType string, payload string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target.Endpoint, strings.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if target.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+target.APIKey)
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func (r *Runner) runCase(ctx context.Context, d *Dataset, c *Case, target *Target) CaseResult {
+	result := CaseResult{Dataset: d.Name, Case: c.Name}
+	payload, contentType, err := d.Payload(c)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	started := time.Now()
+	status, output, err := r.invoke(ctx, target, contentType, payload)
+	result.DurationMs = time.Since(started).Milliseconds()
+	result.Status = status
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if status > 299 {
+		result.Error = fmt.Sprintf("agent responded with status %d", status)
+		return result
+	}
+	result.Pass = true
+	for _, g := range d.GradersFor(c) {
+		grade, err := GradeOutput(ctx, g, c, payload, output, r.Providers)
+		if err != nil {
+			result.Error = fmt.Sprintf("%s grader failed: %s", g.label(), err)
+			result.Pass = false
+			return result
+		}
+		result.Grades = append(result.Grades, *grade)
+		result.Pass = result.Pass && grade.Pass
+	}
+	return result
+}
+
+// Run runs the cases of the datasets and returns the report
+func (r *Runner) Run(ctx context.Context, datasets []*Dataset) (*Report, error) {
+	report := &Report{StartedAt: time.Now().UTC()}
+	type job struct {
+		index   int
+		dataset *Dataset
+		c       *Case
+		target  *Target
+	}
+	var jobs []job
+	for _, d := range datasets {
+		target, err := r.Targets(d.Agent)
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s: %w", d.Name, err)
+		}
+		for i := range d.Cases {
+			jobs = append(jobs, job{len(jobs), d, &d.Cases[i], target})
+		}
+	}
+	report.Results = make([]CaseResult, len(jobs))
+	concurrency := max(1, r.Concurrency)
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for range min(concurrency, max(1, len(jobs))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				report.Results[j.index] = r.runCase(ctx, j.dataset, j.c, j.target)
+			}
+		}()
+	}
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	report.Total = len(report.Results)
+	for _, result := range report.Results {
+		if result.Pass {
+			report.Passed++
+		}
+	}
+	if report.Total > 0 {
+		report.PassRate = float64(report.Passed) / float64(report.Total)
+	}
+	return report, nil
+}
+
+// Compare records the cases which regressed or were fixed since the baseline run. Only the cases
+// present in both runs are compared.
+func (r *Report) Compare(baseline *Report) {
+	r.Regressions, r.Fixed = nil, nil
+	r.BaselinePassRate = &baseline.PassRate
+	previous := make(map[string]bool)
+	for _, result := range baseline.Results {
+		previous[result.Key()] = result.Pass
+	}
+	for _, result := range r.Results {
+		passed, ok := previous[result.Key()]
+		if !ok {
+			continue
+		}
+		switch {
+		case passed && !result.Pass:
+			r.Regressions = append(r.Regressions, result.Key())
+		case !passed && result.Pass:
+			r.Fixed = append(r.Fixed, result.Key())
+		}
+	}
+	slices.Sort(r.Regressions)
+	slices.Sort(r.Fixed)
+}
+
+// LoadReport loads the report from filename and returns nil if it doesn't exist
+func LoadReport(filename string) (*Report, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(buf, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", filename, err)
+	}
+	return &report, nil
+}
+
+// Save writes the report to filename
+func (r *Report) Save(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(buf, '\n'), 0644)
+}
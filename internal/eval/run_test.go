@@ -0,0 +1,173 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDataset = `agent: greeter
+graders:
+  - type: regex
+    pattern: hello
+    ignoreCase: true
+cases:
+  - name: json
+    input: {"name": "Jane"}
+    expected: {"message": "Hello Jane"}
+    graders:
+      - type: exact
+  - name: text
+    input: hi
+  - input: bye
+`
+
+func writeDataset(t *testing.T, dir string, name string, content string) string {
+	filename := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func TestLoadDatasets(t *testing.T) {
+	dir := t.TempDir()
+	writeDataset(t, dir, "greeting.yaml", testDataset)
+	datasets, err := LoadDatasets([]string{filepath.Join(dir, "*.yaml")})
+	assert.NoError(t, err)
+	assert.Len(t, datasets, 1)
+	d := datasets[0]
+	assert.Equal(t, "greeting", d.Name)
+	assert.Equal(t, "case 3", d.Cases[2].Name)
+
+	payload, contentType, err := d.Payload(&d.Cases[0])
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Jane"}`, payload)
+	assert.Equal(t, "application/json", contentType)
+	payload, contentType, _ = d.Payload(&d.Cases[1])
+	assert.Equal(t, "hi", payload)
+	assert.Equal(t, "text/plain", contentType)
+
+	assert.Equal(t, "exact", d.GradersFor(&d.Cases[0])[0].Type)
+	assert.Equal(t, "regex", d.GradersFor(&d.Cases[1])[0].Type)
+
+	_, err = LoadDatasets([]string{filepath.Join(dir, "missing*.yaml")})
+	assert.Error(t, err)
+
+	bad := writeDataset(t, dir, "bad.yaml", "agent: greeter\ncases:\n  - input: hi\n    graders:\n      - type: fuzzy\n")
+	_, err = LoadDataset(bad)
+	assert.ErrorContains(t, err, `unsupported grader type "fuzzy"`)
+	_, err = LoadDataset(writeDataset(t, dir, "noexpected.yaml", "agent: greeter\ncases:\n  - input: hi\n"))
+	assert.ErrorContains(t, err, "the exact grader requires an expected value")
+}
+
+func TestGradeOutput(t *testing.T) {
+	ctx := context.Background()
+	c := &Case{Expected: map[string]any{"a": 1, "b": []any{"x"}}}
+	grade, err := GradeOutput(ctx, GraderConfig{Type: "exact"}, c, "", `{"b": ["x"], "a": 1}`, nil)
+	assert.NoError(t, err)
+	assert.True(t, grade.Pass)
+	grade, _ = GradeOutput(ctx, GraderConfig{Type: "exact"}, c, "", `{"a": 2}`, nil)
+	assert.False(t, grade.Pass)
+
+	grade, _ = GradeOutput(ctx, GraderConfig{Type: "exact", Value: "Hello"}, c, "", " hello\n", nil)
+	assert.False(t, grade.Pass)
+	grade, _ = GradeOutput(ctx, GraderConfig{Type: "exact", Value: "Hello", IgnoreCase: true}, c, "", " hello\n", nil)
+	assert.True(t, grade.Pass)
+
+	grade, _ = GradeOutput(ctx, GraderConfig{Type: "regex", Pattern: `^\d+$`}, c, "", "123", nil)
+	assert.True(t, grade.Pass)
+	assert.Equal(t, 1.0, grade.Score)
+}
+
+func TestLLMGrader(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "gpt-4o-mini", body.Model)
+		prompt = body.Messages[1].Content
+		w.Write([]byte(`{"choices": [{"message": {"content": "{\"score\": 0.6, \"reason\": \"too terse\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	providers := func(name string) (Provider, error) {
+		return NewProvider(name, server.URL, "secret")
+	}
+	c := &Case{}
+	grade, err := GradeOutput(context.Background(), GraderConfig{Type: "llm", Criteria: "Is friendly"}, c, "hi", "hello", providers)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.6, grade.Score)
+	assert.False(t, grade.Pass)
+	assert.Equal(t, "too terse", grade.Reason)
+	assert.Contains(t, prompt, "Criteria:\nIs friendly")
+	assert.Contains(t, prompt, "Output:\nhello")
+
+	threshold := 0.5
+	grade, _ = GradeOutput(context.Background(), GraderConfig{Type: "llm", Criteria: "Is friendly", Threshold: &threshold}, c, "hi", "hello", providers)
+	assert.True(t, grade.Pass)
+}
+
+func TestRunner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(body), "Jane"):
+			w.Write([]byte(`{"message":"Hello Jane"}`))
+		case string(body) == "bye":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("Hello there"))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dataset, err := LoadDataset(writeDataset(t, dir, "greeting.yaml", testDataset))
+	assert.NoError(t, err)
+	runner := &Runner{
+		Targets: func(agent string) (*Target, error) {
+			assert.Equal(t, "greeter", agent)
+			return &Target{Endpoint: server.URL}, nil
+		},
+		Concurrency: 2,
+	}
+	report, err := runner.Run(context.Background(), []*Dataset{dataset})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Passed)
+	assert.Equal(t, "greeting/json", report.Results[0].Key())
+	assert.True(t, report.Results[0].Pass)
+	assert.True(t, report.Results[1].Pass)
+	assert.Equal(t, "agent responded with status 500", report.Results[2].Error)
+
+	filename := filepath.Join(dir, "runs", "baseline.json")
+	baseline := &Report{Results: []CaseResult{
+		{Dataset: "greeting", Case: "json", Pass: true},
+		{Dataset: "greeting", Case: "text", Pass: false},
+		{Dataset: "greeting", Case: "case 3", Pass: true},
+	}, PassRate: 2.0 / 3}
+	assert.NoError(t, baseline.Save(filename))
+	loaded, err := LoadReport(filename)
+	assert.NoError(t, err)
+	report.Compare(loaded)
+	assert.Equal(t, []string{"greeting/case 3"}, report.Regressions)
+	assert.Equal(t, []string{"greeting/text"}, report.Fixed)
+
+	missing, err := LoadReport(filepath.Join(dir, "missing.json"))
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,235 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// Model is a model available through the AI gateway
+type Model struct {
+	// ID is the provider and the name of the model such as openai/gpt-4o
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+	// ContextWindow is the maximum number of tokens of a request and its response
+	ContextWindow int `json:"contextWindow,omitempty"`
+	// InputPrice and OutputPrice are the prices in USD per million tokens
+	InputPrice  float64 `json:"inputPrice,omitempty"`
+	OutputPrice float64 `json:"outputPrice,omitempty"`
+}
+
+// Settings are the settings of the AI gateway for a project
+type Settings struct {
+	// Defaults are the models used for the requests which don't name a model keyed by provider
+	Defaults  map[string]string `json:"defaults,omitempty"`
+	UpdatedAt string            `json:"updatedAt,omitempty"`
+}
+
+// Limit is the usage allowed for a model in each period
+type Limit struct {
+	Requests int64   `json:"requests,omitempty"`
+	Tokens   int64   `json:"tokens,omitempty"`
+	Cost     float64 `json:"cost,omitempty"`
+	// Period is the period the limit resets after such as day or month
+	Period string `json:"period,omitempty"`
+}
+
+// Usage is the usage of a model by a project
+type Usage struct {
+	Model        string  `json:"model"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"inputTokens"`
+	OutputTokens int64   `json:"outputTokens"`
+	Cost         float64 `json:"cost"`
+	Limit        *Limit  `json:"limit,omitempty"`
+	// PeriodUsage is the usage counted against the limit in its current period
+	PeriodUsage *Usage `json:"periodUsage,omitempty"`
+}
+
+// Key is a gateway key which can only call the AI gateway for a project, optionally restricted to
+// some of the models
+type Key struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	ProjectID  string   `json:"projectId"`
+	Models     []string `json:"models,omitempty"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	CreatedAt  string   `json:"createdAt,omitempty"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	// Value is the key itself, only returned when the key is created
+	Value string `json:"value,omitempty"`
+}
+
+type Response[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// ParseModel returns the provider and the name of a model such as openai/gpt-4o
+func ParseModel(model string) (string, string, error) {
+	provider, name, ok := strings.Cut(strings.TrimSpace(model), "/")
+	if !ok || provider == "" || name == "" {
+		return "", "", fmt.Errorf("invalid model %q, expected <provider>/<model> such as openai/gpt-4o", model)
+	}
+	return provider, name, nil
+}
+
+// Percent returns the highest percentage of the limit used in its current period or -1 if the
+// usage has no limit
+func (u Usage) Percent() float64 {
+	if u.Limit == nil {
+		return -1
+	}
+	period := u
+	if u.PeriodUsage != nil {
+		period = *u.PeriodUsage
+	}
+	percent := -1.0
+	for _, used := range []struct {
+		value float64
+		limit float64
+	}{
+		{float64(period.Requests), float64(u.Limit.Requests)},
+		{float64(period.InputTokens + period.OutputTokens), float64(u.Limit.Tokens)},
+		{period.Cost, u.Limit.Cost},
+	} {
+		if used.limit > 0 {
+			percent = max(percent, used.value*100/used.limit)
+		}
+	}
+	return percent
+}
+
+// ListModels returns the models available through the AI gateway sorted by id, only the ones of
+// the provider when it isn't empty
+func ListModels(ctx context.Context, logger logger.Logger, baseUrl string, token string, provider string) ([]Model, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	path := "/cli/gateway/models"
+	if provider != "" {
+		path += "?provider=" + url.QueryEscape(provider)
+	}
+	var resp Response[[]Model]
+	if err := client.Do("GET", path, nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching the gateway models: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the gateway models: %s", resp.Message)
+	}
+	sort.Slice(resp.Data, func(i, j int) bool {
+		return resp.Data[i].ID < resp.Data[j].ID
+	})
+	return resp.Data, nil
+}
+
+// GetSettings returns the gateway settings of the project, which are empty when none are set
+func GetSettings(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) (*Settings, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Settings]
+	if err := client.Do("GET", fmt.Sprintf("/cli/gateway/project/%s", projectId), nil, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return &Settings{}, nil
+		}
+		return nil, fmt.Errorf("error fetching the gateway settings: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the gateway settings: %s", resp.Message)
+	}
+	if resp.Data == nil {
+		return &Settings{}, nil
+	}
+	return resp.Data, nil
+}
+
+// SetSettings replaces the gateway settings of the project
+func SetSettings(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, settings Settings) (*Settings, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Settings]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/gateway/project/%s", projectId), settings, &resp); err != nil {
+		return nil, fmt.Errorf("error saving the gateway settings: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error saving the gateway settings: %s", resp.Message)
+	}
+	if resp.Data == nil {
+		return &settings, nil
+	}
+	return resp.Data, nil
+}
+
+// GetUsage returns the usage of each model by the project since the date in RFC3339 format,
+// the most expensive first
+func GetUsage(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, since string) ([]Usage, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]Usage]
+	if err := client.Do("GET", fmt.Sprintf("/cli/gateway/project/%s/usage?startDate=%s", projectId, url.QueryEscape(since)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching the gateway usage: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the gateway usage: %s", resp.Message)
+	}
+	sort.SliceStable(resp.Data, func(i, j int) bool {
+		if resp.Data[i].Cost == resp.Data[j].Cost {
+			return resp.Data[i].Model < resp.Data[j].Model
+		}
+		return resp.Data[i].Cost > resp.Data[j].Cost
+	})
+	return resp.Data, nil
+}
+
+// ListKeys returns the gateway keys of the project
+func ListKeys(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) ([]Key, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]Key]
+	if err := client.Do("GET", fmt.Sprintf("/cli/gateway/project/%s/keys", projectId), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching the gateway keys: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the gateway keys: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// CreateKey creates a gateway key for the project which can only call the models, or all the
+// models when empty, until expiresAt in RFC3339 format or forever when empty
+func CreateKey(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, name string, models []string, expiresAt string) (*Key, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Key]
+	body := map[string]any{"name": name, "models": models, "expiresAt": expiresAt}
+	if err := client.Do("POST", fmt.Sprintf("/cli/gateway/project/%s/keys", projectId), body, &resp); err != nil {
+		return nil, fmt.Errorf("error creating the gateway key: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error creating the gateway key: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// DeleteKey revokes the gateway key of the project
+func DeleteKey(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, id string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[string]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/gateway/project/%s/keys/%s", projectId, id), nil, &resp); err != nil {
+		return fmt.Errorf("error deleting the gateway key: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error deleting the gateway key: %s", resp.Message)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModel(t *testing.T) {
+	provider, name, err := ParseModel(" openai/gpt-4o ")
+	require.NoError(t, err)
+	assert.Equal(t, "openai", provider)
+	assert.Equal(t, "gpt-4o", name)
+
+	provider, name, err = ParseModel("groq/meta-llama/llama-4-scout")
+	require.NoError(t, err)
+	assert.Equal(t, "groq", provider)
+	assert.Equal(t, "meta-llama/llama-4-scout", name)
+
+	for _, model := range []string{"gpt-4o", "/gpt-4o", "openai/"} {
+		_, _, err := ParseModel(model)
+		assert.Error(t, err, model)
+	}
+}
+
+func TestUsagePercent(t *testing.T) {
+	assert.Equal(t, -1.0, Usage{Requests: 10}.Percent())
+	assert.Equal(t, -1.0, Usage{Requests: 10, Limit: &Limit{Period: "month"}}.Percent())
+
+	usage := Usage{Requests: 500, InputTokens: 9000, OutputTokens: 1000, Cost: 3, Limit: &Limit{Requests: 1000, Tokens: 20000, Cost: 10}}
+	assert.Equal(t, 50.0, usage.Percent())
+
+	// the limit applies to the usage in its current period rather than the whole range
+	usage.PeriodUsage = &Usage{Requests: 100, InputTokens: 15000, OutputTokens: 1000, Cost: 1}
+	assert.Equal(t, 80.0, usage.Percent())
+}
@@ -1,6 +1,7 @@
 package gravity
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -9,11 +10,13 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"mime"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -55,6 +58,10 @@ type Client struct {
 	clientname      string
 	dynamicHostname bool
 	dynamicProject  bool
+	validator       PayloadValidator
+	recorder        Recorder
+	observer        RequestObserver
+	middleware      Middleware
 	server          *http.Server
 	client          *gravity.GravityClient
 	once            sync.Once
@@ -63,6 +70,57 @@ type Client struct {
 	provider        *cliProvider
 }
 
+// PayloadValidator validates the body of a request to an agent and returns the validation errors
+type PayloadValidator func(agentID string, body []byte) []string
+
+// Exchange is a request proxied to an agent and the response of the agent
+type Exchange struct {
+	AgentID      string
+	Request      *http.Request
+	RequestBody  []byte
+	Status       int
+	Header       http.Header
+	ResponseBody []byte
+	Truncated    bool
+	Started      time.Time
+	Duration     time.Duration
+}
+
+// Recorder records the requests proxied to the agents and their responses
+type Recorder func(exchange Exchange)
+
+// RequestObserver is called with a request to an agent and its body before it is proxied
+type RequestObserver func(agentID string, r *http.Request, body []byte)
+
+// MiddlewareRequest is a request to an agent passed to the middleware
+type MiddlewareRequest struct {
+	AgentID string
+	Method  string
+	Path    string
+	Header  http.Header
+	Body    []byte
+}
+
+// MiddlewareResponse is the response of an agent passed to the middleware or the response the
+// middleware sends in place of calling the agent
+type MiddlewareResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Middleware inspects and changes the requests proxied to the agents and their responses
+type Middleware interface {
+	// OnRequest can change the header and body of the request and returns the response to send
+	// instead of calling the agent or nil to call it
+	OnRequest(req *MiddlewareRequest) (*MiddlewareResponse, error)
+	// OnResponse can change the response of the agent
+	OnResponse(req *MiddlewareRequest, resp *MiddlewareResponse) error
+	// HandlesResponses returns true when OnResponse needs to be called for the request which buffers
+	// the response
+	HandlesResponses() bool
+}
+
 type Config struct {
 	Context         context.Context
 	Logger          logger.Logger
@@ -78,6 +136,10 @@ type Config struct {
 	ClientName      string
 	DynamicHostname bool
 	DynamicProject  bool
+	Validator       PayloadValidator
+	Recorder        Recorder
+	Observer        RequestObserver
+	Middleware      Middleware
 }
 
 func New(config Config) *Client {
@@ -97,7 +159,230 @@ func New(config Config) *Client {
 		clientname:      config.ClientName,
 		dynamicHostname: config.DynamicHostname,
 		dynamicProject:  config.DynamicProject,
+		validator:       config.Validator,
+		recorder:        config.Recorder,
+		observer:        config.Observer,
+		middleware:      config.Middleware,
+	}
+}
+
+// validatePayload validates the JSON body of the request to the agent before it is proxied and
+// responds with the validation errors when the payload is invalid. Bodies which aren't JSON are
+// proxied unchanged.
+func (c *Client) validatePayload(w http.ResponseWriter, r *http.Request) bool {
+	if c.validator == nil || r.Method != http.MethodPost || !isJSONContentType(r.Header.Get("Content-Type")) {
+		return true
+	}
+	agentID := agentIDFromPath(r.URL.Path)
+	if agentID == "" {
+		return true
+	}
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+	errs := c.validator(agentID, body)
+	if len(errs) == 0 {
+		return true
+	}
+	c.logger.Warn("rejected invalid payload for %s: %s", agentID, strings.Join(errs, "; "))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]any{"error": "invalid payload", "details": errs})
+	return false
+}
+
+// isJSONContentType returns true if the content type is application/json or a +json media type
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func agentIDFromPath(path string) string {
+	agentID, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return agentID
+}
+
+// readBody reads the body of the request and replaces it so that it can be proxied
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+type middlewareRequestKey struct{}
+
+// middlewareError responds with the error of the middleware
+func (c *Client) middlewareError(w http.ResponseWriter, agentID string, err error) {
+	c.logger.Error("middleware failed for %s: %s", agentID, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+	json.NewEncoder(w).Encode(map[string]any{"error": "middleware failed", "details": err.Error()})
+}
+
+// applyMiddleware passes the request to the agent through the middleware and returns the request to
+// proxy or false when the middleware responded to it instead
+func (c *Client) applyMiddleware(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	agentID := agentIDFromPath(r.URL.Path)
+	if c.middleware == nil || agentID == "" {
+		return r, true
+	}
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return r, false
+	}
+	req := &MiddlewareRequest{AgentID: agentID, Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+	resp, err := c.middleware.OnRequest(req)
+	if err != nil {
+		c.middlewareError(w, agentID, err)
+		return r, false
+	}
+	if resp != nil {
+		writeMiddlewareResponse(w, resp)
+		return r, false
+	}
+	r.Header = req.Header
+	r.Body = io.NopCloser(bytes.NewReader(req.Body))
+	r.ContentLength = int64(len(req.Body))
+	return r.WithContext(context.WithValue(r.Context(), middlewareRequestKey{}, req)), true
+}
+
+func writeMiddlewareResponse(w http.ResponseWriter, resp *MiddlewareResponse) {
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(resp.Body)
+}
+
+// bufferedWriter holds the response of the agent so that the middleware can change it before it
+// is written to the client
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(buf []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
 	}
+	return w.body.Write(buf)
+}
+
+// Flush does nothing since the response is only written once the middleware has seen it
+func (w *bufferedWriter) Flush() {}
+
+// middlewareResponseHandler passes the responses of the agents through the middleware
+func (c *Client) middlewareResponseHandler(proxy http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, ok := r.Context().Value(middlewareRequestKey{}).(*MiddlewareRequest)
+		if !ok || !c.middleware.HandlesResponses() {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		bw := &bufferedWriter{header: make(http.Header)}
+		proxy.ServeHTTP(bw, r)
+		resp := &MiddlewareResponse{Status: bw.status, Header: bw.header, Body: bw.body.Bytes()}
+		if err := c.middleware.OnResponse(req, resp); err != nil {
+			c.middlewareError(w, req.AgentID, err)
+			return
+		}
+		writeMiddlewareResponse(w, resp)
+	})
+}
+
+// maxRecordedBody is the maximum size of the response body kept for the recorder
+const maxRecordedBody = 1 << 20
+
+// recordingWriter captures the status and body of the response while it is written to the client
+type recordingWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(buf []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if remaining := maxRecordedBody - w.body.Len(); remaining > 0 {
+		w.body.Write(buf[:min(len(buf), remaining)])
+		w.truncated = w.truncated || len(buf) > remaining
+	} else if len(buf) > 0 {
+		w.truncated = true
+	}
+	return w.ResponseWriter.Write(buf)
+}
+
+// Unwrap lets the response controller of the proxy flush the underlying writer
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serveAgent proxies the request to the agent, passing it to the observer and recording the exchange
+// when they are configured
+func (c *Client) serveAgent(proxy http.Handler, w http.ResponseWriter, r *http.Request) {
+	agentID := agentIDFromPath(r.URL.Path)
+	if (c.recorder == nil && c.observer == nil) || r.Method != http.MethodPost || agentID == "" {
+		proxy.ServeHTTP(w, r)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if c.observer != nil {
+		c.observer(agentID, r, body)
+	}
+	if c.recorder == nil {
+		proxy.ServeHTTP(w, r)
+		return
+	}
+	started := time.Now()
+	rw := &recordingWriter{ResponseWriter: w}
+	proxy.ServeHTTP(rw, r)
+	c.recorder(Exchange{
+		AgentID:      agentID,
+		Request:      r,
+		RequestBody:  body,
+		Status:       rw.status,
+		Header:       w.Header(),
+		ResponseBody: rw.body.Bytes(),
+		Truncated:    rw.truncated,
+		Started:      started,
+		Duration:     time.Since(started),
+	})
 }
 
 // APIURL returns the API URL of the client.
@@ -378,6 +663,10 @@ func (c *Client) Start() error {
 
 	proxy := httputil.NewSingleHostReverseProxy(upstreamURL)
 	proxy.FlushInterval = -1
+	var agentHandler http.Handler = proxy
+	if c.middleware != nil {
+		agentHandler = c.middlewareResponseHandler(proxy)
+	}
 
 	server := &http.Server{
 		Addr:                         fmt.Sprintf(":%d", c.proxyPort),
@@ -433,8 +722,15 @@ func (c *Client) Start() error {
 				default:
 				}
 			}
+			r, ok := c.applyMiddleware(w, r)
+			if !ok {
+				return
+			}
+			if !c.validatePayload(w, r) {
+				return
+			}
 			started := time.Now()
-			proxy.ServeHTTP(w, r)
+			c.serveAgent(agentHandler, w, r)
 			tp := r.Header.Get("traceparent")
 			if tp != "" {
 				tok := strings.Split(tp, "-")
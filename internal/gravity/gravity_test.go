@@ -0,0 +1,50 @@
+package gravity
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePayload(t *testing.T) {
+	c := &Client{
+		logger: logger.NewTestLogger(),
+		validator: func(agentID string, body []byte) []string {
+			if string(body) == `{"ok":true}` {
+				return nil
+			}
+			return []string{"invalid"}
+		},
+	}
+	tests := []struct {
+		contentType string
+		body        string
+		valid       bool
+	}{
+		{"application/json", `{"ok":true}`, true},
+		{"application/json; charset=utf-8", `{"ok":false}`, false},
+		{"application/vnd.api+json", `{"ok":false}`, false},
+		{"text/plain", "hello", true},
+		{"application/octet-stream", "\x00\x01", true},
+		{"", "hello", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/agent_123", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		assert.Equal(t, tt.valid, c.validatePayload(w, r), tt.contentType)
+		if tt.valid {
+			// the body is proxied unchanged
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		} else {
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	}
+}
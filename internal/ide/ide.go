@@ -0,0 +1,292 @@
+package ide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentuity/cli/internal/dev"
+	"github.com/agentuity/cli/internal/util"
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/marcozac/go-jsonc"
+)
+
+// Editor is an editor which ide setup writes the configuration of the project for
+type Editor string
+
+const (
+	VSCode Editor = "vscode"
+	Cursor Editor = "cursor"
+)
+
+// Editors are the supported editors
+var Editors = []Editor{VSCode, Cursor}
+
+// ParseEditor returns the editor by name
+func ParseEditor(name string) (Editor, error) {
+	for _, editor := range Editors {
+		if string(editor) == name {
+			return editor, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported editor %q, use vscode or cursor", name)
+}
+
+// mcpServerName is the name the Agentuity MCP server is registered with
+const mcpServerName = "agentuity"
+
+// command is the command the tasks and the MCP server run, which is on the PATH of everyone working
+// on the project rather than the path of this executable
+const command = "agentuity"
+
+// File is a configuration file of the editor
+type File struct {
+	// Path is the path of the file relative to the project directory
+	Path string
+	Data []byte
+	// Changed is set when the file doesn't exist or its content is different
+	Changed bool
+}
+
+// configFile is a configuration file and the function which merges the configuration of the
+// project into it
+type configFile struct {
+	path  string
+	merge func(config map[string]any)
+}
+
+// Setup returns the configuration files of the editor for the project in dir. The settings of the
+// existing files are kept: the tasks, launch configurations and MCP servers are added or replaced by
+// name and the recommended extensions are added.
+func Setup(dir string, p *cproject.Project, editor Editor) ([]File, error) {
+	var runtime string
+	if p.Bundler != nil {
+		runtime = p.Bundler.Runtime
+	}
+	files := []configFile{
+		{".vscode/tasks.json", mergeTasks},
+		{".vscode/launch.json", func(config map[string]any) { mergeLaunch(config, runtime) }},
+		{".vscode/extensions.json", func(config map[string]any) { mergeExtensions(config, runtime) }},
+	}
+	switch editor {
+	case VSCode:
+		files = append(files, configFile{".vscode/mcp.json", func(config map[string]any) { mergeMCPServer(config, "servers", true) }})
+	case Cursor:
+		files = append(files, configFile{".cursor/mcp.json", func(config map[string]any) { mergeMCPServer(config, "mcpServers", false) }})
+	}
+	var result []File
+	for _, f := range files {
+		file, err := render(dir, f.path, f.merge)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// Write writes the changed files to the project directory
+func Write(dir string, files []File) error {
+	for _, f := range files {
+		if !f.Changed {
+			continue
+		}
+		filename := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return fmt.Errorf("failed to create the directory of %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(filename, f.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// stripTrailingCommas removes the commas before the end of the objects and arrays, which VS Code
+// allows in its configuration files
+func stripTrailingCommas(data []byte) []byte {
+	result := make([]byte, 0, len(data))
+	var inString, escaped bool
+	for i, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		} else if c == '"' {
+			inString = true
+		} else if c == ',' {
+			next := bytes.TrimLeft(data[i+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == ']' || next[0] == '}') {
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// render reads the file, which can have comments like the configuration files of VS Code, and
+// returns it with the configuration of the project merged in
+func render(dir string, path string, merge func(config map[string]any)) (File, error) {
+	filename := filepath.Join(dir, path)
+	config := make(map[string]any)
+	var existing []byte
+	if util.Exists(filename) {
+		var err error
+		if existing, err = os.ReadFile(filename); err != nil {
+			return File{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if len(bytes.TrimSpace(existing)) > 0 {
+			data, err := jsonc.Sanitize(existing)
+			if err == nil {
+				err = json.Unmarshal(stripTrailingCommas(data), &config)
+			}
+			if err != nil {
+				return File{}, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+		}
+	}
+	before, _ := json.Marshal(config)
+	merge(config)
+	after, _ := json.Marshal(config)
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return File{}, err
+	}
+	data = append(data, '\n')
+	return File{Path: path, Data: data, Changed: existing == nil || !bytes.Equal(before, after)}, nil
+}
+
+// upsert replaces the item of the list with the same value of the key or adds it
+func upsert(config map[string]any, list string, key string, item map[string]any) {
+	items, _ := config[list].([]any)
+	for i, existing := range items {
+		if m, ok := existing.(map[string]any); ok && m[key] == item[key] {
+			items[i] = item
+			config[list] = items
+			return
+		}
+	}
+	config[list] = append(items, item)
+}
+
+func mergeTasks(config map[string]any) {
+	config["version"] = "2.0.0"
+	upsert(config, "tasks", "label", map[string]any{
+		"label":          "agentuity: dev",
+		"type":           "shell",
+		"command":        command + " dev",
+		"isBackground":   true,
+		"problemMatcher": []any{},
+	})
+	upsert(config, "tasks", "label", map[string]any{
+		"label":          "agentuity: dev (debug)",
+		"type":           "shell",
+		"command":        command + " dev --inspect",
+		"isBackground":   true,
+		"problemMatcher": []any{},
+	})
+	upsert(config, "tasks", "label", map[string]any{
+		"label":          "agentuity: deploy",
+		"type":           "shell",
+		"command":        command + " deploy",
+		"problemMatcher": []any{},
+	})
+}
+
+// launchConfiguration returns the configuration which attaches the debugger of the editor to the
+// agents run by dev --inspect
+func launchConfiguration(runtime string) map[string]any {
+	port := dev.DefaultInspectPort(runtime)
+	switch runtime {
+	case "nodejs":
+		return map[string]any{
+			"name":       "Agentuity: attach to agents",
+			"type":       "node",
+			"request":    "attach",
+			"port":       port,
+			"restart":    true,
+			"sourceMaps": true,
+			"skipFiles":  []any{"<node_internals>/**"},
+		}
+	case "bunjs":
+		return map[string]any{
+			"name":    "Agentuity: attach to agents",
+			"type":    "bun",
+			"request": "attach",
+			"url":     fmt.Sprintf("ws://localhost:%d/agentuity", port),
+		}
+	case "uv":
+		return map[string]any{
+			"name":       "Agentuity: attach to agents",
+			"type":       "debugpy",
+			"request":    "attach",
+			"connect":    map[string]any{"host": "localhost", "port": port},
+			"justMyCode": true,
+		}
+	}
+	return nil
+}
+
+func mergeLaunch(config map[string]any, runtime string) {
+	config["version"] = "0.2.0"
+	if c := launchConfiguration(runtime); c != nil {
+		upsert(config, "configurations", "name", c)
+	} else if _, ok := config["configurations"]; !ok {
+		config["configurations"] = []any{}
+	}
+}
+
+// extensions returns the extensions recommended for the runtime
+func extensions(runtime string) []string {
+	recommended := []string{"redhat.vscode-yaml"}
+	switch runtime {
+	case "bunjs":
+		recommended = append(recommended, "oven.bun-vscode")
+	case "uv":
+		recommended = append(recommended, "ms-python.python", "ms-python.debugpy")
+	}
+	return recommended
+}
+
+func mergeExtensions(config map[string]any, runtime string) {
+	recommendations, _ := config["recommendations"].([]any)
+	for _, ext := range extensions(runtime) {
+		var found bool
+		for _, existing := range recommendations {
+			if existing == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			recommendations = append(recommendations, ext)
+		}
+	}
+	config["recommendations"] = recommendations
+}
+
+// mergeMCPServer registers the Agentuity MCP server under the key, which is servers for VS Code
+// and mcpServers for Cursor. VS Code also needs the type of the transport.
+func mergeMCPServer(config map[string]any, key string, withType bool) {
+	servers, _ := config[key].(map[string]any)
+	if servers == nil {
+		servers = make(map[string]any)
+	}
+	server := map[string]any{
+		"command": command,
+		"args":    []any{"mcp", "run", "--stdio"},
+	}
+	if withType {
+		server["type"] = "stdio"
+	}
+	servers[mcpServerName] = server
+	config[key] = servers
+}
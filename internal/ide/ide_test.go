@@ -0,0 +1,107 @@
+package ide
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cproject "github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nodeProject() *cproject.Project {
+	return &cproject.Project{Bundler: &cproject.Bundler{Language: "javascript", Runtime: "nodejs"}}
+}
+
+func readConfig(t *testing.T, files []File, path string) map[string]any {
+	for _, f := range files {
+		if f.Path == path {
+			var config map[string]any
+			require.NoError(t, json.Unmarshal(f.Data, &config))
+			return config
+		}
+	}
+	t.Fatalf("%s wasn't written", path)
+	return nil
+}
+
+func TestSetup(t *testing.T) {
+	dir := t.TempDir()
+	files, err := Setup(dir, nodeProject(), VSCode)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	for _, f := range files {
+		assert.True(t, f.Changed, f.Path)
+	}
+
+	tasks := readConfig(t, files, ".vscode/tasks.json")
+	assert.Equal(t, "2.0.0", tasks["version"])
+	assert.Len(t, tasks["tasks"], 3)
+
+	launch := readConfig(t, files, ".vscode/launch.json")
+	configuration := launch["configurations"].([]any)[0].(map[string]any)
+	assert.Equal(t, "node", configuration["type"])
+	assert.Equal(t, "attach", configuration["request"])
+	assert.Equal(t, float64(9229), configuration["port"])
+
+	mcp := readConfig(t, files, ".vscode/mcp.json")
+	server := mcp["servers"].(map[string]any)["agentuity"].(map[string]any)
+	assert.Equal(t, "stdio", server["type"])
+	assert.Equal(t, []any{"mcp", "run", "--stdio"}, server["args"])
+
+	// running it again doesn't change the files
+	require.NoError(t, Write(dir, files))
+	files, err = Setup(dir, nodeProject(), VSCode)
+	require.NoError(t, err)
+	for _, f := range files {
+		assert.False(t, f.Changed, f.Path)
+	}
+}
+
+func TestSetupMergesExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vscode"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".vscode", "tasks.json"), []byte(`{
+	// the tasks of the team
+	"version": "2.0.0",
+	"tasks": [
+		{"label": "lint", "type": "shell", "command": "npm run lint"},
+		{"label": "agentuity: dev", "type": "shell", "command": "agentuity dev --port 4000"},
+	]
+}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".vscode", "extensions.json"), []byte(`{"recommendations": ["redhat.vscode-yaml", "biomejs.biome"]}`), 0644))
+
+	p := nodeProject()
+	p.Bundler.Runtime = "uv"
+	files, err := Setup(dir, p, Cursor)
+	require.NoError(t, err)
+
+	tasks := readConfig(t, files, ".vscode/tasks.json")["tasks"].([]any)
+	require.Len(t, tasks, 4)
+	assert.Equal(t, "lint", tasks[0].(map[string]any)["label"])
+	assert.Equal(t, "agentuity dev", tasks[1].(map[string]any)["command"])
+
+	extensions := readConfig(t, files, ".vscode/extensions.json")["recommendations"]
+	assert.Equal(t, []any{"redhat.vscode-yaml", "biomejs.biome", "ms-python.python", "ms-python.debugpy"}, extensions)
+
+	launch := readConfig(t, files, ".vscode/launch.json")
+	assert.Equal(t, "debugpy", launch["configurations"].([]any)[0].(map[string]any)["type"])
+
+	mcp := readConfig(t, files, ".cursor/mcp.json")
+	server := mcp["mcpServers"].(map[string]any)["agentuity"].(map[string]any)
+	assert.Equal(t, "agentuity", server["command"])
+	assert.NotContains(t, server, "type")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".vscode", "launch.json"), []byte(`{"configurations": [`), 0644))
+	_, err = Setup(dir, p, VSCode)
+	assert.ErrorContains(t, err, "failed to parse .vscode/launch.json")
+
+	_, err = ParseEditor("emacs")
+	assert.Error(t, err)
+}
+
+func TestStripTrailingCommas(t *testing.T) {
+	assert.Equal(t, `{"a": [1, 2], "b": ",]", "c": "\",}"}`, string(stripTrailingCommas([]byte(`{"a": [1, 2,], "b": ",]", "c": "\",}",}`))))
+}
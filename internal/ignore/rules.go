@@ -20,6 +20,7 @@ limitations under the License.
 // support ** rules by using doublestar library
 // add more default rules
 // added ability to add additional rules programatically
+// match paths with forward slashes on all platforms
 
 package ignore
 
@@ -30,6 +31,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -154,6 +156,9 @@ func (r *Rules) Ignore(path string, fi os.FileInfo) bool {
 		return false
 	}
 
+	// The rules use forward slashes so the path is matched with forward slashes on Windows too
+	path = filepath.ToSlash(path)
+
 	var fullWildcard bool
 	var matched bool
 
@@ -274,7 +279,7 @@ func (r *Rules) parseRule(rule string) error {
 		// Require path matches the root path.
 		p.match = func(n string, fi os.FileInfo) bool {
 			rule = strings.TrimPrefix(rule, "/")
-			ok, err := doublestar.PathMatch(rule, n)
+			ok, err := doublestar.Match(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -284,7 +289,7 @@ func (r *Rules) parseRule(rule string) error {
 	} else if strings.Contains(rule, "/") {
 		// require structural match.
 		p.match = func(n string, fi os.FileInfo) bool {
-			ok, err := doublestar.PathMatch(rule, n)
+			ok, err := doublestar.Match(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -295,8 +300,8 @@ func (r *Rules) parseRule(rule string) error {
 		p.match = func(n string, fi os.FileInfo) bool {
 			// When there is no slash in the pattern, we evaluate ONLY the
 			// filename.
-			n = filepath.Base(n)
-			ok, err := doublestar.PathMatch(rule, n)
+			n = path.Base(n)
+			ok, err := doublestar.Match(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -1,6 +1,7 @@
 package ignore
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -93,3 +94,16 @@ func TestFullWildcardRulesFilteredOut(t *testing.T) {
 	assert.False(t, rules.Ignore("agentuity.yaml", nil))
 	assert.True(t, rules.Ignore("bar.py", nil))
 }
+
+func TestNativeSeparators(t *testing.T) {
+	rules := Empty()
+	rules.AddDefaults()
+	rules.Add("dist/**")
+	rules.Add("*.log")
+	// on Windows the paths passed in use backslashes while the rules use forward slashes
+	assert.True(t, rules.Ignore(filepath.FromSlash("dist/index.js"), nil))
+	assert.True(t, rules.Ignore(filepath.FromSlash("src/logs/debug.log"), nil))
+	assert.True(t, rules.Ignore(filepath.FromSlash("src/node_modules/lodash/index.js"), nil))
+	assert.True(t, rules.Ignore(filepath.FromSlash("/Users/foobar/example/.agentuity/config.json"), nil))
+	assert.False(t, rules.Ignore(filepath.FromSlash("src/agents/my-agent/index.ts"), nil))
+}
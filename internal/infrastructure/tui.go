@@ -9,6 +9,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/agentuity/cli/internal/errsystem"
 	"github.com/agentuity/go-common/tui"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-shellwords"
@@ -160,7 +161,7 @@ func execAction(ctx context.Context, canExecute bool, instruction string, help s
 	case manual:
 		tui.ShowSuccess("Manually executed")
 	case cancelled:
-		os.Exit(1)
+		errsystem.ShowCancelledAndExit()
 	case edit:
 		editor := os.Getenv("EDITOR")
 		if editor == "" {
@@ -0,0 +1,148 @@
+package keys
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// KeyPair is a PEM encoded organization encryption key pair. The deployment path encrypts
+// the deployment bundle with the public key (see crypto.EncryptFIPSKEMDEMStream) so only
+// ECDSA P-256 keys are supported.
+type KeyPair struct {
+	PrivateKey  []byte
+	PublicKey   []byte
+	Fingerprint string
+}
+
+type OrgKey struct {
+	ID          string `json:"id"`
+	OrgId       string `json:"orgId"`
+	Fingerprint string `json:"fingerprint"`
+	PublicKey   string `json:"publicKey"`
+	Active      bool   `json:"active"`
+	CreatedAt   string `json:"createdAt"`
+	RevokedAt   string `json:"revokedAt,omitempty"`
+}
+
+type Response[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// GenerateKeyPair will generate a new ECDSA P-256 key pair suitable for encrypting deployments.
+func GenerateKeyPair() (*KeyPair, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding private key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding public key: %w", err)
+	}
+	return &KeyPair{
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}),
+		PublicKey:   pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}),
+		Fingerprint: fingerprintDER(pubDER),
+	}, nil
+}
+
+func fingerprintDER(der []byte) string {
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// ParsePublicKey will parse a PEM encoded PKIX public key and return the ECDSA public key and its fingerprint.
+func ParsePublicKey(data []byte) (*ecdsa.PublicKey, string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, "", fmt.Errorf("failed to decode PEM formatted public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("error parsing public key: %w", err)
+	}
+	pubKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, "", fmt.Errorf("unexpected public key type: %T", pub)
+	}
+	return pubKey, fingerprintDER(block.Bytes), nil
+}
+
+// List will return the encryption keys for the organization.
+func List(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string) ([]OrgKey, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]OrgKey]
+	if err := client.Do("GET", fmt.Sprintf("/cli/organization/%s/keys", url.PathEscape(orgId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error listing encryption keys: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error listing encryption keys: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// Upload will upload a PEM encoded public key for the organization. If activate is true, the key
+// will be used to encrypt new deployments and the previously active key will be deactivated.
+func Upload(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string, publicKey []byte, activate bool) (*OrgKey, error) {
+	if _, _, err := ParsePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[OrgKey]
+	if err := client.Do("POST", fmt.Sprintf("/cli/organization/%s/keys", url.PathEscape(orgId)), map[string]any{"publicKey": string(publicKey), "activate": activate}, &resp); err != nil {
+		return nil, fmt.Errorf("error uploading encryption key: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error uploading encryption key: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// GetDeploymentKey will return the key which was used to encrypt the deployment.
+func GetDeploymentKey(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string) (*OrgKey, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*OrgKey]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/deployments/%s/key", url.PathEscape(projectId), url.PathEscape(deploymentId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching deployment encryption key: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching deployment encryption key: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// ParsePrivateKey will parse a PEM encoded PKCS8 private key as written by GenerateKeyPair.
+func ParsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM formatted private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type: %T", key)
+	}
+	return priv, nil
+}
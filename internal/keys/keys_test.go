@@ -0,0 +1,48 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(kp.Fingerprint, "SHA256:"))
+
+	pub, fingerprint, err := ParsePublicKey(kp.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, kp.Fingerprint, fingerprint)
+
+	block, _ := pem.Decode(kp.PrivateKey)
+	assert.NotNil(t, block)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	assert.NoError(t, err)
+	priv, ok := key.(*ecdsa.PrivateKey)
+	assert.True(t, ok)
+
+	// make sure the key pair can be used the same way the deployment is encrypted
+	var encrypted, decrypted bytes.Buffer
+	_, err = crypto.EncryptFIPSKEMDEMStream(pub, strings.NewReader("hello world"), &encrypted)
+	assert.NoError(t, err)
+	_, err = crypto.DecryptFIPSKEMDEMStream(priv, &encrypted, &decrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", decrypted.String())
+}
+
+func TestParsePublicKeyInvalid(t *testing.T) {
+	_, _, err := ParsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	_, _, err = ParsePublicKey(kp.PrivateKey)
+	assert.Error(t, err)
+}
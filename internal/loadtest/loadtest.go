@@ -0,0 +1,226 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Profile is how the request rate changes over the duration of the test
+type Profile string
+
+const (
+	// ProfileConstant sends requests at the target rate for the whole test
+	ProfileConstant Profile = "constant"
+	// ProfileLinear increases the rate linearly to the target rate over the ramp up
+	ProfileLinear Profile = "linear"
+	// ProfileStep increases the rate to the target rate in equal steps over the ramp up
+	ProfileStep Profile = "step"
+)
+
+// Config is the configuration of a load test
+type Config struct {
+	Endpoint       string
+	APIKey         string
+	ContentType    string
+	Payload        []byte
+	RPS            float64
+	Duration       time.Duration
+	Profile        Profile
+	RampUp         time.Duration
+	Steps          int
+	MaxConcurrency int
+	Timeout        time.Duration
+	Client         *http.Client
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	if c.RPS <= 0 {
+		return fmt.Errorf("the request rate must be greater than 0")
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("the duration must be greater than 0")
+	}
+	switch c.Profile {
+	case "", ProfileConstant:
+	case ProfileLinear, ProfileStep:
+		if c.RampUp <= 0 || c.RampUp > c.Duration {
+			return fmt.Errorf("the ramp up must be greater than 0 and no longer than the duration")
+		}
+		if c.Profile == ProfileStep && c.Steps < 1 {
+			return fmt.Errorf("the number of steps must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unsupported profile %q (must be constant, linear or step)", c.Profile)
+	}
+	return nil
+}
+
+// RateAt returns the target request rate at the elapsed time of the test
+func (c *Config) RateAt(elapsed time.Duration) float64 {
+	if elapsed >= c.RampUp {
+		return c.RPS
+	}
+	progress := float64(elapsed) / float64(c.RampUp)
+	switch c.Profile {
+	case ProfileLinear:
+		return c.RPS * progress
+	case ProfileStep:
+		step := math.Floor(progress*float64(c.Steps)) + 1
+		return c.RPS * step / float64(c.Steps)
+	}
+	return c.RPS
+}
+
+// ExpectedRequests returns the approximate number of requests the test will send
+func (c *Config) ExpectedRequests() int {
+	var total float64
+	step := 100 * time.Millisecond
+	for t := time.Duration(0); t < c.Duration; t += step {
+		total += c.RateAt(t) * step.Seconds()
+	}
+	return int(math.Round(total))
+}
+
+// Sample is the result of a single request
+type Sample struct {
+	Offset  time.Duration
+	Latency time.Duration
+	Status  int
+	Error   string
+}
+
+// Throttled returns true if the request was rate limited by the platform
+func (s Sample) Throttled() bool {
+	return s.Status == http.StatusTooManyRequests
+}
+
+// Failed returns true if the request failed for any reason other than throttling
+func (s Sample) Failed() bool {
+	return s.Error != "" || (s.Status > 299 && !s.Throttled())
+}
+
+// Run runs the load test until the duration has elapsed or the context is cancelled. The progress
+// callback, if provided, is called every second with the samples collected so far.
+func Run(ctx context.Context, config Config, progress func(elapsed time.Duration, samples int)) (*Report, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1000
+	}
+
+	var mu sync.Mutex
+	var samples []Sample
+	var dropped int
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+
+	send := func(offset time.Duration) {
+		defer wg.Done()
+		defer func() { <-sem }()
+		sample := Sample{Offset: offset}
+		req, err := http.NewRequestWithContext(ctx, "POST", config.Endpoint, bytes.NewReader(config.Payload))
+		if err != nil {
+			sample.Error = err.Error()
+		} else {
+			if config.ContentType != "" {
+				req.Header.Set("Content-Type", config.ContentType)
+			}
+			if config.APIKey != "" {
+				req.Header.Set("Authorization", "Bearer "+config.APIKey)
+			}
+			started := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				sample.Error = err.Error()
+			} else {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				sample.Status = resp.StatusCode
+			}
+			sample.Latency = time.Since(started)
+		}
+		if ctx.Err() != nil && sample.Error != "" {
+			return // cancelled requests aren't part of the results
+		}
+		mu.Lock()
+		samples = append(samples, sample)
+		mu.Unlock()
+	}
+
+	started := time.Now()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	lastProgress := time.Duration(0)
+	var budget float64
+	last := started
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case now := <-ticker.C:
+			elapsed := now.Sub(started)
+			if elapsed >= config.Duration {
+				break loop
+			}
+			// accumulate the requests owed at the current rate since the last tick
+			budget += config.RateAt(elapsed) * now.Sub(last).Seconds()
+			last = now
+			for budget >= 1 {
+				budget--
+				select {
+				case sem <- struct{}{}:
+					wg.Add(1)
+					go send(elapsed)
+				default:
+					mu.Lock()
+					dropped++
+					mu.Unlock()
+				}
+			}
+			if progress != nil && elapsed-lastProgress >= time.Second {
+				lastProgress = elapsed
+				mu.Lock()
+				count := len(samples)
+				mu.Unlock()
+				progress(elapsed, count)
+			}
+		}
+	}
+	// the report covers the time requests were being sent and not the wait for the last responses
+	elapsed := min(time.Since(started), config.Duration)
+	wg.Wait()
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Offset < samples[j].Offset })
+	return NewReport(config, samples, dropped, elapsed), nil
+}
+
+// Percentile returns the p percentile (0-100) of the sorted durations using the nearest rank
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func statusLabel(s Sample) string {
+	if s.Error != "" {
+		return "error"
+	}
+	return strconv.Itoa(s.Status)
+}
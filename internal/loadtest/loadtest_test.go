@@ -0,0 +1,99 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateAt(t *testing.T) {
+	constant := Config{RPS: 10, Duration: time.Minute}
+	assert.Equal(t, 10.0, constant.RateAt(0))
+	assert.Equal(t, 600, constant.ExpectedRequests())
+
+	linear := Config{RPS: 10, Duration: time.Minute, Profile: ProfileLinear, RampUp: 10 * time.Second}
+	assert.Equal(t, 0.0, linear.RateAt(0))
+	assert.Equal(t, 5.0, linear.RateAt(5*time.Second))
+	assert.Equal(t, 10.0, linear.RateAt(30*time.Second))
+
+	step := Config{RPS: 20, Duration: time.Minute, Profile: ProfileStep, RampUp: 20 * time.Second, Steps: 4}
+	assert.Equal(t, 5.0, step.RateAt(0))
+	assert.Equal(t, 10.0, step.RateAt(6*time.Second))
+	assert.Equal(t, 20.0, step.RateAt(20*time.Second))
+
+	assert.Error(t, (&Config{RPS: 0, Duration: time.Second}).Validate())
+	assert.Error(t, (&Config{RPS: 1, Duration: time.Second, Profile: "spike"}).Validate())
+	assert.Error(t, (&Config{RPS: 1, Duration: time.Second, Profile: ProfileLinear}).Validate())
+	assert.NoError(t, step.Validate())
+}
+
+func TestPercentile(t *testing.T) {
+	var values []time.Duration
+	for i := 1; i <= 100; i++ {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, Percentile(values, 50))
+	assert.Equal(t, 99*time.Millisecond, Percentile(values, 99))
+	assert.Equal(t, 100*time.Millisecond, Percentile(values, 100))
+	assert.Equal(t, time.Duration(0), Percentile(nil, 50))
+}
+
+func TestNewReport(t *testing.T) {
+	config := Config{Endpoint: "http://localhost", RPS: 2, Duration: 2 * time.Second}
+	samples := []Sample{
+		{Offset: 0, Latency: 10 * time.Millisecond, Status: 200},
+		{Offset: 500 * time.Millisecond, Latency: 30 * time.Millisecond, Status: 500},
+		{Offset: time.Second, Latency: 20 * time.Millisecond, Status: 429},
+		{Offset: 1500 * time.Millisecond, Error: "connection refused"},
+	}
+	report := NewReport(config, samples, 1, 2*time.Second)
+	assert.Equal(t, 4, report.Requests)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 1, report.Dropped)
+	assert.Equal(t, 2.0, report.Throughput)
+	assert.Equal(t, 50.0, report.ErrorRate)
+	assert.Equal(t, map[string]int{"200": 1, "500": 1, "429": 1, "error": 1}, report.StatusCodes)
+	assert.Equal(t, &Throttling{FirstAt: 1, AtRPS: 2, Throttled: 1, Percentage: 25}, report.Throttling)
+	assert.Equal(t, 10.0, report.Latency.Min)
+	assert.Equal(t, 30.0, report.Latency.Max)
+	assert.Len(t, report.Timeline, 2)
+	assert.Equal(t, Interval{Second: 1, TargetRPS: 2, Sent: 2, Succeeded: 1, Failed: 1, P50: 10, P95: 30, P99: 30}, report.Timeline[0])
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "second,target_rps,sent,succeeded,failed,throttled,p50_ms,p95_ms,p99_ms", lines[0])
+	assert.Equal(t, "2,2,2,0,1,1,20,20,20", lines[2])
+}
+
+func TestRun(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		if count.Add(1) > 5 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{
+		Endpoint: server.URL,
+		APIKey:   "key",
+		Payload:  []byte("hi"),
+		RPS:      50,
+		Duration: 300 * time.Millisecond,
+	}, nil)
+	assert.NoError(t, err)
+	assert.InDelta(t, 15, report.Requests, 3)
+	assert.Equal(t, 5, report.Succeeded)
+	assert.NotNil(t, report.Throttling)
+	assert.Equal(t, report.Requests-5, report.Throttling.Throttled)
+}
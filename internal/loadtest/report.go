@@ -0,0 +1,185 @@
+package loadtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Latency is the latency distribution of the requests in milliseconds
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Interval is the results of the requests sent during one second of the test
+type Interval struct {
+	Second    int     `json:"second"`
+	TargetRPS float64 `json:"targetRps"`
+	Sent      int     `json:"sent"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Throttled int     `json:"throttled"`
+	P50       float64 `json:"p50"`
+	P95       float64 `json:"p95"`
+	P99       float64 `json:"p99"`
+}
+
+// Throttling describes when the platform started rate limiting the requests
+type Throttling struct {
+	FirstAt    float64 `json:"firstAtSeconds"`
+	AtRPS      float64 `json:"atRps"`
+	Throttled  int     `json:"throttled"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Report is the result of a load test
+type Report struct {
+	Endpoint    string         `json:"endpoint"`
+	Profile     Profile        `json:"profile"`
+	TargetRPS   float64        `json:"targetRps"`
+	Duration    float64        `json:"durationSeconds"`
+	Requests    int            `json:"requests"`
+	Succeeded   int            `json:"succeeded"`
+	Failed      int            `json:"failed"`
+	Dropped     int            `json:"dropped"`
+	Throughput  float64        `json:"throughput"`
+	ErrorRate   float64        `json:"errorRate"`
+	Latency     Latency        `json:"latency"`
+	StatusCodes map[string]int `json:"statusCodes"`
+	Throttling  *Throttling    `json:"throttling,omitempty"`
+	Timeline    []Interval     `json:"timeline"`
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func latencies(samples []Sample) []time.Duration {
+	values := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s.Error == "" {
+			values = append(values, s.Latency)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// NewReport summarizes the samples of the load test
+func NewReport(config Config, samples []Sample, dropped int, elapsed time.Duration) *Report {
+	profile := config.Profile
+	if profile == "" {
+		profile = ProfileConstant
+	}
+	report := &Report{
+		Endpoint:    config.Endpoint,
+		Profile:     profile,
+		TargetRPS:   config.RPS,
+		Duration:    elapsed.Seconds(),
+		Requests:    len(samples),
+		Dropped:     dropped,
+		StatusCodes: make(map[string]int),
+	}
+	var throttled int
+	for _, s := range samples {
+		report.StatusCodes[statusLabel(s)]++
+		switch {
+		case s.Throttled():
+			throttled++
+			if report.Throttling == nil {
+				report.Throttling = &Throttling{FirstAt: s.Offset.Seconds(), AtRPS: config.RateAt(s.Offset)}
+			}
+		case s.Failed():
+			report.Failed++
+		default:
+			report.Succeeded++
+		}
+	}
+	if report.Throttling != nil {
+		report.Throttling.Throttled = throttled
+		report.Throttling.Percentage = float64(throttled) / float64(len(samples)) * 100
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(len(samples)) / elapsed.Seconds()
+	}
+	if len(samples) > 0 {
+		report.ErrorRate = float64(report.Failed) / float64(len(samples)) * 100
+	}
+	if values := latencies(samples); len(values) > 0 {
+		var total time.Duration
+		for _, v := range values {
+			total += v
+		}
+		report.Latency = Latency{
+			Min:  millis(values[0]),
+			Mean: millis(total / time.Duration(len(values))),
+			P50:  millis(Percentile(values, 50)),
+			P90:  millis(Percentile(values, 90)),
+			P95:  millis(Percentile(values, 95)),
+			P99:  millis(Percentile(values, 99)),
+			Max:  millis(values[len(values)-1]),
+		}
+	}
+
+	seconds := int(elapsed.Seconds())
+	if elapsed > time.Duration(seconds)*time.Second {
+		seconds++
+	}
+	buckets := make([][]Sample, seconds)
+	for _, s := range samples {
+		if i := int(s.Offset / time.Second); i < len(buckets) {
+			buckets[i] = append(buckets[i], s)
+		}
+	}
+	for i, bucket := range buckets {
+		interval := Interval{Second: i + 1, TargetRPS: config.RateAt(time.Duration(i) * time.Second), Sent: len(bucket)}
+		for _, s := range bucket {
+			switch {
+			case s.Throttled():
+				interval.Throttled++
+			case s.Failed():
+				interval.Failed++
+			default:
+				interval.Succeeded++
+			}
+		}
+		values := latencies(bucket)
+		interval.P50 = millis(Percentile(values, 50))
+		interval.P95 = millis(Percentile(values, 95))
+		interval.P99 = millis(Percentile(values, 99))
+		report.Timeline = append(report.Timeline, interval)
+	}
+	return report
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the timeline of the report as CSV with one row per second
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"second", "target_rps", "sent", "succeeded", "failed", "throttled", "p50_ms", "p95_ms", "p99_ms"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, i := range r.Timeline {
+		cw.Write([]string{strconv.Itoa(i.Second), f(i.TargetRPS), strconv.Itoa(i.Sent), strconv.Itoa(i.Succeeded), strconv.Itoa(i.Failed), strconv.Itoa(i.Throttled), f(i.P50), f(i.P95), f(i.P99)})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
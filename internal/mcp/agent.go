@@ -6,6 +6,7 @@ import (
 	"slices"
 
 	"github.com/agentuity/cli/internal/agent"
+	iproject "github.com/agentuity/cli/internal/project"
 	"github.com/agentuity/go-common/project"
 	mcp_golang "github.com/agentuity/mcp-golang/v2"
 )
@@ -21,6 +22,13 @@ type ListAgentsArguments struct {
 	Directory string `json:"directory" jsonschema:"required,description=The directory where the project is located"`
 }
 
+type SearchAgentsArguments struct {
+	Pattern    string   `json:"pattern" jsonschema:"required,description=The regular expression to search for in the agent source code"`
+	Agents     []string `json:"agents,omitempty" jsonschema:"description=The names of the agents to limit the search to. If not provided all agents are searched"`
+	IgnoreCase bool     `json:"ignoreCase,omitempty" jsonschema:"description=Match the pattern case insensitively"`
+	Directory  string   `json:"directory" jsonschema:"required,description=The directory where the project is located"`
+}
+
 type DeleteAgentArguments struct {
 	AgentIds  []string `json:"agentIds" jsonschema:"required,description=The IDs of the agents to delete"`
 	Directory string   `json:"directory" jsonschema:"required,description=The directory where the project is located"`
@@ -92,11 +100,34 @@ func init() {
 			}
 			c.Project.Agents = agents
 
-			if err := c.Project.Save(c.ProjectDir); err != nil {
+			if err := iproject.SaveProject(c.ProjectDir, c.Project); err != nil {
 				return nil, fmt.Errorf("failed to save project after agent delete: %w", err)
 			}
 
 			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Successfully deleted %d agent(s): %v", len(deleted), deleted))), nil
 		})
 	})
+
+	register(func(c MCPContext) error {
+		return c.Server.RegisterTool("search_agents", "this is a tool for searching the source code of the Agents in this agentuity cloud platform project. the results are grouped by agent", func(ctx context.Context, args SearchAgentsArguments) (*mcp_golang.ToolResponse, error) {
+			if args.Directory != "" {
+				c.ProjectDir = args.Directory
+			}
+			if resp := ensureProject(&c); resp != nil {
+				return resp, nil
+			}
+			cmdargs := []string{args.Pattern, "--dir", c.ProjectDir, "--format", "json"}
+			for _, agent := range args.Agents {
+				cmdargs = append(cmdargs, "--agent", agent)
+			}
+			if args.IgnoreCase {
+				cmdargs = append(cmdargs, "--ignore-case")
+			}
+			result, err := execCommand(ctx, c.ProjectDir, "grep", cmdargs...)
+			if err != nil {
+				return nil, err
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(result)), nil
+		})
+	})
 }
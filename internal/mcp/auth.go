@@ -33,6 +33,7 @@ func init() {
 				viper.Set("auth.api_key", authResult.APIKey)
 				viper.Set("auth.user_id", authResult.UserId)
 				viper.Set("auth.expires", authResult.Expires.UnixMilli())
+				viper.Set("auth.refresh_token", authResult.RefreshToken)
 				if err := viper.WriteConfig(); err != nil {
 					logger.Error("Failed to write config: %v", err)
 					return
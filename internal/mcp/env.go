@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/agentuity/cli/internal/envutil"
 	"github.com/agentuity/cli/internal/project"
 	mcp_golang "github.com/agentuity/mcp-golang/v2"
 )
@@ -40,7 +41,17 @@ func init() {
 			if strings.HasPrefix(args.Key, "AGENTUITY_") {
 				return mcp_golang.NewToolResponse(mcp_golang.NewTextContent("You cannot set a project environment variable that starts with AGENTUITY_")), nil
 			}
-			var err error
+			policy, err := envutil.LoadEnvPolicy(c.ProjectDir)
+			if err != nil {
+				return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Error loading the env policy: %s", err))), nil
+			}
+			if policy.IsLocalOnly(args.Key) {
+				return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("%s is local-only in the env policy of agentuity.yaml and cannot be set in the project", args.Key))), nil
+			}
+			if policy[args.Key] == envutil.PolicySecret {
+				args.IsSecret = true
+			}
+			envutil.SnapshotProjectEnv(ctx, c.Logger, c.ProjectDir, c.APIURL, c.APIKey, c.Project.ProjectId, "set_project_environment")
 			if args.IsSecret {
 				_, err = project.SetProjectEnv(ctx, c.Logger, c.APIURL, c.APIKey, c.Project.ProjectId, map[string]string{}, map[string]string{args.Key: args.Value})
 			} else {
@@ -84,6 +95,7 @@ func init() {
 			if resp := ensureProject(&c); resp != nil {
 				return resp, nil
 			}
+			envutil.SnapshotProjectEnv(ctx, c.Logger, c.ProjectDir, c.APIURL, c.APIKey, c.Project.ProjectId, "delete_project_environment")
 			if err := project.DeleteProjectEnv(ctx, c.Logger, c.APIURL, c.APIKey, c.Project.ProjectId, args.Keys, args.Keys); err != nil {
 				return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Error deleting environment variable: %s", err))), nil
 			}
@@ -0,0 +1,304 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// prefixModels prefixes the names of the models of the contract which don't start with the prefix
+// so that the models of the contracts of several agents can be generated in the same file
+func (c *Contract) prefixModels(prefix string) {
+	rename := func(name string) string {
+		if name == "" || strings.HasPrefix(name, prefix) {
+			return name
+		}
+		return prefix + name
+	}
+	for i, m := range c.Models {
+		c.Models[i].Name = rename(m.Name)
+		if c.names[m.Schema] == m.Name {
+			c.names[m.Schema] = c.Models[i].Name
+		}
+	}
+	c.RequestType = rename(c.RequestType)
+	c.ResponseType = rename(c.ResponseType)
+}
+
+// clientAgent is an agent of the generated client with the name of its method
+type clientAgent struct {
+	AgentEndpoint
+	Method   string
+	Contract *Contract
+}
+
+// clientAgents returns the agents of the client sorted by name with a unique method name for each
+// agent. The contracts of the agents with a payload schema are keyed by agent id.
+func clientAgents(spec ProjectSpec, contracts map[string]*Contract, methodName func(string) string, reserved []string) ([]clientAgent, error) {
+	agents := make([]clientAgent, 0, len(spec.Agents))
+	for _, a := range spec.Agents {
+		agents = append(agents, clientAgent{AgentEndpoint: a, Contract: contracts[a.ID]})
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	methods := make(map[string]bool)
+	for _, name := range reserved {
+		methods[name] = true
+	}
+	models := make(map[string]string)
+	for i, a := range agents {
+		method := methodName(a.Name)
+		for n := 2; methods[method]; n++ {
+			method = fmt.Sprintf("%s%d", methodName(a.Name), n)
+		}
+		methods[method] = true
+		agents[i].Method = method
+		if a.Contract == nil {
+			continue
+		}
+		a.Contract.prefixModels(strcase.ToCamel(a.Name))
+		for _, m := range a.Contract.Models {
+			if other, ok := models[m.Name]; ok {
+				return nil, fmt.Errorf("the payload schemas of agents %s and %s both define %s", other, a.Name, m.Name)
+			}
+			models[m.Name] = a.Name
+		}
+	}
+	return agents, nil
+}
+
+func clientAuth(a clientAgent) bool {
+	return a.AuthType == "bearer" || a.AuthType == "project"
+}
+
+// GenerateTypeScriptClient generates a TypeScript client of the webhooks of the agents with the
+// types of the payload schemas. The contracts of the agents with a payload schema are keyed by agent id.
+func GenerateTypeScriptClient(spec ProjectSpec, contracts map[string]*Contract) (string, error) {
+	agents, err := clientAgents(spec, contracts, strcase.ToLowerCamel, []string{"constructor", "send"})
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Generated by agentuity client generate for the %s project - do not edit manually\n\n", spec.Title))
+	for _, a := range agents {
+		if a.Contract == nil {
+			continue
+		}
+		for _, m := range a.Contract.Models {
+			sb.WriteString(a.Contract.typeScriptModel(m))
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString(fmt.Sprintf(`/** The response of an agent */
+export interface AgentResponse {
+	status: number;
+	contentType: string;
+	/** The parsed JSON response or the text of the response */
+	data: unknown;
+}
+
+/** The error thrown when an agent responds with an error status */
+export class AgentError extends Error {
+	constructor(
+		readonly agent: string,
+		readonly status: number,
+		readonly body: string,
+	) {
+		super(`+"`agent ${agent} failed with status ${status}: ${body}`"+`);
+		this.name = 'AgentError';
+	}
+}
+
+export interface ClientOptions {
+	/** The URL the webhooks are served from */
+	baseUrl?: string;
+	/** The API key sent to the agents which require authentication */
+	apiKey?: string;
+	/** The API keys of the agents by agent name, which are used instead of apiKey */
+	agentKeys?: Record<string, string>;
+	fetch?: typeof fetch;
+}
+
+/** The client of the agents of the %[1]s project */
+export class AgentuityClient {
+	private readonly baseUrl: string;
+	private readonly apiKey?: string;
+	private readonly agentKeys: Record<string, string>;
+	private readonly fetch: typeof fetch;
+
+	constructor(options: ClientOptions = {}) {
+		this.baseUrl = (options.baseUrl ?? %[2]s).replace(/\/+$/, '');
+		this.apiKey = options.apiKey;
+		this.agentKeys = options.agentKeys ?? {};
+		this.fetch = options.fetch ?? globalThis.fetch.bind(globalThis);
+	}
+
+	private async send(agent: string, path: string, auth: boolean, payload: unknown): Promise<AgentResponse> {
+		const headers: Record<string, string> = {};
+		let body: BodyInit;
+		if (typeof payload === 'string') {
+			headers['Content-Type'] = 'text/plain';
+			body = payload;
+		} else if (payload instanceof Uint8Array || payload instanceof ArrayBuffer) {
+			headers['Content-Type'] = 'application/octet-stream';
+			body = payload as BodyInit;
+		} else {
+			headers['Content-Type'] = 'application/json';
+			body = JSON.stringify(payload);
+		}
+		const key = this.agentKeys[agent] ?? this.apiKey;
+		if (auth && key) {
+			headers.Authorization = `+"`Bearer ${key}`"+`;
+		}
+		const res = await this.fetch(this.baseUrl + path, { method: 'POST', headers, body });
+		const contentType = res.headers.get('content-type') ?? '';
+		const text = await res.text();
+		if (!res.ok) {
+			throw new AgentError(agent, res.status, text);
+		}
+		let data: unknown = text;
+		if (contentType.includes('json') && text !== '') {
+			data = JSON.parse(text);
+		}
+		return { status: res.status, contentType, data };
+	}
+`, spec.Title, literal(spec.ServerURL)))
+	for _, a := range agents {
+		payloadType := "unknown"
+		if a.Contract != nil && a.Contract.RequestType != "" {
+			payloadType = a.Contract.RequestType
+		}
+		sb.WriteString("\n")
+		sb.WriteString(tsComment("\t", a.Description))
+		sb.WriteString(fmt.Sprintf("\t%s(payload: %s): Promise<AgentResponse> {\n", a.Method, payloadType))
+		sb.WriteString(fmt.Sprintf("\t\treturn this.send(%s, %s, %v, payload);\n", literal(a.Name), literal(WebhookPath(a.ID)), clientAuth(a)))
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// pyMethodName returns the name of the method of the agent in the Python client
+func pyMethodName(name string) string {
+	return pyFieldName(strcase.ToSnake(name))
+}
+
+// GeneratePythonClient generates a Python client of the webhooks of the agents with the Pydantic
+// models of the payload schemas. The contracts of the agents with a payload schema are keyed by agent id.
+func GeneratePythonClient(spec ProjectSpec, contracts map[string]*Contract) (string, error) {
+	agents, err := clientAgents(spec, contracts, pyMethodName, []string{"_send"})
+	if err != nil {
+		return "", err
+	}
+	var models strings.Builder
+	for _, a := range agents {
+		if a.Contract == nil {
+			continue
+		}
+		for _, m := range a.Contract.Models {
+			models.WriteString("\n\n")
+			models.WriteString(a.Contract.pythonModel(m))
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated by agentuity client generate for the %s project - do not edit manually\n\n", spec.Title))
+	sb.WriteString("from __future__ import annotations\n\n")
+	sb.WriteString("import json\nimport urllib.error\nimport urllib.request\nfrom dataclasses import dataclass\n")
+	sb.WriteString("from typing import Any, Dict, List, Literal, Optional, Union\n")
+	if models.Len() > 0 {
+		sb.WriteString("\nfrom pydantic import BaseModel, ConfigDict, Field, RootModel\n")
+	}
+	sb.WriteString(models.String())
+	sb.WriteString(fmt.Sprintf(`
+
+@dataclass
+class AgentResponse:
+    """The response of an agent"""
+
+    status: int
+    content_type: str
+    # the parsed JSON response or the text of the response
+    data: Any
+
+
+class AgentError(Exception):
+    """The error raised when an agent responds with an error status"""
+
+    def __init__(self, agent: str, status: int, body: str):
+        super().__init__(f"agent {agent} failed with status {status}: {body}")
+        self.agent = agent
+        self.status = status
+        self.body = body
+
+
+class AgentuityClient:
+    """The client of the agents of the %[1]s project"""
+
+    def __init__(
+        self,
+        base_url: str = %[2]s,
+        api_key: Optional[str] = None,
+        agent_keys: Optional[Dict[str, str]] = None,
+        timeout: float = 60.0,
+    ):
+        self.base_url = base_url.rstrip("/")
+        self.api_key = api_key
+        self.agent_keys = agent_keys or {}
+        self.timeout = timeout
+
+    def _send(self, agent: str, path: str, auth: bool, payload: Any) -> AgentResponse:
+        if hasattr(payload, "model_dump"):
+            payload = payload.model_dump(mode="json", by_alias=True, exclude_none=True)
+        if isinstance(payload, str):
+            content_type, body = "text/plain", payload.encode("utf-8")
+        elif isinstance(payload, bytes):
+            content_type, body = "application/octet-stream", payload
+        else:
+            content_type, body = "application/json", json.dumps(payload).encode("utf-8")
+        headers = {"Content-Type": content_type}
+        key = self.agent_keys.get(agent, self.api_key)
+        if auth and key:
+            headers["Authorization"] = f"Bearer {key}"
+        request = urllib.request.Request(self.base_url + path, data=body, headers=headers, method="POST")
+        try:
+            with urllib.request.urlopen(request, timeout=self.timeout) as res:
+                status = res.status
+                response_type = res.headers.get("Content-Type", "")
+                text = res.read().decode("utf-8")
+        except urllib.error.HTTPError as e:
+            raise AgentError(agent, e.code, e.read().decode("utf-8")) from e
+        data: Any = text
+        if "json" in response_type and text:
+            data = json.loads(text)
+        return AgentResponse(status=status, content_type=response_type, data=data)
+`, spec.Title, literal(spec.ServerURL)))
+	for _, a := range agents {
+		payloadType := "Any"
+		if a.Contract != nil && a.Contract.RequestType != "" {
+			payloadType = a.Contract.RequestType
+		}
+		auth := "False"
+		if clientAuth(a) {
+			auth = "True"
+		}
+		sb.WriteString(fmt.Sprintf("\n    def %s(self, payload: %s) -> AgentResponse:\n", a.Method, payloadType))
+		sb.WriteString(pyDocstring("        ", a.Description))
+		sb.WriteString(fmt.Sprintf("        return self._send(%s, %s, %s, payload)\n", literal(a.Name), literal(WebhookPath(a.ID)), auth))
+	}
+	return sb.String(), nil
+}
+
+// ClientLanguages are the languages which a client can be generated in
+var ClientLanguages = []string{"ts", "python"}
+
+// GenerateClient generates the client of the webhooks of the agents in the language
+func GenerateClient(language string, spec ProjectSpec, contracts map[string]*Contract) (string, error) {
+	switch language {
+	case "ts":
+		return GenerateTypeScriptClient(spec, contracts)
+	case "python":
+		return GeneratePythonClient(spec, contracts)
+	}
+	return "", fmt.Errorf("unsupported language %q, must be one of %s", language, strings.Join(ClientLanguages, ", "))
+}
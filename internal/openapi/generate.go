@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AgentFiles returns the files to write to the agent directory keyed by filename. The agent
+// filename is the handler created by the project template (such as index.ts or agent.py) and is
+// replaced with a handler which validates the incoming payload.
+func (c *Contract) AgentFiles(agentFilename string, name string, description string, webhookURL string) (map[string]string, error) {
+	if c.Request == nil {
+		return nil, fmt.Errorf("no request schema found in %s", c.Source)
+	}
+	files := map[string]string{
+		"README.md": c.GenerateReadme(name, description, webhookURL),
+	}
+	switch filepath.Ext(agentFilename) {
+	case ".ts":
+		files[agentFilename] = c.GenerateTypeScriptAgent(name)
+		files["types.ts"] = c.GenerateTypeScriptTypes()
+	case ".py":
+		files[agentFilename] = c.GeneratePythonAgent(name)
+		files["models.py"] = c.GeneratePythonModels()
+	default:
+		return nil, fmt.Errorf("generating agents from a schema is only supported for TypeScript and Python projects")
+	}
+	return files, nil
+}
+
+// WriteAgentFiles writes the files to the agent directory
+func WriteAgentFiles(dir string, files map[string]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `openapi: 3.0.3
+info:
+  title: Orders
+paths:
+  /health:
+    get:
+      operationId: health
+      responses:
+        "200":
+          description: ok
+  /orders:
+    post:
+      operationId: createOrder
+      summary: Create an order
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/NewOrder"
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                allOf:
+                  - $ref: "#/components/schemas/NewOrder"
+                  - type: object
+                    required: [id]
+                    properties:
+                      id:
+                        type: string
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [pending, shipped]
+    NewOrder:
+      type: object
+      description: An order to create
+      required: [customer, items]
+      properties:
+        customer:
+          type: string
+          format: email
+        status:
+          $ref: "#/components/schemas/Status"
+        items:
+          type: array
+          items:
+            type: object
+            required: [sku]
+            properties:
+              sku:
+                type: string
+              quantity:
+                type: integer
+        gift-note:
+          type: string
+          nullable: true
+          description: A note for the recipient
+`
+
+func writeSpec(t *testing.T, name string, content string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func modelNames(c *Contract) []string {
+	var names []string
+	for _, m := range c.Models {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestLoadContract(t *testing.T) {
+	c, err := LoadContract(writeSpec(t, "spec.yaml", testSpec), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", c.Method)
+	assert.Equal(t, "/orders", c.Path)
+	assert.Equal(t, "createOrder", c.OperationID)
+	assert.Equal(t, "Create an order", c.Title)
+	assert.Equal(t, "NewOrder", c.RequestType)
+	assert.Equal(t, "CreateOrderResponse", c.ResponseType)
+	assert.Equal(t, []string{"Status", "NewOrderItemsItem", "NewOrder", "CreateOrderResponse"}, modelNames(c))
+
+	example := c.Example(c.Request).(map[string]any)
+	assert.Equal(t, "user@example.com", example["customer"])
+	assert.Equal(t, "pending", example["status"])
+
+	_, err = LoadContract(writeSpec(t, "spec.yaml", testSpec), "missing")
+	assert.EqualError(t, err, "operation missing not found in spec.yaml")
+}
+
+func TestLoadContractJSONSchema(t *testing.T) {
+	schema := `{"title": "Ticket", "type": "object", "required": ["subject"], "properties": {"subject": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}}`
+	c, err := LoadContract(writeSpec(t, "ticket.json", schema), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "TicketRequest", c.RequestType)
+	assert.Equal(t, "", c.ResponseType)
+	assert.Equal(t, []string{"TicketRequest"}, modelNames(c))
+
+	_, err = LoadContract(writeSpec(t, "other.json", `{"name": "x"}`), "")
+	assert.Error(t, err)
+}
+
+func TestContractAgentName(t *testing.T) {
+	c, err := LoadContract(writeSpec(t, "spec.yaml", testSpec), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "create-order", c.AgentName())
+
+	c, err = LoadContract(writeSpec(t, "ticket.json", `{"title": "Support Ticket", "type": "object"}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "support-ticket", c.AgentName())
+
+	c, err = LoadContract(writeSpec(t, "new_order.json", `{"type": "object"}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-order", c.AgentName())
+
+	assert.Equal(t, "support-ticket", (&Contract{Title: "Support Ticket", Source: "--.json"}).AgentName())
+	assert.Equal(t, "", (&Contract{Title: "!!", Source: "--.json"}).AgentName())
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	c, err := LoadContract(writeSpec(t, "spec.yaml", testSpec), "")
+	assert.NoError(t, err)
+	types := c.GenerateTypeScriptTypes()
+	assert.Contains(t, types, "// Generated from spec.yaml - do not edit manually")
+	assert.Contains(t, types, `export type Status = "pending" | "shipped";`)
+	assert.Contains(t, types, "export interface NewOrder {\n\tcustomer: string;\n\tstatus?: Status;\n\titems: NewOrderItemsItem[];\n\t/** A note for the recipient */\n\t\"gift-note\"?: string | null;\n}")
+	assert.Contains(t, types, "export interface CreateOrderResponse {")
+	assert.Contains(t, types, "export function validateNewOrder(value: unknown): string[]")
+	assert.Contains(t, types, "export function validateCreateOrderResponse(value: unknown): string[]")
+
+	agent := c.GenerateTypeScriptAgent("orders")
+	assert.Contains(t, agent, "import { type NewOrder, validateNewOrder, type CreateOrderResponse } from './types';")
+	assert.Contains(t, agent, "const errors = validateNewOrder(payload);")
+}
+
+func TestGeneratePython(t *testing.T) {
+	c, err := LoadContract(writeSpec(t, "spec.yaml", testSpec), "")
+	assert.NoError(t, err)
+	models := c.GeneratePythonModels()
+	assert.Contains(t, models, `Status = Literal["pending", "shipped"]`)
+	assert.Contains(t, models, "class NewOrder(BaseModel):\n    \"\"\"An order to create\"\"\"\n    model_config = ConfigDict(populate_by_name=True)\n\n    customer: str\n    status: Optional[Status] = None\n    items: List[NewOrderItemsItem]\n")
+	assert.Contains(t, models, `gift_note: Optional[str] = Field(default=None, alias="gift-note", description="A note for the recipient")`)
+
+	agent := c.GeneratePythonAgent("orders")
+	assert.Contains(t, agent, "from .models import NewOrder, CreateOrderResponse")
+	assert.Contains(t, agent, "payload = NewOrder.model_validate(await request.data.json())")
+}
+
+func TestPyFieldName(t *testing.T) {
+	assert.Equal(t, "name", pyFieldName("name"))
+	assert.Equal(t, "firstName", pyFieldName("firstName"))
+	assert.Equal(t, "gift_note", pyFieldName("gift-note"))
+	assert.Equal(t, "class_", pyFieldName("class"))
+}
+
+func TestAgentFiles(t *testing.T) {
+	c, err := LoadContract(writeSpec(t, "spec.yaml", testSpec), "")
+	assert.NoError(t, err)
+	files, err := c.AgentFiles("index.ts", "orders", "", "https://agentuity.ai/webhook/123")
+	assert.NoError(t, err)
+	assert.Contains(t, files, "index.ts")
+	assert.Contains(t, files, "types.ts")
+	assert.Contains(t, files["README.md"], "POST https://agentuity.ai/webhook/123")
+	assert.Contains(t, files["README.md"], "| `gift-note` | `string \\| null` | no | A note for the recipient |")
+
+	files, err = c.AgentFiles("agent.py", "orders", "", "https://agentuity.ai/webhook/123")
+	assert.NoError(t, err)
+	assert.Contains(t, files, "models.py")
+
+	_, err = c.AgentFiles("main.go", "orders", "", "")
+	assert.Error(t, err)
+}
+
+func TestValidator(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "required": ["email", "items"],
+  "additionalProperties": false,
+  "properties": {
+    "email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+    "priority": {"enum": ["low", "high"]},
+    "note": {"type": "string", "nullable": true, "maxLength": 5},
+    "items": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/Item"}}
+  },
+  "definitions": {
+    "Item": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string"}, "quantity": {"type": "integer", "minimum": 1}}}
+  }
+}`
+	v, err := LoadValidator(writeSpec(t, "schema.json", schema))
+	assert.NoError(t, err)
+
+	assert.Empty(t, v.Validate([]byte(`{"email": "a@b.co", "note": null, "items": [{"sku": "x", "quantity": 2}]}`)))
+	assert.Equal(t, []string{"$.items is required"}, v.Validate([]byte(`{"email": "a@b.co"}`)))
+	assert.Equal(t, []string{
+		"$.email must match the pattern ^[^@]+@[^@]+$",
+		"$.priority must be one of \"low\", \"high\"",
+		"$.note must be at most 5 characters",
+		"$.items[0].sku is required",
+		"$.items[0].quantity must be greater than or equal to 1",
+		"$.extra is not allowed",
+	}, v.Validate([]byte(`{"email": "nope", "priority": "medium", "note": "too long", "items": [{"quantity": 0}], "extra": true}`)))
+	assert.Equal(t, []string{"$ must be of type object"}, v.Validate([]byte(`[]`)))
+	assert.Len(t, v.Validate([]byte(`{`)), 1)
+
+	buf, err := v.JSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), `"additionalProperties":false`)
+
+	v, err = NewValidator("tags.json", []byte(`{"type": "array", "uniqueItems": true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"$[2] is a duplicate of $[0]"}, v.Validate([]byte(`["a", "b", "a"]`)))
+
+	_, err = LoadValidator(writeSpec(t, "bad.json", `{"$ref": "#/definitions/Missing"}`))
+	assert.EqualError(t, err, "invalid schema bad.json: unable to resolve schema reference #/definitions/Missing")
+}
+
+func TestGenerateProjectSpec(t *testing.T) {
+	doc, err := GenerateProjectSpec(ProjectSpec{
+		Title:     "orders",
+		ServerURL: "https://agentuity.ai",
+		Agents: []AgentEndpoint{
+			{ID: "agent_123", Name: "order agent", AuthType: "bearer", Tags: []string{"orders"},
+				Schema: []byte(`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"item":{"$ref":"#/$defs/Item"}},"$defs":{"Item":{"type":"string"}}}`)},
+			{ID: "agent_456", Name: "public", AuthType: "none"},
+			{ID: "agent_789", Name: "internal", AuthType: "project"},
+		},
+	})
+	assert.NoError(t, err)
+	buf, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	var spec map[string]any
+	assert.NoError(t, json.Unmarshal(buf, &spec))
+	assert.Equal(t, "3.1.0", spec["openapi"])
+
+	paths := spec["paths"].(map[string]any)
+	assert.Len(t, paths, 3)
+	order := paths["/webhook/123"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, "orderAgent", order["operationId"])
+	assert.Equal(t, []any{map[string]any{"agentApiKey": []any{}}}, order["security"])
+	assert.Contains(t, order["responses"], "400")
+	assert.Equal(t, "#/components/schemas/OrderAgentPayload", order["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)["$ref"])
+
+	public := paths["/webhook/456"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, []any{}, public["security"])
+	assert.NotContains(t, public["responses"], "401")
+
+	components := spec["components"].(map[string]any)
+	schemas := components["schemas"].(map[string]any)
+	payload := schemas["OrderAgentPayload"].(map[string]any)
+	assert.NotContains(t, payload, "$schema")
+	assert.NotContains(t, payload, "$defs")
+	assert.Equal(t, "#/components/schemas/OrderAgentPayloadItem", payload["properties"].(map[string]any)["item"].(map[string]any)["$ref"])
+	assert.Contains(t, schemas, "OrderAgentPayloadItem")
+	assert.Contains(t, components["securitySchemes"], "agentApiKey")
+	assert.Contains(t, components["securitySchemes"], "projectApiKey")
+
+	_, err = GenerateProjectSpec(ProjectSpec{Agents: []AgentEndpoint{{ID: "agent_1", Name: "a", AuthType: "basic"}}})
+	assert.Error(t, err)
+}
+
+func TestGenerateClient(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "order.json")
+	assert.NoError(t, os.WriteFile(schema, []byte(`{"type":"object","required":["item"],"properties":{"item":{"type":"string"},"quantity":{"type":"integer"}}}`), 0644))
+	spec := ProjectSpec{
+		Title:     "orders",
+		ServerURL: "https://agentuity.ai",
+		Agents: []AgentEndpoint{
+			{ID: "agent_123", Name: "order-agent", Description: "Creates orders", AuthType: "bearer"},
+			{ID: "agent_456", Name: "public", AuthType: "none"},
+			{ID: "agent_789", Name: "send", AuthType: "project"},
+		},
+	}
+
+	contract, err := LoadContract(schema, "")
+	assert.NoError(t, err)
+	ts, err := GenerateClient("ts", spec, map[string]*Contract{"agent_123": contract})
+	assert.NoError(t, err)
+	assert.Contains(t, ts, "export interface OrderAgentOrderRequest {\n\titem: string;\n\tquantity?: number;\n}")
+	assert.Contains(t, ts, "\t/** Creates orders */\n\torderAgent(payload: OrderAgentOrderRequest): Promise<AgentResponse> {\n\t\treturn this.send(\"order-agent\", \"/webhook/123\", true, payload);")
+	assert.Contains(t, ts, "\tpublic(payload: unknown): Promise<AgentResponse> {\n\t\treturn this.send(\"public\", \"/webhook/456\", false, payload);")
+	// the method of the agent can't be the send method of the client
+	assert.Contains(t, ts, "\tsend2(payload: unknown)")
+	assert.Contains(t, ts, `options.baseUrl ?? "https://agentuity.ai"`)
+
+	contract, err = LoadContract(schema, "")
+	assert.NoError(t, err)
+	py, err := GenerateClient("python", spec, map[string]*Contract{"agent_123": contract})
+	assert.NoError(t, err)
+	assert.Contains(t, py, "class OrderAgentOrderRequest(BaseModel):\n    item: str\n    quantity: Optional[int] = None\n")
+	assert.Contains(t, py, "    def order_agent(self, payload: OrderAgentOrderRequest) -> AgentResponse:\n        \"\"\"Creates orders\"\"\"\n        return self._send(\"order-agent\", \"/webhook/123\", True, payload)\n")
+	assert.Contains(t, py, "    def public(self, payload: Any) -> AgentResponse:\n        return self._send(\"public\", \"/webhook/456\", False, payload)\n")
+	assert.Contains(t, py, "from pydantic import BaseModel")
+
+	// the agents can't define the same models
+	first, err := LoadContract(schema, "")
+	assert.NoError(t, err)
+	second, err := LoadContract(schema, "")
+	assert.NoError(t, err)
+	spec.Agents = []AgentEndpoint{{ID: "agent_1", Name: "order"}, {ID: "agent_2", Name: "Order"}}
+	_, err = GenerateClient("ts", spec, map[string]*Contract{"agent_1": first, "agent_2": second})
+	assert.ErrorContains(t, err, "both define OrderRequest")
+
+	_, err = GenerateClient("go", spec, nil)
+	assert.ErrorContains(t, err, "unsupported language")
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// AgentEndpoint is the webhook of an agent documented in the OpenAPI document of a project
+type AgentEndpoint struct {
+	ID          string
+	Name        string
+	Description string
+	// AuthType is the authentication of the webhook which is project, bearer or none
+	AuthType string
+	// Schema is the JSON Schema of the payload or nil when the agent doesn't declare one
+	Schema json.RawMessage
+	Tags   []string
+}
+
+// ProjectSpec is the input of the OpenAPI document of the webhooks of a project
+type ProjectSpec struct {
+	Title       string
+	Description string
+	// ServerURL is the URL which the webhooks are served from
+	ServerURL string
+	Agents    []AgentEndpoint
+}
+
+// The document is made of structs so that the top level keys are written in the usual order
+type specDocument struct {
+	OpenAPI    string                    `json:"openapi" yaml:"openapi"`
+	Info       specInfo                  `json:"info" yaml:"info"`
+	Servers    []specServer              `json:"servers" yaml:"servers"`
+	Paths      map[string]map[string]any `json:"paths" yaml:"paths"`
+	Components specComponents            `json:"components" yaml:"components"`
+}
+
+type specInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type specServer struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+type specComponents struct {
+	Schemas         map[string]any `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]any `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+var securitySchemes = map[string]map[string]any{
+	"bearer": {
+		"type":        "http",
+		"scheme":      "bearer",
+		"description": "The API key of the agent, shown by agentuity agent apikey",
+	},
+	"project": {
+		"type":        "http",
+		"scheme":      "bearer",
+		"description": "The API key of the project",
+	},
+}
+
+var securitySchemeNames = map[string]string{
+	"bearer":  "agentApiKey",
+	"project": "projectApiKey",
+}
+
+// WebhookPath returns the path of the webhook of the agent
+func WebhookPath(agentID string) string {
+	return "/webhook/" + strings.TrimPrefix(agentID, "agent_")
+}
+
+// rewriteRefs moves the definitions of the schema to the components of the document with the
+// prefix and rewrites the references to them, since a reference such as #/definitions/Item is
+// resolved against the document once the schema is embedded in it
+func rewriteRefs(node any, prefix string) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if ref, ok := val.(string); ok && k == "$ref" {
+				switch {
+				case ref == "#":
+					v[k] = "#/components/schemas/" + prefix
+				case strings.HasPrefix(ref, "#/definitions/"):
+					v[k] = "#/components/schemas/" + prefix + strings.TrimPrefix(ref, "#/definitions/")
+				case strings.HasPrefix(ref, "#/$defs/"):
+					v[k] = "#/components/schemas/" + prefix + strings.TrimPrefix(ref, "#/$defs/")
+				}
+				continue
+			}
+			v[k] = rewriteRefs(val, prefix)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = rewriteRefs(val, prefix)
+		}
+	}
+	return node
+}
+
+var invalidPayloadSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"error":   map[string]any{"type": "string"},
+		"details": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+	"required": []string{"error"},
+}
+
+// GenerateProjectSpec returns the OpenAPI 3.1 document of the webhooks of the agents of a project
+func GenerateProjectSpec(spec ProjectSpec) (any, error) {
+	doc := specDocument{
+		OpenAPI: "3.1.0",
+		Info:    specInfo{Title: spec.Title, Description: spec.Description, Version: "latest"},
+		Servers: []specServer{{URL: spec.ServerURL}},
+		Paths:   make(map[string]map[string]any),
+		Components: specComponents{
+			Schemas:         make(map[string]any),
+			SecuritySchemes: make(map[string]any),
+		},
+	}
+	agents := append([]AgentEndpoint{}, spec.Agents...)
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	operationIds := make(map[string]bool)
+	for _, agent := range agents {
+		operationId := strcase.ToLowerCamel(agent.Name)
+		for i := 2; operationIds[operationId]; i++ {
+			operationId = fmt.Sprintf("%s%d", strcase.ToLowerCamel(agent.Name), i)
+		}
+		operationIds[operationId] = true
+
+		operation := map[string]any{
+			"operationId": operationId,
+			"summary":     agent.Name,
+		}
+		if agent.Description != "" {
+			operation["description"] = agent.Description
+		}
+		if len(agent.Tags) > 0 {
+			operation["tags"] = agent.Tags
+		}
+
+		requestBody := map[string]any{"required": true}
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": "The response of the agent",
+				"content":     map[string]any{"*/*": map[string]any{"schema": map[string]any{}}},
+			},
+		}
+		if agent.Schema != nil {
+			var schema map[string]any
+			if err := json.Unmarshal(agent.Schema, &schema); err != nil {
+				return nil, fmt.Errorf("invalid payload schema of agent %s: %w", agent.Name, err)
+			}
+			name := strcase.ToCamel(agent.Name) + "Payload"
+			for _, key := range []string{"definitions", "$defs"} {
+				if defs, ok := schema[key].(map[string]any); ok {
+					for defName, def := range defs {
+						doc.Components.Schemas[name+defName] = rewriteRefs(def, name)
+					}
+					delete(schema, key)
+				}
+			}
+			delete(schema, "$schema")
+			delete(schema, "$id")
+			doc.Components.Schemas[name] = rewriteRefs(schema, name)
+			requestBody["content"] = map[string]any{
+				"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/" + name}},
+			}
+			responses["400"] = map[string]any{
+				"description": "The payload doesn't match the schema",
+				"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/InvalidPayload"}}},
+			}
+			doc.Components.Schemas["InvalidPayload"] = invalidPayloadSchema
+		} else {
+			requestBody["content"] = map[string]any{"*/*": map[string]any{"schema": map[string]any{}}}
+		}
+		operation["requestBody"] = requestBody
+
+		switch agent.AuthType {
+		case "bearer", "project":
+			scheme := securitySchemeNames[agent.AuthType]
+			doc.Components.SecuritySchemes[scheme] = securitySchemes[agent.AuthType]
+			operation["security"] = []map[string][]string{{scheme: {}}}
+			responses["401"] = map[string]any{"description": "The API key is missing or invalid"}
+		case "none", "":
+			operation["security"] = []map[string][]string{}
+		default:
+			return nil, fmt.Errorf("unknown authentication type %q of agent %s", agent.AuthType, agent.Name)
+		}
+		operation["responses"] = responses
+		doc.Paths[WebhookPath(agent.ID)] = map[string]any{"post": operation}
+	}
+	return doc, nil
+}
@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+var pyIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var pyKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue",
+	"def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+	"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+// pyFieldName returns a valid python identifier for the property name
+func pyFieldName(name string) string {
+	field := name
+	if !pyIdentifierRegex.MatchString(field) {
+		field = strcase.ToSnake(field)
+	}
+	if !pyIdentifierRegex.MatchString(field) {
+		field = "field_" + regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(field, "_")
+	}
+	if slices.Contains(pyKeywords, field) || field == "model_config" {
+		field += "_"
+	}
+	return field
+}
+
+func pyLiteral(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	}
+	return literal(val)
+}
+
+// PythonType returns the python type annotation for the schema
+func (c *Contract) PythonType(s *Schema) string {
+	if s == nil {
+		return "Any"
+	}
+	var t string
+	if name := c.ModelName(s); name != "" {
+		t = name
+		if s.Ref != "" && s.IsNullable() {
+			t = "Optional[" + t + "]"
+		}
+		return t
+	}
+	r := c.Resolve(s)
+	if r == nil {
+		return "Any"
+	}
+	t = c.pythonExpr(r)
+	if r.IsNullable() && t != "None" && t != "Any" {
+		t = "Optional[" + t + "]"
+	}
+	return t
+}
+
+func (c *Contract) pythonExpr(s *Schema) string {
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, pyLiteral(v))
+		}
+		return "Literal[" + strings.Join(values, ", ") + "]"
+	}
+	if variants := append(append([]*Schema{}, s.OneOf...), s.AnyOf...); len(variants) > 0 {
+		types := make([]string, 0, len(variants))
+		for _, v := range variants {
+			types = append(types, c.PythonType(v))
+		}
+		return "Union[" + strings.Join(types, ", ") + "]"
+	}
+	switch s.PrimaryType() {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "null":
+		return "None"
+	case "array":
+		return "List[" + c.PythonType(s.Items) + "]"
+	case "object":
+		return "Dict[str, Any]"
+	}
+	return "Any"
+}
+
+func pyDocstring(indent string, text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, `"""`, `\"\"\"`))
+	if text == "" {
+		return ""
+	}
+	if !strings.Contains(text, "\n") {
+		return fmt.Sprintf("%s\"\"\"%s\"\"\"\n", indent, text)
+	}
+	var sb strings.Builder
+	sb.WriteString(indent + "\"\"\"\n")
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(strings.TrimRight(indent+line, " ") + "\n")
+	}
+	sb.WriteString(indent + "\"\"\"\n")
+	return sb.String()
+}
+
+func (c *Contract) pythonModel(m Model) string {
+	var sb strings.Builder
+	s := c.Resolve(m.Schema)
+	if s != nil && len(s.Enum) > 0 {
+		sb.WriteString(fmt.Sprintf("%s = %s\n", m.Name, c.pythonExpr(s)))
+		return sb.String()
+	}
+	if s == nil || s.PrimaryType() != "object" || len(s.Properties) == 0 {
+		expr := "Any"
+		if s != nil {
+			expr = c.pythonExpr(s)
+		}
+		sb.WriteString(fmt.Sprintf("class %s(RootModel[%s]):\n", m.Name, expr))
+		if doc := pyDocstring("    ", m.Schema.Description); doc != "" {
+			sb.WriteString(doc)
+		} else {
+			sb.WriteString("    pass\n")
+		}
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("class %s(BaseModel):\n", m.Name))
+	sb.WriteString(pyDocstring("    ", m.Schema.Description))
+	var fields []string
+	var aliased bool
+	for _, p := range s.Properties {
+		field := pyFieldName(p.Name)
+		t := c.PythonType(p.Schema)
+		var args []string
+		if !s.IsRequired(p.Name) {
+			if !strings.HasPrefix(t, "Optional[") && t != "Any" && t != "None" {
+				t = "Optional[" + t + "]"
+			}
+			args = append(args, "default=None")
+		}
+		if field != p.Name {
+			aliased = true
+			args = append(args, "alias="+literal(p.Name))
+		}
+		if desc := strings.TrimSpace(p.Schema.Description); desc != "" {
+			args = append(args, "description="+literal(desc))
+		}
+		switch {
+		case len(args) == 0:
+			fields = append(fields, fmt.Sprintf("    %s: %s\n", field, t))
+		case len(args) == 1 && args[0] == "default=None":
+			fields = append(fields, fmt.Sprintf("    %s: %s = None\n", field, t))
+		default:
+			fields = append(fields, fmt.Sprintf("    %s: %s = Field(%s)\n", field, t, strings.Join(args, ", ")))
+		}
+	}
+	if aliased {
+		sb.WriteString("    model_config = ConfigDict(populate_by_name=True)\n\n")
+	}
+	sb.WriteString(strings.Join(fields, ""))
+	return sb.String()
+}
+
+// GeneratePythonModels generates the Pydantic models for the contract
+func (c *Contract) GeneratePythonModels() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated from %s - do not edit manually\n\n", c.Source))
+	sb.WriteString("from __future__ import annotations\n\n")
+	sb.WriteString("from typing import Any, Dict, List, Literal, Optional, Union\n\n")
+	sb.WriteString("from pydantic import BaseModel, ConfigDict, Field, RootModel\n")
+	for _, m := range c.Models {
+		sb.WriteString("\n\n")
+		sb.WriteString(c.pythonModel(m))
+	}
+	return sb.String()
+}
+
+// GeneratePythonAgent generates the agent handler which validates the request
+func (c *Contract) GeneratePythonAgent(name string) string {
+	imports := c.RequestType
+	result := "    result = {}\n    return response.json(result)\n"
+	if c.ResponseType != "" {
+		imports += ", " + c.ResponseType
+		result = fmt.Sprintf("    result = %s.model_construct()\n    return response.json(result.model_dump(mode=\"json\", by_alias=True))\n", c.ResponseType)
+	}
+	return fmt.Sprintf(`import json
+
+from agentuity import AgentContext, AgentRequest, AgentResponse
+from pydantic import ValidationError
+
+from .models import %[1]s
+
+
+def welcome():
+    return {
+        "welcome": %[2]s,
+        "prompts": [
+            {
+                "data": json.dumps(%[3]s),
+                "contentType": "application/json",
+            }
+        ],
+    }
+
+
+async def run(request: AgentRequest, response: AgentResponse, context: AgentContext):
+    try:
+        payload = %[4]s.model_validate(await request.data.json())
+    except ValidationError as e:
+        context.logger.warning("invalid payload: %%s", e)
+        return response.json({"error": "invalid payload", "details": json.loads(e.json())})
+
+    context.logger.info("received %[4]s: %%s", payload.model_dump_json())
+
+    # TODO: implement the agent
+%[5]s`, imports, literal(fmt.Sprintf("Send a %s payload to the %s agent", c.RequestType, name)), pyLiteralJSON(c.Example(c.Request)), c.RequestType, result)
+}
+
+// pyLiteralJSON returns the value as a python literal
+func pyLiteralJSON(val any) string {
+	switch v := val.(type) {
+	case map[string]any:
+		keys := sortedKeys(v)
+		items := make([]string, 0, len(keys))
+		for _, k := range keys {
+			items = append(items, literal(k)+": "+pyLiteralJSON(v[k]))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, pyLiteralJSON(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return pyLiteral(val)
+}
@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Example returns an example value for the schema using the examples in the schema when provided
+func (c *Contract) Example(s *Schema) any {
+	return c.example(s, 0)
+}
+
+func (c *Contract) example(s *Schema, depth int) any {
+	s = c.Resolve(s)
+	if s == nil || depth > 8 {
+		return nil
+	}
+	if s.Example != nil {
+		return s.Example
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+	if variants := append(append([]*Schema{}, s.OneOf...), s.AnyOf...); len(variants) > 0 {
+		return c.example(variants[0], depth+1)
+	}
+	switch s.PrimaryType() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "2025-01-01T00:00:00Z"
+		case "date":
+			return "2025-01-01"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		if item := c.example(s.Items, depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "object":
+		obj := make(map[string]any)
+		for _, p := range s.Properties {
+			if v := c.example(p.Schema, depth+1); v != nil || s.IsRequired(p.Name) {
+				obj[p.Name] = v
+			}
+		}
+		return obj
+	}
+	return nil
+}
+
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(text), "\n", " "), "|", "\\|")
+}
+
+func (c *Contract) markdownModel(sb *strings.Builder, m Model) {
+	s := c.Resolve(m.Schema)
+	sb.WriteString(fmt.Sprintf("### %s\n\n", m.Name))
+	if desc := strings.TrimSpace(m.Schema.Description); desc != "" {
+		sb.WriteString(desc + "\n\n")
+	}
+	if s == nil || s.PrimaryType() != "object" || len(s.Properties) == 0 || len(s.Enum) > 0 {
+		var t string
+		if s != nil {
+			t = c.typeScriptExpr(s)
+		}
+		sb.WriteString(fmt.Sprintf("Type: `%s`\n\n", markdownCell(t)))
+		return
+	}
+	sb.WriteString("| Field | Type | Required | Description |\n")
+	sb.WriteString("| ----- | ---- | -------- | ----------- |\n")
+	for _, p := range s.Properties {
+		required := "no"
+		if s.IsRequired(p.Name) {
+			required = "yes"
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | %s |\n", p.Name, markdownCell(c.TypeScriptType(p.Schema)), required, markdownCell(p.Schema.Description)))
+	}
+	sb.WriteString("\n")
+}
+
+// GenerateReadme generates the README documenting the webhook contract of the agent
+func (c *Contract) GenerateReadme(name string, description string, webhookURL string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", name))
+	if description != "" {
+		sb.WriteString(description + "\n\n")
+	} else if c.Description != "" {
+		sb.WriteString(strings.TrimSpace(c.Description) + "\n\n")
+	}
+	source := fmt.Sprintf("`%s`", c.Source)
+	if c.Path != "" {
+		source += fmt.Sprintf(" (`%s %s`, operation `%s`)", c.Method, c.Path, c.OperationID)
+	}
+	sb.WriteString(fmt.Sprintf("This agent was generated from %s. Incoming payloads are validated against the schema before the agent runs and invalid payloads are rejected with the list of validation errors.\n\n", source))
+
+	sb.WriteString("## Webhook\n\n")
+	sb.WriteString("Send a `POST` request with a JSON body to the agent webhook:\n\n")
+	sb.WriteString("```\n")
+	sb.WriteString(fmt.Sprintf("POST %s\n", webhookURL))
+	sb.WriteString("Content-Type: application/json\n")
+	sb.WriteString("```\n\n")
+
+	example, _ := json.MarshalIndent(c.Example(c.Request), "", "  ")
+	compact, _ := json.Marshal(c.Example(c.Request))
+	sb.WriteString("Example request:\n\n")
+	sb.WriteString(fmt.Sprintf("```bash\ncurl -X POST %s \\\n  -H 'Content-Type: application/json' \\\n  -d '%s'\n```\n\n", webhookURL, strings.ReplaceAll(string(compact), "'", "'\\''")))
+
+	sb.WriteString(fmt.Sprintf("## Request\n\nThe request body is a `%s`.\n\n", c.RequestType))
+	sb.WriteString(fmt.Sprintf("```json\n%s\n```\n\n", string(example)))
+	if c.ResponseType != "" {
+		sb.WriteString(fmt.Sprintf("## Response\n\nThe response body is a `%s`.\n\n", c.ResponseType))
+		response, _ := json.MarshalIndent(c.Example(c.Response), "", "  ")
+		sb.WriteString(fmt.Sprintf("```json\n%s\n```\n\n", string(response)))
+	}
+	sb.WriteString("If the payload is invalid, the agent responds with:\n\n")
+	sb.WriteString("```json\n{\n  \"error\": \"invalid payload\",\n  \"details\": []\n}\n```\n\n")
+
+	if len(c.Models) > 0 {
+		sb.WriteString("## Models\n\n")
+		for _, m := range c.Models {
+			c.markdownModel(&sb, m)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
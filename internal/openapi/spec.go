@@ -0,0 +1,508 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/iancoleman/strcase"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaType is the type of a schema which can be a single type or a list of types
+type SchemaType []string
+
+func (t *SchemaType) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*t = SchemaType{node.Value}
+	case yaml.SequenceNode:
+		var types []string
+		if err := node.Decode(&types); err != nil {
+			return err
+		}
+		*t = types
+	default:
+		return fmt.Errorf("invalid schema type at line %d", node.Line)
+	}
+	return nil
+}
+
+// Property is a named property of an object schema
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are the properties of an object schema in the order they are declared
+type Properties []Property
+
+func (p *Properties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("properties must be an object at line %d", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var schema Schema
+		if err := node.Content[i+1].Decode(&schema); err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: node.Content[i].Value, Schema: &schema})
+	}
+	return nil
+}
+
+// Schema is the subset of JSON Schema used to generate agent models
+type Schema struct {
+	Ref         string             `yaml:"$ref"`
+	Type        SchemaType         `yaml:"type"`
+	Title       string             `yaml:"title"`
+	Description string             `yaml:"description"`
+	Format      string             `yaml:"format"`
+	Properties  Properties         `yaml:"properties"`
+	Required    []string           `yaml:"required"`
+	Items       *Schema            `yaml:"items"`
+	Enum        []any              `yaml:"enum"`
+	Nullable    bool               `yaml:"nullable"`
+	AllOf       []*Schema          `yaml:"allOf"`
+	OneOf       []*Schema          `yaml:"oneOf"`
+	AnyOf       []*Schema          `yaml:"anyOf"`
+	Example     any                `yaml:"example"`
+	Definitions map[string]*Schema `yaml:"definitions"`
+	Defs        map[string]*Schema `yaml:"$defs"`
+
+	Minimum              *float64              `yaml:"minimum"`
+	Maximum              *float64              `yaml:"maximum"`
+	MinLength            *int                  `yaml:"minLength"`
+	MaxLength            *int                  `yaml:"maxLength"`
+	MinItems             *int                  `yaml:"minItems"`
+	MaxItems             *int                  `yaml:"maxItems"`
+	UniqueItems          bool                  `yaml:"uniqueItems"`
+	Pattern              string                `yaml:"pattern"`
+	AdditionalProperties *AdditionalProperties `yaml:"additionalProperties"`
+}
+
+// AdditionalProperties is either a boolean or the schema of the additional properties of an object
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *AdditionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Allowed)
+	}
+	a.Allowed = true
+	a.Schema = &Schema{}
+	return node.Decode(a.Schema)
+}
+
+// PrimaryType returns the schema type ignoring null
+func (s *Schema) PrimaryType() string {
+	for _, t := range s.Type {
+		if t != "null" {
+			return t
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	if s.Items != nil {
+		return "array"
+	}
+	return ""
+}
+
+// IsNullable returns true if the schema accepts null
+func (s *Schema) IsNullable() bool {
+	if s.Nullable {
+		return true
+	}
+	for _, t := range s.Type {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRequired returns true if the property is required
+func (s *Schema) IsRequired(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+type mediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type operation struct {
+	OperationID string `yaml:"operationId"`
+	Summary     string `yaml:"summary"`
+	Description string `yaml:"description"`
+	RequestBody *struct {
+		Description string               `yaml:"description"`
+		Content     map[string]mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Parameters []struct {
+		In     string  `yaml:"in"`
+		Schema *Schema `yaml:"schema"`
+	} `yaml:"parameters"`
+	Responses map[string]struct {
+		Description string               `yaml:"description"`
+		Content     map[string]mediaType `yaml:"content"`
+		Schema      *Schema              `yaml:"schema"`
+	} `yaml:"responses"`
+}
+
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+	} `yaml:"info"`
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `yaml:"schemas"`
+	} `yaml:"components"`
+	Definitions map[string]*Schema `yaml:"definitions"`
+}
+
+var methods = []string{"post", "put", "patch", "get", "delete"}
+
+// Model is a named type generated for the contract
+type Model struct {
+	Name   string
+	Schema *Schema
+}
+
+// Contract is the request and response contract of the agent webhook
+type Contract struct {
+	Source       string
+	Title        string
+	Description  string
+	Method       string
+	Path         string
+	OperationID  string
+	Request      *Schema
+	Response     *Schema
+	RequestType  string
+	ResponseType string
+	Models       []Model
+
+	refs     map[string]*Schema
+	names    map[*Schema]string
+	resolved map[string]bool
+}
+
+// Resolve returns the schema referenced by s or s itself if it isn't a reference
+func (c *Contract) Resolve(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		s = c.refs[s.Ref]
+	}
+	return s
+}
+
+// ModelName returns the name of the model generated for the schema or an empty string if the
+// schema is generated inline
+func (c *Contract) ModelName(s *Schema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Ref != "" {
+		return c.names[c.Resolve(s)]
+	}
+	return c.names[s]
+}
+
+// AgentName returns the name of the agent generated for the contract from the operationId, the title
+// or the file name, or an empty string if none of them gives a name
+func (c *Contract) AgentName() string {
+	for _, name := range []string{c.OperationID, c.Title, strings.TrimSuffix(c.Source, filepath.Ext(c.Source))} {
+		if name = strcase.ToKebab(strings.TrimSpace(name)); strings.IndexFunc(name, isAlphanumeric) >= 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// LoadContract loads the OpenAPI specification or JSON Schema file and returns the contract of the
+// operation. If operationId is empty, the first operation with a JSON request body is used. For a
+// JSON Schema file the schema is used as the request and the response is left untyped.
+func LoadContract(filename string, operationId string) (*Contract, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var doc document
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(filename), err)
+	}
+	c := &Contract{
+		Source:   filepath.Base(filename),
+		refs:     make(map[string]*Schema),
+		names:    make(map[*Schema]string),
+		resolved: make(map[string]bool),
+	}
+	if doc.OpenAPI == "" && doc.Swagger == "" {
+		var schema Schema
+		if err := yaml.Unmarshal(buf, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(filename), err)
+		}
+		if schema.PrimaryType() == "" && schema.Ref == "" && len(schema.AllOf) == 0 && len(schema.OneOf) == 0 && len(schema.AnyOf) == 0 {
+			return nil, fmt.Errorf("%s is not an OpenAPI specification or JSON Schema", filepath.Base(filename))
+		}
+		for name, s := range schema.Definitions {
+			c.refs["#/definitions/"+name] = s
+		}
+		for name, s := range schema.Defs {
+			c.refs["#/$defs/"+name] = s
+		}
+		c.Title = schema.Title
+		c.Description = schema.Description
+		c.Method = "POST"
+		c.Request = &schema
+		base := strcase.ToCamel(schema.Title)
+		if base == "" {
+			base = strcase.ToCamel(strings.TrimSuffix(c.Source, filepath.Ext(c.Source)))
+		}
+		c.OperationID = strcase.ToLowerCamel(base)
+		if err := c.collect(base); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	for name, s := range doc.Components.Schemas {
+		c.refs["#/components/schemas/"+name] = s
+	}
+	for name, s := range doc.Definitions {
+		c.refs["#/definitions/"+name] = s
+	}
+	c.Title = doc.Info.Title
+	c.Description = doc.Info.Description
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var op *operation
+	for _, method := range methods {
+		for _, path := range paths {
+			node, ok := doc.Paths[path][method]
+			if !ok {
+				continue
+			}
+			var o operation
+			if err := node.Decode(&o); err != nil {
+				return nil, fmt.Errorf("failed to parse %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			if operationId != "" && o.OperationID != operationId {
+				continue
+			}
+			if operationId == "" && requestSchema(&o) == nil {
+				continue
+			}
+			op = &o
+			c.Method = strings.ToUpper(method)
+			c.Path = path
+			break
+		}
+		if op != nil {
+			break
+		}
+	}
+	if op == nil {
+		if operationId != "" {
+			return nil, fmt.Errorf("operation %s not found in %s", operationId, c.Source)
+		}
+		return nil, fmt.Errorf("no operation with a JSON request body found in %s", c.Source)
+	}
+	c.OperationID = op.OperationID
+	if c.OperationID == "" {
+		c.OperationID = strcase.ToLowerCamel(strings.ToLower(c.Method) + " " + strings.NewReplacer("/", " ", "{", "", "}", "").Replace(c.Path))
+	}
+	if op.Summary != "" {
+		c.Title = op.Summary
+	}
+	if op.Description != "" {
+		c.Description = op.Description
+	}
+	c.Request = requestSchema(op)
+	for _, code := range []string{"200", "201", "202", "default"} {
+		resp, ok := op.Responses[code]
+		if !ok {
+			continue
+		}
+		if resp.Schema != nil {
+			c.Response = resp.Schema
+		} else if mt, ok := jsonContent(resp.Content); ok {
+			c.Response = mt.Schema
+		}
+		if c.Response != nil {
+			break
+		}
+	}
+	if err := c.collect(strcase.ToCamel(c.OperationID)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func jsonContent(content map[string]mediaType) (mediaType, bool) {
+	if mt, ok := content["application/json"]; ok && mt.Schema != nil {
+		return mt, true
+	}
+	for name, mt := range content {
+		if strings.HasSuffix(name, "+json") && mt.Schema != nil {
+			return mt, true
+		}
+	}
+	return mediaType{}, false
+}
+
+func requestSchema(op *operation) *Schema {
+	if op.RequestBody != nil {
+		if mt, ok := jsonContent(op.RequestBody.Content); ok {
+			return mt.Schema
+		}
+	}
+	for _, p := range op.Parameters {
+		if p.In == "body" && p.Schema != nil {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+// collect names the request and response schemas and every object schema they reference
+func (c *Contract) collect(base string) error {
+	if c.Request != nil {
+		c.Request = c.mergeAllOf(c.Request)
+		if err := c.name(c.Request, base+"Request"); err != nil {
+			return err
+		}
+		c.RequestType = c.typeName(c.Request, base+"Request")
+	}
+	if c.Response != nil {
+		c.Response = c.mergeAllOf(c.Response)
+		if err := c.name(c.Response, base+"Response"); err != nil {
+			return err
+		}
+		c.ResponseType = c.typeName(c.Response, base+"Response")
+	}
+	return nil
+}
+
+// typeName returns the name of the root type. Roots which aren't objects are generated as aliases.
+func (c *Contract) typeName(s *Schema, hint string) string {
+	if name := c.ModelName(s); name != "" {
+		return name
+	}
+	c.names[s] = hint
+	c.Models = append(c.Models, Model{Name: hint, Schema: s})
+	return hint
+}
+
+// mergeAllOf flattens allOf into a single object schema since the generated models don't support
+// composition
+func (c *Contract) mergeAllOf(s *Schema) *Schema {
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+	merged := *s
+	merged.AllOf = nil
+	merged.Type = SchemaType{"object"}
+	for _, part := range s.AllOf {
+		part = c.mergeAllOf(c.Resolve(part))
+		if part == nil {
+			continue
+		}
+		merged.Properties = append(merged.Properties, part.Properties...)
+		merged.Required = append(merged.Required, part.Required...)
+		if merged.Description == "" {
+			merged.Description = part.Description
+		}
+	}
+	return &merged
+}
+
+func (c *Contract) name(s *Schema, hint string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if c.resolved[s.Ref] {
+			return nil
+		}
+		target, ok := c.refs[s.Ref]
+		if !ok {
+			return fmt.Errorf("unable to resolve schema reference %s", s.Ref)
+		}
+		c.resolved[s.Ref] = true
+		merged := c.mergeAllOf(target)
+		if merged != target {
+			*target = *merged
+		}
+		name := strcase.ToCamel(s.Ref[strings.LastIndex(s.Ref, "/")+1:])
+		c.names[target] = name
+		if err := c.children(target, name); err != nil {
+			return err
+		}
+		if target.PrimaryType() == "object" || len(target.Enum) > 0 {
+			c.Models = append(c.Models, Model{Name: name, Schema: target})
+		} else {
+			// references to scalars and arrays are generated inline
+			delete(c.names, target)
+		}
+		return nil
+	}
+	if err := c.children(s, hint); err != nil {
+		return err
+	}
+	if s.PrimaryType() == "object" && len(s.Properties) > 0 {
+		if _, ok := c.names[s]; !ok {
+			c.names[s] = hint
+			c.Models = append(c.Models, Model{Name: hint, Schema: s})
+		}
+	}
+	return nil
+}
+
+func (c *Contract) children(s *Schema, hint string) error {
+	for i, p := range s.Properties {
+		s.Properties[i].Schema = c.mergeAllOf(p.Schema)
+		if err := c.name(s.Properties[i].Schema, hint+strcase.ToCamel(p.Name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		s.Items = c.mergeAllOf(s.Items)
+		if err := c.name(s.Items, hint+"Item"); err != nil {
+			return err
+		}
+	}
+	var n int
+	for _, variants := range [][]*Schema{s.OneOf, s.AnyOf} {
+		for i := range variants {
+			n++
+			variants[i] = c.mergeAllOf(variants[i])
+			if err := c.name(variants[i], fmt.Sprintf("%sVariant%d", hint, n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var tsIdentifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func literal(val any) string {
+	buf, _ := json.Marshal(val)
+	return string(buf)
+}
+
+// TypeScriptType returns the TypeScript type expression for the schema
+func (c *Contract) TypeScriptType(s *Schema) string {
+	if s == nil {
+		return "unknown"
+	}
+	var t string
+	if name := c.ModelName(s); name != "" {
+		t = name
+	} else {
+		r := c.Resolve(s)
+		if r == nil {
+			return "unknown"
+		}
+		t = c.typeScriptExpr(r)
+		if r.IsNullable() {
+			t += " | null"
+		}
+	}
+	if s.Ref != "" && s.IsNullable() {
+		t += " | null"
+	}
+	return t
+}
+
+func (c *Contract) typeScriptExpr(s *Schema) string {
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, literal(v))
+		}
+		return strings.Join(values, " | ")
+	}
+	if variants := append(append([]*Schema{}, s.OneOf...), s.AnyOf...); len(variants) > 0 {
+		types := make([]string, 0, len(variants))
+		for _, v := range variants {
+			types = append(types, c.TypeScriptType(v))
+		}
+		return strings.Join(types, " | ")
+	}
+	switch s.PrimaryType() {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		item := c.TypeScriptType(s.Items)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+func tsComment(indent string, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "*/", "*\\/"), "\n")
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s/** %s */\n", indent, lines[0])
+	}
+	var sb strings.Builder
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+	return sb.String()
+}
+
+func (c *Contract) typeScriptModel(m Model) string {
+	var sb strings.Builder
+	sb.WriteString(tsComment("", m.Schema.Description))
+	s := c.Resolve(m.Schema)
+	if s == nil || s.PrimaryType() != "object" || len(s.Enum) > 0 || len(s.Properties) == 0 {
+		var expr string
+		if s == nil {
+			expr = "unknown"
+		} else {
+			expr = c.typeScriptExpr(s)
+			if s.IsNullable() {
+				expr += " | null"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("export type %s = %s;\n", m.Name, expr))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("export interface %s {\n", m.Name))
+	for _, p := range s.Properties {
+		sb.WriteString(tsComment("\t", p.Schema.Description))
+		name := p.Name
+		if !tsIdentifierRegex.MatchString(name) {
+			name = literal(name)
+		}
+		optional := "?"
+		if s.IsRequired(p.Name) {
+			optional = ""
+		}
+		sb.WriteString(fmt.Sprintf("\t%s%s: %s;\n", name, optional, c.TypeScriptType(p.Schema)))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// validationSchema returns the simplified schema embedded in the generated validator
+func (c *Contract) validationSchema(s *Schema, root bool) map[string]any {
+	out := make(map[string]any)
+	if s == nil {
+		return out
+	}
+	if s.IsNullable() {
+		out["nullable"] = true
+	}
+	if name := c.ModelName(s); name != "" && !root {
+		out["$ref"] = name
+		return out
+	}
+	r := c.Resolve(s)
+	if r == nil {
+		return out
+	}
+	if r.IsNullable() {
+		out["nullable"] = true
+	}
+	if len(r.Enum) > 0 {
+		out["enum"] = r.Enum
+		return out
+	}
+	if variants := append(append([]*Schema{}, r.OneOf...), r.AnyOf...); len(variants) > 0 {
+		var anyOf []map[string]any
+		for _, v := range variants {
+			anyOf = append(anyOf, c.validationSchema(v, false))
+		}
+		out["anyOf"] = anyOf
+		return out
+	}
+	if t := r.PrimaryType(); t != "" {
+		out["type"] = t
+	}
+	if r.Items != nil {
+		out["items"] = c.validationSchema(r.Items, false)
+	}
+	if len(r.Properties) > 0 {
+		props := make(map[string]any)
+		for _, p := range r.Properties {
+			props[p.Name] = c.validationSchema(p.Schema, false)
+		}
+		out["properties"] = props
+	}
+	if len(r.Required) > 0 {
+		out["required"] = r.Required
+	}
+	return out
+}
+
+const typeScriptValidator = `type Schema = {
+	$ref?: string;
+	type?: string;
+	nullable?: boolean;
+	enum?: unknown[];
+	anyOf?: Schema[];
+	items?: Schema;
+	properties?: Record<string, Schema>;
+	required?: string[];
+};
+
+function check(schema: Schema, value: unknown, path: string, errors: string[]): void {
+	if (value === null && schema.nullable) {
+		return;
+	}
+	if (schema.$ref) {
+		check(models[schema.$ref], value, path, errors);
+		return;
+	}
+	if (schema.anyOf) {
+		const matches = schema.anyOf.some((variant) => {
+			const variantErrors: string[] = [];
+			check(variant, value, path, variantErrors);
+			return variantErrors.length === 0;
+		});
+		if (!matches) {
+			errors.push(` + "`${path} does not match any of the allowed schemas`" + `);
+		}
+		return;
+	}
+	if (schema.enum) {
+		if (!schema.enum.includes(value)) {
+			errors.push(` + "`${path} must be one of ${schema.enum.map((v) => JSON.stringify(v)).join(', ')}`" + `);
+		}
+		return;
+	}
+	switch (schema.type) {
+		case 'string':
+		case 'number':
+		case 'boolean':
+			if (typeof value !== schema.type) {
+				errors.push(` + "`${path} must be a ${schema.type}`" + `);
+			}
+			break;
+		case 'integer':
+			if (!Number.isInteger(value)) {
+				errors.push(` + "`${path} must be an integer`" + `);
+			}
+			break;
+		case 'null':
+			if (value !== null) {
+				errors.push(` + "`${path} must be null`" + `);
+			}
+			break;
+		case 'array':
+			if (!Array.isArray(value)) {
+				errors.push(` + "`${path} must be an array`" + `);
+				break;
+			}
+			if (schema.items) {
+				value.forEach((item, index) => check(schema.items as Schema, item, ` + "`${path}[${index}]`" + `, errors));
+			}
+			break;
+		case 'object': {
+			if (typeof value !== 'object' || value === null || Array.isArray(value)) {
+				errors.push(` + "`${path} must be an object`" + `);
+				break;
+			}
+			const record = value as Record<string, unknown>;
+			for (const name of schema.required ?? []) {
+				if (record[name] === undefined) {
+					errors.push(` + "`${path}.${name} is required`" + `);
+				}
+			}
+			for (const [name, property] of Object.entries(schema.properties ?? {})) {
+				if (record[name] !== undefined) {
+					check(property, record[name], ` + "`${path}.${name}`" + `, errors);
+				}
+			}
+			break;
+		}
+	}
+}
+`
+
+// GenerateTypeScriptTypes generates the TypeScript types and validators for the contract
+func (c *Contract) GenerateTypeScriptTypes() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Generated from %s - do not edit manually\n\n", c.Source))
+	for _, m := range c.Models {
+		sb.WriteString(c.typeScriptModel(m))
+		sb.WriteString("\n")
+	}
+	models := make(map[string]any)
+	for _, m := range c.Models {
+		models[m.Name] = c.validationSchema(m.Schema, true)
+	}
+	buf, _ := json.MarshalIndent(models, "", "\t")
+	sb.WriteString(fmt.Sprintf("const models: Record<string, Schema> = %s;\n\n", string(buf)))
+	sb.WriteString(typeScriptValidator)
+	for _, name := range []string{c.RequestType, c.ResponseType} {
+		if name == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(`
+/**
+ * Validates the value against the %[1]s schema and returns the validation errors
+ */
+export function validate%[1]s(value: unknown): string[] {
+	const errors: string[] = [];
+	check(models.%[1]s, value, '$', errors);
+	return errors;
+}
+`, name))
+	}
+	return sb.String()
+}
+
+// GenerateTypeScriptAgent generates the agent handler which validates the request
+func (c *Contract) GenerateTypeScriptAgent(name string) string {
+	responseType := "unknown"
+	imports := []string{"type " + c.RequestType, "validate" + c.RequestType}
+	if c.ResponseType != "" {
+		responseType = c.ResponseType
+		imports = append(imports, "type "+c.ResponseType)
+	}
+	return fmt.Sprintf(`import type { AgentContext, AgentRequest, AgentResponse } from '@agentuity/sdk';
+import { %[1]s } from './types';
+
+export const welcome = () => {
+	return {
+		welcome: %[2]s,
+		prompts: [
+			{
+				data: JSON.stringify(%[3]s),
+				contentType: 'application/json',
+			},
+		],
+	};
+};
+
+export default async function Agent(req: AgentRequest, resp: AgentResponse, ctx: AgentContext) {
+	const payload = await req.data.json();
+	const errors = validate%[4]s(payload);
+	if (errors.length > 0) {
+		ctx.logger.warn('invalid payload: %%s', errors.join(', '));
+		return resp.json({ error: 'invalid payload', details: errors });
+	}
+	const request = payload as %[4]s;
+	ctx.logger.info('received %[4]s: %%s', JSON.stringify(request));
+
+	// TODO: implement the agent
+	const result = {} as %[5]s;
+
+	return resp.json(result);
+}
+`, strings.Join(imports, ", "), literal(fmt.Sprintf("Send a %s payload to the %s agent", c.RequestType, name)), literal(c.Example(c.Request)), c.RequestType, responseType)
+}
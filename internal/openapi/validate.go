@@ -0,0 +1,291 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validator validates payloads against a JSON Schema
+type Validator struct {
+	root     *Schema
+	refs     map[string]*Schema
+	patterns map[string]*regexp.Regexp
+	raw      any
+}
+
+// LoadValidator loads the JSON Schema file (in JSON or YAML format) and returns a validator for it
+func LoadValidator(filename string) (*Validator, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidator(filepath.Base(filename), buf)
+}
+
+// NewValidator returns a validator for the JSON Schema (in JSON or YAML format) named name
+func NewValidator(name string, buf []byte) (*Validator, error) {
+	var schema Schema
+	if err := yaml.Unmarshal(buf, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	var raw any
+	if err := yaml.Unmarshal(buf, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	v := &Validator{
+		root:     &schema,
+		refs:     make(map[string]*Schema),
+		patterns: make(map[string]*regexp.Regexp),
+		raw:      raw,
+	}
+	v.refs["#"] = &schema
+	for name, s := range schema.Definitions {
+		v.refs["#/definitions/"+name] = s
+	}
+	for name, s := range schema.Defs {
+		v.refs["#/$defs/"+name] = s
+	}
+	if err := v.compile(&schema, 0); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// JSON returns the schema encoded as JSON
+func (v *Validator) JSON() (json.RawMessage, error) {
+	return json.Marshal(v.raw)
+}
+
+// compile checks the references and compiles the patterns of the schema
+func (v *Validator) compile(s *Schema, depth int) error {
+	if s == nil || depth > 64 {
+		return nil
+	}
+	if s.Ref != "" {
+		if _, ok := v.refs[s.Ref]; !ok {
+			return fmt.Errorf("unable to resolve schema reference %s", s.Ref)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		v.patterns[s.Pattern] = re
+	}
+	children := append(append(append([]*Schema{s.Items}, s.AllOf...), s.OneOf...), s.AnyOf...)
+	for _, p := range s.Properties {
+		children = append(children, p.Schema)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.Schema)
+	}
+	for _, d := range s.Definitions {
+		children = append(children, d)
+	}
+	for _, d := range s.Defs {
+		children = append(children, d)
+	}
+	for _, child := range children {
+		if err := v.compile(child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the JSON payload and returns the validation errors
+func (v *Validator) Validate(payload []byte) []string {
+	var value any
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return []string{fmt.Sprintf("payload is not valid JSON: %s", err)}
+	}
+	return v.ValidateValue(value)
+}
+
+// ValidateValue validates the decoded JSON value and returns the validation errors
+func (v *Validator) ValidateValue(value any) []string {
+	var errors []string
+	v.validate(v.root, value, "$", &errors, 0)
+	return errors
+}
+
+func jsonType(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
+
+func (v *Validator) matches(s *Schema, value any, depth int) bool {
+	var errors []string
+	v.validate(s, value, "$", &errors, depth)
+	return len(errors) == 0
+}
+
+func (v *Validator) validate(s *Schema, value any, path string, errors *[]string, depth int) {
+	if s == nil || depth > 64 {
+		return
+	}
+	if s.Ref != "" {
+		v.validate(v.refs[s.Ref], value, path, errors, depth+1)
+		return
+	}
+	if value == nil && s.Nullable {
+		return
+	}
+	for _, part := range s.AllOf {
+		v.validate(part, value, path, errors, depth+1)
+	}
+	if len(s.AnyOf) > 0 {
+		var matched bool
+		for _, variant := range s.AnyOf {
+			if v.matches(variant, value, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errors = append(*errors, fmt.Sprintf("%s does not match any of the allowed schemas", path))
+		}
+	}
+	if len(s.OneOf) > 0 {
+		var matched int
+		for _, variant := range s.OneOf {
+			if v.matches(variant, value, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			*errors = append(*errors, fmt.Sprintf("%s must match exactly one of the allowed schemas", path))
+		}
+	}
+	if len(s.Enum) > 0 {
+		var found bool
+		for _, e := range s.Enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values := make([]string, 0, len(s.Enum))
+			for _, e := range s.Enum {
+				values = append(values, literal(e))
+			}
+			*errors = append(*errors, fmt.Sprintf("%s must be one of %s", path, strings.Join(values, ", ")))
+			return
+		}
+	}
+
+	actual := jsonType(value)
+	if len(s.Type) > 0 {
+		var ok bool
+		for _, t := range s.Type {
+			if t == actual || (t == "number" && actual == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok && !(value == nil && s.IsNullable()) {
+			*errors = append(*errors, fmt.Sprintf("%s must be of type %s", path, strings.Join(s.Type, " or ")))
+			return
+		}
+	}
+
+	switch val := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(val)
+		if s.MinLength != nil && length < *s.MinLength {
+			*errors = append(*errors, fmt.Sprintf("%s must be at least %d characters", path, *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			*errors = append(*errors, fmt.Sprintf("%s must be at most %d characters", path, *s.MaxLength))
+		}
+		if re, ok := v.patterns[s.Pattern]; ok && s.Pattern != "" && !re.MatchString(val) {
+			*errors = append(*errors, fmt.Sprintf("%s must match the pattern %s", path, s.Pattern))
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			*errors = append(*errors, fmt.Sprintf("%s must be greater than or equal to %v", path, *s.Minimum))
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			*errors = append(*errors, fmt.Sprintf("%s must be less than or equal to %v", path, *s.Maximum))
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			*errors = append(*errors, fmt.Sprintf("%s must have at least %d items", path, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			*errors = append(*errors, fmt.Sprintf("%s must have at most %d items", path, *s.MaxItems))
+		}
+		if s.UniqueItems {
+			for i := range val {
+				for j := 0; j < i; j++ {
+					if jsonEqual(val[i], val[j]) {
+						*errors = append(*errors, fmt.Sprintf("%s[%d] is a duplicate of %s[%d]", path, i, path, j))
+						break
+					}
+				}
+			}
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errors, depth+1)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*errors = append(*errors, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		known := make(map[string]bool, len(s.Properties))
+		for _, p := range s.Properties {
+			known[p.Name] = true
+			if pv, ok := val[p.Name]; ok {
+				v.validate(p.Schema, pv, path+"."+p.Name, errors, depth+1)
+			}
+		}
+		if s.AdditionalProperties != nil {
+			for _, name := range sortedKeys(val) {
+				if known[name] {
+					continue
+				}
+				if !s.AdditionalProperties.Allowed {
+					*errors = append(*errors, fmt.Sprintf("%s.%s is not allowed", path, name))
+				} else if s.AdditionalProperties.Schema != nil {
+					v.validate(s.AdditionalProperties.Schema, val[name], path+"."+name, errors, depth+1)
+				}
+			}
+		}
+	}
+}
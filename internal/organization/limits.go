@@ -0,0 +1,108 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// The units of a quota
+const (
+	UnitCount  = "count"
+	UnitBytes  = "bytes"
+	UnitTokens = "tokens"
+)
+
+// The scopes of a quota
+const (
+	ScopeOrganization = "organization"
+	ScopeProject      = "project"
+)
+
+// Quota is a limit of the plan of the organization with its current consumption
+type Quota struct {
+	// Name is the name of the quota, such as deployments per day
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	Unit  string `json:"unit"`
+	// Limit is the maximum, which is 0 when the quota is unlimited
+	Limit int64 `json:"limit"`
+	Used  int64 `json:"used"`
+	// ResetsAt is when the consumption is reset for quotas which are per period, such as per day
+	ResetsAt *time.Time `json:"resetsAt,omitempty"`
+}
+
+// Unlimited returns true if the quota has no limit
+func (q Quota) Unlimited() bool {
+	return q.Limit <= 0
+}
+
+// Remaining returns what is left of the quota, which is 0 when it's exhausted or unlimited
+func (q Quota) Remaining() int64 {
+	if q.Unlimited() || q.Used >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.Used
+}
+
+// Percent returns the percentage of the quota which is used
+func (q Quota) Percent() float64 {
+	if q.Unlimited() {
+		return 0
+	}
+	return float64(q.Used) * 100 / float64(q.Limit)
+}
+
+// Exhausted returns true when nothing is left of the quota, which fails the operations it limits
+func (q Quota) Exhausted() bool {
+	return !q.Unlimited() && q.Used >= q.Limit
+}
+
+// Limits are the quotas of an organization and of one of its projects
+type Limits struct {
+	OrgID     string  `json:"orgId"`
+	OrgName   string  `json:"orgName"`
+	ProjectID string  `json:"projectId,omitempty"`
+	Plan      string  `json:"plan"`
+	Quotas    []Quota `json:"quotas"`
+}
+
+// Exhausted returns the quotas which are exhausted
+func (l *Limits) Exhausted() []Quota {
+	var exhausted []Quota
+	for _, q := range l.Quotas {
+		if q.Exhausted() {
+			exhausted = append(exhausted, q)
+		}
+	}
+	return exhausted
+}
+
+type limitsResult struct {
+	Success bool   `json:"success"`
+	Data    Limits `json:"data"`
+	Message string `json:"message"`
+}
+
+// GetLimits returns the quotas of the organization and their consumption, including the quotas of
+// the project when projectId isn't empty
+func GetLimits(ctx context.Context, logger logger.Logger, baseUrl string, token string, orgId string, projectId string) (*Limits, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	path := fmt.Sprintf("%s/%s/limits", listPath, url.PathEscape(orgId))
+	if projectId != "" {
+		path += "?projectId=" + url.QueryEscape(projectId)
+	}
+	var result limitsResult
+	if err := client.Do("GET", path, nil, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("failed to get the limits of the organization: %s", result.Message)
+	}
+	return &result.Data, nil
+}
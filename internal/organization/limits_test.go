@@ -0,0 +1,26 @@
+package organization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	deployments := Quota{Name: "Deployments per day", Unit: UnitCount, Limit: 20, Used: 15}
+	assert.Equal(t, int64(5), deployments.Remaining())
+	assert.Equal(t, 75.0, deployments.Percent())
+	assert.False(t, deployments.Exhausted())
+
+	tokens := Quota{Name: "Tokens per month", Unit: UnitTokens, Limit: 1000, Used: 1200}
+	assert.Zero(t, tokens.Remaining())
+	assert.True(t, tokens.Exhausted())
+
+	agents := Quota{Name: "Agents", Unit: UnitCount, Used: 40}
+	assert.True(t, agents.Unlimited())
+	assert.Zero(t, agents.Percent())
+	assert.False(t, agents.Exhausted())
+
+	limits := &Limits{Quotas: []Quota{deployments, tokens, agents}}
+	assert.Equal(t, []Quota{tokens}, limits.Exhausted())
+}
@@ -0,0 +1,129 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+const (
+	ProtocolHTTPProtobuf = "http/protobuf"
+	ProtocolHTTPJSON     = "http/json"
+	ProtocolGRPC         = "grpc"
+)
+
+// Protocols are the OTLP protocols supported for exporting traces
+var Protocols = []string{ProtocolHTTPProtobuf, ProtocolHTTPJSON, ProtocolGRPC}
+
+// Config is the customer owned OTLP endpoint the traces of the project are exported to in
+// addition to Agentuity
+type Config struct {
+	Endpoint  string            `json:"endpoint"`
+	Protocol  string            `json:"protocol"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	UpdatedAt string            `json:"updatedAt,omitempty"`
+}
+
+type Response[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// Validate checks the endpoint and protocol of the configuration
+func (c *Config) Validate() error {
+	if c.Protocol == "" {
+		c.Protocol = ProtocolHTTPProtobuf
+	}
+	if !slices.Contains(Protocols, c.Protocol) {
+		return fmt.Errorf("unsupported protocol %q (must be one of %s)", c.Protocol, strings.Join(Protocols, ", "))
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint %q (must be an http or https URL)", c.Endpoint)
+	}
+	for k := range c.Headers {
+		if k == "" || strings.ContainsAny(k, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", k)
+		}
+	}
+	return nil
+}
+
+// TracesURL returns the URL the traces are sent to over HTTP. Like OTEL_EXPORTER_OTLP_ENDPOINT the
+// endpoint is the base URL and the signal path is appended unless it is already present.
+func (c *Config) TracesURL() string {
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if strings.HasSuffix(endpoint, "/v1/traces") {
+		return endpoint
+	}
+	return endpoint + "/v1/traces"
+}
+
+// ParseHeaders parses the headers in key=value form
+func ParseHeaders(values []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, val := range values {
+		k, v, ok := strings.Cut(val, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid header %q (must be in key=value form)", val)
+		}
+		headers[k] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
+
+// GetConfig returns the OTLP configuration of the project or nil if none is configured. The header
+// values are masked unless mask is false.
+func GetConfig(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, mask bool) (*Config, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Config]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/otel?mask=%t", projectId, mask), nil, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching the OpenTelemetry configuration: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the OpenTelemetry configuration: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// SetConfig stores the OTLP configuration of the project
+func SetConfig(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, config Config) (*Config, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Config]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/project/%s/otel", projectId), config, &resp); err != nil {
+		return nil, fmt.Errorf("error saving the OpenTelemetry configuration: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error saving the OpenTelemetry configuration: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// DeleteConfig removes the OTLP configuration of the project
+func DeleteConfig(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[string]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/project/%s/otel", projectId), nil, &resp); err != nil {
+		return fmt.Errorf("error removing the OpenTelemetry configuration: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error removing the OpenTelemetry configuration: %s", resp.Message)
+	}
+	return nil
+}
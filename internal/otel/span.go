@@ -0,0 +1,227 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestSpanName is the name of the synthetic span sent by SendTestSpan
+const TestSpanName = "agentuity.otel.test"
+
+// TestResult is the result of sending the synthetic span
+type TestResult struct {
+	TraceID       string `json:"traceId"`
+	SpanID        string `json:"spanId"`
+	URL           string `json:"url"`
+	Protocol      string `json:"protocol"`
+	DurationMs    int64  `json:"durationMs"`
+	RejectedSpans int64  `json:"rejectedSpans,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+func stringAttributes(attributes map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: attributes[k]}}})
+	}
+	return kvs
+}
+
+// NewTestRequest returns the export request with a single synthetic span for the resource
+func NewTestRequest(resource map[string]string, version string) (*coltracepb.ExportTraceServiceRequest, error) {
+	traceID := make([]byte, 16)
+	spanID := make([]byte, 8)
+	if _, err := rand.Read(traceID); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(spanID); err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	start := end.Add(-time.Millisecond)
+	return &coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{
+			{
+				Resource: &resourcepb.Resource{Attributes: stringAttributes(resource)},
+				ScopeSpans: []*tracepb.ScopeSpans{
+					{
+						Scope: &commonpb.InstrumentationScope{Name: "github.com/agentuity/cli", Version: version},
+						Spans: []*tracepb.Span{
+							{
+								TraceId:           traceID,
+								SpanId:            spanID,
+								Name:              TestSpanName,
+								Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+								StartTimeUnixNano: uint64(start.UnixNano()),
+								EndTimeUnixNano:   uint64(end.UnixNano()),
+								Attributes: stringAttributes(map[string]string{
+									"agentuity.test": "true",
+									"message":        "This is a synthetic span sent by the Agentuity CLI to test the trace export",
+								}),
+								Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// marshalJSON encodes the request in the OTLP/JSON format which, unlike the protobuf JSON mapping,
+// encodes the trace and span ids as hex
+func marshalJSON(req *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	buf, err := protojson.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				for _, id := range [][]byte{span.TraceId, span.SpanId} {
+					buf = bytes.ReplaceAll(buf, []byte(`"`+base64.StdEncoding.EncodeToString(id)+`"`), []byte(`"`+hex.EncodeToString(id)+`"`))
+				}
+			}
+		}
+	}
+	return buf, nil
+}
+
+func sendHTTP(ctx context.Context, config Config, req *coltracepb.ExportTraceServiceRequest, client *http.Client) (*coltracepb.ExportTraceServiceResponse, error) {
+	var body []byte
+	var err error
+	contentType := "application/x-protobuf"
+	if config.Protocol == ProtocolHTTPJSON {
+		contentType = "application/json"
+		body, err = marshalJSON(req)
+	} else {
+		body, err = proto.Marshal(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequestWithContext(ctx, "POST", config.TracesURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Content-Type", contentType)
+	for k, v := range config.Headers {
+		hreq.Header.Set(k, v)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rbody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(rbody))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return nil, fmt.Errorf("the endpoint responded with status %d: %s", resp.StatusCode, msg)
+	}
+	// collectors may respond without a body so the partial success is only reported when present
+	var exportResp coltracepb.ExportTraceServiceResponse
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		protojson.Unmarshal(rbody, &exportResp)
+	} else {
+		proto.Unmarshal(rbody, &exportResp)
+	}
+	return &exportResp, nil
+}
+
+func sendGRPC(ctx context.Context, config Config, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	host := u.Host
+	if u.Scheme == "https" {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		if u.Port() == "" {
+			host += ":443"
+		}
+	}
+	conn, err := grpc.NewClient(host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if len(config.Headers) > 0 {
+		md := metadata.New(nil)
+		for k, v := range config.Headers {
+			md.Set(strings.ToLower(k), v)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return coltracepb.NewTraceServiceClient(conn).Export(ctx, req)
+}
+
+// SendTestSpan sends a synthetic span for the resource to the endpoint of the configuration
+func SendTestSpan(ctx context.Context, config Config, resource map[string]string, version string, client *http.Client) (*TestResult, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	req, err := NewTestRequest(resource, version)
+	if err != nil {
+		return nil, err
+	}
+	span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	result := &TestResult{
+		TraceID:  hex.EncodeToString(span.TraceId),
+		SpanID:   hex.EncodeToString(span.SpanId),
+		URL:      config.TracesURL(),
+		Protocol: config.Protocol,
+	}
+	started := time.Now()
+	var resp *coltracepb.ExportTraceServiceResponse
+	if config.Protocol == ProtocolGRPC {
+		result.URL = config.Endpoint
+		resp, err = sendGRPC(ctx, config, req)
+	} else {
+		resp, err = sendHTTP(ctx, config, req, client)
+	}
+	result.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		return nil, err
+	}
+	if ps := resp.GetPartialSuccess(); ps != nil {
+		result.RejectedSpans = ps.GetRejectedSpans()
+		result.Message = ps.GetErrorMessage()
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	config := Config{Endpoint: "https://otel.example.com"}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, ProtocolHTTPProtobuf, config.Protocol)
+	assert.Equal(t, "https://otel.example.com/v1/traces", config.TracesURL())
+
+	config = Config{Endpoint: "https://otel.example.com/v1/traces/", Protocol: ProtocolHTTPJSON}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, "https://otel.example.com/v1/traces", config.TracesURL())
+
+	assert.Error(t, (&Config{Endpoint: "otel.example.com"}).Validate())
+	assert.Error(t, (&Config{Endpoint: "https://otel.example.com", Protocol: "thrift"}).Validate())
+	assert.Error(t, (&Config{Endpoint: "https://otel.example.com", Headers: map[string]string{"bad header": "x"}}).Validate())
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"x-api-key=abc=123", " x-team = core "})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-api-key": "abc=123", "x-team": "core"}, headers)
+	_, err = ParseHeaders([]string{"novalue"})
+	assert.Error(t, err)
+}
+
+func TestSendTestSpanProtobuf(t *testing.T) {
+	var received coltracepb.ExportTraceServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, proto.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result, err := SendTestSpan(context.Background(), Config{Endpoint: server.URL, Headers: map[string]string{"x-api-key": "secret"}}, map[string]string{"service.name": "my-project"}, "1.0.0", nil)
+	assert.NoError(t, err)
+	assert.Len(t, result.TraceID, 32)
+	assert.Len(t, result.SpanID, 16)
+	assert.Equal(t, server.URL+"/v1/traces", result.URL)
+
+	span := received.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, TestSpanName, span.Name)
+	assert.Equal(t, "service.name", received.ResourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "my-project", received.ResourceSpans[0].Resource.Attributes[0].Value.GetStringValue())
+}
+
+func TestSendTestSpanJSON(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"partialSuccess":{"rejectedSpans":"1","errorMessage":"span too old"}}`)
+	}))
+	defer server.Close()
+
+	result, err := SendTestSpan(context.Background(), Config{Endpoint: server.URL, Protocol: ProtocolHTTPJSON}, nil, "1.0.0", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.RejectedSpans)
+	assert.Equal(t, "span too old", result.Message)
+
+	span := received["resourceSpans"].([]any)[0].(map[string]any)["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, result.TraceID, span["traceId"])
+	assert.Equal(t, result.SpanID, span["spanId"])
+}
+
+func TestSendTestSpanError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := SendTestSpan(context.Background(), Config{Endpoint: server.URL}, nil, "1.0.0", nil)
+	assert.ErrorContains(t, err, "status 401: invalid api key")
+}
@@ -0,0 +1,196 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+const (
+	ScopeOrg     = "org"
+	ScopeProject = "project"
+)
+
+// Policy is the set of rules a deploy must follow. The requirements apply to deploys which include
+// one of the protected tags or to all deploys when no tags are protected.
+type Policy struct {
+	ProtectedTags   []string `json:"protectedTags,omitempty"`
+	RequireCI       bool     `json:"requireCI,omitempty"`
+	RequireCleanGit bool     `json:"requireCleanGit,omitempty"`
+	UpdatedAt       string   `json:"updatedAt,omitempty"`
+}
+
+// Policies are the policies which apply to a project
+type Policies struct {
+	Org     *Policy `json:"org,omitempty"`
+	Project *Policy `json:"project,omitempty"`
+}
+
+// Deploy describes the deploy which is checked against the policy
+type Deploy struct {
+	Tags     []string
+	CI       bool
+	IsRepo   bool
+	GitClean bool
+}
+
+type Response[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// Empty returns true if the policy has no requirements
+func (p *Policy) Empty() bool {
+	return p == nil || (!p.RequireCI && !p.RequireCleanGit)
+}
+
+// Applies returns true if the requirements of the policy apply to a deploy with the tags
+func (p *Policy) Applies(tags []string) bool {
+	if p.Empty() {
+		return false
+	}
+	if len(p.ProtectedTags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if slices.Contains(p.ProtectedTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the reasons the deploy violates the policy or nil if it is allowed
+func (p *Policy) Check(deploy Deploy) []string {
+	if !p.Applies(deploy.Tags) {
+		return nil
+	}
+	var violations []string
+	if p.RequireCI && !deploy.CI {
+		violations = append(violations, "the deploy must be run from CI")
+	}
+	if p.RequireCleanGit {
+		if !deploy.IsRepo {
+			violations = append(violations, "the project must be in a git repository")
+		} else if !deploy.GitClean {
+			violations = append(violations, "the git working tree must not have uncommitted changes")
+		}
+	}
+	return violations
+}
+
+// Check returns the reasons the deploy violates the org or project policy or nil if it is allowed
+func (p *Policies) Check(deploy Deploy) []string {
+	var violations []string
+	for _, policy := range []*Policy{p.Org, p.Project} {
+		for _, v := range policy.Check(deploy) {
+			if !slices.Contains(violations, v) {
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
+func policyPath(scope string, id string) (string, error) {
+	switch scope {
+	case ScopeOrg:
+		return fmt.Sprintf("/cli/policy/org/%s", id), nil
+	case ScopeProject:
+		return fmt.Sprintf("/cli/policy/project/%s", id), nil
+	}
+	return "", fmt.Errorf("invalid policy scope %q", scope)
+}
+
+// GetPolicy returns the policy of the org or project or nil if none is set
+func GetPolicy(ctx context.Context, logger logger.Logger, baseUrl string, token string, scope string, id string) (*Policy, error) {
+	path, err := policyPath(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Policy]
+	if err := client.Do("GET", path, nil, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching the deploy policy: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the deploy policy: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// SetPolicy replaces the policy of the org or project
+func SetPolicy(ctx context.Context, logger logger.Logger, baseUrl string, token string, scope string, id string, policy Policy) (*Policy, error) {
+	path, err := policyPath(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[*Policy]
+	if err := client.Do("PUT", path, policy, &resp); err != nil {
+		return nil, fmt.Errorf("error saving the deploy policy: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error saving the deploy policy: %s", resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// DeletePolicy removes the policy of the org or project
+func DeletePolicy(ctx context.Context, logger logger.Logger, baseUrl string, token string, scope string, id string) error {
+	path, err := policyPath(scope, id)
+	if err != nil {
+		return err
+	}
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[string]
+	if err := client.Do("DELETE", path, nil, &resp); err != nil {
+		return fmt.Errorf("error removing the deploy policy: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("error removing the deploy policy: %s", resp.Message)
+	}
+	return nil
+}
+
+// GetProjectPolicies returns the org and project policies which apply to the project
+func GetProjectPolicies(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) (*Policies, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[Policies]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/policy", projectId), nil, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return &Policies{}, nil
+		}
+		return nil, fmt.Errorf("error fetching the deploy policies: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error fetching the deploy policies: %s", resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// IsCI returns true if the CLI is run from CI, either because the --ci flag was used or because the
+// CI environment variable set by most CI providers is present
+func IsCI(ciFlag bool) bool {
+	if ciFlag {
+		return true
+	}
+	val := os.Getenv("CI")
+	return val != "" && val != "0" && val != "false"
+}
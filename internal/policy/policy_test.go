@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{ProtectedTags: []string{"latest"}, RequireCI: true, RequireCleanGit: true}
+
+	assert.Nil(t, p.Check(Deploy{Tags: []string{"preview"}}))
+	assert.Nil(t, p.Check(Deploy{Tags: []string{"latest"}, CI: true, IsRepo: true, GitClean: true}))
+	assert.Equal(t, []string{"the deploy must be run from CI", "the git working tree must not have uncommitted changes"},
+		p.Check(Deploy{Tags: []string{"preview", "latest"}, IsRepo: true}))
+	assert.Equal(t, []string{"the project must be in a git repository"}, p.Check(Deploy{Tags: []string{"latest"}, CI: true}))
+
+	// without protected tags the requirements apply to every deploy
+	all := &Policy{RequireCI: true}
+	assert.Len(t, all.Check(Deploy{Tags: []string{"preview"}}), 1)
+
+	var none *Policy
+	assert.True(t, none.Empty())
+	assert.Nil(t, none.Check(Deploy{Tags: []string{"latest"}}))
+	assert.Nil(t, (&Policy{ProtectedTags: []string{"latest"}}).Check(Deploy{Tags: []string{"latest"}}))
+}
+
+func TestPoliciesCheck(t *testing.T) {
+	policies := &Policies{
+		Org:     &Policy{RequireCI: true},
+		Project: &Policy{ProtectedTags: []string{"latest"}, RequireCI: true, RequireCleanGit: true},
+	}
+	assert.Equal(t, []string{"the deploy must be run from CI"}, policies.Check(Deploy{Tags: []string{"preview"}, IsRepo: true}))
+	assert.Equal(t, []string{"the deploy must be run from CI", "the git working tree must not have uncommitted changes"},
+		policies.Check(Deploy{Tags: []string{"latest"}, IsRepo: true}))
+	assert.Nil(t, (&Policies{}).Check(Deploy{Tags: []string{"latest"}}))
+}
+
+func TestIsCI(t *testing.T) {
+	t.Setenv("CI", "")
+	assert.False(t, IsCI(false))
+	assert.True(t, IsCI(true))
+	t.Setenv("CI", "true")
+	assert.True(t, IsCI(false))
+	t.Setenv("CI", "false")
+	assert.False(t, IsCI(false))
+}
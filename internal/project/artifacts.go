@@ -0,0 +1,165 @@
+package project
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+)
+
+// AgentuityDir is the directory of the project where the CLI writes the build output and its local state
+const AgentuityDir = ".agentuity"
+
+// StateDirs are the directories in AgentuityDir which hold local state instead of build output. They
+// are kept when the project is built and are never deployed.
+var StateDirs = []string{"backup", "env-history", "evals", "logs", "sessions", "snapshots"}
+
+// StateFiles are the files in AgentuityDir which hold local state. Like StateDirs they are kept when
+// the project is built and are never deployed.
+var StateFiles = []string{"dev.json", "import.json"}
+
+const (
+	ArtifactBuild      = "build"
+	ArtifactBackup     = "backup"
+	ArtifactLogs       = "logs"
+	ArtifactSessions   = "sessions"
+	ArtifactEvals      = "evals"
+	ArtifactSnapshots  = "snapshots"
+	ArtifactEnvHistory = "env-history"
+	ArtifactCrash      = "crash"
+	ArtifactPrompts    = "prompts"
+	ArtifactTemp       = "temp"
+	ArtifactDev        = "dev"
+)
+
+// Artifact is a file or directory created by the CLI which can be removed
+type Artifact struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ArtifactOptions control which artifacts FindArtifacts returns
+type ArtifactOptions struct {
+	// TempDir is the directory of the temporary files, os.TempDir() if empty
+	TempDir string
+	// StaleAfter is the age after which a temporary file is no longer in use by another command
+	StaleAfter time.Duration
+	// All includes the artifacts which can't be recreated: the snapshots, the environment history,
+	// the eval baseline and the prompts generated into the SDK
+	All bool
+}
+
+// Temporary files created by the deploy, template, upgrade and dev commands
+var tempArtifactPatterns = []string{"agentuity-deploy-*.zip", "agentuity-template-*.zip", "agentuity-templates.zip*", "agentuity-upgrade*", "agentuity-extract*", "agentuity-middleware-*"}
+
+// PathSize returns the size of the file or the total size of the files in the directory
+func PathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+func appendArtifact(artifacts []Artifact, kind string, path string) []Artifact {
+	if _, err := os.Lstat(path); err != nil {
+		return artifacts
+	}
+	return append(artifacts, Artifact{Kind: kind, Path: path, Size: PathSize(path)})
+}
+
+// FindArtifacts returns the build output, local state and temporary files the CLI created for the
+// project in dir
+func FindArtifacts(dir string, opts ArtifactOptions) ([]Artifact, error) {
+	var artifacts []Artifact
+	agentuityDir := filepath.Join(dir, AgentuityDir)
+
+	entries, err := os.ReadDir(agentuityDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch name {
+		case "backup":
+			artifacts = appendArtifact(artifacts, ArtifactBackup, filepath.Join(agentuityDir, name))
+		case "logs":
+			artifacts = appendArtifact(artifacts, ArtifactLogs, filepath.Join(agentuityDir, name))
+		case "sessions":
+			artifacts = appendArtifact(artifacts, ArtifactSessions, filepath.Join(agentuityDir, name))
+		case "snapshots":
+			if opts.All {
+				artifacts = appendArtifact(artifacts, ArtifactSnapshots, filepath.Join(agentuityDir, name))
+			}
+		case "env-history":
+			if opts.All {
+				artifacts = appendArtifact(artifacts, ArtifactEnvHistory, filepath.Join(agentuityDir, name))
+			}
+		case "dev.json":
+			artifacts = appendArtifact(artifacts, ArtifactDev, filepath.Join(agentuityDir, name))
+		case "evals":
+			if opts.All {
+				artifacts = appendArtifact(artifacts, ArtifactEvals, filepath.Join(agentuityDir, name))
+			} else {
+				// the baseline is what new runs are compared with so only the last run is removed
+				artifacts = appendArtifact(artifacts, ArtifactEvals, filepath.Join(agentuityDir, name, "last-run.json"))
+			}
+		default:
+			artifacts = appendArtifact(artifacts, ArtifactBuild, filepath.Join(agentuityDir, name))
+		}
+	}
+
+	crashes, _ := filepath.Glob(filepath.Join(dir, ".agentuity-crash-*.json"))
+	for _, fn := range crashes {
+		artifacts = appendArtifact(artifacts, ArtifactCrash, fn)
+	}
+
+	if opts.All {
+		for _, sdkDir := range []string{"dist", "src"} {
+			generated := filepath.Join(dir, "node_modules", "@agentuity", "sdk", sdkDir, "apis", "prompt", "generated")
+			for _, name := range []string{"_index.js", "index.d.ts"} {
+				artifacts = appendArtifact(artifacts, ArtifactPrompts, filepath.Join(generated, name))
+			}
+		}
+	}
+
+	tempDir := opts.TempDir
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	var temps []string
+	for _, pattern := range tempArtifactPatterns {
+		matches, _ := filepath.Glob(filepath.Join(tempDir, pattern))
+		temps = append(temps, matches...)
+	}
+	sort.Strings(temps)
+	for _, fn := range temps {
+		// temporary files which are still recent may belong to a command which is running
+		if info, err := os.Lstat(fn); err == nil && time.Since(info.ModTime()) >= opts.StaleAfter {
+			artifacts = appendArtifact(artifacts, ArtifactTemp, fn)
+		}
+	}
+	return artifacts, nil
+}
+
+// RemoveArtifacts removes the files and directories of the artifacts
+func RemoveArtifacts(artifacts []Artifact) error {
+	for _, artifact := range artifacts {
+		if err := util.RemoveAll(artifact.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
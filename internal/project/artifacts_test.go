@@ -0,0 +1,58 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeArtifact(t *testing.T, fn string, size int) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+	assert.NoError(t, os.WriteFile(fn, make([]byte, size), 0644))
+}
+
+func TestFindArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	tempDir := t.TempDir()
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "index.js"), 100)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "backup", "hello", "index.ts"), 10)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "sessions", "default.jsonl"), 20)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "snapshots", "hello.json"), 5)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "evals", "baseline.json"), 5)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "env-history", "20261015-120000.json"), 4)
+	writeArtifact(t, filepath.Join(dir, AgentuityDir, "evals", "last-run.json"), 7)
+	writeArtifact(t, filepath.Join(dir, ".agentuity-crash-1700000000.json"), 3)
+	writeArtifact(t, filepath.Join(dir, "node_modules", "@agentuity", "sdk", "dist", "apis", "prompt", "generated", "_index.js"), 1)
+	writeArtifact(t, filepath.Join(tempDir, "agentuity-deploy-123.zip"), 50)
+	writeArtifact(t, filepath.Join(tempDir, "agentuity-deploy-456.zip"), 50)
+	writeArtifact(t, filepath.Join(tempDir, "unrelated.zip"), 50)
+	stale := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(tempDir, "agentuity-deploy-123.zip"), stale, stale))
+
+	artifacts, err := FindArtifacts(dir, ArtifactOptions{TempDir: tempDir, StaleAfter: time.Hour})
+	assert.NoError(t, err)
+	kinds := make(map[string]int64)
+	for _, a := range artifacts {
+		kinds[a.Kind] += a.Size
+	}
+	assert.Equal(t, map[string]int64{ArtifactBuild: 100, ArtifactBackup: 10, ArtifactSessions: 20, ArtifactEvals: 7, ArtifactCrash: 3, ArtifactTemp: 50}, kinds)
+
+	assert.NoError(t, RemoveArtifacts(artifacts))
+	assert.NoFileExists(t, filepath.Join(dir, AgentuityDir, "index.js"))
+	assert.NoFileExists(t, filepath.Join(tempDir, "agentuity-deploy-123.zip"))
+	assert.FileExists(t, filepath.Join(tempDir, "agentuity-deploy-456.zip"))
+	assert.FileExists(t, filepath.Join(dir, AgentuityDir, "evals", "baseline.json"))
+	assert.FileExists(t, filepath.Join(dir, AgentuityDir, "snapshots", "hello.json"))
+	assert.FileExists(t, filepath.Join(dir, AgentuityDir, "env-history", "20261015-120000.json"))
+
+	artifacts, err = FindArtifacts(dir, ArtifactOptions{TempDir: tempDir, StaleAfter: time.Hour, All: true})
+	assert.NoError(t, err)
+	kinds = make(map[string]int64)
+	for _, a := range artifacts {
+		kinds[a.Kind] += a.Size
+	}
+	assert.Equal(t, map[string]int64{ArtifactSnapshots: 5, ArtifactEnvHistory: 4, ArtifactEvals: 5, ArtifactPrompts: 1}, kinds)
+}
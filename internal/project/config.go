@@ -0,0 +1,712 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/project"
+	"gopkg.in/yaml.v3"
+)
+
+// the go-common project only knows about the keys it defines and drops everything else
+// when it is saved so we keep track of the keys which are only used by the CLI here
+type agentExtensions struct {
+	ID         string   `yaml:"id"`
+	Schema     string   `yaml:"schema,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"`
+	Entrypoint string   `yaml:"entrypoint,omitempty"`
+}
+
+type developmentExtensions struct {
+	Middleware  string            `yaml:"middleware,omitempty"`
+	LocalModels LocalModelsConfig `yaml:"local_models"`
+}
+
+// LocalModelsConfig is the local model runtime which serves the LLM calls of the agents in dev
+// mode with --local-models
+type LocalModelsConfig struct {
+	// Endpoint is the OpenAI compatible URL of the runtime, detected when empty
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Default is the local model used in place of the cloud models which aren't mapped
+	Default string `yaml:"default,omitempty"`
+	// Models maps the names of the cloud models to the local models
+	Models map[string]string `yaml:"models,omitempty"`
+}
+
+type redactionPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+type redactionExtensions struct {
+	// Defaults disables the default rules when false
+	Defaults *bool              `yaml:"defaults,omitempty"`
+	Patterns []redactionPattern `yaml:"patterns,omitempty"`
+}
+
+type envExtensions struct {
+	// Policy is the class of each variable such as secret or local-only
+	Policy map[string]string `yaml:"policy,omitempty"`
+}
+
+// BackupConfig is the retention of the backups of the source files of the deleted agents
+type BackupConfig struct {
+	// Keep is the number of backups kept
+	Keep int `yaml:"keep,omitempty"`
+	// MaxSize is the total size of the backups such as 100Mi
+	MaxSize string `yaml:"max_size,omitempty"`
+}
+
+// The limits of the autoscaling of a deployment
+const (
+	MaxScaleInstances   = 100
+	MaxScaleConcurrency = 1000
+)
+
+// ScaleConfig is the autoscaling of the deployments of the project where the cloud default is
+// used for each setting which is nil
+type ScaleConfig struct {
+	// Min is the number of instances always running, zero scales the deployment to zero when idle
+	Min *int `yaml:"min,omitempty" json:"min,omitempty"`
+	// Max is the maximum number of instances
+	Max *int `yaml:"max,omitempty" json:"max,omitempty"`
+	// Concurrency is the number of requests an instance handles at once before more instances are started
+	Concurrency *int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+}
+
+// Empty returns true if none of the settings are set
+func (c *ScaleConfig) Empty() bool {
+	return c == nil || (c.Min == nil && c.Max == nil && c.Concurrency == nil)
+}
+
+// Validate returns an error if a setting is out of the limits of the cloud or the minimum number
+// of instances is more than the maximum
+func (c *ScaleConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Min != nil && (*c.Min < 0 || *c.Min > MaxScaleInstances) {
+		return fmt.Errorf("the minimum number of instances must be between 0 and %d", MaxScaleInstances)
+	}
+	if c.Max != nil && (*c.Max < 1 || *c.Max > MaxScaleInstances) {
+		return fmt.Errorf("the maximum number of instances must be between 1 and %d", MaxScaleInstances)
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("the minimum number of instances (%d) is more than the maximum (%d)", *c.Min, *c.Max)
+	}
+	if c.Concurrency != nil && (*c.Concurrency < 1 || *c.Concurrency > MaxScaleConcurrency) {
+		return fmt.Errorf("the concurrency must be between 1 and %d", MaxScaleConcurrency)
+	}
+	return nil
+}
+
+type deploymentExtensions struct {
+	Scale ScaleConfig `yaml:"scale"`
+}
+
+// AssetPipeline are the assets processed by the bundler under bundler.assets, such as for the agents
+// which serve a web UI or send emails. The patterns are files, directories or glob patterns relative
+// to the project like the assets copied verbatim.
+type AssetPipeline struct {
+	// Static are copied with the hash of their content in their filename so that they can be cached
+	Static []string `yaml:"static,omitempty"`
+	// CSS are bundled with the files they import and minified, with the files they reference hashed
+	CSS []string `yaml:"css,omitempty"`
+	// Templates are inlined into a single file keyed by their path, such as email templates
+	Templates []string `yaml:"templates,omitempty"`
+}
+
+// Empty returns true if the pipeline has no assets
+func (a *AssetPipeline) Empty() bool {
+	return a == nil || (len(a.Static) == 0 && len(a.CSS) == 0 && len(a.Templates) == 0)
+}
+
+type bundlerExtensions struct {
+	Assets *AssetPipeline `yaml:"assets"`
+}
+
+type projectExtensions struct {
+	Tags        []string              `yaml:"tags,omitempty"`
+	Deployment  deploymentExtensions  `yaml:"deployment"`
+	Development developmentExtensions `yaml:"development"`
+	Redaction   redactionExtensions   `yaml:"redaction"`
+	Env         envExtensions         `yaml:"env"`
+	Backup      BackupConfig          `yaml:"backup"`
+	Assets      []string              `yaml:"assets,omitempty"`
+	Bundler     bundlerExtensions     `yaml:"bundler"`
+	Agents      []agentExtensions     `yaml:"agents"`
+}
+
+func loadExtensions(dir string) (*projectExtensions, error) {
+	buf, err := os.ReadFile(project.GetProjectFilename(dir))
+	if err != nil {
+		return nil, err
+	}
+	var ext projectExtensions
+	if err := yaml.Unmarshal(buf, &ext); err != nil {
+		return nil, fmt.Errorf("failed to parse project file: %w", err)
+	}
+	return &ext, nil
+}
+
+// LoadProjectTags returns the tags of the project from the project file
+func LoadProjectTags(dir string) ([]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ext.Tags, nil
+}
+
+// LoadProjectAssets returns the asset patterns of the project from the project file
+func LoadProjectAssets(dir string) ([]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ext.Assets, nil
+}
+
+// LoadAssetPipeline returns the assets processed by the bundler from the project file, which is
+// nil when there are none
+func LoadAssetPipeline(dir string) (*AssetPipeline, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	if ext.Bundler.Assets.Empty() {
+		return nil, nil
+	}
+	return ext.Bundler.Assets, nil
+}
+
+// LoadAgentTags returns the tags of each agent in the project file keyed by agent id
+func LoadAgentTags(dir string) (map[string][]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string)
+	for _, agent := range ext.Agents {
+		if agent.ID != "" && len(agent.Tags) > 0 {
+			tags[agent.ID] = agent.Tags
+		}
+	}
+	return tags, nil
+}
+
+// LoadAgentSchemas returns the absolute path of the payload schema declared for each agent in
+// the project file keyed by agent id
+func LoadAgentSchemas(dir string) (map[string]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[string]string)
+	for _, agent := range ext.Agents {
+		if agent.ID == "" || agent.Schema == "" {
+			continue
+		}
+		filename := agent.Schema
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(dir, filename)
+		}
+		schemas[agent.ID] = filename
+	}
+	return schemas, nil
+}
+
+// LoadAgentEntrypoints returns the entrypoint declared for each agent in the project file keyed by
+// agent id. The entrypoints are relative to the project directory and use forward slashes.
+func LoadAgentEntrypoints(dir string) (map[string]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	entrypoints := make(map[string]string)
+	for _, agent := range ext.Agents {
+		if agent.ID == "" || agent.Entrypoint == "" {
+			continue
+		}
+		entrypoint, err := CleanAgentEntrypoint(agent.Entrypoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entrypoint of the agent %s: %w", agent.ID, err)
+		}
+		entrypoints[agent.ID] = entrypoint
+	}
+	return entrypoints, nil
+}
+
+// CleanAgentEntrypoint returns the entrypoint of an agent relative to the project directory with
+// forward slashes or an error when it isn't a file in the project directory
+func CleanAgentEntrypoint(entrypoint string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(entrypoint))
+	if path.IsAbs(cleaned) || filepath.IsAbs(entrypoint) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%s is not a file in the project directory", entrypoint)
+	}
+	return cleaned, nil
+}
+
+// AgentEntrypoint returns the entrypoint of the agent relative to the project directory, which is
+// the one declared in the project file or the filename in the directory of the agent
+func AgentEntrypoint(p *project.Project, agent project.AgentConfig, entrypoints map[string]string, filename string) string {
+	if entrypoint, ok := entrypoints[agent.ID]; ok {
+		return entrypoint
+	}
+	return path.Join(filepath.ToSlash(p.Bundler.AgentConfig.Dir), util.SafeProjectFilename(agent.Name, p.IsPython()), filename)
+}
+
+// LoadDevMiddleware returns the absolute path of the dev mode middleware script declared in the
+// project file or an empty string when there is none
+func LoadDevMiddleware(dir string) (string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return "", err
+	}
+	filename := ext.Development.Middleware
+	if filename != "" && !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	return filename, nil
+}
+
+// LoadScaleConfig returns the autoscaling of the deployments from the project file, which is empty
+// when it isn't set
+func LoadScaleConfig(dir string) (*ScaleConfig, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ext.Deployment.Scale, nil
+}
+
+// LoadLocalModels returns the local model runtime configuration of the dev mode from the project file
+func LoadLocalModels(dir string) (*LocalModelsConfig, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ext.Development.LocalModels, nil
+}
+
+// LoadRedactor returns the redactor of the sensitive data in the output of the project with the
+// default rules (unless disabled) and the patterns declared in the redaction section of the project file
+func LoadRedactor(dir string) (*util.Redactor, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	redactor := util.NewRedactor()
+	if ext.Redaction.Defaults == nil || *ext.Redaction.Defaults {
+		for _, rule := range util.DefaultRedactionRules {
+			redactor.AddRule(rule)
+		}
+	}
+	for i, p := range ext.Redaction.Patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p.Pattern, err)
+		}
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("pattern-%d", i+1)
+		}
+		redactor.AddRule(util.RedactionRule{Name: name, Pattern: re})
+	}
+	return redactor, nil
+}
+
+// LoadBackupConfig returns the backup section of the project file
+func LoadBackupConfig(dir string) (*BackupConfig, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ext.Backup, nil
+}
+
+// LoadEnvPolicy returns the class of each variable declared in the env policy of the project file
+// or nil when the project file has no env policy
+func LoadEnvPolicy(dir string) (map[string]string, error) {
+	ext, err := loadExtensions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ext.Env.Policy, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// setMappingValueAfter sets the value of the key like setMappingValue but inserts a new key after
+// the key named after (when present) instead of at the end
+func setMappingValueAfter(node *yaml.Node, after string, key string, value *yaml.Node) {
+	if mappingValue(node, key) != nil {
+		setMappingValue(node, key, value)
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == after {
+			content := append([]*yaml.Node{}, node.Content[:i+2]...)
+			content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+			node.Content = append(content, node.Content[i+2:]...)
+			return
+		}
+	}
+	setMappingValue(node, key, value)
+}
+
+func removeMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func tagsNode(tags []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, tag := range tags {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tag})
+	}
+	return node
+}
+
+func documentRoot(buf []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// SaveProject saves the project file in dir, keeping the keys which are only used by the CLI
+// (such as the tags, the deployment size budget and scale, the dev mode middleware, the asset
+// pipeline, the env policy and the agent payload schemas and entrypoints) from the existing file.
+// Every key which go-common doesn't write is kept, wherever it is in the file.
+func SaveProject(dir string, p *project.Project) error {
+	return saveProject(dir, p, saveOverrides{})
+}
+
+// SaveProjectWithTags saves the project file like SaveProject but replaces the tags of the project
+func SaveProjectWithTags(dir string, p *project.Project, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	return saveProject(dir, p, saveOverrides{project: tags})
+}
+
+// SaveProjectWithAgentTags saves the project file like SaveProject but replaces the tags of the agent
+func SaveProjectWithAgentTags(dir string, p *project.Project, agentID string, tags []string) error {
+	return saveProject(dir, p, saveOverrides{agents: map[string][]string{agentID: tags}})
+}
+
+// SaveProjectWithAgentEntrypoints saves the project file like SaveProject but sets the entrypoints
+// of the agents keyed by agent id, where an empty entrypoint removes it
+func SaveProjectWithAgentEntrypoints(dir string, p *project.Project, entrypoints map[string]string) error {
+	return saveProject(dir, p, saveOverrides{entrypoints: entrypoints})
+}
+
+// SaveProjectWithEnvPolicy saves the project file like SaveProject but adds the classes of the
+// variables to its env policy, keeping the classes of the other variables
+func SaveProjectWithEnvPolicy(dir string, p *project.Project, classes map[string]string) error {
+	return saveProject(dir, p, saveOverrides{envPolicy: classes})
+}
+
+// SaveProjectWithScale saves the project file like SaveProject but replaces the autoscaling of the
+// deployments, which is removed when empty
+func SaveProjectWithScale(dir string, p *project.Project, scale ScaleConfig) error {
+	return saveProject(dir, p, saveOverrides{scale: &scale})
+}
+
+// saveOverrides are the values to replace when saving the project where a nil project slice keeps
+// the existing project tags and only the agents in the maps have their tags or entrypoint replaced
+// and only the variables in envPolicy have their class replaced. A nil scale keeps the existing one.
+type saveOverrides struct {
+	project     []string
+	agents      map[string][]string
+	entrypoints map[string]string
+	envPolicy   map[string]string
+	scale       *ScaleConfig
+}
+
+func saveProject(dir string, p *project.Project, overrides saveOverrides) error {
+	filename := project.GetProjectFilename(dir)
+	existing, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	saved, err := renderProject(p)
+	if err != nil {
+		return err
+	}
+	var old *yaml.Node
+	if len(existing) > 0 {
+		// nothing can be carried over from a file we can't parse
+		old, _ = documentRoot(existing)
+	}
+	root, err := documentRoot(saved)
+	if err != nil {
+		return err
+	}
+	if root == nil || root.Kind != yaml.MappingNode {
+		return writeProjectFile(filename, saved)
+	}
+	mergeUnknownKeys(old, root, goCommonKeys)
+	if err := applySaveOverrides(root, overrides); err != nil {
+		return err
+	}
+
+	// keep the generated header as is since the encoder doesn't preserve the blank lines between comments
+	// and the comment right before the first key belongs to the key
+	var header, end int
+	for _, line := range strings.SplitAfter(string(saved), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		header += len(line)
+		if trimmed == "" {
+			end = header
+		}
+	}
+	var buf bytes.Buffer
+	buf.Write(saved[:end])
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeProjectFile(filename, buf.Bytes())
+}
+
+// renderProject returns the project file as go-common writes it. The project can only be saved to
+// a directory so it's saved to a temporary one to keep the project file as is until it's complete.
+func renderProject(p *project.Project) ([]byte, error) {
+	tmpdir, err := os.MkdirTemp("", "agentuity-project-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+	if err := p.Save(tmpdir); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(project.GetProjectFilename(tmpdir))
+}
+
+// writeProjectFile writes to a temporary file first which replaces the project file so that an
+// interrupted save never leaves a partial project file
+func writeProjectFile(filename string, buf []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer util.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// keyTree is the tree of the keys of a yaml document where a nil subtree is a value as a whole
+type keyTree map[string]keyTree
+
+// goCommonKeys are the keys of the project file which go-common writes, every other key is only
+// used by the CLI and is carried over from the existing file when the project is saved
+var goCommonKeys = yamlKeys(reflect.TypeOf(project.Project{}))
+
+// yamlKeys returns the keys the yaml encoder writes for the type, where the elements of a slice
+// of structs have the keys of the struct
+func yamlKeys(t reflect.Type) keyTree {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Implements(reflect.TypeOf((*yaml.Marshaler)(nil)).Elem()) {
+		return nil
+	}
+	keys := make(keyTree)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			maps.Copy(keys, yamlKeys(field.Type))
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		keys[name] = yamlKeys(field.Type)
+	}
+	return keys
+}
+
+// mergeUnknownKeys copies the keys of old which aren't in known to node, creating the mappings
+// of the known keys which contain them when node doesn't have them. The elements of sequences are
+// matched by their id. A new key is inserted after the key before it in old to keep its place.
+func mergeUnknownKeys(old *yaml.Node, node *yaml.Node, known keyTree) {
+	if old == nil || node == nil || old.Kind != node.Kind {
+		return
+	}
+	switch old.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			id := mappingValue(item, "id")
+			if id == nil {
+				continue
+			}
+			for _, oldItem := range old.Content {
+				if oldId := mappingValue(oldItem, "id"); oldId != nil && oldId.Value == id.Value {
+					mergeUnknownKeys(oldItem, item, known)
+					break
+				}
+			}
+		}
+	case yaml.MappingNode:
+		var previous string
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			key, value := old.Content[i].Value, old.Content[i+1]
+			sub, ok := known[key]
+			switch {
+			case !ok:
+				setMappingValueAfter(node, previous, key, value)
+			case sub != nil:
+				if existing := mappingValue(node, key); existing != nil {
+					mergeUnknownKeys(value, existing, sub)
+				} else if value.Kind == yaml.MappingNode {
+					created := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+					mergeUnknownKeys(value, created, sub)
+					if len(created.Content) > 0 {
+						setMappingValueAfter(node, previous, key, created)
+					}
+				}
+			}
+			if mappingValue(node, key) != nil {
+				previous = key
+			}
+		}
+	}
+}
+
+// applySaveOverrides replaces the values of the overrides in the project file
+func applySaveOverrides(root *yaml.Node, overrides saveOverrides) error {
+	if overrides.project != nil {
+		if len(overrides.project) > 0 {
+			setMappingValueAfter(root, "description", "tags", tagsNode(overrides.project))
+		} else {
+			removeMappingValue(root, "tags")
+		}
+	}
+	if overrides.scale != nil {
+		deployment := mappingValue(root, "deployment")
+		if overrides.scale.Empty() {
+			if deployment != nil {
+				removeMappingValue(deployment, "scale")
+			}
+		} else {
+			scale := &yaml.Node{}
+			if err := scale.Encode(overrides.scale); err != nil {
+				return err
+			}
+			if deployment == nil || deployment.Kind != yaml.MappingNode {
+				deployment = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				setMappingValue(root, "deployment", deployment)
+			}
+			setMappingValue(deployment, "scale", scale)
+		}
+	}
+	if len(overrides.envPolicy) > 0 {
+		envValue := mappingValue(root, "env")
+		if envValue == nil || envValue.Kind != yaml.MappingNode {
+			envValue = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(root, "env", envValue)
+		}
+		policy := mappingValue(envValue, "policy")
+		if policy == nil || policy.Kind != yaml.MappingNode {
+			policy = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(envValue, "policy", policy)
+		}
+		keys := make([]string, 0, len(overrides.envPolicy))
+		for key := range overrides.envPolicy {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			setMappingValue(policy, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: overrides.envPolicy[key]})
+		}
+	}
+	agents := mappingValue(root, "agents")
+	if agents == nil || agents.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, agent := range agents.Content {
+		id := mappingValue(agent, "id")
+		if id == nil || agent.Kind != yaml.MappingNode {
+			continue
+		}
+		if tags, ok := overrides.agents[id.Value]; ok {
+			if len(tags) > 0 {
+				setMappingValue(agent, "tags", tagsNode(tags))
+			} else {
+				removeMappingValue(agent, "tags")
+			}
+		}
+		if entrypoint, ok := overrides.entrypoints[id.Value]; ok {
+			if entrypoint != "" {
+				setMappingValue(agent, "entrypoint", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entrypoint})
+			} else {
+				removeMappingValue(agent, "entrypoint")
+			}
+		}
+	}
+	return nil
+}
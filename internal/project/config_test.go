@@ -0,0 +1,349 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveProjectKeepsExtensions(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	p.Agents = []project.AgentConfig{{ID: "agent_1", Name: "first"}, {ID: "agent_2", Name: "second"}}
+	p.Development = &project.Development{Port: 3500, Command: "bun"}
+	assert.NoError(t, SaveProject(dir, p))
+
+	filename := project.GetProjectFilename(dir)
+	buf, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	original := string(buf)
+
+	schemas, err := LoadAgentSchemas(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, schemas)
+	middleware, err := LoadDevMiddleware(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, middleware)
+
+	var p2 project.Project
+	assert.NoError(t, p2.Load(dir))
+	assert.NoError(t, SaveProject(dir, &p2))
+	buf, _ = os.ReadFile(filename)
+	assert.Equal(t, original, string(buf))
+
+	// add the CLI only keys by hand like a user would
+	content := original + "\n"
+	content = replaceOnce(t, content, "id: agent_1\n", "id: agent_1\n    schema: schemas/first.json\n")
+	content = replaceOnce(t, content, "deployment:\n", "deployment:\n  budget:\n    warn: 10Mi\n  externalize:\n    threshold: 50Mi\n")
+	content = replaceOnce(t, content, "development:\n", "development:\n  middleware: dev/middleware.js\n  local_models:\n    default: llama3.1\n    models:\n      gpt-4o: qwen2.5\n")
+	content = replaceOnce(t, content, "\nagents:\n", "\nassets:\n  - models/*.onnx\nagents:\n")
+	content = replaceOnce(t, content, "    dir: src/agents\n", "    dir: src/agents\n  assets:\n    static:\n      - ui/*.png\n    css:\n      - ui/app.css\n")
+	content += "licenses:\n  mode: fail\n  deny:\n    - GPL-*\n"
+	content += "redaction:\n  defaults: false\n  patterns:\n    - name: customer-id\n      pattern: cust_[a-z0-9]+\n"
+	content += "backup:\n  keep: 3\n  max_size: 10Mi\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+
+	var p3 project.Project
+	assert.NoError(t, p3.Load(dir))
+	p3.Agents[1].Description = "updated"
+	assert.NoError(t, SaveProject(dir, &p3))
+
+	buf, _ = os.ReadFile(filename)
+	assert.Contains(t, string(buf), "# This file is generated by Agentuity\n")
+	assert.Contains(t, string(buf), "description: updated")
+	assert.Contains(t, string(buf), "budget:\n    warn: 10Mi\n")
+	assert.Contains(t, string(buf), "externalize:\n    threshold: 50Mi\n")
+	assert.Contains(t, string(buf), "middleware: dev/middleware.js\n")
+	assert.Contains(t, string(buf), "local_models:\n    default: llama3.1\n    models:\n      gpt-4o: qwen2.5\n")
+	assert.Contains(t, string(buf), "  assets:\n    static:\n      - ui/*.png\n    css:\n      - ui/app.css\nassets:\n  - models/*.onnx\n")
+	assert.Contains(t, string(buf), "licenses:\n  mode: fail\n  deny:\n    - GPL-*\n")
+	assert.Contains(t, string(buf), "redaction:\n  defaults: false\n")
+	assert.Contains(t, string(buf), "backup:\n  keep: 3\n  max_size: 10Mi\n")
+
+	schemas, err = LoadAgentSchemas(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"agent_1": filepath.Join(dir, "schemas/first.json")}, schemas)
+	middleware, err = LoadDevMiddleware(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "dev/middleware.js"), middleware)
+	localModels, err := LoadLocalModels(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &LocalModelsConfig{Default: "llama3.1", Models: map[string]string{"gpt-4o": "qwen2.5"}}, localModels)
+	assets, err := LoadProjectAssets(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"models/*.onnx"}, assets)
+	pipeline, err := LoadAssetPipeline(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &AssetPipeline{Static: []string{"ui/*.png"}, CSS: []string{"ui/app.css"}}, pipeline)
+	backup, err := LoadBackupConfig(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &BackupConfig{Keep: 3, MaxSize: "10Mi"}, backup)
+	redactor, err := LoadRedactor(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "[REDACTED:customer-id] jane@example.com", redactor.Redact("cust_a1b2 jane@example.com"))
+}
+
+func TestLoadRedactor(t *testing.T) {
+	dir := t.TempDir()
+	filename := project.GetProjectFilename(dir)
+	assert.NoError(t, os.WriteFile(filename, []byte("name: test\n"), 0644))
+	redactor, err := LoadRedactor(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "mail [REDACTED:email]", redactor.Redact("mail jane@example.com"))
+
+	assert.NoError(t, os.WriteFile(filename, []byte("name: test\nredaction:\n  patterns:\n    - pattern: \"[\"\n"), 0644))
+	_, err = LoadRedactor(dir)
+	assert.Error(t, err)
+}
+
+func TestSaveProjectKeepsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	filename := project.GetProjectFilename(dir)
+	content := `version: '>=0.0.1'
+project_id: proj_123
+name: test
+description: ""
+custom: kept
+development:
+  middleware: dev/middleware.js
+  local_models:
+    default: llama3.1
+deployment:
+  command: bun
+  budget:
+    warn: 10Mi
+  future:
+    setting: true
+bundler:
+  language: javascript
+  runtime: bunjs
+  agents:
+    dir: src/agents
+  ignore:
+    - dist/**
+  assets:
+    static:
+      - ui/*.png
+agents:
+  - id: agent_1
+    name: first
+    schema: schemas/first.json
+`
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+
+	// go-common omits the development and bundler sections when they're nil
+	var p project.Project
+	assert.NoError(t, p.Load(dir))
+	p.Development = nil
+	p.Bundler = nil
+	p.Deployment.Command = "node"
+	assert.NoError(t, SaveProject(dir, &p))
+
+	buf, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), "description: \"\"\ncustom: kept\n")
+	assert.Contains(t, string(buf), "development:\n  middleware: dev/middleware.js\n  local_models:\n    default: llama3.1\n")
+	assert.Contains(t, string(buf), "command: node\n")
+	assert.Contains(t, string(buf), "budget:\n    warn: 10Mi\n  future:\n    setting: true\n")
+	assert.Contains(t, string(buf), "bundler:\n  assets:\n    static:\n      - ui/*.png\n")
+	assert.Contains(t, string(buf), "schema: schemas/first.json\n")
+	// the keys go-common writes aren't brought back from the existing file
+	assert.NotContains(t, string(buf), "dist/**")
+	assert.NotContains(t, string(buf), "language: javascript")
+	pipeline, err := LoadAssetPipeline(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &AssetPipeline{Static: []string{"ui/*.png"}}, pipeline)
+
+	// the file is replaced without leaving a temporary file behind
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func replaceOnce(t *testing.T, content string, old string, replacement string) string {
+	i := strings.Index(content, old)
+	assert.NotEqual(t, -1, i, "missing %q", old)
+	return content[:i] + replacement + content[i+len(old):]
+}
+
+func TestSaveProjectWithTags(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Description = "the description"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	assert.NoError(t, SaveProjectWithTags(dir, p, []string{"support", "prod"}))
+
+	buf, err := os.ReadFile(project.GetProjectFilename(dir))
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), "description: the description\ntags: [support, prod]\n")
+	tags, err := LoadProjectTags(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"support", "prod"}, tags)
+
+	// the tags are kept when the project is saved without them
+	p.Name = "renamed"
+	assert.NoError(t, SaveProject(dir, p))
+	tags, err = LoadProjectTags(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"support", "prod"}, tags)
+
+	assert.NoError(t, SaveProjectWithTags(dir, p, nil))
+	buf, _ = os.ReadFile(project.GetProjectFilename(dir))
+	assert.NotContains(t, string(buf), "tags:")
+	assert.Contains(t, string(buf), "name: renamed")
+}
+
+func TestSaveProjectWithAgentTags(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	p.Agents = []project.AgentConfig{{ID: "agent_1", Name: "first"}, {ID: "agent_2", Name: "second"}}
+	assert.NoError(t, SaveProject(dir, p))
+
+	filename := project.GetProjectFilename(dir)
+	buf, _ := os.ReadFile(filename)
+	content := replaceOnce(t, string(buf), "id: agent_1\n", "id: agent_1\n    schema: schemas/first.json\n")
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+
+	p.Agents[0].Description = "the first agent"
+	assert.NoError(t, SaveProjectWithAgentTags(dir, p, "agent_1", []string{"beta"}))
+	tags, err := LoadAgentTags(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"agent_1": {"beta"}}, tags)
+	schemas, err := LoadAgentSchemas(dir)
+	assert.NoError(t, err)
+	assert.Len(t, schemas, 1)
+
+	// the agent tags are kept when the project is saved without them
+	assert.NoError(t, SaveProjectWithTags(dir, p, []string{"prod"}))
+	tags, _ = LoadAgentTags(dir)
+	assert.Equal(t, map[string][]string{"agent_1": {"beta"}}, tags)
+
+	assert.NoError(t, SaveProjectWithAgentTags(dir, p, "agent_1", nil))
+	tags, _ = LoadAgentTags(dir)
+	assert.Empty(t, tags)
+	projectTags, _ := LoadProjectTags(dir)
+	assert.Equal(t, []string{"prod"}, projectTags)
+	schemas, _ = LoadAgentSchemas(dir)
+	assert.Len(t, schemas, 1)
+}
+
+func TestSaveProjectWithAgentEntrypoints(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	p.Agents = []project.AgentConfig{{ID: "agent_1", Name: "first"}, {ID: "agent_2", Name: "Second Agent"}}
+	assert.NoError(t, SaveProject(dir, p))
+
+	assert.NoError(t, SaveProjectWithAgentEntrypoints(dir, p, map[string]string{"agent_1": "lib/support/handler.ts"}))
+	entrypoints, err := LoadAgentEntrypoints(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"agent_1": "lib/support/handler.ts"}, entrypoints)
+
+	// the entrypoints are kept when the project is saved without them
+	p.Agents[1].Description = "the second agent"
+	assert.NoError(t, SaveProject(dir, p))
+	entrypoints, _ = LoadAgentEntrypoints(dir)
+	assert.Equal(t, "lib/support/handler.ts", entrypoints["agent_1"])
+
+	assert.Equal(t, "lib/support/handler.ts", AgentEntrypoint(p, p.Agents[0], entrypoints, "index.ts"))
+	assert.Equal(t, "src/agents/Second-Agent/index.ts", AgentEntrypoint(p, p.Agents[1], entrypoints, "index.ts"))
+
+	assert.NoError(t, SaveProjectWithAgentEntrypoints(dir, p, map[string]string{"agent_1": ""}))
+	entrypoints, _ = LoadAgentEntrypoints(dir)
+	assert.Empty(t, entrypoints)
+}
+
+func TestCleanAgentEntrypoint(t *testing.T) {
+	entrypoint, err := CleanAgentEntrypoint("./src/agents/support/../support/handler.ts")
+	assert.NoError(t, err)
+	assert.Equal(t, "src/agents/support/handler.ts", entrypoint)
+	for _, invalid := range []string{"/etc/handler.ts", "../handler.ts", ".", ""} {
+		_, err := CleanAgentEntrypoint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSaveProjectWithEnvPolicy(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	assert.NoError(t, SaveProject(dir, p))
+	policy, err := LoadEnvPolicy(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	assert.NoError(t, SaveProjectWithEnvPolicy(dir, p, map[string]string{"OPENAI_API_KEY": "secret", "DEBUG": "local-only"}))
+	buf, err := os.ReadFile(project.GetProjectFilename(dir))
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), "env:\n  policy:\n    DEBUG: local-only\n    OPENAI_API_KEY: secret\n")
+
+	// the classes of the other variables are kept
+	assert.NoError(t, SaveProjectWithEnvPolicy(dir, p, map[string]string{"DEBUG": "env", "REGION": "env"}))
+	policy, err = LoadEnvPolicy(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"OPENAI_API_KEY": "secret", "DEBUG": "env", "REGION": "env"}, policy)
+
+	// and the policy is kept when the project is saved without it
+	p.Name = "renamed"
+	assert.NoError(t, SaveProject(dir, p))
+	policy, err = LoadEnvPolicy(dir)
+	assert.NoError(t, err)
+	assert.Len(t, policy, 3)
+}
+
+func TestSaveProjectWithScale(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_123"
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	p.Deployment = &project.Deployment{Command: "bun", Resources: &project.Resources{Memory: "1Gi"}}
+	assert.NoError(t, SaveProject(dir, p))
+
+	scale, err := LoadScaleConfig(dir)
+	assert.NoError(t, err)
+	assert.True(t, scale.Empty())
+
+	minimum, concurrency := 0, 4
+	assert.NoError(t, SaveProjectWithScale(dir, p, ScaleConfig{Min: &minimum, Concurrency: &concurrency}))
+	buf, _ := os.ReadFile(project.GetProjectFilename(dir))
+	assert.Contains(t, string(buf), "  scale:\n    min: 0\n    concurrency: 4\n")
+	scale, err = LoadScaleConfig(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &ScaleConfig{Min: &minimum, Concurrency: &concurrency}, scale)
+
+	// the scale is kept when the project is saved without changing it
+	assert.NoError(t, SaveProject(dir, p))
+	buf, _ = os.ReadFile(project.GetProjectFilename(dir))
+	assert.Contains(t, string(buf), "  scale:\n    min: 0\n    concurrency: 4\n")
+
+	assert.NoError(t, SaveProjectWithScale(dir, p, ScaleConfig{}))
+	buf, _ = os.ReadFile(project.GetProjectFilename(dir))
+	assert.NotContains(t, string(buf), "scale:")
+}
+
+func TestScaleConfigValidate(t *testing.T) {
+	value := func(v int) *int { return &v }
+	assert.NoError(t, (*ScaleConfig)(nil).Validate())
+	assert.NoError(t, (&ScaleConfig{Min: value(0), Max: value(10), Concurrency: value(4)}).Validate())
+	assert.ErrorContains(t, (&ScaleConfig{Min: value(-1)}).Validate(), "minimum number of instances must be between 0 and 100")
+	assert.ErrorContains(t, (&ScaleConfig{Max: value(0)}).Validate(), "maximum number of instances must be between 1 and 100")
+	assert.ErrorContains(t, (&ScaleConfig{Min: value(5), Max: value(2)}).Validate(), "(5) is more than the maximum (2)")
+	assert.ErrorContains(t, (&ScaleConfig{Concurrency: value(1001)}).Validate(), "concurrency must be between 1 and 1000")
+}
@@ -0,0 +1,136 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+)
+
+// DeployLockRenewInterval is how often a held deploy lock is renewed. The lock expires when it
+// hasn't been renewed for a few intervals so a deploy which crashed doesn't block the next ones.
+const DeployLockRenewInterval = 20 * time.Second
+
+// DeployLockHolder describes who started the deploy which holds a lock
+type DeployLockHolder struct {
+	// User is the name of the member of the organization and is set by the server
+	User     string `json:"user,omitempty"`
+	Username string `json:"username,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	// Origin is cli or ci
+	Origin  string `json:"origin,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	LogsURL string `json:"logsUrl,omitempty"`
+}
+
+func (h DeployLockHolder) String() string {
+	var who string
+	switch {
+	case h.User != "":
+		who = h.User
+	case h.Username != "":
+		who = h.Username
+	default:
+		who = "an unknown user"
+	}
+	if h.Origin == "ci" {
+		who += " from a CI job"
+	} else if h.Hostname != "" {
+		who += " on " + h.Hostname
+	}
+	var details []string
+	if h.Branch != "" {
+		details = append(details, "branch "+h.Branch)
+	}
+	if h.Commit != "" {
+		commit := h.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		details = append(details, "commit "+commit)
+	}
+	if h.LogsURL != "" {
+		details = append(details, h.LogsURL)
+	}
+	if len(details) > 0 {
+		who += " (" + strings.Join(details, ", ") + ")"
+	}
+	return who
+}
+
+// DeployLock is the lock of a deploy in progress for some tags of a project
+type DeployLock struct {
+	ID         string           `json:"id"`
+	Tags       []string         `json:"tags"`
+	Holder     DeployLockHolder `json:"holder"`
+	AcquiredAt time.Time        `json:"acquiredAt"`
+	ExpiresAt  time.Time        `json:"expiresAt"`
+}
+
+// DeployLockResult is the result of acquiring a deploy lock. When it isn't acquired, the lock is
+// the one of the deploy in progress.
+type DeployLockResult struct {
+	Acquired bool       `json:"acquired"`
+	Lock     DeployLock `json:"lock"`
+}
+
+// ErrDeployLockUnsupported is returned by AcquireDeployLock when the API doesn't support deploy locks
+var ErrDeployLockUnsupported = errors.New("the API doesn't support deploy locks")
+
+// AcquireDeployLock acquires the lock to deploy the tags of the project. It isn't acquired when
+// another deploy of one of the tags is in progress unless takeover is set, which releases the lock
+// of the other deploy first. It returns ErrDeployLockUnsupported when the API doesn't have deploy locks.
+func AcquireDeployLock(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, tags []string, holder DeployLockHolder, takeover bool) (*DeployLockResult, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	payload := map[string]any{"tags": tags, "holder": holder, "takeover": takeover}
+	var resp Response[DeployLockResult]
+	if err := client.Do("POST", fmt.Sprintf("/cli/project/%s/deploy-lock", projectId), payload, &resp); err != nil {
+		var apiErr *util.APIError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusNotFound || apiErr.Status == http.StatusNotImplemented) {
+			logger.Debug("deploy locks aren't supported by the API: %s", err)
+			return nil, ErrDeployLockUnsupported
+		}
+		return nil, fmt.Errorf("error acquiring the deploy lock: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// RenewDeployLock extends the expiration of a held deploy lock. It fails when the lock was taken
+// over by another deploy or has expired.
+func RenewDeployLock(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, lockId string) (*DeployLock, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[DeployLock]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/project/%s/deploy-lock/%s", projectId, url.PathEscape(lockId)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error renewing the deploy lock: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// ReleaseDeployLock releases a held deploy lock
+func ReleaseDeployLock(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, lockId string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[string]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/project/%s/deploy-lock/%s", projectId, url.PathEscape(lockId)), nil, &resp); err != nil {
+		return fmt.Errorf("error releasing the deploy lock: %w", err)
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package project
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployLockHolderString(t *testing.T) {
+	assert.Equal(t, "Jane Doe on laptop (branch main, commit 1a2b3c4)", DeployLockHolder{User: "Jane Doe", Username: "jane", Hostname: "laptop", Origin: "cli", Branch: "main", Commit: "1a2b3c4d5e6f"}.String())
+	assert.Equal(t, "jane on laptop", DeployLockHolder{Username: "jane", Hostname: "laptop"}.String())
+	assert.Equal(t, "Deploy Bot from a CI job (https://ci.example.com/jobs/42)", DeployLockHolder{User: "Deploy Bot", Hostname: "runner-7", Origin: "ci", LogsURL: "https://ci.example.com/jobs/42"}.String())
+	assert.Equal(t, "an unknown user", DeployLockHolder{}.String())
+}
+
+func TestAcquireDeployLockUnsupported(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusNotImplemented} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		_, err := AcquireDeployLock(context.Background(), logger.NewTestLogger(), server.URL, "token", "proj_1", []string{"latest"}, DeployLockHolder{}, false)
+		server.Close()
+		assert.ErrorIs(t, err, ErrDeployLockUnsupported, "status %d", status)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	_, err := AcquireDeployLock(context.Background(), logger.NewTestLogger(), server.URL, "token", "proj_1", []string{"latest"}, DeployLockHolder{}, false)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDeployLockUnsupported)
+}
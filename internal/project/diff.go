@@ -0,0 +1,163 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ValueChange is a value which is different between two deployments
+type ValueChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SetDiff is the difference between two sets of names
+type SetDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty returns true if nothing was added, removed or changed
+func (d SetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DeploymentDiff is the difference between the metadata of two deployments
+type DeploymentDiff struct {
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	Commit    *ValueChange           `json:"commit,omitempty"`
+	Branch    *ValueChange           `json:"branch,omitempty"`
+	Commits   []string               `json:"commits,omitempty"`
+	Labels    SetDiff                `json:"labels"`
+	Agents    SetDiff                `json:"agents"`
+	Env       SetDiff                `json:"env"`
+	Secrets   SetDiff                `json:"secrets"`
+	Resources map[string]ValueChange `json:"resources,omitempty"`
+	Digest    *ValueChange           `json:"digest,omitempty"`
+	Files     SetDiff                `json:"files"`
+}
+
+// Empty returns true if the deployments have the same metadata
+func (d *DeploymentDiff) Empty() bool {
+	return d.Commit == nil && d.Branch == nil && d.Labels.Empty() && d.Agents.Empty() && d.Env.Empty() && d.Secrets.Empty() &&
+		len(d.Resources) == 0 && d.Digest == nil && d.Files.Empty()
+}
+
+func diffKeys(from, to []string) SetDiff {
+	var diff SetDiff
+	for _, k := range to {
+		if !slices.Contains(from, k) {
+			diff.Added = append(diff.Added, k)
+		}
+	}
+	for _, k := range from {
+		if !slices.Contains(to, k) {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+func diffValues(from, to map[string]string) SetDiff {
+	var diff SetDiff
+	for k, v := range to {
+		if old, ok := from[k]; !ok {
+			diff.Added = append(diff.Added, k)
+		} else if old != v {
+			diff.Changed = append(diff.Changed, k)
+		}
+	}
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+func changed(from, to string) *ValueChange {
+	if from == to {
+		return nil
+	}
+	return &ValueChange{From: from, To: to}
+}
+
+// DiffDeployments compares the metadata of two deployments. Agents are compared by id and a
+// renamed agent is reported as changed.
+func DiffDeployments(from, to *DeploymentDetail) *DeploymentDiff {
+	diff := &DeploymentDiff{From: from.ID, To: to.ID}
+
+	var fromGit, toGit DeploymentGit
+	if from.Git != nil {
+		fromGit = *from.Git
+	}
+	if to.Git != nil {
+		toGit = *to.Git
+	}
+	diff.Commit = changed(fromGit.Commit, toGit.Commit)
+	diff.Branch = changed(fromGit.Branch, toGit.Branch)
+	diff.Labels = diffValues(from.Labels, to.Labels)
+
+	fromAgents := make(map[string]string)
+	for _, a := range from.Agents {
+		fromAgents[a.ID] = a.Name
+	}
+	toAgents := make(map[string]string)
+	for _, a := range to.Agents {
+		toAgents[a.ID] = a.Name
+	}
+	agents := diffValues(fromAgents, toAgents)
+	for i, id := range agents.Added {
+		agents.Added[i] = toAgents[id]
+	}
+	for i, id := range agents.Removed {
+		agents.Removed[i] = fromAgents[id]
+	}
+	for i, id := range agents.Changed {
+		agents.Changed[i] = fmt.Sprintf("%s → %s", fromAgents[id], toAgents[id])
+	}
+	diff.Agents = agents
+
+	diff.Env = diffKeys(from.EnvKeys, to.EnvKeys)
+	diff.Secrets = diffKeys(from.SecretKeys, to.SecretKeys)
+
+	var fromResources, toResources DeploymentResources
+	if from.Resources != nil {
+		fromResources = *from.Resources
+	}
+	if to.Resources != nil {
+		toResources = *to.Resources
+	}
+	resources := make(map[string]ValueChange)
+	if c := changed(formatQuantity(fromResources.Memory, "Mi"), formatQuantity(toResources.Memory, "Mi")); c != nil {
+		resources["memory"] = *c
+	}
+	if c := changed(formatQuantity(fromResources.CPU, "m"), formatQuantity(toResources.CPU, "m")); c != nil {
+		resources["cpu"] = *c
+	}
+	if c := changed(formatQuantity(fromResources.Disk, "Mi"), formatQuantity(toResources.Disk, "Mi")); c != nil {
+		resources["disk"] = *c
+	}
+	if len(resources) > 0 {
+		diff.Resources = resources
+	}
+
+	diff.Digest = changed(from.Digest, to.Digest)
+	diff.Files = diffValues(from.Files, to.Files)
+	return diff
+}
+
+func formatQuantity(val int64, unit string) string {
+	if val == 0 {
+		return "default"
+	}
+	return fmt.Sprintf("%d%s", val, unit)
+}
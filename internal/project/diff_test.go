@@ -0,0 +1,43 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDeployments(t *testing.T) {
+	from := &DeploymentDetail{
+		DeploymentListData: DeploymentListData{ID: "deploy_1", Labels: map[string]string{"team": "payments", "ticket": "JIRA-1"}},
+		Git:                &DeploymentGit{Branch: "main", Commit: "aaaaaaaaaa"},
+		Agents:             []DeploymentAgent{{ID: "agent_1", Name: "hello"}, {ID: "agent_2", Name: "support"}},
+		EnvKeys:            []string{"LOG_LEVEL", "REGION"},
+		SecretKeys:         []string{"OPENAI_API_KEY"},
+		Resources:          &DeploymentResources{Memory: 250, CPU: 500},
+		Digest:             "sha256:1",
+		Files:              map[string]string{"index.js": "1", "package.json": "1", "old.js": "1"},
+	}
+	to := &DeploymentDetail{
+		DeploymentListData: DeploymentListData{ID: "deploy_2", Labels: map[string]string{"team": "payments", "ticket": "JIRA-2", "release": "42"}},
+		Git:                &DeploymentGit{Branch: "main", Commit: "bbbbbbbbbb"},
+		Agents:             []DeploymentAgent{{ID: "agent_1", Name: "hello-world"}, {ID: "agent_3", Name: "billing"}},
+		EnvKeys:            []string{"REGION", "FEATURE_FLAG"},
+		SecretKeys:         []string{"OPENAI_API_KEY"},
+		Resources:          &DeploymentResources{Memory: 500, CPU: 500},
+		Digest:             "sha256:2",
+		Files:              map[string]string{"index.js": "2", "package.json": "1", "new.js": "1"},
+	}
+	diff := DiffDeployments(from, to)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, &ValueChange{From: "aaaaaaaaaa", To: "bbbbbbbbbb"}, diff.Commit)
+	assert.Nil(t, diff.Branch)
+	assert.Equal(t, SetDiff{Added: []string{"release"}, Changed: []string{"ticket"}}, diff.Labels)
+	assert.Equal(t, SetDiff{Added: []string{"billing"}, Removed: []string{"support"}, Changed: []string{"hello → hello-world"}}, diff.Agents)
+	assert.Equal(t, SetDiff{Added: []string{"FEATURE_FLAG"}, Removed: []string{"LOG_LEVEL"}}, diff.Env)
+	assert.True(t, diff.Secrets.Empty())
+	assert.Equal(t, map[string]ValueChange{"memory": {From: "250Mi", To: "500Mi"}}, diff.Resources)
+	assert.Equal(t, SetDiff{Added: []string{"new.js"}, Removed: []string{"old.js"}, Changed: []string{"index.js"}}, diff.Files)
+
+	assert.True(t, DiffDeployments(from, from).Empty())
+	assert.True(t, DiffDeployments(&DeploymentDetail{}, &DeploymentDetail{}).Empty())
+}
@@ -0,0 +1,125 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// The kinds of drift
+const (
+	DriftAgent      = "agent"
+	DriftEnv        = "env"
+	DriftDeployment = "deployment"
+)
+
+var isAgentuityEnv = regexp.MustCompile(`(?i)AGENTUITY_`)
+
+// DriftState is the state of the agents and the environment of a project, locally and in the cloud,
+// and its active deployment which is compared over time to detect drift. The environment values
+// are hashed so the state never holds them.
+type DriftState struct {
+	// LocalAgents and CloudAgents are the names of the agents by id
+	LocalAgents map[string]string   `json:"localAgents"`
+	CloudAgents map[string]string   `json:"cloudAgents"`
+	LocalEnv    map[string]string   `json:"localEnv"`
+	CloudEnv    map[string]string   `json:"cloudEnv"`
+	Deployment  *DeploymentListData `json:"deployment,omitempty"`
+}
+
+// Drift is a difference between the project and the cloud or a change in the cloud
+type Drift struct {
+	Kind string `json:"kind"`
+	// Key is the id of the agent or the deployment or the environment variable
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// HashEnvValues returns the variables with their values hashed, without the variables of Agentuity
+func HashEnvValues(values map[string]string) map[string]string {
+	hashed := make(map[string]string)
+	for k, v := range values {
+		if isAgentuityEnv.MatchString(k) {
+			continue
+		}
+		sum := sha256.Sum256([]byte(v))
+		hashed[k] = hex.EncodeToString(sum[:])
+	}
+	return hashed
+}
+
+// DetectDrift returns the drift of cur which wasn't in prev, which is nil for the first state. The
+// differences between the project and the cloud are returned once when they appear, along with the
+// environment variables changed in the cloud and a new active deployment since prev.
+func DetectDrift(prev *DriftState, cur *DriftState) []Drift {
+	var drift []Drift
+	reported := make(map[string]bool)
+	if prev != nil {
+		env := diffValues(prev.CloudEnv, cur.CloudEnv)
+		for _, k := range env.Added {
+			drift = append(drift, Drift{DriftEnv, k, fmt.Sprintf("%s was set in the cloud", k)})
+		}
+		for _, k := range env.Changed {
+			drift = append(drift, Drift{DriftEnv, k, fmt.Sprintf("%s was changed in the cloud", k)})
+		}
+		for _, k := range env.Removed {
+			drift = append(drift, Drift{DriftEnv, k, fmt.Sprintf("%s was deleted from the cloud", k)})
+		}
+		for _, d := range drift {
+			reported[d.Kind+":"+d.Key] = true
+		}
+		if cur.Deployment != nil && (prev.Deployment == nil || prev.Deployment.ID != cur.Deployment.ID) {
+			message := fmt.Sprintf("The deployment %s is now active", cur.Deployment.ID)
+			if cur.Deployment.Message != "" {
+				message += fmt.Sprintf(" (%s)", cur.Deployment.Message)
+			}
+			drift = append(drift, Drift{DriftDeployment, cur.Deployment.ID, message})
+		}
+	}
+	previous := make(map[Drift]bool)
+	if prev != nil {
+		for _, d := range driftMismatches(prev) {
+			previous[d] = true
+		}
+	}
+	for _, d := range driftMismatches(cur) {
+		if !previous[d] && !reported[d.Kind+":"+d.Key] {
+			drift = append(drift, d)
+		}
+	}
+	return drift
+}
+
+// driftMismatches returns the differences between the project and the cloud. The variables which
+// are only in the cloud aren't a difference since secrets are often only set in the cloud.
+func driftMismatches(s *DriftState) []Drift {
+	var drift []Drift
+	for id, name := range s.LocalAgents {
+		if cloud, ok := s.CloudAgents[id]; !ok {
+			drift = append(drift, Drift{DriftAgent, id, fmt.Sprintf("The agent %s isn't in the cloud", name)})
+		} else if cloud != name {
+			drift = append(drift, Drift{DriftAgent, id, fmt.Sprintf("The agent %s is named %s in the cloud", name, cloud)})
+		}
+	}
+	for id, name := range s.CloudAgents {
+		if _, ok := s.LocalAgents[id]; !ok {
+			drift = append(drift, Drift{DriftAgent, id, fmt.Sprintf("The agent %s is in the cloud but not in the project", name)})
+		}
+	}
+	for k, v := range s.LocalEnv {
+		if cloud, ok := s.CloudEnv[k]; !ok {
+			drift = append(drift, Drift{DriftEnv, k, fmt.Sprintf("%s is set locally but not in the cloud", k)})
+		} else if cloud != v {
+			drift = append(drift, Drift{DriftEnv, k, fmt.Sprintf("%s has a different value locally than in the cloud", k)})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Kind != drift[j].Kind {
+			return drift[i].Kind < drift[j].Kind
+		}
+		return drift[i].Key < drift[j].Key
+	})
+	return drift
+}
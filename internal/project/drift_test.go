@@ -0,0 +1,56 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashEnvValues(t *testing.T) {
+	hashed := HashEnvValues(map[string]string{"REGION": "us", "AGENTUITY_SDK_KEY": "sdk", "OTHER": "us"})
+	assert.Len(t, hashed, 2)
+	assert.Equal(t, hashed["REGION"], hashed["OTHER"])
+	assert.NotEqual(t, "us", hashed["REGION"])
+}
+
+func TestDetectDrift(t *testing.T) {
+	first := &DriftState{
+		LocalAgents: map[string]string{"agent_1": "hello", "agent_2": "support"},
+		CloudAgents: map[string]string{"agent_1": "hello", "agent_3": "billing"},
+		LocalEnv:    HashEnvValues(map[string]string{"REGION": "us", "LOG_LEVEL": "debug"}),
+		CloudEnv:    HashEnvValues(map[string]string{"REGION": "us", "OPENAI_API_KEY": "sk"}),
+		Deployment:  &DeploymentListData{ID: "deploy_1"},
+	}
+	assert.Equal(t, []Drift{
+		{DriftAgent, "agent_2", "The agent support isn't in the cloud"},
+		{DriftAgent, "agent_3", "The agent billing is in the cloud but not in the project"},
+		{DriftEnv, "LOG_LEVEL", "LOG_LEVEL is set locally but not in the cloud"},
+	}, DetectDrift(nil, first))
+
+	// nothing changed
+	assert.Empty(t, DetectDrift(first, first))
+
+	second := &DriftState{
+		LocalAgents: first.LocalAgents,
+		CloudAgents: map[string]string{"agent_1": "hello-world", "agent_3": "billing"},
+		LocalEnv:    first.LocalEnv,
+		CloudEnv:    HashEnvValues(map[string]string{"REGION": "eu", "OPENAI_API_KEY": "sk", "FEATURE": "on"}),
+		Deployment:  &DeploymentListData{ID: "deploy_2", Message: "fix the billing agent"},
+	}
+	assert.Equal(t, []Drift{
+		{DriftEnv, "FEATURE", "FEATURE was set in the cloud"},
+		{DriftEnv, "REGION", "REGION was changed in the cloud"},
+		{DriftDeployment, "deploy_2", "The deployment deploy_2 is now active (fix the billing agent)"},
+		{DriftAgent, "agent_1", "The agent hello is named hello-world in the cloud"},
+	}, DetectDrift(first, second))
+
+	// the drift which was already reported isn't reported again
+	third := &DriftState{
+		LocalAgents: map[string]string{"agent_1": "hello-world", "agent_2": "support"},
+		CloudAgents: second.CloudAgents,
+		LocalEnv:    second.LocalEnv,
+		CloudEnv:    second.CloudEnv,
+		Deployment:  second.Deployment,
+	}
+	assert.Empty(t, DetectDrift(second, third))
+}
@@ -0,0 +1,112 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The changes of an agent between deployments
+const (
+	AgentAdded   = "added"
+	AgentChanged = "changed"
+	AgentRemoved = "removed"
+)
+
+// AgentHistoryEntry is a deployment in which the source of an agent changed
+type AgentHistoryEntry struct {
+	DeploymentID string   `json:"deploymentId"`
+	CreatedAt    string   `json:"createdAt"`
+	Message      string   `json:"message,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Active       bool     `json:"active"`
+	Change       string   `json:"change"`
+	// Digest is empty when the agent was removed
+	Digest string `json:"digest,omitempty"`
+	// Files are the files of the agent which were added, removed or changed
+	Files SetDiff `json:"files"`
+}
+
+// AgentFiles returns the digests of the files of the bundle in the agent directory, which is
+// relative to the project directory
+func AgentFiles(files map[string]string, dir string) map[string]string {
+	prefix := strings.TrimSuffix(path.Clean(strings.ReplaceAll(dir, "\\", "/")), "/") + "/"
+	result := make(map[string]string)
+	for fn, digest := range files {
+		if strings.HasPrefix(fn, prefix) {
+			result[fn] = digest
+		}
+	}
+	return result
+}
+
+// AgentDigest returns the digest of the files of the bundle in the agent directory or an empty
+// string when the bundle has no files in the directory
+func AgentDigest(files map[string]string, dir string) string {
+	agentFiles := AgentFiles(files, dir)
+	if len(agentFiles) == 0 {
+		return ""
+	}
+	return FilesDigest(agentFiles)
+}
+
+// FilesDigest returns a single digest of the digests of the files by their name
+func FilesDigest(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for fn := range files {
+		names = append(names, fn)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, fn := range names {
+		fmt.Fprintf(h, "%s %s\n", fn, files[fn])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// AgentHistory returns the deployments in which the files of the agent directory changed, the
+// newest first. The deployments without the digests of their files are skipped.
+func AgentHistory(deployments []DeploymentDetail, dir string) []AgentHistoryEntry {
+	sorted := make([]DeploymentDetail, 0, len(deployments))
+	for _, d := range deployments {
+		if d.Files != nil {
+			sorted = append(sorted, d)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt < sorted[j].CreatedAt
+	})
+	var history []AgentHistoryEntry
+	var previous string
+	previousFiles := map[string]string{}
+	for _, d := range sorted {
+		digest := AgentDigest(d.Files, dir)
+		if digest == previous {
+			continue
+		}
+		entry := AgentHistoryEntry{
+			DeploymentID: d.ID,
+			CreatedAt:    d.CreatedAt,
+			Message:      d.Message,
+			Tags:         d.Tags,
+			Active:       d.Active,
+			Digest:       digest,
+			Change:       AgentChanged,
+		}
+		switch {
+		case previous == "":
+			entry.Change = AgentAdded
+		case digest == "":
+			entry.Change = AgentRemoved
+		}
+		files := AgentFiles(d.Files, dir)
+		entry.Files = diffValues(previousFiles, files)
+		history = append([]AgentHistoryEntry{entry}, history...)
+		previous = digest
+		previousFiles = files
+	}
+	return history
+}
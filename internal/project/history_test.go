@@ -0,0 +1,57 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentDigest(t *testing.T) {
+	files := map[string]string{
+		"src/agents/my-agent/index.ts":        "sha256:aaa",
+		"src/agents/my-agent/prompt.ts":       "sha256:bbb",
+		"src/agents/my-agent-v2/index.ts":     "sha256:ccc",
+		"src/agents/other/index.ts":           "sha256:ddd",
+		"package.json":                        "sha256:eee",
+		"src/agents/my-agent/lib/helpers.ts":  "sha256:fff",
+		"src/agents/my-agent-extra/README.md": "sha256:ggg",
+	}
+	assert.Len(t, AgentFiles(files, "src/agents/my-agent"), 3)
+	assert.Len(t, AgentFiles(files, "src/agents/my-agent/"), 3)
+	digest := AgentDigest(files, "src/agents/my-agent")
+	assert.Contains(t, digest, "sha256:")
+	assert.Empty(t, AgentDigest(files, "src/agents/missing"))
+
+	// files of other agents don't change the digest
+	files["src/agents/other/index.ts"] = "sha256:changed"
+	assert.Equal(t, digest, AgentDigest(files, "src/agents/my-agent"))
+	files["src/agents/my-agent/index.ts"] = "sha256:changed"
+	assert.NotEqual(t, digest, AgentDigest(files, "src/agents/my-agent"))
+}
+
+func TestAgentHistory(t *testing.T) {
+	deployment := func(id, createdAt string, files map[string]string) DeploymentDetail {
+		return DeploymentDetail{DeploymentListData: DeploymentListData{ID: id, CreatedAt: createdAt}, Files: files}
+	}
+	deployments := []DeploymentDetail{
+		deployment("deploy_4", "2026-01-04T00:00:00Z", map[string]string{"src/agents/a/index.ts": "2", "src/agents/b/index.ts": "2"}),
+		deployment("deploy_1", "2026-01-01T00:00:00Z", map[string]string{"src/agents/b/index.ts": "1"}),
+		deployment("deploy_2", "2026-01-02T00:00:00Z", map[string]string{"src/agents/a/index.ts": "1", "src/agents/b/index.ts": "1"}),
+		deployment("deploy_3", "2026-01-03T00:00:00Z", map[string]string{"src/agents/a/index.ts": "1", "src/agents/b/index.ts": "2"}),
+		deployment("deploy_5", "2026-01-05T00:00:00Z", map[string]string{"src/agents/b/index.ts": "2"}),
+		// the digests of the files aren't known
+		deployment("deploy_6", "2026-01-06T00:00:00Z", nil),
+	}
+	history := AgentHistory(deployments, "src/agents/a")
+	assert.Len(t, history, 3)
+	assert.Equal(t, "deploy_5", history[0].DeploymentID)
+	assert.Equal(t, AgentRemoved, history[0].Change)
+	assert.Empty(t, history[0].Digest)
+	assert.Equal(t, []string{"src/agents/a/index.ts"}, history[0].Files.Removed)
+	assert.Equal(t, "deploy_4", history[1].DeploymentID)
+	assert.Equal(t, AgentChanged, history[1].Change)
+	assert.Equal(t, []string{"src/agents/a/index.ts"}, history[1].Files.Changed)
+	assert.Equal(t, "deploy_2", history[2].DeploymentID)
+	assert.Equal(t, AgentAdded, history[2].Change)
+	assert.Equal(t, []string{"src/agents/a/index.ts"}, history[2].Files.Added)
+}
@@ -2,6 +2,7 @@ package project
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,7 +10,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/agentuity/cli/internal/errsystem"
@@ -148,11 +152,12 @@ func ProjectWithNameExists(ctx context.Context, logger logger.Logger, baseUrl st
 }
 
 type ProjectListData struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	OrgId       string `json:"orgId"`
-	OrgName     string `json:"orgName"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	OrgId       string   `json:"orgId"`
+	OrgName     string   `json:"orgName"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
 func ListProjects(ctx context.Context, logger logger.Logger, baseUrl string, token string) ([]ProjectListData, error) {
@@ -166,11 +171,12 @@ func ListProjects(ctx context.Context, logger logger.Logger, baseUrl string, tok
 }
 
 type DeploymentListData struct {
-	ID        string   `json:"id"`
-	Message   string   `json:"message"`
-	Tags      []string `json:"tags"`
-	Active    bool     `json:"active"`
-	CreatedAt string   `json:"createdAt"`
+	ID        string            `json:"id"`
+	Message   string            `json:"message"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Active    bool              `json:"active"`
+	CreatedAt string            `json:"createdAt"`
 }
 
 func ListDeployments(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) ([]DeploymentListData, error) {
@@ -209,6 +215,289 @@ func RollbackDeployment(ctx context.Context, logger logger.Logger, baseUrl strin
 	return nil
 }
 
+// DeploymentGit is the git commit a deployment was made from
+type DeploymentGit struct {
+	RemoteURL     string `json:"remoteUrl,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	Commit        string `json:"commit,omitempty"`
+	CommitMessage string `json:"commitMessage,omitempty"`
+	CommitAuthor  string `json:"commitAuthor,omitempty"`
+	// Detached, Worktree and Shallow describe the checkout the deployment was made from
+	Detached bool `json:"detached,omitempty"`
+	Worktree bool `json:"worktree,omitempty"`
+	Shallow  bool `json:"shallow,omitempty"`
+}
+
+// Checkout returns the notable properties of the checkout the deployment was made from, such as a
+// detached HEAD or a shallow clone
+func (g DeploymentGit) Checkout() []string {
+	var checkout []string
+	if g.Detached {
+		checkout = append(checkout, "detached")
+	}
+	if g.Worktree {
+		checkout = append(checkout, "worktree")
+	}
+	if g.Shallow {
+		checkout = append(checkout, "shallow")
+	}
+	return checkout
+}
+
+// DeploymentAgent is an agent which is part of a deployment
+type DeploymentAgent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DeploymentResources are the resources a deployment runs with in megabytes and millicores
+type DeploymentResources struct {
+	Memory int64 `json:"memory,omitempty"`
+	CPU    int64 `json:"cpu,omitempty"`
+	Disk   int64 `json:"disk,omitempty"`
+}
+
+// The states of a deployment once it is uploaded
+const (
+	DeploymentStateBuilding = "building"
+	DeploymentStateStarting = "starting"
+	DeploymentStateActive   = "active"
+	DeploymentStateFailed   = "failed"
+)
+
+// The phases of a deployment in which it can fail once it is uploaded
+const (
+	FailureBuild = "build"
+	FailureBoot  = "boot"
+)
+
+// DeploymentFailure is why a deployment failed in the cloud after it was uploaded
+type DeploymentFailure struct {
+	// Phase is build when the deployment couldn't be built and boot when it didn't start
+	Phase  string   `json:"phase,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	Logs   []string `json:"logs,omitempty"`
+}
+
+// DeploymentDetail is the metadata of a single deployment
+type DeploymentDetail struct {
+	DeploymentListData
+	// State is empty when the API doesn't report the state of the deployment
+	State      string               `json:"state,omitempty"`
+	Failure    *DeploymentFailure   `json:"failure,omitempty"`
+	Git        *DeploymentGit       `json:"git,omitempty"`
+	Agents     []DeploymentAgent    `json:"agents"`
+	EnvKeys    []string             `json:"envKeys"`
+	SecretKeys []string             `json:"secretKeys"`
+	Resources  *DeploymentResources `json:"resources,omitempty"`
+	// Scale is the effective autoscaling of the deployment with the cloud defaults filled in
+	Scale  *ScaleConfig      `json:"scale,omitempty"`
+	Digest string            `json:"digest,omitempty"`
+	Files  map[string]string `json:"files,omitempty"` // the sha256 digest of each file in the bundle
+}
+
+func GetDeployment(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string) (*DeploymentDetail, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[DeploymentDetail]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/deployments/%s", projectId, deploymentId), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching deployment: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// GetDeploymentFile returns the content of a file of the bundle of the deployment. The filename is
+// relative to the project directory with forward slashes.
+func GetDeploymentFile(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string, filename string) ([]byte, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[struct {
+		Content string `json:"content"` // base64 encoded
+	}]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/deployments/%s/files?path=%s", projectId, deploymentId, url.QueryEscape(filename)), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching deployment file: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	buf, err := base64.StdEncoding.DecodeString(resp.Data.Content)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding deployment file: %w", err)
+	}
+	return buf, nil
+}
+
+// DeploymentTag is a tag which points to a deployment of the project
+type DeploymentTag struct {
+	Name         string `json:"name"`
+	DeploymentID string `json:"deploymentId"`
+	Description  string `json:"description,omitempty"`
+	Message      string `json:"message,omitempty"`
+	Active       bool   `json:"active"`
+	UpdatedAt    string `json:"updatedAt,omitempty"`
+}
+
+// DeploymentTagUpdate is the change to make to a deployment tag. Setting the deployment creates the
+// tag or moves it to the deployment. Fields which are nil are left unchanged.
+type DeploymentTagUpdate struct {
+	DeploymentID *string `json:"deploymentId,omitempty"`
+	Name         *string `json:"name,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Message      *string `json:"message,omitempty"`
+}
+
+func ListDeploymentTags(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string) ([]DeploymentTag, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[[]DeploymentTag]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/tags", projectId), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error listing deployment tags: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+func UpdateDeploymentTag(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, tag string, update DeploymentTagUpdate) (*DeploymentTag, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[DeploymentTag]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/project/%s/tags/%s", projectId, url.PathEscape(tag)), update, &resp); err != nil {
+		return nil, fmt.Errorf("error updating deployment tag: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+func DeleteDeploymentTag(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, tag string) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[string]
+	if err := client.Do("DELETE", fmt.Sprintf("/cli/project/%s/tags/%s", projectId, url.PathEscape(tag)), nil, &resp); err != nil {
+		return fmt.Errorf("error removing deployment tag: %w", err)
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+var deploymentTagRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateDeploymentTag returns an error if the name can't be used as a deployment tag
+func ValidateDeploymentTag(name string) error {
+	if len(name) > 64 || !deploymentTagRegex.MatchString(name) {
+		return fmt.Errorf("invalid tag %q (must be at most 64 letters, digits, '.', '_' or '-')", name)
+	}
+	return nil
+}
+
+var deploymentLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_./-]*$`)
+
+// ParseDeploymentLabels parses the key=value labels of a deployment. A key given more than once
+// keeps its last value.
+func ParseDeploymentLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q (must be key=value)", value)
+		}
+		if len(key) > 64 || !deploymentLabelRegex.MatchString(key) {
+			return nil, fmt.Errorf("invalid label key %q (must be at most 64 letters, digits, '.', '_', '/' or '-')", key)
+		}
+		if len(val) > 256 {
+			return nil, fmt.Errorf("the value of the label %s is longer than 256 characters", key)
+		}
+		labels[key] = val
+	}
+	return labels, nil
+}
+
+// FormatDeploymentLabels returns the labels as key=value pairs sorted by key
+func FormatDeploymentLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + labels[k]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+type DeploymentApproval struct {
+	State       string `json:"state"`
+	ApprovalURL string `json:"approvalUrl,omitempty"`
+	RequestedBy string `json:"requestedBy,omitempty"`
+	ApprovedBy  string `json:"approvedBy,omitempty"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+}
+
+const (
+	DeploymentApprovalPending  = "pending"
+	DeploymentApprovalApproved = "approved"
+	DeploymentApprovalRejected = "rejected"
+)
+
+func GetDeploymentApproval(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string) (*DeploymentApproval, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[DeploymentApproval]
+	if err := client.Do("GET", fmt.Sprintf("/cli/project/%s/deployments/%s/approval", projectId, deploymentId), nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching deployment approval: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
+// WaitForDeploymentApproval polls the approval of the deployment every interval until it's no
+// longer pending. It returns the error of the context when it's done before, such as on a timeout.
+func WaitForDeploymentApproval(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string, interval time.Duration) (*DeploymentApproval, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		res, err := GetDeploymentApproval(ctx, logger, baseUrl, token, projectId, deploymentId)
+		if err != nil {
+			logger.Debug("error checking deployment approval: %s", err)
+		} else if res.State != DeploymentApprovalPending {
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApproveDeployment will approve (or reject) a pending deployment. The approval must be made by a
+// different user than the one who requested the deployment.
+func ApproveDeployment(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, deploymentId string, approve bool, comment string) (*DeploymentApproval, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	payload := map[string]any{"approve": approve, "comment": comment}
+	var resp Response[DeploymentApproval]
+	if err := client.Do("POST", fmt.Sprintf("/cli/project/%s/deployments/%s/approval", projectId, deploymentId), payload, &resp); err != nil {
+		return nil, fmt.Errorf("error approving deployment: %w", err)
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &resp.Data, nil
+}
+
 func DeleteProjects(ctx context.Context, logger logger.Logger, baseUrl string, token string, ids []string) ([]string, error) {
 	client := util.NewAPIClient(ctx, logger, baseUrl, token)
 
@@ -247,6 +536,45 @@ func GetProject(ctx context.Context, logger logger.Logger, baseUrl string, token
 	return &projectResponse.Data, nil
 }
 
+// ProjectUpdate is the metadata of the project to update in the cloud. Fields which are nil are
+// left unchanged.
+type ProjectUpdate struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+}
+
+func UpdateProject(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, update ProjectUpdate) error {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+
+	var resp Response[any]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/project/%s", projectId), update, &resp); err != nil {
+		return fmt.Errorf("error updating project: %w", err)
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+var projectTagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]*$`)
+
+// NormalizeTags lowercases, validates and removes the duplicates from the project tags
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if len(tag) > 32 || !projectTagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q (must be at most 32 lowercase letters, digits, '.', '_', ':' or '-')", tag)
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
 func SetProjectEnv(ctx context.Context, logger logger.Logger, baseUrl string, token string, projectId string, env map[string]string, secrets map[string]string) (*ProjectData, error) {
 	client := util.NewAPIClient(ctx, logger, baseUrl, token)
 	var projectResponse ProjectResponse
@@ -426,20 +754,23 @@ func isVersionCheckRequired(ver string) bool {
 }
 
 func EnsureProject(ctx context.Context, cmd *cobra.Command) ProjectContext {
-	logger := env.NewLogger(cmd)
+	logger := util.NewLogger(cmd)
 	dir := ResolveProjectDir(logger, cmd, true)
 	urls := util.GetURLs(logger)
 	apiUrl := urls.API
 	appUrl := urls.App
 	transportUrl := urls.Transport
-	var token string
+	p := LoadProject(logger, dir, apiUrl, appUrl, transportUrl, "")
 	// if the --api-key flag is used, we only need to verify the api key
 	if cmd.Flags().Changed("api-key") {
-		token = util.EnsureLoggedInWithOnlyAPIKey(ctx, logger, cmd)
+		p.Token = util.EnsureLoggedInWithOnlyAPIKey(ctx, logger, cmd)
 	} else {
-		token, _ = util.EnsureLoggedIn(ctx, logger, cmd)
+		var projectId string
+		if p.Project != nil {
+			projectId = p.Project.ProjectId
+		}
+		p.Token, _ = util.EnsureLoggedInForProject(ctx, logger, cmd, projectId, dir)
 	}
-	p := LoadProject(logger, dir, apiUrl, appUrl, transportUrl, token)
 	if !p.NewProject && isVersionCheckRequired(Version) && p.Project.Version != "" {
 		v := semver.MustParse(Version)
 		c, err := semver.NewConstraint(p.Project.Version)
@@ -455,7 +786,7 @@ func EnsureProject(ctx context.Context, cmd *cobra.Command) ProjectContext {
 }
 
 func TryProject(ctx context.Context, cmd *cobra.Command) ProjectContext {
-	logger := env.NewLogger(cmd)
+	logger := util.NewLogger(cmd)
 	dir := ResolveProjectDir(logger, cmd, false)
 	urls := util.GetURLs(logger)
 	apiUrl := urls.API
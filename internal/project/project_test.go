@@ -0,0 +1,106 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Support ", "prod", "support", "", "team:core"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"support", "prod", "team:core"}, tags)
+
+	tags, err = NormalizeTags(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, tags)
+
+	_, err = NormalizeTags([]string{"has space"})
+	assert.Error(t, err)
+	_, err = NormalizeTags([]string{"-leading"})
+	assert.Error(t, err)
+}
+
+func TestValidateDeploymentTag(t *testing.T) {
+	assert.NoError(t, ValidateDeploymentTag("staging"))
+	assert.NoError(t, ValidateDeploymentTag("v1.2.3_rc-1"))
+	assert.Error(t, ValidateDeploymentTag(""))
+	assert.Error(t, ValidateDeploymentTag("-staging"))
+	assert.Error(t, ValidateDeploymentTag("has space"))
+	assert.Error(t, ValidateDeploymentTag(strings.Repeat("a", 65)))
+}
+
+func TestParseDeploymentLabels(t *testing.T) {
+	labels, err := ParseDeploymentLabels([]string{"team=payments", "ticket=JIRA-123", "note=a=b", "empty=", "team=billing"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "billing", "ticket": "JIRA-123", "note": "a=b", "empty": ""}, labels)
+	assert.Equal(t, "empty=, note=a=b, team=billing, ticket=JIRA-123", FormatDeploymentLabels(labels))
+
+	_, err = ParseDeploymentLabels([]string{"team"})
+	assert.Error(t, err)
+	_, err = ParseDeploymentLabels([]string{"=payments"})
+	assert.Error(t, err)
+	_, err = ParseDeploymentLabels([]string{"has space=x"})
+	assert.Error(t, err)
+	_, err = ParseDeploymentLabels([]string{"team=" + strings.Repeat("a", 257)})
+	assert.Error(t, err)
+	assert.Equal(t, "", FormatDeploymentLabels(nil))
+}
+
+func TestWaitForDeploymentApproval(t *testing.T) {
+	approvalServer := func(states ...string) *httptest.Server {
+		var calls int
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/cli/project/proj_1/deployments/deploy_1/approval", r.URL.Path)
+			state := states[min(calls, len(states)-1)]
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"success":true,"data":{"state":%q,"approvedBy":"Jane Doe"}}`, state)
+		}))
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		server := approvalServer(DeploymentApprovalPending, DeploymentApprovalPending, DeploymentApprovalApproved)
+		defer server.Close()
+		approval, err := WaitForDeploymentApproval(context.Background(), logger.NewTestLogger(), server.URL, "token", "proj_1", "deploy_1", time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, DeploymentApprovalApproved, approval.State)
+		assert.Equal(t, "Jane Doe", approval.ApprovedBy)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server := approvalServer(DeploymentApprovalRejected)
+		defer server.Close()
+		approval, err := WaitForDeploymentApproval(context.Background(), logger.NewTestLogger(), server.URL, "token", "proj_1", "deploy_1", time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, DeploymentApprovalRejected, approval.State)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := approvalServer(DeploymentApprovalPending)
+		defer server.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		approval, err := WaitForDeploymentApproval(ctx, logger.NewTestLogger(), server.URL, "token", "proj_1", "deploy_1", time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, approval)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		server := approvalServer(DeploymentApprovalPending)
+		defer server.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		approval, err := WaitForDeploymentApproval(ctx, logger.NewTestLogger(), server.URL, "token", "proj_1", "deploy_1", time.Millisecond)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, approval)
+	})
+}
@@ -0,0 +1,175 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentuity/cli/internal/openapi"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Schema is the JSON Schema of the project file which is embedded in the CLI (set by main)
+var Schema []byte
+
+// SchemaURL is the published JSON Schema of the project file referenced by the modeline of the project file
+const SchemaURL = "https://raw.githubusercontent.com/agentuity/cli/refs/heads/main/agentuity.schema.json"
+
+// SchemaModeline is the comment which tells the YAML language server in editors which schema to use
+const SchemaModeline = "# yaml-language-server: $schema=" + SchemaURL
+
+// ValidationError is a problem with the project file and where it is in the file
+type ValidationError struct {
+	// Path is the location of the problem such as $.agents[0].id
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// ValidateProjectFile validates the project file against the schema and checks the values the schema
+// can't express (such as the resource quantities and duplicate agents). The problems are returned
+// in the order of their line and column in the file.
+func ValidateProjectFile(filename string, schema []byte) ([]ValidationError, error) {
+	validator, err := openapi.NewValidator("agentuity.schema.json", schema)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		line := 1
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		return []ValidationError{{Path: "$", Line: line, Column: 1, Message: fmt.Sprintf("invalid YAML in %s: %s", filepath.Base(filename), strings.TrimPrefix(err.Error(), "yaml: "))}}, nil
+	}
+	if len(doc.Content) == 0 {
+		return []ValidationError{{Path: "$", Line: 1, Column: 1, Message: fmt.Sprintf("%s is empty", filepath.Base(filename))}}, nil
+	}
+	root := doc.Content[0]
+	var value any
+	if err := root.Decode(&value); err != nil {
+		return nil, err
+	}
+	// round trip through JSON so the values have the types of the JSON Schema data model
+	jbuf, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s can't be represented as JSON: %w", filepath.Base(filename), err)
+	}
+	if err := json.Unmarshal(jbuf, &value); err != nil {
+		return nil, err
+	}
+	var errs []ValidationError
+	seen := make(map[string]bool)
+	add := func(path string, message string) {
+		node := locateNode(root, path)
+		seen[path] = true
+		errs = append(errs, ValidationError{Path: path, Line: node.Line, Column: node.Column, Message: message})
+	}
+	for _, message := range validator.ValidateValue(value) {
+		path, _, _ := strings.Cut(message, " ")
+		add(path, message)
+	}
+	if resources := mappingValue(mappingValue(root, "deployment"), "resources"); resources != nil {
+		for _, key := range []string{"memory", "cpu", "disk"} {
+			if val := mappingValue(resources, key); val != nil && val.Kind == yaml.ScalarNode && !seen["$.deployment.resources."+key] {
+				if _, err := resource.ParseQuantity(val.Value); err != nil {
+					add("$.deployment.resources."+key, fmt.Sprintf("$.deployment.resources.%s %q is not a valid quantity such as 1Gi or 500m", key, val.Value))
+				}
+			}
+		}
+	}
+	if scale := mappingValue(mappingValue(root, "deployment"), "scale"); scale != nil && !seen["$.deployment.scale.min"] && !seen["$.deployment.scale.max"] {
+		minNode, maxNode := mappingValue(scale, "min"), mappingValue(scale, "max")
+		if minNode != nil && maxNode != nil {
+			minValue, minErr := strconv.Atoi(minNode.Value)
+			maxValue, maxErr := strconv.Atoi(maxNode.Value)
+			if minErr == nil && maxErr == nil && minValue > maxValue {
+				add("$.deployment.scale.min", fmt.Sprintf("$.deployment.scale.min %d is more than $.deployment.scale.max %d", minValue, maxValue))
+			}
+		}
+	}
+	if agents := mappingValue(root, "agents"); agents != nil && agents.Kind == yaml.SequenceNode {
+		used := map[string]map[string]int{"id": {}, "name": {}}
+		for i, agent := range agents.Content {
+			for _, key := range []string{"id", "name"} {
+				val := mappingValue(agent, key)
+				if val == nil || val.Kind != yaml.ScalarNode || val.Value == "" {
+					continue
+				}
+				name := strings.ToLower(val.Value)
+				if j, ok := used[key][name]; ok {
+					add(fmt.Sprintf("$.agents[%d].%s", i, key), fmt.Sprintf("$.agents[%d].%s %q is already used by $.agents[%d]", i, key, val.Value, j))
+					continue
+				}
+				used[key][name] = i
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs, nil
+}
+
+var pathSegment = regexp.MustCompile(`\.([^.\[]+)|\[(\d+)\]`)
+
+// locateNode returns the node at the path (such as $.agents[0].id) or the closest parent in the
+// path which exists, such as the object missing a required property. Properties are located at
+// their key.
+func locateNode(root *yaml.Node, path string) *yaml.Node {
+	node, location := root, root
+	for _, m := range pathSegment.FindAllStringSubmatch(strings.TrimPrefix(path, "$"), -1) {
+		var next, key *yaml.Node
+		if m[1] != "" && node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == m[1] {
+					key, next = node.Content[i], node.Content[i+1]
+					break
+				}
+			}
+		} else if m[2] != "" && node.Kind == yaml.SequenceNode {
+			if i, _ := strconv.Atoi(m[2]); i < len(node.Content) {
+				key, next = node.Content[i], node.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, location = next, key
+	}
+	return location
+}
+
+// HasSchemaModeline returns true if the project file tells editors which schema to use
+func HasSchemaModeline(filename string) (bool, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "# yaml-language-server:") && strings.Contains(line, "$schema=") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
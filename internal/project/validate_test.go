@@ -0,0 +1,84 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProjectFile(t *testing.T) {
+	schema, err := os.ReadFile("../../agentuity.schema.json")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	p := NewProject()
+	p.ProjectId = "proj_" + strings.Repeat("a", 32)
+	p.Name = "test"
+	p.Bundler = &project.Bundler{Enabled: true, Identifier: "bunjs", Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	p.Agents = []project.AgentConfig{{ID: "agent_" + strings.Repeat("1", 32), Name: "first"}, {ID: "agent_" + strings.Repeat("2", 32), Name: "second"}}
+	p.Development = &project.Development{Port: 3500, Command: "bun"}
+	require.NoError(t, SaveProject(dir, p))
+	filename := project.GetProjectFilename(dir)
+
+	ok, err := HasSchemaModeline(filename)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	errs, err := ValidateProjectFile(filename, schema)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	content := strings.Replace(string(buf), "language: javascript", "language: cobol", 1)
+	content = strings.Replace(content, "name: second", "name: First", 1)
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	lines := strings.Split(content, "\n")
+	lineOf := func(text string) int {
+		for i, line := range lines {
+			if strings.Contains(line, text) {
+				return i + 1
+			}
+		}
+		t.Fatalf("missing %q", text)
+		return 0
+	}
+
+	errs, err = ValidateProjectFile(filename, schema)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "$.bundler.language", errs[0].Path)
+	assert.Equal(t, lineOf("language: cobol"), errs[0].Line)
+	assert.Equal(t, "$.agents[1].name", errs[1].Path)
+	assert.Equal(t, lineOf("name: First"), errs[1].Line)
+	assert.Contains(t, errs[1].Message, "already used by $.agents[0]")
+
+	// the schema checks the range of each scale setting but not that the minimum is within the maximum
+	content = strings.Replace(string(buf), "deployment:\n", "deployment:\n  scale:\n    min: 5\n    max: 2\n    concurrency: 0\n", 1)
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	errs, err = ValidateProjectFile(filename, schema)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "$.deployment.scale.min", errs[0].Path)
+	assert.Contains(t, errs[0].Message, "is more than $.deployment.scale.max 2")
+	assert.Equal(t, "$.deployment.scale.concurrency", errs[1].Path)
+
+	require.NoError(t, os.WriteFile(filename, []byte("version: '>=0.0.0'\nagents: [\n"), 0644))
+	errs, err = ValidateProjectFile(filename, schema)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "invalid YAML")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("name: test\n"), 0644))
+	errs, err = ValidateProjectFile(filepath.Join(dir, "other.yaml"), schema)
+	require.NoError(t, err)
+	assert.NotEmpty(t, errs)
+	for _, e := range errs {
+		assert.Equal(t, 1, e.Line, e.Message)
+	}
+}
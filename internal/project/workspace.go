@@ -0,0 +1,139 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentuity/go-common/project"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFile is the name of the file which lists the projects of a workspace, such as a monorepo
+const WorkspaceFile = "agentuity.workspace.yaml"
+
+// WorkspaceSettings are the settings of the projects of a workspace which are used by the commands
+// when the corresponding flag isn't provided
+type WorkspaceSettings struct {
+	OrgId      string   `yaml:"org_id,omitempty" json:"org_id,omitempty"`
+	Tags       []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	EnvProfile string   `yaml:"env_profile,omitempty" json:"env_profile,omitempty"`
+}
+
+// WorkspaceProject is a project of a workspace
+type WorkspaceProject struct {
+	// Path is the directory of the project relative to the workspace file
+	Path string `yaml:"path" json:"path"`
+	// Name defaults to the name in the project file
+	Name              string `yaml:"name,omitempty" json:"name,omitempty"`
+	WorkspaceSettings `yaml:",inline"`
+}
+
+// Workspace is the agentuity.workspace.yaml file
+type Workspace struct {
+	// Dir is the directory of the workspace file
+	Dir      string             `yaml:"-" json:"dir"`
+	Defaults WorkspaceSettings  `yaml:"defaults,omitempty" json:"defaults"`
+	Projects []WorkspaceProject `yaml:"projects" json:"projects"`
+}
+
+// WorkspaceMember is a project of a workspace with the defaults of the workspace applied
+type WorkspaceMember struct {
+	Name      string `json:"name"`
+	Dir       string `json:"dir"`
+	Path      string `json:"path"`
+	ProjectId string `json:"project_id,omitempty"`
+	WorkspaceSettings
+}
+
+// LoadWorkspace loads the workspace file in dir
+func LoadWorkspace(dir string) (*Workspace, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, WorkspaceFile))
+	if err != nil {
+		return nil, err
+	}
+	var ws Workspace
+	if err := yaml.Unmarshal(buf, &ws); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", WorkspaceFile, err)
+	}
+	ws.Dir = dir
+	if len(ws.Projects) == 0 {
+		return nil, fmt.Errorf("%s doesn't list any projects", WorkspaceFile)
+	}
+	for _, p := range ws.Projects {
+		if p.Path == "" {
+			return nil, fmt.Errorf("a project in %s has no path", WorkspaceFile)
+		}
+	}
+	return &ws, nil
+}
+
+// FindWorkspace loads the workspace file in dir or the closest of its parent directories
+func FindWorkspace(dir string) (*Workspace, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(abs, WorkspaceFile)); err == nil {
+			return LoadWorkspace(abs)
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil, fmt.Errorf("no %s found in %s or its parent directories", WorkspaceFile, dir)
+		}
+		abs = parent
+	}
+}
+
+// Members returns the projects of the workspace in the order of the workspace file. When names are
+// provided, only the projects with these names (or paths) are returned.
+func (w *Workspace) Members(names []string) ([]WorkspaceMember, error) {
+	var members []WorkspaceMember
+	found := make(map[string]bool)
+	for _, p := range w.Projects {
+		dir := filepath.Join(w.Dir, filepath.FromSlash(p.Path))
+		if !project.ProjectExists(dir) {
+			return nil, fmt.Errorf("no Agentuity project found in %s, listed in %s", p.Path, WorkspaceFile)
+		}
+		member := WorkspaceMember{Name: p.Name, Dir: dir, Path: p.Path, WorkspaceSettings: w.Defaults}
+		var theproject project.Project
+		if err := theproject.Load(dir); err != nil {
+			return nil, fmt.Errorf("failed to load the project in %s: %w", p.Path, err)
+		}
+		member.ProjectId = theproject.ProjectId
+		if member.Name == "" {
+			member.Name = theproject.Name
+		}
+		if member.Name == "" {
+			member.Name = filepath.Base(dir)
+		}
+		if p.OrgId != "" {
+			member.OrgId = p.OrgId
+		}
+		if len(p.Tags) > 0 {
+			member.Tags = p.Tags
+		}
+		if p.EnvProfile != "" {
+			member.EnvProfile = p.EnvProfile
+		}
+		if len(names) > 0 {
+			idx := slices.IndexFunc(names, func(name string) bool {
+				return strings.EqualFold(name, member.Name) || filepath.Clean(name) == filepath.Clean(p.Path)
+			})
+			if idx < 0 {
+				continue
+			}
+			found[names[idx]] = true
+		}
+		members = append(members, member)
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("project %s not found in %s", name, WorkspaceFile)
+		}
+	}
+	return members, nil
+}
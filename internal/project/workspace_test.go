@@ -0,0 +1,81 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/go-common/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeWorkspaceProject(t *testing.T, dir string, projectId string, name string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	p := NewProject()
+	p.ProjectId = projectId
+	p.Name = name
+	p.Bundler = &project.Bundler{Language: "javascript", Runtime: "bunjs", AgentConfig: project.AgentBundlerConfig{Dir: "src/agents"}}
+	require.NoError(t, SaveProject(dir, p))
+}
+
+func TestWorkspaceMembers(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkspaceProject(t, filepath.Join(dir, "services", "support"), "proj_1", "support-bot")
+	writeWorkspaceProject(t, filepath.Join(dir, "services", "billing"), "proj_2", "billing")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, WorkspaceFile), []byte(`defaults:
+  org_id: org_1
+  tags: [staging]
+  env_profile: staging
+projects:
+  - path: services/support
+    name: support
+    tags: [latest, support]
+  - path: services/billing
+    env_profile: production
+`), 0644))
+
+	ws, err := FindWorkspace(filepath.Join(dir, "services", "billing"))
+	require.NoError(t, err)
+	assert.Equal(t, dir, ws.Dir)
+
+	members, err := ws.Members(nil)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, WorkspaceMember{
+		Name:              "support",
+		Dir:               filepath.Join(dir, "services", "support"),
+		Path:              "services/support",
+		ProjectId:         "proj_1",
+		WorkspaceSettings: WorkspaceSettings{OrgId: "org_1", Tags: []string{"latest", "support"}, EnvProfile: "staging"},
+	}, members[0])
+	assert.Equal(t, "billing", members[1].Name)
+	assert.Equal(t, []string{"staging"}, members[1].Tags)
+	assert.Equal(t, "production", members[1].EnvProfile)
+
+	members, err = ws.Members([]string{"services/billing/"})
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	assert.Equal(t, "proj_2", members[0].ProjectId)
+	members, err = ws.Members([]string{"SUPPORT"})
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	_, err = ws.Members([]string{"unknown"})
+	assert.ErrorContains(t, err, "project unknown not found")
+}
+
+func TestLoadWorkspaceErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := FindWorkspace(dir)
+	assert.ErrorContains(t, err, "no "+WorkspaceFile+" found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, WorkspaceFile), []byte("projects: []\n"), 0644))
+	_, err = LoadWorkspace(dir)
+	assert.ErrorContains(t, err, "doesn't list any projects")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, WorkspaceFile), []byte("projects:\n  - path: missing\n"), 0644))
+	ws, err := LoadWorkspace(dir)
+	require.NoError(t, err)
+	_, err = ws.Members(nil)
+	assert.ErrorContains(t, err, "no Agentuity project found in missing")
+}
@@ -0,0 +1,254 @@
+package sbom
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/agentuity/cli/internal/util"
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// Formats are the supported SBOM formats
+var Formats = []string{FormatCycloneDX, FormatSPDX}
+
+// Document describes the project the SBOM is generated for
+type Document struct {
+	// Name is the name of the project
+	Name string
+	// Version of the CLI which generated the SBOM
+	ToolVersion string
+	Components  []Component
+	// Timestamp of the SBOM, defaults to now
+	Timestamp time.Time
+}
+
+func (d Document) timestamp() string {
+	ts := d.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+// Marshal returns the document encoded as JSON in the format
+func (d Document) Marshal(format string) ([]byte, error) {
+	var val any
+	switch format {
+	case FormatCycloneDX:
+		val = d.cycloneDX()
+	case FormatSPDX:
+		val = d.spdx()
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format: %s. must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+	return json.MarshalIndent(val, "", "  ")
+}
+
+type cdxLicense struct {
+	License    *cdxLicenseName `json:"license,omitempty"`
+	Expression string          `json:"expression,omitempty"`
+}
+
+type cdxLicenseName struct {
+	Name string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type     string       `json:"type"`
+	BOMRef   string       `json:"bom-ref,omitempty"`
+	Group    string       `json:"group,omitempty"`
+	Name     string       `json:"name"`
+	Version  string       `json:"version,omitempty"`
+	PURL     string       `json:"purl,omitempty"`
+	Licenses []cdxLicense `json:"licenses,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cdxComponent `json:"components"`
+	} `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxBOM struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+// cycloneDX returns the document as a CycloneDX 1.5 BOM
+func (d Document) cycloneDX() cdxBOM {
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid.New().String(),
+		Version:      1,
+		Components:   make([]cdxComponent, 0, len(d.Components)),
+	}
+	bom.Metadata.Timestamp = d.timestamp()
+	bom.Metadata.Tools.Components = []cdxComponent{{Type: "application", Group: "agentuity", Name: "cli", Version: d.ToolVersion}}
+	bom.Metadata.Component = cdxComponent{Type: "application", BOMRef: d.Name, Name: d.Name}
+	for _, c := range d.Components {
+		component := cdxComponent{
+			Type:    "library",
+			BOMRef:  c.PURL(),
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL(),
+		}
+		if c.Ecosystem == EcosystemNPM {
+			if scope, name, ok := strings.Cut(c.Name, "/"); ok {
+				component.Group = scope
+				component.Name = name
+			}
+		}
+		if c.License != "" {
+			if isLicenseExpression(c.License) && strings.Contains(c.License, " ") {
+				component.Licenses = []cdxLicense{{Expression: c.License}}
+			} else {
+				component.Licenses = []cdxLicense{{License: &cdxLicenseName{Name: c.License}}}
+			}
+		}
+		bom.Components = append(bom.Components, component)
+	}
+	return bom
+}
+
+type spdxExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxDocument struct {
+	SPDXVersion       string `json:"spdxVersion"`
+	DataLicense       string `json:"dataLicense"`
+	SPDXID            string `json:"SPDXID"`
+	Name              string `json:"name"`
+	DocumentNamespace string `json:"documentNamespace"`
+	CreationInfo      struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	} `json:"creationInfo"`
+	Packages      []spdxPackage      `json:"packages"`
+	Relationships []spdxRelationship `json:"relationships"`
+}
+
+var spdxIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+var licenseIdentifier = regexp.MustCompile(`^[A-Za-z0-9.+-]+$`)
+
+// isLicenseExpression returns true if the license looks like a SPDX license identifier or
+// expression such as MIT or (MIT OR Apache-2.0) and not a name like "MIT License"
+func isLicenseExpression(license string) bool {
+	tokens := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(license))
+	if len(tokens)%2 == 0 {
+		return false
+	}
+	for i, token := range tokens {
+		if i%2 == 1 {
+			if token != "AND" && token != "OR" && token != "WITH" {
+				return false
+			}
+		} else if !licenseIdentifier.MatchString(token) {
+			return false
+		}
+	}
+	return true
+}
+
+// spdx returns the document as a SPDX 2.3 document
+func (d Document) spdx() spdxDocument {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Name,
+		DocumentNamespace: "https://agentuity.com/spdx/" + spdxIDUnsafe.ReplaceAllString(d.Name, "-") + "-" + uuid.New().String(),
+		Packages:          make([]spdxPackage, 0, len(d.Components)+1),
+	}
+	doc.CreationInfo.Created = d.timestamp()
+	doc.CreationInfo.Creators = []string{"Tool: agentuity-cli-" + d.ToolVersion}
+	root := spdxPackage{
+		Name:             d.Name,
+		SPDXID:           "SPDXRef-Project",
+		DownloadLocation: "NOASSERTION",
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+	}
+	doc.Packages = append(doc.Packages, root)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{Element: doc.SPDXID, Type: "DESCRIBES", Related: root.SPDXID})
+	for i, c := range d.Components {
+		license := "NOASSERTION"
+		if c.License != "" && isLicenseExpression(c.License) {
+			license = c.License
+		}
+		pkg := spdxPackage{
+			Name:             c.Name,
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%s-%d", spdxIDUnsafe.ReplaceAllString(c.Ecosystem+"-"+c.Name, "-"), i),
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  license,
+			ExternalRefs:     []spdxExternalRef{{Category: "PACKAGE-MANAGER", Type: "purl", Locator: c.PURL()}},
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: root.SPDXID, Type: "DEPENDS_ON", Related: pkg.SPDXID})
+	}
+	return doc
+}
+
+// Filename is the name of the SBOM in the .agentuity directory of the deployment
+const Filename = "sbom.cdx.json"
+
+// Mutator returns a zip mutator which adds the CycloneDX SBOM to the deployment zip after
+// calling next
+func Mutator(doc Document, next util.ZipDirCallbackMutator) util.ZipDirCallbackMutator {
+	return func(writer *zip.Writer) error {
+		if next != nil {
+			if err := next(writer); err != nil {
+				return err
+			}
+		}
+		buf, err := doc.Marshal(FormatCycloneDX)
+		if err != nil {
+			return err
+		}
+		w, err := writer.Create(".agentuity/" + Filename)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+}
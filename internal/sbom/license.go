@@ -0,0 +1,250 @@
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LicenseModeWarn shows the violations of the license policy as warnings
+	LicenseModeWarn = "warn"
+	// LicenseModeFail fails the deploy or import when the license policy is violated
+	LicenseModeFail = "fail"
+)
+
+// LicensePolicy is the licenses section of the project file. The patterns are SPDX license
+// identifiers which can contain wildcards such as GPL-*.
+type LicensePolicy struct {
+	// Mode is either warn (the default) or fail
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Allow are the only licenses which are allowed when set
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	// Deny are the licenses which are not allowed
+	Deny []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+	// Ignore are the names of the packages which aren't checked
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// LoadLicensePolicy reads the licenses section of the project file and returns nil when there is
+// none. The policy is read separately since it isn't part of the project configuration shared
+// with the runtime.
+func LoadLicensePolicy(filename string) (*LicensePolicy, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Licenses *LicensePolicy `yaml:"licenses"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Base(filename), err)
+	}
+	if p := config.Licenses; p != nil && p.Mode != "" && p.Mode != LicenseModeWarn && p.Mode != LicenseModeFail {
+		return nil, fmt.Errorf("invalid licenses.mode %q: must be %s or %s", p.Mode, LicenseModeWarn, LicenseModeFail)
+	}
+	return config.Licenses, nil
+}
+
+// Fail returns true if violations of the policy should fail the deploy or import
+func (p *LicensePolicy) Fail() bool {
+	return p.Mode == LicenseModeFail
+}
+
+// licenseAliases are the common license names used instead of SPDX identifiers (mostly in the
+// metadata of Python packages)
+var licenseAliases = map[string]string{
+	"apache 2.0":                            "Apache-2.0",
+	"apache-2":                              "Apache-2.0",
+	"apache 2":                              "Apache-2.0",
+	"apache license 2.0":                    "Apache-2.0",
+	"apache license, version 2.0":           "Apache-2.0",
+	"apache software license":               "Apache-2.0",
+	"mit license":                           "MIT",
+	"isc license":                           "ISC",
+	"isc license (iscl)":                    "ISC",
+	"bsd":                                   "BSD-3-Clause",
+	"bsd license":                           "BSD-3-Clause",
+	"new bsd license":                       "BSD-3-Clause",
+	"python software foundation license":    "PSF-2.0",
+	"mozilla public license 2.0 (mpl 2.0)":  "MPL-2.0",
+	"gnu general public license v3 (gplv3)": "GPL-3.0",
+	"gnu general public license v2 (gplv2)": "GPL-2.0",
+	"gnu lesser general public license v3 (lgplv3)": "LGPL-3.0",
+	"gnu affero general public license v3":          "AGPL-3.0",
+	"the unlicense (unlicense)":                     "Unlicense",
+}
+
+// NormalizeLicense returns the SPDX identifier of a license name such as "MIT License" or the
+// license as is when it isn't known
+func NormalizeLicense(license string) string {
+	license = strings.TrimSpace(license)
+	if id, ok := licenseAliases[strings.ToLower(license)]; ok {
+		return id
+	}
+	return license
+}
+
+func matchLicense(patterns []string, id string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(id)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedID returns true if the single license identifier is allowed by the policy
+func (p *LicensePolicy) allowedID(id string) bool {
+	id = strings.TrimSuffix(id, "+")
+	if matchLicense(p.Deny, id) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchLicense(p.Allow, id)
+}
+
+// Allowed returns true if the license (a SPDX identifier or expression) is allowed by the policy.
+// For an expression with OR, one of the choices must be allowed and with AND, all of them.
+// Unknown licenses are only allowed when the policy doesn't have an allow list.
+func (p *LicensePolicy) Allowed(license string) bool {
+	license = NormalizeLicense(license)
+	if license == "" {
+		return len(p.Allow) == 0
+	}
+	if !isLicenseExpression(license) {
+		return p.allowedID(license)
+	}
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(license))
+	e := &licenseEvaluator{tokens: tokens, allowed: p.allowedID}
+	ok := e.or()
+	if e.pos != len(tokens) {
+		// unbalanced parentheses so check each identifier instead
+		for _, token := range tokens {
+			if token != "(" && token != ")" && token != "AND" && token != "OR" && token != "WITH" && !p.allowedID(token) {
+				return false
+			}
+		}
+		return true
+	}
+	return ok
+}
+
+// licenseEvaluator evaluates a SPDX license expression where AND binds tighter than OR
+type licenseEvaluator struct {
+	tokens  []string
+	pos     int
+	allowed func(id string) bool
+}
+
+func (e *licenseEvaluator) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos]
+	}
+	return ""
+}
+
+func (e *licenseEvaluator) or() bool {
+	ok := e.and()
+	for e.peek() == "OR" {
+		e.pos++
+		// evaluate both sides so the whole expression is consumed
+		right := e.and()
+		ok = ok || right
+	}
+	return ok
+}
+
+func (e *licenseEvaluator) and() bool {
+	ok := e.term()
+	for e.peek() == "AND" {
+		e.pos++
+		right := e.term()
+		ok = ok && right
+	}
+	return ok
+}
+
+func (e *licenseEvaluator) term() bool {
+	if e.peek() == "(" {
+		e.pos++
+		ok := e.or()
+		if e.peek() == ")" {
+			e.pos++
+		}
+		return ok
+	}
+	id := e.peek()
+	e.pos++
+	ok := e.allowed(id)
+	if e.peek() == "WITH" {
+		// the exception (such as Classpath-exception-2.0) only relaxes the license
+		e.pos += 2
+	}
+	return ok
+}
+
+// LicenseViolation is a package with a license which isn't allowed by the policy
+type LicenseViolation struct {
+	Component Component `json:"component"`
+	Reason    string    `json:"reason"`
+}
+
+// Check returns the components with a license which isn't allowed by the policy
+func (p *LicensePolicy) Check(components []Component) []LicenseViolation {
+	var violations []LicenseViolation
+	for _, c := range components {
+		if matchLicense(p.Ignore, c.Name) || p.Allowed(c.License) {
+			continue
+		}
+		reason := fmt.Sprintf("license %s is not allowed", c.License)
+		if c.License == "" {
+			reason = "the license is unknown"
+		}
+		violations = append(violations, LicenseViolation{Component: c, Reason: reason})
+	}
+	return violations
+}
+
+// DetectLicense returns the SPDX identifier of the license text (such as the contents of a LICENSE
+// file) for the most common licenses or an empty string when the license isn't recognized
+func DetectLicense(text string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	upper := strings.ToUpper(normalized)
+	switch {
+	case strings.Contains(upper, "GNU AFFERO GENERAL PUBLIC LICENSE"):
+		return "AGPL-3.0"
+	case strings.Contains(upper, "GNU LESSER GENERAL PUBLIC LICENSE"):
+		if strings.Contains(upper, "VERSION 2.1") {
+			return "LGPL-2.1"
+		}
+		return "LGPL-3.0"
+	case strings.Contains(upper, "GNU GENERAL PUBLIC LICENSE"):
+		if strings.Contains(upper, "VERSION 2,") || strings.Contains(upper, "VERSION 2 ") {
+			return "GPL-2.0"
+		}
+		return "GPL-3.0"
+	case strings.Contains(upper, "MOZILLA PUBLIC LICENSE VERSION 2.0"):
+		return "MPL-2.0"
+	case strings.Contains(upper, "APACHE LICENSE") && strings.Contains(upper, "VERSION 2.0"):
+		return "Apache-2.0"
+	case strings.Contains(normalized, "Permission is hereby granted, free of charge"):
+		return "MIT"
+	case strings.Contains(normalized, "Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee"):
+		return "ISC"
+	case strings.Contains(normalized, "Redistribution and use in source and binary forms"):
+		if strings.Contains(normalized, "Neither the name") || strings.Contains(normalized, "endorse or promote products") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case strings.Contains(normalized, "This is free and unencumbered software released into the public domain"):
+		return "Unlicense"
+	}
+	return ""
+}
+
+// LicenseFilenames are the names of the files which contain the license of a repository
+var LicenseFilenames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "COPYING", "COPYING.md", "COPYING.txt"}
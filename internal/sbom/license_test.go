@@ -0,0 +1,84 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicensePolicyAllowed(t *testing.T) {
+	deny := &LicensePolicy{Deny: []string{"GPL-*", "AGPL-*"}}
+	allow := &LicensePolicy{Allow: []string{"MIT", "Apache-2.0", "BSD-*"}, Deny: []string{"BSD-4-Clause"}}
+	tests := []struct {
+		license string
+		deny    bool
+		allow   bool
+	}{
+		{"MIT", true, true},
+		{"mit", true, true},
+		{"GPL-3.0", false, false},
+		{"GPL-2.0+", false, false},
+		{"", true, false},
+		{"BSD-3-Clause", true, true},
+		{"BSD-4-Clause", true, false},
+		{"ISC", true, false},
+		{"(MIT OR GPL-3.0)", true, true},
+		{"(MIT AND GPL-3.0)", false, false},
+		{"GPL-3.0 OR (MIT AND Apache-2.0)", true, true},
+		{"GPL-2.0 WITH Classpath-exception-2.0", false, false},
+		{"Apache 2.0", true, true},
+		{"MIT License", true, true},
+		{"SEE LICENSE IN LICENSE.md", true, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.deny, deny.Allowed(tt.license), "deny %q", tt.license)
+		assert.Equal(t, tt.allow, allow.Allowed(tt.license), "allow %q", tt.license)
+	}
+}
+
+func TestLicensePolicyCheck(t *testing.T) {
+	policy := &LicensePolicy{Allow: []string{"MIT"}, Ignore: []string{"internal-*"}}
+	violations := policy.Check([]Component{
+		{Name: "a", Version: "1.0.0", License: "MIT"},
+		{Name: "b", Version: "1.0.0", License: "GPL-3.0"},
+		{Name: "c", Version: "1.0.0"},
+		{Name: "internal-lib", Version: "1.0.0", License: "UNLICENSED"},
+	})
+	require.Len(t, violations, 2)
+	assert.Equal(t, "b", violations[0].Component.Name)
+	assert.Equal(t, "license GPL-3.0 is not allowed", violations[0].Reason)
+	assert.Equal(t, "c", violations[1].Component.Name)
+	assert.Equal(t, "the license is unknown", violations[1].Reason)
+}
+
+func TestLoadLicensePolicy(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "agentuity.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("name: app\n"), 0644))
+	policy, err := LoadLicensePolicy(filename)
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	require.NoError(t, os.WriteFile(filename, []byte("name: app\nlicenses:\n  mode: fail\n  deny: [GPL-*]\n"), 0644))
+	policy, err = LoadLicensePolicy(filename)
+	require.NoError(t, err)
+	assert.Equal(t, &LicensePolicy{Mode: LicenseModeFail, Deny: []string{"GPL-*"}}, policy)
+	assert.True(t, policy.Fail())
+
+	require.NoError(t, os.WriteFile(filename, []byte("licenses:\n  mode: maybe\n"), 0644))
+	_, err = LoadLicensePolicy(filename)
+	assert.Error(t, err)
+}
+
+func TestDetectLicense(t *testing.T) {
+	assert.Equal(t, "MIT", DetectLicense("MIT License\n\nCopyright (c) 2024\n\nPermission is hereby granted, free of charge, to any person"))
+	assert.Equal(t, "Apache-2.0", DetectLicense("   Apache License\n   Version 2.0, January 2004"))
+	assert.Equal(t, "GPL-3.0", DetectLicense("GNU GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007"))
+	assert.Equal(t, "GPL-2.0", DetectLicense("GNU GENERAL PUBLIC LICENSE\n Version 2, June 1991"))
+	assert.Equal(t, "AGPL-3.0", DetectLicense("GNU AFFERO GENERAL PUBLIC LICENSE\n Version 3"))
+	assert.Equal(t, "BSD-3-Clause", DetectLicense("Redistribution and use in source and binary forms ... Neither the name of the copyright holder"))
+	assert.Equal(t, "", DetectLicense("All rights reserved."))
+}
@@ -0,0 +1,406 @@
+package sbom
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	EcosystemNPM  = "npm"
+	EcosystemPyPI = "pypi"
+)
+
+// Component is a third-party package which is part of the deployment
+type Component struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	// License is the declared license of the package (usually a SPDX identifier or expression)
+	License string `json:"license,omitempty"`
+	// Path is the directory of the package relative to the project, with forward slashes
+	Path string `json:"path,omitempty"`
+
+	dependencies []string
+}
+
+// PURL returns the package URL of the component such as pkg:npm/%40scope/name@1.0.0
+func (c Component) PURL() string {
+	switch c.Ecosystem {
+	case EcosystemNPM:
+		if scope, name, ok := strings.Cut(c.Name, "/"); ok {
+			// the @ of the scope is percent-encoded in a purl
+			return fmt.Sprintf("pkg:npm/%%40%s/%s@%s", url.PathEscape(strings.TrimPrefix(scope, "@")), url.PathEscape(name), url.PathEscape(c.Version))
+		}
+		return fmt.Sprintf("pkg:npm/%s@%s", url.PathEscape(c.Name), url.PathEscape(c.Version))
+	case EcosystemPyPI:
+		return fmt.Sprintf("pkg:pypi/%s@%s", url.PathEscape(normalizePythonName(c.Name)), url.PathEscape(c.Version))
+	}
+	return ""
+}
+
+// Collect returns the JavaScript and Python packages installed in the project in dir which are
+// part of the deployment: the packages reachable from the (non-development) dependencies of the
+// project and the native packages installed into the build output. The components are sorted by
+// ecosystem, name and version.
+func Collect(dir string) ([]Component, error) {
+	js, err := collectJavaScript(dir)
+	if err != nil {
+		return nil, err
+	}
+	py, err := collectPython(dir)
+	if err != nil {
+		return nil, err
+	}
+	components := append(js, py...)
+	sort.Slice(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return components, nil
+}
+
+// reachable returns the components reachable from the roots through their dependencies. The
+// dependencies are resolved by name so when several versions of a package are installed, all of
+// them are included.
+func reachable(components []Component, roots []string, normalize func(string) string) []Component {
+	byName := make(map[string][]int)
+	for i, c := range components {
+		byName[normalize(c.Name)] = append(byName[normalize(c.Name)], i)
+	}
+	seen := make(map[int]bool)
+	queue := append([]string(nil), roots...)
+	for len(queue) > 0 {
+		name := normalize(queue[0])
+		queue = queue[1:]
+		for _, i := range byName[name] {
+			if !seen[i] {
+				seen[i] = true
+				queue = append(queue, components[i].dependencies...)
+			}
+		}
+	}
+	var result []Component
+	for i, c := range components {
+		if seen[i] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	License              json.RawMessage   `json:"license"`
+	Licenses             []json.RawMessage `json:"licenses"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+func readPackageJSON(filename string) (*packageJSON, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(buf, &pkg); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+	}
+	return &pkg, nil
+}
+
+// license returns the license of the package which is either a string, an object with a type or
+// the deprecated list of licenses
+func (p *packageJSON) license() string {
+	parse := func(raw json.RawMessage) string {
+		var val string
+		if json.Unmarshal(raw, &val) == nil {
+			return val
+		}
+		var obj struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(raw, &obj) == nil {
+			return obj.Type
+		}
+		return ""
+	}
+	if len(p.License) > 0 {
+		return parse(p.License)
+	}
+	var licenses []string
+	for _, raw := range p.Licenses {
+		if val := parse(raw); val != "" {
+			licenses = append(licenses, val)
+		}
+	}
+	if len(licenses) > 1 {
+		return "(" + strings.Join(licenses, " OR ") + ")"
+	}
+	return strings.Join(licenses, "")
+}
+
+func (p *packageJSON) dependencies() []string {
+	var names []string
+	for _, deps := range []map[string]string{p.Dependencies, p.OptionalDependencies, p.PeerDependencies} {
+		for name := range deps {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walkNodeModules adds the packages installed in the node_modules directory, including the nested
+// node_modules directories and the virtual store of pnpm
+func walkNodeModules(projectDir string, dir string, seen map[string]bool, components *[]Component) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case name == ".pnpm":
+			store, err := os.ReadDir(filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			for _, e := range store {
+				if err := walkNodeModules(projectDir, filepath.Join(dir, name, e.Name(), "node_modules"), seen, components); err != nil {
+					return err
+				}
+			}
+		case strings.HasPrefix(name, "."):
+			// .bin, .cache, .package-lock.json etc
+		case strings.HasPrefix(name, "@"):
+			scoped, err := os.ReadDir(filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			for _, e := range scoped {
+				if err := addNodePackage(projectDir, filepath.Join(dir, name, e.Name()), e, seen, components); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := addNodePackage(projectDir, filepath.Join(dir, name), entry, seen, components); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addNodePackage(projectDir string, dir string, entry os.DirEntry, seen map[string]bool, components *[]Component) error {
+	pkg, err := readPackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if pkg.Name == "" || pkg.Version == "" {
+		return nil
+	}
+	key := pkg.Name + "@" + pkg.Version
+	if !seen[key] {
+		seen[key] = true
+		rel, _ := filepath.Rel(projectDir, dir)
+		*components = append(*components, Component{
+			Ecosystem:    EcosystemNPM,
+			Name:         pkg.Name,
+			Version:      pkg.Version,
+			License:      pkg.license(),
+			Path:         filepath.ToSlash(rel),
+			dependencies: pkg.dependencies(),
+		})
+	}
+	// symlinked packages (such as the ones pnpm links from its virtual store) are walked there
+	if entry.Type()&os.ModeSymlink == 0 {
+		return walkNodeModules(projectDir, filepath.Join(dir, "node_modules"), seen, components)
+	}
+	return nil
+}
+
+func collectJavaScript(dir string) ([]Component, error) {
+	var installed []Component
+	seen := make(map[string]bool)
+	if err := walkNodeModules(dir, filepath.Join(dir, "node_modules"), seen, &installed); err != nil {
+		return nil, err
+	}
+	// the native packages which the bundler installs next to the bundle are always deployed
+	var native []Component
+	if err := walkNodeModules(dir, filepath.Join(dir, ".agentuity", "node_modules"), make(map[string]bool), &native); err != nil {
+		return nil, err
+	}
+	pkg, err := readPackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if pkg != nil {
+		installed = reachable(installed, pkg.dependencies(), func(name string) string { return name })
+	}
+	for _, c := range native {
+		if !seen[c.Name+"@"+c.Version] {
+			installed = append(installed, c)
+		}
+	}
+	return installed, nil
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes the name of a Python package (PEP 503)
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+var pythonRequirementName = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// sitePackagesDirs returns the site-packages directories of the virtual environment of the project
+func sitePackagesDirs(dir string) []string {
+	dirs, _ := filepath.Glob(filepath.Join(dir, ".venv", "lib", "python*", "site-packages"))
+	if windows := filepath.Join(dir, ".venv", "Lib", "site-packages"); len(dirs) == 0 && isDir(windows) {
+		dirs = append(dirs, windows)
+	}
+	return dirs
+}
+
+func isDir(dir string) bool {
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+// readDistInfo reads the name, version, license and dependencies from the METADATA file of an
+// installed Python package
+func readDistInfo(filename string) (*Component, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &Component{Ecosystem: EcosystemPyPI}
+	var classifierLicenses []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break // the headers end at the first blank line and the description follows
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch key {
+		case "Name":
+			c.Name = val
+		case "Version":
+			c.Version = val
+		case "License-Expression":
+			c.License = val
+		case "License":
+			// the License field is sometimes the whole license text so only short values are used
+			if c.License == "" && !strings.Contains(val, "\n") && len(val) <= 64 {
+				c.License = val
+			}
+		case "Classifier":
+			if lic, ok := strings.CutPrefix(val, "License :: OSI Approved :: "); ok {
+				classifierLicenses = append(classifierLicenses, lic)
+			}
+		case "Requires-Dist":
+			requirement, marker, _ := strings.Cut(val, ";")
+			if strings.Contains(marker, "extra") {
+				continue
+			}
+			if m := pythonRequirementName.FindStringSubmatch(requirement); m != nil {
+				c.dependencies = append(c.dependencies, m[1])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if c.License == "" && len(classifierLicenses) > 0 {
+		c.License = strings.Join(classifierLicenses, " OR ")
+	}
+	return c, nil
+}
+
+var pyprojectDependencies = regexp.MustCompile(`(?ms)^\[project\].*?^dependencies\s*=\s*\[(.*?)\]`)
+var quotedString = regexp.MustCompile(`["']([^"']+)["']`)
+
+// pyprojectRoots returns the names of the dependencies of the project in pyproject.toml
+func pyprojectRoots(filename string) ([]string, bool, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	m := pyprojectDependencies.FindSubmatch(buf)
+	if m == nil {
+		return nil, true, nil
+	}
+	var roots []string
+	for _, q := range quotedString.FindAllSubmatch(m[1], -1) {
+		if name := pythonRequirementName.FindSubmatch(q[1]); name != nil {
+			roots = append(roots, string(name[1]))
+		}
+	}
+	return roots, true, nil
+}
+
+func collectPython(dir string) ([]Component, error) {
+	var installed []Component
+	seen := make(map[string]bool)
+	for _, sitePackages := range sitePackagesDirs(dir) {
+		infos, _ := filepath.Glob(filepath.Join(sitePackages, "*.dist-info"))
+		sort.Strings(infos)
+		for _, info := range infos {
+			c, err := readDistInfo(filepath.Join(info, "METADATA"))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			key := normalizePythonName(c.Name) + "@" + c.Version
+			if c.Name == "" || c.Version == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			rel, _ := filepath.Rel(dir, info)
+			c.Path = filepath.ToSlash(rel)
+			installed = append(installed, *c)
+		}
+	}
+	roots, ok, err := pyprojectRoots(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		installed = reachable(installed, roots, normalizePythonName)
+	}
+	return installed, nil
+}
@@ -0,0 +1,103 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, filename string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+}
+
+func TestCollectJavaScript(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"name":"app","dependencies":{"a":"^1.0.0","@scope/b":"2"},"devDependencies":{"dev":"1"}}`)
+	writeFile(t, filepath.Join(dir, "node_modules", "a", "package.json"), `{"name":"a","version":"1.0.0","license":"MIT","dependencies":{"c":"1"}}`)
+	writeFile(t, filepath.Join(dir, "node_modules", "a", "node_modules", "c", "package.json"), `{"name":"c","version":"1.0.0","license":{"type":"ISC"}}`)
+	writeFile(t, filepath.Join(dir, "node_modules", "c", "package.json"), `{"name":"c","version":"2.0.0","licenses":[{"type":"MIT"},{"type":"Apache-2.0"}]}`)
+	writeFile(t, filepath.Join(dir, "node_modules", "@scope", "b", "package.json"), `{"name":"@scope/b","version":"2.1.0","license":"Apache-2.0"}`)
+	writeFile(t, filepath.Join(dir, "node_modules", "dev", "package.json"), `{"name":"dev","version":"1.0.0","license":"MIT"}`)
+	writeFile(t, filepath.Join(dir, "node_modules", ".bin", "package.json"), `{"name":"bin","version":"1.0.0"}`)
+	writeFile(t, filepath.Join(dir, ".agentuity", "node_modules", "native", "package.json"), `{"name":"native","version":"0.1.0","license":"MIT"}`)
+
+	components, err := Collect(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Component{
+		{Ecosystem: EcosystemNPM, Name: "@scope/b", Version: "2.1.0", License: "Apache-2.0", Path: "node_modules/@scope/b"},
+		{Ecosystem: EcosystemNPM, Name: "a", Version: "1.0.0", License: "MIT", Path: "node_modules/a", dependencies: []string{"c"}},
+		{Ecosystem: EcosystemNPM, Name: "c", Version: "1.0.0", License: "ISC", Path: "node_modules/a/node_modules/c"},
+		{Ecosystem: EcosystemNPM, Name: "c", Version: "2.0.0", License: "(MIT OR Apache-2.0)", Path: "node_modules/c"},
+		{Ecosystem: EcosystemNPM, Name: "native", Version: "0.1.0", License: "MIT", Path: ".agentuity/node_modules/native"},
+	}, components)
+	assert.Equal(t, "pkg:npm/%40scope/b@2.1.0", components[0].PURL())
+	assert.Equal(t, "pkg:npm/a@1.0.0", components[1].PURL())
+}
+
+func TestCollectPython(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pyproject.toml"), "[project]\nname = \"app\"\ndependencies = [\n  \"Requests>=2\",\n  'agentuity',\n]\n\n[dependency-groups]\ndev = [\"pytest\"]\n")
+	sitePackages := filepath.Join(dir, ".venv", "lib", "python3.12", "site-packages")
+	writeFile(t, filepath.Join(sitePackages, "requests-2.32.0.dist-info", "METADATA"), "Metadata-Version: 2.1\nName: requests\nVersion: 2.32.0\nLicense: Apache 2.0\nClassifier: License :: OSI Approved :: Apache Software License\nRequires-Dist: charset_normalizer (<4,>=2)\nRequires-Dist: PySocks (!=1.5.7,>=1.5.6) ; extra == 'socks'\n\nRequires-Dist: ignored\n")
+	writeFile(t, filepath.Join(sitePackages, "charset_normalizer-3.3.2.dist-info", "METADATA"), "Name: charset-normalizer\nVersion: 3.3.2\nLicense-Expression: MIT\n")
+	writeFile(t, filepath.Join(sitePackages, "agentuity-0.0.100.dist-info", "METADATA"), "Name: agentuity\nVersion: 0.0.100\nClassifier: License :: OSI Approved :: Apache Software License\n")
+	writeFile(t, filepath.Join(sitePackages, "pysocks-1.7.1.dist-info", "METADATA"), "Name: PySocks\nVersion: 1.7.1\n")
+	writeFile(t, filepath.Join(sitePackages, "pytest-8.0.0.dist-info", "METADATA"), "Name: pytest\nVersion: 8.0.0\n")
+
+	components, err := Collect(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, c := range components {
+		names = append(names, c.Name+"@"+c.Version+" "+c.License)
+	}
+	assert.Equal(t, []string{
+		"agentuity@0.0.100 Apache Software License",
+		"charset-normalizer@3.3.2 MIT",
+		"requests@2.32.0 Apache 2.0",
+	}, names)
+	assert.Equal(t, "pkg:pypi/charset-normalizer@3.3.2", components[1].PURL())
+}
+
+func TestMarshal(t *testing.T) {
+	doc := Document{
+		Name:        "app",
+		ToolVersion: "1.0.0",
+		Components: []Component{
+			{Ecosystem: EcosystemNPM, Name: "@scope/b", Version: "2.1.0", License: "(MIT OR Apache-2.0)"},
+			{Ecosystem: EcosystemPyPI, Name: "requests", Version: "2.32.0", License: "Apache 2.0"},
+		},
+	}
+
+	buf, err := doc.Marshal(FormatCycloneDX)
+	require.NoError(t, err)
+	var bom cdxBOM
+	require.NoError(t, json.Unmarshal(buf, &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "app", bom.Metadata.Component.Name)
+	require.Len(t, bom.Components, 2)
+	assert.Equal(t, "@scope", bom.Components[0].Group)
+	assert.Equal(t, "b", bom.Components[0].Name)
+	assert.Equal(t, "pkg:npm/%40scope/b@2.1.0", bom.Components[0].PURL)
+	assert.Equal(t, []cdxLicense{{Expression: "(MIT OR Apache-2.0)"}}, bom.Components[0].Licenses)
+	assert.Equal(t, []cdxLicense{{License: &cdxLicenseName{Name: "Apache 2.0"}}}, bom.Components[1].Licenses)
+
+	buf, err = doc.Marshal(FormatSPDX)
+	require.NoError(t, err)
+	var spdx spdxDocument
+	require.NoError(t, json.Unmarshal(buf, &spdx))
+	assert.Equal(t, "SPDX-2.3", spdx.SPDXVersion)
+	require.Len(t, spdx.Packages, 3)
+	assert.Equal(t, "(MIT OR Apache-2.0)", spdx.Packages[1].LicenseDeclared)
+	assert.Equal(t, "NOASSERTION", spdx.Packages[2].LicenseDeclared)
+	assert.Equal(t, "pkg:pypi/requests@2.32.0", spdx.Packages[2].ExternalRefs[0].Locator)
+	assert.Len(t, spdx.Relationships, 3)
+
+	_, err = doc.Marshal("xml")
+	assert.Error(t, err)
+}
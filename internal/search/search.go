@@ -0,0 +1,180 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/agentuity/cli/internal/ignore"
+)
+
+// Options control how the agent source is searched
+type Options struct {
+	// Pattern is the expression to match against each line
+	Pattern *regexp.Regexp
+	// AgentNames maps an agent directory name to the agent name used in the results
+	AgentNames map[string]string
+	// Agents limits the search to the agent directory names provided (all agents if empty)
+	Agents []string
+	// MaxMatches limits the number of matches returned, which are the first ones once sorted (no limit if zero)
+	MaxMatches int
+}
+
+// Match is a line in an agent source file which matched the pattern
+type Match struct {
+	Agent  string `json:"agent"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text"`
+	// Truncated is set when the line is longer than maxLineLen, in which case Text is only the
+	// start of the match
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+const binarySniffLen = 8000
+
+// maxLineLen is the length of the lines, such as in minified files, above which only the start of the
+// match is returned in the text
+const maxLineLen = 1024 * 1024
+
+// truncatedTextLen is the length of the text returned for a match in a line longer than maxLineLen
+const truncatedTextLen = 200
+
+// Search searches the source files of the agents in agentsDir (relative to dir) for the pattern.
+// Files matching the ignore rules are skipped. Matches are returned grouped by agent and sorted
+// by file and line, and then limited to MaxMatches. File paths in the result are relative to dir.
+func Search(ctx context.Context, dir string, agentsDir string, rules *ignore.Rules, opts Options) ([]Match, error) {
+	root := filepath.Join(dir, agentsDir)
+	var matches []Match
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if path != root && rules != nil && rules.Ignore(rel, fi) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		agentDir := agentDirName(root, path)
+		if agentDir == "" {
+			// files directly in the agents directory don't belong to an agent
+			return nil
+		}
+		if len(opts.Agents) > 0 && !contains(opts.Agents, agentDir) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		agentName := agentDir
+		if name, ok := opts.AgentNames[agentDir]; ok {
+			agentName = name
+		}
+		found, err := searchFile(path, filepath.ToSlash(rel), agentName, opts.Pattern)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Agent != matches[j].Agent {
+			return matches[i].Agent < matches[j].Agent
+		}
+		if matches[i].File != matches[j].File {
+			return matches[i].File < matches[j].File
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	if opts.MaxMatches > 0 && len(matches) > opts.MaxMatches {
+		matches = matches[:opts.MaxMatches]
+	}
+	return matches, nil
+}
+
+// agentDirName returns the name of the agent directory (the first path segment below root)
+// or an empty string if the path is root or a file directly in root
+func agentDirName(root string, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return ""
+	}
+	first, _, ok := strings.Cut(filepath.ToSlash(rel), "/")
+	if !ok {
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			return first
+		}
+		return ""
+	}
+	return first
+}
+
+func searchFile(filename string, rel string, agent string, pattern *regexp.Regexp) ([]Match, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(buf[:min(len(buf), binarySniffLen)], 0) >= 0 {
+		return nil, nil
+	}
+	var matches []Match
+	for line := 1; len(buf) > 0; line++ {
+		text := buf
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			text, buf = buf[:i], buf[i+1:]
+		} else {
+			buf = nil
+		}
+		text = bytes.TrimSuffix(text, []byte{'\r'})
+		loc := pattern.FindIndex(text)
+		if loc == nil {
+			continue
+		}
+		match := Match{
+			Agent:  agent,
+			File:   rel,
+			Line:   line,
+			Column: loc[0] + 1,
+			Text:   string(text),
+		}
+		if len(text) > maxLineLen {
+			match.Text = strings.ToValidUTF8(string(text[loc[0]:min(len(text), loc[0]+truncatedTextLen)]), "")
+			match.Truncated = true
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/agentuity/cli/internal/ignore"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupProject(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/agents/weather/index.ts":           "import { fetch } from 'x';\nexport default async function Agent() {\n  return fetch('weather');\n}\n",
+		"src/agents/weather/util.ts":            "export const fetchWeather = () => 1;\n",
+		"src/agents/news/index.ts":              "export default async function Agent() {\n  return fetch('news');\n}\n",
+		"src/agents/news/node_modules/dep/x.js": "fetch('ignored')\n",
+		"src/agents/news/generated.gen.ts":      "fetch('generated')\n",
+		"src/agents/readme.md":                  "fetch at the top level\n",
+		"src/index.ts":                          "fetch('outside agents')\n",
+		"src/agents/news/image.bin":             "fetch\x00binary",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestSearch(t *testing.T) {
+	dir := setupProject(t)
+	rules := ignore.Empty()
+	rules.AddDefaults()
+	assert.NoError(t, rules.Add("**/*.gen.ts"))
+
+	matches, err := Search(context.Background(), dir, "src/agents", rules, Options{
+		Pattern:    regexp.MustCompile(`fetch\(`),
+		AgentNames: map[string]string{"weather": "Weather Agent"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Agent: "Weather Agent", File: "src/agents/weather/index.ts", Line: 3, Column: 10, Text: "  return fetch('weather');"},
+		{Agent: "news", File: "src/agents/news/index.ts", Line: 2, Column: 10, Text: "  return fetch('news');"},
+	}, matches)
+}
+
+func TestSearchScopedToAgent(t *testing.T) {
+	dir := setupProject(t)
+	matches, err := Search(context.Background(), dir, "src/agents", nil, Options{
+		Pattern: regexp.MustCompile(`(?i)FETCH`),
+		Agents:  []string{"weather"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 3)
+	for _, m := range matches {
+		assert.Equal(t, "weather", m.Agent)
+	}
+}
+
+func TestSearchMaxMatches(t *testing.T) {
+	dir := setupProject(t)
+	matches, err := Search(context.Background(), dir, "src/agents", nil, Options{
+		Pattern:    regexp.MustCompile(`fetch`),
+		Agents:     []string{"weather"},
+		MaxMatches: 1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+}
+
+func TestSearchMaxMatchesSorted(t *testing.T) {
+	dir := setupProject(t)
+	// the news agent is walked first but the weather agent sorts first by its name
+	matches, err := Search(context.Background(), dir, "src/agents", ignore.Empty(), Options{
+		Pattern:    regexp.MustCompile(`fetch\(`),
+		AgentNames: map[string]string{"weather": "Weather Agent"},
+		MaxMatches: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Agent: "Weather Agent", File: "src/agents/weather/index.ts", Line: 3, Column: 10, Text: "  return fetch('weather');"},
+	}, matches)
+}
+
+func TestSearchLongLine(t *testing.T) {
+	dir := setupProject(t)
+	long := strings.Repeat("x", maxLineLen) + "fetch('minified')" + strings.Repeat("y", 1000)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src/agents/weather/bundle.js"), []byte(long+"\r\nfetch('after')\n"), 0644))
+
+	matches, err := Search(context.Background(), dir, "src/agents", nil, Options{
+		Pattern: regexp.MustCompile(`fetch\('(minified|after)`),
+		Agents:  []string{"weather"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, 1, matches[0].Line)
+	assert.Equal(t, maxLineLen+1, matches[0].Column)
+	assert.True(t, matches[0].Truncated)
+	assert.Len(t, matches[0].Text, truncatedTextLen)
+	assert.True(t, strings.HasPrefix(matches[0].Text, "fetch('minified')"))
+	assert.Equal(t, Match{Agent: "weather", File: "src/agents/weather/bundle.js", Line: 2, Column: 1, Text: "fetch('after')"}, matches[1])
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Kind is the service manager which runs the service at boot
+type Kind string
+
+const (
+	Systemd Kind = "systemd"
+	Launchd Kind = "launchd"
+	InitD   Kind = "initd"
+)
+
+// Kinds are the supported service managers
+var Kinds = []Kind{Systemd, Launchd, InitD}
+
+// launchdLabelPrefix is the prefix of the label of the launchd services
+const launchdLabelPrefix = "com.agentuity."
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Service is a command run at boot as a user, such as a development server shared by a team
+type Service struct {
+	Name        string
+	Description string
+	// Command is the absolute path of the executable followed by its arguments
+	Command []string
+	Dir     string
+	User    string
+	// Env are the environment variables of the command as KEY=VALUE
+	Env []string
+	// LogFile is the file the output is written to by the service managers which don't have a journal
+	LogFile string
+}
+
+// ValidateName returns an error if the name can't be used as the name of a service
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid service name %q, use lowercase letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// ParseKind returns the kind of service manager by name
+func ParseKind(name string) (Kind, error) {
+	for _, kind := range Kinds {
+		if string(kind) == name {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported service manager %q, use systemd, launchd or initd", name)
+}
+
+// Detect returns the service manager of this machine: launchd on macOS, and systemd or else
+// init.d scripts on Linux
+func Detect() (Kind, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return Launchd, nil
+	case "linux":
+		if info, err := os.Stat("/run/systemd/system"); err == nil && info.IsDir() {
+			return Systemd, nil
+		}
+		if info, err := os.Stat("/etc/init.d"); err == nil && info.IsDir() {
+			return InitD, nil
+		}
+		return "", fmt.Errorf("neither systemd nor /etc/init.d was found on this machine")
+	}
+	return "", fmt.Errorf("services aren't supported on %s", runtime.GOOS)
+}
+
+// Path returns the file of the service with the name
+func Path(kind Kind, name string) string {
+	switch kind {
+	case Systemd:
+		return "/etc/systemd/system/" + name + ".service"
+	case Launchd:
+		return "/Library/LaunchDaemons/" + launchdLabelPrefix + name + ".plist"
+	case InitD:
+		return "/etc/init.d/" + name
+	}
+	return ""
+}
+
+// Render returns the file of the service for the service manager
+func (s Service) Render(kind Kind) ([]byte, error) {
+	if err := ValidateName(s.Name); err != nil {
+		return nil, err
+	}
+	if len(s.Command) == 0 {
+		return nil, fmt.Errorf("the service %s has no command", s.Name)
+	}
+	switch kind {
+	case Systemd:
+		return s.systemd(), nil
+	case Launchd:
+		return s.launchd(), nil
+	case InitD:
+		return s.initd(), nil
+	}
+	return nil, fmt.Errorf("unsupported service manager %q", kind)
+}
+
+// systemdQuote quotes the value for a systemd unit, where % starts a specifier
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(s)
+	return `"` + s + `"`
+}
+
+func (s Service) systemd() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[Unit]\n")
+	fmt.Fprintf(&buf, "Description=%s\n", s.Description)
+	buf.WriteString("After=network-online.target\nWants=network-online.target\n\n")
+	buf.WriteString("[Service]\nType=simple\n")
+	if s.User != "" {
+		fmt.Fprintf(&buf, "User=%s\n", s.User)
+	}
+	if s.Dir != "" {
+		// unlike the other settings, the working directory can't be quoted
+		fmt.Fprintf(&buf, "WorkingDirectory=%s\n", strings.ReplaceAll(s.Dir, "%", "%%"))
+	}
+	for _, env := range s.Env {
+		fmt.Fprintf(&buf, "Environment=%s\n", systemdQuote(env))
+	}
+	args := make([]string, 0, len(s.Command))
+	for _, arg := range s.Command {
+		args = append(args, systemdQuote(arg))
+	}
+	fmt.Fprintf(&buf, "ExecStart=%s\n", strings.Join(args, " "))
+	buf.WriteString("Restart=on-failure\nRestartSec=5\n\n")
+	buf.WriteString("[Install]\nWantedBy=multi-user.target\n")
+	return buf.Bytes()
+}
+
+func xmlString(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return "<string>" + buf.String() + "</string>"
+}
+
+func (s Service) launchd() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&buf, "\t<key>Label</key>\n\t%s\n", xmlString(launchdLabelPrefix+s.Name))
+	buf.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range s.Command {
+		fmt.Fprintf(&buf, "\t\t%s\n", xmlString(arg))
+	}
+	buf.WriteString("\t</array>\n")
+	if s.Dir != "" {
+		fmt.Fprintf(&buf, "\t<key>WorkingDirectory</key>\n\t%s\n", xmlString(s.Dir))
+	}
+	if s.User != "" {
+		fmt.Fprintf(&buf, "\t<key>UserName</key>\n\t%s\n", xmlString(s.User))
+	}
+	if len(s.Env) > 0 {
+		env := append([]string(nil), s.Env...)
+		sort.Strings(env)
+		buf.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, kv := range env {
+			k, v, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(&buf, "\t\t<key>%s</key>\n\t\t%s\n", k, xmlString(v))
+		}
+		buf.WriteString("\t</dict>\n")
+	}
+	buf.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	buf.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	if s.LogFile != "" {
+		fmt.Fprintf(&buf, "\t<key>StandardOutPath</key>\n\t%s\n", xmlString(s.LogFile))
+		fmt.Fprintf(&buf, "\t<key>StandardErrorPath</key>\n\t%s\n", xmlString(s.LogFile))
+	}
+	buf.WriteString("</dict>\n</plist>\n")
+	return buf.Bytes()
+}
+
+// shellQuote quotes the value for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (s Service) initd() []byte {
+	command := "exec nohup"
+	if len(s.Env) > 0 {
+		command += " env"
+		for _, env := range s.Env {
+			command += " " + shellQuote(env)
+		}
+	}
+	for _, arg := range s.Command {
+		command += " " + shellQuote(arg)
+	}
+	logFile := s.LogFile
+	if logFile == "" {
+		logFile = "/dev/null"
+	}
+	command += " >> " + shellQuote(logFile) + " 2>&1"
+	if s.Dir != "" {
+		command = "cd " + shellQuote(s.Dir) + " && " + command
+	}
+	// the command runs in the background and prints its pid, which the script keeps to stop it
+	start := shellQuote("(" + command + ") & echo $!")
+	if s.User != "" {
+		start = "su -s /bin/sh " + shellQuote(s.User) + " -c " + start
+	} else {
+		start = "sh -c " + start
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          %[1]s
+# Required-Start:    $network $remote_fs
+# Required-Stop:     $network $remote_fs
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: %[2]s
+### END INIT INFO
+
+PIDFILE=/var/run/%[1]s.pid
+
+running() {
+	[ -f "$PIDFILE" ] && kill -0 "$(cat "$PIDFILE")" 2>/dev/null
+}
+
+case "$1" in
+	start)
+		if running; then
+			echo "%[1]s is already running"
+			exit 0
+		fi
+		%[3]s > "$PIDFILE"
+		echo "%[1]s started"
+		;;
+	stop)
+		if running; then
+			kill "$(cat "$PIDFILE")"
+		fi
+		rm -f "$PIDFILE"
+		echo "%[1]s stopped"
+		;;
+	restart)
+		"$0" stop
+		sleep 1
+		"$0" start
+		;;
+	status)
+		if running; then
+			echo "%[1]s is running with pid $(cat "$PIDFILE")"
+			exit 0
+		fi
+		echo "%[1]s is not running"
+		exit 3
+		;;
+	*)
+		echo "Usage: $0 {start|stop|restart|status}"
+		exit 1
+		;;
+esac
+`, s.Name, s.Description, start)
+	return buf.Bytes()
+}
+
+// InstallCommands returns the commands which install the rendered file of the service from
+// filename and start it now and at boot
+func InstallCommands(kind Kind, name string, filename string) [][]string {
+	path := Path(kind, name)
+	switch kind {
+	case Systemd:
+		return [][]string{
+			{"install", "-m", "0644", filename, path},
+			{"systemctl", "daemon-reload"},
+			{"systemctl", "enable", "--now", name},
+		}
+	case Launchd:
+		return [][]string{
+			{"install", "-m", "0644", filename, path},
+			{"launchctl", "bootstrap", "system", path},
+		}
+	case InitD:
+		return [][]string{
+			{"install", "-m", "0755", filename, path},
+			initdEnableCommand(name, true),
+			{path, "start"},
+		}
+	}
+	return nil
+}
+
+// UninstallCommands returns the commands which stop the service and remove it
+func UninstallCommands(kind Kind, name string) [][]string {
+	path := Path(kind, name)
+	switch kind {
+	case Systemd:
+		return [][]string{
+			{"systemctl", "disable", "--now", name},
+			{"rm", "-f", path},
+			{"systemctl", "daemon-reload"},
+		}
+	case Launchd:
+		return [][]string{
+			{"launchctl", "bootout", "system/" + launchdLabelPrefix + name},
+			{"rm", "-f", path},
+		}
+	case InitD:
+		return [][]string{
+			{path, "stop"},
+			initdEnableCommand(name, false),
+			{"rm", "-f", path},
+		}
+	}
+	return nil
+}
+
+// StatusCommand returns the command which shows the status of the service
+func StatusCommand(kind Kind, name string) []string {
+	switch kind {
+	case Systemd:
+		return []string{"systemctl", "status", "--no-pager", name}
+	case Launchd:
+		return []string{"launchctl", "print", "system/" + launchdLabelPrefix + name}
+	case InitD:
+		return []string{Path(kind, name), "status"}
+	}
+	return nil
+}
+
+// initdEnableCommand returns the command which adds or removes the init.d script from the boot
+// sequence with update-rc.d on Debian and chkconfig on Red Hat
+func initdEnableCommand(name string, enable bool) []string {
+	if _, err := os.Stat("/usr/sbin/update-rc.d"); err == nil {
+		if enable {
+			return []string{"update-rc.d", name, "defaults"}
+		}
+		return []string{"update-rc.d", "-f", name, "remove"}
+	}
+	if enable {
+		return []string{"chkconfig", "--add", name}
+	}
+	return []string{"chkconfig", "--del", name}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testService() Service {
+	return Service{
+		Name:        "agentuity-support",
+		Description: "Agentuity development server for support",
+		Command:     []string{"/usr/local/bin/agentuity", "dev", "--headless", "--dir", "/srv/my project"},
+		Dir:         "/srv/my project",
+		User:        "dev",
+		Env:         []string{"PATH=/usr/local/bin:/usr/bin", "HOME=/home/dev"},
+		LogFile:     "/srv/my project/.agentuity/service.log",
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	assert.NoError(t, ValidateName("agentuity-support.v2"))
+	for _, name := range []string{"", "Agentuity", "-support", "a/b", "a b"} {
+		assert.Error(t, ValidateName(name), name)
+	}
+	_, err := ParseKind("upstart")
+	assert.Error(t, err)
+	kind, err := ParseKind("launchd")
+	require.NoError(t, err)
+	assert.Equal(t, Launchd, kind)
+}
+
+func TestRenderSystemd(t *testing.T) {
+	buf, err := testService().Render(Systemd)
+	require.NoError(t, err)
+	unit := string(buf)
+	assert.Contains(t, unit, "Description=Agentuity development server for support\n")
+	assert.Contains(t, unit, "User=dev\n")
+	assert.Contains(t, unit, "WorkingDirectory=/srv/my project\n")
+	assert.Contains(t, unit, `Environment="HOME=/home/dev"`+"\n")
+	assert.Contains(t, unit, `ExecStart="/usr/local/bin/agentuity" "dev" "--headless" "--dir" "/srv/my project"`+"\n")
+	assert.Contains(t, unit, "WantedBy=multi-user.target\n")
+
+	assert.Equal(t, `"100%% \"done\""`, systemdQuote(`100% "done"`))
+}
+
+func TestRenderLaunchd(t *testing.T) {
+	s := testService()
+	s.Env = append(s.Env, "TOKEN=a<b&c")
+	buf, err := s.Render(Launchd)
+	require.NoError(t, err)
+	plist := string(buf)
+	assert.Contains(t, plist, "<string>com.agentuity.agentuity-support</string>")
+	assert.Contains(t, plist, "<string>--headless</string>")
+	assert.Contains(t, plist, "<key>UserName</key>\n\t<string>dev</string>")
+	assert.Contains(t, plist, "<key>TOKEN</key>\n\t\t<string>a&lt;b&amp;c</string>")
+	assert.Contains(t, plist, "<key>StandardOutPath</key>\n\t<string>/srv/my project/.agentuity/service.log</string>")
+}
+
+func TestRenderInitD(t *testing.T) {
+	s := testService()
+	buf, err := s.Render(InitD)
+	require.NoError(t, err)
+	script := string(buf)
+	assert.Contains(t, script, "# Provides:          agentuity-support\n")
+	assert.Contains(t, script, `su -s /bin/sh 'dev' -c '(cd '\''/srv/my project'\'' && exec nohup env`)
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the script")
+	}
+	// the script without a user runs the command in the background and keeps its pid
+	dir := t.TempDir()
+	s.User = ""
+	s.Dir = dir
+	s.Env = nil
+	s.Command = []string{"sh", "-c", "echo \"it's running\"; exec sleep 30"}
+	s.LogFile = filepath.Join(dir, "service.log")
+	buf, err = s.Render(InitD)
+	require.NoError(t, err)
+	script = strings.ReplaceAll(string(buf), "/var/run/agentuity-support.pid", filepath.Join(dir, "service.pid"))
+	filename := filepath.Join(dir, "service")
+	require.NoError(t, os.WriteFile(filename, []byte(script), 0755))
+	out, err := exec.Command(filename, "start").CombinedOutput()
+	require.NoError(t, err, string(out))
+	defer exec.Command(filename, "stop").Run()
+	out, err = exec.Command(filename, "status").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "agentuity-support is running")
+	out, err = exec.Command(filename, "stop").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Error(t, exec.Command(filename, "status").Run())
+	log, err := os.ReadFile(s.LogFile)
+	require.NoError(t, err)
+	assert.Equal(t, "it's running\n", string(log))
+}
+
+func TestRenderWithoutCommand(t *testing.T) {
+	_, err := Service{Name: "agentuity-support"}.Render(Systemd)
+	assert.Error(t, err)
+	_, err = Service{Name: "Bad Name", Command: []string{"/bin/true"}}.Render(Systemd)
+	assert.Error(t, err)
+}
+
+func TestCommands(t *testing.T) {
+	assert.Equal(t, [][]string{
+		{"install", "-m", "0644", "/tmp/unit", "/etc/systemd/system/agentuity-support.service"},
+		{"systemctl", "daemon-reload"},
+		{"systemctl", "enable", "--now", "agentuity-support"},
+	}, InstallCommands(Systemd, "agentuity-support", "/tmp/unit"))
+	assert.Equal(t, [][]string{
+		{"launchctl", "bootout", "system/com.agentuity.agentuity-support"},
+		{"rm", "-f", "/Library/LaunchDaemons/com.agentuity.agentuity-support.plist"},
+	}, UninstallCommands(Launchd, "agentuity-support"))
+	assert.Equal(t, []string{"/etc/init.d/agentuity-support", "status"}, StatusCommand(InitD, "agentuity-support"))
+}
@@ -0,0 +1,131 @@
+package templates
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentuity/cli/internal/util"
+)
+
+// The actions of the changes of a step
+const (
+	ChangeCreate = "create"
+	ChangeModify = "modify"
+	ChangeDelete = "delete"
+	ChangeRun    = "run"
+)
+
+// Change is a change which a step makes to the project
+type Change struct {
+	Action string
+	// Path is relative to the project directory with forward slashes or the command line for ChangeRun
+	Path string
+}
+
+// fileChange returns the change of a step which writes the file, which replaces the file when it exists
+func fileChange(ctx TemplateContext, filename string) Change {
+	action := ChangeCreate
+	if util.Exists(filename) {
+		action = ChangeModify
+	}
+	return Change{Action: action, Path: projectPath(ctx, filename)}
+}
+
+func projectPath(ctx TemplateContext, filename string) string {
+	rel, err := filepath.Rel(ctx.ProjectDir, filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (s *CommandStep) Plan(ctx TemplateContext) ([]Change, error) {
+	return []Change{{Action: ChangeRun, Path: strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))}}, nil
+}
+
+func (s *DeleteFileActionStep) Plan(ctx TemplateContext) ([]Change, error) {
+	var changes []Change
+	for _, file := range s.Files {
+		filename := filepath.Join(ctx.ProjectDir, localizePath(file))
+		if util.Exists(filename) {
+			changes = append(changes, Change{Action: ChangeDelete, Path: projectPath(ctx, filename)})
+		}
+	}
+	return changes, nil
+}
+
+func (s *ModifyPackageJsonStep) Plan(ctx TemplateContext) ([]Change, error) {
+	if !util.Exists(filepath.Join(ctx.ProjectDir, "package.json")) {
+		return nil, fmt.Errorf("package.json does not exist")
+	}
+	return []Change{{Action: ChangeModify, Path: "package.json"}}, nil
+}
+
+func (s *ModifyTsConfigStep) Plan(ctx TemplateContext) ([]Change, error) {
+	if !util.Exists(filepath.Join(ctx.ProjectDir, "tsconfig.json")) {
+		return nil, fmt.Errorf("tsconfig.json does not exist")
+	}
+	return []Change{{Action: ChangeModify, Path: "tsconfig.json"}}, nil
+}
+
+func (s *AppendFileStep) Plan(ctx TemplateContext) ([]Change, error) {
+	filename := filepath.Join(ctx.ProjectDir, localizePath(s.Filename))
+	if !util.Exists(filename) {
+		return nil, fmt.Errorf("%s does not exist", filename)
+	}
+	return []Change{{Action: ChangeModify, Path: projectPath(ctx, filename)}}, nil
+}
+
+func (s *CreateFileAction) Plan(ctx TemplateContext) ([]Change, error) {
+	return []Change{fileChange(ctx, filepath.Join(ctx.ProjectDir, localizePath(s.Filename)))}, nil
+}
+
+func (s *CopyFileAction) Plan(ctx TemplateContext) ([]Change, error) {
+	return []Change{fileChange(ctx, filepath.Join(ctx.ProjectDir, localizePath(s.To)))}, nil
+}
+
+func (s *CopyDirAction) Plan(ctx TemplateContext) ([]Change, error) {
+	from, err := getEmbeddedDir(filepath.Join(ctx.TemplateDir, embeddedPath(s.From)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedded file: %w", err)
+	}
+	dir := filepath.Join(ctx.ProjectDir, localizePath(s.To))
+	var changes []Change
+	for _, file := range from {
+		if s.Filter != "" {
+			matched, err := filepath.Match(s.Filter, file.Name())
+			if err != nil {
+				return nil, fmt.Errorf("failed to match filter: %w", err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		changes = append(changes, fileChange(ctx, filepath.Join(dir, localizePath(file.Name()))))
+	}
+	return changes, nil
+}
+
+func (s *CloneRepoAction) Plan(ctx TemplateContext) ([]Change, error) {
+	// the files of the repository aren't known until it is downloaded
+	return []Change{{Action: ChangeCreate, Path: projectPath(ctx, s.Todir) + "/"}}, nil
+}
+
+// PlanNewAgent returns the changes which NewAgent would make to the project without making them
+func (t *TemplateRules) PlanNewAgent(ctx TemplateContext) ([]Change, error) {
+	if ctx.Template == nil {
+		return nil, fmt.Errorf("template is nil and is required")
+	}
+	var changes []Change
+	for _, step := range t.NewAgentSteps.Steps {
+		if command, ok := resolveStep(ctx, step); ok {
+			stepChanges, err := command.Plan(ctx)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, stepChanges...)
+		}
+	}
+	return changes, nil
+}
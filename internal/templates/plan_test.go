@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPlanNewAgent(t *testing.T) {
+	projectDir := t.TempDir()
+	templateDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(templateDir, "common", "agent"), 0755))
+	for _, name := range []string{"index.ts", "prompt.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "common", "agent", name), []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "package.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte(""), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "src", "agents", "my-agent"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "src", "agents", "my-agent", "prompt.md"), []byte("x"), 0644))
+
+	var rules TemplateRules
+	require.NoError(t, yaml.Unmarshal([]byte(`new_agent:
+  steps:
+    - action: copy_dir
+      from: common/agent
+      to: src/agents/{{ .AgentName }}
+      filter: "*.ts"
+    - action: copy_dir
+      from: common/agent
+      to: src/agents/{{ .AgentName }}
+      filter: "*.md"
+    - action: create_file
+      filename: src/agents/{{ .AgentName }}/README.md
+      content: "# {{ .AgentName }}"
+    - action: append_file
+      filename: .gitignore
+      content: "dist"
+    - action: modify_package_json
+      main: index.js
+    - action: delete_file
+      files: ["missing.txt", ".gitignore"]
+    - command: bun
+      args: ["install"]
+`), &rules))
+
+	ctx := TemplateContext{AgentName: "my-agent", ProjectDir: projectDir, TemplateDir: templateDir, Template: &Template{Language: "javascript"}}
+	changes, err := rules.PlanNewAgent(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Action: ChangeCreate, Path: "src/agents/my-agent/index.ts"},
+		{Action: ChangeModify, Path: "src/agents/my-agent/prompt.md"},
+		{Action: ChangeCreate, Path: "src/agents/my-agent/README.md"},
+		{Action: ChangeModify, Path: ".gitignore"},
+		{Action: ChangeModify, Path: "package.json"},
+		{Action: ChangeDelete, Path: ".gitignore"},
+		{Action: ChangeRun, Path: "bun install"},
+	}, changes)
+
+	// nothing was written
+	assert.NoFileExists(t, filepath.Join(projectDir, "src", "agents", "my-agent", "index.ts"))
+	assert.FileExists(t, filepath.Join(projectDir, ".gitignore"))
+
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "package.json")))
+	_, err = rules.PlanNewAgent(ctx)
+	assert.EqualError(t, err, "package.json does not exist")
+}
@@ -0,0 +1,294 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/agentuity/cli/internal/util"
+	"github.com/agentuity/go-common/logger"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ManifestFilename is the name of the manifest file of a community template
+const ManifestFilename = "template.yaml"
+
+// Manifest describes a community template. The template directory must contain the manifest
+// along with the rules.yaml and templates.yaml files used by the runtime templates.
+type Manifest struct {
+	Template   `yaml:",inline"`
+	Version    string   `yaml:"version" json:"version"`
+	Authors    []string `yaml:"authors" json:"authors"`
+	Repository string   `yaml:"repository" json:"repository"`
+	Homepage   string   `yaml:"homepage" json:"homepage"`
+	License    string   `yaml:"license" json:"license"`
+	Keywords   []string `yaml:"keywords" json:"keywords"`
+}
+
+// PackagedTemplate is a community template which has been validated
+type PackagedTemplate struct {
+	Manifest  Manifest
+	Rules     TemplateRules
+	Templates LanguageTemplates
+}
+
+// ValidationError is returned when a community template fails validation
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("template is invalid: %s", strings.Join(e.Problems, "; "))
+}
+
+func decodeYAMLFile(filename string, v any) error {
+	of, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer of.Close()
+	if err := yaml.NewDecoder(of).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filepath.Base(filename), err)
+	}
+	return nil
+}
+
+func validateStep(ctx TemplateContext, step any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	if _, ok := resolveStep(ctx, step); !ok {
+		return fmt.Errorf("step must have a command or an action")
+	}
+	return nil
+}
+
+// ValidateTemplate loads and validates the community template in dir
+func ValidateTemplate(ctx context.Context, logger logger.Logger, dir string) (*PackagedTemplate, error) {
+	var pkg PackagedTemplate
+	if err := decodeYAMLFile(filepath.Join(dir, ManifestFilename), &pkg.Manifest); err != nil {
+		return nil, err
+	}
+	if err := decodeYAMLFile(filepath.Join(dir, "rules.yaml"), &pkg.Rules); err != nil {
+		return nil, err
+	}
+	if err := decodeYAMLFile(filepath.Join(dir, "templates.yaml"), &pkg.Templates); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	manifest := pkg.Manifest
+	if manifest.Name == "" {
+		problem("%s: name is required", ManifestFilename)
+	}
+	if manifest.Description == "" {
+		problem("%s: description is required", ManifestFilename)
+	}
+	if manifest.Identifier == "" {
+		problem("%s: identifier is required", ManifestFilename)
+	}
+	if manifest.Language == "" {
+		problem("%s: language is required", ManifestFilename)
+	}
+	if manifest.Version == "" {
+		problem("%s: version is required", ManifestFilename)
+	} else if _, err := semver.NewVersion(manifest.Version); err != nil {
+		problem("%s: version %s is not a valid semantic version", ManifestFilename, manifest.Version)
+	}
+	for _, requirement := range manifest.Requirements {
+		if requirement.Command == "" {
+			problem("%s: requirement is missing the command", ManifestFilename)
+		} else if _, err := semver.NewConstraint(requirement.Version); err != nil {
+			problem("%s: requirement %s has an invalid version constraint %q", ManifestFilename, requirement.Command, requirement.Version)
+		}
+	}
+
+	rules := pkg.Rules
+	if rules.Identifier != manifest.Identifier {
+		problem("rules.yaml: identifier %s does not match the manifest identifier %s", rules.Identifier, manifest.Identifier)
+	}
+	if rules.Language != manifest.Language {
+		problem("rules.yaml: language %s does not match the manifest language %s", rules.Language, manifest.Language)
+	}
+	if rules.Runtime == "" {
+		problem("rules.yaml: runtime is required")
+	}
+	if rules.SrcDir == "" {
+		problem("rules.yaml: src_dir is required")
+	}
+	if rules.Development.Command == "" {
+		problem("rules.yaml: development.command is required")
+	}
+	if rules.Deployment.Command == "" {
+		problem("rules.yaml: deployment.command is required")
+	}
+	for name, val := range map[string]string{"memory": rules.Deployment.Resources.Memory, "cpu": rules.Deployment.Resources.CPU, "disk": rules.Deployment.Resources.Disk} {
+		if val == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(val); err != nil {
+			problem("rules.yaml: deployment.resources.%s %q is invalid", name, val)
+		}
+	}
+
+	tctx := TemplateContext{
+		Context:     ctx,
+		Logger:      logger,
+		Name:        manifest.Name,
+		TemplateDir: dir,
+		ProjectDir:  dir,
+		Template:    &pkg.Manifest.Template,
+	}
+	for i, step := range rules.NewProjectSteps.Steps {
+		if err := validateStep(tctx, step); err != nil {
+			problem("rules.yaml: new_project step %d is invalid: %s", i+1, err)
+		}
+	}
+	for i, step := range rules.NewAgentSteps.Steps {
+		if err := validateStep(tctx, step); err != nil {
+			problem("rules.yaml: new_agent step %d is invalid: %s", i+1, err)
+		}
+	}
+
+	if len(pkg.Templates) == 0 {
+		problem("templates.yaml: at least one template is required")
+	}
+	seen := make(map[string]bool)
+	for _, t := range pkg.Templates {
+		if t.Name == "" {
+			problem("templates.yaml: template name is required")
+			continue
+		}
+		if seen[t.Name] {
+			problem("templates.yaml: template %s is defined more than once", t.Name)
+		}
+		seen[t.Name] = true
+		if t.Description == "" {
+			problem("templates.yaml: template %s is missing a description", t.Name)
+		}
+		for i, step := range t.Steps {
+			if err := validateStep(tctx, step); err != nil {
+				problem("templates.yaml: template %s step %d is invalid: %s", t.Name, i+1, err)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return &pkg, nil
+}
+
+type Response[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// PublishRequest is the request to publish a community template
+type PublishRequest struct {
+	OrgId       string   `json:"orgId"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Identifier  string   `json:"identifier"`
+	Language    string   `json:"language"`
+	Version     string   `json:"version"`
+	Tags        []string `json:"tags"`
+	Authors     []string `json:"authors"`
+	Repository  string   `json:"repository"`
+	Homepage    string   `json:"homepage"`
+	License     string   `json:"license"`
+	Keywords    []string `json:"keywords"`
+	Templates   []string `json:"templates"`
+}
+
+// PublishedTemplate is a community template version in the templates registry
+type PublishedTemplate struct {
+	ID         string `json:"id"`
+	Identifier string `json:"identifier"`
+	Version    string `json:"version"`
+	UploadURL  string `json:"uploadUrl"`
+	URL        string `json:"url"`
+}
+
+// NewPublishRequest returns the request to publish the template to the organization with the tags
+func NewPublishRequest(pkg *PackagedTemplate, orgId string, tags []string) PublishRequest {
+	req := PublishRequest{
+		OrgId:       orgId,
+		Name:        pkg.Manifest.Name,
+		Description: pkg.Manifest.Description,
+		Identifier:  pkg.Manifest.Identifier,
+		Language:    pkg.Manifest.Language,
+		Version:     pkg.Manifest.Version,
+		Tags:        tags,
+		Authors:     pkg.Manifest.Authors,
+		Repository:  pkg.Manifest.Repository,
+		Homepage:    pkg.Manifest.Homepage,
+		License:     pkg.Manifest.License,
+		Keywords:    pkg.Manifest.Keywords,
+	}
+	for _, t := range pkg.Templates {
+		req.Templates = append(req.Templates, t.Name)
+	}
+	return req
+}
+
+// PublishTemplate creates the template version in the templates registry and uploads the zip file
+func PublishTemplate(ctx context.Context, logger logger.Logger, baseUrl string, token string, req PublishRequest, zipfile string) (*PublishedTemplate, error) {
+	client := util.NewAPIClient(ctx, logger, baseUrl, token)
+	var resp Response[PublishedTemplate]
+	if err := client.Do("POST", "/cli/templates", req, &resp); err != nil {
+		return nil, fmt.Errorf("error publishing template: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("error publishing template: %s", resp.Message)
+	}
+	published := resp.Data
+
+	of, err := os.Open(zipfile)
+	if err != nil {
+		return nil, err
+	}
+	defer of.Close()
+	fi, err := of.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: this is a one-time signed url so we don't use the api client
+	upload, err := http.NewRequestWithContext(ctx, "PUT", util.TransformUrl(published.UploadURL), of)
+	if err != nil {
+		return nil, fmt.Errorf("error creating upload request: %w", err)
+	}
+	upload.ContentLength = fi.Size()
+	upload.Header.Set("Content-Type", "application/zip")
+	upload.Header.Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+	uploadResp, err := http.DefaultClient.Do(upload)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading template: %w", err)
+	}
+	uploadResp.Body.Close()
+	if uploadResp.StatusCode > 299 {
+		return nil, fmt.Errorf("error uploading template: %s", uploadResp.Status)
+	}
+
+	var completeResp Response[PublishedTemplate]
+	if err := client.Do("PUT", fmt.Sprintf("/cli/templates/%s/complete", published.ID), nil, &completeResp); err != nil {
+		return nil, fmt.Errorf("error completing template publish: %w", err)
+	}
+	if !completeResp.Success {
+		return nil, fmt.Errorf("error completing template publish: %s", completeResp.Message)
+	}
+	return &completeResp.Data, nil
+}
@@ -0,0 +1,99 @@
+package templates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `name: Deno
+description: Deno runtime with the Agentuity SDK
+identifier: deno
+language: javascript
+version: 1.0.0
+authors:
+  - Jane Doe <jane@example.com>
+requirements:
+  - command: deno
+    args: ["--version"]
+    version: ">=2.0.0"
+`
+
+const testRules = `identifier: deno
+runtime: deno
+language: javascript
+src_dir: src
+development:
+  command: deno
+  args: ["run", "-A", "index.ts"]
+deployment:
+  command: deno
+  args: ["run", "-A", "index.ts"]
+  resources:
+    memory: 250Mi
+    cpu: 500m
+    disk: 100Mi
+new_project:
+  steps:
+    - command: deno
+      args: ["init"]
+new_agent:
+  steps:
+    - action: create_file
+      filename: "src/agents/{{ .AgentName }}/index.ts"
+      template: agent.ts
+`
+
+const testTemplates = `- name: Default
+  description: A simple agent
+  steps:
+    - action: copy_file
+      from: common/index.ts
+      to: index.ts
+`
+
+func writeTestTemplate(t *testing.T, manifest, rules, templates string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFilename), []byte(manifest), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(rules), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "templates.yaml"), []byte(templates), 0644))
+	return dir
+}
+
+func TestValidateTemplate(t *testing.T) {
+	dir := writeTestTemplate(t, testManifest, testRules, testTemplates)
+	pkg, err := ValidateTemplate(context.Background(), logger.NewTestLogger(), dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "deno", pkg.Manifest.Identifier)
+	assert.Equal(t, "1.0.0", pkg.Manifest.Version)
+	assert.Len(t, pkg.Manifest.Requirements, 1)
+	assert.Len(t, pkg.Templates, 1)
+
+	req := NewPublishRequest(pkg, "org_123", []string{"latest"})
+	assert.Equal(t, "org_123", req.OrgId)
+	assert.Equal(t, []string{"Default"}, req.Templates)
+	assert.Equal(t, []string{"Jane Doe <jane@example.com>"}, req.Authors)
+}
+
+func TestValidateTemplateProblems(t *testing.T) {
+	manifest := "name: Deno\nidentifier: deno\nlanguage: javascript\nversion: one\n"
+	templates := "- name: Default\n  steps:\n    - action: launch_rockets\n"
+	dir := writeTestTemplate(t, manifest, testRules, templates)
+	_, err := ValidateTemplate(context.Background(), logger.NewTestLogger(), dir)
+	assert.Error(t, err)
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Contains(t, verr.Problems, "template.yaml: description is required")
+	assert.Contains(t, verr.Problems, "template.yaml: version one is not a valid semantic version")
+	assert.Contains(t, verr.Problems, "templates.yaml: template Default is missing a description")
+	assert.Contains(t, verr.Problems, "templates.yaml: template Default step 1 is invalid: unknown step action: launch_rockets")
+}
+
+func TestValidateTemplateMissingFiles(t *testing.T) {
+	_, err := ValidateTemplate(context.Background(), logger.NewTestLogger(), t.TempDir())
+	assert.Error(t, err)
+}
@@ -31,6 +31,8 @@ func embeddedPath(path string) string {
 
 type Step interface {
 	Run(ctx TemplateContext) error
+	// Plan returns the changes which Run would make without making them
+	Plan(ctx TemplateContext) ([]Change, error)
 }
 
 type CommandStep struct {
@@ -1375,7 +1375,7 @@ func ShowProjectUI(initial ProjectForm) ProjectForm {
 	finalModel := m.(projectFormModel)
 
 	if finalModel.quit {
-		os.Exit(1)
+		errsystem.ShowCancelledAndExit()
 	}
 
 	var provider *templates.Template
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+var aliasPlaceholder = regexp.MustCompile(`\$([1-9][0-9]*)`)
+
+// ParseAlias splits the expansion of an alias into the arguments of the command the way a shell would
+func ParseAlias(expansion string) ([]string, error) {
+	words, err := shellwords.Parse(strings.TrimSpace(expansion))
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias expansion %q: %w", expansion, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("the alias expansion is empty")
+	}
+	return words, nil
+}
+
+// ExpandAlias returns the arguments of the command of an alias invoked with the arguments. The
+// placeholders $1, $2, ... of the expansion are replaced with the arguments and the arguments
+// after the last placeholder are appended.
+func ExpandAlias(expansion string, args []string) ([]string, error) {
+	words, err := ParseAlias(expansion)
+	if err != nil {
+		return nil, err
+	}
+	var used int
+	for i, word := range words {
+		words[i] = aliasPlaceholder.ReplaceAllStringFunc(word, func(placeholder string) string {
+			n, _ := strconv.Atoi(placeholder[1:])
+			if n > len(args) {
+				if err == nil {
+					err = fmt.Errorf("the alias requires %s but %s provided", Pluralize(n, "argument", "arguments"), Pluralize(len(args), "argument was", "arguments were"))
+				}
+				return placeholder
+			}
+			used = max(used, n)
+			return args[n-1]
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(words, args[used:]...), nil
+}
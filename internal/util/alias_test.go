@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandAlias(t *testing.T) {
+	args, err := ExpandAlias("deploy --tag preview", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "--tag", "preview"}, args)
+
+	args, err = ExpandAlias("deploy --tag preview", []string{"--message", "fix the thing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "--tag", "preview", "--message", "fix the thing"}, args)
+
+	args, err = ExpandAlias(`agent test $1 --payload '{"name": "$2"}'`, []string{"my-agent", "Jane Doe", "--local"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent", "test", "my-agent", "--payload", `{"name": "Jane Doe"}`, "--local"}, args)
+
+	args, err = ExpandAlias("deploy --tag $2 --message $1", []string{"release", "v2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "--tag", "v2", "--message", "release"}, args)
+
+	args, err = ExpandAlias("env set FOO $0", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env", "set", "FOO", "$0"}, args)
+
+	_, err = ExpandAlias("deploy --tag $2", []string{"preview"})
+	assert.EqualError(t, err, "the alias requires 2 arguments but 1 argument was provided")
+
+	_, err = ExpandAlias("deploy --tag 'preview", nil)
+	assert.Error(t, err)
+
+	_, err = ExpandAlias("  ", nil)
+	assert.EqualError(t, err, "the alias expansion is empty")
+}
@@ -321,6 +321,12 @@ func run(ctx context.Context, c *cobra.Command, command string, args ...string)
 	if c.Flags().Changed("log-level") {
 		cmdargs = append(cmdargs, []string{"--log-level", c.Flag("log-level").Value.String()}...)
 	}
+	if c.Flags().Changed("log-file") {
+		cmdargs = append(cmdargs, []string{"--log-file", c.Flag("log-file").Value.String()}...)
+	}
+	if c.Flags().Changed("log-file-level") {
+		cmdargs = append(cmdargs, []string{"--log-file-level", c.Flag("log-file-level").Value.String()}...)
+	}
 	if c.Flags().Changed("config") {
 		cmdargs = append(cmdargs, []string{"--config", c.Flag("config").Value.String()}...)
 	}
@@ -343,7 +349,7 @@ func ShowLogin(ctx context.Context, logger logger.Logger, cmd *cobra.Command) {
 			run(ctx, cmd, "auth", "login")
 		} else {
 			fmt.Println(tui.Warning("Use " + tui.Command("agentuity login") + " to login to Agentuity"))
-			os.Exit(1)
+			os.Exit(ExitCodeAuth)
 		}
 	} else {
 		if tui.HasTTY {
@@ -365,7 +371,7 @@ func ShowLogin(ctx context.Context, logger logger.Logger, cmd *cobra.Command) {
 			}
 		} else {
 			fmt.Println(tui.Warning("Use " + tui.Command("agentuity auth signup") + " to create an account or " + tui.Command("agentuity login") + " to login to Agentuity"))
-			os.Exit(1)
+			os.Exit(ExitCodeAuth)
 		}
 	}
 }
@@ -386,30 +392,19 @@ func TryLoggedIn() (string, string, bool) {
 	return apikey, userId, true
 }
 
+// EnsureLoggedIn returns the api key and user id of the session. The session is refreshed (or the
+// user is asked to login) when it has expired or would expire before the command is done, see
+// SessionValidityAnnotation.
 func EnsureLoggedIn(ctx context.Context, logger logger.Logger, cmd *cobra.Command) (string, string) {
-	apikey := viper.GetString("auth.api_key")
-	if apikey == "" {
-		ShowLogin(ctx, logger, cmd)
-		os.Exit(1)
-	}
-	userId := viper.GetString("auth.user_id")
-	if userId == "" {
-		ShowLogin(ctx, logger, cmd)
-		os.Exit(1)
-	}
-	expires := viper.GetInt64("auth.expires")
-	if expires < time.Now().UnixMilli() {
-		ShowLogin(ctx, logger, cmd)
-		os.Exit(1)
-	}
-	return apikey, userId
+	s := ensureSession(ctx, logger, cmd)
+	return s.APIKey, s.UserId
 }
 
 func EnsureLoggedInWithOnlyAPIKey(ctx context.Context, logger logger.Logger, cmd *cobra.Command) string {
 	apikey := viper.GetString("auth.api_key")
 	if apikey == "" {
 		ShowLogin(ctx, logger, cmd)
-		os.Exit(1)
+		os.Exit(ExitCodeAuth)
 	}
 	return apikey
 }
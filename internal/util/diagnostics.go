@@ -0,0 +1,74 @@
+package util
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ToolVersion is the version of an external tool installed on the machine
+type ToolVersion struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Installed returns true if the tool was found on the PATH
+func (t ToolVersion) Installed() bool {
+	return t.Path != ""
+}
+
+// DiagnosticTools are the tools reported by the CLI diagnostics. The first command found on the
+// PATH is used for each tool.
+var DiagnosticTools = []struct {
+	Name     string
+	Commands []string
+}{
+	{"node", []string{"node"}},
+	{"bun", []string{"bun"}},
+	{"uv", []string{"uv"}},
+	{"python", []string{"python3", "python"}},
+}
+
+var toolVersionRegex = regexp.MustCompile(`v?(\d+\.\d+(\.\d+)?([-+.][0-9A-Za-z.-]+)?)`)
+
+// parseToolVersion returns the version number from the output of a tool's --version command
+func parseToolVersion(output string) string {
+	output = strings.TrimSpace(output)
+	if m := toolVersionRegex.FindStringSubmatch(output); len(m) > 1 {
+		return m[1]
+	}
+	if idx := strings.IndexByte(output, '\n'); idx > 0 {
+		return output[:idx]
+	}
+	return output
+}
+
+// GetToolVersions returns the versions of the diagnostic tools installed on the machine
+func GetToolVersions(ctx context.Context) []ToolVersion {
+	var tools []ToolVersion
+	for _, tool := range DiagnosticTools {
+		tv := ToolVersion{Name: tool.Name}
+		for _, name := range tool.Commands {
+			if path, err := exec.LookPath(name); err == nil {
+				tv.Path = path
+				break
+			}
+		}
+		if tv.Path != "" {
+			c, cancel := context.WithTimeout(ctx, 5*time.Second)
+			out, err := exec.CommandContext(c, tv.Path, "--version").CombinedOutput()
+			cancel()
+			if err != nil {
+				tv.Error = err.Error()
+			} else {
+				tv.Version = parseToolVersion(string(out))
+			}
+		}
+		tools = append(tools, tv)
+	}
+	return tools
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseToolVersion(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"v22.11.0\n", "22.11.0"},
+		{"1.2.5", "1.2.5"},
+		{"uv 0.5.11 (c4d0caaee 2024-12-19)", "0.5.11"},
+		{"Python 3.12.8", "3.12.8"},
+		{"1.2.0-canary.20250101", "1.2.0-canary.20250101"},
+		{"unknown\nsecond line", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseToolVersion(tt.output))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package util
+
+// The exit codes of the CLI are a stable contract so that scripts and CI can branch on the kind of
+// failure. New codes may be added but the meaning of these codes doesn't change.
+const (
+	// ExitCodeGeneral is an error which doesn't belong to another category
+	ExitCodeGeneral = 1
+	// ExitCodeValidation is an invalid flag, argument, configuration or project
+	ExitCodeValidation = 2
+	// ExitCodeAuth is a missing or expired login or a missing permission
+	ExitCodeAuth = 3
+	// ExitCodeAPI is a failed request to the Agentuity API
+	ExitCodeAPI = 4
+	// ExitCodePartial is an operation on several items where some of them failed
+	ExitCodePartial = 5
+	// ExitCodeAgentClientError is an agent which responded with a 4xx status, such as to agent test
+	ExitCodeAgentClientError = 6
+	// ExitCodeAgentServerError is an agent which responded with a 5xx status
+	ExitCodeAgentServerError = 7
+	// ExitCodeCancelled is an operation cancelled by the user, such as with Ctrl+C
+	ExitCodeCancelled = 130
+)
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"path/filepath"
 )
 
@@ -62,8 +61,8 @@ func CopyDir(src string, dst string) error {
 		return fmt.Errorf("error readdir %s: %w", src, err)
 	}
 	for _, fd := range fds {
-		srcfp := path.Join(src, fd.Name())
-		dstfp := path.Join(dst, fd.Name())
+		srcfp := filepath.Join(src, fd.Name())
+		dstfp := filepath.Join(dst, fd.Name())
 
 		if fd.IsDir() {
 			if err = CopyDir(srcfp, dstfp); err != nil {
@@ -166,20 +165,9 @@ func ZipDir(dir string, outfilename string, opts ...Option) error {
 				}
 			}
 		}
-		rf, err := os.Open(file)
-		if err != nil {
-			return fmt.Errorf("error opening file: %s. %w", file, err)
-		}
-		defer rf.Close()
-		w, err := zw.Create(fn)
-		if err != nil {
-			return fmt.Errorf("error creating file: %s. %w", fn, err)
+		if err := zipFile(zw, file, fn); err != nil {
+			return err
 		}
-		_, err = io.Copy(w, rf)
-		if err != nil {
-			return fmt.Errorf("error copying file: %s. %w", file, err)
-		}
-		rf.Close()
 	}
 	if options.mutator != nil {
 		if err := options.mutator(zw); err != nil {
@@ -191,6 +179,24 @@ func ZipDir(dir string, outfilename string, opts ...Option) error {
 	return zf.Close()
 }
 
+// zipFile adds the file to the zip as name. Zip entries always use forward slashes and the file
+// is closed right away so that it isn't held open (and locked on Windows) while the zip is written.
+func zipFile(zw *zip.Writer, file string, name string) error {
+	rf, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("error opening file: %s. %w", file, err)
+	}
+	defer rf.Close()
+	w, err := zw.Create(filepath.ToSlash(name))
+	if err != nil {
+		return fmt.Errorf("error creating file: %s. %w", name, err)
+	}
+	if _, err := io.Copy(w, rf); err != nil {
+		return fmt.Errorf("error copying file: %s. %w", file, err)
+	}
+	return nil
+}
+
 func ReadFileLines(filename string, startLine, endLine int) ([]string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"archive/zip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -226,3 +227,40 @@ func TestGetRelativePath(t *testing.T) {
 		})
 	}
 }
+
+func TestZipDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "agents", "my-agent"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src", "agents", "my-agent", "index.ts"), []byte("export {}"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644))
+
+	outfile := filepath.Join(t.TempDir(), "out.zip")
+	err := ZipDir(dir, outfile, WithMatcher(func(fn string, fi os.FileInfo) bool {
+		return fn != "package.json"
+	}))
+	assert.NoError(t, err)
+
+	zr, err := zip.OpenReader(outfile)
+	assert.NoError(t, err)
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	// zip entries always use forward slashes regardless of the platform
+	assert.Equal(t, []string{"src/agents/my-agent/index.ts"}, names)
+}
+
+func TestRemove(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.MkdirAll(filepath.Join(sub, "nested"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(sub, "nested", "file.txt"), []byte("file"), 0644))
+
+	assert.Error(t, Remove(sub))
+	assert.NoError(t, Remove(filepath.Join(sub, "nested", "file.txt")))
+	assert.NoError(t, RemoveAll(sub))
+	assert.False(t, Exists(sub))
+	assert.NoError(t, RemoveAll(sub))
+	assert.True(t, os.IsNotExist(Remove(sub)))
+}
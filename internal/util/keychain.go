@@ -0,0 +1,77 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errKeychainUnavailable is returned when the platform has no keychain the CLI can use
+var errKeychainUnavailable = errors.New("no keychain available")
+
+// keychainService is the service of the secrets the CLI stores in the keychain
+const keychainService = "agentuity-cli"
+
+// keychainName returns the name of the keychain of the platform
+func keychainName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS keychain"
+	case "linux":
+		return "secret service"
+	}
+	return ""
+}
+
+// keychainGet returns the secret of the account from the keychain of the platform using the
+// security command on macOS and secret-tool on Linux
+var keychainGet = func(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case "linux":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return "", errKeychainUnavailable
+		}
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	default:
+		return "", errKeychainUnavailable
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the %s: %w %s", account, keychainName(), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// keychainSet stores the secret of the account in the keychain of the platform, replacing the
+// existing one. The secret is written to the stdin of the command so it isn't visible in the process
+// list.
+var keychainSet = func(account string, secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security prompts for the password, and then for it again to confirm, when -w is the last
+		// option without a value
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-w")
+		cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	case "linux":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return errKeychainUnavailable
+		}
+		cmd = exec.Command("secret-tool", "store", "--label", "Agentuity CLI "+account, "service", keychainService, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return errKeychainUnavailable
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store %s in the %s: %w %s", account, keychainName(), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
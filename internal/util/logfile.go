@@ -0,0 +1,187 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/agentuity/go-common/env"
+	"github.com/agentuity/go-common/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultLogFile is the log file used when --log-file is passed without a value. It is relative to the project directory.
+	DefaultLogFile = ".agentuity/logs/agentuity.log"
+
+	defaultLogFileMaxSize    = 10 * 1024 * 1024
+	defaultLogFileMaxBackups = 5
+)
+
+// RotatingFileWriter is an io.Writer which writes newline delimited records to a file
+// and rotates the file once it reaches the maximum size.
+type RotatingFileWriter struct {
+	filename   string
+	maxSize    int64
+	maxBackups int
+	size       int64
+	file       *os.File
+	lock       sync.Mutex
+}
+
+// NewRotatingFileWriter opens (or creates) filename for appending. When a write would grow the file
+// beyond maxSize bytes the file is rotated, keeping at most maxBackups previous files (filename.1 being the newest).
+func NewRotatingFileWriter(filename string, maxSize int64, maxBackups int) (*RotatingFileWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, fmt.Errorf("error creating log directory: %w", err)
+	}
+	w := &RotatingFileWriter{
+		filename:   filename,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) open() error {
+	of, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+	fi, err := of.Stat()
+	if err != nil {
+		of.Close()
+		return fmt.Errorf("error reading log file: %w", err)
+	}
+	w.file = of
+	w.size = fi.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	for i := w.maxBackups; i > 0; i-- {
+		src := w.filename
+		if i > 1 {
+			src = fmt.Sprintf("%s.%d", w.filename, i-1)
+		}
+		if !Exists(src) {
+			continue
+		}
+		if err := os.Rename(src, fmt.Sprintf("%s.%d", w.filename, i)); err != nil {
+			return fmt.Errorf("error rotating log file: %w", err)
+		}
+	}
+	if w.maxBackups <= 0 {
+		os.Remove(w.filename)
+	}
+	return w.open()
+}
+
+// Write writes p to the file as a single record, appending a newline if p doesn't end with one.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	buf := p
+	if len(buf) == 0 || buf[len(buf)-1] != '\n' {
+		buf = append(append(make([]byte, 0, len(p)+1), p...), '\n')
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(buf)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(buf)
+	w.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
+// Close closes the underlying file.
+func (w *RotatingFileWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file.Close()
+}
+
+var (
+	logFileOnce   sync.Once
+	logFileLogger logger.Logger
+)
+
+func parseLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(level) {
+	case "trace":
+		return logger.LevelTrace
+	case "debug":
+		return logger.LevelDebug
+	case "warn":
+		return logger.LevelWarn
+	case "error":
+		return logger.LevelError
+	}
+	return logger.LevelInfo
+}
+
+// ResolveLogFilename returns the absolute path of the structured log file for the command
+// or an empty string if file logging hasn't been requested.
+func ResolveLogFilename(cmd *cobra.Command) string {
+	filename := env.FlagOrEnv(cmd, "log-file", "AGENTUITY_LOG_FILE", "")
+	if filename == "" {
+		return ""
+	}
+	if !filepath.IsAbs(filename) {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		filename = filepath.Join(dir, filename)
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	return filename
+}
+
+func openLogFile(cmd *cobra.Command) {
+	filename := ResolveLogFilename(cmd)
+	if filename == "" {
+		return
+	}
+	w, err := NewRotatingFileWriter(filename, defaultLogFileMaxSize, defaultLogFileMaxBackups)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open log file %s: %s\n", filename, err)
+		return
+	}
+	level := parseLogLevel(env.FlagOrEnv(cmd, "log-file-level", "AGENTUITY_LOG_FILE_LEVEL", "debug"))
+	logFileLogger = logger.NewJSONLoggerWithSink(w, level).With(map[string]interface{}{
+		"command": cmd.CommandPath(),
+		"pid":     os.Getpid(),
+		"version": Version,
+	})
+}
+
+// FileLogger returns the structured file logger if --log-file was provided, otherwise nil.
+func FileLogger() logger.Logger {
+	return logFileLogger
+}
+
+// NewLogger returns a console logger for the command. If --log-file (or AGENTUITY_LOG_FILE) is set,
+// every log line is also written as JSON to the rotating log file.
+func NewLogger(cmd *cobra.Command) logger.Logger {
+	log := env.NewLogger(cmd)
+	logFileOnce.Do(func() { openLogFile(cmd) })
+	if logFileLogger != nil {
+		return log.Stack(logFileLogger)
+	}
+	return log
+}
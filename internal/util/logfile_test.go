@@ -0,0 +1,55 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	tmpDir := t.TempDir()
+	filename := filepath.Join(tmpDir, "logs", "agentuity.log")
+
+	w, err := NewRotatingFileWriter(filename, 20, 2)
+	assert.NoError(t, err)
+	defer w.Close()
+
+	for _, line := range []string{"first record", "second record", "third record", "fourth record"} {
+		n, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+
+	buf, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "fourth record\n", string(buf))
+
+	buf, err = os.ReadFile(filename + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "third record\n", string(buf))
+
+	buf, err = os.ReadFile(filename + ".2")
+	assert.NoError(t, err)
+	assert.Equal(t, "second record\n", string(buf))
+
+	assert.False(t, Exists(filename+".3"))
+}
+
+func TestRotatingFileWriterAppends(t *testing.T) {
+	tmpDir := t.TempDir()
+	filename := filepath.Join(tmpDir, "agentuity.log")
+	assert.NoError(t, os.WriteFile(filename, []byte("existing\n"), 0600))
+
+	w, err := NewRotatingFileWriter(filename, 1024, 1)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("new\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	buf, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"existing", "new", ""}, strings.Split(string(buf), "\n"))
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"slices"
+
+	"github.com/spf13/viper"
+)
+
+// The steps of the onboarding of a new user
+const (
+	OnboardingLogin        = "login"
+	OnboardingOrganization = "organization"
+	OnboardingProject      = "project"
+	OnboardingAgent        = "agent"
+	OnboardingTest         = "test"
+	OnboardingDeploy       = "deploy"
+)
+
+// OnboardingSteps are the steps of the onboarding in the order they run
+var OnboardingSteps = []string{
+	OnboardingLogin,
+	OnboardingOrganization,
+	OnboardingProject,
+	OnboardingAgent,
+	OnboardingTest,
+	OnboardingDeploy,
+}
+
+// OnboardingProgress is the progress of the onboarding which is stored in the configuration so
+// that it can be resumed
+type OnboardingProgress struct {
+	Completed  []string
+	ProjectDir string
+}
+
+// CurrentOnboarding returns the progress of the onboarding from the configuration
+func CurrentOnboarding() OnboardingProgress {
+	return OnboardingProgress{
+		Completed:  viper.GetStringSlice("onboarding.completed"),
+		ProjectDir: viper.GetString("onboarding.project_dir"),
+	}
+}
+
+// SaveOnboarding stores the progress of the onboarding in the configuration
+func SaveOnboarding(p OnboardingProgress) error {
+	viper.Set("onboarding.completed", p.Completed)
+	viper.Set("onboarding.project_dir", p.ProjectDir)
+	return viper.WriteConfig()
+}
+
+// Done returns true if the step has been completed
+func (p OnboardingProgress) Done(step string) bool {
+	return slices.Contains(p.Completed, step)
+}
+
+// Complete marks the step as completed
+func (p *OnboardingProgress) Complete(step string) {
+	if !p.Done(step) {
+		p.Completed = append(p.Completed, step)
+	}
+}
+
+// ResetFrom marks the step and the steps after it as not completed so that they run again
+func (p *OnboardingProgress) ResetFrom(step string) {
+	index := slices.Index(OnboardingSteps, step)
+	if index < 0 {
+		return
+	}
+	p.Completed = slices.DeleteFunc(p.Completed, func(s string) bool {
+		return slices.Index(OnboardingSteps, s) >= index
+	})
+}
+
+// Next returns the first step which hasn't been completed or an empty string when the onboarding is finished
+func (p OnboardingProgress) Next() string {
+	for _, step := range OnboardingSteps {
+		if !p.Done(step) {
+			return step
+		}
+	}
+	return ""
+}
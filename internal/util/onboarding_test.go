@@ -0,0 +1,51 @@
+package util
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingProgress(t *testing.T) {
+	var p OnboardingProgress
+	assert.Equal(t, OnboardingLogin, p.Next())
+	p.Complete(OnboardingLogin)
+	p.Complete(OnboardingLogin)
+	assert.Equal(t, []string{OnboardingLogin}, p.Completed)
+	p.Complete(OnboardingOrganization)
+	p.Complete(OnboardingProject)
+	assert.Equal(t, OnboardingAgent, p.Next())
+
+	// the steps after the reset step depend on it so they run again
+	p.Complete(OnboardingAgent)
+	p.Complete(OnboardingTest)
+	p.ResetFrom(OnboardingProject)
+	assert.Equal(t, []string{OnboardingLogin, OnboardingOrganization}, p.Completed)
+	assert.Equal(t, OnboardingProject, p.Next())
+	p.ResetFrom("unknown")
+	assert.Len(t, p.Completed, 2)
+
+	for _, step := range OnboardingSteps {
+		p.Complete(step)
+	}
+	assert.Empty(t, p.Next())
+}
+
+func TestSaveOnboarding(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(configFile)
+	require.NoError(t, SaveOnboarding(OnboardingProgress{Completed: []string{OnboardingLogin}, ProjectDir: "/tmp/my-project"}))
+
+	viper.Reset()
+	viper.SetConfigFile(configFile)
+	require.NoError(t, viper.ReadInConfig())
+	p := CurrentOnboarding()
+	assert.Equal(t, []string{OnboardingLogin}, p.Completed)
+	assert.Equal(t, "/tmp/my-project", p.ProjectDir)
+	assert.Equal(t, OnboardingOrganization, p.Next())
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressInterval is how often the progress of a transfer is reported
+const ProgressInterval = 100 * time.Millisecond
+
+// Progress is the progress of an upload or a download
+type Progress struct {
+	// Done is the number of bytes transferred
+	Done int64
+	// Total is the number of bytes to transfer, zero if unknown
+	Total   int64
+	Elapsed time.Duration
+	// Finished is true for the last report once the transfer is done
+	Finished bool
+}
+
+// Rate returns the average number of bytes transferred per second
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done) / p.Elapsed.Seconds()
+}
+
+// ETA returns the estimated time until the transfer is done at the average rate, zero if unknown
+func (p Progress) ETA() time.Duration {
+	rate := p.Rate()
+	if rate <= 0 || p.Total <= 0 || p.Done >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Total-p.Done) / rate * float64(time.Second))
+}
+
+// Percent returns the percentage of the bytes transferred between 0 and 100, zero if the total is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(100, float64(p.Done)*100/float64(p.Total))
+}
+
+// Bar returns a progress bar of width characters such as [=====>    ]
+func (p Progress) Bar(width int) string {
+	if width < 3 {
+		return ""
+	}
+	inner := width - 2
+	filled := int(p.Percent() * float64(inner) / 100)
+	var sb strings.Builder
+	sb.WriteString("[")
+	sb.WriteString(strings.Repeat("=", filled))
+	if filled < inner {
+		sb.WriteString(">")
+		sb.WriteString(strings.Repeat(" ", inner-filled-1))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// ProgressTracker counts the bytes transferred by the readers it wraps and reports the progress at
+// most once per ProgressInterval. A nil tracker does nothing.
+type ProgressTracker struct {
+	total    int64
+	report   func(Progress)
+	started  time.Time
+	done     int64
+	reported time.Time
+	lock     sync.Mutex
+}
+
+// NewProgressTracker returns a tracker of a transfer of total bytes which calls report with the
+// progress, or nil if report is nil
+func NewProgressTracker(total int64, report func(Progress)) *ProgressTracker {
+	if report == nil {
+		return nil
+	}
+	return &ProgressTracker{total: total, report: report, started: time.Now()}
+}
+
+// Add counts n more bytes transferred
+func (t *ProgressTracker) Add(n int64) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.lock.Lock()
+	t.done += n
+	now := time.Now()
+	if now.Sub(t.reported) < ProgressInterval {
+		t.lock.Unlock()
+		return
+	}
+	t.reported = now
+	p := Progress{Done: t.done, Total: t.total, Elapsed: now.Sub(t.started)}
+	t.lock.Unlock()
+	t.report(p)
+}
+
+// Finish reports the final progress
+func (t *ProgressTracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	p := Progress{Done: t.done, Total: t.total, Elapsed: time.Since(t.started), Finished: true}
+	t.lock.Unlock()
+	t.report(p)
+}
+
+// Reader returns a reader which counts the bytes read from r
+func (t *ProgressTracker) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &progressReader{r, t}
+}
+
+type progressReader struct {
+	reader  io.Reader
+	tracker *ProgressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.tracker.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	p := Progress{Done: 25, Total: 100, Elapsed: 5 * time.Second}
+	assert.Equal(t, 5.0, p.Rate())
+	assert.Equal(t, 15*time.Second, p.ETA())
+	assert.Equal(t, 25.0, p.Percent())
+	assert.Equal(t, "[==>       ]", p.Bar(12))
+
+	p.Done = 100
+	assert.Equal(t, time.Duration(0), p.ETA())
+	assert.Equal(t, "[==========]", p.Bar(12))
+
+	unknown := Progress{Done: 10}
+	assert.Equal(t, 0.0, unknown.Rate())
+	assert.Equal(t, time.Duration(0), unknown.ETA())
+	assert.Equal(t, 0.0, unknown.Percent())
+	assert.Equal(t, "[>   ]", unknown.Bar(6))
+}
+
+func TestProgressTracker(t *testing.T) {
+	var reports []Progress
+	tracker := NewProgressTracker(3000, func(p Progress) {
+		reports = append(reports, p)
+	})
+	data := make([]byte, 1000)
+	for range 3 {
+		_, err := io.Copy(io.Discard, tracker.Reader(bytes.NewReader(data)))
+		require.NoError(t, err)
+	}
+	tracker.Finish()
+
+	// the reports are throttled so only the first one and the final one are made right away
+	require.Len(t, reports, 2)
+	assert.Equal(t, int64(1000), reports[0].Done)
+	assert.False(t, reports[0].Finished)
+	assert.Equal(t, int64(3000), reports[1].Done)
+	assert.Equal(t, int64(3000), reports[1].Total)
+	assert.True(t, reports[1].Finished)
+
+	var nilTracker *ProgressTracker
+	assert.Nil(t, NewProgressTracker(10, nil))
+	r := bytes.NewReader(data)
+	assert.Equal(t, r, nilTracker.Reader(r))
+	nilTracker.Add(10)
+	nilTracker.Finish()
+}
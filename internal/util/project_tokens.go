@@ -0,0 +1,285 @@
+package util
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// projectTokensAccount is the keychain account of the key which encrypts the project tokens
+const projectTokensAccount = "project-tokens"
+
+// ProjectTokensKeyFileConfig is the configuration key which allows the key of the project tokens to
+// be stored in a file when there is no keychain
+const ProjectTokensKeyFileConfig = "auth.project_tokens_key_file"
+
+// ProjectTokensKeyFileStore is the KeyStore of the project tokens when their key is in a file
+const ProjectTokensKeyFileStore = "key file"
+
+// errProjectTokensNoKeychain is returned when there is no keychain to store the key of the project
+// tokens and storing it in a file wasn't allowed
+var errProjectTokensNoKeychain = fmt.Errorf("no keychain is available to store the key of the project tokens, set %s to true in the configuration to store it in a file next to the tokens instead", ProjectTokensKeyFileConfig)
+
+// errProjectTokensDecrypt is returned when the project tokens can't be decrypted with the key
+var errProjectTokensDecrypt = errors.New("failed to decrypt the project tokens")
+
+// ProjectToken is the credential cached for a project so that the project keeps using the account
+// it was last used with
+type ProjectToken struct {
+	ProjectId string    `json:"projectId"`
+	Dir       string    `json:"dir,omitempty"`
+	UserId    string    `json:"userId"`
+	APIKey    string    `json:"apiKey"`
+	Expires   time.Time `json:"expires"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ValidFor returns true if the token is valid for at least d
+func (t ProjectToken) ValidFor(d time.Duration) bool {
+	return t.APIKey != "" && t.UserId != "" && time.Now().Add(d).Before(t.Expires)
+}
+
+// ProjectTokens is the local cache of the project tokens keyed by project id. The cache is stored
+// encrypted with a key kept in the keychain of the platform, or in a key file readable only by the
+// user when there is none and ProjectTokensKeyFileConfig is set.
+type ProjectTokens struct {
+	// KeyStore is where the encryption key is stored
+	KeyStore string
+	filename string
+	key      []byte
+	tokens   map[string]ProjectToken
+}
+
+// ProjectTokensFilename returns the file of the project tokens of the current configuration, so
+// each profile has its own tokens
+func ProjectTokensFilename() string {
+	cfg := viper.ConfigFileUsed()
+	if cfg == "" {
+		return ""
+	}
+	return strings.TrimSuffix(cfg, filepath.Ext(cfg)) + ".tokens"
+}
+
+// LoadProjectTokens returns the project tokens of the file decrypted with the key, which are empty
+// when the file doesn't exist
+func LoadProjectTokens(filename string, key []byte) (*ProjectTokens, error) {
+	t := &ProjectTokens{filename: filename, key: key, tokens: make(map[string]ProjectToken)}
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return t, nil
+		}
+		return nil, fmt.Errorf("failed to read the project tokens: %w", err)
+	}
+	gcm, err := projectTokensCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < gcm.NonceSize() {
+		return nil, errProjectTokensDecrypt
+	}
+	plaintext, err := gcm.Open(nil, buf[:gcm.NonceSize()], buf[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errProjectTokensDecrypt
+	}
+	if err := json.Unmarshal(plaintext, &t.tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode the project tokens: %w", err)
+	}
+	return t, nil
+}
+
+// OpenProjectTokens returns the project tokens of the current configuration. The tokens can't be
+// recovered when the key was removed from the keychain so the cache starts again empty.
+func OpenProjectTokens() (*ProjectTokens, error) {
+	filename := ProjectTokensFilename()
+	if filename == "" {
+		return nil, fmt.Errorf("no configuration file to store the project tokens next to")
+	}
+	key, store, err := projectTokensKey(filename)
+	if err != nil {
+		return nil, err
+	}
+	t, err := LoadProjectTokens(filename, key)
+	if errors.Is(err, errProjectTokensDecrypt) {
+		t, err = &ProjectTokens{filename: filename, key: key, tokens: make(map[string]ProjectToken)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.KeyStore = store
+	return t, nil
+}
+
+// ClearProjectTokens removes the project tokens of the current configuration
+func ClearProjectTokens() error {
+	filename := ProjectTokensFilename()
+	if filename == "" {
+		return nil
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Get returns the token of the project
+func (t *ProjectTokens) Get(projectId string) (ProjectToken, bool) {
+	token, ok := t.tokens[projectId]
+	return token, ok
+}
+
+// Set adds or replaces the token of its project
+func (t *ProjectTokens) Set(token ProjectToken) {
+	t.tokens[token.ProjectId] = token
+}
+
+// Remove removes the token of the project and returns false if there was none
+func (t *ProjectTokens) Remove(projectId string) bool {
+	if _, ok := t.tokens[projectId]; !ok {
+		return false
+	}
+	delete(t.tokens, projectId)
+	return true
+}
+
+// List returns the tokens sorted by directory and project id
+func (t *ProjectTokens) List() []ProjectToken {
+	tokens := make([]ProjectToken, 0, len(t.tokens))
+	for _, token := range t.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Dir == tokens[j].Dir {
+			return tokens[i].ProjectId < tokens[j].ProjectId
+		}
+		return tokens[i].Dir < tokens[j].Dir
+	})
+	return tokens
+}
+
+// Save encrypts the tokens and writes them to the file, which only the user can read
+func (t *ProjectTokens) Save() error {
+	plaintext, err := json.Marshal(t.tokens)
+	if err != nil {
+		return err
+	}
+	gcm, err := projectTokensCipher(t.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	tmp := t.filename + ".tmp"
+	if err := os.WriteFile(tmp, gcm.Seal(nonce, nonce, plaintext, nil), 0600); err != nil {
+		return fmt.Errorf("failed to write the project tokens: %w", err)
+	}
+	if err := os.Rename(tmp, t.filename); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write the project tokens: %w", err)
+	}
+	return nil
+}
+
+func projectTokensCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project tokens key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// projectTokensKey returns the key which encrypts the project tokens and where it's stored. The
+// key is created the first time in the keychain of the platform. A key file next to the tokens is
+// only used when there is no keychain and ProjectTokensKeyFileConfig is set, since anyone who can
+// read the tokens can then also read their key.
+func projectTokensKey(filename string) ([]byte, string, error) {
+	if secret, err := keychainGet(projectTokensAccount); err == nil {
+		if key, err := hex.DecodeString(secret); err == nil && len(key) == 32 {
+			return key, keychainName(), nil
+		}
+	}
+	allowKeyFile := viper.GetBool(ProjectTokensKeyFileConfig)
+	keyfile := filename + ".key"
+	if buf, err := os.ReadFile(keyfile); err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(buf)))
+		if err != nil || len(key) != 32 {
+			return nil, "", fmt.Errorf("invalid project tokens key in %s", keyfile)
+		}
+		if !allowKeyFile {
+			return nil, "", errProjectTokensNoKeychain
+		}
+		return key, ProjectTokensKeyFileStore, nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", err
+	}
+	err := keychainSet(projectTokensAccount, hex.EncodeToString(key))
+	if err == nil {
+		return key, keychainName(), nil
+	}
+	if !allowKeyFile {
+		if errors.Is(err, errKeychainUnavailable) {
+			return nil, "", errProjectTokensNoKeychain
+		}
+		return nil, "", err
+	}
+	if err := os.WriteFile(keyfile, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, "", fmt.Errorf("failed to write the project tokens key: %w", err)
+	}
+	return key, ProjectTokensKeyFileStore, nil
+}
+
+// EnsureLoggedInForProject returns the api key and user id to use for the project. The token
+// cached for the project is used while it's valid when the session is of another account or has
+// expired, so that working on projects of several accounts doesn't require logging in again each
+// time. Otherwise the session is ensured like EnsureLoggedIn and cached for the project.
+func EnsureLoggedInForProject(ctx context.Context, logger logger.Logger, cmd *cobra.Command, projectId string, dir string) (string, string) {
+	if projectId == "" {
+		return EnsureLoggedIn(ctx, logger, cmd)
+	}
+	tokens, err := OpenProjectTokens()
+	if err != nil {
+		logger.Debug("project tokens are unavailable: %s", err)
+		return EnsureLoggedIn(ctx, logger, cmd)
+	}
+	validity := sessionValidity(logger, cmd)
+	s := CurrentSession()
+	cached, ok := tokens.Get(projectId)
+	if ok && cached.ValidFor(validity) && (cached.UserId != s.UserId || !s.ValidFor(validity)) {
+		logger.Debug("using the cached token of user %s for project %s", cached.UserId, projectId)
+		return cached.APIKey, cached.UserId
+	}
+	s = ensureSession(ctx, logger, cmd)
+	if ok && cached.APIKey == s.APIKey && cached.Dir == dir {
+		return s.APIKey, s.UserId
+	}
+	tokens.Set(ProjectToken{
+		ProjectId: projectId,
+		Dir:       dir,
+		UserId:    s.UserId,
+		APIKey:    s.APIKey,
+		Expires:   s.Expires,
+		UpdatedAt: time.Now(),
+	})
+	if err := tokens.Save(); err != nil {
+		logger.Debug("failed to cache the token of project %s: %s", projectId, err)
+	}
+	return s.APIKey, s.UserId
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTokens(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.tokens")
+	key := bytes.Repeat([]byte{1}, 32)
+
+	tokens, err := LoadProjectTokens(filename, key)
+	require.NoError(t, err)
+	assert.Empty(t, tokens.List())
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokens.Set(ProjectToken{ProjectId: "proj_2", Dir: "/work/b", UserId: "user_1", APIKey: "key_1", Expires: expires})
+	tokens.Set(ProjectToken{ProjectId: "proj_1", Dir: "/work/a", UserId: "user_2", APIKey: "key_2", Expires: expires})
+	require.NoError(t, tokens.Save())
+
+	// the tokens are encrypted and only readable by the user
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(buf), "key_1")
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	tokens, err = LoadProjectTokens(filename, key)
+	require.NoError(t, err)
+	list := tokens.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "proj_1", list[0].ProjectId)
+	assert.Equal(t, "proj_2", list[1].ProjectId)
+	token, ok := tokens.Get("proj_2")
+	require.True(t, ok)
+	assert.Equal(t, "key_1", token.APIKey)
+	assert.True(t, token.Expires.Equal(expires))
+
+	assert.True(t, tokens.Remove("proj_2"))
+	assert.False(t, tokens.Remove("proj_2"))
+	require.NoError(t, tokens.Save())
+	tokens, err = LoadProjectTokens(filename, key)
+	require.NoError(t, err)
+	assert.Len(t, tokens.List(), 1)
+
+	_, err = LoadProjectTokens(filename, bytes.Repeat([]byte{2}, 32))
+	assert.ErrorIs(t, err, errProjectTokensDecrypt)
+}
+
+func TestProjectTokenValidFor(t *testing.T) {
+	token := ProjectToken{APIKey: "key", UserId: "user", Expires: time.Now().Add(10 * time.Minute)}
+	assert.True(t, token.ValidFor(time.Minute))
+	assert.False(t, token.ValidFor(15*time.Minute))
+	assert.False(t, ProjectToken{UserId: "user", Expires: token.Expires}.ValidFor(0))
+}
+
+func TestProjectTokensFilename(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	assert.Equal(t, "", ProjectTokensFilename())
+	viper.SetConfigFile(filepath.Join("home", ".config", "agentuity", "staging.yaml"))
+	assert.Equal(t, filepath.Join("home", ".config", "agentuity", "staging.tokens"), ProjectTokensFilename())
+}
+
+func TestProjectTokensKeyWithoutKeychain(t *testing.T) {
+	origGet, origSet := keychainGet, keychainSet
+	defer func() { keychainGet, keychainSet = origGet, origSet }()
+	keychainGet = func(account string) (string, error) { return "", errKeychainUnavailable }
+	keychainSet = func(account string, secret string) error { return errKeychainUnavailable }
+	defer viper.Set(ProjectTokensKeyFileConfig, nil)
+	filename := filepath.Join(t.TempDir(), "config.tokens")
+
+	// the key isn't stored in a file unless allowed
+	_, _, err := projectTokensKey(filename)
+	assert.ErrorIs(t, err, errProjectTokensNoKeychain)
+	assert.NoFileExists(t, filename+".key")
+
+	viper.Set(ProjectTokensKeyFileConfig, true)
+	key, store, err := projectTokensKey(filename)
+	require.NoError(t, err)
+	assert.Equal(t, ProjectTokensKeyFileStore, store)
+	assert.FileExists(t, filename+".key")
+	again, _, err := projectTokensKey(filename)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	// an existing key file isn't used either once it's no longer allowed
+	viper.Set(ProjectTokensKeyFileConfig, false)
+	_, _, err = projectTokensKey(filename)
+	assert.ErrorIs(t, err, errProjectTokensNoKeychain)
+}
+
+func TestProjectTokensKeyInKeychain(t *testing.T) {
+	origGet, origSet := keychainGet, keychainSet
+	defer func() { keychainGet, keychainSet = origGet, origSet }()
+	stored := make(map[string]string)
+	keychainGet = func(account string) (string, error) {
+		if secret, ok := stored[account]; ok {
+			return secret, nil
+		}
+		return "", errors.New("not found")
+	}
+	keychainSet = func(account string, secret string) error {
+		stored[account] = secret
+		return nil
+	}
+	filename := filepath.Join(t.TempDir(), "config.tokens")
+
+	key, store, err := projectTokensKey(filename)
+	require.NoError(t, err)
+	assert.Equal(t, keychainName(), store)
+	assert.NoFileExists(t, filename+".key")
+	again, _, err := projectTokensKey(filename)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+}
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MinRedactedValueLength is the length of the shortest secret value which is redacted, shorter
+// values such as "true" or "1" would redact too much unrelated output
+const MinRedactedValueLength = 6
+
+// RedactionRule is a pattern of sensitive data which is replaced with [REDACTED:<name>]
+type RedactionRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Group is the submatch which is replaced, the whole match when 0
+	Group int
+}
+
+// DefaultRedactionRules are the rules used unless they're disabled in the project file
+var DefaultRedactionRules = []RedactionRule{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{Name: "bearer-token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{8,})`), Group: 1},
+	{Name: "api-key", Pattern: regexp.MustCompile(`\b(sk|pk|rk)[-_][A-Za-z0-9_-]{16,}`)},
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(gh[pousr]|github_pat)_[A-Za-z0-9_]{30,}`)},
+	{Name: "secret", Pattern: regexp.MustCompile(`(?i)\b(password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)("?'?\s*[:=]\s*"?'?)([^\s"',;&]{4,})`), Group: 3},
+	{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+}
+
+// Redactor replaces sensitive data such as api keys, emails and the values of secrets in text
+// before it's shown or saved. A nil Redactor doesn't change the text.
+type Redactor struct {
+	rules  []RedactionRule
+	values []redactedValue
+}
+
+type redactedValue struct {
+	name  string
+	value string
+}
+
+// NewRedactor returns a redactor with the rules
+func NewRedactor(rules ...RedactionRule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// AddRule adds a rule to the redactor
+func (r *Redactor) AddRule(rule RedactionRule) {
+	r.rules = append(r.rules, rule)
+}
+
+// AddValues redacts the exact values, such as the values of the secrets of the project
+func (r *Redactor) AddValues(name string, values ...string) {
+	for _, value := range values {
+		if len(value) >= MinRedactedValueLength {
+			r.values = append(r.values, redactedValue{name, value})
+		}
+	}
+	// the longest first so that a value containing another one is redacted as a whole
+	sort.SliceStable(r.values, func(i, j int) bool {
+		return len(r.values[i].value) > len(r.values[j].value)
+	})
+}
+
+// Enabled returns true if the redactor has any rules or values
+func (r *Redactor) Enabled() bool {
+	return r != nil && (len(r.rules) > 0 || len(r.values) > 0)
+}
+
+func redacted(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+// Redact returns the text with the sensitive data replaced
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, v := range r.values {
+		text = strings.ReplaceAll(text, v.value, redacted(v.name))
+	}
+	for _, rule := range r.rules {
+		if rule.Group == 0 {
+			text = rule.Pattern.ReplaceAllLiteralString(text, redacted(rule.Name))
+			continue
+		}
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			loc := rule.Pattern.FindStringSubmatchIndex(match)
+			if len(loc) <= 2*rule.Group+1 || loc[2*rule.Group] < 0 {
+				return match
+			}
+			return match[:loc[2*rule.Group]] + redacted(rule.Name) + match[loc[2*rule.Group+1]:]
+		})
+	}
+	return text
+}
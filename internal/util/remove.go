@@ -0,0 +1,32 @@
+package util
+
+import (
+	"os"
+	"time"
+)
+
+// removeAttempts is how many times a removal which failed because the file is in use is attempted
+const removeAttempts = 5
+
+// RemoveAll removes the path and any children it contains like os.RemoveAll. On Windows a file can't
+// be removed while another process (such as an editor, a virus scanner or the project server which
+// was just stopped) has it open so the removal is retried for a short while when the file is in use.
+func RemoveAll(path string) error {
+	return retryWhenLocked(func() error { return os.RemoveAll(path) })
+}
+
+// Remove removes the file or empty directory like os.Remove, retrying when the file is in use on Windows
+func Remove(path string) error {
+	return retryWhenLocked(func() error { return os.Remove(path) })
+}
+
+func retryWhenLocked(fn func() error) error {
+	var err error
+	for i := 0; i < removeAttempts; i++ {
+		if err = fn(); err == nil || !isLockedError(err) {
+			return err
+		}
+		time.Sleep(time.Duration(50<<i) * time.Millisecond)
+	}
+	return err
+}
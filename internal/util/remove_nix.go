@@ -0,0 +1,9 @@
+//go:build !windows
+
+package util
+
+// isLockedError returns true if the error is because the file is in use by another process, which
+// doesn't prevent removing files on unix
+func isLockedError(err error) bool {
+	return false
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// isLockedError returns true if the error is because the file is in use by another process. Windows
+// reports access denied for files which are pending deletion while they are still open.
+func isLockedError(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation) || errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+}
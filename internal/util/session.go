@@ -0,0 +1,175 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/agentuity/go-common/logger"
+	"github.com/agentuity/go-common/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// SessionValidityAnnotation is the cobra annotation with the duration the session must still be
+	// valid for when the command starts. Long running commands (such as deploy) set it so that the
+	// session doesn't expire in the middle of the operation.
+	SessionValidityAnnotation = "agentuity.session.validity"
+
+	// DefaultSessionValidity is how long the session must still be valid for when a command doesn't
+	// set the annotation
+	DefaultSessionValidity = time.Minute
+)
+
+// ErrSessionRefreshRejected is returned when the refresh token is no longer accepted
+var ErrSessionRefreshRejected = errors.New("the session can no longer be refreshed")
+
+// Session is the authentication state of the CLI
+type Session struct {
+	APIKey       string
+	UserId       string
+	Expires      time.Time
+	RefreshToken string
+}
+
+// CurrentSession returns the session from the configuration
+func CurrentSession() Session {
+	return Session{
+		APIKey:       viper.GetString("auth.api_key"),
+		UserId:       viper.GetString("auth.user_id"),
+		Expires:      time.UnixMilli(viper.GetInt64("auth.expires")),
+		RefreshToken: viper.GetString("auth.refresh_token"),
+	}
+}
+
+// ValidFor returns true if the session has credentials which are valid for at least d
+func (s Session) ValidFor(d time.Duration) bool {
+	return s.APIKey != "" && s.UserId != "" && time.Now().Add(d).Before(s.Expires)
+}
+
+// SaveSession stores the session in the configuration
+func SaveSession(s Session) error {
+	viper.Set("auth.api_key", s.APIKey)
+	viper.Set("auth.user_id", s.UserId)
+	viper.Set("auth.expires", s.Expires.UnixMilli())
+	viper.Set("auth.refresh_token", s.RefreshToken)
+	return viper.WriteConfig()
+}
+
+type refreshSessionResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    *struct {
+		APIKey       string `json:"apiKey"`
+		UserId       string `json:"userId"`
+		Expires      int64  `json:"expires"`
+		RefreshToken string `json:"refreshToken,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// RefreshSession exchanges the refresh token for a new session. The refresh token is kept when
+// the server doesn't rotate it.
+func RefreshSession(ctx context.Context, logger logger.Logger, baseUrl string, refreshToken string) (*Session, error) {
+	client := NewAPIClient(ctx, logger, baseUrl, "")
+	var resp refreshSessionResponse
+	if err := client.Do("POST", "/cli/auth/refresh", map[string]string{"refreshToken": refreshToken}, &resp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden) {
+			return nil, ErrSessionRefreshRejected
+		}
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("%s", resp.Message)
+	}
+	if resp.Data == nil || resp.Data.APIKey == "" {
+		return nil, ErrSessionRefreshRejected
+	}
+	s := &Session{
+		APIKey:       resp.Data.APIKey,
+		UserId:       resp.Data.UserId,
+		Expires:      time.UnixMilli(resp.Data.Expires),
+		RefreshToken: resp.Data.RefreshToken,
+	}
+	if s.RefreshToken == "" {
+		s.RefreshToken = refreshToken
+	}
+	return s, nil
+}
+
+// sessionValidity returns the duration the session must be valid for to run the command
+func sessionValidity(logger logger.Logger, cmd *cobra.Command) time.Duration {
+	for c := cmd; c != nil; c = c.Parent() {
+		if val, ok := c.Annotations[SessionValidityAnnotation]; ok {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				logger.Debug("invalid %s annotation %q on %s: %s", SessionValidityAnnotation, val, c.CommandPath(), err)
+				break
+			}
+			return d
+		}
+	}
+	return DefaultSessionValidity
+}
+
+// refreshSession refreshes the session when it has a refresh token and saves it. It returns false
+// if the session couldn't be refreshed for at least validity.
+func refreshSession(ctx context.Context, logger logger.Logger, baseUrl string, s Session, validity time.Duration) (Session, bool) {
+	if s.APIKey == "" || s.RefreshToken == "" {
+		return s, false
+	}
+	refreshed, err := RefreshSession(ctx, logger, baseUrl, s.RefreshToken)
+	if err != nil {
+		logger.Debug("failed to refresh the session: %s", err)
+		return s, false
+	}
+	if !refreshed.ValidFor(validity) {
+		logger.Debug("the refreshed session expires at %s which is too soon", refreshed.Expires.Format(time.RFC3339))
+		return s, false
+	}
+	if err := SaveSession(*refreshed); err != nil {
+		logger.Warn("failed to save the refreshed session: %s", err)
+	}
+	logger.Debug("refreshed the session which expired at %s", s.Expires.Format(time.RFC3339))
+	return *refreshed, true
+}
+
+// ensureSession returns a session which is valid for at least the validity of the command. An
+// expired (or nearly expired) session is refreshed when there is a refresh token. Otherwise the
+// user is asked to login and the command continues with the new session. Without a terminal, the
+// command exits with the steps to login and run it again.
+func ensureSession(ctx context.Context, logger logger.Logger, cmd *cobra.Command) Session {
+	validity := sessionValidity(logger, cmd)
+	s := CurrentSession()
+	if s.ValidFor(validity) {
+		return s
+	}
+	if refreshed, ok := refreshSession(ctx, logger, GetURLs(logger).API, s, validity); ok {
+		return refreshed
+	}
+	if s.APIKey != "" && !s.Expires.IsZero() && time.Now().Before(s.Expires) {
+		fmt.Println(tui.Warning(fmt.Sprintf("Your session expires in %s which isn't long enough for %s.", time.Until(s.Expires).Round(time.Second), tui.Command(cmd.CommandPath()))))
+	}
+	if !tui.HasTTY {
+		fmt.Println(tui.Warning("You are not currently logged in or your session has expired."))
+		fmt.Println(tui.Warning("Use " + tui.Command("agentuity login") + " to login to Agentuity and then run " + tui.Command(cmd.CommandPath()) + " again"))
+		os.Exit(ExitCodeAuth)
+	}
+	ShowLogin(ctx, logger, cmd)
+	// the login ran in another process so read the new session from the configuration
+	if err := viper.ReadInConfig(); err != nil {
+		logger.Debug("failed to read the configuration after login: %s", err)
+	}
+	s = CurrentSession()
+	if !s.ValidFor(validity) {
+		fmt.Println(tui.Warning("Login did not complete. Use " + tui.Command("agentuity login") + " and then run " + tui.Command(cmd.CommandPath()) + " again"))
+		os.Exit(ExitCodeAuth)
+	}
+	fmt.Println()
+	tui.ShowSuccess("Logged in, continuing with %s", cmd.CommandPath())
+	return s
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRefreshServer(t *testing.T, expires time.Time, rotate bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cli/auth/refresh", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["refreshToken"] != "rt_1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		refreshToken := ""
+		if rotate {
+			refreshToken = "rt_2"
+		}
+		fmt.Fprintf(w, `{"success":true,"data":{"apiKey":"key_2","userId":"user_1","expires":%d,"refreshToken":%q}}`, expires.UnixMilli(), refreshToken)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRefreshSession(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	server := newRefreshServer(t, expires, true)
+
+	s, err := RefreshSession(context.Background(), &mockLogger{}, server.URL, "rt_1")
+	require.NoError(t, err)
+	assert.Equal(t, "key_2", s.APIKey)
+	assert.Equal(t, "user_1", s.UserId)
+	assert.Equal(t, "rt_2", s.RefreshToken)
+	assert.Equal(t, expires.UnixMilli(), s.Expires.UnixMilli())
+
+	_, err = RefreshSession(context.Background(), &mockLogger{}, server.URL, "rt_revoked")
+	assert.ErrorIs(t, err, ErrSessionRefreshRejected)
+
+	server = newRefreshServer(t, expires, false)
+	s, err = RefreshSession(context.Background(), &mockLogger{}, server.URL, "rt_1")
+	require.NoError(t, err)
+	assert.Equal(t, "rt_1", s.RefreshToken)
+}
+
+func TestSessionValidFor(t *testing.T) {
+	s := Session{APIKey: "key", UserId: "user", Expires: time.Now().Add(10 * time.Minute)}
+	assert.True(t, s.ValidFor(time.Minute))
+	assert.False(t, s.ValidFor(15*time.Minute))
+	assert.False(t, Session{APIKey: "key", Expires: s.Expires}.ValidFor(0))
+}
+
+func TestSessionValidity(t *testing.T) {
+	root := &cobra.Command{Use: "agentuity"}
+	cloud := &cobra.Command{Use: "cloud", Annotations: map[string]string{SessionValidityAnnotation: "15m"}}
+	deploy := &cobra.Command{Use: "deploy"}
+	invalid := &cobra.Command{Use: "invalid", Annotations: map[string]string{SessionValidityAnnotation: "soon"}}
+	root.AddCommand(cloud, invalid)
+	cloud.AddCommand(deploy)
+
+	assert.Equal(t, DefaultSessionValidity, sessionValidity(&mockLogger{}, root))
+	assert.Equal(t, 15*time.Minute, sessionValidity(&mockLogger{}, deploy))
+	assert.Equal(t, DefaultSessionValidity, sessionValidity(&mockLogger{}, invalid))
+}
+
+func TestRefreshStaleSession(t *testing.T) {
+	server := newRefreshServer(t, time.Now().Add(time.Hour), true)
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(configFile)
+
+	stale := Session{APIKey: "key_1", UserId: "user_1", Expires: time.Now().Add(5 * time.Minute), RefreshToken: "rt_1"}
+	s, ok := refreshSession(context.Background(), &mockLogger{}, server.URL, stale, 15*time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, "key_2", s.APIKey)
+
+	// the refreshed session is saved so the next command uses it
+	viper.Reset()
+	viper.SetConfigFile(configFile)
+	require.NoError(t, viper.ReadInConfig())
+	assert.Equal(t, "key_2", viper.GetString("auth.api_key"))
+	assert.Equal(t, "rt_2", viper.GetString("auth.refresh_token"))
+
+	// the refreshed session must also be valid long enough
+	_, ok = refreshSession(context.Background(), &mockLogger{}, server.URL, stale, 2*time.Hour)
+	assert.False(t, ok)
+
+	stale.RefreshToken = ""
+	_, ok = refreshSession(context.Background(), &mockLogger{}, server.URL, stale, 15*time.Minute)
+	assert.False(t, ok)
+}
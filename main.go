@@ -22,6 +22,8 @@ THE SOFTWARE.
 package main
 
 import (
+	_ "embed"
+
 	"github.com/agentuity/cli/cmd"
 	"github.com/agentuity/cli/internal/bundler"
 	"github.com/agentuity/cli/internal/errsystem"
@@ -35,12 +37,16 @@ var (
 	date    = "unknown"
 )
 
+//go:embed agentuity.schema.json
+var projectSchema []byte
+
 func main() {
 	cmd.Version = version
 	cmd.Commit = commit
 	cmd.Date = date
 	errsystem.Version = version
 	project.Version = version
+	project.Schema = projectSchema
 	util.Version = version
 	util.Commit = commit
 	bundler.Version = version
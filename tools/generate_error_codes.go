@@ -13,8 +13,9 @@ import (
 
 // ErrorCode represents a single error code definition
 type ErrorCode struct {
-	Code    string `yaml:"code"`
-	Message string `yaml:"message"`
+	Code     string `yaml:"code"`
+	Message  string `yaml:"message"`
+	Category string `yaml:"category"`
 }
 
 // ErrorCodes represents the structure of the YAML file
@@ -29,8 +30,9 @@ package errsystem
 var (
 {{- range .Errors }}
 	{{ .VarName }} = errorType{
-		Code:    "{{ .Code }}",
-		Message: "{{ .Message }}",
+		Code:     "{{ .Code }}",
+		Message:  "{{ .Message }}",
+		Category: "{{ .Category }}",
 	}
 {{- end }}
 )
@@ -126,6 +128,9 @@ func main() {
 	// Create a slice of ErrorCodeWithVarName
 	var errorCodesWithVarNames []ErrorCodeWithVarName
 	for _, ec := range errorCodes.Errors {
+		if ec.Category == "" {
+			ec.Category = "general"
+		}
 		errorCodesWithVarNames = append(errorCodesWithVarNames, ErrorCodeWithVarName{
 			ErrorCode: ec,
 			VarName:   GenerateVarName(ec.Code, ec.Message),